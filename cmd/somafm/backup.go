@@ -0,0 +1,137 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/backup"
+)
+
+// backupResult is the output of `somafm backup --json`.
+type backupResult struct {
+	OK      bool      `json:"ok"`
+	Error   string    `json:"error,omitempty"`
+	Archive string    `json:"archive,omitempty"`
+	Created time.Time `json:"created,omitzero"`
+	Files   []string  `json:"files,omitempty"`
+}
+
+const backupUsage = "Usage: somafm backup [--json] create [file.tar.gz] | restore <file.tar.gz>"
+
+// runBackup packs the config, favorites, likes, and history into an
+// archive, or restores them from one. "-" stands for stdout or stdin.
+func runBackup(args []string) int {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print the result as JSON")
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(args) == 0 || len(args) > 2 {
+		fmt.Fprintln(os.Stderr, backupUsage)
+		return 2
+	}
+
+	var archive string
+	if len(args) == 2 {
+		archive = args[1]
+	}
+	var m backup.Manifest
+	switch args[0] {
+	case "create":
+		if archive == "" {
+			archive = fmt.Sprintf("somafm-backup-%s.tar.gz", time.Now().Format("2006-01-02"))
+		}
+		if archive == "-" && *asJSON {
+			fmt.Fprintln(os.Stderr, "Error: --json can't share stdout with the archive")
+			return 2
+		}
+		m, err = createBackup(archive)
+	case "restore":
+		if archive == "" {
+			fmt.Fprintln(os.Stderr, backupUsage)
+			return 2
+		}
+		m, err = restoreBackup(archive)
+	default:
+		fmt.Fprintln(os.Stderr, backupUsage)
+		return 2
+	}
+
+	if *asJSON {
+		if err != nil {
+			printJSON(backupResult{Error: err.Error()})
+			return 1
+		}
+		return printJSON(backupResult{OK: true, Archive: archive, Created: m.Created, Files: m.Files})
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	// Keep stdout clean when the archive itself goes there
+	out := os.Stdout
+	if archive == "-" {
+		out, archive = os.Stderr, "stdout"
+	}
+	if args[0] == "create" {
+		fmt.Fprintf(out, "Backed up %s to %s\n", strings.Join(m.Files, ", "), archive)
+	} else {
+		fmt.Fprintf(out, "Restored %s from backup made %s\n", strings.Join(m.Files, ", "),
+			m.Created.Local().Format("2006-01-02 15:04"))
+	}
+	return 0
+}
+
+func createBackup(archive string) (backup.Manifest, error) {
+	dir, err := backup.Dir()
+	if err != nil {
+		return backup.Manifest{}, err
+	}
+	if archive == "-" {
+		return backup.Create(os.Stdout, dir)
+	}
+
+	// Write beside the target and rename, so a failed run never leaves a
+	// truncated archive where a good one was
+	tmpFile, err := os.CreateTemp(filepath.Dir(archive), ".somafm-backup-*.tmp")
+	if err != nil {
+		return backup.Manifest{}, fmt.Errorf("failed to create archive: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	m, err := backup.Create(tmpFile, dir)
+	if closeErr := tmpFile.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write archive: %w", closeErr)
+	}
+	if err != nil {
+		return m, err
+	}
+	if err := os.Rename(tmpPath, archive); err != nil {
+		return m, fmt.Errorf("failed to create archive: %w", err)
+	}
+	return m, nil
+}
+
+func restoreBackup(archive string) (backup.Manifest, error) {
+	dir, err := backup.Dir()
+	if err != nil {
+		return backup.Manifest{}, err
+	}
+	var r io.Reader = os.Stdin
+	if archive != "-" {
+		f, err := os.Open(archive)
+		if err != nil {
+			return backup.Manifest{}, err
+		}
+		defer f.Close()
+		r = f
+	}
+	return backup.Restore(r, dir)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/player"
+)
+
+type command struct {
+	name        string
+	description string
+	run         func(args []string) int
+}
+
+var commands = []command{
+	{"test-audio", "Play a short test tone to check audio output", runTestAudio},
+	{"doctor", "Check config, network, and playback, then print diagnostics", runDoctor},
+	{"import-theme", "Import a base16 YAML color scheme into the config", runImportTheme},
+	{"list", "List all stations: ID, title, genres, listeners", runList},
+	{"play", "Play a station without the TUI until Ctrl+C, e.g. play groovesalad", runPlay},
+	{"now-playing", "Print the track a station is playing, e.g. now-playing dronezone", runNowPlaying},
+	{"favorites", "List favorite stations", runFavorites},
+	{"genres", "List genre tags with their station counts", runGenres},
+	{"search", "List stations matching a query, e.g. search ambient --json", runSearch},
+	{"history", "List or search the listening history, e.g. history bonobo --limit 0", runHistory},
+	{"likes", "List liked tracks or export them, e.g. likes --format csv > likes.csv", runLikes},
+	{"export", "Export history and liked tracks, e.g. export --format csv --since 7d > heard.csv", runExport},
+	{"session", "Summarize the last listening session as Markdown, e.g. session export > today.md", runSession},
+	{"backup", "Create or restore a state archive, e.g. backup create state.tar.gz", runBackup},
+	{"ctl", "Control a running player: play <id>, next, stop, pause, volume <n>, status", runCtl},
+}
+
+func printCommands() {
+	fmt.Fprintf(os.Stderr, "\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", cmd.name, cmd.description)
+	}
+	fmt.Fprintf(os.Stderr, "\nEvery command takes --json for machine-readable output.\n")
+}
+
+func runCommand(args []string) int {
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:])
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", args[0])
+	flag.Usage()
+	return 2
+}
+
+// commandResult is the JSON output of commands that only report success.
+type commandResult struct {
+	OK     bool   `json:"ok"`
+	Error  string `json:"error,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// printResult prints r as JSON and returns the exit code it stands for.
+func printResult(r commandResult) int {
+	if code := printJSON(r); code != 0 || !r.OK {
+		return 1
+	}
+	return 0
+}
+
+func runTestAudio(args []string) int {
+	fs := flag.NewFlagSet("test-audio", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print the result as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if !*asJSON {
+		fmt.Printf("Playing %.0f Hz test tone for %v through the default audio device...\n",
+			player.TestToneFrequency, player.TestToneDuration)
+	}
+
+	p := player.NewPlayer()
+	err := p.PlayTestTone(player.TestToneFrequency, player.TestToneDuration)
+	if *asJSON {
+		if err != nil {
+			return printResult(commandResult{Error: err.Error()})
+		}
+		return printResult(commandResult{OK: true})
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Audio test FAILED: %v\n", err)
+		return 1
+	}
+
+	fmt.Println("Audio test OK. If you heard nothing, check your system volume and output device.")
+	return 0
+}
+
+// runImportTheme converts a base16 scheme file and saves it as the theme.
+func runImportTheme(args []string) int {
+	fs := flag.NewFlagSet("import-theme", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print the result as JSON")
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: somafm import-theme [--json] <scheme.yaml>")
+		return 2
+	}
+
+	fail := func(err error) int {
+		if *asJSON {
+			return printResult(commandResult{Error: err.Error()})
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fail(err)
+	}
+	theme, name, err := config.ThemeFromBase16(data)
+	if err != nil {
+		return fail(err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fail(fmt.Errorf("fix the config before importing a theme: %w", err))
+	}
+	cfg.Theme = theme
+	if err := cfg.Save(); err != nil {
+		return fail(err)
+	}
+
+	if name == "" {
+		name = args[0]
+	}
+	configPath, _ := config.GetConfigPath()
+	if *asJSON {
+		return printResult(commandResult{OK: true, Detail: fmt.Sprintf("imported theme %q into %s", name, configPath)})
+	}
+	fmt.Printf("Imported theme %q into %s\n", name, configPath)
+	return 0
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/control"
+	"github.com/glebovdev/somafm-cli/internal/mpd"
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/glebovdev/somafm-cli/internal/sdnotify"
+	"github.com/glebovdev/somafm-cli/internal/service"
+	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/rs/zerolog/log"
+)
+
+// socketPath returns the control socket from --socket, or the default.
+func socketPath() string {
+	if *socketFlag != "" {
+		return *socketFlag
+	}
+	return control.DefaultSocketPath()
+}
+
+// runDaemon runs the player without the TUI, taking commands on the control
+// socket until SIGINT or SIGTERM. It plays --station right away if given.
+func runDaemon(cfg *config.Config, stations *service.StationService, p *player.Player) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if _, err := stations.GetStations(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to load stations")
+		return 1
+	}
+
+	path := socketPath()
+	ln, err := control.Listen(path)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to open control socket")
+		return 1
+	}
+	defer os.Remove(path)
+
+	c := control.NewController(p, stations, cfg)
+	if *stationFlag != "" {
+		id, err := station.ParseStationID(*stationFlag)
+		if err == nil {
+			err = c.Play(id)
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("No station to play")
+			ln.Close()
+			return 1
+		}
+	}
+
+	stopAPI := startHTTPAPI(cfg, c)
+	defer stopAPI()
+	stopMPD := startMPD(cfg, c, stations)
+	defer stopMPD()
+
+	log.Info().Msgf("Listening for commands on %s", path)
+	notify(sdnotify.Ready, sdnotify.Status("Listening on %s", path))
+
+	err = control.NewServer(c).Serve(ctx, ln)
+	notify(sdnotify.Stopping)
+	p.Stop()
+	if err != nil {
+		log.Error().Err(err).Msg("Control socket failed")
+		return 1
+	}
+	log.Info().Msg("Received shutdown signal, stopping playback")
+	return 0
+}
+
+// startControl serves the control socket for the TUI. The returned func
+// closes it. Another instance already listening only disables control.
+func startControl(target control.Target) func() {
+	path := socketPath()
+	ln, err := control.Listen(path)
+	if err != nil {
+		log.Warn().Err(err).Msg("Remote control disabled")
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		if err := control.NewServer(target).Serve(ctx, ln); err != nil {
+			log.Warn().Err(err).Msg("Control socket failed")
+		}
+		close(done)
+	}()
+	return func() {
+		cancel()
+		<-done
+		os.Remove(path)
+	}
+}
+
+// startHTTPAPI serves the HTTP control API when api.listen is set. The
+// returned func stops it.
+func startHTTPAPI(cfg *config.Config, target control.Target) func() {
+	if cfg.API.Listen == "" {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		log.Info().Msgf("HTTP control API on %s", cfg.API.Listen)
+		if err := control.ServeHTTP(ctx, cfg.API.Listen, target); err != nil {
+			log.Warn().Err(err).Msg("HTTP control API disabled")
+		}
+		close(done)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// startMPD serves MPD clients when mpd.listen is set. The returned func
+// stops it.
+func startMPD(cfg *config.Config, target control.Target, stations *service.StationService) func() {
+	if cfg.MPD.Listen == "" {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		log.Info().Msgf("MPD protocol on %s", cfg.MPD.Listen)
+		if err := mpd.NewServer(target, stations).ListenAndServe(ctx, cfg.MPD.Listen); err != nil {
+			log.Warn().Err(err).Msg("MPD protocol disabled")
+		}
+		close(done)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// runCtl sends one command to a running instance, the TUI or a daemon,
+// and prints the reply. With --json it prints the resulting status as
+// JSON instead, for every command.
+func runCtl(args []string) int {
+	// Not a FlagSet: volume takes deltas such as -5
+	asJSON := false
+	var command []string
+	for _, arg := range args {
+		if arg == "--json" || arg == "-json" {
+			asJSON = true
+			continue
+		}
+		command = append(command, arg)
+	}
+	if len(command) == 0 {
+		fmt.Fprintf(os.Stderr, "Usage: somafm ctl <%s> [args] [--json]\n", strings.Join(control.Commands, "|"))
+		return 2
+	}
+
+	path := socketPath()
+	if command[0] != control.CommandStatus {
+		reply, err := control.Send(path, strings.Join(command, " "))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if !asJSON {
+			if reply != "" {
+				fmt.Println(reply)
+			}
+			return 0
+		}
+	}
+	return runCtlStatus(path, asJSON)
+}
+
+func runCtlStatus(path string, asJSON bool) int {
+	status, err := control.Status(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if asJSON {
+		return printJSON(status)
+	}
+
+	line := status.State
+	if status.StationTitle != "" {
+		line += " " + status.StationTitle
+	}
+	if status.Track != "" {
+		line += " — " + status.Track
+	}
+	fmt.Printf("%s (volume %d%%)\n", line, status.Volume)
+	return 0
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/glebovdev/somafm-cli/internal/cache"
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/dircheck"
+)
+
+// checkDirs checks that the config and cache directories can be written,
+// creating them if needed. The cache is skipped with --no-cache.
+func checkDirs() []error {
+	var problems []error
+	if err := config.CheckDir(); err != nil {
+		problems = append(problems, err)
+	}
+	if !*noCacheFlag {
+		if err := cache.CheckDir(); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	return problems
+}
+
+// printDirProblems explains each directory problem on stderr, with the
+// fix for permission problems.
+func printDirProblems(problems []error) {
+	for _, err := range problems {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		if e, ok := dircheck.As(err); ok {
+			fmt.Fprintf(os.Stderr, "  %s\n", e.Advice())
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/glebovdev/somafm-cli/internal/api"
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/genre"
+	"github.com/glebovdev/somafm-cli/internal/service"
+	"github.com/glebovdev/somafm-cli/internal/station"
+)
+
+// stationResult is one station in `somafm list`, `search`, and `favorites`
+// JSON output.
+type stationResult struct {
+	ID          station.StationID `json:"id"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Genres      []string          `json:"genres"`
+	Listeners   int               `json:"listeners"`
+}
+
+// genreCount is one genre in `somafm genres --json` output.
+type genreCount struct {
+	Tag      string `json:"tag"`
+	Name     string `json:"name"`
+	Stations int    `json:"stations"`
+}
+
+// newAPIClient returns a client for the configured API endpoint.
+func newAPIClient(cfg *config.Config) *api.SomaFMClient {
+	if cfg.Endpoints.API != "" {
+		return api.NewSomaFMClientWithBaseURL(cfg.Endpoints.API)
+	}
+	return api.NewSomaFMClient()
+}
+
+// loadStations fetches the station list, most listened first, along with a
+// genre translator that honors the config's overrides.
+func loadStations() ([]station.Station, *genre.Translator, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("fix the config first: %w", err)
+	}
+
+	stations, err := service.NewStationService(newAPIClient(cfg), service.CacheDisabled).GetStations(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	genres, _ := genre.NewTranslator(cfg.Genres)
+	return stations, genres, nil
+}
+
+// runGenres prints every genre tag with the number of stations that carry
+// it, most common first.
+func runGenres(args []string) int {
+	fs := flag.NewFlagSet("genres", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print JSON instead of a table")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	stations, genres, err := loadStations()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	counts := make(map[string]int)
+	for _, s := range stations {
+		for _, tag := range genre.Split(s.Genre) {
+			counts[strings.ToLower(tag)]++
+		}
+	}
+	result := make([]genreCount, 0, len(counts))
+	for tag, n := range counts {
+		result = append(result, genreCount{Tag: tag, Name: genres.Name(tag), Stations: n})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Stations != result[j].Stations {
+			return result[i].Stations > result[j].Stations
+		}
+		return result[i].Tag < result[j].Tag
+	})
+
+	if *asJSON {
+		return printJSON(result)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, g := range result {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", g.Tag, g.Name, g.Stations)
+	}
+	_ = w.Flush()
+	return 0
+}
+
+// runSearch lists the stations whose title or genre matches the query, the
+// same way the station filter in the player does.
+func runSearch(args []string) int {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print JSON instead of a table")
+	terms, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	query := strings.Join(terms, " ")
+	if query == "" {
+		fmt.Fprintln(os.Stderr, "Usage: somafm search [--json] <query>")
+		return 2
+	}
+
+	stations, genres, err := loadStations()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	result := []stationResult{}
+	for _, s := range stations {
+		if strings.Contains(strings.ToLower(s.Title), strings.ToLower(query)) || genres.Matches(s.Genre, query) {
+			result = append(result, newStationResult(s, genres))
+		}
+	}
+
+	if len(result) == 0 && !*asJSON {
+		fmt.Fprintf(os.Stderr, "No stations match %q\n", query)
+		return 1
+	}
+	return printStations(result, *asJSON)
+}
+
+// runList prints every station, most listened first.
+func runList(args []string) int {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print JSON instead of a table")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	stations, genres, err := loadStations()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	result := make([]stationResult, 0, len(stations))
+	for _, s := range stations {
+		result = append(result, newStationResult(s, genres))
+	}
+	return printStations(result, *asJSON)
+}
+
+// runFavorites prints the favorite stations in config order. Favorites the
+// API no longer lists are printed with their ID only.
+func runFavorites(args []string) int {
+	fs := flag.NewFlagSet("favorites", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print JSON instead of a table")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: fix the config first: %v\n", err)
+		return 1
+	}
+	if len(cfg.Favorites) == 0 {
+		if *asJSON {
+			return printJSON([]stationResult{})
+		}
+		fmt.Fprintln(os.Stderr, "No favorites yet")
+		return 1
+	}
+
+	stations, genres, err := loadStations()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	byID := make(map[station.StationID]station.Station, len(stations))
+	for _, s := range stations {
+		byID[s.ID] = s
+	}
+
+	result := make([]stationResult, 0, len(cfg.Favorites))
+	for _, id := range cfg.Favorites {
+		if s, ok := byID[id]; ok {
+			result = append(result, newStationResult(s, genres))
+		} else {
+			result = append(result, stationResult{ID: id, Genres: []string{}})
+		}
+	}
+	return printStations(result, *asJSON)
+}
+
+// nowPlaying is `somafm now-playing --json` output.
+type nowPlaying struct {
+	ID    station.StationID `json:"id"`
+	Title string            `json:"title"`
+	Track string            `json:"track"`
+}
+
+// runNowPlaying prints the track a station is playing, from the station's
+// song history rather than the stream, so nothing is played.
+func runNowPlaying(args []string) int {
+	fs := flag.NewFlagSet("now-playing", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print JSON instead of text")
+	ids, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(ids) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: somafm now-playing [--json] <station-id>")
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: fix the config first: %v\n", err)
+		return 1
+	}
+	stationService := service.NewStationService(newAPIClient(cfg), service.CacheDisabled)
+	stationService.SetTitleRewriter(titleRewriter(cfg))
+	stations, err := stationService.GetStations(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	s, err := findStation(stations, ids[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	track, err := stationService.GetCurrentTrackForStation(context.Background(), s.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *asJSON {
+		return printJSON(nowPlaying{ID: s.ID, Title: s.Title, Track: track})
+	}
+	if track == "" {
+		fmt.Fprintf(os.Stderr, "%s has no track information\n", s.Title)
+		return 1
+	}
+	fmt.Println(track)
+	return 0
+}
+
+// findStation looks up a station by a user-supplied ID.
+func findStation(stations []station.Station, arg string) (*station.Station, error) {
+	id, err := station.ParseStationID(arg)
+	if err != nil {
+		return nil, err
+	}
+	for i := range stations {
+		if stations[i].ID == id {
+			return &stations[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown station %q, see somafm list", id)
+}
+
+func newStationResult(s station.Station, genres *genre.Translator) stationResult {
+	listeners, _ := strconv.Atoi(s.Listeners)
+	return stationResult{
+		ID:          s.ID,
+		Title:       s.Title,
+		Description: s.Description,
+		Genres:      genres.Names(s.Genre),
+		Listeners:   listeners,
+	}
+}
+
+// printStations prints one tab-aligned line per station: ID, title,
+// genres, and listeners.
+func printStations(result []stationResult, asJSON bool) int {
+	if asJSON {
+		return printJSON(result)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, r := range result {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", r.ID, r.Title, strings.Join(r.Genres, ", "), r.Listeners)
+	}
+	_ = w.Flush()
+	return 0
+}
+
+// parseInterspersed parses flags that may come after positional arguments,
+// as in "search ambient --json", and returns the positional ones.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+func printJSON(v interface{}) int {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
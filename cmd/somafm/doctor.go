@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/cache"
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/dircheck"
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/glebovdev/somafm-cli/internal/station"
+)
+
+// doctorListenTime is how long the doctor plays a station, muted.
+const doctorListenTime = 8 * time.Second
+
+// doctorCheck is one result in `somafm doctor --json` output.
+type doctorCheck struct {
+	Check  string `json:"check"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail"`
+}
+
+// doctorReport is `somafm doctor --json` output.
+type doctorReport struct {
+	Version string        `json:"version"`
+	Checks  []doctorCheck `json:"checks"`
+	Journal []string      `json:"journal"`
+}
+
+// doctor collects check results and prints them as they come, unless the
+// report is printed as JSON at the end.
+type doctor struct {
+	report doctorReport
+	quiet  bool
+	failed bool
+}
+
+func (d *doctor) line(check string, ok bool, detail string) {
+	d.report.Checks = append(d.report.Checks, doctorCheck{Check: check, OK: ok, Detail: detail})
+	if d.quiet {
+		return
+	}
+	status := "OK"
+	if !ok {
+		status = "FAIL"
+	}
+	fmt.Printf("  %-10s %-4s %s\n", check, status, detail)
+}
+
+// dir checks that a directory the app writes to is usable, with the fix
+// in the detail when it isn't.
+func (d *doctor) dir(check, path string, err error) {
+	if err == nil {
+		d.line(check, true, path)
+		return
+	}
+	detail := err.Error()
+	if e, ok := dircheck.As(err); ok {
+		detail += ". " + e.Advice()
+	}
+	d.line(check, false, detail)
+	d.failed = true
+}
+
+// finish prints the JSON report when asked for and returns the exit code.
+func (d *doctor) finish() int {
+	if d.quiet {
+		if d.report.Journal == nil {
+			d.report.Journal = []string{}
+		}
+		if code := printJSON(d.report); code != 0 {
+			return code
+		}
+	}
+	if d.failed {
+		if !d.quiet {
+			fmt.Fprintln(os.Stderr, "\nSome checks failed. Run with --debug for the full log.")
+		}
+		return 1
+	}
+	return 0
+}
+
+// runDoctor checks the config, whether the config and cache directories
+// can be written, ffmpeg, the API, and a short muted playback,
+// then prints the player's diagnostics journal.
+func runDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print a JSON report instead of text")
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+
+	d := &doctor{report: doctorReport{Version: config.AppVersion}, quiet: *asJSON}
+	if !d.quiet {
+		fmt.Printf("%s v%s doctor\n\n", config.AppName, config.AppVersion)
+	}
+
+	configPath, _ := config.GetConfigPath()
+	cfg, err := config.Load()
+	if err != nil {
+		d.line("config", false, err.Error())
+		d.failed = true
+	} else {
+		d.line("config", true, configPath)
+	}
+
+	configDir, _ := config.GetConfigDir()
+	d.dir("config dir", configDir, config.CheckDir())
+	cacheDir, _ := cache.GetCacheDir()
+	d.dir("cache dir", cacheDir, cache.CheckDir())
+
+	if path, err := player.LookupFFmpeg(); err != nil {
+		d.line("ffmpeg", false, "not found, AAC streams can't be played")
+	} else {
+		d.line("ffmpeg", true, path)
+	}
+
+	client := newAPIClient(cfg)
+	stations, err := client.GetStations(context.Background())
+	if err != nil {
+		d.line("api", false, err.Error())
+		d.failed = true
+		return d.finish()
+	}
+	d.line("api", true, fmt.Sprintf("%d stations from %s", len(stations), client.BaseURL()))
+
+	requested := cfg.LastStation
+	if len(args) > 0 {
+		requested = station.NewStationID(args[0])
+	}
+	s := doctorStation(stations, requested)
+	if s == nil {
+		d.line("playback", false, "no station to test")
+		d.failed = true
+		return d.finish()
+	}
+
+	p := player.NewPlayer()
+	p.SetVolume(0)
+	p.SetStreamBaseURL(cfg.Endpoints.Streams)
+	p.SetPlaylistPreference(cfg.PlaylistPreference())
+	p.SetListenerID(cfg.ActiveListenerID())
+
+	if !d.quiet {
+		fmt.Printf("\nPlaying %s muted for %v...\n\n", s.Title, doctorListenTime)
+	}
+	go func() { _ = p.Play(s) }()
+
+	deadline := time.Now().Add(doctorListenTime)
+	for time.Now().Before(deadline) && p.GetState() != player.StateError {
+		time.Sleep(250 * time.Millisecond)
+	}
+
+	state := p.GetState()
+	info := p.GetStreamInfo()
+	if state == player.StatePlaying {
+		d.line("playback", true, fmt.Sprintf("%s: %s %dk, received %dk, buffer %d%%",
+			s.Title, info.Format, info.Bitrate, p.GetMeasuredBitrate(), p.GetBufferHealth()))
+	} else {
+		detail := state.String()
+		if lastError := p.GetLastError(); lastError != "" {
+			detail += ": " + lastError
+		}
+		d.line("playback", false, detail)
+		d.failed = true
+	}
+	p.Stop()
+
+	for _, e := range p.Journal() {
+		d.report.Journal = append(d.report.Journal, e.String())
+	}
+	if !d.quiet {
+		fmt.Println("\nDiagnostics journal:")
+		if len(d.report.Journal) == 0 {
+			fmt.Println("  (empty)")
+		}
+		for _, e := range d.report.Journal {
+			fmt.Printf("  %s\n", e)
+		}
+	}
+	return d.finish()
+}
+
+// doctorStation returns the station with the given ID, or the first one.
+func doctorStation(stations []station.Station, id station.StationID) *station.Station {
+	for i := range stations {
+		if stations[i].ID == id {
+			return &stations[i]
+		}
+	}
+	if len(stations) > 0 {
+		return &stations[0]
+	}
+	return nil
+}
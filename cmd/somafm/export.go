@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/export"
+	"github.com/glebovdev/somafm-cli/internal/history"
+	"github.com/glebovdev/somafm-cli/internal/likes"
+	"github.com/glebovdev/somafm-cli/internal/storage"
+)
+
+// runExport dumps the listening history and liked tracks, oldest first,
+// for spreadsheets and other tools.
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Same as --format json")
+	format := fs.String("format", export.FormatCSV, "Export as csv or json")
+	sinceFlag := fs.String("since", "", "Only what was heard or liked since, e.g. 7d, 12h, or 2024-06-01")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *asJSON {
+		*format = export.FormatJSON
+	}
+	if *format != export.FormatCSV && *format != export.FormatJSON {
+		fmt.Fprintf(os.Stderr, "Error: unknown export format %q, want csv or json\n", *format)
+		return 2
+	}
+	var since time.Time
+	if *sinceFlag != "" {
+		var err error
+		if since, err = export.ParseSince(*sinceFlag, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: fix the config first: %v\n", err)
+		return 1
+	}
+	db, err := storage.Open(cfg.Storage.Backend)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if db != nil {
+		defer db.Close()
+	}
+	historyStore, err := history.OpenDefault(db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	likesStore, err := likes.OpenDefault(db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	// Search reaches the whole SQLite history, not just the latest entries
+	entries, err := historyStore.Search("", 0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	slices.Reverse(entries)
+
+	if err := export.Write(os.Stdout, export.Collect(entries, likesStore.Tracks(), since), *format); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
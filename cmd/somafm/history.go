@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/history"
+	"github.com/glebovdev/somafm-cli/internal/storage"
+)
+
+// historyResult is one entry in `somafm history --json` output.
+type historyResult struct {
+	Station      string    `json:"station"`
+	StationTitle string    `json:"station_title"`
+	Track        string    `json:"track"`
+	Start        time.Time `json:"start"`
+	HeardSeconds int       `json:"heard_seconds,omitempty"`
+	Note         string    `json:"note,omitempty"`
+}
+
+// runHistory prints the listening history, most recent first, optionally
+// only the entries whose track, station, or note matches a query.
+func runHistory(args []string) int {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print JSON instead of a table")
+	limit := fs.Int("limit", 50, "Maximum number of entries, 0 for all")
+	terms, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	query := strings.Join(terms, " ")
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: fix the config first: %v\n", err)
+		return 1
+	}
+	db, err := storage.Open(cfg.Storage.Backend)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if db != nil {
+		defer db.Close()
+	}
+	store, err := history.OpenDefault(db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	entries, err := store.Search(query, *limit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	result := make([]historyResult, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, historyResult{
+			Station:      e.Station,
+			StationTitle: e.StationTitle,
+			Track:        e.Track,
+			Start:        e.Start,
+			HeardSeconds: int(e.Heard().Seconds()),
+			Note:         e.Note,
+		})
+	}
+
+	if *asJSON {
+		return printJSON(result)
+	}
+	if len(result) == 0 {
+		if query != "" {
+			fmt.Fprintf(os.Stderr, "No history matches %q\n", query)
+		} else {
+			fmt.Fprintln(os.Stderr, "No listening history yet")
+		}
+		return 1
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, r := range result {
+		note := ""
+		if r.Note != "" {
+			note = "✎ " + r.Note
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Start.Local().Format("2006-01-02 15:04"), r.StationTitle, r.Track, note)
+	}
+	_ = w.Flush()
+	return 0
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/likes"
+	"github.com/glebovdev/somafm-cli/internal/storage"
+)
+
+// runLikes prints the liked tracks, most recent first, as a table or in
+// one of the export formats.
+func runLikes(args []string) int {
+	fs := flag.NewFlagSet("likes", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print JSON instead of a table")
+	format := fs.String("format", "", "Export as csv, json, or txt instead of a table")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *asJSON {
+		*format = likes.FormatJSON
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: fix the config first: %v\n", err)
+		return 1
+	}
+	db, err := storage.Open(cfg.Storage.Backend)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if db != nil {
+		defer db.Close()
+	}
+	store, err := likes.OpenDefault(db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	tracks := store.Tracks()
+
+	if *format != "" {
+		if err := likes.Export(os.Stdout, tracks, *format); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	if len(tracks) == 0 {
+		fmt.Fprintln(os.Stderr, "No liked tracks yet")
+		return 1
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, t := range tracks {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.LikedAt.Local().Format("2006-01-02"), t.Artist, t.Title, t.Station)
+	}
+	_ = w.Flush()
+	return 0
+}
@@ -1,35 +1,59 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
 	"syscall"
+	"time"
 
+	"github.com/glebovdev/somafm-cli/internal/alarm"
 	"github.com/glebovdev/somafm-cli/internal/api"
 	"github.com/glebovdev/somafm-cli/internal/cache"
 	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/hooks"
 	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/glebovdev/somafm-cli/internal/playlog"
+	"github.com/glebovdev/somafm-cli/internal/priority"
+	"github.com/glebovdev/somafm-cli/internal/publish"
+	"github.com/glebovdev/somafm-cli/internal/retitle"
 	"github.com/glebovdev/somafm-cli/internal/service"
+	"github.com/glebovdev/somafm-cli/internal/throttle"
 	"github.com/glebovdev/somafm-cli/internal/ui"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
 var (
-	versionFlag = flag.Bool("version", false, "Show version information")
-	debugFlag   = flag.Bool("debug", false, "Enable debug logging")
-	randomFlag  = flag.Bool("random", false, "Start with a random station")
+	versionFlag  = flag.Bool("version", false, "Show version information")
+	debugFlag    = flag.Bool("debug", false, "Enable debug logging")
+	randomFlag   = flag.Bool("random", false, "Start with a random station")
+	noCacheFlag  = flag.Bool("no-cache", false, "Bypass cached data for this run")
+	refreshFlag  = flag.Bool("refresh", false, "Clear cached data and fetch fresh copies")
+	safeModeFlag = flag.Bool("safe-mode", false, "Start with the default theme and without hooks or autostart")
+	serviceFlag  = flag.Bool("service", false, "Run headless without the TUI, e.g. as a systemd user service")
+	daemonFlag   = flag.Bool("daemon", false, "Run headless without the TUI, taking commands on a control socket (see the ctl command)")
+	socketFlag   = flag.String("socket", "", "Control socket `path` for --daemon and ctl (default: in $XDG_RUNTIME_DIR or the temp dir)")
+	stationFlag  = flag.String("station", "", "Station ID to play in --service mode (default: last station), or to start --daemon with")
+	alarmFlag    = flag.String("alarm", "", "Start playing at `HH:MM[=station]`, fading the volume in")
+
+	screenshotFlag = flag.String("screenshot", "", "Render the main screen as text to `file` (- for stdout) and exit")
+
+	configDirFlag = flag.String("config-dir", "", "Keep the config, history, and likes in `dir` (default: ~/.config/somafm)")
+	cacheDirFlag  = flag.String("cache-dir", "", "Keep cached logos and other data in `dir` (default: the user cache directory)")
 )
 
 func init() {
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "%s v%s - %s\n\n", config.AppName, config.AppVersion, config.AppDescription)
-		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] [command]\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
+		printCommands()
 
 		configPath, err := config.GetConfigPath()
 		if err == nil {
@@ -51,6 +75,13 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *configDirFlag != "" {
+		config.SetDir(*configDirFlag)
+	}
+	if *cacheDirFlag != "" {
+		cache.SetDir(*cacheDirFlag)
+	}
+
 	if *debugFlag {
 		zerolog.SetGlobalLevel(zerolog.DebugLevel)
 
@@ -71,6 +102,14 @@ func main() {
 		log.Logger = log.Output(zerolog.ConsoleWriter{Out: logFile, TimeFormat: "15:04:05"})
 		fmt.Printf("Debug log: %s\n", logPath)
 		log.Info().Msgf("Starting %s v%s (debug mode)", config.AppName, config.AppVersion)
+	} else if *serviceFlag || *daemonFlag {
+		// No TUI to corrupt; journald adds its own timestamps
+		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+		log.Logger = log.Output(zerolog.ConsoleWriter{
+			Out:          os.Stderr,
+			NoColor:      true,
+			PartsExclude: []string{zerolog.TimestampFieldName},
+		})
 	} else {
 		// Avoid TUI corruption by only logging errors to /dev/null
 		zerolog.SetGlobalLevel(zerolog.ErrorLevel)
@@ -89,10 +128,81 @@ func main() {
 		}
 	}
 
+	dirProblems := checkDirs()
+
+	if flag.NArg() > 0 {
+		// doctor reports the directories itself
+		if flag.Arg(0) != "doctor" {
+			printDirProblems(dirProblems)
+		}
+		os.Exit(runCommand(flag.Args()))
+	}
+
+	// Load falls back to defaults for whatever it couldn't read
+	cfg, err := config.Load()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to load config")
+	}
+
+	if *screenshotFlag != "" {
+		os.Exit(runScreenshot(cfg, *screenshotFlag))
+	}
+
+	wakeAlarm, hasAlarm := pickAlarm(cfg)
+
+	marker := beginSession(cfg)
+
+	if changed, err := cfg.EnsureListenerID(); err != nil {
+		log.Warn().Err(err).Msg("Listener ID disabled for this run")
+		cfg.ListenerID.Enabled = false
+	} else if changed {
+		if err := cfg.Save(); err != nil {
+			log.Warn().Err(err).Msg("Failed to save listener ID")
+		}
+	}
+
+	startTime := time.Now()
+	hooks.RunAsync(hooks.EventStartup, cfg.Hooks.OnStartup, hookEnv(cfg, 0))
+
 	apiClient := api.NewSomaFMClient()
-	stationService := service.NewStationService(apiClient)
-	somaPlayer := player.NewPlayer()
-	somaUi := ui.NewUI(somaPlayer, stationService, *randomFlag)
+	if cfg.Endpoints.API != "" {
+		log.Info().Msgf("Using API endpoint %s", cfg.Endpoints.API)
+		apiClient = api.NewSomaFMClientWithBaseURL(cfg.Endpoints.API)
+	}
+	stationService := service.NewStationService(apiClient, cacheMode())
+	stationService.SetTitleRewriter(titleRewriter(cfg))
+	somaPlayer := newPlayer(cfg)
+	limiter := throttle.New(cfg.BackgroundBandwidth << 10)
+	stationService.SetThrottle(limiter)
+	somaPlayer.SetUnderrunHandler(limiter.Tighten)
+	plays := startPlayLog(somaPlayer)
+	raisePriority(cfg)
+	stopPublishing := startPublishing(cfg, somaPlayer)
+
+	if *serviceFlag || *daemonFlag {
+		printDirProblems(dirProblems)
+		run := runService
+		if *daemonFlag {
+			run = runDaemon
+		}
+		code := run(cfg, stationService, somaPlayer)
+		stopPublishing()
+		if err := cfg.Save(); err != nil {
+			log.Warn().Err(err).Msg("Failed to save config")
+		}
+		runShutdownHook(cfg, startTime)
+		marker.End()
+		os.Exit(code)
+	}
+	somaUi := ui.NewUI(somaPlayer, stationService, cfg, *randomFlag)
+	somaUi.SetDirProblems(dirProblems)
+	somaUi.SetPlayLog(plays)
+	if hasAlarm {
+		somaUi.SetAlarm(wakeAlarm, cfg.Alarm.Ramp)
+	}
+	stopControl := startControl(somaUi.Remote())
+	stopAPI := startHTTPAPI(cfg, somaUi.Remote())
+	stopMPD := startMPD(cfg, somaUi.Remote(), stationService)
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -120,13 +230,151 @@ func main() {
 		if *debugFlag {
 			log.Error().Err(err).Msg("Error running UI")
 		}
+		stopControl()
+		stopAPI()
+		stopMPD()
 		somaPlayer.Stop()
+		stopPublishing()
+		runShutdownHook(cfg, startTime)
+		marker.End()
 		os.Exit(1)
 	}
 
 	// Ensure player is fully stopped before exiting
+	stopControl()
+	stopAPI()
+	stopMPD()
 	somaPlayer.Stop()
+	stopPublishing()
+	runShutdownHook(cfg, startTime)
+	marker.End()
 	if *debugFlag {
 		log.Info().Msg("SomaFM CLI stopped")
 	}
 }
+
+// startPublishing sends playback state to the configured MQTT broker and
+// InfluxDB endpoint. The returned func stops it after a final idle event.
+func startPublishing(cfg *config.Config, p *player.Player) func() {
+	publishers, err := publish.FromConfig(cfg.Publish)
+	if err != nil {
+		log.Warn().Err(err).Msg("Publishing disabled")
+		return func() {}
+	}
+	if len(publishers) == 0 {
+		return func() {}
+	}
+	for _, pub := range publishers {
+		log.Debug().Msgf("Publishing playback state to %s", pub.Name())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		publish.Watch(ctx, p, publishers)
+		close(done)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// startPlayLog writes every track change to the play log in the cache
+// directory and returns the log, or nil when there is no cache directory.
+func startPlayLog(p *player.Player) *playlog.Log {
+	path, err := playlog.DefaultPath()
+	if err != nil {
+		log.Warn().Err(err).Msg("Play log disabled")
+		return nil
+	}
+	plays := playlog.New(path)
+	p.SetTrackStartHandler(func(l player.TrackListen) {
+		if err := plays.Append(playlog.NewEntry(l.Start, l.Station, l.Track)); err != nil {
+			log.Warn().Err(err).Msg("Failed to log track change")
+		}
+	})
+	return plays
+}
+
+// pickAlarm returns the alarm from --alarm, or else from the config.
+func pickAlarm(cfg *config.Config) (alarm.Alarm, bool) {
+	spec := *alarmFlag
+	if spec == "" {
+		if !cfg.Alarm.Enabled {
+			return alarm.Alarm{}, false
+		}
+		spec = cfg.Alarm.Time + "=" + cfg.Alarm.Station.String()
+	}
+	a, err := alarm.Parse(spec, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	return a, true
+}
+
+// newPlayer returns a player set up for the configured stream endpoint,
+// playlist preference, normalization, and night mode.
+func newPlayer(cfg *config.Config) *player.Player {
+	p := player.NewPlayer()
+	p.SetStreamBaseURL(cfg.Endpoints.Streams)
+	p.SetPlaylistPreference(cfg.PlaylistPreference())
+	p.SetNormalize(cfg.Audio.Normalize)
+	p.SetNightMode(cfg.Audio.NightMode)
+	p.SetTitleRewriter(titleRewriter(cfg))
+	return p
+}
+
+// raisePriority applies the audio.nice and audio.realtime settings. Being
+// denied only costs robustness under load, so it is logged, not fatal.
+func raisePriority(cfg *config.Config) {
+	method, err := priority.Apply(cfg.Audio.Nice, cfg.Audio.Realtime)
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not raise playback priority, see audio.nice and audio.realtime in the README")
+	}
+	if method != priority.MethodNone {
+		log.Info().Msgf("Realtime scheduling enabled via %s", method)
+	}
+}
+
+// titleRewriter returns the config's title rules. config.Load drops rules
+// that don't compile, so this only fails for a config built by hand.
+func titleRewriter(cfg *config.Config) *retitle.Rewriter {
+	r, err := retitle.New(cfg.TitleRules)
+	if err != nil {
+		log.Warn().Err(err).Msg("Title rules disabled")
+		return nil
+	}
+	return r
+}
+
+func cacheMode() service.CacheMode {
+	switch {
+	case *noCacheFlag:
+		return service.CacheDisabled
+	case *refreshFlag:
+		return service.CacheRefresh
+	default:
+		return service.CacheNormal
+	}
+}
+
+func hookEnv(cfg *config.Config, uptime time.Duration) map[string]string {
+	env := map[string]string{
+		"station": cfg.LastStation.String(),
+		"volume":  strconv.Itoa(cfg.Volume),
+	}
+	if uptime > 0 {
+		env["uptime_seconds"] = strconv.Itoa(int(uptime.Seconds()))
+	}
+	return env
+}
+
+// Runs synchronously so the hook completes before the process exits.
+func runShutdownHook(cfg *config.Config, startTime time.Time) {
+	env := hookEnv(cfg, time.Since(startTime))
+	if err := hooks.Run(context.Background(), hooks.EventShutdown, cfg.Hooks.OnShutdown, env); err != nil {
+		log.Warn().Err(err).Msg("Hook error")
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/publish"
+)
+
+// playStatusInterval is how often `somafm play` checks for a new track.
+const playStatusInterval = time.Second
+
+// runPlay plays a station in the foreground without the TUI, printing a
+// line whenever the track or connection state changes, until interrupted.
+// With --json each line is a status object, as from `ctl status --json`.
+func runPlay(args []string) int {
+	fs := flag.NewFlagSet("play", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print a JSON object per change instead of text")
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: somafm play [--json] <station-id>")
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: fix the config first: %v\n", err)
+		return 1
+	}
+	stations, _, err := loadStations()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	s, err := findStation(stations, args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	p := newPlayer(cfg)
+	raisePriority(cfg)
+	p.SetVolume(cfg.Volume)
+	p.SetPauseKeywords(cfg.PauseKeywords)
+	p.SetListenerID(cfg.ActiveListenerID())
+
+	done := make(chan error, 1)
+	go func() { done <- p.Play(s) }()
+
+	ticker := time.NewTicker(playStatusInterval)
+	defer ticker.Stop()
+
+	enc := json.NewEncoder(os.Stdout)
+	lastStatus := ""
+	var lastEvent publish.Event
+	for {
+		select {
+		case <-ctx.Done():
+			p.Stop()
+			return 0
+		case err := <-done:
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			return 0
+		case <-ticker.C:
+			if *asJSON {
+				event := publish.Snapshot(p)
+				now := event.Time
+				event.Time = lastEvent.Time // Compare without the timestamp
+				if event != lastEvent {
+					event.Time = now
+					_ = enc.Encode(event)
+					lastEvent = event
+				}
+				continue
+			}
+			if status := serviceStatus(p, s); status != lastStatus {
+				fmt.Println(status)
+				lastStatus = status
+			}
+		}
+	}
+}
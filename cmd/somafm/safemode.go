@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/glebovdev/somafm-cli/internal/cache"
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/session"
+	"github.com/rs/zerolog/log"
+)
+
+// beginSession writes the running-session marker and, if the previous run
+// crashed, asks whether to start in safe mode.
+func beginSession(cfg *config.Config) *session.Marker {
+	cacheDir, err := cache.GetCacheDir()
+	if err != nil {
+		log.Debug().Err(err).Msg("Session tracking disabled")
+		return nil
+	}
+
+	marker, status, err := session.Begin(cacheDir)
+	if err != nil {
+		log.Debug().Err(err).Msg("Session tracking disabled")
+	}
+
+	switch status {
+	case session.StatusCrashed:
+		log.Warn().Msg("Previous run did not exit cleanly")
+		if !*safeModeFlag && !*serviceFlag && !*daemonFlag && promptSafeMode() {
+			cfg.EnterSafeMode()
+		}
+	case session.StatusRunning:
+		log.Debug().Msg("Another instance appears to be running")
+	}
+
+	if *safeModeFlag {
+		cfg.EnterSafeMode()
+	}
+	return marker
+}
+
+func promptSafeMode() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+
+	fmt.Printf("%s did not exit cleanly last time.\n", config.AppName)
+	fmt.Print("Start in safe mode (default theme, no hooks, no autostart)? [y/N] ")
+
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
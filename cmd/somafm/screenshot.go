@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/api"
+	"github.com/glebovdev/somafm-cli/internal/clock"
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/glebovdev/somafm-cli/internal/service"
+	"github.com/glebovdev/somafm-cli/internal/ui"
+)
+
+// Screenshot dimensions, in terminal cells.
+const (
+	screenshotWidth  = 100
+	screenshotHeight = 40
+)
+
+// screenshotTime is the frozen time screenshots are taken at, so repeated
+// runs only differ when the station data does.
+var screenshotTime = time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+// runScreenshot renders the main screen as plain text to path ("-" for
+// stdout) with a frozen clock, without playing anything or touching the
+// cache.
+func runScreenshot(cfg *config.Config, path string) int {
+	apiClient := api.NewSomaFMClient()
+	if cfg.Endpoints.API != "" {
+		apiClient = api.NewSomaFMClientWithBaseURL(cfg.Endpoints.API)
+	}
+	stationService := service.NewStationService(apiClient, service.CacheDisabled)
+
+	fakeClock := clock.NewFake(screenshotTime)
+	somaPlayer := player.NewPlayer()
+	somaPlayer.SetClock(fakeClock)
+	cfg.Logos = config.LogosBlocks // Graphics don't show up in text
+	somaUi := ui.NewUI(somaPlayer, stationService, cfg, false)
+	somaUi.SetClock(fakeClock)
+
+	text, err := somaUi.Screenshot(screenshotWidth, screenshotHeight)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Screenshot failed: %v\n", err)
+		return 1
+	}
+
+	if path == "-" {
+		fmt.Print(text)
+		return 0
+	}
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Screenshot failed: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Screenshot saved to %s\n", path)
+	return 0
+}
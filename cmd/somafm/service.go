@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/glebovdev/somafm-cli/internal/sdnotify"
+	"github.com/glebovdev/somafm-cli/internal/service"
+	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	serviceStatusInterval = 5 * time.Second
+	serviceRestartDelay   = 10 * time.Second
+)
+
+// pickServiceStation returns the station to play headless: the requested ID,
+// then the last station, then the first favorite, then the most popular one.
+func pickServiceStation(cfg *config.Config, stations *service.StationService, requested string) (*station.Station, error) {
+	if requested != "" {
+		id, err := station.ParseStationID(requested)
+		if err != nil {
+			return nil, err
+		}
+		if s := stations.GetStation(stations.FindIndexByID(id)); s != nil {
+			return s, nil
+		}
+		return nil, fmt.Errorf("unknown station %q", id)
+	}
+
+	candidates := append([]station.StationID{cfg.LastStation}, cfg.Favorites...)
+	for _, id := range candidates {
+		if s := stations.GetStation(stations.FindIndexByID(id)); s != nil {
+			return s, nil
+		}
+	}
+	if s := stations.GetStation(0); s != nil {
+		return s, nil
+	}
+	return nil, errors.New("no stations available")
+}
+
+// serviceStatus describes playback in one line for `systemctl status`.
+func serviceStatus(p *player.Player, s *station.Station) string {
+	state := p.GetState()
+	switch state {
+	case player.StatePlaying:
+		if track := p.GetCurrentTrack(); track != "" && track != player.NoTrackInfo {
+			return fmt.Sprintf("Playing %s — %s", s.Title, track)
+		}
+		return "Playing " + s.Title
+	case player.StateReconnecting:
+		current, max := p.GetRetryInfo()
+		return fmt.Sprintf("Reconnecting to %s (%d/%d)", s.Title, current, max)
+	case player.StateError:
+		return fmt.Sprintf("Error on %s: %s", s.Title, p.GetLastError())
+	default:
+		return fmt.Sprintf("%s %s", state, s.Title)
+	}
+}
+
+func notify(states ...string) {
+	if _, err := sdnotify.Notify(states...); err != nil {
+		log.Warn().Err(err).Msg("systemd notification failed")
+	}
+}
+
+// runService plays a station without the TUI until SIGINT or SIGTERM,
+// reporting readiness, status, and watchdog pings to systemd when present.
+func runService(cfg *config.Config, stations *service.StationService, p *player.Player) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if _, err := stations.GetStations(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to load stations")
+		notify(sdnotify.Status("Failed to load stations: %v", err))
+		return 1
+	}
+
+	s, err := pickServiceStation(cfg, stations, *stationFlag)
+	if err != nil {
+		log.Error().Err(err).Msg("No station to play")
+		notify(sdnotify.Status("No station to play: %v", err))
+		return 1
+	}
+
+	p.SetVolume(cfg.Volume)
+	p.SetPauseKeywords(cfg.PauseKeywords)
+	p.SetListenerID(cfg.ActiveListenerID())
+	cfg.LastStation = s.ID
+
+	log.Info().Msgf("Playing %s (%s) at %d%% volume", s.Title, s.ID, cfg.Volume)
+
+	// Keep trying after the player gives up, a service shouldn't just go quiet
+	go func() {
+		for ctx.Err() == nil {
+			err := p.Play(s)
+			if ctx.Err() != nil {
+				return
+			}
+			log.Warn().Err(err).Msgf("Playback stopped, restarting in %v", serviceRestartDelay)
+			select {
+			case <-ctx.Done():
+			case <-time.After(serviceRestartDelay):
+			}
+		}
+	}()
+
+	notify(sdnotify.Ready, sdnotify.Status("Starting %s", s.Title))
+
+	statusTicker := time.NewTicker(serviceStatusInterval)
+	defer statusTicker.Stop()
+
+	var watchdog <-chan time.Time
+	if interval := sdnotify.WatchdogInterval(); interval > 0 {
+		watchdogTicker := time.NewTicker(interval / 2)
+		defer watchdogTicker.Stop()
+		watchdog = watchdogTicker.C
+	}
+
+	lastStatus := ""
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Received shutdown signal, stopping playback")
+			notify(sdnotify.Stopping)
+			p.Stop()
+			return 0
+		case <-statusTicker.C:
+			status := serviceStatus(p, s)
+			if status != lastStatus {
+				log.Info().Msg(status)
+				notify(sdnotify.Status("%s", status))
+				lastStatus = status
+			}
+		case <-watchdog:
+			notify(sdnotify.Watchdog)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/history"
+	"github.com/glebovdev/somafm-cli/internal/likes"
+	"github.com/glebovdev/somafm-cli/internal/storage"
+	"github.com/glebovdev/somafm-cli/internal/summary"
+)
+
+const sessionUsage = "Usage: somafm session export [--out file.md] [--gap 30m] [--json]"
+
+// runSession writes the most recent listening session up as Markdown, to
+// stdout or a file.
+func runSession(args []string) int {
+	fs := flag.NewFlagSet("session", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print the session as JSON instead of Markdown")
+	out := fs.String("out", "", "Write the summary to this file instead of stdout")
+	gap := fs.Duration("gap", summary.Gap, "A break this long ends a session")
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(args) != 1 || args[0] != "export" || *gap <= 0 {
+		fmt.Fprintln(os.Stderr, sessionUsage)
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: fix the config first: %v\n", err)
+		return 1
+	}
+	db, err := storage.Open(cfg.Storage.Backend)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if db != nil {
+		defer db.Close()
+	}
+	historyStore, err := history.OpenDefault(db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	likesStore, err := likes.OpenDefault(db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	session, ok := summary.Last(historyStore.Entries(), likesStore.Tracks(), *gap)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "No listening history yet")
+		return 1
+	}
+	if *asJSON {
+		return printJSON(session)
+	}
+
+	var buf bytes.Buffer
+	if err := summary.Markdown(&buf, session, time.Local); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if *out == "" {
+		_, _ = os.Stdout.Write(buf.Bytes())
+		return 0
+	}
+	if err := os.WriteFile(*out, buf.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Wrote the session of %s to %s\n", session.Start.Local().Format("2006-01-02 15:04"), *out)
+	return 0
+}
@@ -0,0 +1,61 @@
+// Package alarm schedules playback to start at a time of day.
+package alarm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/station"
+)
+
+// DefaultRamp is how long the volume takes to reach its target.
+const DefaultRamp = time.Minute
+
+// Alarm starts Station at At. An empty Station means the last one played.
+type Alarm struct {
+	At      time.Time
+	Station station.StationID
+}
+
+// Parse reads "HH:MM" or "HH:MM=station" and returns the next occurrence
+// of that time after now.
+func Parse(spec string, now time.Time) (Alarm, error) {
+	clock, name, hasStation := strings.Cut(strings.TrimSpace(spec), "=")
+	at, err := Next(clock, now)
+	if err != nil {
+		return Alarm{}, err
+	}
+	var id station.StationID
+	if hasStation && strings.TrimSpace(name) != "" {
+		if id, err = station.ParseStationID(name); err != nil {
+			return Alarm{}, fmt.Errorf("invalid alarm station: %w", err)
+		}
+	}
+	return Alarm{At: at, Station: id}, nil
+}
+
+// Next returns the next time after now that the clock shows "HH:MM".
+func Next(clock string, now time.Time) (time.Time, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid alarm time %q, want HH:MM", clock)
+	}
+	at := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	if !at.After(now) {
+		at = at.AddDate(0, 0, 1)
+	}
+	return at, nil
+}
+
+// RampVolume returns the volume elapsed into a ramp of the given length
+// that rises from 0 to target.
+func RampVolume(target int, elapsed, ramp time.Duration) int {
+	if ramp <= 0 || elapsed >= ramp {
+		return target
+	}
+	if elapsed <= 0 {
+		return 0
+	}
+	return int(float64(target) * float64(elapsed) / float64(ramp))
+}
@@ -0,0 +1,60 @@
+package alarm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	now := time.Date(2026, 3, 14, 8, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		spec    string
+		want    time.Time
+		station string
+		wantErr bool
+	}{
+		{"09:15", time.Date(2026, 3, 14, 9, 15, 0, 0, time.UTC), "", false},
+		{"07:00=groovesalad", time.Date(2026, 3, 15, 7, 0, 0, 0, time.UTC), "groovesalad", false},
+		{"08:30", time.Date(2026, 3, 15, 8, 30, 0, 0, time.UTC), "", false},
+		{" 23:59 = dronezone ", time.Date(2026, 3, 14, 23, 59, 0, 0, time.UTC), "dronezone", false},
+		{"7am", time.Time{}, "", true},
+		{"25:00", time.Time{}, "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.spec, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if !got.At.Equal(tt.want) || got.Station.String() != tt.station {
+			t.Errorf("Parse(%q) = %v %q, want %v %q", tt.spec, got.At, got.Station, tt.want, tt.station)
+		}
+	}
+}
+
+func TestRampVolume(t *testing.T) {
+	tests := []struct {
+		elapsed time.Duration
+		want    int
+	}{
+		{-time.Second, 0},
+		{0, 0},
+		{15 * time.Second, 20},
+		{30 * time.Second, 40},
+		{time.Minute, 80},
+		{2 * time.Minute, 80},
+	}
+	for _, tt := range tests {
+		if got := RampVolume(80, tt.elapsed, time.Minute); got != tt.want {
+			t.Errorf("RampVolume(80, %v) = %d, want %d", tt.elapsed, got, tt.want)
+		}
+	}
+	if got := RampVolume(80, 0, 0); got != 80 {
+		t.Errorf("RampVolume with no ramp = %d, want target", got)
+	}
+}
@@ -2,8 +2,12 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/glebovdev/somafm-cli/internal/station"
@@ -11,27 +15,59 @@ import (
 )
 
 const (
-	baseURL        = "https://api.somafm.com"
-	requestTimeout = 30 * time.Second
+	// DefaultBaseURL serves the channel list, song history, station images,
+	// and playlist files.
+	DefaultBaseURL = "https://api.somafm.com"
+
+	// stationsTimeout bounds a channel list request. The list is large and
+	// fetched rarely, so it may take a while on a slow link.
+	stationsTimeout = 30 * time.Second
+	// songsTimeout bounds a song history request. These are small and
+	// polled often; a slow one is better dropped and retried on the next
+	// poll than left to pile up behind it.
+	songsTimeout = 10 * time.Second
 )
 
 // SomaFMClient is the HTTP client for interacting with the SomaFM API.
 type SomaFMClient struct {
-	client *resty.Client
+	client  *resty.Client
+	baseURL string
 }
 
 // NewSomaFMClient creates a new SomaFM API client with sensible defaults.
 func NewSomaFMClient() *SomaFMClient {
+	return NewSomaFMClientWithBaseURL(DefaultBaseURL)
+}
+
+// NewSomaFMClientWithBaseURL creates a client for an alternative API
+// endpoint, such as a mirror or a local test server.
+// Image and playlist URLs of the returned stations are rewritten to the
+// same endpoint, so they go through the mirror as well.
+func NewSomaFMClientWithBaseURL(url string) *SomaFMClient {
+	url = strings.TrimRight(url, "/")
 	return &SomaFMClient{
-		client: resty.New().
-			SetBaseURL(baseURL).
-			SetTimeout(requestTimeout),
+		client:  resty.New().SetBaseURL(url),
+		baseURL: url,
 	}
 }
 
+// SetTransport sends requests through t, e.g. to throttle them.
+func (c *SomaFMClient) SetTransport(t http.RoundTripper) {
+	c.client.SetTransport(t)
+}
+
+// BaseURL returns the API endpoint the client talks to.
+func (c *SomaFMClient) BaseURL() string {
+	return c.baseURL
+}
+
 // GetStations fetches the list of available radio stations from the SomaFM API.
-func (c *SomaFMClient) GetStations() ([]station.Station, error) {
-	resp, err := c.client.R().Get("/channels.json")
+// The request ends when ctx is done or after stationsTimeout.
+func (c *SomaFMClient) GetStations(ctx context.Context) ([]station.Station, error) {
+	ctx, cancel := context.WithTimeout(ctx, stationsTimeout)
+	defer cancel()
+
+	resp, err := c.client.R().SetContext(ctx).Get("/channels.json")
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch stations: %w", err)
 	}
@@ -48,9 +84,67 @@ func (c *SomaFMClient) GetStations() ([]station.Station, error) {
 		return nil, fmt.Errorf("failed to parse stations response: %w", err)
 	}
 
+	if c.baseURL != DefaultBaseURL {
+		for i := range response.Channels {
+			c.rewriteStationURLs(&response.Channels[i])
+		}
+	}
+
 	return response.Channels, nil
 }
 
+// rewriteStationURLs points image and playlist URLs hosted on the default
+// API host at the client's endpoint.
+func (c *SomaFMClient) rewriteStationURLs(s *station.Station) {
+	rewrite := func(raw string) string {
+		if !sameHost(raw, DefaultBaseURL) {
+			return raw
+		}
+		return RewriteURL(raw, c.baseURL)
+	}
+
+	s.Image = rewrite(s.Image)
+	s.LargeImage = rewrite(s.LargeImage)
+	s.XLImage = rewrite(s.XLImage)
+	for i := range s.Playlists {
+		s.Playlists[i].URL = rewrite(s.Playlists[i].URL)
+	}
+}
+
+// RewriteURL replaces the scheme and host of raw with those of base, and
+// prefixes its path with base's path. raw is returned unchanged when either
+// URL is empty or invalid.
+func RewriteURL(raw, base string) string {
+	if raw == "" || base == "" {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	b, err := url.Parse(base)
+	if err != nil || b.Host == "" {
+		return raw
+	}
+
+	u.Scheme = b.Scheme
+	u.Host = b.Host
+	u.Path = strings.TrimRight(b.Path, "/") + u.Path
+	return u.String()
+}
+
+func sameHost(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(ua.Host, ub.Host)
+}
+
 type SongInfo struct {
 	Title  string `json:"title"`
 	Artist string `json:"artist"`
@@ -64,8 +158,12 @@ type SongsResponse struct {
 }
 
 // GetRecentSongs fetches the recent song history for a specific station.
-func (c *SomaFMClient) GetRecentSongs(stationID string) (*SongsResponse, error) {
-	resp, err := c.client.R().Get(fmt.Sprintf("/songs/%s.json", stationID))
+// The request ends when ctx is done or after songsTimeout.
+func (c *SomaFMClient) GetRecentSongs(ctx context.Context, stationID station.StationID) (*SongsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, songsTimeout)
+	defer cancel()
+
+	resp, err := c.client.R().SetContext(ctx).Get(fmt.Sprintf("/songs/%s.json", stationID))
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch songs for station %s: %w", stationID, err)
 	}
@@ -82,8 +180,8 @@ func (c *SomaFMClient) GetRecentSongs(stationID string) (*SongsResponse, error)
 	return &response, nil
 }
 
-func (c *SomaFMClient) GetCurrentTrackForStation(stationID string) (string, error) {
-	songs, err := c.GetRecentSongs(stationID)
+func (c *SomaFMClient) GetCurrentTrackForStation(ctx context.Context, stationID station.StationID) (string, error) {
+	songs, err := c.GetRecentSongs(ctx, stationID)
 	if err != nil {
 		return "", err
 	}
@@ -91,13 +189,31 @@ func (c *SomaFMClient) GetCurrentTrackForStation(stationID string) (string, erro
 	if len(songs.Songs) == 0 {
 		return "", nil
 	}
+	return songs.Songs[0].Track(), nil
+}
+
+// GetRecentTracks returns up to n "Artist - Title" strings for the station,
+// the currently playing track first. Songs without a title are empty.
+func (c *SomaFMClient) GetRecentTracks(ctx context.Context, stationID station.StationID, n int) ([]string, error) {
+	songs, err := c.GetRecentSongs(ctx, stationID)
+	if err != nil {
+		return nil, err
+	}
 
-	song := songs.Songs[0]
-	if song.Artist != "" && song.Title != "" {
-		return fmt.Sprintf("%s - %s", song.Artist, song.Title), nil
+	var tracks []string
+	for _, song := range songs.Songs {
+		if len(tracks) >= n {
+			break
+		}
+		tracks = append(tracks, song.Track())
 	}
-	if song.Title != "" {
-		return song.Title, nil
+	return tracks, nil
+}
+
+// Track formats the song the way stream metadata shows it.
+func (s SongInfo) Track() string {
+	if s.Artist != "" && s.Title != "" {
+		return fmt.Sprintf("%s - %s", s.Artist, s.Title)
 	}
-	return "", nil
+	return s.Title
 }
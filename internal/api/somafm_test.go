@@ -1,10 +1,14 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/glebovdev/somafm-cli/internal/station"
 	"github.com/go-resty/resty/v2"
@@ -51,7 +55,7 @@ func TestGetStations(t *testing.T) {
 	})
 	defer server.Close()
 
-	stations, err := client.GetStations()
+	stations, err := client.GetStations(context.Background())
 	if err != nil {
 		t.Fatalf("GetStations() error = %v", err)
 	}
@@ -83,7 +87,7 @@ func TestGetStationsEmptyResponse(t *testing.T) {
 	})
 	defer server.Close()
 
-	stations, err := client.GetStations()
+	stations, err := client.GetStations(context.Background())
 	if err != nil {
 		t.Fatalf("GetStations() error = %v", err)
 	}
@@ -100,12 +104,35 @@ func TestGetStationsInvalidJSON(t *testing.T) {
 	})
 	defer server.Close()
 
-	_, err := client.GetStations()
+	_, err := client.GetStations(context.Background())
 	if err == nil {
 		t.Error("GetStations() should return error for invalid JSON")
 	}
 }
 
+func TestGetStationsCanceled(t *testing.T) {
+	release := make(chan struct{})
+	server, client := setupTestServer(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	})
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := client.GetStations(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("GetStations() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("GetStations() returned %v after the cancel", elapsed)
+	}
+}
+
 func TestGetRecentSongs(t *testing.T) {
 	expectedSongs := &SongsResponse{
 		ID: "groovesalad",
@@ -126,7 +153,7 @@ func TestGetRecentSongs(t *testing.T) {
 	})
 	defer server.Close()
 
-	songs, err := client.GetRecentSongs("groovesalad")
+	songs, err := client.GetRecentSongs(context.Background(), "groovesalad")
 	if err != nil {
 		t.Fatalf("GetRecentSongs() error = %v", err)
 	}
@@ -161,7 +188,7 @@ func TestGetRecentSongsEmpty(t *testing.T) {
 	})
 	defer server.Close()
 
-	songs, err := client.GetRecentSongs("groovesalad")
+	songs, err := client.GetRecentSongs(context.Background(), "groovesalad")
 	if err != nil {
 		t.Fatalf("GetRecentSongs() error = %v", err)
 	}
@@ -226,7 +253,7 @@ func TestGetCurrentTrackForStation(t *testing.T) {
 			})
 			defer server.Close()
 
-			result, err := client.GetCurrentTrackForStation("teststation")
+			result, err := client.GetCurrentTrackForStation(context.Background(), "teststation")
 			if err != nil {
 				t.Fatalf("GetCurrentTrackForStation() error = %v", err)
 			}
@@ -248,6 +275,14 @@ func TestNewSomaFMClient(t *testing.T) {
 	}
 }
 
+func TestNewSomaFMClientWithBaseURL(t *testing.T) {
+	client := NewSomaFMClientWithBaseURL("http://localhost:8080")
+
+	if got := client.client.BaseURL; got != "http://localhost:8080" {
+		t.Errorf("NewSomaFMClientWithBaseURL() BaseURL = %q, want %q", got, "http://localhost:8080")
+	}
+}
+
 func TestSongInfoFields(t *testing.T) {
 	song := SongInfo{
 		Title:  "Test Title",
@@ -286,3 +321,94 @@ func TestSongsResponseFields(t *testing.T) {
 		t.Errorf("SongsResponse.Songs length = %d, want 2", len(response.Songs))
 	}
 }
+
+func TestRewriteURL(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		base string
+		want string
+	}{
+		{"replaces host", "https://api.somafm.com/img/groovesalad.png", "http://localhost:8080", "http://localhost:8080/img/groovesalad.png"},
+		{"prefixes base path", "https://api.somafm.com/groovesalad.pls", "http://proxy.lan/somafm/", "http://proxy.lan/somafm/groovesalad.pls"},
+		{"keeps query", "http://ice1.somafm.com/groovesalad-128-mp3?x=1", "http://mirror:8000", "http://mirror:8000/groovesalad-128-mp3?x=1"},
+		{"empty base", "https://api.somafm.com/a.png", "", "https://api.somafm.com/a.png"},
+		{"relative raw", "/a.png", "http://mirror", "/a.png"},
+		{"invalid base", "https://api.somafm.com/a.png", "mirror", "https://api.somafm.com/a.png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RewriteURL(tt.raw, tt.base); got != tt.want {
+				t.Errorf("RewriteURL(%q, %q) = %q, want %q", tt.raw, tt.base, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetStationsRewritesToMirror(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := struct {
+			Channels []station.Station `json:"channels"`
+		}{
+			Channels: []station.Station{{
+				ID:      "groovesalad",
+				Image:   "https://api.somafm.com/img/groovesalad120.png",
+				XLImage: "https://api.somafm.com/logos/512/groovesalad512.png",
+				Playlists: []station.Playlist{
+					{URL: "https://api.somafm.com/groovesalad256.pls", Format: "mp3", Quality: "highest"},
+					{URL: "https://example.com/other.pls", Format: "aac", Quality: "high"},
+				},
+			}},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	client := NewSomaFMClientWithBaseURL(server.URL + "/")
+	stations, err := client.GetStations(context.Background())
+	if err != nil {
+		t.Fatalf("GetStations() error = %v", err)
+	}
+
+	st := stations[0]
+	if want := server.URL + "/img/groovesalad120.png"; st.Image != want {
+		t.Errorf("Image = %q, want %q", st.Image, want)
+	}
+	if want := server.URL + "/logos/512/groovesalad512.png"; st.XLImage != want {
+		t.Errorf("XLImage = %q, want %q", st.XLImage, want)
+	}
+	if want := server.URL + "/groovesalad256.pls"; st.Playlists[0].URL != want {
+		t.Errorf("Playlists[0].URL = %q, want %q", st.Playlists[0].URL, want)
+	}
+	if want := "https://example.com/other.pls"; st.Playlists[1].URL != want {
+		t.Errorf("Playlists[1].URL = %q, want %q (other hosts are left alone)", st.Playlists[1].URL, want)
+	}
+}
+
+func TestGetRecentTracks(t *testing.T) {
+	server, client := setupTestServer(func(w http.ResponseWriter, _ *http.Request) {
+		response := SongsResponse{
+			ID: "teststation",
+			Songs: []SongInfo{
+				{Artist: "First", Title: "Song"},
+				{Title: "Untitled Jam"},
+				{Artist: "Third", Title: "Song"},
+				{Artist: "Fourth", Title: "Song"},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	})
+	defer server.Close()
+
+	tracks, err := client.GetRecentTracks(context.Background(), "teststation", 3)
+	if err != nil {
+		t.Fatalf("GetRecentTracks() error = %v", err)
+	}
+	want := []string{"First - Song", "Untitled Jam", "Third - Song"}
+	if strings.Join(tracks, "|") != strings.Join(want, "|") {
+		t.Errorf("GetRecentTracks() = %q, want %q", tracks, want)
+	}
+}
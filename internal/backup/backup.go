@@ -0,0 +1,204 @@
+// Package backup packs the user's state, the config with its favorites,
+// liked tracks, and listening history, into one tar.gz archive and
+// restores it. Caches are left out; they are rebuilt on demand.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/history"
+	"github.com/glebovdev/somafm-cli/internal/likes"
+	"github.com/glebovdev/somafm-cli/internal/storage"
+)
+
+// ManifestName is the first entry of every backup archive.
+const ManifestName = "somafm-backup.json"
+
+// sqliteWAL holds SQLite writes not yet folded into the database file.
+const sqliteWAL = storage.DBFileName + "-wal"
+
+// Files lists the state files, relative to the config directory, that a
+// backup includes when they exist.
+var Files = []string{
+	config.ConfigFileName,
+	likes.FileName,
+	likes.LegacyFileName,
+	history.FileName,
+	history.LegacyFileName,
+	storage.DBFileName,
+	sqliteWAL,
+}
+
+// Manifest describes a backup archive.
+type Manifest struct {
+	Version string    `json:"version"`
+	Created time.Time `json:"created"`
+	Files   []string  `json:"files"`
+}
+
+// Dir returns the config directory that backups are made from.
+func Dir() (string, error) {
+	path, err := config.GetConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(path), nil
+}
+
+// Create writes a gzipped tar of the state files found in dir to w.
+func Create(w io.Writer, dir string) (Manifest, error) {
+	m := Manifest{Version: config.AppVersion, Created: time.Now().UTC()}
+	for _, name := range Files {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && info.Mode().IsRegular() {
+			m.Files = append(m.Files, name)
+		}
+	}
+	if len(m.Files) == 0 {
+		return m, fmt.Errorf("nothing to back up in %s", dir)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return m, err
+	}
+	if err := writeEntry(tw, ManifestName, manifest, m.Created); err != nil {
+		return m, err
+	}
+	for _, name := range m.Files {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return m, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if err := writeEntry(tw, name, data, m.Created); err != nil {
+			return m, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return m, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return m, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return m, nil
+}
+
+func writeEntry(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return nil
+}
+
+// Restore reads an archive made by Create and writes its files into dir,
+// replacing existing ones. Files the archive doesn't contain are left
+// alone. Nothing is written unless the whole archive reads cleanly.
+func Restore(r io.Reader, dir string) (Manifest, error) {
+	var m Manifest
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return m, fmt.Errorf("not a backup archive: %w", err)
+	}
+	defer gz.Close()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for first := true; ; first = false {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return m, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if first && header.Name != ManifestName {
+			return m, errors.New("not a backup archive: missing manifest")
+		}
+		if header.Typeflag != tar.TypeReg {
+			return m, fmt.Errorf("unexpected entry %q in archive", header.Name)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return m, fmt.Errorf("failed to read %s: %w", header.Name, err)
+		}
+		if header.Name == ManifestName {
+			if err := json.Unmarshal(data, &m); err != nil {
+				return m, fmt.Errorf("invalid manifest: %w", err)
+			}
+			continue
+		}
+		if !slices.Contains(Files, header.Name) {
+			return m, fmt.Errorf("unexpected entry %q in archive", header.Name)
+		}
+		files[header.Name] = data
+	}
+	if m.Version == "" && len(files) == 0 {
+		return m, errors.New("not a backup archive: missing manifest")
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return m, fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if _, ok := files[storage.DBFileName]; ok {
+		// A log left from the replaced database would be applied to the
+		// restored one
+		for _, suffix := range []string{"-wal", "-shm"} {
+			if err := os.Remove(filepath.Join(dir, storage.DBFileName+suffix)); err != nil && !os.IsNotExist(err) {
+				return m, fmt.Errorf("failed to remove old database log: %w", err)
+			}
+		}
+	}
+	m.Files = m.Files[:0]
+	for _, name := range Files {
+		data, ok := files[name]
+		if !ok {
+			continue
+		}
+		if err := writeFile(filepath.Join(dir, name), data); err != nil {
+			return m, fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+		m.Files = append(m.Files, name)
+	}
+	return m, nil
+}
+
+// writeFile replaces path with data using a temp file and rename.
+func writeFile(path string, data []byte) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), ".restore-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,147 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCreateRestoreRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{
+		"config.yml":    "volume: 40\n",
+		"likes.jsonl":   `{"track":"a"}` + "\n",
+		"history.jsonl": `{"track":"b"}` + "\n",
+	})
+	// Anything that isn't state stays out of the archive
+	writeFiles(t, src, map[string]string{"notes.txt": "x"})
+
+	var buf bytes.Buffer
+	m, err := Create(&buf, src)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if want := []string{"config.yml", "likes.jsonl", "history.jsonl"}; !slices.Equal(m.Files, want) {
+		t.Errorf("Create files = %v, want %v", m.Files, want)
+	}
+
+	dst := t.TempDir()
+	writeFiles(t, dst, map[string]string{"config.yml": "volume: 90\n", "likes.json": "[]"})
+	restored, err := Restore(&buf, dst)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if !slices.Equal(restored.Files, m.Files) {
+		t.Errorf("Restore files = %v, want %v", restored.Files, m.Files)
+	}
+	if !restored.Created.Equal(m.Created) {
+		t.Errorf("Created = %v, want %v", restored.Created, m.Created)
+	}
+
+	for name, want := range map[string]string{
+		"config.yml":    "volume: 40\n",
+		"history.jsonl": `{"track":"b"}` + "\n",
+		"likes.json":    "[]",
+	} {
+		got, err := os.ReadFile(filepath.Join(dst, name))
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst, "notes.txt")); !os.IsNotExist(err) {
+		t.Error("notes.txt should not have been restored")
+	}
+}
+
+func TestCreateEmptyDir(t *testing.T) {
+	if _, err := Create(&bytes.Buffer{}, t.TempDir()); err == nil {
+		t.Error("Create should fail when there is nothing to back up")
+	}
+}
+
+func TestRestoreDropsStaleDatabaseLog(t *testing.T) {
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{"somafm.db": "new"})
+	var buf bytes.Buffer
+	if _, err := Create(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := t.TempDir()
+	writeFiles(t, dst, map[string]string{"somafm.db": "old", "somafm.db-wal": "old", "somafm.db-shm": "old"})
+	if _, err := Restore(&buf, dst); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	for _, name := range []string{"somafm.db-wal", "somafm.db-shm"} {
+		if _, err := os.Stat(filepath.Join(dst, name)); !os.IsNotExist(err) {
+			t.Errorf("%s should have been removed", name)
+		}
+	}
+}
+
+func archive(t *testing.T, entries map[string]string, order ...string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range order {
+		data := entries[name]
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(data))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestRestoreRejectsBadArchives(t *testing.T) {
+	manifest := `{"version":"1.0.0"}`
+	tests := []struct {
+		name    string
+		entries map[string]string
+		order   []string
+	}{
+		{"no manifest", map[string]string{"config.yml": "a"}, []string{"config.yml"}},
+		{"path traversal", map[string]string{ManifestName: manifest, "../config.yml": "a"}, []string{ManifestName, "../config.yml"}},
+		{"unknown file", map[string]string{ManifestName: manifest, "cache.json": "a"}, []string{ManifestName, "cache.json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := t.TempDir()
+			if _, err := Restore(archive(t, tt.entries, tt.order...), dst); err == nil {
+				t.Fatal("Restore should fail")
+			}
+			if entries, _ := os.ReadDir(dst); len(entries) != 0 {
+				t.Errorf("Restore wrote %d files from a rejected archive", len(entries))
+			}
+		})
+	}
+
+	if _, err := Restore(bytes.NewBufferString("plain text"), t.TempDir()); err == nil {
+		t.Error("Restore should fail on a non-gzip input")
+	}
+}
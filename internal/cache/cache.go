@@ -2,15 +2,26 @@
 package cache
 
 import (
+	"bytes"
 	"crypto/md5"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"image"
+	_ "image/gif"
+	_ "image/jpeg"
 	"image/png"
+	"mime"
+	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/glebovdev/somafm-cli/internal/clock"
+	"github.com/glebovdev/somafm-cli/internal/dircheck"
 	"github.com/rs/zerolog/log"
 )
 
@@ -21,12 +32,26 @@ const (
 	ImageSubdir = "images"
 	// AppName is used for the cache directory name.
 	AppName = "somafm"
+	// savingsFile records, per cached logo, how many bytes keeping the
+	// original format saved over storing it as PNG.
+	savingsFile = "image-savings.json"
 )
 
+// imageExtensions maps the logo formats kept as-is to their file extension.
+// Anything else is transcoded to PNG.
+var imageExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+}
+
 // Cache manages disk-based caching of station logo images.
 type Cache struct {
 	baseDir string
 	expiry  time.Duration
+	clock   clock.Clock // Nil means the wall clock
+
+	savingsMu sync.Mutex
 }
 
 // NewCache creates a new Cache instance with the default expiry.
@@ -42,8 +67,38 @@ func NewCache() (*Cache, error) {
 	}, nil
 }
 
+// SetClock replaces the wall clock used to expire cached files.
+func (c *Cache) SetClock(clk clock.Clock) {
+	c.clock = clk
+}
+
+// DirFlag is the command-line flag that calls SetDir.
+const DirFlag = "--cache-dir"
+
+// dirOverride replaces the platform cache directory when set with SetDir.
+var dirOverride string
+
+// SetDir keeps cached files in dir instead of the platform cache
+// directory.
+func SetDir(dir string) {
+	dirOverride = dir
+}
+
+// CheckDir reports a *dircheck.Error when the cache directory can't be
+// written, creating it if needed.
+func CheckDir() error {
+	dir, err := GetCacheDir()
+	if err != nil {
+		return err
+	}
+	return dircheck.Writable("cache directory", dir, DirFlag)
+}
+
 // GetCacheDir returns the platform-specific cache directory for the application.
 func GetCacheDir() (string, error) {
+	if dirOverride != "" {
+		return dirOverride, nil
+	}
 	userCacheDir, err := os.UserCacheDir()
 	if err != nil {
 		return "", fmt.Errorf("failed to get user cache directory: %w", err)
@@ -62,10 +117,29 @@ func hashURL(url string) string {
 	return hex.EncodeToString(hash[:])
 }
 
-// GetImage retrieves a cached image by URL. Returns nil if not found or expired.
+func variantFilename(url string, cols, rows int) string {
+	return fmt.Sprintf("%s_%dx%d.png", hashURL(url), cols, rows)
+}
+
+// GetImage retrieves a cached image by URL, in whichever format it was
+// stored. Returns nil if not found or expired.
 func (c *Cache) GetImage(url string) image.Image {
+	for _, ext := range []string{".jpg", ".png", ".gif"} {
+		if img := c.loadImageFile(hashURL(url) + ext); img != nil {
+			return img
+		}
+	}
+	return nil
+}
+
+// GetImageVariant retrieves a cached rendering of the image scaled for the
+// given cell dimensions. Returns nil if not found or expired.
+func (c *Cache) GetImageVariant(url string, cols, rows int) image.Image {
+	return c.loadImageFile(variantFilename(url, cols, rows))
+}
+
+func (c *Cache) loadImageFile(filename string) image.Image {
 	imageDir := filepath.Join(c.baseDir, ImageSubdir)
-	filename := hashURL(url) + ".png"
 	imagePath := filepath.Join(imageDir, filename)
 
 	info, err := os.Stat(imagePath)
@@ -73,7 +147,7 @@ func (c *Cache) GetImage(url string) image.Image {
 		return nil
 	}
 
-	if time.Since(info.ModTime()) > c.expiry {
+	if clock.Or(c.clock).Since(info.ModTime()) > c.expiry {
 		if err := os.Remove(imagePath); err != nil {
 			log.Debug().Err(err).Str("file", imagePath).Msg("Failed to remove expired cache file")
 		}
@@ -97,13 +171,102 @@ func (c *Cache) GetImage(url string) image.Image {
 
 // SaveImage stores an image in the cache, keyed by its URL.
 func (c *Cache) SaveImage(url string, img image.Image) error {
+	return c.saveImageFile(hashURL(url)+".png", img)
+}
+
+// SaveImageData stores a downloaded logo as-is when it is a PNG, JPEG, or
+// GIF, so a small JPEG is not inflated by re-encoding it. Other formats are
+// transcoded to PNG. contentType comes from the response and is sniffed
+// when missing or generic.
+func (c *Cache) SaveImageData(url string, data []byte, contentType string) error {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	ext, ok := imageExtensions[mediaType]
+	if !ok {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(data))
+		ext, ok = imageExtensions[mediaType]
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %w", err)
+	}
+	if !ok {
+		return c.SaveImage(url, img)
+	}
+
+	imageDir := filepath.Join(c.baseDir, ImageSubdir)
+	if err := c.ensureDir(imageDir); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	filename := hashURL(url) + ext
+	if err := os.WriteFile(filepath.Join(imageDir, filename), data, 0644); err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+	c.removeOtherFormats(url, ext)
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, img); err == nil {
+		c.recordSavings(filename, int64(encoded.Len()-len(data)))
+	}
+	return nil
+}
+
+// removeOtherFormats deletes copies of the logo stored under another
+// extension, e.g. a PNG left by an older version.
+func (c *Cache) removeOtherFormats(url, keep string) {
+	for _, ext := range imageExtensions {
+		if ext == keep {
+			continue
+		}
+		path := filepath.Join(c.baseDir, ImageSubdir, hashURL(url)+ext)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Debug().Err(err).Str("file", path).Msg("Failed to remove stale cache file")
+		}
+	}
+}
+
+func (c *Cache) loadSavings() map[string]int64 {
+	savings := make(map[string]int64)
+	data, err := os.ReadFile(filepath.Join(c.baseDir, savingsFile))
+	if err == nil {
+		if err := json.Unmarshal(data, &savings); err != nil {
+			log.Debug().Err(err).Msg("Failed to parse cache savings")
+		}
+	}
+	return savings
+}
+
+// recordSavings notes how many bytes filename saved over a PNG copy.
+// Negative savings (the original is larger) are recorded as zero.
+func (c *Cache) recordSavings(filename string, saved int64) {
+	c.savingsMu.Lock()
+	defer c.savingsMu.Unlock()
+
+	savings := c.loadSavings()
+	savings[filename] = max(saved, 0)
+	data, err := json.Marshal(savings)
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(filepath.Join(c.baseDir, savingsFile), data, 0644); err != nil {
+		log.Debug().Err(err).Msg("Failed to save cache savings")
+	}
+}
+
+// SaveImageVariant stores a scaled rendering of the image, keyed by its URL
+// and the cell dimensions it was scaled for.
+func (c *Cache) SaveImageVariant(url string, cols, rows int, img image.Image) error {
+	return c.saveImageFile(variantFilename(url, cols, rows), img)
+}
+
+func (c *Cache) saveImageFile(filename string, img image.Image) error {
 	imageDir := filepath.Join(c.baseDir, ImageSubdir)
 
 	if err := c.ensureDir(imageDir); err != nil {
 		return fmt.Errorf("failed to create cache directory: %w", err)
 	}
 
-	filename := hashURL(url) + ".png"
 	imagePath := filepath.Join(imageDir, filename)
 
 	file, err := os.Create(imagePath)
@@ -119,6 +282,15 @@ func (c *Cache) SaveImage(url string, img image.Image) error {
 	return nil
 }
 
+// Clear removes all cached images so they are fetched again on next use.
+func (c *Cache) Clear() error {
+	imageDir := filepath.Join(c.baseDir, ImageSubdir)
+	if err := os.RemoveAll(imageDir); err != nil {
+		return fmt.Errorf("failed to clear cache directory: %w", err)
+	}
+	return nil
+}
+
 // CleanExpired removes cache files older than the expiry duration.
 func (c *Cache) CleanExpired() error {
 	imageDir := filepath.Join(c.baseDir, ImageSubdir)
@@ -131,7 +303,7 @@ func (c *Cache) CleanExpired() error {
 		return fmt.Errorf("failed to read cache directory: %w", err)
 	}
 
-	now := time.Now()
+	now := clock.Or(c.clock).Now()
 	var removed, failed int
 	for _, entry := range entries {
 		if entry.IsDir() {
@@ -161,3 +333,134 @@ func (c *Cache) CleanExpired() error {
 
 	return nil
 }
+
+const (
+	CategoryImages       = "images"
+	CategoryVariants     = "variants"
+	CategoryLogs         = "logs"
+	CategoryPlays        = "plays"
+	CategoryTranslations = "translations"
+)
+
+// PlayLogName is the name, less extensions, of the log of every track
+// change that package playlog keeps in the cache directory.
+const PlayLogName = "plays"
+
+// TranslationsFileName is the cache of station translations that package
+// translate keeps in the cache directory.
+const TranslationsFileName = "translations.json"
+
+// category describes a group of cache files for accounting and pruning.
+type category struct {
+	name        string
+	description string
+	subdir      string
+	match       func(filename string) bool
+}
+
+var categories = []category{
+	{CategoryImages, "Station logos", ImageSubdir, func(name string) bool { return !isVariantFile(name) }},
+	{CategoryVariants, "Scaled logos", ImageSubdir, isVariantFile},
+	{CategoryLogs, "Debug logs", "", func(name string) bool { return filepath.Ext(name) == ".log" }},
+	{CategoryPlays, "Now-playing log", "", func(name string) bool {
+		return strings.HasPrefix(name, PlayLogName+".") && filepath.Ext(name) == ".jsonl"
+	}},
+	{CategoryTranslations, "Translations", "", func(name string) bool { return name == TranslationsFileName }},
+}
+
+// variantFilePattern matches the names variantFilename gives scaled logos.
+var variantFilePattern = regexp.MustCompile(`^[0-9a-f]{32}_[0-9]+x[0-9]+\.png$`)
+
+func isVariantFile(name string) bool {
+	return variantFilePattern.MatchString(name)
+}
+
+// CategoryUsage reports the disk usage of one cache category. Saved is how
+// many bytes storing logos in their original format saved over PNG.
+type CategoryUsage struct {
+	Name        string
+	Description string
+	Files       int
+	Bytes       int64
+	Saved       int64
+}
+
+// Dir returns the cache's base directory.
+func (c *Cache) Dir() string {
+	return c.baseDir
+}
+
+// Usage returns per-category disk usage in a stable order.
+func (c *Cache) Usage() ([]CategoryUsage, error) {
+	c.savingsMu.Lock()
+	savings := c.loadSavings()
+	c.savingsMu.Unlock()
+
+	usage := make([]CategoryUsage, 0, len(categories))
+	for _, cat := range categories {
+		u := CategoryUsage{Name: cat.name, Description: cat.description}
+		err := c.walkCategory(cat, func(_ string, info os.FileInfo) {
+			u.Files++
+			u.Bytes += info.Size()
+			u.Saved += savings[info.Name()]
+		})
+		if err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, nil
+}
+
+// Prune removes all files in the named category and returns how many were
+// removed.
+func (c *Cache) Prune(name string) (int, error) {
+	for _, cat := range categories {
+		if cat.name != name {
+			continue
+		}
+		removed := 0
+		var firstErr error
+		err := c.walkCategory(cat, func(path string, _ os.FileInfo) {
+			if err := os.Remove(path); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			removed++
+		})
+		if err != nil {
+			return removed, err
+		}
+		if firstErr != nil {
+			return removed, fmt.Errorf("failed to remove cache file: %w", firstErr)
+		}
+		log.Debug().Str("category", name).Int("removed", removed).Msg("Cache category pruned")
+		return removed, nil
+	}
+	return 0, fmt.Errorf("unknown cache category: %s", name)
+}
+
+func (c *Cache) walkCategory(cat category, fn func(path string, info os.FileInfo)) error {
+	dir := filepath.Join(c.baseDir, cat.subdir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !cat.match(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		fn(filepath.Join(dir, entry.Name()), info)
+	}
+	return nil
+}
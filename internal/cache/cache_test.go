@@ -1,12 +1,16 @@
 package cache
 
 import (
+	"bytes"
 	"image"
 	"image/color"
+	"image/jpeg"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/clock"
 )
 
 func TestHashURL(t *testing.T) {
@@ -97,6 +101,58 @@ func TestSaveAndGetImage(t *testing.T) {
 	}
 }
 
+func TestSaveImageDataKeepsOriginalFormat(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	cache := &Cache{
+		baseDir: tmpDir,
+		expiry:  DefaultExpiry,
+	}
+
+	testURL := "http://example.com/logo.jpg"
+	testImg := createTestImage(120, 120)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImg, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// An older PNG copy should be replaced by the original JPEG.
+	if err := cache.SaveImage(testURL, testImg); err != nil {
+		t.Fatal(err)
+	}
+	// Empty content type: the format is sniffed from the data.
+	if err := cache.SaveImageData(testURL, buf.Bytes(), ""); err != nil {
+		t.Fatalf("SaveImageData() error = %v", err)
+	}
+
+	imageDir := filepath.Join(tmpDir, ImageSubdir)
+	stored, err := os.ReadFile(filepath.Join(imageDir, hashURL(testURL)+".jpg"))
+	if err != nil {
+		t.Fatalf("original JPEG not stored: %v", err)
+	}
+	if !bytes.Equal(stored, buf.Bytes()) {
+		t.Error("stored JPEG differs from the downloaded bytes")
+	}
+	if _, err := os.Stat(filepath.Join(imageDir, hashURL(testURL)+".png")); !os.IsNotExist(err) {
+		t.Error("stale PNG copy should have been removed")
+	}
+
+	img := cache.GetImage(testURL)
+	if img == nil || img.Bounds().Dx() != 120 {
+		t.Fatal("GetImage() should decode the stored JPEG")
+	}
+
+	usage, err := cache.Usage()
+	if err != nil {
+		t.Fatalf("Usage() error = %v", err)
+	}
+	for _, u := range usage {
+		if u.Name == CategoryImages && u.Saved <= 0 {
+			t.Errorf("Usage() images saved = %d, want > 0", u.Saved)
+		}
+	}
+}
+
 func TestGetImageNonExistent(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -114,9 +170,11 @@ func TestGetImageNonExistent(t *testing.T) {
 func TestGetImageExpired(t *testing.T) {
 	tmpDir := t.TempDir()
 
+	fakeClock := clock.NewFake(time.Now())
 	cache := &Cache{
 		baseDir: tmpDir,
-		expiry:  1 * time.Millisecond,
+		expiry:  DefaultExpiry,
+		clock:   fakeClock,
 	}
 
 	testURL := "http://example.com/expired-image.png"
@@ -126,8 +184,11 @@ func TestGetImageExpired(t *testing.T) {
 	if err != nil {
 		t.Fatalf("SaveImage() error = %v", err)
 	}
+	if cache.GetImage(testURL) == nil {
+		t.Fatal("GetImage() for a fresh image should return it")
+	}
 
-	time.Sleep(10 * time.Millisecond)
+	fakeClock.Advance(DefaultExpiry + time.Minute)
 
 	result := cache.GetImage(testURL)
 	if result != nil {
@@ -320,3 +381,146 @@ func TestMultipleImagesSameCache(t *testing.T) {
 		}
 	}
 }
+
+func TestSaveAndGetImageVariant(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	cache := &Cache{
+		baseDir: tmpDir,
+		expiry:  DefaultExpiry,
+	}
+
+	testURL := "http://example.com/variant.png"
+
+	if err := cache.SaveImageVariant(testURL, 24, 12, createTestImage(192, 192)); err != nil {
+		t.Fatalf("SaveImageVariant() error = %v", err)
+	}
+
+	variant := cache.GetImageVariant(testURL, 24, 12)
+	if variant == nil {
+		t.Fatal("GetImageVariant() returned nil, expected image")
+	}
+	if b := variant.Bounds(); b.Dx() != 192 || b.Dy() != 192 {
+		t.Errorf("Variant size = %dx%d, want 192x192", b.Dx(), b.Dy())
+	}
+
+	if cache.GetImageVariant(testURL, 12, 6) != nil {
+		t.Error("GetImageVariant() for other dimensions should return nil")
+	}
+	if cache.GetImage(testURL) != nil {
+		t.Error("GetImage() should not return a variant")
+	}
+}
+
+func TestIsVariantFile(t *testing.T) {
+	hash := hashURL("https://somafm.com/img/groovesalad.png")
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{variantFilename("https://somafm.com/img/groovesalad.png", 20, 10), true},
+		{hash + ".png", false},
+		{hash + "_20x10.png.tmp", false},
+		{"my_logo.png", false},
+		{hash + "_wide.png", false},
+	}
+	for _, tt := range tests {
+		if got := isVariantFile(tt.name); got != tt.want {
+			t.Errorf("isVariantFile(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestClear(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	cache := &Cache{
+		baseDir: tmpDir,
+		expiry:  DefaultExpiry,
+	}
+
+	testURL := "http://example.com/clear.png"
+	if err := cache.SaveImage(testURL, createTestImage(10, 10)); err != nil {
+		t.Fatalf("SaveImage() error = %v", err)
+	}
+
+	if err := cache.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+
+	if cache.GetImage(testURL) != nil {
+		t.Error("GetImage() after Clear() should return nil")
+	}
+
+	if err := cache.Clear(); err != nil {
+		t.Errorf("Clear() on empty cache error = %v", err)
+	}
+}
+
+func TestUsageAndPrune(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	cache := &Cache{
+		baseDir: tmpDir,
+		expiry:  DefaultExpiry,
+	}
+
+	_ = cache.SaveImage("http://example.com/a.png", createTestImage(10, 10))
+	_ = cache.SaveImage("http://example.com/b.png", createTestImage(10, 10))
+	_ = cache.SaveImageVariant("http://example.com/a.png", 26, 12, createTestImage(5, 5))
+	_ = os.WriteFile(filepath.Join(tmpDir, "debug.log"), []byte("log line\n"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "plays.jsonl"), []byte("{}\n"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "plays.1.jsonl"), []byte("{}\n"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, TranslationsFileName), []byte("{}\n"), 0644)
+
+	usage, err := cache.Usage()
+	if err != nil {
+		t.Fatalf("Usage() error = %v", err)
+	}
+
+	files := make(map[string]int)
+	for _, u := range usage {
+		files[u.Name] = u.Files
+		if u.Files > 0 && u.Bytes == 0 {
+			t.Errorf("Usage() %s has %d files but 0 bytes", u.Name, u.Files)
+		}
+	}
+	expected := map[string]int{CategoryImages: 2, CategoryVariants: 1, CategoryLogs: 1, CategoryPlays: 2, CategoryTranslations: 1}
+	for name, want := range expected {
+		if files[name] != want {
+			t.Errorf("Usage() %s files = %d, want %d", name, files[name], want)
+		}
+	}
+
+	removed, err := cache.Prune(CategoryVariants)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Prune() removed %d files, want 1", removed)
+	}
+	if cache.GetImage("http://example.com/a.png") == nil {
+		t.Error("pruning variants should keep original images")
+	}
+
+	if _, err := cache.Prune("bogus"); err == nil {
+		t.Error("Prune() with unknown category should return error")
+	}
+}
+
+func TestUsageEmptyCache(t *testing.T) {
+	cache := &Cache{
+		baseDir: filepath.Join(t.TempDir(), "missing"),
+		expiry:  DefaultExpiry,
+	}
+
+	usage, err := cache.Usage()
+	if err != nil {
+		t.Fatalf("Usage() error = %v", err)
+	}
+	for _, u := range usage {
+		if u.Files != 0 || u.Bytes != 0 {
+			t.Errorf("Usage() %s = %d files, %d bytes, want empty", u.Name, u.Files, u.Bytes)
+		}
+	}
+}
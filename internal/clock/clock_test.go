@@ -0,0 +1,118 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func TestFakeNowAndAdvance(t *testing.T) {
+	c := NewFake(epoch)
+	start := c.Now()
+
+	c.Advance(90 * time.Second)
+	if got := c.Since(start); got != 90*time.Second {
+		t.Errorf("Since() = %v, want 90s", got)
+	}
+	if got := c.Until(start.Add(2 * time.Minute)); got != 30*time.Second {
+		t.Errorf("Until() = %v, want 30s", got)
+	}
+}
+
+func TestFakeTimer(t *testing.T) {
+	c := NewFake(epoch)
+	timer := c.NewTimer(time.Second)
+
+	c.Advance(999 * time.Millisecond)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	c.Advance(time.Millisecond)
+	select {
+	case at := <-timer.C():
+		if !at.Equal(epoch.Add(time.Second)) {
+			t.Errorf("timer fired at %v, want %v", at, epoch.Add(time.Second))
+		}
+	default:
+		t.Fatal("timer did not fire")
+	}
+	if timer.Stop() {
+		t.Error("Stop() on a fired timer = true, want false")
+	}
+}
+
+func TestFakeTickerDropsMissedTicks(t *testing.T) {
+	c := NewFake(epoch)
+	ticker := c.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	c.Advance(3500 * time.Millisecond)
+	if at := <-ticker.C(); !at.Equal(epoch.Add(time.Second)) {
+		t.Errorf("first tick at %v, want %v", at, epoch.Add(time.Second))
+	}
+	select {
+	case <-ticker.C():
+		t.Error("missed ticks should be dropped")
+	default:
+	}
+
+	c.Advance(500 * time.Millisecond)
+	if at := <-ticker.C(); !at.Equal(epoch.Add(4 * time.Second)) {
+		t.Errorf("next tick at %v, want %v", at, epoch.Add(4*time.Second))
+	}
+}
+
+func TestFakeAfterFuncAndStop(t *testing.T) {
+	c := NewFake(epoch)
+	fired := make(chan string, 3)
+
+	c.AfterFunc(2*time.Second, func() { fired <- "late" })
+	stopped := c.AfterFunc(time.Second, func() { fired <- "stopped" })
+	if !stopped.Stop() {
+		t.Error("Stop() on a pending timer = false, want true")
+	}
+
+	c.Advance(time.Second)
+	select {
+	case name := <-fired:
+		t.Fatalf("%s fired early", name)
+	default:
+	}
+
+	c.Advance(time.Second)
+	if name := <-fired; name != "late" {
+		t.Errorf("fired %q, want late", name)
+	}
+	if c.Pending() != 0 {
+		t.Errorf("Pending() = %d, want 0", c.Pending())
+	}
+}
+
+func TestFakeSleep(t *testing.T) {
+	c := NewFake(epoch)
+	done := make(chan struct{})
+	go func() {
+		c.Sleep(time.Minute)
+		close(done)
+	}()
+
+	for c.Pending() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	c.Advance(time.Minute)
+	<-done
+}
+
+func TestOr(t *testing.T) {
+	if Or(nil) != Real {
+		t.Error("Or(nil) should be the real clock")
+	}
+	fake := NewFake(epoch)
+	if Or(fake) != fake {
+		t.Error("Or(c) should return c")
+	}
+}
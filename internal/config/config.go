@@ -1,12 +1,25 @@
 package config
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/dircheck"
+	"github.com/glebovdev/somafm-cli/internal/genre"
+	"github.com/glebovdev/somafm-cli/internal/priority"
+	"github.com/glebovdev/somafm-cli/internal/retitle"
+	"github.com/glebovdev/somafm-cli/internal/station"
 	"gopkg.in/yaml.v3"
 )
 
@@ -27,6 +40,10 @@ const (
 	DefaultVolume  = 70
 	MinVolume      = 0
 	MaxVolume      = 100
+
+	DefaultDeadAirTimeout = 3 * time.Minute
+
+	DefaultRandomExcludeRecent = 3
 )
 
 // ClampVolume ensures volume is within the valid range [0, 100].
@@ -44,7 +61,12 @@ func ClampVolume(volume int) int {
 // go build -ldflags "-X github.com/glebovdev/somafm-cli/internal/config.AppVersion=1.0.0"
 var AppVersion = "dev"
 
+// Theme holds the UI colors. Name is set when the theme came from a preset
+// such as "terminal" and is written back instead of the individual colors.
+// Preset is the palette a color mapping starts from.
 type Theme struct {
+	Name                        string `yaml:"-"`
+	Preset                      string `yaml:"preset,omitempty"`
 	Background                  string `yaml:"background"`
 	Foreground                  string `yaml:"foreground"`
 	Borders                     string `yaml:"borders"`
@@ -60,24 +82,509 @@ type Theme struct {
 	ModalBackground             string `yaml:"modal_background"`
 }
 
+// DeadAir controls what happens when a stream keeps delivering silence.
+// Without a fallback station, playback is stopped and the user is notified.
+type DeadAir struct {
+	Enabled         bool              `yaml:"enabled"`
+	Timeout         time.Duration     `yaml:"timeout"`
+	FallbackStation station.StationID `yaml:"fallback_station"`
+}
+
+// Hooks are shell commands run on application events. The command receives
+// SOMAFM_EVENT, SOMAFM_STATION, and SOMAFM_VOLUME in its environment;
+// on_shutdown also gets SOMAFM_UPTIME_SECONDS.
+type Hooks struct {
+	OnStartup  string `yaml:"on_startup"`
+	OnShutdown string `yaml:"on_shutdown"`
+}
+
+// ListenerID is an optional anonymous identifier sent with stream requests
+// so SomaFM can count a returning listener once. It is random, contains no
+// personal data, and is only sent while Enabled is true.
+type ListenerID struct {
+	Enabled bool   `yaml:"enabled"`
+	ID      string `yaml:"id,omitempty"`
+}
+
+// Endpoints override where SomaFM content is fetched from, e.g. a mirror,
+// a local caching proxy, or a test server. Empty values use SomaFM itself.
+type Endpoints struct {
+	// API serves channels.json, song history, station images, and playlists.
+	API string `yaml:"api"`
+	// Streams replaces the host of stream URLs listed in playlists.
+	Streams string `yaml:"streams"`
+}
+
+// API serves a local HTTP control API, for Stream Deck buttons, scripts,
+// and home automation. It is off unless Listen is set.
+type API struct {
+	// Listen is the address to serve on, e.g. 127.0.0.1:8723. There is no
+	// authentication, so keep it on localhost.
+	Listen string `yaml:"listen"`
+}
+
+// MPD serves a subset of the Music Player Daemon protocol, so MPD clients
+// can control the player. It is off unless Listen is set.
+type MPD struct {
+	// Listen is the address to serve on, e.g. 127.0.0.1:6600.
+	Listen string `yaml:"listen"`
+}
+
+// Alarm starts playback at a time of day while the app is open, fading
+// the volume in over Ramp. --alarm overrides it for one run.
+type Alarm struct {
+	Enabled bool `yaml:"enabled"`
+	// Time is "HH:MM" in local time.
+	Time string `yaml:"time"`
+	// Station defaults to the last station played.
+	Station station.StationID `yaml:"station"`
+	Ramp    time.Duration     `yaml:"ramp"`
+}
+
+// Recording saves the stream to disk while playing.
+type Recording struct {
+	// Dir defaults to ~/Music/SomaFM; a leading ~/ is expanded.
+	Dir string `yaml:"dir"`
+	// SplitTracks writes one "Artist - Title" file per track.
+	SplitTracks bool `yaml:"split_tracks"`
+	// KeepPartial keeps the cut-off first and last tracks when splitting.
+	KeepPartial bool `yaml:"keep_partial"`
+	// MaxSizeGB caps the recordings in Dir; the oldest are deleted to stay
+	// under it, and recording stops when the current one alone would not
+	// fit. 0 means no limit.
+	MaxSizeGB float64 `yaml:"max_size_gb"`
+	// KeepDays deletes recordings older than this. 0 keeps them.
+	KeepDays int `yaml:"keep_days"`
+}
+
+// MaxBytes returns MaxSizeGB in bytes, 0 for no limit.
+func (r Recording) MaxBytes() int64 {
+	return int64(r.MaxSizeGB * (1 << 30))
+}
+
+// MaxAge returns KeepDays as a duration, 0 for no limit.
+func (r Recording) MaxAge() time.Duration {
+	return time.Duration(r.KeepDays) * 24 * time.Hour
+}
+
+// Directory returns the directory recordings are saved to.
+func (r Recording) Directory() (string, error) {
+	if r.Dir != "" && !strings.HasPrefix(r.Dir, "~/") {
+		return r.Dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	if r.Dir != "" {
+		return filepath.Join(home, r.Dir[2:]), nil
+	}
+	return filepath.Join(home, "Music", "SomaFM"), nil
+}
+
+// Audio holds playback processing options.
+type Audio struct {
+	// Normalize evens out loudness between stations with a slowly adapting
+	// gain and a soft limiter.
+	Normalize bool `yaml:"normalize"`
+	// NightMode compresses the dynamic range so loud tracks and station
+	// changes don't startle at a low volume. Toggled with n.
+	NightMode bool `yaml:"night_mode"`
+	// Nice lowers the process's nice value, -20 to 19, so decoding and
+	// output get the CPU first under load. 0 leaves it alone; negative
+	// values need CAP_SYS_NICE or a raised RLIMIT_NICE.
+	Nice int `yaml:"nice"`
+	// Realtime asks for round-robin realtime scheduling, directly where
+	// permitted and otherwise through RealtimeKit. Linux only.
+	Realtime bool `yaml:"realtime"`
+	// PauseOnDisconnect pauses playback when the audio output goes away,
+	// e.g. headphones are unplugged, instead of moving to the speakers.
+	// Linux with PulseAudio or PipeWire only.
+	PauseOnDisconnect bool `yaml:"pause_on_disconnect"`
+}
+
+// DefaultHistoryMinListen is how long a track must play to be recorded.
+const DefaultHistoryMinListen = 30 * time.Second
+
+// DefaultSimilarAfter is how long one station plays before similar
+// stations are suggested.
+const DefaultSimilarAfter = 3 * time.Hour
+
+// History controls the listening history. Tracks that played for less than
+// MinListen, not counting pauses, are not recorded.
+type History struct {
+	MinListen time.Duration `yaml:"min_listen"`
+}
+
+// Storage backends for the listening history and likes.
+const (
+	StorageFile   = "file"
+	StorageSQLite = "sqlite"
+)
+
+// Storage picks where the listening history and likes are kept: JSON Lines
+// files in the config directory, or a SQLite database that keeps the whole
+// history and searches it quickly. SQLite needs a build with -tags sqlite.
+type Storage struct {
+	Backend string `yaml:"backend"`
+}
+
+// Stream preferences for stream_quality and stream_format.
+const (
+	StreamQualityHighest = "highest"
+	StreamQualityHigh    = "high"
+	StreamQualityLow     = "low"
+	StreamFormatMP3      = "mp3"
+	StreamFormatAAC      = "aac"
+)
+
+// Random weighting modes for the random station key.
+const (
+	RandomWeightNone      = "none"
+	RandomWeightFavorites = "favorites"
+	RandomWeightListeners = "listeners"
+)
+
+// Station list orders for sort_by.
+const (
+	SortListeners = "listeners"
+	SortTitle     = "title"
+	SortGenre     = "genre"
+	SortFavorites = "favorites"
+)
+
+// SortOrders lists the station list orders in the order the sort key
+// cycles through them.
+var SortOrders = []string{SortListeners, SortTitle, SortGenre, SortFavorites}
+
+// What number keys 1-9 play, for quick_select.
+const (
+	QuickSelectFavorites = "favorites"
+	QuickSelectRows      = "rows"
+)
+
+// How station logos are drawn, for logos.
+const (
+	LogosAuto   = "auto"
+	LogosBlocks = "blocks"
+	LogosSixel  = "sixel"
+	LogosKitty  = "kitty"
+	LogosITerm2 = "iterm2"
+)
+
+// How station logos are drawn with characters, for cover_art.
+const (
+	CoverArtAuto   = "auto"
+	CoverArtBlocks = "blocks"
+	CoverArtASCII  = "ascii"
+	CoverArtOff    = "off"
+)
+
+// Random tunes how a random station is picked. ExcludeRecent skips the
+// last N stations played; Weight favors favorites or busy stations.
+type Random struct {
+	ExcludeRecent int    `yaml:"exclude_recent"`
+	Weight        string `yaml:"weight"`
+}
+
+// Watch limits and defaults.
+const (
+	MaxWatchStations     = 10
+	MinWatchInterval     = 5 * time.Second
+	DefaultWatchInterval = 10 * time.Second
+)
+
+// Watch polls the songs API for the first few favorites, so their tracks
+// in the station list's Now Playing column are fresher than the channel
+// list refreshed every 30 seconds. No audio streams are opened.
+type Watch struct {
+	// Stations is how many favorites to watch, up to MaxWatchStations. 0
+	// turns watching and the Now Playing column off.
+	Stations int `yaml:"stations"`
+	// Interval between polls, at least MinWatchInterval.
+	Interval time.Duration `yaml:"interval"`
+}
+
+// Branding lets kiosk-style setups (a radio in a shop or office) replace
+// the app name and hide the version and the about links.
+type Branding struct {
+	// Title replaces the app name in the header and terminal title.
+	Title       string `yaml:"title,omitempty"`
+	HideVersion bool   `yaml:"hide_version"`
+	HideLinks   bool   `yaml:"hide_links"`
+}
+
+// DisplayTitle returns the configured title, or the app name.
+func (b Branding) DisplayTitle() string {
+	if strings.TrimSpace(b.Title) != "" {
+		return b.Title
+	}
+	return AppName
+}
+
+// Publish sends playback state to MQTT and/or InfluxDB. Each destination
+// is only used when its broker or URL is set.
+type Publish struct {
+	MQTT     MQTT     `yaml:"mqtt"`
+	InfluxDB InfluxDB `yaml:"influxdb"`
+}
+
+// MQTT publishes retained JSON state to "<topic>/state". With Discovery on,
+// Home Assistant picks up the sensors automatically.
+type MQTT struct {
+	// Broker is e.g. tcp://homeassistant.local:1883 or mqtts://host:8883.
+	Broker          string `yaml:"broker"`
+	Username        string `yaml:"username,omitempty"`
+	Password        string `yaml:"password,omitempty"`
+	Topic           string `yaml:"topic"`
+	Discovery       bool   `yaml:"discovery"`
+	DiscoveryPrefix string `yaml:"discovery_prefix"`
+}
+
+// InfluxDB writes line protocol to a write endpoint such as
+// http://localhost:8086/api/v2/write?org=home&bucket=radio.
+type InfluxDB struct {
+	URL   string `yaml:"url"`
+	Token string `yaml:"token,omitempty"`
+}
+
+// Translation backends for station descriptions and genre names.
+const (
+	TranslateCommand        = "command"
+	TranslateDeepL          = "deepl"
+	TranslateLibreTranslate = "libretranslate"
+)
+
+// Translate shows station descriptions and genre names in another
+// language when t is pressed. It is off unless Backend is set.
+type Translate struct {
+	// Backend is command, deepl, or libretranslate.
+	Backend string `yaml:"backend"`
+	// Language is the target language, e.g. de or pt-BR. Empty uses the
+	// locale from LC_ALL, LC_MESSAGES, or LANG.
+	Language string `yaml:"language,omitempty"`
+	// Command reads the text on stdin and prints the translation. It gets
+	// SOMAFM_LANGUAGE in its environment.
+	Command string `yaml:"command,omitempty"`
+	// URL of the translation server. DeepL defaults to its API for the
+	// key's plan; LibreTranslate needs one, e.g. http://localhost:5000.
+	URL    string `yaml:"url,omitempty"`
+	APIKey string `yaml:"api_key,omitempty"`
+}
+
 type Config struct {
-	Volume      int      `yaml:"volume"`
-	LastStation string   `yaml:"last_station"`
-	Autostart   bool     `yaml:"autostart"`
-	Favorites   []string `yaml:"favorites"`
-	Theme       Theme    `yaml:"theme"`
+	Volume      int                 `yaml:"volume"`
+	LastStation station.StationID   `yaml:"last_station"`
+	Autostart   bool                `yaml:"autostart"`
+	Favorites   []station.StationID `yaml:"favorites"`
+	Theme       Theme               `yaml:"theme"`
+	Branding    Branding            `yaml:"branding"`
+	Random      Random              `yaml:"random"`
+	DeadAir     DeadAir             `yaml:"dead_air"`
+	Hooks       Hooks               `yaml:"hooks"`
+
+	Endpoints Endpoints `yaml:"endpoints"`
+
+	API API `yaml:"api"`
+
+	MPD MPD `yaml:"mpd"`
+
+	Storage Storage `yaml:"storage"`
 
-	saveMu sync.Mutex `yaml:"-"`
+	Publish Publish `yaml:"publish"`
+
+	Recording Recording `yaml:"recording"`
+
+	Alarm Alarm `yaml:"alarm"`
+
+	History History `yaml:"history"`
+
+	Audio Audio `yaml:"audio"`
+
+	Translate Translate `yaml:"translate"`
+
+	// Genres overrides the built-in genre display names and descriptions,
+	// keyed by SomaFM's genre tag, e.g. "idm".
+	Genres map[string]genre.Info `yaml:"genres,omitempty"`
+
+	ListenerID ListenerID `yaml:"listener_id"`
+
+	// Hints shows occasional keybinding tips in the footer.
+	Hints bool `yaml:"hints"`
+
+	// Hyperlinks makes the links in the About, help, and station details
+	// clickable in terminals with OSC 8 support. Turn it off for terminals
+	// that print the escape codes instead.
+	Hyperlinks bool `yaml:"hyperlinks"`
+
+	// TourShown is set once the first-launch tour of the main screen was
+	// finished or skipped.
+	TourShown bool `yaml:"tour_shown"`
+
+	// IdleStop stops playback after this long without input, warning a
+	// minute before. Zero keeps playing indefinitely.
+	IdleStop time.Duration `yaml:"idle_stop"`
+
+	// AutoStopAfter stops playback once it has gone on this long without
+	// a break, warning five minutes before. Zero never stops.
+	AutoStopAfter time.Duration `yaml:"auto_stop_after"`
+
+	// SimilarAfter suggests stations with similar genres once one station
+	// has played this long. Zero never suggests.
+	SimilarAfter time.Duration `yaml:"similar_after"`
+
+	// Pulse briefly brightens the track title when the track changes.
+	Pulse bool `yaml:"pulse"`
+
+	// Visualizer shows a level meter of the playing stream in the player
+	// panel. It's off by default, as it measures every decoded sample.
+	Visualizer bool `yaml:"visualizer"`
+
+	// BufferGraph shows the buffer fill over the last minute in the
+	// footer, with dropouts marked, instead of the five buffer bars.
+	BufferGraph bool `yaml:"buffer_graph"`
+
+	// TitleRules correct ICY track titles, e.g. strip a suffix or swap
+	// artist and title on one station, before anything else sees them.
+	TitleRules []retitle.Rule `yaml:"title_rules,omitempty"`
+
+	// PauseKeywords silence playback while the track title contains any of
+	// them, e.g. "BREAK" on talk channels. Experimental.
+	PauseKeywords []string `yaml:"pause_keywords"`
+
+	// Prefetch keeps connections open to the stations next to the selected
+	// one so < and > start almost instantly. Costs extra bandwidth.
+	Prefetch bool `yaml:"prefetch"`
+
+	// BackgroundBandwidth caps logo downloads and API requests at this
+	// many KB/s so they leave room for the stream on slow links. 0 doesn't
+	// cap them; either way the cap tightens while the stream runs dry and
+	// eases off after a minute without underruns.
+	BackgroundBandwidth int `yaml:"background_bandwidth"`
+
+	// SortBy orders the station list: listeners (busiest first), title,
+	// genre, or favorites (favorites first, then by listeners).
+	SortBy string `yaml:"sort_by"`
+
+	// QuickSelect is what number keys 1-9 play: favorites (in the order of
+	// the favorites list) or rows (the first nine visible).
+	QuickSelect string `yaml:"quick_select"`
+
+	Watch Watch `yaml:"watch"`
+
+	// AmbiguousWidth is how many cells East Asian ambiguous-width
+	// characters such as ○ or ① take: 1, or 2 on terminals set up for CJK.
+	// 0 follows the RUNEWIDTH_EASTASIAN variable.
+	AmbiguousWidth int `yaml:"ambiguous_width,omitempty"`
+
+	// Logos is how station logos are drawn: auto uses the terminal's
+	// graphics protocol if it has one and half blocks otherwise, blocks
+	// always uses half blocks, and sixel, kitty, or iterm2 skip detection.
+	Logos string `yaml:"logos"`
+
+	// CoverArt is how logos are drawn when not with graphics: auto uses
+	// half blocks in truecolor terminals and ASCII art elsewhere, blocks
+	// and ascii always draw that way, ignoring logos, and off hides them.
+	CoverArt string `yaml:"cover_art"`
+
+	// TrackSearch is the web search b opens for the playing track.
+	// {query} is replaced by the artist and title, {artist} and {title}
+	// by either part, all URL-escaped.
+	TrackSearch string `yaml:"track_search"`
+
+	// StreamQuality and StreamFormat choose which playlists are tried first
+	// for every station, e.g. low and aac on metered connections. Empty
+	// values keep the default of the best MP3 stream.
+	StreamQuality string `yaml:"stream_quality,omitempty"`
+	StreamFormat  string `yaml:"stream_format,omitempty"`
+
+	// StreamVariants remembers the playlist picked per station with the
+	// quality selector, e.g. groovesalad: aac-high. Stations without an
+	// entry try every playlist, best first.
+	StreamVariants map[station.StationID]string `yaml:"stream_variants,omitempty"`
+
+	// savedFavorites and favoritesModTime describe the file as last read
+	// or written. Both are guarded by favoritesMu.
+	savedFavorites   []station.StationID `yaml:"-"`
+	favoritesModTime time.Time           `yaml:"-"`
+	safeMode         *safeModeBackup     `yaml:"-"`
+
+	// rejected holds settings Load reset because they were invalid, by
+	// dotted key, so that saving keeps them as written in the file.
+	rejected map[string]rejectedSetting `yaml:"-"`
 }
 
-func GetConfigPath() (string, error) {
+// rejectedSetting is an invalid setting as written in the file, and the
+// default Load used in its place. Saving writes the file's value back
+// unless the setting was changed since.
+type rejectedSetting struct {
+	file  *yaml.Node
+	reset string
+}
+
+// The locks live outside Config because encoding a Config copies it
+// whole, which would read a lock while another goroutine takes it.
+var (
+	// saveMu serializes writes of the config file.
+	saveMu sync.Mutex
+
+	// favoritesMu guards Favorites, which saves and ReloadFavorites merge
+	// with the file from other goroutines.
+	favoritesMu sync.Mutex
+)
+
+// safeModeBackup holds the user's settings while safe mode overrides them,
+// so saving during a safe-mode run doesn't discard them.
+type safeModeBackup struct {
+	theme     Theme
+	hooks     Hooks
+	autostart bool
+}
+
+// DirFlag is the command-line flag that calls SetDir.
+const DirFlag = "--config-dir"
+
+// dirName is how errors and notices refer to the config directory.
+const dirName = "config directory"
+
+// dirOverride replaces ~/.config/somafm when set with SetDir.
+var dirOverride string
+
+// SetDir keeps the config file, history, and likes in dir instead of
+// ~/.config/somafm, e.g. when the home directory isn't writable.
+func SetDir(dir string) {
+	dirOverride = dir
+}
+
+// GetConfigDir returns the directory holding the config file, history,
+// and likes.
+func GetConfigDir() (string, error) {
+	if dirOverride != "" {
+		return dirOverride, nil
+	}
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("failed to get user home directory: %w", err)
 	}
+	return filepath.Join(home, ConfigDir), nil
+}
 
-	configPath := filepath.Join(home, ConfigDir, ConfigFileName)
-	return configPath, nil
+// CheckDir reports a *dircheck.Error when the config directory can't be
+// written, creating it if needed.
+func CheckDir() error {
+	dir, err := GetConfigDir()
+	if err != nil {
+		return err
+	}
+	return dircheck.Writable(dirName, dir, DirFlag)
+}
+
+func GetConfigPath() (string, error) {
+	dir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, ConfigFileName), nil
 }
 
 func Load() (*Config, error) {
@@ -86,7 +593,8 @@ func Load() (*Config, error) {
 		return DefaultConfig(), err
 	}
 
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+	info, err := os.Stat(configPath)
+	if os.IsNotExist(err) {
 		return DefaultConfig(), nil
 	}
 
@@ -99,16 +607,222 @@ func Load() (*Config, error) {
 	if err := yaml.Unmarshal(data, cfg); err != nil {
 		return DefaultConfig(), fmt.Errorf("failed to parse config file: %w", err)
 	}
+	var file yaml.Node
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return DefaultConfig(), fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if info != nil {
+		cfg.favoritesModTime = info.ModTime()
+	}
+	cfg.savedFavorites = append([]station.StationID(nil), cfg.Favorites...)
 
 	cfg.Volume = ClampVolume(cfg.Volume)
+	if cfg.DeadAir.Timeout <= 0 {
+		cfg.DeadAir.Timeout = DefaultDeadAirTimeout
+	}
+
+	// Every check runs, so one bad setting doesn't leave the ones after it
+	// unchecked. A bad setting falls back to its default for this run only.
+	var errs []error
+	var rejected []string
+	invalid := func(key string, err error) {
+		errs = append(errs, err)
+		rejected = append(rejected, key)
+	}
 
-	return cfg, nil
+	if err := cfg.Endpoints.validate(); err != nil {
+		cfg.Endpoints = Endpoints{}
+		invalid("endpoints", err)
+	}
+	if cfg.Random.ExcludeRecent < 0 {
+		cfg.Random.ExcludeRecent = 0
+	}
+	switch cfg.Random.Weight {
+	case RandomWeightNone, RandomWeightFavorites, RandomWeightListeners:
+	case "":
+		cfg.Random.Weight = RandomWeightNone
+	default:
+		weight := cfg.Random.Weight
+		cfg.Random.Weight = RandomWeightNone
+		invalid("random.weight", fmt.Errorf("invalid random.weight %q, want none, favorites, or listeners", weight))
+	}
+	switch cfg.SortBy {
+	case SortListeners, SortTitle, SortGenre, SortFavorites:
+	case "":
+		cfg.SortBy = SortListeners
+	default:
+		sortBy := cfg.SortBy
+		cfg.SortBy = SortListeners
+		invalid("sort_by", fmt.Errorf("invalid sort_by %q, want listeners, title, genre, or favorites", sortBy))
+	}
+	switch cfg.QuickSelect {
+	case QuickSelectFavorites, QuickSelectRows:
+	case "":
+		cfg.QuickSelect = QuickSelectFavorites
+	default:
+		quickSelect := cfg.QuickSelect
+		cfg.QuickSelect = QuickSelectFavorites
+		invalid("quick_select", fmt.Errorf("invalid quick_select %q, want favorites or rows", quickSelect))
+	}
+	switch cfg.Storage.Backend {
+	case StorageFile, StorageSQLite:
+	case "":
+		cfg.Storage.Backend = StorageFile
+	default:
+		backend := cfg.Storage.Backend
+		cfg.Storage.Backend = StorageFile
+		invalid("storage.backend", fmt.Errorf("invalid storage.backend %q, want file or sqlite", backend))
+	}
+	if cfg.Audio.Nice < priority.MinNice || cfg.Audio.Nice > priority.MaxNice {
+		nice := cfg.Audio.Nice
+		cfg.Audio.Nice = 0
+		invalid("audio.nice", fmt.Errorf("invalid audio.nice %d, want %d to %d", nice, priority.MinNice, priority.MaxNice))
+	}
+	if cfg.Alarm.Ramp < 0 {
+		cfg.Alarm.Ramp = 0
+	}
+	if cfg.History.MinListen < 0 {
+		cfg.History.MinListen = 0
+	}
+	if cfg.IdleStop < 0 {
+		cfg.IdleStop = 0
+	}
+	if cfg.AutoStopAfter < 0 {
+		cfg.AutoStopAfter = 0
+	}
+	if cfg.SimilarAfter < 0 {
+		cfg.SimilarAfter = 0
+	}
+	if cfg.Watch.Interval == 0 {
+		cfg.Watch.Interval = DefaultWatchInterval
+	}
+	cfg.Watch.Interval = max(cfg.Watch.Interval, MinWatchInterval)
+	if cfg.Watch.Stations < 0 || cfg.Watch.Stations > MaxWatchStations {
+		stations := cfg.Watch.Stations
+		cfg.Watch.Stations = 0
+		invalid("watch.stations", fmt.Errorf("invalid watch.stations %d, want 0 to %d", stations, MaxWatchStations))
+	}
+	switch cfg.Logos {
+	case LogosAuto, LogosBlocks, LogosSixel, LogosKitty, LogosITerm2:
+	case "":
+		cfg.Logos = LogosAuto
+	default:
+		logos := cfg.Logos
+		cfg.Logos = LogosAuto
+		invalid("logos", fmt.Errorf("invalid logos %q, want auto, blocks, sixel, kitty, or iterm2", logos))
+	}
+	switch cfg.CoverArt {
+	case CoverArtAuto, CoverArtBlocks, CoverArtASCII, CoverArtOff:
+	case "":
+		cfg.CoverArt = CoverArtAuto
+	default:
+		coverArt := cfg.CoverArt
+		cfg.CoverArt = CoverArtAuto
+		invalid("cover_art", fmt.Errorf("invalid cover_art %q, want auto, blocks, ascii, or off", coverArt))
+	}
+	if kbps := cfg.BackgroundBandwidth; kbps < 0 {
+		cfg.BackgroundBandwidth = 0
+		invalid("background_bandwidth", fmt.Errorf("invalid background_bandwidth %d, want 0 or more", kbps))
+	}
+	if width := cfg.AmbiguousWidth; width < 0 || width > 2 {
+		cfg.AmbiguousWidth = 0
+		invalid("ambiguous_width", fmt.Errorf("invalid ambiguous_width %d, want 1 or 2", width))
+	}
+	if size := cfg.Recording.MaxSizeGB; size < 0 {
+		cfg.Recording.MaxSizeGB = 0
+		invalid("recording.max_size_gb", fmt.Errorf("invalid recording.max_size_gb %g, want 0 or more", size))
+	}
+	if days := cfg.Recording.KeepDays; days < 0 {
+		cfg.Recording.KeepDays = 0
+		invalid("recording.keep_days", fmt.Errorf("invalid recording.keep_days %d, want 0 or more", days))
+	}
+	if _, err := time.Parse("15:04", cfg.Alarm.Time); cfg.Alarm.Enabled && err != nil {
+		cfg.Alarm.Enabled = false
+		invalid("alarm.enabled", fmt.Errorf("invalid alarm.time %q, want HH:MM", cfg.Alarm.Time))
+	}
+	if err := cfg.Theme.validate(); err != nil {
+		cfg.Theme = DefaultConfig().Theme
+		invalid("theme", err)
+	}
+	if _, err := retitle.New(cfg.TitleRules); err != nil {
+		cfg.TitleRules = nil
+		invalid("title_rules", err)
+	}
+	switch cfg.StreamQuality {
+	case "", StreamQualityHighest, StreamQualityHigh, StreamQualityLow:
+	default:
+		quality := cfg.StreamQuality
+		cfg.StreamQuality = ""
+		invalid("stream_quality", fmt.Errorf("invalid stream_quality %q, want highest, high, or low", quality))
+	}
+	switch cfg.StreamFormat {
+	case "", StreamFormatMP3, StreamFormatAAC:
+	default:
+		format := cfg.StreamFormat
+		cfg.StreamFormat = ""
+		invalid("stream_format", fmt.Errorf("invalid stream_format %q, want mp3 or aac", format))
+	}
+	if err := validateListen("api.listen", cfg.API.Listen); err != nil {
+		cfg.API.Listen = ""
+		invalid("api.listen", err)
+	}
+	if err := validateListen("mpd.listen", cfg.MPD.Listen); err != nil {
+		cfg.MPD.Listen = ""
+		invalid("mpd.listen", err)
+	}
+	// publish.FromConfig refuses these for the run, so the settings
+	// themselves stay as written.
+	if err := cfg.Publish.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := cfg.Translate.validate(); err != nil {
+		cfg.Translate.Backend = ""
+		invalid("translate.backend", err)
+	}
+	if cfg.TrackSearch == "" {
+		cfg.TrackSearch = DefaultTrackSearch
+	} else if err := validateTrackSearch(cfg.TrackSearch); err != nil {
+		cfg.TrackSearch = DefaultTrackSearch
+		invalid("track_search", err)
+	}
+	if cleared, err := cfg.validateStationIDs(); err != nil {
+		errs = append(errs, err)
+		rejected = append(rejected, cleared...)
+	}
+
+	cfg.keepRejected(&file, rejected)
+	return cfg, errors.Join(errs...)
 }
 
 // Save writes the configuration to disk atomically using temp file + rename.
 func (c *Config) Save() error {
-	c.saveMu.Lock()
-	defer c.saveMu.Unlock()
+	data, err := c.Snapshot()
+	if err != nil {
+		return err
+	}
+	return c.SaveSnapshot(data)
+}
+
+// Snapshot encodes the configuration as it is now, for SaveSnapshot to
+// write later from another goroutine. Call it on the goroutine that changes
+// the settings.
+func (c *Config) Snapshot() ([]byte, error) {
+	favoritesMu.Lock()
+	defer favoritesMu.Unlock()
+
+	data, err := c.marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return data, nil
+}
+
+// SaveSnapshot writes a snapshot taken by Snapshot atomically using temp
+// file + rename. Favorites are the exception: they are guarded by their own
+// lock, so the current ones are written, merged with the file's.
+func (c *Config) SaveSnapshot(snapshot []byte) error {
+	saveMu.Lock()
+	defer saveMu.Unlock()
 
 	configPath, err := GetConfigPath()
 	if err != nil {
@@ -117,17 +831,24 @@ func (c *Config) Save() error {
 
 	configDir := filepath.Dir(configPath)
 	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
+		return dircheck.Classify(dirName, configDir, DirFlag, fmt.Errorf("failed to create config directory: %w", err))
 	}
 
-	data, err := yaml.Marshal(c)
+	// Keep favorites edited in the file since it was last read. A file
+	// that can't be parsed is overwritten, as before.
+	_, _ = c.mergeFileFavorites(configPath)
+
+	favoritesMu.Lock()
+	saved := append([]station.StationID(nil), c.Favorites...)
+	favoritesMu.Unlock()
+	data, err := withFavorites(snapshot, saved)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
 	tmpFile, err := os.CreateTemp(configDir, ".config-*.tmp")
 	if err != nil {
-		return fmt.Errorf("failed to create temp file: %w", err)
+		return dircheck.Classify(dirName, configDir, DirFlag, fmt.Errorf("failed to create temp file: %w", err))
 	}
 	tmpPath := tmpFile.Name()
 
@@ -151,6 +872,93 @@ func (c *Config) Save() error {
 	}
 
 	tmpPath = "" // Prevent defer from removing the final file
+
+	favoritesMu.Lock()
+	c.savedFavorites = saved
+	if info, err := os.Stat(configPath); err == nil {
+		c.favoritesModTime = info.ModTime()
+	}
+	favoritesMu.Unlock()
+	return nil
+}
+
+// validate rejects endpoints that aren't absolute http(s) URLs.
+func (e Endpoints) validate() error {
+	for _, field := range []struct{ name, value string }{
+		{"api", e.API},
+		{"streams", e.Streams},
+	} {
+		name, value := field.name, field.value
+		if value == "" {
+			continue
+		}
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid endpoints.%s URL %q", name, value)
+		}
+	}
+	return nil
+}
+
+// validateListen rejects listen addresses that aren't host:port.
+func validateListen(name, addr string) error {
+	if addr == "" {
+		return nil
+	}
+	_, port, err := net.SplitHostPort(addr)
+	if _, portErr := strconv.Atoi(port); err != nil || portErr != nil {
+		return fmt.Errorf("invalid %s %q, want host:port such as 127.0.0.1:8723", name, addr)
+	}
+	return nil
+}
+
+// Validate rejects broker and write URLs with unsupported schemes.
+func (p Publish) Validate() error {
+	if p.MQTT.Broker != "" {
+		u, err := url.Parse(p.MQTT.Broker)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid publish.mqtt.broker URL %q", p.MQTT.Broker)
+		}
+		switch u.Scheme {
+		case "tcp", "mqtt", "ssl", "tls", "mqtts":
+		default:
+			return fmt.Errorf("invalid publish.mqtt.broker URL %q", p.MQTT.Broker)
+		}
+	}
+	if p.InfluxDB.URL != "" {
+		u, err := url.Parse(p.InfluxDB.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid publish.influxdb.url %q", p.InfluxDB.URL)
+		}
+	}
+	return nil
+}
+
+func (t Translate) validate() error {
+	switch t.Backend {
+	case "":
+		return nil
+	case TranslateCommand:
+		if strings.TrimSpace(t.Command) == "" {
+			return fmt.Errorf("translate.backend is command but translate.command is empty")
+		}
+	case TranslateDeepL:
+		if t.APIKey == "" {
+			return fmt.Errorf("translate.backend is deepl but translate.api_key is empty")
+		}
+	case TranslateLibreTranslate:
+		if t.URL == "" {
+			return fmt.Errorf("translate.backend is libretranslate but translate.url is empty")
+		}
+	default:
+		return fmt.Errorf("invalid translate.backend %q, want command, deepl, or libretranslate", t.Backend)
+	}
+	if t.URL != "" {
+		u, err := url.Parse(t.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid translate.url %q", t.URL)
+		}
+	}
 	return nil
 }
 
@@ -159,8 +967,9 @@ func DefaultConfig() *Config {
 		Volume:      DefaultVolume,
 		LastStation: "",
 		Autostart:   false,
-		Favorites:   []string{},
+		Favorites:   []station.StationID{},
 		Theme: Theme{
+			Name:                        ThemeAuto,
 			Background:                  "#1a1b25",
 			Foreground:                  "#a3aacb",
 			Borders:                     "#40445b",
@@ -175,10 +984,357 @@ func DefaultConfig() *Config {
 			GenreTagBackground:          "#3a3d4f",
 			ModalBackground:             "#282a36",
 		},
+		DeadAir: DeadAir{
+			Enabled: false,
+			Timeout: DefaultDeadAirTimeout,
+		},
+		Random: Random{
+			ExcludeRecent: DefaultRandomExcludeRecent,
+			Weight:        RandomWeightNone,
+		},
+		Alarm: Alarm{
+			Time: "07:30",
+			Ramp: time.Minute,
+		},
+		History: History{
+			MinListen: DefaultHistoryMinListen,
+		},
+		Storage: Storage{
+			Backend: StorageFile,
+		},
+		Publish: Publish{
+			MQTT: MQTT{
+				Topic:           "somafm",
+				Discovery:       true,
+				DiscoveryPrefix: "homeassistant",
+			},
+		},
+		Watch: Watch{
+			Interval: DefaultWatchInterval,
+		},
+		Hints:        true,
+		Hyperlinks:   true,
+		SortBy:       SortListeners,
+		QuickSelect:  QuickSelectFavorites,
+		Logos:        LogosAuto,
+		CoverArt:     CoverArtAuto,
+		TrackSearch:  DefaultTrackSearch,
+		SimilarAfter: DefaultSimilarAfter,
+	}
+}
+
+// EnterSafeMode disables the custom theme, hooks, and autostart for this
+// run. The user's values are kept and still written back by Save.
+func (c *Config) EnterSafeMode() {
+	if c.safeMode != nil {
+		return
+	}
+	c.safeMode = &safeModeBackup{theme: c.Theme, hooks: c.Hooks, autostart: c.Autostart}
+
+	defaults := DefaultConfig()
+	c.Theme = defaults.Theme
+	c.Hooks = Hooks{}
+	c.Autostart = false
+}
+
+// InSafeMode reports whether EnterSafeMode was called.
+func (c *Config) InSafeMode() bool {
+	return c.safeMode != nil
+}
+
+// marshal encodes the config for Save. In safe mode the user's own theme,
+// hooks, and autostart are written in place of the overrides, and settings
+// Load rejected are written as they were in the file, all without touching
+// c, which the UI goroutine keeps using meanwhile.
+func (c *Config) marshal() ([]byte, error) {
+	if c.safeMode == nil && len(c.rejected) == 0 {
+		return yaml.Marshal(c)
+	}
+	var doc yaml.Node
+	if err := doc.Encode(c); err != nil {
+		return nil, err
+	}
+	if c.safeMode != nil {
+		backup := map[string]any{
+			"theme":     c.safeMode.theme,
+			"hooks":     c.safeMode.hooks,
+			"autostart": c.safeMode.autostart,
+		}
+		// A mapping node's content alternates keys and values
+		for i := 0; i+1 < len(doc.Content); i += 2 {
+			value, ok := backup[doc.Content[i].Value]
+			if !ok {
+				continue
+			}
+			if err := doc.Content[i+1].Encode(value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for key, setting := range c.rejected {
+		node := lookupNode(&doc, key)
+		if encodeNode(node) != setting.reset {
+			continue
+		}
+		if node == nil {
+			// Reset to an omitted empty value
+			node = addNode(&doc, key)
+		}
+		*node = *setting.file
+	}
+	return yaml.Marshal(&doc)
+}
+
+// keepRejected remembers the file's values of the rejected keys, so that
+// saving doesn't replace them with the defaults Load used instead.
+func (c *Config) keepRejected(file *yaml.Node, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	var doc yaml.Node
+	if err := doc.Encode(c); err != nil {
+		return
+	}
+	c.rejected = make(map[string]rejectedSetting, len(keys))
+	for _, key := range keys {
+		if fileNode := lookupNode(file, key); fileNode != nil {
+			c.rejected[key] = rejectedSetting{file: fileNode, reset: encodeNode(lookupNode(&doc, key))}
+		}
+	}
+}
+
+// lookupNode returns the value at a dotted key such as "watch.stations" in
+// a decoded or encoded YAML mapping, or nil.
+func lookupNode(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	for _, name := range strings.Split(key, ".") {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == name {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+// addNode adds an empty value at a dotted key to an encoded YAML mapping,
+// creating the mappings on the way, and returns it.
+func addNode(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
 	}
+	for _, name := range strings.Split(key, ".") {
+		next := lookupNode(node, name)
+		if next == nil {
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, next)
+		}
+		node = next
+	}
+	return node
+}
+
+// encodeNode returns node as YAML, or "" for nil, for comparing values.
+func encodeNode(node *yaml.Node) string {
+	if node == nil {
+		return ""
+	}
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// withFavorites replaces the favorites in an encoded config.
+func withFavorites(data []byte, favorites []station.StationID) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return data, nil
+	}
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "favorites" {
+			if err := root.Content[i+1].Encode(favorites); err != nil {
+				return nil, err
+			}
+			return yaml.Marshal(&doc)
+		}
+	}
+	return data, nil
+}
+
+// EnsureListenerID generates a listener ID if one is enabled but not yet set.
+// It reports whether the config changed and should be saved.
+func (c *Config) EnsureListenerID() (bool, error) {
+	if !c.ListenerID.Enabled || c.ListenerID.ID != "" {
+		return false, nil
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return false, fmt.Errorf("failed to generate listener ID: %w", err)
+	}
+	c.ListenerID.ID = hex.EncodeToString(buf)
+	return true, nil
+}
+
+// ActiveListenerID returns the listener ID to send, or an empty string when
+// the feature is disabled.
+func (c *Config) ActiveListenerID() string {
+	if !c.ListenerID.Enabled {
+		return ""
+	}
+	return c.ListenerID.ID
+}
+
+// validateStationIDs drops invalid and duplicate favorites and clears
+// invalid single-station settings, returning the keys of those it cleared.
+// Reading already normalized the IDs, so "GrooveSalad" and "groovesalad"
+// count as one favorite.
+func (c *Config) validateStationIDs() ([]string, error) {
+	var invalid []string
+	favorites := []station.StationID{}
+	seen := make(map[station.StationID]bool)
+	for _, id := range c.Favorites {
+		if err := id.Validate(); err != nil {
+			invalid = append(invalid, fmt.Sprintf("%q", id))
+			continue
+		}
+		if !seen[id] {
+			seen[id] = true
+			favorites = append(favorites, id)
+		}
+	}
+	c.Favorites = favorites
+
+	var cleared []string
+	for _, field := range []struct {
+		name string
+		id   *station.StationID
+	}{
+		{"last_station", &c.LastStation},
+		{"dead_air.fallback_station", &c.DeadAir.FallbackStation},
+		{"alarm.station", &c.Alarm.Station},
+	} {
+		if *field.id == "" {
+			continue
+		}
+		if err := field.id.Validate(); err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s %q", field.name, *field.id))
+			cleared = append(cleared, field.name)
+			*field.id = ""
+		}
+	}
+
+	if len(invalid) > 0 {
+		return cleared, fmt.Errorf("ignoring invalid station IDs: %s", strings.Join(invalid, ", "))
+	}
+	return nil, nil
+}
+
+// ReloadFavorites merges favorites changed in the config file by someone
+// else, such as dotfile sync or another instance, into c. It reports
+// whether c.Favorites changed.
+func (c *Config) ReloadFavorites() (bool, error) {
+	saveMu.Lock()
+	defer saveMu.Unlock()
+
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return false, err
+	}
+	return c.mergeFileFavorites(configPath)
+}
+
+// mergeFileFavorites applies favorites added or removed in the file since
+// it was last read or written, keeping local changes that weren't saved
+// yet. The caller holds saveMu.
+func (c *Config) mergeFileFavorites(configPath string) (bool, error) {
+	info, err := os.Stat(configPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to stat config file: %w", err)
+	}
+
+	favoritesMu.Lock()
+	unchanged := info.ModTime().Equal(c.favoritesModTime)
+	favoritesMu.Unlock()
+	if unchanged {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var file struct {
+		Favorites []station.StationID `yaml:"favorites"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return false, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	favoritesMu.Lock()
+	defer favoritesMu.Unlock()
+	merged := mergeFavorites(c.savedFavorites, c.Favorites, file.Favorites)
+	changed := fmt.Sprint(merged) != fmt.Sprint(c.Favorites)
+	c.Favorites = merged
+	c.savedFavorites = file.Favorites
+	c.favoritesModTime = info.ModTime()
+	return changed, nil
+}
+
+// mergeFavorites is a three-way merge of favorite lists: starting from
+// local, it drops what the file removed since base and appends what the
+// file added, so changes on both sides survive.
+func mergeFavorites(base, local, file []station.StationID) []station.StationID {
+	inBase := make(map[station.StationID]bool)
+	for _, id := range base {
+		inBase[id] = true
+	}
+	inFile := make(map[station.StationID]bool)
+	for _, id := range file {
+		inFile[id] = true
+	}
+
+	merged := []station.StationID{}
+	seen := make(map[station.StationID]bool)
+	for _, id := range local {
+		if (inBase[id] && !inFile[id]) || seen[id] {
+			continue
+		}
+		seen[id] = true
+		merged = append(merged, id)
+	}
+	for _, id := range file {
+		if !inBase[id] && !seen[id] && id.Validate() == nil {
+			seen[id] = true
+			merged = append(merged, id)
+		}
+	}
+	return merged
 }
 
-func (c *Config) IsFavorite(stationID string) bool {
+func (c *Config) IsFavorite(stationID station.StationID) bool {
+	favoritesMu.Lock()
+	defer favoritesMu.Unlock()
 	for _, id := range c.Favorites {
 		if id == stationID {
 			return true
@@ -187,7 +1343,23 @@ func (c *Config) IsFavorite(stationID string) bool {
 	return false
 }
 
-func (c *Config) ToggleFavorite(stationID string) {
+// FavoriteIDs returns a copy of the favorites in config order.
+func (c *Config) FavoriteIDs() []station.StationID {
+	favoritesMu.Lock()
+	defer favoritesMu.Unlock()
+	return append([]station.StationID(nil), c.Favorites...)
+}
+
+// WatchedStations returns the favorites polled for their current track,
+// the first Watch.Stations of them.
+func (c *Config) WatchedStations() []station.StationID {
+	ids := c.FavoriteIDs()
+	return ids[:min(len(ids), c.Watch.Stations)]
+}
+
+func (c *Config) ToggleFavorite(stationID station.StationID) {
+	favoritesMu.Lock()
+	defer favoritesMu.Unlock()
 	for i, id := range c.Favorites {
 		if id == stationID {
 			c.Favorites = append(c.Favorites[:i], c.Favorites[i+1:]...)
@@ -197,8 +1369,72 @@ func (c *Config) ToggleFavorite(stationID string) {
 	c.Favorites = append(c.Favorites, stationID)
 }
 
-func (c *Config) CleanupFavorites(validStationIDs map[string]bool) {
-	cleaned := []string{}
+// PlaylistPreference returns the global stream quality and format.
+func (c *Config) PlaylistPreference() station.PlaylistPreference {
+	return station.PlaylistPreference{Quality: c.StreamQuality, Format: c.StreamFormat}
+}
+
+// StreamVariant returns the playlist variant picked for the station, or "".
+func (c *Config) StreamVariant(stationID station.StationID) string {
+	return c.StreamVariants[stationID]
+}
+
+// DefaultTrackSearch searches Bandcamp, where much of what SomaFM plays
+// can be bought.
+const DefaultTrackSearch = "https://bandcamp.com/search?q={query}"
+
+// TrackSearchURL fills the TrackSearch template in for a track.
+func (c *Config) TrackSearchURL(artist, title string) string {
+	return strings.NewReplacer(
+		"{query}", url.QueryEscape(strings.TrimSpace(artist+" "+title)),
+		"{artist}", url.QueryEscape(artist),
+		"{title}", url.QueryEscape(title),
+	).Replace(c.TrackSearch)
+}
+
+func validateTrackSearch(template string) error {
+	if !strings.Contains(template, "{query}") && !strings.Contains(template, "{artist}") && !strings.Contains(template, "{title}") {
+		return fmt.Errorf("invalid track_search %q, want a URL with {query}, {artist}, or {title}", template)
+	}
+	u, err := url.Parse((&Config{TrackSearch: template}).TrackSearchURL("artist", "title"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid track_search %q, want an http or https URL", template)
+	}
+	return nil
+}
+
+// StreamPlaylistURL returns the URL of the playlist picked for s with the
+// quality selector, or "" to try all of its playlists. That includes a
+// picked variant the station no longer offers.
+func (c *Config) StreamPlaylistURL(s *station.Station) string {
+	variant := c.StreamVariant(s.ID)
+	if variant == "" {
+		return ""
+	}
+	playlist, ok := s.PlaylistByVariant(variant)
+	if !ok {
+		return ""
+	}
+	return playlist.URL
+}
+
+// SetStreamVariant remembers variant for the station. An empty variant
+// goes back to automatic selection.
+func (c *Config) SetStreamVariant(stationID station.StationID, variant string) {
+	if variant == "" {
+		delete(c.StreamVariants, stationID)
+		return
+	}
+	if c.StreamVariants == nil {
+		c.StreamVariants = make(map[station.StationID]string)
+	}
+	c.StreamVariants[stationID] = variant
+}
+
+func (c *Config) CleanupFavorites(validStationIDs map[station.StationID]bool) {
+	favoritesMu.Lock()
+	defer favoritesMu.Unlock()
+	cleaned := []station.StationID{}
 	for _, id := range c.Favorites {
 		if validStationIDs[id] {
 			cleaned = append(cleaned, id)
@@ -208,7 +1444,7 @@ func (c *Config) CleanupFavorites(validStationIDs map[string]bool) {
 }
 
 func GetColor(colorStr string) tcell.Color {
-	if colorStr == "" || colorStr == "default" {
+	if colorStr == "" || colorStr == "default" || colorStr == ColorTransparent {
 		return tcell.ColorDefault
 	}
 	return tcell.GetColor(colorStr)
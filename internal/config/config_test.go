@@ -1,9 +1,17 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/clock"
+	"github.com/glebovdev/somafm-cli/internal/station"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -179,40 +187,209 @@ func TestThemePersistence(t *testing.T) {
 	}
 }
 
+func TestTerminalTheme(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configPath := filepath.Join(tmpDir, ConfigDir, ConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte("volume: 50\ntheme: terminal\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Theme != TerminalTheme() {
+		t.Errorf("Theme = %+v, want terminal preset", cfg.Theme)
+	}
+	if GetColor(cfg.Theme.Background) != tcell.ColorDefault {
+		t.Errorf("terminal background should be the terminal default color")
+	}
+
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "theme: terminal\n") {
+		t.Errorf("saved config should keep the preset name, got:\n%s", data)
+	}
+}
+
+func TestThemePresets(t *testing.T) {
+	for _, name := range ThemePresets {
+		theme, ok := ThemePreset(name)
+		if !ok {
+			t.Fatalf("ThemePreset(%q) not found", name)
+		}
+		if theme.Name != name {
+			t.Errorf("ThemePreset(%q).Name = %q", name, theme.Name)
+		}
+		if err := theme.validate(); err != nil {
+			t.Errorf("preset %q: %v", name, err)
+		}
+	}
+
+	dark, _ := ThemePreset("default")
+	if dark.Name != ThemeDark || dark.Background != DefaultConfig().Theme.Background {
+		t.Errorf("default should be the dark preset, got %+v", dark)
+	}
+	if _, ok := ThemePreset("nord"); ok {
+		t.Error("ThemePreset(nord) should not exist")
+	}
+}
+
+func TestThemePresetMapping(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configPath := filepath.Join(tmpDir, ConfigDir, ConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := "theme:\n  preset: gruvbox\n  highlight: \"#00ff00\"\n"
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	gruvbox, _ := ThemePreset(ThemeGruvbox)
+	if cfg.Theme.Background != gruvbox.Background {
+		t.Errorf("Background = %q, want the gruvbox one", cfg.Theme.Background)
+	}
+	if cfg.Theme.Highlight != "#00ff00" {
+		t.Errorf("Highlight = %q, want the override", cfg.Theme.Highlight)
+	}
+
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	reloaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load() after Save() error = %v", err)
+	}
+	if reloaded.Theme != cfg.Theme {
+		t.Errorf("theme after round trip = %+v, want %+v", reloaded.Theme, cfg.Theme)
+	}
+
+	if err := os.WriteFile(configPath, []byte("theme:\n  preset: nord\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(); err == nil {
+		t.Error("Load() should reject an unknown theme.preset")
+	}
+}
+
+func TestUnknownThemePreset(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configPath := filepath.Join(tmpDir, ConfigDir, ConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte("theme: nord\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err == nil {
+		t.Fatal("Load() should reject an unknown theme preset")
+	}
+	if cfg.Theme != DefaultConfig().Theme {
+		t.Errorf("unknown preset should fall back to the default theme, got %+v", cfg.Theme)
+	}
+}
+
+func TestThemeFromBase16(t *testing.T) {
+	colors := ""
+	for i := 0; i < 16; i++ {
+		colors += fmt.Sprintf("base%02X: \"%02x%02x%02x\"\n", i, i, i, i)
+	}
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"classic", "scheme: \"Test Scheme\"\nauthor: \"someone\"\n" + colors},
+		{"palette", "name: \"Test Scheme\"\nsystem: \"base16\"\npalette:\n" + indent(colors)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			theme, name, err := ThemeFromBase16([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("ThemeFromBase16() error = %v", err)
+			}
+			if name != "Test Scheme" {
+				t.Errorf("name = %q, want %q", name, "Test Scheme")
+			}
+			if theme.Background != "#000000" {
+				t.Errorf("Background = %q, want base00", theme.Background)
+			}
+			if theme.Foreground != "#050505" {
+				t.Errorf("Foreground = %q, want base05", theme.Foreground)
+			}
+			if theme.Highlight != "#090909" {
+				t.Errorf("Highlight = %q, want base09", theme.Highlight)
+			}
+		})
+	}
+
+	if _, _, err := ThemeFromBase16([]byte("base00: \"zzzzzz\"\n")); err == nil {
+		t.Error("ThemeFromBase16() should reject invalid colors")
+	}
+	if _, _, err := ThemeFromBase16([]byte("base00: \"000000\"\n")); err == nil {
+		t.Error("ThemeFromBase16() should reject incomplete schemes")
+	}
+}
+
+func indent(s string) string {
+	return "  " + strings.ReplaceAll(strings.TrimSuffix(s, "\n"), "\n", "\n  ") + "\n"
+}
+
 func TestIsFavorite(t *testing.T) {
 	tests := []struct {
 		name      string
-		favorites []string
-		stationID string
+		favorites []station.StationID
+		stationID station.StationID
 		expected  bool
 	}{
 		{
 			name:      "station is favorite",
-			favorites: []string{"groovesalad", "dronezone", "lush"},
+			favorites: []station.StationID{"groovesalad", "dronezone", "lush"},
 			stationID: "dronezone",
 			expected:  true,
 		},
 		{
 			name:      "station is not favorite",
-			favorites: []string{"groovesalad", "dronezone"},
+			favorites: []station.StationID{"groovesalad", "dronezone"},
 			stationID: "lush",
 			expected:  false,
 		},
 		{
 			name:      "empty favorites list",
-			favorites: []string{},
+			favorites: []station.StationID{},
 			stationID: "groovesalad",
 			expected:  false,
 		},
 		{
 			name:      "first item in list",
-			favorites: []string{"groovesalad", "dronezone"},
+			favorites: []station.StationID{"groovesalad", "dronezone"},
 			stationID: "groovesalad",
 			expected:  true,
 		},
 		{
 			name:      "last item in list",
-			favorites: []string{"groovesalad", "dronezone", "lush"},
+			favorites: []station.StationID{"groovesalad", "dronezone", "lush"},
 			stationID: "lush",
 			expected:  true,
 		},
@@ -238,51 +415,51 @@ func TestIsFavorite(t *testing.T) {
 func TestToggleFavorite(t *testing.T) {
 	tests := []struct {
 		name              string
-		initialFavorites  []string
-		stationID         string
-		expectedFavorites []string
+		initialFavorites  []station.StationID
+		stationID         station.StationID
+		expectedFavorites []station.StationID
 	}{
 		{
 			name:              "add to empty list",
-			initialFavorites:  []string{},
+			initialFavorites:  []station.StationID{},
 			stationID:         "groovesalad",
-			expectedFavorites: []string{"groovesalad"},
+			expectedFavorites: []station.StationID{"groovesalad"},
 		},
 		{
 			name:              "add to existing list",
-			initialFavorites:  []string{"dronezone"},
+			initialFavorites:  []station.StationID{"dronezone"},
 			stationID:         "groovesalad",
-			expectedFavorites: []string{"dronezone", "groovesalad"},
+			expectedFavorites: []station.StationID{"dronezone", "groovesalad"},
 		},
 		{
 			name:              "remove from list",
-			initialFavorites:  []string{"groovesalad", "dronezone", "lush"},
+			initialFavorites:  []station.StationID{"groovesalad", "dronezone", "lush"},
 			stationID:         "dronezone",
-			expectedFavorites: []string{"groovesalad", "lush"},
+			expectedFavorites: []station.StationID{"groovesalad", "lush"},
 		},
 		{
 			name:              "remove first item",
-			initialFavorites:  []string{"groovesalad", "dronezone"},
+			initialFavorites:  []station.StationID{"groovesalad", "dronezone"},
 			stationID:         "groovesalad",
-			expectedFavorites: []string{"dronezone"},
+			expectedFavorites: []station.StationID{"dronezone"},
 		},
 		{
 			name:              "remove last item",
-			initialFavorites:  []string{"groovesalad", "dronezone"},
+			initialFavorites:  []station.StationID{"groovesalad", "dronezone"},
 			stationID:         "dronezone",
-			expectedFavorites: []string{"groovesalad"},
+			expectedFavorites: []station.StationID{"groovesalad"},
 		},
 		{
 			name:              "remove only item",
-			initialFavorites:  []string{"groovesalad"},
+			initialFavorites:  []station.StationID{"groovesalad"},
 			stationID:         "groovesalad",
-			expectedFavorites: []string{},
+			expectedFavorites: []station.StationID{},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cfg := &Config{Favorites: make([]string, len(tt.initialFavorites))}
+			cfg := &Config{Favorites: make([]station.StationID, len(tt.initialFavorites))}
 			copy(cfg.Favorites, tt.initialFavorites)
 
 			cfg.ToggleFavorite(tt.stationID)
@@ -302,7 +479,7 @@ func TestToggleFavorite(t *testing.T) {
 }
 
 func TestToggleFavoriteDoubleToggle(t *testing.T) {
-	cfg := &Config{Favorites: []string{}}
+	cfg := &Config{Favorites: []station.StationID{}}
 
 	cfg.ToggleFavorite("groovesalad")
 	if !cfg.IsFavorite("groovesalad") {
@@ -318,45 +495,45 @@ func TestToggleFavoriteDoubleToggle(t *testing.T) {
 func TestCleanupFavorites(t *testing.T) {
 	tests := []struct {
 		name              string
-		initialFavorites  []string
-		validStationIDs   map[string]bool
-		expectedFavorites []string
+		initialFavorites  []station.StationID
+		validStationIDs   map[station.StationID]bool
+		expectedFavorites []station.StationID
 	}{
 		{
 			name:              "all valid",
-			initialFavorites:  []string{"groovesalad", "dronezone"},
-			validStationIDs:   map[string]bool{"groovesalad": true, "dronezone": true, "lush": true},
-			expectedFavorites: []string{"groovesalad", "dronezone"},
+			initialFavorites:  []station.StationID{"groovesalad", "dronezone"},
+			validStationIDs:   map[station.StationID]bool{"groovesalad": true, "dronezone": true, "lush": true},
+			expectedFavorites: []station.StationID{"groovesalad", "dronezone"},
 		},
 		{
 			name:              "some invalid",
-			initialFavorites:  []string{"groovesalad", "deleted_station", "dronezone"},
-			validStationIDs:   map[string]bool{"groovesalad": true, "dronezone": true},
-			expectedFavorites: []string{"groovesalad", "dronezone"},
+			initialFavorites:  []station.StationID{"groovesalad", "deleted_station", "dronezone"},
+			validStationIDs:   map[station.StationID]bool{"groovesalad": true, "dronezone": true},
+			expectedFavorites: []station.StationID{"groovesalad", "dronezone"},
 		},
 		{
 			name:              "all invalid",
-			initialFavorites:  []string{"deleted1", "deleted2"},
-			validStationIDs:   map[string]bool{"groovesalad": true},
-			expectedFavorites: []string{},
+			initialFavorites:  []station.StationID{"deleted1", "deleted2"},
+			validStationIDs:   map[station.StationID]bool{"groovesalad": true},
+			expectedFavorites: []station.StationID{},
 		},
 		{
 			name:              "empty favorites",
-			initialFavorites:  []string{},
-			validStationIDs:   map[string]bool{"groovesalad": true},
-			expectedFavorites: []string{},
+			initialFavorites:  []station.StationID{},
+			validStationIDs:   map[station.StationID]bool{"groovesalad": true},
+			expectedFavorites: []station.StationID{},
 		},
 		{
 			name:              "empty valid IDs",
-			initialFavorites:  []string{"groovesalad"},
-			validStationIDs:   map[string]bool{},
-			expectedFavorites: []string{},
+			initialFavorites:  []station.StationID{"groovesalad"},
+			validStationIDs:   map[station.StationID]bool{},
+			expectedFavorites: []station.StationID{},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cfg := &Config{Favorites: make([]string, len(tt.initialFavorites))}
+			cfg := &Config{Favorites: make([]station.StationID, len(tt.initialFavorites))}
 			copy(cfg.Favorites, tt.initialFavorites)
 
 			cfg.CleanupFavorites(tt.validStationIDs)
@@ -383,6 +560,7 @@ func TestGetColor(t *testing.T) {
 	}{
 		{"empty string returns default", "", true},
 		{"default keyword returns default", "default", true},
+		{"transparent keyword returns default", "transparent", true},
 		{"named color white", "white", true},
 		{"named color red", "red", true},
 		{"named color darkcyan", "darkcyan", true},
@@ -393,7 +571,7 @@ func TestGetColor(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			result := GetColor(tt.colorStr)
-			if tt.colorStr == "" || tt.colorStr == "default" {
+			if tt.colorStr == "" || tt.colorStr == "default" || tt.colorStr == ColorTransparent {
 				if result != 0 {
 					t.Errorf("GetColor(%q) = %v, want ColorDefault (0)", tt.colorStr, result)
 				}
@@ -408,7 +586,7 @@ func TestFavoritesPersistence(t *testing.T) {
 
 	testCfg := &Config{
 		Volume:    70,
-		Favorites: []string{"groovesalad", "dronezone", "lush"},
+		Favorites: []station.StationID{"groovesalad", "dronezone", "lush"},
 		Theme:     DefaultConfig().Theme,
 	}
 
@@ -426,7 +604,7 @@ func TestFavoritesPersistence(t *testing.T) {
 		t.Fatalf("Load().Favorites has %d items, want 3", len(loadedCfg.Favorites))
 	}
 
-	expected := []string{"groovesalad", "dronezone", "lush"}
+	expected := []station.StationID{"groovesalad", "dronezone", "lush"}
 	for i, fav := range loadedCfg.Favorites {
 		if fav != expected[i] {
 			t.Errorf("Favorites[%d] = %q, want %q", i, fav, expected[i])
@@ -495,3 +673,869 @@ func TestGetConfigPath(t *testing.T) {
 		t.Errorf("GetConfigPath() = %q, want absolute path", path)
 	}
 }
+
+func TestDeadAirDefaults(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if cfg.DeadAir.Enabled {
+		t.Error("DefaultConfig().DeadAir.Enabled = true, want false")
+	}
+	if cfg.DeadAir.Timeout != DefaultDeadAirTimeout {
+		t.Errorf("DefaultConfig().DeadAir.Timeout = %v, want %v", cfg.DeadAir.Timeout, DefaultDeadAirTimeout)
+	}
+}
+
+func TestDeadAirPersistence(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configDir := filepath.Join(tmpDir, ConfigDir)
+	_ = os.MkdirAll(configDir, 0755)
+	configPath := filepath.Join(configDir, ConfigFileName)
+
+	data := []byte("dead_air:\n  enabled: true\n  timeout: 90s\n  fallback_station: groovesalad\n")
+	if err := os.WriteFile(configPath, data, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if !cfg.DeadAir.Enabled {
+		t.Error("Load().DeadAir.Enabled = false, want true")
+	}
+	if cfg.DeadAir.Timeout != 90*time.Second {
+		t.Errorf("Load().DeadAir.Timeout = %v, want 90s", cfg.DeadAir.Timeout)
+	}
+	if cfg.DeadAir.FallbackStation != "groovesalad" {
+		t.Errorf("Load().DeadAir.FallbackStation = %q, want %q", cfg.DeadAir.FallbackStation, "groovesalad")
+	}
+}
+
+func TestDeadAirInvalidTimeout(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configDir := filepath.Join(tmpDir, ConfigDir)
+	_ = os.MkdirAll(configDir, 0755)
+	configPath := filepath.Join(configDir, ConfigFileName)
+
+	_ = os.WriteFile(configPath, []byte("dead_air:\n  enabled: true\n  timeout: 0s\n"), 0644)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.DeadAir.Timeout != DefaultDeadAirTimeout {
+		t.Errorf("Load().DeadAir.Timeout = %v, want default %v", cfg.DeadAir.Timeout, DefaultDeadAirTimeout)
+	}
+}
+
+func TestEnsureListenerID(t *testing.T) {
+	cfg := DefaultConfig()
+
+	changed, err := cfg.EnsureListenerID()
+	if err != nil || changed {
+		t.Fatalf("EnsureListenerID() when disabled = (%v, %v), want (false, nil)", changed, err)
+	}
+	if cfg.ActiveListenerID() != "" {
+		t.Error("ActiveListenerID() should be empty when disabled")
+	}
+
+	cfg.ListenerID.Enabled = true
+	changed, err = cfg.EnsureListenerID()
+	if err != nil || !changed {
+		t.Fatalf("EnsureListenerID() when enabled = (%v, %v), want (true, nil)", changed, err)
+	}
+	id := cfg.ActiveListenerID()
+	if len(id) != 32 {
+		t.Errorf("ActiveListenerID() = %q, want 32 hex characters", id)
+	}
+
+	changed, _ = cfg.EnsureListenerID()
+	if changed || cfg.ActiveListenerID() != id {
+		t.Error("EnsureListenerID() should keep an existing ID")
+	}
+
+	cfg.ListenerID.Enabled = false
+	if cfg.ActiveListenerID() != "" {
+		t.Error("ActiveListenerID() should be empty after disabling")
+	}
+}
+
+func TestSafeModeKeepsUserSettings(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	cfg := DefaultConfig()
+	cfg.Theme.Background = "#000000"
+	cfg.Hooks.OnStartup = "echo hi"
+	cfg.Autostart = true
+
+	cfg.EnterSafeMode()
+	cfg.EnterSafeMode()
+
+	if !cfg.InSafeMode() {
+		t.Fatal("InSafeMode() = false after EnterSafeMode()")
+	}
+	if cfg.Theme.Background != DefaultConfig().Theme.Background {
+		t.Errorf("Theme.Background in safe mode = %q, want default", cfg.Theme.Background)
+	}
+	if cfg.Hooks.OnStartup != "" || cfg.Autostart {
+		t.Error("safe mode should disable hooks and autostart")
+	}
+
+	// A preset picked in safe mode is used but not saved
+	cfg.Volume = 42
+	cfg.Theme.Background = "#ffffff"
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if cfg.Theme.Background != "#ffffff" || cfg.Hooks.OnStartup != "" {
+		t.Error("Save() should not change the settings of the running session")
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Theme.Background != "#000000" || loaded.Hooks.OnStartup != "echo hi" || !loaded.Autostart {
+		t.Error("Save() in safe mode should persist the user's original settings")
+	}
+	if loaded.Volume != 42 {
+		t.Errorf("Load().Volume = %d, want 42", loaded.Volume)
+	}
+}
+
+func TestEndpointsValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr bool
+		wantAPI string
+	}{
+		{"unset", "volume: 50\n", false, ""},
+		{"valid mirror", "endpoints:\n  api: http://localhost:8080\n  streams: https://proxy.lan/soma\n", false, "http://localhost:8080"},
+		{"missing scheme", "endpoints:\n  api: localhost:8080\n", true, ""},
+		{"unsupported scheme", "endpoints:\n  streams: ftp://mirror\n", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			t.Setenv("HOME", tmpDir)
+
+			configDir := filepath.Join(tmpDir, ConfigDir)
+			_ = os.MkdirAll(configDir, 0755)
+			_ = os.WriteFile(filepath.Join(configDir, ConfigFileName), []byte(tt.yaml), 0644)
+
+			cfg, err := Load()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if cfg.Endpoints.API != tt.wantAPI {
+				t.Errorf("Load().Endpoints.API = %q, want %q", cfg.Endpoints.API, tt.wantAPI)
+			}
+		})
+	}
+}
+
+func TestValidateListen(t *testing.T) {
+	tests := []struct {
+		listen  string
+		wantErr bool
+	}{
+		{"", false},
+		{"127.0.0.1:8723", false},
+		{"localhost:8723", false},
+		{"8723", true},
+		{"127.0.0.1:http", true},
+	}
+
+	for _, tt := range tests {
+		err := validateListen("api.listen", tt.listen)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateListen(%q) error = %v, wantErr %v", tt.listen, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPublishValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		yaml       string
+		wantErr    bool
+		wantBroker string
+	}{
+		{"unset", "volume: 50\n", false, ""},
+		{"valid broker", "publish:\n  mqtt:\n    broker: tcp://ha.local:1883\n", false, "tcp://ha.local:1883"},
+		// Invalid settings are reported but kept; publish.FromConfig
+		// refuses them.
+		{"http broker", "publish:\n  mqtt:\n    broker: http://ha.local\n", true, "http://ha.local"},
+		{"bad influx url", "publish:\n  mqtt:\n    broker: tcp://ha.local\n  influxdb:\n    url: localhost:8086\n", true, "tcp://ha.local"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			t.Setenv("HOME", tmpDir)
+
+			configDir := filepath.Join(tmpDir, ConfigDir)
+			_ = os.MkdirAll(configDir, 0755)
+			_ = os.WriteFile(filepath.Join(configDir, ConfigFileName), []byte(tt.yaml), 0644)
+
+			cfg, err := Load()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if cfg.Publish.MQTT.Broker != tt.wantBroker {
+				t.Errorf("Load().Publish.MQTT.Broker = %q, want %q", cfg.Publish.MQTT.Broker, tt.wantBroker)
+			}
+			if cfg.Publish.MQTT.Topic != "somafm" {
+				t.Errorf("Load().Publish.MQTT.Topic = %q, want default", cfg.Publish.MQTT.Topic)
+			}
+		})
+	}
+}
+
+func TestTranslateValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		yaml        string
+		wantErr     bool
+		wantBackend string
+	}{
+		{"unset", "volume: 50\n", false, ""},
+		{"command", "translate:\n  backend: command\n  command: trans -b :de\n", false, TranslateCommand},
+		{"command without command", "translate:\n  backend: command\n", true, ""},
+		{"deepl", "translate:\n  backend: deepl\n  api_key: abc:fx\n", false, TranslateDeepL},
+		{"deepl without key", "translate:\n  backend: deepl\n", true, ""},
+		{"libretranslate", "translate:\n  backend: libretranslate\n  url: http://localhost:5000\n", false, TranslateLibreTranslate},
+		{"libretranslate bad url", "translate:\n  backend: libretranslate\n  url: localhost:5000\n", true, ""},
+		{"unknown backend", "translate:\n  backend: google\n", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			t.Setenv("HOME", tmpDir)
+
+			configDir := filepath.Join(tmpDir, ConfigDir)
+			_ = os.MkdirAll(configDir, 0755)
+			_ = os.WriteFile(filepath.Join(configDir, ConfigFileName), []byte(tt.yaml), 0644)
+
+			cfg, err := Load()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if cfg.Translate.Backend != tt.wantBackend {
+				t.Errorf("Load().Translate.Backend = %q, want %q", cfg.Translate.Backend, tt.wantBackend)
+			}
+		})
+	}
+}
+
+func TestBrandingDisplayTitle(t *testing.T) {
+	if got := (Branding{}).DisplayTitle(); got != AppName {
+		t.Errorf("DisplayTitle() = %q, want %q", got, AppName)
+	}
+	if got := (Branding{Title: "Lobby Radio"}).DisplayTitle(); got != "Lobby Radio" {
+		t.Errorf("DisplayTitle() = %q, want %q", got, "Lobby Radio")
+	}
+}
+
+func TestRandomValidation(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configPath := filepath.Join(tmpDir, ConfigDir, ConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte("random:\n  exclude_recent: -2\n  weight: loudness\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err == nil {
+		t.Fatal("Load() should reject an unknown random.weight")
+	}
+	if cfg.Random.Weight != RandomWeightNone {
+		t.Errorf("Random.Weight = %q, want %q", cfg.Random.Weight, RandomWeightNone)
+	}
+	if cfg.Random.ExcludeRecent != 0 {
+		t.Errorf("Random.ExcludeRecent = %d, want 0", cfg.Random.ExcludeRecent)
+	}
+}
+
+func TestSortByValidation(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configPath := filepath.Join(tmpDir, ConfigDir, ConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte("sort_by: popularity\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err == nil {
+		t.Fatal("Load() should reject an unknown sort_by")
+	}
+	if cfg.SortBy != SortListeners {
+		t.Errorf("SortBy = %q, want %q", cfg.SortBy, SortListeners)
+	}
+}
+
+func TestQuickSelectValidation(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configPath := filepath.Join(tmpDir, ConfigDir, ConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte("quick_select: genres\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err == nil {
+		t.Fatal("Load() should reject an unknown quick_select")
+	}
+	if cfg.QuickSelect != QuickSelectFavorites {
+		t.Errorf("QuickSelect = %q, want %q", cfg.QuickSelect, QuickSelectFavorites)
+	}
+}
+
+func TestLogosValidation(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configPath := filepath.Join(tmpDir, ConfigDir, ConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte("logos: ascii\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err == nil {
+		t.Fatal("Load() should reject unknown logos")
+	}
+	if cfg.Logos != LogosAuto {
+		t.Errorf("Logos = %q, want %q", cfg.Logos, LogosAuto)
+	}
+}
+
+func TestCoverArtValidation(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configPath := filepath.Join(tmpDir, ConfigDir, ConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte("cover_art: ansi\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err == nil {
+		t.Fatal("Load() should reject unknown cover_art")
+	}
+	if cfg.CoverArt != CoverArtAuto {
+		t.Errorf("CoverArt = %q, want %q", cfg.CoverArt, CoverArtAuto)
+	}
+
+	if err := os.WriteFile(configPath, []byte("cover_art: ascii\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.CoverArt != CoverArtASCII {
+		t.Errorf("CoverArt = %q, want %q", cfg.CoverArt, CoverArtASCII)
+	}
+}
+
+func TestBackgroundBandwidthValidation(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configPath := filepath.Join(tmpDir, ConfigDir, ConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte("background_bandwidth: -64\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err == nil {
+		t.Fatal("Load() should reject a negative background_bandwidth")
+	}
+	if cfg.BackgroundBandwidth != 0 {
+		t.Errorf("BackgroundBandwidth = %d, want 0", cfg.BackgroundBandwidth)
+	}
+}
+
+func TestTrackSearchURL(t *testing.T) {
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{DefaultTrackSearch, "https://bandcamp.com/search?q=Bonobo+Kiara"},
+		{"https://www.youtube.com/results?search_query={query}", "https://www.youtube.com/results?search_query=Bonobo+Kiara"},
+		{"https://www.discogs.com/search/?artist={artist}&track={title}", "https://www.discogs.com/search/?artist=Bonobo&track=Kiara"},
+	}
+	for _, tt := range tests {
+		cfg := &Config{TrackSearch: tt.template}
+		if got := cfg.TrackSearchURL("Bonobo", "Kiara"); got != tt.want {
+			t.Errorf("TrackSearchURL(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+
+	cfg := DefaultConfig()
+	if got, want := cfg.TrackSearchURL("", "Station ID & News"), "https://bandcamp.com/search?q=Station+ID+%26+News"; got != want {
+		t.Errorf("TrackSearchURL() without artist = %q, want %q", got, want)
+	}
+}
+
+func TestTrackSearchValidation(t *testing.T) {
+	for _, template := range []string{"https://bandcamp.com/search", "file:///tmp/{query}"} {
+		tmpDir := t.TempDir()
+		t.Setenv("HOME", tmpDir)
+
+		configPath := filepath.Join(tmpDir, ConfigDir, ConfigFileName)
+		if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(configPath, []byte("track_search: "+template+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := Load()
+		if err == nil {
+			t.Errorf("Load() should reject track_search %q", template)
+		}
+		if cfg.TrackSearch != DefaultTrackSearch {
+			t.Errorf("TrackSearch = %q, want the default", cfg.TrackSearch)
+		}
+	}
+}
+
+func TestWatchValidation(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configPath := filepath.Join(tmpDir, ConfigDir, ConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte("watch:\n  stations: 50\n  interval: 1s\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err == nil {
+		t.Fatal("Load() should reject too many watched stations")
+	}
+	if cfg.Watch.Stations != 0 {
+		t.Errorf("Watch.Stations = %d, want 0", cfg.Watch.Stations)
+	}
+	if cfg.Watch.Interval != MinWatchInterval {
+		t.Errorf("Watch.Interval = %v, want %v", cfg.Watch.Interval, MinWatchInterval)
+	}
+}
+
+func TestWatchedStations(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Favorites = []station.StationID{"dronezone", "groovesalad", "defcon"}
+	if got := cfg.WatchedStations(); len(got) != 0 {
+		t.Errorf("WatchedStations() = %v, want none by default", got)
+	}
+	cfg.Watch.Stations = 2
+	if got := cfg.WatchedStations(); !slices.Equal(got, []station.StationID{"dronezone", "groovesalad"}) {
+		t.Errorf("WatchedStations() = %v, want the first two favorites", got)
+	}
+	cfg.Watch.Stations = MaxWatchStations
+	if got := cfg.WatchedStations(); len(got) != 3 {
+		t.Errorf("WatchedStations() = %v, want all favorites", got)
+	}
+}
+
+func TestRecordingQuotaValidation(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configPath := filepath.Join(tmpDir, ConfigDir, ConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte("recording:\n  max_size_gb: 1.5\n  keep_days: -3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err == nil {
+		t.Fatal("Load() should reject a negative keep_days")
+	}
+	if cfg.Recording.KeepDays != 0 {
+		t.Errorf("KeepDays = %d, want 0", cfg.Recording.KeepDays)
+	}
+	if got := cfg.Recording.MaxBytes(); got != 3<<29 {
+		t.Errorf("MaxBytes() = %d, want 1.5 GiB", got)
+	}
+}
+
+func TestAmbiguousWidthValidation(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configPath := filepath.Join(tmpDir, ConfigDir, ConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte("ambiguous_width: 3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err == nil {
+		t.Fatal("Load() should reject ambiguous_width 3")
+	}
+	if cfg.AmbiguousWidth != 0 {
+		t.Errorf("AmbiguousWidth = %d, want 0", cfg.AmbiguousWidth)
+	}
+}
+
+func TestTitleRulesValidation(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configPath := filepath.Join(tmpDir, ConfigDir, ConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte("title_rules:\n  - match: ' \\[SomaFM\\]$'\n  - match: '(unclosed'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err == nil {
+		t.Fatal("Load() should reject a title rule that doesn't compile")
+	}
+	if cfg.TitleRules != nil {
+		t.Errorf("TitleRules = %+v, want none", cfg.TitleRules)
+	}
+}
+
+func TestStorageValidation(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configPath := filepath.Join(tmpDir, ConfigDir, ConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte("storage:\n  backend: postgres\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err == nil {
+		t.Fatal("Load() should reject an unknown storage.backend")
+	}
+	if cfg.Storage.Backend != StorageFile {
+		t.Errorf("Storage.Backend = %q, want %q", cfg.Storage.Backend, StorageFile)
+	}
+}
+
+func TestAudioNiceValidation(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configPath := filepath.Join(tmpDir, ConfigDir, ConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte("audio:\n  nice: -40\n  realtime: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err == nil {
+		t.Fatal("Load() should reject audio.nice below -20")
+	}
+	if cfg.Audio.Nice != 0 {
+		t.Errorf("Audio.Nice = %d, want 0", cfg.Audio.Nice)
+	}
+	if !cfg.Audio.Realtime {
+		t.Error("Audio.Realtime should be kept")
+	}
+}
+
+func TestStationIDsNormalizedOnLoad(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configPath := filepath.Join(tmpDir, ConfigDir, ConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := "last_station: ' GrooveSalad '\nfavorites: [DroneZone, dronezone, 'bad/id', Lush]\n"
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "bad/id") {
+		t.Errorf("Load() error = %v, want it to name the invalid favorite", err)
+	}
+	if cfg.LastStation != "groovesalad" {
+		t.Errorf("LastStation = %q, want %q", cfg.LastStation, "groovesalad")
+	}
+	want := []station.StationID{"dronezone", "lush"}
+	if fmt.Sprint(cfg.Favorites) != fmt.Sprint(want) {
+		t.Errorf("Favorites = %v, want %v", cfg.Favorites, want)
+	}
+	if !cfg.IsFavorite(station.NewStationID("DroneZone")) {
+		t.Error("IsFavorite should match regardless of case")
+	}
+}
+
+func TestLoadRunsEveryCheck(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configDir := filepath.Join(tmpDir, ConfigDir)
+	configPath := filepath.Join(configDir, ConfigFileName)
+	_ = os.MkdirAll(configDir, 0755)
+	data := "endpoints:\n  api: ftp://x\nsort_by: bogus\nwatch:\n  interval: -5s\n" +
+		"last_station: ../etc\nfavorites: [GrooveSalad]\nstream_quality: best\n"
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err == nil {
+		t.Fatal("Load() error = nil, want the invalid settings reported")
+	}
+	for _, want := range []string{"endpoints.api", "sort_by", "stream_quality", "last_station"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error = %v, want it to mention %s", err, want)
+		}
+	}
+	if cfg.Endpoints.API != "" || cfg.SortBy != SortListeners || cfg.StreamQuality != "" || cfg.LastStation != "" {
+		t.Errorf("Load() kept invalid settings: %+v", cfg)
+	}
+	if cfg.Watch.Interval != MinWatchInterval {
+		t.Errorf("Watch.Interval = %v, want %v", cfg.Watch.Interval, MinWatchInterval)
+	}
+	if !cfg.IsFavorite("groovesalad") {
+		t.Errorf("Favorites = %v, want groovesalad normalized", cfg.Favorites)
+	}
+
+	// Saving keeps the file's own values of the settings Load reset,
+	// unless they were changed since.
+	cfg.Volume = 40
+	cfg.SortBy = SortTitle
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	saved, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"api: ftp://x", "sort_by: title", "last_station: ../etc", "stream_quality: best", "volume: 40"} {
+		if !strings.Contains(string(saved), want) {
+			t.Errorf("saved config lacks %q:\n%s", want, saved)
+		}
+	}
+}
+
+func TestStreamPreferenceValidation(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configPath := filepath.Join(tmpDir, ConfigDir, ConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte("stream_quality: low\nstream_format: flac\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err == nil {
+		t.Fatal("Load() should reject an unknown stream_format")
+	}
+	want := station.PlaylistPreference{Quality: StreamQualityLow}
+	if got := cfg.PlaylistPreference(); got != want {
+		t.Errorf("PlaylistPreference() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReloadFavoritesMergesExternalEdits(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configPath := filepath.Join(tmpDir, ConfigDir, ConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte("favorites: [groovesalad, dronezone]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Unsaved local change, then another instance edits the file
+	cfg.ToggleFavorite("lush")
+	if err := os.WriteFile(configPath, []byte("favorites: [groovesalad, defcon]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(configPath, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := cfg.ReloadFavorites()
+	if err != nil {
+		t.Fatalf("ReloadFavorites() error = %v", err)
+	}
+	want := []station.StationID{"groovesalad", "lush", "defcon"}
+	if !changed || fmt.Sprint(cfg.Favorites) != fmt.Sprint(want) {
+		t.Errorf("ReloadFavorites() = %v, Favorites %v; want true, %v", changed, cfg.Favorites, want)
+	}
+	if changed, _ := cfg.ReloadFavorites(); changed {
+		t.Error("ReloadFavorites() without a new edit should report no change")
+	}
+
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	saved, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if fmt.Sprint(saved.Favorites) != fmt.Sprint(want) {
+		t.Errorf("saved Favorites = %v, want %v", saved.Favorites, want)
+	}
+}
+
+func TestSaveKeepsExternallyAddedFavorites(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	cfg := DefaultConfig()
+	cfg.ToggleFavorite("groovesalad")
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	configPath := filepath.Join(tmpDir, ConfigDir, ConfigFileName)
+	if err := os.WriteFile(configPath, []byte("favorites: [groovesalad, dronezone]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(configPath, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Volume = 40
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if !cfg.IsFavorite("dronezone") {
+		t.Errorf("Favorites = %v, want the externally added dronezone kept", cfg.Favorites)
+	}
+}
+
+func TestWriterBatchesSaves(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	configPath := filepath.Join(tmpDir, ConfigDir, ConfigFileName)
+
+	fakeClock := clock.NewFake(time.Now())
+	cfg := DefaultConfig()
+	w := NewWriter(cfg, time.Second, func(err error) { t.Errorf("save error = %v", err) })
+	w.SetClock(fakeClock)
+
+	for volume := 10; volume <= 30; volume += 10 {
+		cfg.Volume = volume
+		w.Request()
+		fakeClock.Advance(500 * time.Millisecond)
+	}
+	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
+		t.Fatal("config saved before the changes settled")
+	}
+
+	fakeClock.Advance(time.Second)
+	deadline := time.Now().Add(2 * time.Second)
+	for _, err := os.Stat(configPath); err != nil && time.Now().Before(deadline); _, err = os.Stat(configPath) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Volume != 30 {
+		t.Errorf("saved Volume = %d, want 30", loaded.Volume)
+	}
+
+	cfg.Volume = 55
+	w.Request()
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if loaded, _ := Load(); loaded.Volume != 55 {
+		t.Errorf("Volume after Flush = %d, want 55", loaded.Volume)
+	}
+	if fakeClock.Pending() != 0 {
+		t.Error("Flush should cancel the scheduled save")
+	}
+}
+
+func TestWriterSnapshotsOnRequest(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	fakeClock := clock.NewFake(time.Now())
+	cfg := DefaultConfig()
+	w := NewWriter(cfg, time.Second, func(err error) { t.Errorf("save error = %v", err) })
+	w.SetClock(fakeClock)
+
+	// Run with -race: the delayed saves fire while the settings change.
+	for i := 0; i < 50; i++ {
+		cfg.Volume = i
+		cfg.LastStation = "groovesalad"
+		cfg.SortBy = SortTitle
+		cfg.TourShown = i%2 == 0
+		w.Request()
+		fakeClock.Advance(time.Second)
+	}
+
+	cfg.Volume = 77
+	w.Request()
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Volume != 77 {
+		t.Errorf("saved Volume = %d, want 77", loaded.Volume)
+	}
+}
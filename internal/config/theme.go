@@ -0,0 +1,276 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Built-in theme presets. ThemeAuto, the default, is ThemeDark or
+// ThemeLight to match the terminal's background; "default" is accepted as
+// another name for ThemeDark.
+const (
+	ThemeAuto         = "auto"
+	ThemeDark         = "dark"
+	ThemeLight        = "light"
+	ThemeSolarized    = "solarized"
+	ThemeGruvbox      = "gruvbox"
+	ThemeHighContrast = "high-contrast"
+)
+
+// ThemeTerminal is the preset that takes all colors from the terminal's own
+// ANSI palette, so the UI follows whatever colorscheme the terminal uses.
+const ThemeTerminal = "terminal"
+
+// ColorTransparent is the background color that lets the terminal's own
+// background, including any translucency, show through.
+const ColorTransparent = "transparent"
+
+// Transparent reports whether the main background is transparent. The
+// header, help, and modal panels then drop their backgrounds too, so no
+// solid box is drawn anywhere.
+func (t Theme) Transparent() bool {
+	return t.Background == ColorTransparent
+}
+
+// TerminalTheme returns the "terminal" preset. Named colors such as "olive"
+// or "gray" are the 16 palette slots, which terminals remap to the active
+// colorscheme; "default" keeps the terminal's own foreground and background.
+func TerminalTheme() Theme {
+	return Theme{
+		Name:                        ThemeTerminal,
+		Background:                  "default",
+		Foreground:                  "default",
+		Borders:                     "gray",
+		Highlight:                   "yellow",
+		MutedVolume:                 "red",
+		HeaderBackground:            "default",
+		StationListHeaderBackground: "navy",
+		StationListHeaderForeground: "white",
+		HelpBackground:              "default",
+		HelpForeground:              "silver",
+		HelpHotkey:                  "yellow",
+		GenreTagBackground:          "gray",
+		ModalBackground:             "default",
+	}
+}
+
+// ThemePresets lists the built-in presets in the order they are offered.
+var ThemePresets = []string{
+	ThemeAuto,
+	ThemeDark,
+	ThemeLight,
+	ThemeSolarized,
+	ThemeGruvbox,
+	ThemeHighContrast,
+	ThemeTerminal,
+}
+
+// ThemePreset returns the named built-in theme. ThemeAuto comes with the
+// dark colors, which the UI swaps for the light ones on a light terminal.
+func ThemePreset(name string) (Theme, bool) {
+	var t Theme
+	switch name {
+	case ThemeAuto:
+		t = DefaultConfig().Theme
+	case ThemeDark, "default":
+		t = DefaultConfig().Theme
+		name = ThemeDark
+	case ThemeLight:
+		t = Theme{
+			Background:                  "#fafafa",
+			Foreground:                  "#383a42",
+			Borders:                     "#a0a1a7",
+			Highlight:                   "#c75200",
+			MutedVolume:                 "#e45649",
+			HeaderBackground:            "#f0e4d7",
+			StationListHeaderBackground: "#e5e5e6",
+			StationListHeaderForeground: "#383a42",
+			HelpBackground:              "#eaeaeb",
+			HelpForeground:              "#696c77",
+			HelpHotkey:                  "#c75200",
+			GenreTagBackground:          "#dcdcdd",
+			ModalBackground:             "#f0f0f1",
+		}
+	case ThemeSolarized:
+		t = Theme{
+			Background:                  "#002b36",
+			Foreground:                  "#839496",
+			Borders:                     "#586e75",
+			Highlight:                   "#b58900",
+			MutedVolume:                 "#dc322f",
+			HeaderBackground:            "#073642",
+			StationListHeaderBackground: "#073642",
+			StationListHeaderForeground: "#93a1a1",
+			HelpBackground:              "#073642",
+			HelpForeground:              "#839496",
+			HelpHotkey:                  "#cb4b16",
+			GenreTagBackground:          "#073642",
+			ModalBackground:             "#073642",
+		}
+	case ThemeGruvbox:
+		t = Theme{
+			Background:                  "#282828",
+			Foreground:                  "#ebdbb2",
+			Borders:                     "#504945",
+			Highlight:                   "#fe8019",
+			MutedVolume:                 "#fb4934",
+			HeaderBackground:            "#3c3836",
+			StationListHeaderBackground: "#3c3836",
+			StationListHeaderForeground: "#d5c4a1",
+			HelpBackground:              "#3c3836",
+			HelpForeground:              "#bdae93",
+			HelpHotkey:                  "#fabd2f",
+			GenreTagBackground:          "#504945",
+			ModalBackground:             "#32302f",
+		}
+	case ThemeHighContrast:
+		t = Theme{
+			Background:                  "#000000",
+			Foreground:                  "#ffffff",
+			Borders:                     "#ffffff",
+			Highlight:                   "#ffff00",
+			MutedVolume:                 "#ff0000",
+			HeaderBackground:            "#000000",
+			StationListHeaderBackground: "#ffffff",
+			StationListHeaderForeground: "#000000",
+			HelpBackground:              "#000000",
+			HelpForeground:              "#ffffff",
+			HelpHotkey:                  "#00ffff",
+			GenreTagBackground:          "#0000ff",
+			ModalBackground:             "#000000",
+		}
+	case ThemeTerminal:
+		return TerminalTheme(), true
+	default:
+		return Theme{}, false
+	}
+	t.Name = name
+	return t, true
+}
+
+// themeFields has Theme's fields without its YAML hooks.
+type themeFields Theme
+
+// UnmarshalYAML accepts either a preset name (theme: terminal) or a mapping
+// of individual colors. In a mapping, preset picks the starting palette and
+// the other keys override it; colors missing from the mapping keep their
+// defaults.
+func (t *Theme) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		if preset, ok := ThemePreset(node.Value); ok {
+			*t = preset
+			if node.Value == "default" {
+				t.Name = ""
+			}
+		} else {
+			t.Name = node.Value
+		}
+		return nil
+	}
+
+	t.Name = ""
+	var base struct {
+		Preset string `yaml:"preset"`
+	}
+	if err := node.Decode(&base); err != nil {
+		return err
+	}
+	if base.Preset != "" {
+		if preset, ok := ThemePreset(base.Preset); ok {
+			*t = preset
+			t.Name = ""
+		}
+		t.Preset = base.Preset
+	}
+	return node.Decode((*themeFields)(t))
+}
+
+// MarshalYAML writes presets back by name, unless their colors were
+// changed since.
+func (t Theme) MarshalYAML() (interface{}, error) {
+	if preset, ok := ThemePreset(t.Name); ok && preset == t {
+		return t.Name, nil
+	}
+	return themeFields(t), nil
+}
+
+// IsAuto reports whether t is the untouched ThemeAuto preset, whose colors
+// are picked at startup.
+func (t Theme) IsAuto() bool {
+	auto, _ := ThemePreset(ThemeAuto)
+	return t == auto
+}
+
+func (t Theme) validate() error {
+	for _, name := range []string{t.Name, t.Preset} {
+		if _, ok := ThemePreset(name); name != "" && !ok {
+			return fmt.Errorf("unknown theme %q, want one of %s or a map of colors",
+				name, strings.Join(ThemePresets, ", "))
+		}
+	}
+	return nil
+}
+
+// ThemeFromBase16 converts a base16 scheme (base00..base0F, either at the
+// top level or under "palette") into a Theme. It also returns the scheme
+// name when the file has one.
+func ThemeFromBase16(data []byte) (Theme, string, error) {
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return Theme{}, "", fmt.Errorf("failed to parse base16 scheme: %w", err)
+	}
+
+	name, _ := raw["scheme"].(string)
+	if n, ok := raw["name"].(string); ok && name == "" {
+		name = n
+	}
+	palette := raw
+	if p, ok := raw["palette"].(map[string]interface{}); ok {
+		palette = p
+	}
+
+	var base [16]string
+	for i := range base {
+		key := fmt.Sprintf("base%02X", i)
+		value, ok := palette[key].(string)
+		if !ok {
+			return Theme{}, "", fmt.Errorf("base16 scheme is missing %s", key)
+		}
+		color, err := base16Color(value)
+		if err != nil {
+			return Theme{}, "", fmt.Errorf("base16 %s: %w", key, err)
+		}
+		base[i] = color
+	}
+
+	return Theme{
+		Background:                  base[0x00],
+		Foreground:                  base[0x05],
+		Borders:                     base[0x03],
+		Highlight:                   base[0x09],
+		MutedVolume:                 base[0x08],
+		HeaderBackground:            base[0x01],
+		StationListHeaderBackground: base[0x02],
+		StationListHeaderForeground: base[0x06],
+		HelpBackground:              base[0x01],
+		HelpForeground:              base[0x04],
+		HelpHotkey:                  base[0x0A],
+		GenreTagBackground:          base[0x02],
+		ModalBackground:             base[0x01],
+	}, name, nil
+}
+
+func base16Color(value string) (string, error) {
+	hexPart := strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if len(hexPart) != 6 {
+		return "", fmt.Errorf("invalid color %q", value)
+	}
+	for _, c := range hexPart {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return "", fmt.Errorf("invalid color %q", value)
+		}
+	}
+	return "#" + strings.ToLower(hexPart), nil
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"sync"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/clock"
+)
+
+// DefaultSaveDelay is how long a Writer waits for further changes before
+// saving, so holding a volume key writes the file once.
+const DefaultSaveDelay = time.Second
+
+// Writer coordinates saves of a Config from many goroutines. Requests made
+// in quick succession are batched into one save, delay after the last of
+// them, and Flush writes anything still pending, e.g. on exit. Each
+// Request takes a snapshot of the settings on the calling goroutine, so the
+// delayed save doesn't read them while they change.
+type Writer struct {
+	cfg     *Config
+	delay   time.Duration
+	onError func(error)
+
+	// flushMu keeps a save from overtaking a newer one.
+	flushMu sync.Mutex
+
+	mu      sync.Mutex
+	clock   clock.Clock
+	timer   clock.Timer
+	pending bool
+	data    []byte
+	err     error
+}
+
+// NewWriter returns a Writer for cfg. onError, if not nil, receives the
+// errors of saves made in the background.
+func NewWriter(cfg *Config, delay time.Duration, onError func(error)) *Writer {
+	return &Writer{cfg: cfg, delay: delay, onError: onError}
+}
+
+// SetClock replaces the wall clock that times the delay.
+func (w *Writer) SetClock(c clock.Clock) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.clock = c
+}
+
+// Request snapshots the config and schedules a save of it, postponing one
+// already scheduled.
+func (w *Writer) Request() {
+	data, err := w.cfg.Snapshot()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pending = true
+	w.data, w.err = data, err
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = clock.Or(w.clock).AfterFunc(w.delay, w.fire)
+}
+
+func (w *Writer) fire() {
+	if err := w.Flush(); err != nil && w.onError != nil {
+		w.onError(err)
+	}
+}
+
+// Flush saves now if a save is pending and cancels the scheduled one.
+func (w *Writer) Flush() error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	pending, data, err := w.pending, w.data, w.err
+	w.pending, w.data, w.err = false, nil, nil
+	w.mu.Unlock()
+
+	if !pending {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return w.cfg.SaveSnapshot(data)
+}
+
+// Pending reports whether a save is scheduled but not yet written.
+func (w *Writer) Pending() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.pending
+}
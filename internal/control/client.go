@@ -0,0 +1,71 @@
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/publish"
+)
+
+// Commands understood on the control socket. Each is sent as one line,
+// with arguments separated by spaces.
+const (
+	CommandPlay   = "play"
+	CommandNext   = "next"
+	CommandStop   = "stop"
+	CommandPause  = "pause"
+	CommandVolume = "volume"
+	CommandStatus = "status"
+)
+
+// Commands lists every command, for usage messages.
+var Commands = []string{CommandPlay, CommandNext, CommandStop, CommandPause, CommandVolume, CommandStatus}
+
+// Every reply is one line starting with one of these, then a space and the
+// result or error message.
+const (
+	okReply  = "OK"
+	errReply = "ERR"
+)
+
+// Send connects to the instance at path, sends one command, and returns its
+// reply. An "ERR" reply is returned as an error.
+func Send(path, command string) (string, error) {
+	conn, err := net.DialTimeout("unix", path, dialTimeout)
+	if err != nil {
+		return "", fmt.Errorf("no instance listening on %s: %w", path, err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(dialTimeout))
+
+	if _, err := fmt.Fprintln(conn, command); err != nil {
+		return "", err
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read reply: %w", err)
+	}
+	reply = strings.TrimSpace(reply)
+	if msg, ok := strings.CutPrefix(reply, errReply+" "); ok {
+		return "", errors.New(msg)
+	}
+	return strings.TrimSpace(strings.TrimPrefix(reply, okReply)), nil
+}
+
+// Status asks the instance at path what it is playing.
+func Status(path string) (publish.Event, error) {
+	var e publish.Event
+	reply, err := Send(path, CommandStatus)
+	if err != nil {
+		return e, err
+	}
+	if err := json.Unmarshal([]byte(reply), &e); err != nil {
+		return e, fmt.Errorf("invalid status reply: %w", err)
+	}
+	return e, nil
+}
@@ -0,0 +1,110 @@
+// Package control lets `somafm ctl` drive a running instance through
+// line-based commands on a local socket. The instance is either the TUI or
+// a Controller, which runs the player headless for `somafm --daemon`.
+package control
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/glebovdev/somafm-cli/internal/publish"
+	"github.com/glebovdev/somafm-cli/internal/service"
+	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/rs/zerolog/log"
+)
+
+// Controller wires the player, station list, and config together the way
+// the TUI does, so they can be used headless. It is the daemon's Target.
+type Controller struct {
+	player   *player.Player
+	stations *service.StationService
+	config   *config.Config
+	mu       sync.Mutex // Serializes commands from concurrent clients
+}
+
+// NewController returns a controller for p. The station list must already
+// be loaded into stations.
+func NewController(p *player.Player, stations *service.StationService, cfg *config.Config) *Controller {
+	p.SetVolume(cfg.Volume)
+	p.SetPauseKeywords(cfg.PauseKeywords)
+	p.SetListenerID(cfg.ActiveListenerID())
+	return &Controller{player: p, stations: stations, config: cfg}
+}
+
+// Play starts the station with the given ID, replacing whatever is playing,
+// and remembers it as the last station.
+func (c *Controller) Play(id station.StationID) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.stations.GetStation(c.stations.FindIndexByID(id))
+	if s == nil {
+		return fmt.Errorf("unknown station %q", id)
+	}
+	c.config.LastStation = s.ID
+
+	playlistURL := c.config.StreamPlaylistURL(s)
+	go func() {
+		log.Info().Msgf("Playing %s (%s)", s.Title, s.ID)
+		err := c.player.PlayPlaylist(s, playlistURL)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			log.Error().Err(err).Msgf("Failed to play %s", s.ID)
+		}
+	}()
+	return nil
+}
+
+// Next plays the station after the current one, in listener order, and
+// returns its ID.
+func (c *Controller) Next() (station.StationID, error) {
+	current := c.config.LastStation
+	if s := c.player.GetCurrentStation(); s != nil {
+		current = s.ID
+	}
+	count := c.stations.StationCount()
+	if count == 0 {
+		return "", errors.New("no stations available")
+	}
+
+	next := c.stations.GetStation((c.stations.FindIndexByID(current) + 1) % count)
+	return next.ID, c.Play(next.ID)
+}
+
+// Stop ends playback.
+func (c *Controller) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.player.Stop()
+}
+
+// TogglePause pauses or resumes playback and reports whether it is now
+// paused.
+func (c *Controller) TogglePause() (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.player.IsPlaying() {
+		return false, errors.New("nothing is playing")
+	}
+	c.player.TogglePause()
+	return c.player.IsPaused(), nil
+}
+
+// SetVolume sets the volume, clamped to 0-100, and returns it.
+func (c *Controller) SetVolume(percent int) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	percent = config.ClampVolume(percent)
+	c.player.SetVolume(percent)
+	c.config.Volume = percent
+	return percent
+}
+
+// Status describes the current playback.
+func (c *Controller) Status() publish.Event {
+	return publish.Snapshot(c.player)
+}
@@ -0,0 +1,198 @@
+package control
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/glebovdev/somafm-cli/internal/api"
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/glebovdev/somafm-cli/internal/service"
+)
+
+func newTestController(t *testing.T) *Controller {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"channels":[{"id":"groovesalad","title":"Groove Salad","listeners":"100"}]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	stations := service.NewStationService(api.NewSomaFMClientWithBaseURL(server.URL), service.CacheDisabled)
+	if _, err := stations.GetStations(context.Background()); err != nil {
+		t.Fatalf("GetStations() error = %v", err)
+	}
+	return NewController(player.NewPlayer(), stations, config.DefaultConfig())
+}
+
+func TestHandleCommands(t *testing.T) {
+	c := newTestController(t)
+	s := NewServer(c)
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"volume 40", "OK volume 40"},
+		{"volume +5", "OK volume 45"},
+		{"volume -60", "OK volume 0"},
+		{"volume loud", `ERR invalid volume "loud"`},
+		{"pause", "ERR nothing is playing"},
+		{"play nosuch", `ERR unknown station "nosuch"`},
+		{"play", "ERR usage: play <station>"},
+		{"next", "OK playing groovesalad"},
+		{"stop", "OK stopped"},
+		{"rewind", `ERR unknown command "rewind", want play, next, stop, pause, volume, status`},
+	}
+	for _, tt := range tests {
+		if got := s.Handle(tt.command); got != tt.want {
+			t.Errorf("Handle(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+	if c.config.Volume != 0 {
+		t.Errorf("config volume = %d, want 0", c.config.Volume)
+	}
+}
+
+func TestServeAnswersSend(t *testing.T) {
+	c := newTestController(t)
+	path := filepath.Join(t.TempDir(), SocketName)
+	ln, err := Listen(path)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- NewServer(c).Serve(ctx, ln) }()
+
+	if _, err := Listen(path); err == nil {
+		t.Error("Listen() on a live socket succeeded, want error")
+	}
+
+	status, err := Status(path)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if status.State != "idle" || status.Volume != config.DefaultVolume {
+		t.Errorf("Status() = %+v, want idle at the default volume", status)
+	}
+	if _, err := Send(path, "play nosuch"); err == nil || err.Error() != `unknown station "nosuch"` {
+		t.Errorf("Send(play nosuch) error = %v, want unknown station", err)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Serve() error = %v", err)
+	}
+}
+
+func TestListenKeepsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), SocketName)
+	if err := os.WriteFile(path, []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen(path); err == nil {
+		t.Error("Listen() over a regular file succeeded, want error")
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "notes" {
+		t.Errorf("file at the socket path = %q, %v, want it untouched", data, err)
+	}
+
+	// A stale socket is replaced, and the new one is private
+	_ = os.Remove(path)
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	ln, err := Listen(path)
+	if err != nil {
+		t.Fatalf("Listen() over a stale socket error = %v", err)
+	}
+	if info, err := os.Stat(path); err != nil {
+		t.Errorf("Stat() error = %v", err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Errorf("socket mode = %v, want 0600", info.Mode().Perm())
+	}
+	ln.Close()
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Error("Close() left the socket behind")
+	}
+}
+
+func TestHTTPHandler(t *testing.T) {
+	server := httptest.NewServer(NewHTTPHandler(newTestController(t)))
+	defer server.Close()
+
+	tests := []struct {
+		method, path string
+		wantStatus   int
+		wantBody     string
+	}{
+		{http.MethodGet, "/status", http.StatusOK, `"state":"idle"`},
+		{http.MethodPost, "/volume?level=40", http.StatusOK, `{"volume":40}`},
+		{http.MethodPost, "/volume?level=%2B5", http.StatusOK, `{"volume":45}`},
+		{http.MethodPost, "/volume?level=+5", http.StatusOK, `{"volume":50}`},
+		{http.MethodPost, "/volume", http.StatusBadRequest, `"error"`},
+		{http.MethodPost, "/pause", http.StatusConflict, `{"error":"nothing is playing"}`},
+		{http.MethodPost, "/play/nosuch", http.StatusNotFound, `{"error":"unknown station \"nosuch\""}`},
+		{http.MethodGet, "/pause", http.StatusMethodNotAllowed, ""},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, server.URL+tt.path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", tt.method, tt.path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.wantStatus || !strings.Contains(string(body), tt.wantBody) {
+			t.Errorf("%s %s = %d %s, want %d containing %s", tt.method, tt.path, resp.StatusCode, body, tt.wantStatus, tt.wantBody)
+		}
+	}
+}
+
+func TestHTTPHandlerRefusesBrowsers(t *testing.T) {
+	server := httptest.NewServer(NewHTTPHandler(newTestController(t)))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		origin string
+		host   string
+		want   int
+	}{
+		{"script", "", "", http.StatusOK},
+		{"localhost", "", "localhost:8723", http.StatusOK},
+		{"ipv6 loopback", "", "[::1]:8723", http.StatusOK},
+		{"web page", "https://example.com", "", http.StatusForbidden},
+		// As a page would send after rebinding its name to 127.0.0.1
+		{"rebound name", "", "example.com:8723", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/stop", nil)
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		if tt.host != "" {
+			req.Host = tt.host
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s: POST /stop = %d, want %d", tt.name, resp.StatusCode, tt.want)
+		}
+	}
+}
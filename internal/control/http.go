@@ -0,0 +1,157 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/rs/zerolog/log"
+)
+
+// httpShutdownTimeout bounds how long requests in flight may take to finish
+// when the API stops.
+const httpShutdownTimeout = 2 * time.Second
+
+// NewHTTPHandler returns the HTTP control API for t. Every response is
+// JSON; failures are {"error": "..."} with a 4xx status.
+//
+//	GET  /status           Current playback, as from `somafm ctl status --json`
+//	POST /play/{station}   Play a station
+//	POST /next             Play the next station
+//	POST /pause            Toggle pause
+//	POST /stop             Stop playback
+//	POST /volume?level=N   Set the volume, 0-100, or step it with +N / -N
+//
+// Requests from web pages, which carry an Origin header, are refused, as
+// are requests for a Host other than localhost or a loopback address, so a
+// page can't control the player through the user's browser, even by DNS
+// rebinding.
+func NewHTTPHandler(t Target) http.Handler {
+	return refuseBrowsers(newHTTPMux(t), true)
+}
+
+// refuseBrowsers rejects requests with an Origin header and, with
+// loopbackOnly, requests naming any Host but a loopback one.
+func refuseBrowsers(next http.Handler, loopbackOnly bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" {
+			log.Warn().Str("origin", origin).Msgf("Refused HTTP control request for %s from a web page", r.URL.Path)
+			writeError(w, http.StatusForbidden, errors.New("requests from web pages are not allowed"))
+			return
+		}
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = strings.Trim(r.Host, "[]")
+		}
+		if loopbackOnly && !isLoopback(host) {
+			log.Warn().Str("host", r.Host).Msgf("Refused HTTP control request for %s with a foreign Host", r.URL.Path)
+			writeError(w, http.StatusForbidden, fmt.Errorf("unexpected host %q", r.Host))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func newHTTPMux(t Target) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, t.Status())
+	})
+	mux.HandleFunc("POST /play/{station}", func(w http.ResponseWriter, r *http.Request) {
+		id, err := station.ParseStationID(r.PathValue("station"))
+		if err == nil {
+			err = t.Play(id)
+		}
+		if err != nil {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"playing": id.String()})
+	})
+	mux.HandleFunc("POST /next", func(w http.ResponseWriter, _ *http.Request) {
+		id, err := t.Next()
+		if err != nil {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"playing": id.String()})
+	})
+	mux.HandleFunc("POST /pause", func(w http.ResponseWriter, _ *http.Request) {
+		paused, err := t.TogglePause()
+		if err != nil {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]bool{"paused": paused})
+	})
+	mux.HandleFunc("POST /stop", func(w http.ResponseWriter, _ *http.Request) {
+		t.Stop()
+		writeJSON(w, http.StatusOK, t.Status())
+	})
+	mux.HandleFunc("POST /volume", func(w http.ResponseWriter, r *http.Request) {
+		level := r.URL.Query().Get("level")
+		if level == "" {
+			writeError(w, http.StatusBadRequest, errors.New("missing level, e.g. /volume?level=40 or level=%2B5"))
+			return
+		}
+		// A literal + in a query string decodes to a space
+		n, err := parseVolume(strings.Replace(level, " ", "+", 1), t)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]int{"volume": t.SetVolume(n)})
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// ServeHTTP serves the HTTP control API for t on addr until ctx is done.
+func ServeHTTP(ctx context.Context, addr string, t Target) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	// Reached from other machines, the API may be addressed by any name
+	host, _, _ := net.SplitHostPort(addr)
+	loopback := isLoopback(host)
+	if !loopback {
+		log.Warn().Msgf("HTTP control API on %s is reachable from other machines and has no authentication", addr)
+	}
+
+	server := &http.Server{Handler: refuseBrowsers(newHTTPMux(t), loopback), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
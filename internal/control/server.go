@@ -0,0 +1,216 @@
+package control
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/publish"
+	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/rs/zerolog/log"
+)
+
+// SocketName is the file name of the control socket.
+const SocketName = "somafm.sock"
+
+// dialTimeout bounds how long a client waits for the daemon.
+const dialTimeout = 5 * time.Second
+
+// DefaultSocketPath returns where the daemon listens: in XDG_RUNTIME_DIR
+// when set, otherwise in the temp directory, named after the user so that
+// users on a shared machine don't collide. Windows 10 and later support
+// these sockets too.
+func DefaultSocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, SocketName)
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("somafm-%d.sock", os.Getuid()))
+}
+
+// Listen opens the control socket at path. A stale socket left behind by a
+// daemon that crashed is replaced, but one that still answers is not, and
+// neither is a file that isn't a socket.
+func Listen(path string) (net.Listener, error) {
+	if conn, err := net.DialTimeout("unix", path, dialTimeout); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("another daemon is listening on %s", path)
+	}
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode().Type() != os.ModeSocket {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		_ = os.Remove(path)
+	}
+
+	// Bind in a private directory and move the socket into place once
+	// restricted, so no one can connect while it has the umask's mode
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".somafm-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	defer os.RemoveAll(dir)
+	bound := filepath.Join(dir, SocketName)
+
+	ln, err := net.Listen("unix", bound)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	// Closing removes the socket at path rather than where it was bound
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Chmod(bound, 0600); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("failed to restrict %s: %w", path, err)
+	}
+	if err := os.Rename(bound, path); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	return &socketListener{Listener: ln, path: path}, nil
+}
+
+// socketListener removes its socket file when closed.
+type socketListener struct {
+	net.Listener
+	path string
+}
+
+func (l *socketListener) Close() error {
+	err := l.Listener.Close()
+	_ = os.Remove(l.path)
+	return err
+}
+
+// Target is what the control socket drives: the headless Controller, or a
+// running TUI.
+type Target interface {
+	Play(id station.StationID) error
+	Next() (station.StationID, error)
+	Stop()
+	TogglePause() (paused bool, err error)
+	SetVolume(percent int) int
+	Status() publish.Event
+}
+
+// Server answers control commands for a Target.
+type Server struct {
+	target Target
+}
+
+// NewServer returns a server that drives t.
+func NewServer(t Target) *Server {
+	return &Server{target: t}
+}
+
+// Serve answers commands on ln until ctx is done. Each line is one command
+// and gets a one-line reply starting with "OK" or "ERR".
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		log.Debug().Msgf("Control command: %s", line)
+		if _, err := fmt.Fprintln(conn, s.Handle(line)); err != nil {
+			return
+		}
+	}
+}
+
+// Handle runs one command line and returns the reply.
+func (s *Server) Handle(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return errReply + " empty command"
+	}
+	args := fields[1:]
+
+	switch strings.ToLower(fields[0]) {
+	case CommandPlay:
+		if len(args) != 1 {
+			return errReply + " usage: play <station>"
+		}
+		id, err := station.ParseStationID(args[0])
+		if err != nil {
+			return errReply + " " + err.Error()
+		}
+		if err := s.target.Play(id); err != nil {
+			return errReply + " " + err.Error()
+		}
+		return okReply + " playing " + id.String()
+	case CommandNext:
+		id, err := s.target.Next()
+		if err != nil {
+			return errReply + " " + err.Error()
+		}
+		return okReply + " playing " + id.String()
+	case CommandStop:
+		s.target.Stop()
+		return okReply + " stopped"
+	case CommandPause:
+		paused, err := s.target.TogglePause()
+		if err != nil {
+			return errReply + " " + err.Error()
+		}
+		if paused {
+			return okReply + " paused"
+		}
+		return okReply + " resumed"
+	case CommandVolume:
+		if len(args) != 1 {
+			return errReply + " usage: volume <0-100|+N|-N>"
+		}
+		n, err := parseVolume(args[0], s.target)
+		if err != nil {
+			return errReply + " " + err.Error()
+		}
+		return fmt.Sprintf("%s volume %d", okReply, s.target.SetVolume(n))
+	case CommandStatus:
+		data, err := json.Marshal(s.target.Status())
+		if err != nil {
+			return errReply + " " + err.Error()
+		}
+		return okReply + " " + string(data)
+	default:
+		return fmt.Sprintf("%s unknown command %q, want %s", errReply, fields[0], strings.Join(Commands, ", "))
+	}
+}
+
+// parseVolume reads an absolute volume such as "40", or a step relative to
+// t's current volume such as "+5" or "-10".
+func parseVolume(arg string, t Target) (int, error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, fmt.Errorf("invalid volume %q", arg)
+	}
+	if strings.HasPrefix(arg, "+") || strings.HasPrefix(arg, "-") {
+		n += t.Status().Volume
+	}
+	return n, nil
+}
@@ -0,0 +1,129 @@
+// Package dircheck tells whether the directories the app writes to are
+// usable and, when they aren't, explains what's wrong and how to fix it.
+// NFS homes with root squashing, sandboxes, and directories created by
+// sudo otherwise only show up as warnings in the debug log.
+package dircheck
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// Error describes a directory the app can't write to.
+type Error struct {
+	// Name is what the directory holds, e.g. "config directory".
+	Name string
+	Path string
+	// Flag picks another directory, e.g. "--config-dir".
+	Flag string
+	// Owner names the owner of Path, or of the parent it would be created
+	// in, when that is someone else, e.g. "root"; empty otherwise.
+	Owner string
+	Err   error
+
+	// fixable is Path or the parent it would be created in, when that is
+	// in the home directory and so the user's to fix; empty otherwise.
+	fixable string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s %s is not writable: %s", e.Name, e.Path, e.reason())
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) reason() string {
+	switch {
+	case errors.Is(e.Err, syscall.EROFS):
+		return "read-only file system"
+	case e.Owner != "":
+		return "owned by " + e.Owner
+	default:
+		return "permission denied"
+	}
+}
+
+// Advice says how to fix the problem: which permission is needed and
+// which flag moves the directory elsewhere.
+func (e *Error) Advice() string {
+	switch {
+	case errors.Is(e.Err, syscall.EROFS) || e.fixable == "":
+		return fmt.Sprintf("Use %s to pick a writable directory.", e.Flag)
+	case e.Owner != "":
+		return fmt.Sprintf("Take it back with sudo chown -R $USER %s, or use %s to pick another directory.", e.fixable, e.Flag)
+	default:
+		return fmt.Sprintf("It needs read, write, and search permission for you (chmod u+rwx %s), or use %s to pick another directory.", e.fixable, e.Flag)
+	}
+}
+
+// Short is a one-line version for a notice: what, where, and the flag.
+func (e *Error) Short() string {
+	return fmt.Sprintf("Can't write %s %s (%s) — fix it or use %s", e.Name, e.Path, e.reason(), e.Flag)
+}
+
+// Writable creates dir if needed and checks that a file can be written in
+// it. It returns an *Error when permissions or a read-only file system get
+// in the way, and other failures as they are.
+func Writable(name, dir, flag string) error {
+	err := os.MkdirAll(dir, 0755)
+	if err == nil {
+		var f *os.File
+		if f, err = os.CreateTemp(dir, ".write-test-*"); err == nil {
+			f.Close()
+			return os.Remove(f.Name())
+		}
+	}
+	return Classify(name, dir, flag, err)
+}
+
+// Classify turns err from writing in dir into an *Error if it is a
+// permission problem, and returns it unchanged otherwise.
+func Classify(name, dir, flag string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, fs.ErrPermission) && !errors.Is(err, syscall.EROFS) {
+		return err
+	}
+	e := &Error{Name: name, Path: dir, Flag: flag, Err: err}
+	if existing := nearestExisting(dir); inHome(existing) {
+		e.fixable = existing
+		e.Owner = foreignOwner(existing)
+	}
+	return e
+}
+
+// nearestExisting returns dir or its closest parent that exists.
+func nearestExisting(dir string) string {
+	for {
+		if _, err := os.Stat(dir); err == nil || dir == filepath.Dir(dir) {
+			return dir
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// inHome reports whether path is the home directory or inside it. Directories
+// elsewhere aren't the user's to change, so the advice leaves them alone.
+func inHome(path string) bool {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return false
+	}
+	rel, err := filepath.Rel(home, path)
+	return err == nil && !strings.HasPrefix(rel, "..")
+}
+
+// As returns the *Error in err's chain, if any.
+func As(err error) (*Error, bool) {
+	var e *Error
+	ok := errors.As(err, &e)
+	return e, ok
+}
@@ -0,0 +1,95 @@
+package dircheck
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestWritable(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "new", "dir")
+	if err := Writable("config directory", dir, "--config-dir"); err != nil {
+		t.Fatalf("Writable() = %v, want nil", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Writable() left %d files behind", len(entries))
+	}
+}
+
+func TestClassify(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".config", "somafm")
+
+	tests := []struct {
+		name       string
+		err        error
+		wantReason string
+		wantAdvice string
+	}{
+		{
+			name:       "permission denied",
+			err:        &os.PathError{Op: "mkdir", Path: dir, Err: syscall.EACCES},
+			wantReason: "permission denied",
+			wantAdvice: "chmod u+rwx " + home + ")",
+		},
+		{
+			name:       "read-only file system",
+			err:        &os.PathError{Op: "open", Path: dir, Err: syscall.EROFS},
+			wantReason: "read-only file system",
+			wantAdvice: "Use --config-dir to pick a writable directory.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Classify("config directory", dir, "--config-dir", tt.err)
+			e, ok := As(err)
+			if !ok {
+				t.Fatalf("Classify() = %v, want *Error", err)
+			}
+			if !errors.Is(err, tt.err.(*os.PathError).Err) {
+				t.Error("Classify() dropped the underlying error")
+			}
+			if !strings.HasSuffix(e.Error(), tt.wantReason) {
+				t.Errorf("Error() = %q, want reason %q", e.Error(), tt.wantReason)
+			}
+			if !strings.Contains(e.Advice(), tt.wantAdvice) {
+				t.Errorf("Advice() = %q, want it to contain %q", e.Advice(), tt.wantAdvice)
+			}
+			if !strings.Contains(e.Short(), "--config-dir") {
+				t.Errorf("Short() = %q, want the flag", e.Short())
+			}
+		})
+	}
+}
+
+func TestClassifyOutsideHome(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	err := Classify("cache directory", "/var/cache/somafm", "--cache-dir",
+		&os.PathError{Op: "mkdir", Path: "/var/cache/somafm", Err: syscall.EACCES})
+	e, ok := As(err)
+	if !ok {
+		t.Fatalf("Classify() = %v, want *Error", err)
+	}
+	if want := "Use --cache-dir to pick a writable directory."; e.Advice() != want {
+		t.Errorf("Advice() = %q, want %q", e.Advice(), want)
+	}
+}
+
+func TestClassifyOtherErrors(t *testing.T) {
+	if err := Classify("config directory", "/x", "--config-dir", nil); err != nil {
+		t.Errorf("Classify(nil) = %v, want nil", err)
+	}
+	other := &os.PathError{Op: "open", Path: "/x", Err: syscall.ENOSPC}
+	if err := Classify("config directory", "/x", "--config-dir", other); err != other {
+		t.Errorf("Classify() = %v, want the error unchanged", err)
+	}
+}
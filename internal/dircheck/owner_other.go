@@ -0,0 +1,8 @@
+//go:build !unix
+
+package dircheck
+
+// foreignOwner is only known on Unix.
+func foreignOwner(path string) string {
+	return ""
+}
@@ -0,0 +1,28 @@
+//go:build unix
+
+package dircheck
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"syscall"
+)
+
+// foreignOwner names the owner of path when that isn't the current user,
+// most likely because it was created with sudo.
+func foreignOwner(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || int(stat.Uid) == os.Getuid() {
+		return ""
+	}
+	uid := fmt.Sprint(stat.Uid)
+	if u, err := user.LookupId(uid); err == nil {
+		return u.Username
+	}
+	return "uid " + uid
+}
@@ -0,0 +1,127 @@
+// Package export writes the listening history and liked tracks in formats
+// for spreadsheets and other tools.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/history"
+	"github.com/glebovdev/somafm-cli/internal/likes"
+)
+
+// Export formats.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Kinds of rows in the CSV export.
+const (
+	KindHistory = "history"
+	KindLike    = "like"
+)
+
+// Data is what gets exported.
+type Data struct {
+	History []HistoryEntry `json:"history"`
+	Likes   []likes.Track  `json:"likes"`
+}
+
+// HistoryEntry is a history entry with the track split into artist and
+// title, as likes are.
+type HistoryEntry struct {
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end,omitzero"`
+	Station      string    `json:"station"`
+	StationTitle string    `json:"station_title"`
+	Artist       string    `json:"artist,omitempty"`
+	Title        string    `json:"title"`
+	HeardSeconds int       `json:"heard_seconds,omitempty"`
+	Note         string    `json:"note,omitempty"`
+}
+
+// Collect returns the history entries and liked tracks from since on, both
+// oldest first. A zero since takes everything.
+func Collect(entries []history.Entry, tracks []likes.Track, since time.Time) Data {
+	d := Data{History: []HistoryEntry{}, Likes: []likes.Track{}}
+	for _, e := range entries {
+		if e.Start.Before(since) {
+			continue
+		}
+		artist, title := likes.SplitTrack(e.Track)
+		d.History = append(d.History, HistoryEntry{
+			Start:        e.Start,
+			End:          e.End,
+			Station:      e.Station,
+			StationTitle: e.StationTitle,
+			Artist:       artist,
+			Title:        title,
+			HeardSeconds: int(e.Heard().Seconds()),
+			Note:         e.Note,
+		})
+	}
+	// Tracks come most recent first
+	for i := len(tracks) - 1; i >= 0; i-- {
+		if !tracks[i].LikedAt.Before(since) {
+			d.Likes = append(d.Likes, tracks[i])
+		}
+	}
+	return d
+}
+
+// Write writes d to w in format. CSV is one table of both, told apart by
+// the kind column, so a spreadsheet gets a single sheet to filter.
+func Write(w io.Writer, d Data, format string) error {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"kind", "time", "station", "artist", "title", "heard_seconds", "note"})
+		for _, e := range d.History {
+			heard := ""
+			if e.HeardSeconds > 0 {
+				heard = strconv.Itoa(e.HeardSeconds)
+			}
+			_ = cw.Write([]string{KindHistory, e.Start.UTC().Format(time.RFC3339), e.Station, e.Artist, e.Title, heard, e.Note})
+		}
+		for _, t := range d.Likes {
+			_ = cw.Write([]string{KindLike, t.LikedAt.UTC().Format(time.RFC3339), t.Station, t.Artist, t.Title, "", ""})
+		}
+		cw.Flush()
+		return cw.Error()
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	default:
+		return fmt.Errorf("unknown export format %q, want csv or json", format)
+	}
+}
+
+// ParseSince reads a --since value relative to now: a duration such as
+// 12h, or a number of days or weeks such as 7d or 2w, means that long ago,
+// and a date such as 2024-06-01 means its start in now's time zone.
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	if date, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return date, nil
+	}
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil || count < 0 {
+				break
+			}
+			return now.Add(-time.Duration(count) * unit), nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("invalid since %q, want a duration like 7d or 12h, or a date like 2024-06-01", s)
+	}
+	return now.Add(-d), nil
+}
@@ -0,0 +1,83 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/history"
+	"github.com/glebovdev/somafm-cli/internal/likes"
+)
+
+func TestCollectAndWrite(t *testing.T) {
+	day := time.Date(2024, 6, 1, 20, 0, 0, 0, time.UTC)
+	entries := []history.Entry{
+		{Station: "groovesalad", StationTitle: "Groove Salad", Track: "Old - Track", Start: day.AddDate(0, 0, -10)},
+		{Station: "groovesalad", StationTitle: "Groove Salad", Track: "Bonobo - Kiara", Start: day, End: day.Add(5 * time.Minute), Note: "from, \"that\" movie"},
+		{Station: "dronezone", StationTitle: "Drone Zone", Track: "Station ID", Start: day.Add(6 * time.Minute)},
+	}
+	tracks := []likes.Track{
+		{Artist: "Bonobo", Title: "Kiara", Station: "groovesalad", LikedAt: day.Add(2 * time.Minute)},
+		{Artist: "Old", Title: "Track", Station: "groovesalad", LikedAt: day.AddDate(0, 0, -10)},
+	}
+
+	d := Collect(entries, tracks, day.AddDate(0, 0, -7))
+	if len(d.History) != 2 || d.History[0].Artist != "Bonobo" || d.History[0].HeardSeconds != 300 {
+		t.Errorf("history = %+v, want Bonobo and the station ID", d.History)
+	}
+	if len(d.Likes) != 1 || d.Likes[0].Artist != "Bonobo" {
+		t.Errorf("likes = %+v, want only the like within the week", d.Likes)
+	}
+
+	var b bytes.Buffer
+	if err := Write(&b, d, FormatCSV); err != nil {
+		t.Fatal(err)
+	}
+	want := `kind,time,station,artist,title,heard_seconds,note
+history,2024-06-01T20:00:00Z,groovesalad,Bonobo,Kiara,300,"from, ""that"" movie"
+history,2024-06-01T20:06:00Z,dronezone,,Station ID,,
+like,2024-06-01T20:02:00Z,groovesalad,Bonobo,Kiara,,
+`
+	if got := b.String(); got != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", got, want)
+	}
+
+	b.Reset()
+	if err := Write(&b, Collect(nil, nil, time.Time{}), FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	var empty map[string][]any
+	if err := json.Unmarshal(b.Bytes(), &empty); err != nil || empty["history"] == nil || empty["likes"] == nil {
+		t.Errorf("empty JSON = %s, want empty arrays", b.String())
+	}
+
+	if err := Write(&b, d, "xml"); err == nil || !strings.Contains(err.Error(), "xml") {
+		t.Errorf("Write(xml) error = %v", err)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 6, 8, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"7d", now.AddDate(0, 0, -7), false},
+		{"2w", now.AddDate(0, 0, -14), false},
+		{"12h", now.Add(-12 * time.Hour), false},
+		{"90m", now.Add(-90 * time.Minute), false},
+		{"2024-06-01", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), false},
+		{"-3d", time.Time{}, true},
+		{"xd", time.Time{}, true},
+		{"soon", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSince(tt.in, now)
+		if (err != nil) != tt.wantErr || !got.Equal(tt.want) {
+			t.Errorf("ParseSince(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
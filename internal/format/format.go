@@ -0,0 +1,128 @@
+// Package format renders numbers and durations for people: listener
+// counts with the digit grouping of the user's locale, and durations such
+// as "1 h 23 min".
+package format
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	nbsp       = "\u00a0" // No-break space
+	narrowNbsp = "\u202f" // Narrow no-break space, French style
+)
+
+// separators maps languages, or language-region pairs where the region
+// writes numbers differently, to what goes between groups of three
+// digits. Other locales use a comma, as English does.
+var separators = map[string]string{
+	"bg": nbsp, "cs": nbsp, "et": nbsp, "fi": nbsp, "hu": nbsp, "lt": nbsp,
+	"lv": nbsp, "nb": nbsp, "nn": nbsp, "no": nbsp, "pl": nbsp, "ru": nbsp,
+	"sk": nbsp, "sv": nbsp, "uk": nbsp, "pt-PT": nbsp,
+	"fr": narrowNbsp,
+	"da": ".", "de": ".", "el": ".", "es": ".", "hr": ".", "id": ".",
+	"it": ".", "nl": ".", "pt": ".", "ro": ".", "sl": ".", "sr": ".",
+	"tr":    ".",
+	"de-CH": "’", "it-CH": "’",
+}
+
+// groupFive lists the locales that only group numbers of five digits or
+// more, writing 1234 but 12 345.
+var groupFive = map[string]bool{"es": true, "pl": true, "pt-PT": true}
+
+// Numbers groups the digits of numbers the way a locale writes them. The
+// zero value doesn't group them.
+type Numbers struct {
+	separator string
+	minDigits int // Numbers with fewer digits aren't grouped
+}
+
+// NumbersFor returns the grouping of a POSIX locale such as de_DE.UTF-8.
+func NumbersFor(locale string) Numbers {
+	language, region := parseLocale(locale)
+	tag := language + "-" + region
+	separator, ok := separators[tag]
+	if !ok {
+		separator, ok = separators[language]
+	}
+	if !ok {
+		separator = ","
+	}
+	n := Numbers{separator: separator, minDigits: 4}
+	if groupFive[tag] || groupFive[language] {
+		n.minDigits = 5
+	}
+	return n
+}
+
+// LocaleNumbers returns the grouping of the user's numeric locale, from
+// LC_ALL, LC_NUMERIC, or LANG.
+func LocaleNumbers() Numbers {
+	for _, name := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return NumbersFor(value)
+		}
+	}
+	return NumbersFor("")
+}
+
+// Int formats i with its digits grouped, e.g. "12,345".
+func (n Numbers) Int(i int) string {
+	digits := strconv.Itoa(i)
+	sign := ""
+	if i < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if n.separator == "" || len(digits) < n.minDigits {
+		return sign + digits
+	}
+
+	var b strings.Builder
+	b.WriteString(sign)
+	first := len(digits) % 3
+	if first == 0 {
+		first = 3
+	}
+	b.WriteString(digits[:first])
+	for rest := digits[first:]; rest != ""; rest = rest[3:] {
+		b.WriteString(n.separator)
+		b.WriteString(rest[:3])
+	}
+	return b.String()
+}
+
+// Duration formats d to the second below a minute and to the minute
+// above, e.g. "45 s", "3 min", or "1 h 23 min". The unit symbols read the
+// same in most languages.
+func Duration(d time.Duration) string {
+	d = d.Round(time.Second)
+	if d < time.Minute {
+		return fmt.Sprintf("%d s", int(d.Seconds()))
+	}
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	if hours == 0 {
+		return fmt.Sprintf("%d min", minutes)
+	}
+	if minutes == 0 {
+		return fmt.Sprintf("%d h", hours)
+	}
+	return fmt.Sprintf("%d h %d min", hours, minutes)
+}
+
+// parseLocale splits a POSIX locale such as pt_BR.UTF-8@euro into its
+// language and region, both empty for the C and POSIX locales.
+func parseLocale(locale string) (language, region string) {
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	if locale == "C" || locale == "POSIX" {
+		return "", ""
+	}
+	language, region, _ = strings.Cut(locale, "_")
+	return strings.ToLower(language), strings.ToUpper(region)
+}
@@ -0,0 +1,67 @@
+package format
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInt(t *testing.T) {
+	tests := []struct {
+		locale string
+		n      int
+		want   string
+	}{
+		{"en_US.UTF-8", 1234567, "1,234,567"},
+		{"en_US.UTF-8", 999, "999"},
+		{"en_US.UTF-8", 1000, "1,000"},
+		{"en_US.UTF-8", -12345, "-12,345"},
+		{"C", 12345, "12,345"},
+		{"", 12345, "12,345"},
+		{"de_DE.UTF-8", 12345, "12.345"},
+		{"de_CH.UTF-8", 12345, "12’345"},
+		{"fr_FR.UTF-8", 12345, "12\u202f345"},
+		{"ru_RU.UTF-8", 1234, "1\u00a0234"},
+		{"es_ES.UTF-8", 1234, "1234"},
+		{"es_ES.UTF-8", 12345, "12.345"},
+		{"pt_BR.UTF-8", 1234, "1.234"},
+		{"pt_PT.UTF-8", 1234, "1234"},
+		{"pt_PT.UTF-8", 12345, "12\u00a0345"},
+	}
+	for _, tt := range tests {
+		if got := NumbersFor(tt.locale).Int(tt.n); got != tt.want {
+			t.Errorf("NumbersFor(%q).Int(%d) = %q, want %q", tt.locale, tt.n, got, tt.want)
+		}
+	}
+	if got := (Numbers{}).Int(12345); got != "12345" {
+		t.Errorf("Numbers{}.Int() = %q, want no grouping", got)
+	}
+}
+
+func TestLocaleNumbers(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_NUMERIC", "de_DE.UTF-8")
+	t.Setenv("LANG", "en_US.UTF-8")
+	if got := LocaleNumbers().Int(1234); got != "1.234" {
+		t.Errorf("LocaleNumbers().Int() = %q, want LC_NUMERIC's grouping", got)
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0 s"},
+		{45 * time.Second, "45 s"},
+		{59*time.Second + 600*time.Millisecond, "1 min"},
+		{3*time.Minute + 20*time.Second, "3 min"},
+		{time.Hour, "1 h"},
+		{time.Hour + 23*time.Minute, "1 h 23 min"},
+		{26*time.Hour + 5*time.Minute, "26 h 5 min"},
+	}
+	for _, tt := range tests {
+		if got := Duration(tt.d); got != tt.want {
+			t.Errorf("Duration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Package genre translates SomaFM's terse genre tags into display names.
+package genre
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed genres.yaml
+var builtinYAML []byte
+
+// Info is the display name and optional description of a genre tag.
+type Info struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+}
+
+// Translator maps genre tags to display names. The zero value and nil
+// both return tags unchanged.
+type Translator struct {
+	entries map[string]Info
+}
+
+// NewTranslator returns a translator with the built-in mapping, with
+// overrides applied on top. An override with an empty name keeps the
+// built-in name, so only a description can be changed.
+func NewTranslator(overrides map[string]Info) (*Translator, error) {
+	entries := make(map[string]Info)
+	if err := yaml.Unmarshal(builtinYAML, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse built-in genres: %w", err)
+	}
+
+	for tag, info := range overrides {
+		key := normalize(tag)
+		existing := entries[key]
+		if info.Name == "" {
+			info.Name = existing.Name
+		}
+		if info.Description == "" {
+			info.Description = existing.Description
+		}
+		entries[key] = info
+	}
+
+	return &Translator{entries: entries}, nil
+}
+
+func normalize(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// Split returns the individual tags of a pipe-separated genre string.
+func Split(genre string) []string {
+	var tags []string
+	for _, tag := range strings.Split(genre, "|") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Similarity returns how much two pipe-separated genre lists overlap: the
+// tags they share over all their distinct tags, from 0 for nothing in
+// common to 1 for the same tags.
+func Similarity(a, b string) float64 {
+	tags := make(map[string]int)
+	for _, tag := range Split(a) {
+		tags[normalize(tag)] |= 1
+	}
+	for _, tag := range Split(b) {
+		tags[normalize(tag)] |= 2
+	}
+	shared := 0
+	for _, in := range tags {
+		if in == 3 {
+			shared++
+		}
+	}
+	if shared == 0 {
+		return 0
+	}
+	return float64(shared) / float64(len(tags))
+}
+
+// Name returns the display name for tag, or tag itself when unknown.
+func (t *Translator) Name(tag string) string {
+	if t != nil {
+		if info, ok := t.entries[normalize(tag)]; ok && info.Name != "" {
+			return info.Name
+		}
+	}
+	return strings.TrimSpace(tag)
+}
+
+// Description returns the description for tag, or "" when there is none.
+func (t *Translator) Description(tag string) string {
+	if t == nil {
+		return ""
+	}
+	return t.entries[normalize(tag)].Description
+}
+
+// Names translates every tag of a pipe-separated genre string.
+func (t *Translator) Names(genre string) []string {
+	tags := Split(genre)
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = t.Name(tag)
+	}
+	return names
+}
+
+// Matches reports whether query, ignoring case, appears in the genre string
+// as listed, in a tag's display name, or in a tag's description.
+func (t *Translator) Matches(genre, query string) bool {
+	query = strings.ToLower(query)
+	if strings.Contains(strings.ToLower(strings.ReplaceAll(genre, "|", ", ")), query) ||
+		strings.Contains(strings.ToLower(strings.Join(t.Names(genre), ", ")), query) {
+		return true
+	}
+	for _, tag := range Split(genre) {
+		if strings.Contains(strings.ToLower(t.Description(tag)), query) {
+			return true
+		}
+	}
+	return false
+}
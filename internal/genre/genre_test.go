@@ -0,0 +1,117 @@
+package genre
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuiltinTranslations(t *testing.T) {
+	tr, err := NewTranslator(nil)
+	if err != nil {
+		t.Fatalf("NewTranslator() error = %v", err)
+	}
+
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"idm", "IDM / braindance"},
+		{"IDM", "IDM / braindance"},
+		{" ambient ", "Ambient"},
+		{"80s", "80s"},
+		{"vaporwave", "vaporwave"},
+	}
+
+	for _, tt := range tests {
+		if got := tr.Name(tt.tag); got != tt.want {
+			t.Errorf("Name(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+
+	if tr.Description("idm") == "" {
+		t.Error("Description(\"idm\") should not be empty")
+	}
+	if got := tr.Description("vaporwave"); got != "" {
+		t.Errorf("Description(\"vaporwave\") = %q, want empty", got)
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	tr, err := NewTranslator(map[string]Info{
+		"IDM":       {Name: "Braindance"},
+		"vaporwave": {Name: "Vaporwave", Description: "Slowed-down nostalgia"},
+		"ambient":   {Description: "Music for airports"},
+	})
+	if err != nil {
+		t.Fatalf("NewTranslator() error = %v", err)
+	}
+
+	if got := tr.Name("idm"); got != "Braindance" {
+		t.Errorf("Name(\"idm\") = %q, want override", got)
+	}
+	if tr.Description("idm") == "" {
+		t.Error("override without description should keep the built-in one")
+	}
+	if got := tr.Name("vaporwave"); got != "Vaporwave" {
+		t.Errorf("Name(\"vaporwave\") = %q, want %q", got, "Vaporwave")
+	}
+	if got := tr.Name("ambient"); got != "Ambient" {
+		t.Errorf("Name(\"ambient\") = %q, want built-in name kept", got)
+	}
+	if got := tr.Description("ambient"); got != "Music for airports" {
+		t.Errorf("Description(\"ambient\") = %q, want override", got)
+	}
+}
+
+func TestNames(t *testing.T) {
+	tr, _ := NewTranslator(nil)
+
+	if got, want := tr.Names("ambient|idm||space"), []string{"Ambient", "IDM / braindance", "Space music"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+	if got := tr.Names(""); len(got) != 0 {
+		t.Errorf("Names(\"\") = %v, want empty", got)
+	}
+}
+
+func TestNilTranslator(t *testing.T) {
+	var tr *Translator
+	if got := tr.Name("idm"); got != "idm" {
+		t.Errorf("nil Name(\"idm\") = %q, want tag unchanged", got)
+	}
+	if got := tr.Description("idm"); got != "" {
+		t.Errorf("nil Description(\"idm\") = %q, want empty", got)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tr, _ := NewTranslator(nil)
+
+	for _, query := range []string{"idm", "Braindance", "ambient, idm"} {
+		if !tr.Matches("ambient|idm", query) {
+			t.Errorf("Matches(%q) = false, want true", query)
+		}
+	}
+	if tr.Matches("ambient|idm", "metal") {
+		t.Error("Matches(\"metal\") = true, want false")
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"ambient|electronica", "ambient|electronica", 1},
+		{"ambient|electronica", "Electronica | Ambient", 1},
+		{"ambient|electronica", "ambient|space", 1.0 / 3},
+		{"ambient|electronica", "electronica", 0.5},
+		{"ambient", "metal", 0},
+		{"", "", 0},
+	}
+	for _, tt := range tests {
+		if got := Similarity(tt.a, tt.b); got != tt.want {
+			t.Errorf("Similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
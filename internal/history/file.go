@@ -0,0 +1,90 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/glebovdev/somafm-cli/internal/storage"
+)
+
+// FileBackend stores entries as JSON Lines, appending one line per entry.
+// Once the file holds twice MaxEntries it is rewritten with the latest
+// MaxEntries.
+type FileBackend struct {
+	path  string
+	lines int
+}
+
+// NewFileBackend returns a backend for the history file at path.
+func NewFileBackend(path string) *FileBackend {
+	return &FileBackend{path: path}
+}
+
+// Load reads the history file, converting a LegacyFileName next to it when
+// the file doesn't exist yet.
+func (b *FileBackend) Load(limit int) ([]Entry, error) {
+	entries, err := b.read()
+	if err != nil {
+		return nil, err
+	}
+	b.lines = len(entries)
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
+
+// Append adds e as a line at the end of the file.
+func (b *FileBackend) Append(e Entry) error {
+	if b.lines >= 2*MaxEntries {
+		return b.compact(e)
+	}
+	if err := storage.AppendJSONL(b.path, e); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	b.lines++
+	return nil
+}
+
+func (b *FileBackend) compact(e Entry) error {
+	entries, err := b.read()
+	if err != nil {
+		return err
+	}
+	entries = append(entries, e)
+	if len(entries) > MaxEntries {
+		entries = entries[len(entries)-MaxEntries:]
+	}
+	if err := storage.WriteJSONL(b.path, entries); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	b.lines = len(entries)
+	return nil
+}
+
+func (b *FileBackend) read() ([]Entry, error) {
+	entries, err := storage.ReadJSONL[Entry](b.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+	if _, err := os.Stat(b.path); !os.IsNotExist(err) {
+		return entries, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(b.path), LegacyFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", LegacyFileName, err)
+	}
+	if err := storage.WriteJSONL(b.path, entries); err != nil {
+		return nil, fmt.Errorf("failed to convert %s: %w", LegacyFileName, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,209 @@
+// Package history records the tracks heard while listening.
+package history
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/config"
+)
+
+const (
+	// FileName is the history file inside the config directory.
+	FileName = "history.jsonl"
+	// LegacyFileName is the JSON array written by older versions. It is
+	// converted when FileName doesn't exist yet.
+	LegacyFileName = "history.json"
+	// MaxEntries bounds the history kept in memory and in the history
+	// file; the oldest entries are dropped. The SQLite backend keeps all.
+	MaxEntries = 5000
+	// MaxTrackLength caps how long an entry is assumed to have played when
+	// nothing followed it, e.g. the last track before quitting.
+	MaxTrackLength = 10 * time.Minute
+)
+
+// Entry is one track heard on a station. End and Paused are unknown for
+// entries written by older versions.
+type Entry struct {
+	Station      string        `json:"station"`
+	StationTitle string        `json:"station_title"`
+	Track        string        `json:"track"`
+	Start        time.Time     `json:"start"`
+	End          time.Time     `json:"end,omitzero"`
+	Paused       time.Duration `json:"paused,omitempty"`
+	// Note is the listener's own remark on the track, e.g. "the one from
+	// that movie".
+	Note string `json:"note,omitempty"`
+}
+
+// Heard returns how long the track played, excluding pauses, or 0 when
+// the end is unknown.
+func (e Entry) Heard() time.Duration {
+	if e.End.IsZero() {
+		return 0
+	}
+	return max(e.End.Sub(e.Start)-e.Paused, 0)
+}
+
+// Backend persists the entries of a Store.
+type Backend interface {
+	// Load returns up to limit of the most recent entries, oldest first.
+	// A limit of 0 or less returns all of them.
+	Load(limit int) ([]Entry, error)
+	// Append stores e after the existing entries.
+	Append(e Entry) error
+}
+
+// Searcher is implemented by backends that search more than the entries
+// a Store keeps in memory.
+type Searcher interface {
+	Search(query string, limit int) ([]Entry, error)
+}
+
+// Store keeps the most recent history in memory and persists it through a
+// Backend.
+type Store struct {
+	mu      sync.Mutex
+	backend Backend
+	entries []Entry
+}
+
+// DefaultPath returns the history file path next to the config file.
+func DefaultPath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileName), nil
+}
+
+// Open loads the history file at path. A missing file yields an empty store.
+func Open(path string) (*Store, error) {
+	return New(NewFileBackend(path))
+}
+
+// OpenDefault opens the history in db, or in the default history file when
+// db is nil. A new database imports the history file first.
+func OpenDefault(db *sql.DB) (*Store, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	if db == nil {
+		return Open(path)
+	}
+	b, err := NewSQLiteBackend(db, path)
+	if err != nil {
+		return nil, err
+	}
+	return New(b)
+}
+
+// New loads the most recent MaxEntries from b. On error the store is empty
+// but still usable.
+func New(b Backend) (*Store, error) {
+	s := &Store{backend: b}
+	entries, err := b.Load(MaxEntries)
+	if err != nil {
+		return s, err
+	}
+	s.entries = entries
+	return s, nil
+}
+
+// Add records e and saves it. The same track on the same station as the
+// latest entry is not recorded again and returns false.
+func (s *Store) Add(e Entry) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n := len(s.entries); n > 0 {
+		last := s.entries[n-1]
+		if last.Station == e.Station && last.Track == e.Track {
+			return false, nil
+		}
+	}
+	if e.Start.IsZero() {
+		e.Start = time.Now()
+	}
+
+	s.entries = append(s.entries, e)
+	if len(s.entries) > MaxEntries {
+		s.entries = s.entries[len(s.entries)-MaxEntries:]
+	}
+	if err := s.backend.Append(e); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// Entries returns the history in chronological order.
+func (s *Store) Entries() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Entry(nil), s.entries...)
+}
+
+// Search returns up to limit entries whose track, station, or note
+// contains query, case-insensitively, most recent first. A limit of 0 or less
+// returns all matches.
+func (s *Store) Search(query string, limit int) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if searcher, ok := s.backend.(Searcher); ok {
+		return searcher.Search(query, limit)
+	}
+	query = strings.ToLower(query)
+	var found []Entry
+	for i := len(s.entries) - 1; i >= 0 && (limit <= 0 || len(found) < limit); i-- {
+		e := s.entries[i]
+		if strings.Contains(strings.ToLower(e.Track), query) ||
+			strings.Contains(strings.ToLower(e.StationTitle), query) ||
+			strings.Contains(e.Station, query) ||
+			strings.Contains(strings.ToLower(e.Note), query) {
+			found = append(found, e)
+		}
+	}
+	return found, nil
+}
+
+// Span is an entry with the time it played until: its recorded end or the
+// start of the next entry, capped at MaxTrackLength.
+type Span struct {
+	Entry
+	End time.Time
+}
+
+// Day is the listening of one local calendar day.
+type Day struct {
+	Date  time.Time
+	Spans []Span
+}
+
+// Days groups entries into days, oldest first. Entries must be in
+// chronological order. A span that runs past midnight stays on its start day.
+func Days(entries []Entry, loc *time.Location) []Day {
+	var days []Day
+	for i, e := range entries {
+		end := e.Start.Add(MaxTrackLength)
+		if !e.End.IsZero() && e.End.Before(end) {
+			end = e.End
+		}
+		if i+1 < len(entries) && entries[i+1].Start.Before(end) {
+			end = entries[i+1].Start
+		}
+
+		start := e.Start.In(loc)
+		date := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+		if len(days) == 0 || !days[len(days)-1].Date.Equal(date) {
+			days = append(days, Day{Date: date})
+		}
+		last := &days[len(days)-1]
+		last.Spans = append(last.Spans, Span{Entry: e, End: end})
+	}
+	return days
+}
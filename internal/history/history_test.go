@@ -0,0 +1,197 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/storage"
+)
+
+func TestAddSkipsRepeats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	entries := []Entry{
+		{Station: "groovesalad", Track: "Bonobo - Kiara"},
+		{Station: "groovesalad", Track: "Bonobo - Kiara"},
+		{Station: "dronezone", Track: "Bonobo - Kiara"},
+	}
+	want := []bool{true, false, true}
+	for i, e := range entries {
+		added, err := store.Add(e)
+		if err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+		if added != want[i] {
+			t.Errorf("Add(%d) = %v, want %v", i, added, want[i])
+		}
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	got := reopened.Entries()
+	if len(got) != 2 || got[1].Station != "dronezone" || got[0].Start.IsZero() {
+		t.Errorf("reopened entries = %+v", got)
+	}
+}
+
+func TestAddBoundsEntries(t *testing.T) {
+	store, _ := Open(filepath.Join(t.TempDir(), FileName))
+	store.entries = make([]Entry, MaxEntries)
+
+	if _, err := store.Add(Entry{Station: "groovesalad", Track: "newest"}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	entries := store.Entries()
+	if len(entries) != MaxEntries || entries[len(entries)-1].Track != "newest" {
+		t.Errorf("len = %d, last = %q; want %d entries ending with newest", len(entries), entries[len(entries)-1].Track, MaxEntries)
+	}
+}
+
+func TestFileBackendConvertsLegacyFile(t *testing.T) {
+	dir := t.TempDir()
+	legacy := `[{"station": "groovesalad", "station_title": "Groove Salad", "track": "Bonobo - Kiara", "start": "2026-03-14T10:00:00Z"}]`
+	if err := os.WriteFile(filepath.Join(dir, LegacyFileName), []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, FileName)
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, err := store.Add(Entry{Station: "dronezone", Track: "Stars of the Lid - Music for Nitrous Oxide"}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("history file not written: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("history file has %d lines, want 2:\n%s", lines, data)
+	}
+	reopened, _ := Open(path)
+	if got := reopened.Entries(); len(got) != 2 || got[0].Track != "Bonobo - Kiara" {
+		t.Errorf("reopened entries = %+v", got)
+	}
+}
+
+func TestFileBackendCompacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	b := NewFileBackend(path)
+	entries := make([]Entry, 2*MaxEntries)
+	for i := range entries {
+		entries[i] = Entry{Station: "groovesalad", Track: "old"}
+	}
+	if err := storage.WriteJSONL(path, entries); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Load(0); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if err := b.Append(Entry{Station: "groovesalad", Track: "newest"}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	got, err := b.Load(0)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(got) != MaxEntries || got[len(got)-1].Track != "newest" {
+		t.Errorf("after compaction len = %d, last = %q; want %d ending with newest", len(got), got[len(got)-1].Track, MaxEntries)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	store, _ := Open(filepath.Join(t.TempDir(), FileName))
+	for _, e := range []Entry{
+		{Station: "groovesalad", StationTitle: "Groove Salad", Track: "Bonobo - Kiara"},
+		{Station: "dronezone", StationTitle: "Drone Zone", Track: "Stars of the Lid - Requiem"},
+		{Station: "groovesalad", StationTitle: "Groove Salad", Track: "Bonobo - Cirrus", Note: "The one from that Movie"},
+	} {
+		if _, err := store.Add(e); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		query string
+		limit int
+		want  []string
+	}{
+		{"bonobo", 0, []string{"Bonobo - Cirrus", "Bonobo - Kiara"}},
+		{"bonobo", 1, []string{"Bonobo - Cirrus"}},
+		{"drone zone", 0, []string{"Stars of the Lid - Requiem"}},
+		{"aphex", 0, nil},
+		{"movie", 0, []string{"Bonobo - Cirrus"}},
+	}
+	for _, tt := range tests {
+		found, err := store.Search(tt.query, tt.limit)
+		if err != nil {
+			t.Fatalf("Search(%q) error = %v", tt.query, err)
+		}
+		var got []string
+		for _, e := range found {
+			got = append(got, e.Track)
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("Search(%q, %d) = %q, want %q", tt.query, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestDays(t *testing.T) {
+	base := time.Date(2026, 3, 14, 23, 50, 0, 0, time.UTC)
+	entries := []Entry{
+		{Station: "a", Track: "one", Start: base},
+		{Station: "a", Track: "two", Start: base.Add(4 * time.Minute)},
+		{Station: "b", Track: "three", Start: base.Add(20 * time.Minute)},
+		{Station: "b", Track: "four", Start: base.Add(3 * time.Hour)},
+	}
+
+	days := Days(entries, time.UTC)
+	if len(days) != 2 {
+		t.Fatalf("Days() = %d days, want 2", len(days))
+	}
+	if len(days[0].Spans) != 2 || len(days[1].Spans) != 2 {
+		t.Fatalf("spans per day = %d, %d; want 2, 2", len(days[0].Spans), len(days[1].Spans))
+	}
+
+	if got := days[0].Spans[0].End; !got.Equal(entries[1].Start) {
+		t.Errorf("span ends at %v, want next entry start", got)
+	}
+	if got := days[0].Spans[1].End; !got.Equal(entries[1].Start.Add(MaxTrackLength)) {
+		t.Errorf("span before a gap ends at %v, want capped at MaxTrackLength", got)
+	}
+	if got := days[1].Date; !got.Equal(time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("second day = %v", got)
+	}
+}
+
+func TestDaysUsesRecordedEnd(t *testing.T) {
+	base := time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Station: "a", Track: "one", Start: base, End: base.Add(3 * time.Minute), Paused: time.Minute},
+		{Station: "a", Track: "two", Start: base.Add(30 * time.Minute)},
+	}
+
+	days := Days(entries, time.UTC)
+	if got := days[0].Spans[0].End; !got.Equal(base.Add(3 * time.Minute)) {
+		t.Errorf("span end = %v, want the recorded end", got)
+	}
+	if got := entries[0].Heard(); got != 2*time.Minute {
+		t.Errorf("Heard() = %v, want 2m", got)
+	}
+	if got := entries[1].Heard(); got != 0 {
+		t.Errorf("Heard() without an end = %v, want 0", got)
+	}
+}
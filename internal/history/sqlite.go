@@ -0,0 +1,157 @@
+package history
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS history (
+	id            INTEGER PRIMARY KEY,
+	station       TEXT    NOT NULL,
+	station_title TEXT    NOT NULL,
+	track         TEXT    NOT NULL,
+	start         INTEGER NOT NULL,
+	end           INTEGER NOT NULL DEFAULT 0,
+	paused        INTEGER NOT NULL DEFAULT 0,
+	note          TEXT    NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS history_start ON history (start);`
+
+// SQLiteBackend keeps the whole history in a SQLite table, for years of
+// listening that should stay quick to search.
+type SQLiteBackend struct {
+	db *sql.DB
+}
+
+// NewSQLiteBackend creates the history table in db if needed. While the
+// table is empty, the history file at importPath is copied into it.
+func NewSQLiteBackend(db *sql.DB, importPath string) (*SQLiteBackend, error) {
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return nil, fmt.Errorf("failed to create history table: %w", err)
+	}
+	if err := addNoteColumn(db); err != nil {
+		return nil, fmt.Errorf("failed to add notes to history table: %w", err)
+	}
+	b := &SQLiteBackend{db: db}
+	if importPath == "" {
+		return b, nil
+	}
+
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM history`).Scan(&n); err != nil {
+		return nil, fmt.Errorf("failed to count history: %w", err)
+	}
+	if n > 0 {
+		return b, nil
+	}
+	entries, err := NewFileBackend(importPath).Load(0)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.insert(entries...); err != nil {
+		return nil, fmt.Errorf("failed to import history file: %w", err)
+	}
+	return b, nil
+}
+
+// addNoteColumn upgrades a history table created before entries had
+// notes.
+func addNoteColumn(db *sql.DB) error {
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('history') WHERE name = 'note'`).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	_, err := db.Exec(`ALTER TABLE history ADD COLUMN note TEXT NOT NULL DEFAULT ''`)
+	return err
+}
+
+// Load returns up to limit of the most recent entries, oldest first.
+func (b *SQLiteBackend) Load(limit int) ([]Entry, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := b.db.Query(`SELECT station, station_title, track, start, end, paused, note FROM
+		(SELECT * FROM history ORDER BY id DESC LIMIT ?) ORDER BY id`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return scanEntries(rows)
+}
+
+// Append inserts e.
+func (b *SQLiteBackend) Append(e Entry) error {
+	if err := b.insert(e); err != nil {
+		return fmt.Errorf("failed to save history: %w", err)
+	}
+	return nil
+}
+
+// Search matches query against tracks, stations, and notes with LIKE, most
+// recent first.
+func (b *SQLiteBackend) Search(query string, limit int) ([]Entry, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	pattern := "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(query) + "%"
+	rows, err := b.db.Query(`SELECT station, station_title, track, start, end, paused, note FROM history
+		WHERE track LIKE ?1 ESCAPE '\' OR station_title LIKE ?1 ESCAPE '\' OR station LIKE ?1 ESCAPE '\'
+			OR note LIKE ?1 ESCAPE '\'
+		ORDER BY id DESC LIMIT ?2`, pattern, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search history: %w", err)
+	}
+	return scanEntries(rows)
+}
+
+func (b *SQLiteBackend) insert(entries ...Entry) error {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO history (station, station_title, track, start, end, paused, note) VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, e := range entries {
+		var end int64
+		if !e.End.IsZero() {
+			end = e.End.UnixNano()
+		}
+		if _, err := stmt.Exec(e.Station, e.StationTitle, e.Track, e.Start.UnixNano(), end, int64(e.Paused), e.Note); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func scanEntries(rows *sql.Rows) ([]Entry, error) {
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var e Entry
+		var start, end, paused int64
+		if err := rows.Scan(&e.Station, &e.StationTitle, &e.Track, &start, &end, &paused, &e.Note); err != nil {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+		e.Start = time.Unix(0, start)
+		if end != 0 {
+			e.End = time.Unix(0, end)
+		}
+		e.Paused = time.Duration(paused)
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return entries, nil
+}
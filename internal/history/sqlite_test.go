@@ -0,0 +1,97 @@
+//go:build sqlite
+
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/storage"
+)
+
+func TestSQLiteBackend(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC)
+	file, _ := Open(filepath.Join(dir, FileName))
+	if _, err := file.Add(Entry{Station: "groovesalad", StationTitle: "Groove Salad", Track: "Bonobo - Kiara", Start: start}); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := storage.OpenSQLite(filepath.Join(dir, storage.DBFileName))
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	defer db.Close()
+	b, err := NewSQLiteBackend(db, filepath.Join(dir, FileName))
+	if err != nil {
+		t.Fatalf("NewSQLiteBackend() error = %v", err)
+	}
+	store, err := New(b)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got := store.Entries(); len(got) != 1 || !got[0].Start.Equal(start) {
+		t.Fatalf("imported entries = %+v", got)
+	}
+
+	entry := Entry{Station: "dronezone", StationTitle: "Drone Zone", Track: "100% Ambient_Mix", Start: start.Add(time.Hour), End: start.Add(time.Hour + 3*time.Minute), Paused: time.Minute, Note: "heard at the cafe"}
+	if _, err := store.Add(entry); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if _, err := NewSQLiteBackend(db, filepath.Join(dir, FileName)); err != nil {
+		t.Fatalf("NewSQLiteBackend() again error = %v", err)
+	}
+	all, _ := b.Load(0)
+	if len(all) != 2 || all[1].Heard() != 2*time.Minute || all[1].Note != entry.Note {
+		t.Errorf("Load() = %+v, want the import once and the new entry", all)
+	}
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"kiara", 1},
+		{"drone", 1},
+		{"100%", 1},
+		{"1_0", 0},
+		{"cafe", 1},
+		{"", 2},
+	}
+	for _, tt := range tests {
+		found, err := store.Search(tt.query, 0)
+		if err != nil {
+			t.Fatalf("Search(%q) error = %v", tt.query, err)
+		}
+		if len(found) != tt.want {
+			t.Errorf("Search(%q) = %d entries, want %d", tt.query, len(found), tt.want)
+		}
+	}
+}
+
+func TestSQLiteBackendAddsNoteColumn(t *testing.T) {
+	db, err := storage.OpenSQLite(filepath.Join(t.TempDir(), storage.DBFileName))
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	defer db.Close()
+	// The table as versions without notes created it
+	if _, err := db.Exec(`CREATE TABLE history (
+		id INTEGER PRIMARY KEY, station TEXT NOT NULL, station_title TEXT NOT NULL, track TEXT NOT NULL,
+		start INTEGER NOT NULL, end INTEGER NOT NULL DEFAULT 0, paused INTEGER NOT NULL DEFAULT 0);
+		INSERT INTO history (station, station_title, track, start) VALUES ('groovesalad', 'Groove Salad', 'Bonobo - Kiara', 1)`); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewSQLiteBackend(db, "")
+	if err != nil {
+		t.Fatalf("NewSQLiteBackend() error = %v", err)
+	}
+	if err := b.Append(Entry{Station: "dronezone", Track: "Requiem", Start: time.Now(), Note: "rainy day"}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	all, err := b.Load(0)
+	if err != nil || len(all) != 2 || all[0].Note != "" || all[1].Note != "rainy day" {
+		t.Errorf("Load() = %+v, %v", all, err)
+	}
+}
@@ -0,0 +1,88 @@
+// Package hooks runs user-configured shell commands on application events.
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultTimeout bounds how long a hook may run before it is killed.
+	DefaultTimeout = 10 * time.Second
+
+	EventStartup  = "startup"
+	EventShutdown = "shutdown"
+)
+
+// Run executes command through the platform shell and waits for it to finish.
+// Variables in env are exported with a SOMAFM_ prefix alongside SOMAFM_EVENT.
+// An empty command is a no-op.
+func Run(ctx context.Context, event, command string, env map[string]string) error {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	cmd := Command(ctx, command)
+	cmd.Env = append(os.Environ(), buildEnv(event, env)...)
+
+	start := time.Now()
+	output, err := cmd.CombinedOutput()
+	if len(output) > 0 {
+		log.Debug().Str("event", event).Msgf("Hook output: %s", strings.TrimSpace(string(output)))
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s hook timed out after %v", event, DefaultTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("%s hook failed: %w", event, err)
+	}
+
+	log.Debug().Str("event", event).Dur("took", time.Since(start)).Msg("Hook finished")
+	return nil
+}
+
+// RunAsync runs the hook in the background and logs failures.
+func RunAsync(event, command string, env map[string]string) {
+	if strings.TrimSpace(command) == "" {
+		return
+	}
+	go func() {
+		if err := Run(context.Background(), event, command, env); err != nil {
+			log.Warn().Err(err).Msg("Hook error")
+		}
+	}()
+}
+
+// Command returns an exec.Cmd running command through the platform shell.
+func Command(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+func buildEnv(event string, env map[string]string) []string {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := []string{"SOMAFM_EVENT=" + event}
+	for _, key := range keys {
+		result = append(result, "SOMAFM_"+strings.ToUpper(key)+"="+env[key])
+	}
+	return result
+}
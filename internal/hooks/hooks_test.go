@@ -0,0 +1,74 @@
+package hooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestBuildEnv(t *testing.T) {
+	env := buildEnv(EventStartup, map[string]string{
+		"station": "groovesalad",
+		"volume":  "70",
+	})
+
+	expected := []string{
+		"SOMAFM_EVENT=startup",
+		"SOMAFM_STATION=groovesalad",
+		"SOMAFM_VOLUME=70",
+	}
+
+	if len(env) != len(expected) {
+		t.Fatalf("buildEnv() returned %d entries, want %d: %v", len(env), len(expected), env)
+	}
+	for i := range expected {
+		if env[i] != expected[i] {
+			t.Errorf("buildEnv()[%d] = %q, want %q", i, env[i], expected[i])
+		}
+	}
+}
+
+func TestRunEmptyCommand(t *testing.T) {
+	if err := Run(context.Background(), EventStartup, "   ", nil); err != nil {
+		t.Errorf("Run() with empty command error = %v, want nil", err)
+	}
+}
+
+func TestRunPassesEnvironment(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+
+	outFile := filepath.Join(t.TempDir(), "hook.out")
+	command := `printf "%s %s" "$SOMAFM_EVENT" "$SOMAFM_STATION" > ` + outFile
+
+	err := Run(context.Background(), EventShutdown, command, map[string]string{"station": "dronezone"})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if got := string(data); got != "shutdown dronezone" {
+		t.Errorf("hook output = %q, want %q", got, "shutdown dronezone")
+	}
+}
+
+func TestRunFailingCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+
+	err := Run(context.Background(), EventStartup, "exit 3", nil)
+	if err == nil {
+		t.Fatal("Run() should return error for failing command")
+	}
+	if !strings.Contains(err.Error(), "startup hook failed") {
+		t.Errorf("Run() error = %q, expected to mention the event", err.Error())
+	}
+}
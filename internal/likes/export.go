@@ -0,0 +1,55 @@
+package likes
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Export formats.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+	// FormatText writes one "Artist - Title" line per track, ready to paste
+	// into a search box or a playlist importer.
+	FormatText = "txt"
+)
+
+// Formats lists the export formats.
+var Formats = []string{FormatCSV, FormatJSON, FormatText}
+
+// Export writes tracks to w in format.
+func Export(w io.Writer, tracks []Track, format string) error {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"artist", "title", "station", "liked_at"})
+		for _, t := range tracks {
+			_ = cw.Write([]string{t.Artist, t.Title, t.Station, t.LikedAt.UTC().Format(time.RFC3339)})
+		}
+		cw.Flush()
+		return cw.Error()
+	case FormatJSON:
+		if tracks == nil {
+			tracks = []Track{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(tracks)
+	case FormatText:
+		for _, t := range tracks {
+			line := t.Title
+			if t.Artist != "" {
+				line = t.Artist + " - " + t.Title
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown export format %q, want csv, json, or txt", format)
+	}
+}
@@ -0,0 +1,55 @@
+package likes
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/glebovdev/somafm-cli/internal/storage"
+)
+
+// FileBackend stores tracks as JSON Lines, appending one line per like.
+type FileBackend struct {
+	path string
+}
+
+// NewFileBackend returns a backend for the likes file at path.
+func NewFileBackend(path string) *FileBackend {
+	return &FileBackend{path: path}
+}
+
+// Load reads the likes file, converting a LegacyFileName next to it when
+// the file doesn't exist yet.
+func (b *FileBackend) Load() ([]Track, error) {
+	tracks, err := storage.ReadJSONL[Track](b.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read likes file: %w", err)
+	}
+	if _, err := os.Stat(b.path); !os.IsNotExist(err) {
+		return tracks, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(b.path), LegacyFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read likes file: %w", err)
+	}
+	if err := json.Unmarshal(data, &tracks); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", LegacyFileName, err)
+	}
+	if err := storage.WriteJSONL(b.path, tracks); err != nil {
+		return nil, fmt.Errorf("failed to convert %s: %w", LegacyFileName, err)
+	}
+	return tracks, nil
+}
+
+// Append adds t as a line at the end of the file.
+func (b *FileBackend) Append(t Track) error {
+	if err := storage.AppendJSONL(b.path, t); err != nil {
+		return fmt.Errorf("failed to write likes file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+// Package likes stores tracks the user liked while listening.
+package likes
+
+import (
+	"database/sql"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/config"
+)
+
+const (
+	// FileName is the likes file inside the config directory.
+	FileName = "likes.jsonl"
+	// LegacyFileName is the JSON array written by older versions. It is
+	// converted when FileName doesn't exist yet.
+	LegacyFileName = "likes.json"
+)
+
+// Track is a single liked track.
+type Track struct {
+	Artist  string    `json:"artist"`
+	Title   string    `json:"title"`
+	Station string    `json:"station"`
+	LikedAt time.Time `json:"liked_at"`
+}
+
+// ArtistCount is one row of the artist-grouped view.
+type ArtistCount struct {
+	Artist    string
+	Count     int
+	LastLiked time.Time
+}
+
+// Backend persists the tracks of a Store.
+type Backend interface {
+	// Load returns every liked track, oldest first.
+	Load() ([]Track, error)
+	// Append stores t after the existing tracks.
+	Append(t Track) error
+}
+
+// Store keeps liked tracks in memory and persists them through a Backend.
+type Store struct {
+	mu      sync.Mutex
+	backend Backend
+	tracks  []Track
+}
+
+// DefaultPath returns the likes file path next to the config file.
+func DefaultPath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileName), nil
+}
+
+// Open loads the likes file at path. A missing file yields an empty store;
+// the file is only created on the first Add.
+func Open(path string) (*Store, error) {
+	return New(NewFileBackend(path))
+}
+
+// OpenDefault opens the likes in db, or in the default likes file when db
+// is nil. A new database imports the likes file first.
+func OpenDefault(db *sql.DB) (*Store, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	if db == nil {
+		return Open(path)
+	}
+	b, err := NewSQLiteBackend(db, path)
+	if err != nil {
+		return nil, err
+	}
+	return New(b)
+}
+
+// New loads the tracks from b. On error the store is empty but still
+// usable.
+func New(b Backend) (*Store, error) {
+	s := &Store{backend: b}
+	tracks, err := b.Load()
+	if err != nil {
+		return s, err
+	}
+	s.tracks = tracks
+	return s, nil
+}
+
+// SplitTrack splits an ICY "Artist - Title" string. Without a separator the
+// whole string is the title.
+func SplitTrack(track string) (artist, title string) {
+	if artist, title, ok := strings.Cut(track, " - "); ok {
+		return strings.TrimSpace(artist), strings.TrimSpace(title)
+	}
+	return "", strings.TrimSpace(track)
+}
+
+// Add records t and saves it. Liking the same artist and title again
+// is a no-op and returns false.
+func (s *Store) Add(t Track) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.tracks {
+		if strings.EqualFold(existing.Artist, t.Artist) && strings.EqualFold(existing.Title, t.Title) {
+			return false, nil
+		}
+	}
+	if t.LikedAt.IsZero() {
+		t.LikedAt = time.Now()
+	}
+
+	s.tracks = append(s.tracks, t)
+	if err := s.backend.Append(t); err != nil {
+		s.tracks = s.tracks[:len(s.tracks)-1]
+		return false, err
+	}
+	return true, nil
+}
+
+// Tracks returns the liked tracks, most recent first.
+func (s *Store) Tracks() []Track {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tracks := make([]Track, len(s.tracks))
+	for i, t := range s.tracks {
+		tracks[len(s.tracks)-1-i] = t
+	}
+	return tracks
+}
+
+// ByArtist groups tracks by artist, case-insensitively, ordered by count
+// and then by the most recent like. Tracks without an artist are skipped.
+func ByArtist(tracks []Track) []ArtistCount {
+	index := make(map[string]int)
+	var groups []ArtistCount
+
+	for _, t := range tracks {
+		if t.Artist == "" {
+			continue
+		}
+		key := strings.ToLower(t.Artist)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, ArtistCount{Artist: t.Artist})
+		}
+		groups[i].Count++
+		if t.LikedAt.After(groups[i].LastLiked) {
+			groups[i].LastLiked = t.LikedAt
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].LastLiked.After(groups[j].LastLiked)
+	})
+	return groups
+}
+
+// LookupURL returns a Discogs artist search for artist.
+func LookupURL(artist string) string {
+	return "https://www.discogs.com/search/?type=artist&q=" + url.QueryEscape(artist)
+}
@@ -0,0 +1,184 @@
+package likes
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitTrack(t *testing.T) {
+	tests := []struct {
+		track      string
+		wantArtist string
+		wantTitle  string
+	}{
+		{"Bonobo - Kiara", "Bonobo", "Kiara"},
+		{"Boards of Canada - Roygbiv - Live", "Boards of Canada", "Roygbiv - Live"},
+		{"Station ID", "", "Station ID"},
+		{"  Air -  Alone in Kyoto ", "Air", "Alone in Kyoto"},
+	}
+
+	for _, tt := range tests {
+		artist, title := SplitTrack(tt.track)
+		if artist != tt.wantArtist || title != tt.wantTitle {
+			t.Errorf("SplitTrack(%q) = (%q, %q), want (%q, %q)", tt.track, artist, title, tt.wantArtist, tt.wantTitle)
+		}
+	}
+}
+
+func TestStoreAddAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", FileName)
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Open() should not create the file")
+	}
+
+	added, err := store.Add(Track{Artist: "Bonobo", Title: "Kiara", Station: "groovesalad"})
+	if err != nil || !added {
+		t.Fatalf("Add() = %v, %v, want true, nil", added, err)
+	}
+	added, err = store.Add(Track{Artist: "bonobo", Title: "KIARA", Station: "dronezone"})
+	if err != nil || added {
+		t.Fatalf("Add() duplicate = %v, %v, want false, nil", added, err)
+	}
+	if _, err := store.Add(Track{Artist: "Air", Title: "Alone in Kyoto", Station: "groovesalad"}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	reloaded, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() reload error = %v", err)
+	}
+	tracks := reloaded.Tracks()
+	if len(tracks) != 2 {
+		t.Fatalf("Tracks() returned %d, want 2", len(tracks))
+	}
+	if tracks[0].Artist != "Air" {
+		t.Errorf("Tracks()[0].Artist = %q, want most recent first", tracks[0].Artist)
+	}
+	if tracks[1].LikedAt.IsZero() {
+		t.Error("Add() should set LikedAt")
+	}
+}
+
+func TestOpenSkipsInvalidLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	_ = os.WriteFile(path, []byte("not json\n{\"artist\":\"Air\",\"title\":\"La femme d'argent\"}\n"), 0644)
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v, want the invalid line skipped", err)
+	}
+	if tracks := store.Tracks(); len(tracks) != 1 || tracks[0].Artist != "Air" {
+		t.Errorf("Tracks() = %v, want the one valid like", tracks)
+	}
+}
+
+func TestOpenConvertsLegacyFile(t *testing.T) {
+	dir := t.TempDir()
+	legacy := `[{"artist": "Bonobo", "title": "Kiara", "station": "groovesalad", "liked_at": "2026-01-01T00:00:00Z"}]`
+	if err := os.WriteFile(filepath.Join(dir, LegacyFileName), []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, FileName)
+	if _, err := Open(path); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if err := os.Remove(filepath.Join(dir, LegacyFileName)); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() reload error = %v", err)
+	}
+	if tracks := reloaded.Tracks(); len(tracks) != 1 || tracks[0].Artist != "Bonobo" {
+		t.Errorf("Tracks() = %+v, want the converted like", tracks)
+	}
+}
+
+func TestByArtist(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracks := []Track{
+		{Artist: "Air", Title: "A", LikedAt: base},
+		{Artist: "Bonobo", Title: "B", LikedAt: base.Add(time.Hour)},
+		{Artist: "air", Title: "C", LikedAt: base.Add(2 * time.Hour)},
+		{Artist: "Tycho", Title: "D", LikedAt: base.Add(3 * time.Hour)},
+		{Title: "Station ID", LikedAt: base.Add(4 * time.Hour)},
+	}
+
+	groups := ByArtist(tracks)
+	want := []ArtistCount{
+		{Artist: "Air", Count: 2, LastLiked: base.Add(2 * time.Hour)},
+		{Artist: "Tycho", Count: 1, LastLiked: base.Add(3 * time.Hour)},
+		{Artist: "Bonobo", Count: 1, LastLiked: base.Add(time.Hour)},
+	}
+
+	if len(groups) != len(want) {
+		t.Fatalf("ByArtist() returned %d groups, want %d: %v", len(groups), len(want), groups)
+	}
+	for i := range want {
+		if groups[i] != want[i] {
+			t.Errorf("ByArtist()[%d] = %+v, want %+v", i, groups[i], want[i])
+		}
+	}
+}
+
+func TestLookupURL(t *testing.T) {
+	want := "https://www.discogs.com/search/?type=artist&q=Boards+of+Canada"
+	if got := LookupURL("Boards of Canada"); got != want {
+		t.Errorf("LookupURL() = %q, want %q", got, want)
+	}
+}
+
+func TestExport(t *testing.T) {
+	likedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tracks := []Track{
+		{Artist: "Bonobo", Title: "Kiara, Pt. 2", Station: "groovesalad", LikedAt: likedAt},
+		{Title: "Station ID", Station: "dronezone", LikedAt: likedAt},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{FormatCSV, "artist,title,station,liked_at\n" +
+			"Bonobo,\"Kiara, Pt. 2\",groovesalad,2024-05-01T12:00:00Z\n" +
+			",Station ID,dronezone,2024-05-01T12:00:00Z\n"},
+		{FormatText, "Bonobo - Kiara, Pt. 2\nStation ID\n"},
+	}
+
+	for _, tt := range tests {
+		var b strings.Builder
+		if err := Export(&b, tracks, tt.format); err != nil {
+			t.Fatalf("Export(%s) error = %v", tt.format, err)
+		}
+		if b.String() != tt.want {
+			t.Errorf("Export(%s) = %q, want %q", tt.format, b.String(), tt.want)
+		}
+	}
+
+	var b strings.Builder
+	if err := Export(&b, tracks, FormatJSON); err != nil {
+		t.Fatalf("Export(json) error = %v", err)
+	}
+	var got []Track
+	if err := json.Unmarshal([]byte(b.String()), &got); err != nil {
+		t.Fatalf("Export(json) wrote invalid JSON: %v", err)
+	}
+	if len(got) != 2 || got[0].Title != "Kiara, Pt. 2" || !got[1].LikedAt.Equal(likedAt) {
+		t.Errorf("Export(json) round trip = %+v", got)
+	}
+
+	if err := Export(&b, tracks, "xml"); err == nil {
+		t.Error("Export(xml) should fail")
+	}
+}
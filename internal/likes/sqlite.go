@@ -0,0 +1,85 @@
+package likes
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS likes (
+	id       INTEGER PRIMARY KEY,
+	artist   TEXT    NOT NULL,
+	title    TEXT    NOT NULL,
+	station  TEXT    NOT NULL,
+	liked_at INTEGER NOT NULL
+);`
+
+// SQLiteBackend keeps liked tracks in a SQLite table.
+type SQLiteBackend struct {
+	db *sql.DB
+}
+
+// NewSQLiteBackend creates the likes table in db if needed. While the table
+// is empty, the likes file at importPath is copied into it.
+func NewSQLiteBackend(db *sql.DB, importPath string) (*SQLiteBackend, error) {
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return nil, fmt.Errorf("failed to create likes table: %w", err)
+	}
+	b := &SQLiteBackend{db: db}
+	if importPath == "" {
+		return b, nil
+	}
+
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM likes`).Scan(&n); err != nil {
+		return nil, fmt.Errorf("failed to count likes: %w", err)
+	}
+	if n > 0 {
+		return b, nil
+	}
+	tracks, err := NewFileBackend(importPath).Load()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tracks {
+		if err := b.Append(t); err != nil {
+			return nil, fmt.Errorf("failed to import likes file: %w", err)
+		}
+	}
+	return b, nil
+}
+
+// Load returns every liked track, oldest first.
+func (b *SQLiteBackend) Load() ([]Track, error) {
+	rows, err := b.db.Query(`SELECT artist, title, station, liked_at FROM likes ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read likes: %w", err)
+	}
+	defer rows.Close()
+
+	var tracks []Track
+	for rows.Next() {
+		var t Track
+		var likedAt int64
+		if err := rows.Scan(&t.Artist, &t.Title, &t.Station, &likedAt); err != nil {
+			return nil, fmt.Errorf("failed to read likes: %w", err)
+		}
+		t.LikedAt = time.Unix(0, likedAt)
+		tracks = append(tracks, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read likes: %w", err)
+	}
+	return tracks, nil
+}
+
+// Append inserts t.
+func (b *SQLiteBackend) Append(t Track) error {
+	_, err := b.db.Exec(`INSERT INTO likes (artist, title, station, liked_at) VALUES (?, ?, ?, ?)`,
+		t.Artist, t.Title, t.Station, t.LikedAt.UnixNano())
+	if err != nil {
+		return fmt.Errorf("failed to save like: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,414 @@
+// Package mpd speaks a subset of the Music Player Daemon protocol, so MPD
+// clients such as ncmpcpp or phone remotes can control the player. The
+// station list appears as the queue: playing song N plays station N.
+package mpd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/control"
+	"github.com/glebovdev/somafm-cli/internal/publish"
+	"github.com/glebovdev/somafm-cli/internal/service"
+	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/rs/zerolog/log"
+)
+
+// Version is the protocol version announced to clients.
+const Version = "0.23.0"
+
+// IdlePollInterval is how often an idle client's player state is checked
+// for changes.
+const IdlePollInterval = time.Second
+
+// MPD error codes, from the protocol's ack.h.
+const (
+	ackArg     = 2
+	ackNoExist = 50
+	ackSystem  = 52
+	ackUnknown = 5
+)
+
+// ackError is a failed command, sent to the client as an ACK line.
+type ackError struct {
+	code    int
+	message string
+}
+
+func (e *ackError) Error() string { return e.message }
+
+func ack(code int, format string, args ...any) error {
+	return &ackError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// Server answers MPD clients for a control target.
+type Server struct {
+	target   control.Target
+	stations *service.StationService
+}
+
+// NewServer returns a server that drives t, with stations as the queue.
+func NewServer(t control.Target, stations *service.StationService) *Server {
+	return &Server{target: t, stations: stations}
+}
+
+// Serve answers MPD clients on ln until ctx is done.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go s.serveConn(ctx, conn)
+	}
+}
+
+// ListenAndServe serves MPD clients on addr until ctx is done.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+func (s *Server) serveConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	w := bufio.NewWriter(conn)
+	fmt.Fprintf(w, "OK MPD %s\n", Version)
+	if w.Flush() != nil {
+		return
+	}
+
+	// Lines are read separately so that "noidle" can interrupt "idle"
+	lines := make(chan string)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var list []string
+	inList, listOK := false, false
+	for line := range lines {
+		line = strings.TrimSpace(line)
+		if looksLikeHTTP(line) {
+			log.Warn().Msgf("Closing MPD connection from %s that sent HTTP", conn.RemoteAddr())
+			return
+		}
+		switch {
+		case line == "command_list_begin" || line == "command_list_ok_begin":
+			inList, listOK, list = true, line == "command_list_ok_begin", nil
+			continue
+		case inList && line != "command_list_end":
+			list = append(list, line)
+			continue
+		case inList:
+			inList = false
+		default:
+			list, listOK = []string{line}, false
+		}
+
+		failed := false
+		for i, command := range list {
+			name, _, _ := strings.Cut(command, " ")
+			if name == "close" {
+				w.Flush()
+				return
+			}
+			var err error
+			if name == "idle" {
+				err = s.idle(ctx, w, lines)
+			} else {
+				err = s.handle(w, command)
+			}
+			if err != nil {
+				code := ackSystem
+				if a, ok := err.(*ackError); ok {
+					code = a.code
+				}
+				fmt.Fprintf(w, "ACK [%d@%d] {%s} %s\n", code, i, name, err)
+				failed = true
+				break
+			}
+			if listOK {
+				fmt.Fprintln(w, "list_OK")
+			}
+		}
+		if !inList && !failed {
+			fmt.Fprintln(w, "OK")
+		}
+		if w.Flush() != nil {
+			return
+		}
+	}
+}
+
+// httpRequestLine matches the first line of an HTTP request.
+var httpRequestLine = regexp.MustCompile(`^[A-Z]+ \S+ HTTP/1\.[01]$`)
+
+// looksLikeHTTP reports whether line is part of an HTTP request, such as a
+// web page posting MPD commands to the port in a request body.
+func looksLikeHTTP(line string) bool {
+	if httpRequestLine.MatchString(line) {
+		return true
+	}
+	name, _, _ := strings.Cut(line, ":")
+	return strings.EqualFold(name, "Host") || strings.EqualFold(name, "Origin")
+}
+
+// idle waits until playback changes or the client sends noidle.
+func (s *Server) idle(ctx context.Context, w *bufio.Writer, lines <-chan string) error {
+	before := s.target.Status()
+	ticker := time.NewTicker(IdlePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case line, ok := <-lines:
+			if !ok || strings.TrimSpace(line) == "noidle" {
+				return nil
+			}
+		case <-ticker.C:
+			now := s.target.Status()
+			if now.Volume != before.Volume {
+				fmt.Fprintln(w, "changed: mixer")
+			}
+			if now.State != before.State || now.Station != before.Station || now.Track != before.Track {
+				fmt.Fprintln(w, "changed: player")
+			}
+			if now.Volume != before.Volume || now.State != before.State ||
+				now.Station != before.Station || now.Track != before.Track {
+				return nil
+			}
+		}
+	}
+}
+
+// handle runs one command, writing its response lines to w.
+func (s *Server) handle(w *bufio.Writer, command string) error {
+	name, args := parseCommand(command)
+	log.Debug().Msgf("MPD command: %s", command)
+
+	switch name {
+	case "ping", "clearerror", "noidle":
+		return nil
+	case "status":
+		s.writeStatus(w)
+	case "currentsong":
+		status := s.target.Status()
+		if pos := s.stations.FindIndexByID(station.StationID(status.Station)); pos >= 0 && status.State != "idle" {
+			s.writeSong(w, pos, status)
+		}
+	case "playlistinfo", "playlistid", "plchanges":
+		for pos := 0; pos < s.stations.StationCount(); pos++ {
+			s.writeSong(w, pos, publish.Event{})
+		}
+	case "plchangesposid":
+		for pos := 0; pos < s.stations.StationCount(); pos++ {
+			fmt.Fprintf(w, "cpos: %d\nId: %d\n", pos, pos+1)
+		}
+	case "play", "playid":
+		return s.play(name, args)
+	case "pause":
+		return s.pause(args)
+	case "stop":
+		s.target.Stop()
+	case "next":
+		_, err := s.target.Next()
+		return err
+	case "previous":
+		return s.previous()
+	case "setvol":
+		if len(args) != 1 {
+			return ack(ackArg, "wrong number of arguments for \"setvol\"")
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return ack(ackArg, "Integer expected: %s", args[0])
+		}
+		s.target.SetVolume(n)
+	case "volume":
+		if len(args) != 1 {
+			return ack(ackArg, "wrong number of arguments for \"volume\"")
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return ack(ackArg, "Integer expected: %s", args[0])
+		}
+		s.target.SetVolume(s.target.Status().Volume + n)
+	case "outputs":
+		fmt.Fprint(w, "outputid: 0\noutputname: somafm\nplugin: beep\noutputenabled: 1\n")
+	case "stats":
+		fmt.Fprintf(w, "artists: 0\nalbums: 0\nsongs: %d\nuptime: 0\nplaytime: 0\ndb_playtime: 0\n", s.stations.StationCount())
+	case "replay_gain_status":
+		fmt.Fprintln(w, "replay_gain_mode: off")
+	case "commands":
+		for _, c := range supportedCommands {
+			fmt.Fprintf(w, "command: %s\n", c)
+		}
+	case "notcommands", "tagtypes", "urlhandlers", "decoders", "listplaylists", "lsinfo", "list", "listall", "listallinfo", "find", "search":
+		// Nothing to list: there is no music database
+	default:
+		return ack(ackUnknown, "unknown command \"%s\"", name)
+	}
+	return nil
+}
+
+var supportedCommands = []string{
+	"close", "commands", "currentsong", "idle", "next", "noidle", "outputs", "pause", "ping",
+	"play", "playid", "playlistid", "playlistinfo", "plchanges", "previous", "setvol", "stats",
+	"status", "stop", "volume",
+}
+
+// parseCommand splits a command line into its name and arguments, which
+// may be double-quoted.
+func parseCommand(line string) (string, []string) {
+	var fields []string
+	var current strings.Builder
+	inQuotes, escaped, started := false, false, false
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && inQuotes:
+			escaped = true
+		case r == '"':
+			inQuotes = !inQuotes
+			started = true
+		case r == ' ' && !inQuotes:
+			if started {
+				fields = append(fields, current.String())
+				current.Reset()
+				started = false
+			}
+		default:
+			current.WriteRune(r)
+			started = true
+		}
+	}
+	if started {
+		fields = append(fields, current.String())
+	}
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return fields[0], fields[1:]
+}
+
+func mpdState(state string) string {
+	switch state {
+	case "playing", "buffering", "reconnecting":
+		return "play"
+	case "paused":
+		return "pause"
+	default:
+		return "stop"
+	}
+}
+
+func (s *Server) writeStatus(w *bufio.Writer) {
+	status := s.target.Status()
+	fmt.Fprintf(w, "volume: %d\nrepeat: 0\nrandom: 0\nsingle: 0\nconsume: 0\n", status.Volume)
+	fmt.Fprintf(w, "playlist: 1\nplaylistlength: %d\nstate: %s\n", s.stations.StationCount(), mpdState(status.State))
+	if pos := s.stations.FindIndexByID(station.StationID(status.Station)); pos >= 0 && status.State != "idle" {
+		fmt.Fprintf(w, "song: %d\nsongid: %d\n", pos, pos+1)
+	}
+}
+
+// writeSong describes the station at pos as a queue entry. When status is
+// for that station, the playing track is its title, as MPD shows for
+// radio streams.
+func (s *Server) writeSong(w *bufio.Writer, pos int, status publish.Event) {
+	st := s.stations.GetStation(pos)
+	if st == nil {
+		return
+	}
+	fmt.Fprintf(w, "file: somafm://%s\n", st.ID)
+	if status.Track != "" && status.Station == st.ID.String() {
+		fmt.Fprintf(w, "Title: %s\n", status.Track)
+	} else {
+		fmt.Fprintf(w, "Title: %s\n", st.Title)
+	}
+	fmt.Fprintf(w, "Name: %s\n", st.Title)
+	if st.Genre != "" {
+		fmt.Fprintf(w, "Genre: %s\n", strings.ReplaceAll(st.Genre, "|", ", "))
+	}
+	fmt.Fprintf(w, "Pos: %d\nId: %d\n", pos, pos+1)
+}
+
+// play starts the station at a queue position (play) or with a song ID
+// (playid). Without an argument it resumes, or replays the last station.
+func (s *Server) play(name string, args []string) error {
+	status := s.target.Status()
+	if len(args) == 0 {
+		if status.State == "paused" {
+			_, err := s.target.TogglePause()
+			return err
+		}
+		if status.Station != "" {
+			return s.target.Play(station.StationID(status.Station))
+		}
+		args = []string{"0"}
+		if name == "playid" {
+			args = []string{"1"}
+		}
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return ack(ackArg, "Integer expected: %s", args[0])
+	}
+	if name == "playid" {
+		n--
+	}
+	st := s.stations.GetStation(n)
+	if st == nil {
+		return ack(ackNoExist, "No such song")
+	}
+	return s.target.Play(st.ID)
+}
+
+// pause sets pause (1) or resume (0), or toggles without an argument.
+func (s *Server) pause(args []string) error {
+	paused := s.target.Status().State == "paused"
+	if len(args) == 1 && (args[0] == "1") == paused {
+		return nil
+	}
+	_, err := s.target.TogglePause()
+	return err
+}
+
+func (s *Server) previous() error {
+	count := s.stations.StationCount()
+	if count == 0 {
+		return ack(ackNoExist, "No such song")
+	}
+	pos := max(s.stations.FindIndexByID(station.StationID(s.target.Status().Station)), 0)
+	return s.target.Play(s.stations.GetStation((pos - 1 + count) % count).ID)
+}
@@ -0,0 +1,199 @@
+package mpd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/glebovdev/somafm-cli/internal/api"
+	"github.com/glebovdev/somafm-cli/internal/publish"
+	"github.com/glebovdev/somafm-cli/internal/service"
+	"github.com/glebovdev/somafm-cli/internal/station"
+)
+
+// fakeTarget plays instantly and remembers what it was told.
+type fakeTarget struct {
+	mu     sync.Mutex
+	status publish.Event
+}
+
+func (f *fakeTarget) Play(id station.StationID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.status.State, f.status.Station, f.status.Track = "playing", id.String(), "Artist - Title"
+	return nil
+}
+
+func (f *fakeTarget) Next() (station.StationID, error) { return "", errors.New("not implemented") }
+
+func (f *fakeTarget) Stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.status.State, f.status.Station = "idle", ""
+}
+
+func (f *fakeTarget) TogglePause() (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.status.State == "paused" {
+		f.status.State = "playing"
+		return false, nil
+	}
+	f.status.State = "paused"
+	return true, nil
+}
+
+func (f *fakeTarget) SetVolume(percent int) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.status.Volume = percent
+	return percent
+}
+
+func (f *fakeTarget) Status() publish.Event {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.status
+}
+
+func newTestServer(t *testing.T) (*fakeTarget, *bufio.ReadWriter) {
+	t.Helper()
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"channels":[
+			{"id":"groovesalad","title":"Groove Salad","genre":"ambient|electronica","listeners":"200"},
+			{"id":"dronezone","title":"Drone Zone","genre":"ambient","listeners":"100"}]}`))
+	}))
+	t.Cleanup(apiServer.Close)
+	stations := service.NewStationService(api.NewSomaFMClientWithBaseURL(apiServer.URL), service.CacheDisabled)
+	if _, err := stations.GetStations(context.Background()); err != nil {
+		t.Fatalf("GetStations() error = %v", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	target := &fakeTarget{status: publish.Event{State: "idle", Volume: 70}}
+	go func() { _ = NewServer(target, stations).Serve(ctx, ln) }()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	if greeting, _ := rw.ReadString('\n'); greeting != "OK MPD "+Version+"\n" {
+		t.Fatalf("greeting = %q", greeting)
+	}
+	return target, rw
+}
+
+// send writes command and returns the response lines up to OK or ACK.
+func send(t *testing.T, rw *bufio.ReadWriter, command string) []string {
+	t.Helper()
+	rw.WriteString(command + "\n")
+	rw.Flush()
+	var lines []string
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			t.Fatalf("%s: %v", command, err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		lines = append(lines, line)
+		if line == "OK" || strings.HasPrefix(line, "ACK ") {
+			return lines
+		}
+	}
+}
+
+func TestPlayAndStatus(t *testing.T) {
+	target, rw := newTestServer(t)
+
+	if got := send(t, rw, "status"); !contains(got, "state: stop") || !contains(got, "playlistlength: 2") {
+		t.Errorf("idle status = %v", got)
+	}
+	send(t, rw, "play 1")
+	if target.Status().Station != "dronezone" {
+		t.Fatalf("play 1 played %q, want dronezone", target.Status().Station)
+	}
+	if got := send(t, rw, "status"); !contains(got, "state: play") || !contains(got, "song: 1") {
+		t.Errorf("playing status = %v", got)
+	}
+	if got := send(t, rw, "currentsong"); !contains(got, "Title: Artist - Title") || !contains(got, "Name: Drone Zone") {
+		t.Errorf("currentsong = %v", got)
+	}
+
+	send(t, rw, "pause 1")
+	send(t, rw, "pause 1")
+	if target.Status().State != "paused" {
+		t.Errorf("state after pause 1 twice = %q, want paused", target.Status().State)
+	}
+	send(t, rw, `setvol "40"`)
+	if target.Status().Volume != 40 {
+		t.Errorf("volume = %d, want 40", target.Status().Volume)
+	}
+	send(t, rw, "previous")
+	if target.Status().Station != "groovesalad" {
+		t.Errorf("previous played %q, want groovesalad", target.Status().Station)
+	}
+}
+
+func TestErrorsAndCommandLists(t *testing.T) {
+	_, rw := newTestServer(t)
+
+	if got := send(t, rw, "play 9"); got[0] != "ACK [50@0] {play} No such song" {
+		t.Errorf("play 9 = %v", got)
+	}
+	if got := send(t, rw, "update"); got[0] != `ACK [5@0] {update} unknown command "update"` {
+		t.Errorf("update = %v", got)
+	}
+
+	rw.WriteString("command_list_ok_begin\nping\nsetvol 10\ncommand_list_end\n")
+	rw.Flush()
+	for _, want := range []string{"list_OK", "list_OK", "OK"} {
+		if line, _ := rw.ReadString('\n'); line != want+"\n" {
+			t.Errorf("command list line = %q, want %q", line, want)
+		}
+	}
+}
+
+func TestRefusesHTTP(t *testing.T) {
+	target, rw := newTestServer(t)
+
+	rw.WriteString("POST / HTTP/1.1\nHost: 127.0.0.1:6600\n\nsetvol 5\n")
+	rw.Flush()
+	if line, err := rw.ReadString('\n'); err == nil {
+		t.Errorf("server answered %q to an HTTP request, want the connection closed", line)
+	}
+	if target.Status().Volume != 70 {
+		t.Errorf("volume = %d, want the HTTP body ignored", target.Status().Volume)
+	}
+	if !looksLikeHTTP("origin: http://evil.example") || looksLikeHTTP("find artist Air") {
+		t.Error("looksLikeHTTP() misjudged a header or a command")
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	name, args := parseCommand(`find "artist" "Boards of \"Canada\""`)
+	if name != "find" || len(args) != 2 || args[1] != `Boards of "Canada"` {
+		t.Errorf("parseCommand() = %q, %q", name, args)
+	}
+}
+
+func contains(lines []string, want string) bool {
+	for _, line := range lines {
+		if line == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+// Package outputdev notices when the audio output goes away, e.g.
+// headphones pulled from the jack or a Bluetooth headset switched off, so
+// playback can pause instead of carrying on through the laptop speakers.
+//
+// The player writes to the sound server's default output, which moves to
+// another device on its own when one disappears. On Linux the package
+// follows PulseAudio or PipeWire (through pipewire-pulse) with pactl;
+// elsewhere Watch returns ErrUnsupported.
+package outputdev
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrUnsupported is returned where the platform reports no device changes.
+var ErrUnsupported = errors.New("output device events are not supported on this platform")
+
+// Output is the sound server's default sink and the port it plays through.
+type Output struct {
+	Sink string
+	Port string // Empty for sinks without ports, e.g. Bluetooth
+	// Name describes the port if there is one, otherwise the sink, e.g.
+	// "Headphones".
+	Name string
+}
+
+func (o Output) String() string {
+	if o.Name != "" {
+		return o.Name
+	}
+	return o.Sink
+}
+
+// sink is one entry of `pactl list sinks`.
+type sink struct {
+	name        string
+	description string
+	activePort  string
+	ports       map[string]string // Port name to description
+	unavailable map[string]bool   // Ports reported as not available
+}
+
+// parseSinks reads the output of `pactl list sinks` in the C locale.
+func parseSinks(out string) []sink {
+	var sinks []sink
+	var cur *sink
+	inPorts := false
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "Sink #") {
+			sinks = append(sinks, sink{ports: map[string]string{}, unavailable: map[string]bool{}})
+			cur = &sinks[len(sinks)-1]
+			inPorts = false
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		// Port lines are indented one level deeper than the "Ports:" header
+		if inPorts && strings.HasPrefix(line, "\t\t") {
+			name, rest, ok := strings.Cut(strings.TrimSpace(line), ": ")
+			if !ok {
+				continue
+			}
+			desc, _, _ := strings.Cut(rest, " (")
+			cur.ports[name] = desc
+			if strings.HasSuffix(rest, "not available)") {
+				cur.unavailable[name] = true
+			}
+			continue
+		}
+		inPorts = false
+		key, value, _ := strings.Cut(strings.TrimSpace(line), ": ")
+		switch key {
+		case "Name":
+			cur.name = value
+		case "Description":
+			cur.description = value
+		case "Active Port":
+			cur.activePort = value
+		case "Ports:":
+			inPorts = true
+		}
+	}
+	return sinks
+}
+
+// parseDefaultSink reads the default sink's name from `pactl info`.
+func parseDefaultSink(out string) string {
+	for _, line := range strings.Split(out, "\n") {
+		if name, ok := strings.CutPrefix(line, "Default Sink: "); ok {
+			return strings.TrimSpace(name)
+		}
+	}
+	return ""
+}
+
+// defaultOutput finds the default sink among sinks.
+func defaultOutput(name string, sinks []sink) Output {
+	for _, s := range sinks {
+		if s.name != name {
+			continue
+		}
+		out := Output{Sink: s.name, Port: s.activePort, Name: s.description}
+		if desc := s.ports[s.activePort]; desc != "" {
+			out.Name = desc
+		}
+		return out
+	}
+	return Output{Sink: name}
+}
+
+// lost reports whether o has gone: its sink was removed or its port is no
+// longer available.
+func lost(o Output, sinks []sink) bool {
+	if o.Sink == "" {
+		return false
+	}
+	for _, s := range sinks {
+		if s.name == o.Sink {
+			return o.Port != "" && s.unavailable[o.Port]
+		}
+	}
+	return true
+}
+
+// tracker follows the default output from one look at the sinks to the
+// next.
+type tracker struct {
+	current Output
+	gone    bool // current was already reported lost
+}
+
+// update takes the default output and sinks after a change and returns
+// the output that disappeared since the last update, if any. An output is
+// reported once, even when the sound server is slow to move away from it.
+func (t *tracker) update(next Output, sinks []sink) (Output, bool) {
+	prev := t.current
+	gone := lost(prev, sinks)
+	fired := gone && !t.gone
+	if next != prev {
+		gone = lost(next, sinks)
+	}
+	t.current, t.gone = next, gone
+	return prev, fired
+}
+
+// relevant reports whether a `pactl subscribe` event line may change the
+// default output. Sink inputs, which change with every volume step, don't.
+func relevant(event string) bool {
+	for _, facility := range []string{" on sink #", " on server", " on card #"} {
+		if strings.Contains(event, facility) {
+			return true
+		}
+	}
+	return false
+}
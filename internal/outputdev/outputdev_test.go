@@ -0,0 +1,113 @@
+package outputdev
+
+import "testing"
+
+// listSinks builds `pactl list sinks` output for a laptop's built-in sink
+// with the headphone port in the given state, plus a Bluetooth headset.
+func listSinks(headphones, active string, bluetooth bool) string {
+	out := `Sink #56
+	State: RUNNING
+	Name: alsa_output.pci-0000_00_1f.3.analog-stereo
+	Description: Built-in Audio Analog Stereo
+	Volume: front-left: 39321 /  60% / -13.31 dB
+	Ports:
+		analog-output-speaker: Speakers (type: Speaker, priority: 10000, availability group: Legacy 3, availability unknown)
+		analog-output-headphones: Headphones (type: Headphones, priority: 9900, availability group: Legacy 2, ` + headphones + `)
+	Active Port: ` + active + `
+	Formats:
+		pcm
+`
+	if bluetooth {
+		out += `
+Sink #81
+	State: SUSPENDED
+	Name: bluez_output.AC_80_0A_00_00_01.1
+	Description: WH-1000XM4
+	Ports:
+	Active Port:
+	Formats:
+		pcm
+`
+	}
+	return out
+}
+
+const (
+	builtIn   = "alsa_output.pci-0000_00_1f.3.analog-stereo"
+	headset   = "bluez_output.AC_80_0A_00_00_01.1"
+	speaker   = "analog-output-speaker"
+	headphone = "analog-output-headphones"
+)
+
+func TestParseSinks(t *testing.T) {
+	sinks := parseSinks(listSinks("not available", speaker, true))
+	if len(sinks) != 2 {
+		t.Fatalf("parseSinks() found %d sinks, want 2", len(sinks))
+	}
+	if got := defaultOutput(builtIn, sinks); got != (Output{Sink: builtIn, Port: speaker, Name: "Speakers"}) {
+		t.Errorf("built-in output = %+v", got)
+	}
+	if got := defaultOutput(headset, sinks); got != (Output{Sink: headset, Name: "WH-1000XM4"}) {
+		t.Errorf("headset output = %+v", got)
+	}
+	if !sinks[0].unavailable[headphone] || sinks[0].unavailable[speaker] {
+		t.Errorf("unavailable ports = %v, want only the headphones", sinks[0].unavailable)
+	}
+}
+
+func TestParseDefaultSink(t *testing.T) {
+	info := "Server Name: PulseAudio (on PipeWire 1.0.5)\nDefault Sink: " + headset + "\nDefault Source: x\n"
+	if got := parseDefaultSink(info); got != headset {
+		t.Errorf("parseDefaultSink() = %q, want %q", got, headset)
+	}
+}
+
+func TestTrackerHeadphonesUnplugged(t *testing.T) {
+	sinks := parseSinks(listSinks("available", headphone, false))
+	start := defaultOutput(builtIn, sinks)
+	tr := &tracker{current: start, gone: lost(start, sinks)}
+
+	// The jack reports the headphones gone before the port moves
+	sinks = parseSinks(listSinks("not available", headphone, false))
+	out, ok := tr.update(defaultOutput(builtIn, sinks), sinks)
+	if !ok || out.Name != "Headphones" {
+		t.Errorf("update() = %+v, %v, want the headphones lost", out, ok)
+	}
+
+	sinks = parseSinks(listSinks("not available", speaker, false))
+	if out, ok := tr.update(defaultOutput(builtIn, sinks), sinks); ok {
+		t.Errorf("update() reported %+v again after moving to the speakers", out)
+	}
+
+	// Plugging them back in is not a loss
+	sinks = parseSinks(listSinks("available", headphone, false))
+	if out, ok := tr.update(defaultOutput(builtIn, sinks), sinks); ok {
+		t.Errorf("update() reported %+v lost when headphones were plugged in", out)
+	}
+}
+
+func TestTrackerSinkRemoved(t *testing.T) {
+	sinks := parseSinks(listSinks("not available", speaker, true))
+	tr := &tracker{current: defaultOutput(headset, sinks)}
+
+	sinks = parseSinks(listSinks("not available", speaker, false))
+	out, ok := tr.update(defaultOutput(builtIn, sinks), sinks)
+	if !ok || out.String() != "WH-1000XM4" {
+		t.Errorf("update() = %+v, %v, want the headset lost", out, ok)
+	}
+}
+
+func TestRelevant(t *testing.T) {
+	tests := map[string]bool{
+		"Event 'remove' on sink #81":        true,
+		"Event 'change' on server #4294967": true,
+		"Event 'change' on card #47":        true,
+		"Event 'change' on sink-input #212": false,
+		"Event 'new' on source-output #9":   false,
+	}
+	for event, want := range tests {
+		if got := relevant(event); got != want {
+			t.Errorf("relevant(%q) = %v, want %v", event, got, want)
+		}
+	}
+}
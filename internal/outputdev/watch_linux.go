@@ -0,0 +1,86 @@
+package outputdev
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Watch calls onLost from its own goroutine each time the default output
+// disappears, until ctx is done. It fails if the sound server can't be
+// reached, e.g. on a system with bare ALSA.
+func Watch(ctx context.Context, onLost func(Output)) error {
+	pactl, err := exec.LookPath("pactl")
+	if err != nil {
+		return errors.New("pactl not found")
+	}
+	current, sinks, err := query(ctx, pactl)
+	if err != nil {
+		return err
+	}
+	t := &tracker{current: current, gone: lost(current, sinks)}
+
+	cmd := exec.CommandContext(ctx, pactl, "subscribe")
+	cmd.Env = cLocale()
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start pactl subscribe: %w", err)
+	}
+	log.Debug().Msgf("Watching audio output %s", current)
+
+	go func() {
+		defer cmd.Wait()
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			if !relevant(scanner.Text()) {
+				continue
+			}
+			next, sinks, err := query(ctx, pactl)
+			if err != nil {
+				log.Debug().Err(err).Msg("Failed to read audio outputs")
+				continue
+			}
+			if out, ok := t.update(next, sinks); ok {
+				onLost(out)
+			}
+		}
+	}()
+	return nil
+}
+
+// query returns the default output and all sinks.
+func query(ctx context.Context, pactl string) (Output, []sink, error) {
+	info, err := run(ctx, pactl, "info")
+	if err != nil {
+		return Output{}, nil, err
+	}
+	list, err := run(ctx, pactl, "list", "sinks")
+	if err != nil {
+		return Output{}, nil, err
+	}
+	sinks := parseSinks(list)
+	return defaultOutput(parseDefaultSink(info), sinks), sinks, nil
+}
+
+func run(ctx context.Context, pactl string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, pactl, args...)
+	cmd.Env = cLocale()
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("pactl %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+// cLocale keeps pactl's output in English, which is what the parsers read.
+func cLocale() []string {
+	return append(os.Environ(), "LC_ALL=C")
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package outputdev
+
+import "context"
+
+// Watch is only implemented on Linux.
+func Watch(ctx context.Context, onLost func(Output)) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,91 @@
+package player
+
+import (
+	"sync"
+	"time"
+)
+
+// BitrateWindow is the sliding window over which received bitrate is measured.
+const BitrateWindow = 5 * time.Second
+
+type byteSample struct {
+	at time.Time
+	n  int
+}
+
+// bitrateMeter measures network throughput over a sliding window.
+type bitrateMeter struct {
+	mu      sync.Mutex
+	started time.Time
+	samples []byteSample
+	now     func() time.Time
+}
+
+func (m *bitrateMeter) clock() time.Time {
+	if m.now != nil {
+		return m.now()
+	}
+	return time.Now()
+}
+
+func (m *bitrateMeter) add(n int) {
+	if n <= 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.clock()
+	if m.started.IsZero() {
+		m.started = now
+	}
+	m.samples = append(m.samples, byteSample{at: now, n: n})
+	m.prune(now)
+}
+
+func (m *bitrateMeter) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.started = time.Time{}
+	m.samples = nil
+}
+
+func (m *bitrateMeter) prune(now time.Time) {
+	cutoff := now.Add(-BitrateWindow)
+	i := 0
+	for i < len(m.samples) && m.samples[i].at.Before(cutoff) {
+		i++
+	}
+	m.samples = m.samples[i:]
+}
+
+// kbps returns the average bitrate in kilobits per second, or 0 until at
+// least a second of data has been observed.
+func (m *bitrateMeter) kbps() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.started.IsZero() {
+		return 0
+	}
+	now := m.clock()
+	m.prune(now)
+
+	span := min(now.Sub(m.started), BitrateWindow)
+	if span < time.Second {
+		return 0
+	}
+
+	total := 0
+	for _, s := range m.samples {
+		total += s.n
+	}
+	return int(float64(total*8) / span.Seconds() / 1000)
+}
+
+// GetMeasuredBitrate returns the bitrate actually received from the network
+// over the last BitrateWindow, in kbps. Returns 0 while not enough data has
+// arrived.
+func (p *Player) GetMeasuredBitrate() int {
+	return p.bitrate.kbps()
+}
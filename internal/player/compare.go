@@ -0,0 +1,262 @@
+package player
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gopxl/beep/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// Comparison is the state of an A/B comparison: A is the stream playing,
+// B another variant of the station connected alongside it.
+type Comparison struct {
+	A, B ComparisonSide
+	// HearingB is set while B is the one heard.
+	HearingB bool
+}
+
+// ComparisonSide is one stream of a comparison.
+type ComparisonSide struct {
+	Info StreamInfo
+	// Bitrate is the measured bitrate in kbps, 0 until known.
+	Bitrate int
+	// Buffer is the fill level as a percentage (0-100).
+	Buffer int
+}
+
+// comparisonStream is the B side of a comparison: a second connection
+// decoded into its own buffer. While A is heard it drops its oldest
+// samples, so switching to it doesn't replay old audio.
+type comparisonStream struct {
+	info    StreamInfo
+	samples chan [2]float64
+	bitrate bitrateMeter
+	hearing atomic.Bool
+	cancel  context.CancelFunc
+}
+
+// buffer returns the fill level as a percentage (0-100).
+func (c *comparisonStream) buffer() int {
+	return len(c.samples) * 100 / cap(c.samples)
+}
+
+// StartComparison connects to the first stream of playlistURL alongside
+// the one playing, for SwitchComparison to flip between them, e.g. to hear
+// the difference between 128k and 256k. A running comparison is replaced;
+// it ends with playback or StopComparison.
+func (p *Player) StartComparison(playlistURL string) error {
+	p.mu.Lock()
+	playing, station, sampleRate := p.isPlaying, p.currentStation, p.format.SampleRate
+	p.mu.Unlock()
+	if !playing {
+		return ErrNotPlaying
+	}
+	p.StopComparison()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c, streamer, err := p.connectComparison(ctx, playlistURL, sampleRate)
+	if err != nil {
+		cancel()
+		return err
+	}
+	c.cancel = cancel
+
+	// Playback may have stopped or moved to another station while B
+	// connected. Storing under mu lets a Stop that follows end B.
+	p.mu.Lock()
+	if !p.isPlaying || p.currentStation != station || p.format.SampleRate != sampleRate {
+		p.mu.Unlock()
+		cancel()
+		streamer.Close()
+		return ErrNotPlaying
+	}
+	if previous := p.comparison.Swap(c); previous != nil {
+		previous.cancel()
+	}
+	p.mu.Unlock()
+	p.journal.add(JournalConnect, "Comparing with %s %dk", c.info.Format, c.info.Bitrate)
+	go p.decodeComparison(ctx, c, streamer)
+	return nil
+}
+
+// connectComparison opens and starts decoding the first stream of
+// playlistURL, resampled to sampleRate.
+func (p *Player) connectComparison(ctx context.Context, playlistURL string, sampleRate beep.SampleRate) (*comparisonStream, beep.StreamCloser, error) {
+	streamURLs, err := p.cachedPlaylistFetcher(ctx, playlistURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := p.newStreamRequest(ctx, streamURLs[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	// Titles come from A; without metadata the body is plain audio
+	req.Header.Del("Icy-MetaData")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch stream: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, nil, &httpStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	guess := parseStreamInfoFromURL(streamURLs[0])
+	guess.PlaylistURL = playlistURL
+	c := &comparisonStream{
+		info:    streamInfoFromHeaders(resp.Header, guess),
+		samples: make(chan [2]float64, SampleChannelSize),
+	}
+	c.bitrate.now = p.bitrate.now
+
+	body := meteredBody{
+		Reader: &contextReader{reader: resp.Body, ctx: ctx, timeout: ReadTimeout},
+		Closer: resp.Body,
+		meter:  &c.bitrate,
+	}
+	codec, decode := selectDecoder(c.info.Format)
+	streamer, format, err := decode(body)
+	if err != nil {
+		resp.Body.Close()
+		return nil, nil, fmt.Errorf("failed to decode %s stream: %w", codec, err)
+	}
+	if headerSampleRate(resp.Header) == 0 {
+		c.info.SampleRate = int(format.SampleRate)
+	}
+	if format.SampleRate == sampleRate {
+		return c, streamer, nil
+	}
+	return c, resampled{Resampler: beep.Resample(4, format.SampleRate, sampleRate, streamer), closer: streamer}, nil
+}
+
+// decodeComparison fills the comparison buffer until ctx is done or the
+// stream fails, which ends the comparison.
+func (p *Player) decodeComparison(ctx context.Context, c *comparisonStream, streamer beep.StreamCloser) {
+	defer streamer.Close()
+
+	decoded := make([][2]float64, NetworkReadSize)
+	for ctx.Err() == nil {
+		n, ok := streamer.Stream(decoded)
+		if !ok {
+			if ctx.Err() == nil {
+				err := streamer.Err()
+				if err == nil {
+					err = io.ErrUnexpectedEOF
+				}
+				log.Warn().Err(err).Msg("Comparison stream ended")
+				p.journal.add(JournalError, "Comparison stream ended: %v", err)
+				p.endComparison(c)
+			}
+			return
+		}
+		// The channel is never closed, so Stream can't take the end of B
+		// for the end of playback
+		for _, sample := range decoded[:n] {
+			select {
+			case c.samples <- sample:
+			default:
+				select {
+				case <-c.samples:
+				default:
+				}
+				c.samples <- sample
+			}
+		}
+	}
+}
+
+// endComparison ends c if it's still the running comparison.
+func (p *Player) endComparison(c *comparisonStream) {
+	if !p.comparison.CompareAndSwap(c, nil) {
+		return
+	}
+	c.cancel()
+	if c.hearing.Load() {
+		p.fadeIn.Store(true)
+	}
+	p.notifyChange()
+}
+
+// StopComparison disconnects B, going back to A if B was heard.
+func (p *Player) StopComparison() {
+	if c := p.comparison.Load(); c != nil {
+		p.endComparison(c)
+	}
+}
+
+// SwitchComparison flips between A and B. It reports which one is heard
+// now, and false when no comparison is running.
+func (p *Player) SwitchComparison() (hearingB, ok bool) {
+	c := p.comparison.Load()
+	if c == nil {
+		return false, false
+	}
+	hearingB = !c.hearing.Load()
+	c.hearing.Store(hearingB)
+	p.fadeIn.Store(true)
+	p.notifyChange()
+	return hearingB, true
+}
+
+// Comparison returns the state of the running comparison.
+func (p *Player) Comparison() (Comparison, bool) {
+	c := p.comparison.Load()
+	if c == nil {
+		return Comparison{}, false
+	}
+	return Comparison{
+		A: ComparisonSide{
+			Info:    p.GetStreamInfo(),
+			Bitrate: p.GetMeasuredBitrate(),
+			Buffer:  p.GetBufferHealth(),
+		},
+		B: ComparisonSide{
+			Info:    c.info,
+			Bitrate: c.bitrate.kbps(),
+			Buffer:  c.buffer(),
+		},
+		HearingB: c.hearing.Load(),
+	}, true
+}
+
+// meteredBody is a response body that counts what is read from it.
+type meteredBody struct {
+	io.Reader
+	io.Closer
+	meter *bitrateMeter
+}
+
+func (b meteredBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	b.meter.add(n)
+	return n, err
+}
+
+// resampled closes the streamer under a Resampler.
+type resampled struct {
+	*beep.Resampler
+	closer beep.StreamCloser
+}
+
+func (r resampled) Close() error {
+	return r.closer.Close()
+}
+
+// drain discards up to n samples from ch without waiting.
+func drain(ch chan [2]float64, n int) {
+	for range n {
+		select {
+		case _, more := <-ch:
+			if !more {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package player
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/clock"
+	"github.com/rs/zerolog/log"
+)
+
+const playlistFetchTimeout = 10 * time.Second
+
+// RetryPolicy decides whether and how long to wait before retrying a failed
+// stream connection.
+type RetryPolicy interface {
+	// Delay returns the wait before the given retry (starting at 1), or
+	// false when no more retries should be made.
+	Delay(retry int) (time.Duration, bool)
+	// MaxRetries is the retry budget shown to the user.
+	MaxRetries() int
+}
+
+// FixedDelayPolicy retries up to Retries times, waiting Wait between attempts.
+type FixedDelayPolicy struct {
+	Retries int
+	Wait    time.Duration
+}
+
+func (f FixedDelayPolicy) Delay(retry int) (time.Duration, bool) {
+	return f.Wait, retry <= f.Retries
+}
+
+func (f FixedDelayPolicy) MaxRetries() int {
+	return f.Retries
+}
+
+// DefaultRetryPolicy is used by Play.
+var DefaultRetryPolicy RetryPolicy = FixedDelayPolicy{Retries: MaxRetries, Wait: RetryDelay}
+
+// streamConnector plays a single stream URL and blocks until it ends.
+type streamConnector func(ctx context.Context, streamURL string) error
+
+// playlistFetcher resolves a playlist URL into stream URLs.
+type playlistFetcher func(ctx context.Context, playlistURL string) ([]string, error)
+
+// connectionManager walks a station's playlists and their stream URLs,
+// retrying according to policy. Every attempt and retry wait is bound to the
+// context passed to run, so cancelling it stops the loop immediately.
+type connectionManager struct {
+	player  *Player
+	policy  RetryPolicy
+	fetch   playlistFetcher
+	connect streamConnector
+}
+
+func (m *connectionManager) run(ctx context.Context, playlistURLs []string) error {
+	p := m.player
+	maxRetries := m.policy.MaxRetries()
+
+	p.setState(StateBuffering)
+	p.setRetryInfo(0, maxRetries)
+	p.setCurrentTrack("")
+	p.setFallback(nil)
+
+	allErrors := make([]string, 0, MaxErrorsToKeep)
+	addError := func(msg string) {
+		p.journal.add(JournalError, "%s", msg)
+		if len(allErrors) < MaxErrorsToKeep {
+			allErrors = append(allErrors, msg)
+		}
+	}
+
+	for playlistIdx, playlistURL := range playlistURLs {
+		log.Debug().Msgf("Trying playlist %d/%d: %s", playlistIdx+1, len(playlistURLs), playlistURL)
+
+		streamInfo := parseStreamInfoFromURL(playlistURL)
+		streamInfo.PlaylistURL = playlistURL
+		if playlistIdx > 0 {
+			p.setFallback(&Fallback{
+				FailedURL:  playlistURLs[0],
+				PlayingURL: playlistURL,
+				Failed:     parseStreamInfoFromURL(playlistURLs[0]),
+				Playing:    streamInfo,
+			})
+		}
+
+		fetchCtx, cancel := context.WithTimeout(ctx, playlistFetchTimeout)
+		streamURLs, err := m.fetch(fetchCtx, playlistURL)
+		cancel()
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return context.Canceled
+			}
+			log.Warn().Err(err).Msgf("Failed to fetch playlist: %s", playlistURL)
+			addError(fmt.Sprintf("playlist %s: %v", playlistURL, err))
+			continue
+		}
+
+		log.Debug().Msgf("Found %d stream URLs in playlist", len(streamURLs))
+
+		for urlIdx, streamURL := range streamURLs {
+			for attempt := 0; ; attempt++ {
+				if attempt > 0 {
+					delay, ok := m.policy.Delay(attempt)
+					if !ok {
+						break
+					}
+					p.setState(StateReconnecting)
+					p.setRetryInfo(attempt, maxRetries)
+					p.journal.add(JournalReconnect, "Retry %d/%d in %v: %s", attempt, maxRetries, delay, streamURL)
+					log.Warn().Msgf("Stream failed, retrying in %v... (%d/%d)", delay, attempt, maxRetries)
+					if err := sleepContext(ctx, p.timeSource(), delay); err != nil {
+						return context.Canceled
+					}
+				}
+
+				log.Debug().Msgf("Trying stream %d/%d (attempt %d/%d): %s",
+					urlIdx+1, len(streamURLs), attempt, maxRetries, streamURL)
+
+				err := m.attempt(ctx, streamURL, streamInfo)
+				if err == nil {
+					return nil
+				}
+				if errors.Is(err, context.Canceled) || ctx.Err() != nil {
+					return context.Canceled
+				}
+
+				// StatePlaying means the stream connected and played before dropping
+				if p.GetState() == StatePlaying {
+					p.journal.add(JournalError, "Stream dropped: %v", err)
+					log.Info().Msg("Stream was playing, entering reconnect mode")
+					return m.reconnect(ctx, streamURLs, streamInfo)
+				}
+
+				if isNonRetryableError(err) {
+					log.Warn().Err(err).Msgf("Non-retryable error for %s, moving to next URL", streamURL)
+					addError(fmt.Sprintf("%s: %v", streamURL, err))
+					break
+				}
+
+				addError(fmt.Sprintf("%s (attempt %d): %v", streamURL, attempt, err))
+			}
+		}
+	}
+
+	p.setState(StateError)
+	p.setLastError("Connection failed")
+	return fmt.Errorf("all streams failed: %s", strings.Join(allErrors, "; "))
+}
+
+// reconnect rotates through streamURLs after a playing stream dropped.
+// If a stream recovers then drops again, the retry counter resets.
+func (m *connectionManager) reconnect(ctx context.Context, streamURLs []string, streamInfo StreamInfo) error {
+	p := m.player
+	maxRetries := m.policy.MaxRetries()
+	var lastErr error
+
+	for retry := 1; ; retry++ {
+		delay, ok := m.policy.Delay(retry)
+		if !ok {
+			break
+		}
+		streamURL := streamURLs[(retry-1)%len(streamURLs)]
+
+		p.setState(StateReconnecting)
+		p.setRetryInfo(retry, maxRetries)
+		p.journal.add(JournalReconnect, "Reconnect %d/%d in %v: %s", retry, maxRetries, delay, streamURL)
+		log.Warn().Msgf("Reconnecting in %v... (%d/%d) %s", delay, retry, maxRetries, streamURL)
+		if err := sleepContext(ctx, p.timeSource(), delay); err != nil {
+			return context.Canceled
+		}
+
+		err := m.attempt(ctx, streamURL, streamInfo)
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, context.Canceled) || ctx.Err() != nil {
+			return context.Canceled
+		}
+
+		lastErr = err
+		p.journal.add(JournalError, "%s: %v", streamURL, err)
+
+		// Stream recovered then dropped again — reset retry counter
+		if p.GetState() == StatePlaying {
+			log.Info().Msg("Stream was playing, resetting retry counter")
+			retry = 0
+		}
+	}
+
+	p.setState(StateError)
+	p.setLastError("Connection failed")
+	return fmt.Errorf("reconnection failed: %w", lastErr)
+}
+
+func (m *connectionManager) attempt(ctx context.Context, streamURL string, streamInfo StreamInfo) error {
+	attemptCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	m.player.setStreamInfo(streamInfo)
+	return m.connect(attemptCtx, streamURL)
+}
+
+// sleepContext waits for d on clk or until ctx is cancelled.
+func sleepContext(ctx context.Context, clk clock.Clock, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := clk.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
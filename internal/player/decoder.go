@@ -0,0 +1,165 @@
+package player
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os/exec"
+	"strings"
+
+	"github.com/gopxl/beep/v2"
+	"github.com/gopxl/beep/v2/mp3"
+	"github.com/rs/zerolog/log"
+)
+
+// Decoder turns a compressed audio stream into samples. Closing the returned
+// streamer must also close r.
+type Decoder func(r io.ReadCloser) (beep.StreamSeekCloser, beep.Format, error)
+
+// ErrUnsupportedFormat is returned when a stream can't be decoded on this
+// system. Retrying the same URL won't help, so the next one is tried.
+var ErrUnsupportedFormat = errors.New("unsupported stream format")
+
+// ffmpegPath is the binary used to decode AAC streams.
+var ffmpegPath = "ffmpeg"
+
+// decoders maps a StreamInfo format to its decoder.
+var decoders = map[string]Decoder{
+	"MP3": mp3.Decode,
+	"AAC": decodeWithFFmpeg,
+}
+
+// formatFromContentType maps a stream Content-Type to a decoder format, or
+// returns "" when the type doesn't identify the codec.
+func formatFromContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	switch strings.ToLower(mediaType) {
+	case "audio/mpeg", "audio/mp3", "audio/mpeg3", "audio/x-mpeg":
+		return "MP3"
+	case "audio/aac", "audio/aacp", "audio/x-aac", "audio/mp4", "audio/x-m4a":
+		return "AAC"
+	}
+	return ""
+}
+
+// selectDecoder returns the decoder for a StreamInfo format, defaulting to MP3.
+func selectDecoder(format string) (string, Decoder) {
+	if decoder, ok := decoders[format]; ok {
+		return format, decoder
+	}
+	return "MP3", decoders["MP3"]
+}
+
+// decodeWithFFmpeg decodes any format ffmpeg understands (AAC, HE-AAC) by
+// piping the stream through it and reading 16-bit stereo PCM back.
+func decodeWithFFmpeg(r io.ReadCloser) (beep.StreamSeekCloser, beep.Format, error) {
+	format := beep.Format{SampleRate: DefaultSampleRate, NumChannels: 2, Precision: 2}
+
+	path, err := exec.LookPath(ffmpegPath)
+	if err != nil {
+		return nil, format, fmt.Errorf("%w: AAC playback requires ffmpeg in PATH", ErrUnsupportedFormat)
+	}
+
+	cmd := exec.Command(path,
+		"-hide_banner", "-loglevel", "error",
+		"-i", "pipe:0",
+		"-f", "s16le", "-ac", "2", "-ar", fmt.Sprintf("%d", int(DefaultSampleRate)),
+		"pipe:1")
+	cmd.Stdin = r
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, format, fmt.Errorf("failed to create ffmpeg pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, format, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+	log.Debug().Msgf("Decoding with %s (pid %d)", path, cmd.Process.Pid)
+
+	return &pcmStreamer{
+		reader: bufio.NewReaderSize(stdout, NetworkReadSize*4),
+		closer: func() error {
+			_ = cmd.Process.Kill()
+			r.Close()
+			_ = cmd.Wait()
+			return nil
+		},
+	}, format, nil
+}
+
+// pcmStreamer reads interleaved signed 16-bit little-endian stereo PCM.
+type pcmStreamer struct {
+	reader io.Reader
+	closer func() error
+	buf    []byte
+	err    error
+}
+
+func (s *pcmStreamer) Stream(samples [][2]float64) (n int, ok bool) {
+	if s.err != nil {
+		return 0, false
+	}
+
+	const frameSize = 4
+	if cap(s.buf) < len(samples)*frameSize {
+		s.buf = make([]byte, len(samples)*frameSize)
+	}
+	buf := s.buf[:len(samples)*frameSize]
+
+	// Block for at least one frame, then take whatever else is available
+	read, err := io.ReadAtLeast(s.reader, buf, frameSize)
+	if rem := read % frameSize; err == nil && rem != 0 {
+		// Complete the last frame so the next read starts aligned
+		var n int
+		n, err = io.ReadFull(s.reader, buf[read:read+frameSize-rem])
+		read += n
+	}
+	frames := read / frameSize
+	for i := 0; i < frames; i++ {
+		left := int16(binary.LittleEndian.Uint16(buf[i*frameSize:]))
+		right := int16(binary.LittleEndian.Uint16(buf[i*frameSize+2:]))
+		samples[i][0] = float64(left) / 32768
+		samples[i][1] = float64(right) / 32768
+	}
+
+	if err != nil {
+		if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			s.err = err
+		} else {
+			s.err = io.EOF
+		}
+		return frames, frames > 0
+	}
+	return frames, true
+}
+
+func (s *pcmStreamer) Err() error {
+	if errors.Is(s.err, io.EOF) {
+		return nil
+	}
+	return s.err
+}
+
+func (s *pcmStreamer) Len() int      { return 0 }
+func (s *pcmStreamer) Position() int { return 0 }
+
+func (s *pcmStreamer) Seek(int) error {
+	return errors.New("live stream is not seekable")
+}
+
+func (s *pcmStreamer) Close() error {
+	if s.closer != nil {
+		return s.closer()
+	}
+	return nil
+}
+
+// LookupFFmpeg returns the path of the ffmpeg binary used for AAC streams.
+func LookupFFmpeg() (string, error) {
+	return exec.LookPath(ffmpegPath)
+}
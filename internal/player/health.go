@@ -0,0 +1,73 @@
+package player
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// HealthInterval is how often the buffer fill is sampled.
+	HealthInterval = time.Second
+	// HealthSamples is how many samples are kept: a minute's worth.
+	HealthSamples = 60
+)
+
+// HealthSample is the buffer fill at one moment of playback.
+type HealthSample struct {
+	Time time.Time
+	// Buffer is the fill level as a percentage (0-100).
+	Buffer int
+	// Dropout is set when the buffer ran dry since the sample before.
+	Dropout bool
+}
+
+// healthHistory is a ring of the latest HealthSamples samples, taken while
+// audio is being played.
+type healthHistory struct {
+	mu      sync.Mutex
+	samples [HealthSamples]HealthSample
+	next    int
+	count   int
+	dropout bool
+}
+
+// sample records buffer at now, unless the last sample is less than
+// HealthInterval old.
+func (h *healthHistory) sample(now time.Time, buffer int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.count > 0 {
+		last := h.samples[(h.next-1+HealthSamples)%HealthSamples]
+		if now.Sub(last.Time) < HealthInterval {
+			return
+		}
+	}
+	h.samples[h.next] = HealthSample{Time: now, Buffer: buffer, Dropout: h.dropout}
+	h.next = (h.next + 1) % HealthSamples
+	h.count = min(h.count+1, HealthSamples)
+	h.dropout = false
+}
+
+// markDropout flags the next sample as following an underrun.
+func (h *healthHistory) markDropout() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.dropout = true
+}
+
+func (h *healthHistory) snapshot() []HealthSample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	samples := make([]HealthSample, 0, h.count)
+	start := (h.next - h.count + HealthSamples) % HealthSamples
+	for i := 0; i < h.count; i++ {
+		samples = append(samples, h.samples[(start+i)%HealthSamples])
+	}
+	return samples
+}
+
+// HealthHistory returns the buffer fill sampled every HealthInterval over
+// the last minute of playback, oldest first. Pauses and stops leave gaps.
+func (p *Player) HealthHistory() []HealthSample {
+	return p.health.snapshot()
+}
@@ -0,0 +1,80 @@
+package player
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/clock"
+)
+
+// JournalSize is how many diagnostic events the player keeps; older ones
+// are overwritten so memory stays bounded during long sessions.
+const JournalSize = 200
+
+// JournalKind classifies a diagnostic event.
+type JournalKind string
+
+const (
+	JournalConnect   JournalKind = "connect"
+	JournalError     JournalKind = "error"
+	JournalReconnect JournalKind = "reconnect"
+	JournalUnderrun  JournalKind = "underrun"
+	JournalStartup   JournalKind = "startup"
+)
+
+// JournalEntry is one diagnostic event.
+type JournalEntry struct {
+	Time    time.Time
+	Kind    JournalKind
+	Message string
+}
+
+func (e JournalEntry) String() string {
+	return fmt.Sprintf("%s %-9s %s", e.Time.Local().Format("15:04:05.000"), e.Kind, e.Message)
+}
+
+// journal is a fixed-size ring buffer of diagnostic events.
+type journal struct {
+	mu      sync.Mutex
+	entries [JournalSize]JournalEntry
+	next    int
+	count   int
+	clock   clock.Clock
+}
+
+func (j *journal) setClock(c clock.Clock) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.clock = c
+}
+
+func (j *journal) add(kind JournalKind, format string, args ...any) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entry := JournalEntry{Time: clock.Or(j.clock).Now(), Kind: kind, Message: fmt.Sprintf(format, args...)}
+	j.entries[j.next] = entry
+	j.next = (j.next + 1) % JournalSize
+	if j.count < JournalSize {
+		j.count++
+	}
+}
+
+// snapshot returns the kept events, oldest first.
+func (j *journal) snapshot() []JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entries := make([]JournalEntry, 0, j.count)
+	start := (j.next - j.count + JournalSize) % JournalSize
+	for i := 0; i < j.count; i++ {
+		entries = append(entries, j.entries[(start+i)%JournalSize])
+	}
+	return entries
+}
+
+// Journal returns recent connects, errors, reconnects, and buffer
+// underruns, oldest first.
+func (p *Player) Journal() []JournalEntry {
+	return p.journal.snapshot()
+}
@@ -0,0 +1,192 @@
+package player
+
+import (
+	"sync"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/station"
+)
+
+// Interval is a span of wall time, such as a pause.
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// TrackListen is one track as it was heard: from when it started playing
+// until the next track, a station change, or Stop, with the pauses in
+// between.
+type TrackListen struct {
+	Station *station.Station
+	Track   string
+	Start   time.Time
+	End     time.Time
+	Pauses  []Interval
+	Note    string // Set with SetTrackNote
+}
+
+// Paused returns the total time spent paused during the track.
+func (l TrackListen) Paused() time.Duration {
+	var total time.Duration
+	for _, pause := range l.Pauses {
+		total += pause.End.Sub(pause.Start)
+	}
+	return total
+}
+
+// Heard returns how long the track actually played.
+func (l TrackListen) Heard() time.Duration {
+	return max(l.End.Sub(l.Start)-l.Paused(), 0)
+}
+
+// listenTracker follows the track being heard. Its lock is never held
+// while calling out, so it can be used under the player's other locks.
+type listenTracker struct {
+	mu       sync.Mutex
+	current  *TrackListen
+	pausedAt time.Time
+	onFinish func(TrackListen)
+	onStart  func(TrackListen)
+}
+
+// begin finishes the current listen and, unless track is empty, starts a
+// new one, which it returns as started. The same track on the same
+// station, e.g. after a reconnect, continues the current listen.
+func (t *listenTracker) begin(s *station.Station, track string, now time.Time) (done TrackListen, ok bool, started *TrackListen) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if c := t.current; c != nil && s != nil && c.Station.ID == s.ID && c.Track == track {
+		return TrackListen{}, false, nil
+	}
+	done, ok = t.finishLocked(now)
+	if track != "" && track != NoTrackInfo && s != nil {
+		t.current = &TrackListen{Station: s, Track: track, Start: now}
+		started = &TrackListen{Station: s, Track: track, Start: now}
+	}
+	return done, ok, started
+}
+
+func (t *listenTracker) finish(now time.Time) (TrackListen, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.finishLocked(now)
+}
+
+func (t *listenTracker) finishLocked(now time.Time) (TrackListen, bool) {
+	if t.current == nil {
+		t.pausedAt = time.Time{}
+		return TrackListen{}, false
+	}
+	done := *t.current
+	if !t.pausedAt.IsZero() {
+		done.Pauses = append(done.Pauses, Interval{Start: t.pausedAt, End: now})
+	}
+	done.End = now
+	t.current = nil
+	t.pausedAt = time.Time{}
+	return done, true
+}
+
+func (t *listenTracker) pause(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pausedAt.IsZero() {
+		t.pausedAt = now
+	}
+}
+
+func (t *listenTracker) resume(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.current != nil && !t.pausedAt.IsZero() {
+		t.current.Pauses = append(t.current.Pauses, Interval{Start: t.pausedAt, End: now})
+	}
+	t.pausedAt = time.Time{}
+}
+
+// setNote sets the note of the current listen if it is of track.
+func (t *listenTracker) setNote(track, note string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.current == nil || t.current.Track != track {
+		return false
+	}
+	t.current.Note = note
+	return true
+}
+
+func (t *listenTracker) note() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.current == nil {
+		return ""
+	}
+	return t.current.Note
+}
+
+func (t *listenTracker) handler() func(TrackListen) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.onFinish
+}
+
+// SetListenHandler registers fn to receive each track once it is no longer
+// playing, with its pause intervals, e.g. for listening history. fn runs on
+// the goroutine that ended the track and must not call back into Stop.
+func (p *Player) SetListenHandler(fn func(TrackListen)) {
+	p.listen.mu.Lock()
+	defer p.listen.mu.Unlock()
+	p.listen.onFinish = fn
+}
+
+// SetTrackStartHandler registers fn to receive each track as it starts
+// playing, without an End yet, e.g. for a log of track changes. It runs
+// like the listen handler.
+func (p *Player) SetTrackStartHandler(fn func(TrackListen)) {
+	p.listen.mu.Lock()
+	defer p.listen.mu.Unlock()
+	p.listen.onStart = fn
+}
+
+// SetTrackNote attaches note to track, which is handed to the listen
+// handler with it. It reports false when track is no longer the one
+// playing, e.g. because it ended while the note was typed.
+func (p *Player) SetTrackNote(track, note string) bool {
+	return p.listen.setNote(track, note)
+}
+
+// TrackNote returns the note on the track playing, if any.
+func (p *Player) TrackNote() string {
+	return p.listen.note()
+}
+
+// beginListen starts following track on the current station.
+func (p *Player) beginListen(track string) {
+	done, ok, started := p.listen.begin(p.GetCurrentStation(), track, p.timeSource().Now())
+	p.emitListen(done, ok)
+	if started == nil {
+		return
+	}
+	p.listen.mu.Lock()
+	fn := p.listen.onStart
+	p.listen.mu.Unlock()
+	if fn != nil {
+		fn(*started)
+	}
+}
+
+// endListen finishes the track being heard, e.g. when playback stops.
+func (p *Player) endListen() {
+	done, ok := p.listen.finish(p.timeSource().Now())
+	p.emitListen(done, ok)
+}
+
+func (p *Player) emitListen(done TrackListen, ok bool) {
+	if !ok {
+		return
+	}
+	if fn := p.listen.handler(); fn != nil {
+		fn(done)
+	}
+}
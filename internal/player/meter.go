@@ -0,0 +1,75 @@
+package player
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// Levels is the loudness of each channel, left then right, as amplitudes
+// from 0 to 1.
+type Levels struct {
+	RMS  [2]float64
+	Peak [2]float64
+}
+
+// levelMeter sums up the decoded audio for Levels. It skips the samples
+// while metering is off, so a hidden visualizer costs nothing.
+type levelMeter struct {
+	enabled atomic.Bool
+
+	mu      sync.Mutex
+	squares [2]float64
+	peak    [2]float64
+	count   int
+}
+
+func (m *levelMeter) add(samples [][2]float64) {
+	if !m.enabled.Load() || len(samples) == 0 {
+		return
+	}
+	var squares, peak [2]float64
+	for _, s := range samples {
+		for ch := range 2 {
+			squares[ch] += s[ch] * s[ch]
+			peak[ch] = max(peak[ch], math.Abs(s[ch]))
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for ch := range 2 {
+		m.squares[ch] += squares[ch]
+		m.peak[ch] = max(m.peak[ch], peak[ch])
+	}
+	m.count += len(samples)
+}
+
+// take returns the levels since the last take and starts over.
+func (m *levelMeter) take() Levels {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var l Levels
+	if m.count > 0 {
+		for ch := range 2 {
+			l.RMS[ch] = math.Sqrt(m.squares[ch] / float64(m.count))
+		}
+		l.Peak = m.peak
+	}
+	m.squares, m.peak, m.count = [2]float64{}, [2]float64{}, 0
+	return l
+}
+
+// SetMetering turns measuring the decoded audio for Levels on or off.
+func (p *Player) SetMetering(enabled bool) {
+	p.meter.enabled.Store(enabled)
+	if !enabled {
+		p.meter.take()
+	}
+}
+
+// Levels returns the loudness of the audio decoded since the last call.
+// It is zero while metering is off, paused, or between streams.
+func (p *Player) Levels() Levels {
+	return p.meter.take()
+}
@@ -0,0 +1,112 @@
+package player
+
+import (
+	"math"
+	"sync/atomic"
+
+	"github.com/gopxl/beep/v2"
+)
+
+const (
+	// nightThreshold is the level above which night mode compresses,
+	// about -24 dBFS.
+	nightThreshold = 0.063
+	// nightRatio is how strongly levels above the threshold are reduced:
+	// 4 dB louder in comes out 1 dB louder.
+	nightRatio = 4.0
+	// nightAttack is quick enough to catch a loud intro before it startles;
+	// nightRelease is slow enough that quiet passages don't pump.
+	nightAttack  = 0.005 // seconds
+	nightRelease = 0.5   // seconds
+	// nightMakeup lifts the compressed signal by about 6 dB so quiet
+	// passages stay audible at a low volume.
+	nightMakeup = 2.0
+	// nightFade is how long switching night mode on or off cross-fades,
+	// so the makeup gain doesn't jump.
+	nightFade = 0.1 // seconds
+)
+
+// compressor is the night mode stage: a dynamic range compressor that
+// tames loud tracks and station changes so a low volume stays comfortable
+// throughout. It follows the peak level with a fast attack and a slow
+// release and passes audio through untouched while disabled.
+type compressor struct {
+	streamer beep.Streamer
+	enabled  *atomic.Bool
+
+	attack   float64 // Per-sample envelope smoothing while the level rises
+	release  float64 // Per-sample envelope smoothing while the level falls
+	fadeStep float64
+
+	envelope float64
+	mix      float64 // 0 is the dry signal, 1 fully compressed
+}
+
+func newCompressor(s beep.Streamer, rate beep.SampleRate, enabled *atomic.Bool) *compressor {
+	perSample := func(seconds float64) float64 {
+		return math.Exp(-1 / (seconds * float64(rate)))
+	}
+	c := &compressor{
+		streamer: s,
+		enabled:  enabled,
+		attack:   perSample(nightAttack),
+		release:  perSample(nightRelease),
+		fadeStep: 1 / (nightFade * float64(rate)),
+	}
+	if enabled.Load() {
+		c.mix = 1
+	}
+	return c
+}
+
+func (c *compressor) Stream(samples [][2]float64) (int, bool) {
+	count, ok := c.streamer.Stream(samples)
+	on := c.enabled.Load()
+	if count == 0 || (!on && c.mix == 0) {
+		return count, ok
+	}
+
+	for i := range samples[:count] {
+		level := max(math.Abs(samples[i][0]), math.Abs(samples[i][1]))
+		coef := c.release
+		if level > c.envelope {
+			coef = c.attack
+		}
+		c.envelope = level + coef*(c.envelope-level)
+
+		if on {
+			c.mix = min(1, c.mix+c.fadeStep)
+		} else {
+			c.mix = max(0, c.mix-c.fadeStep)
+		}
+		gain := 1 + c.mix*(compressorGain(c.envelope)*nightMakeup-1)
+		samples[i][0] = softLimit(samples[i][0] * gain)
+		samples[i][1] = softLimit(samples[i][1] * gain)
+	}
+	return count, ok
+}
+
+func (c *compressor) Err() error {
+	return c.streamer.Err()
+}
+
+// compressorGain returns the gain that brings envelope down to the
+// compressed level: unchanged up to nightThreshold, and above it reduced
+// by nightRatio in decibels.
+func compressorGain(envelope float64) float64 {
+	if envelope <= nightThreshold {
+		return 1
+	}
+	return math.Pow(envelope/nightThreshold, 1/nightRatio-1)
+}
+
+// SetNightMode turns the night mode compressor on or off. It fades in or
+// out over a tenth of a second, including for the stream already playing.
+func (p *Player) SetNightMode(enabled bool) {
+	p.nightMode.Store(enabled)
+}
+
+// NightMode reports whether the night mode compressor is on.
+func (p *Player) NightMode() bool {
+	return p.nightMode.Load()
+}
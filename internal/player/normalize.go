@@ -0,0 +1,98 @@
+package player
+
+import (
+	"math"
+	"sync/atomic"
+
+	"github.com/gopxl/beep/v2"
+)
+
+const (
+	// normalizeTarget is the RMS level streams are brought to, about -18 dBFS.
+	normalizeTarget = 0.125
+	// normalizeWindow is the time constant of the running loudness estimate,
+	// long enough to follow a station rather than a drum hit.
+	normalizeWindow = 3.0 // seconds
+	// normalizeMinGain and normalizeMaxGain limit the correction to ±12 dB.
+	normalizeMinGain = 0.25
+	normalizeMaxGain = 4.0
+	// normalizeSilence is the RMS below which a batch doesn't update the
+	// estimate, so gaps between tracks aren't boosted.
+	normalizeSilence = 0.003
+	// limiterThreshold is where the soft limiter starts bending peaks.
+	limiterThreshold = 0.9
+)
+
+// normalizer evens out loudness between streams with a slowly adapting
+// gain derived from the running RMS, followed by a soft limiter so the
+// added gain can't clip. It passes audio through untouched while disabled.
+type normalizer struct {
+	streamer beep.Streamer
+	rate     beep.SampleRate
+	enabled  *atomic.Bool
+
+	meanSquare float64
+	gain       float64
+}
+
+func newNormalizer(s beep.Streamer, rate beep.SampleRate, enabled *atomic.Bool) *normalizer {
+	return &normalizer{
+		streamer:   s,
+		rate:       rate,
+		enabled:    enabled,
+		meanSquare: normalizeTarget * normalizeTarget,
+		gain:       1,
+	}
+}
+
+func (n *normalizer) Stream(samples [][2]float64) (int, bool) {
+	count, ok := n.streamer.Stream(samples)
+	if count == 0 || !n.enabled.Load() {
+		return count, ok
+	}
+	batch := samples[:count]
+
+	var sum float64
+	for _, s := range batch {
+		sum += (s[0]*s[0] + s[1]*s[1]) / 2
+	}
+	if batchMS := sum / float64(count); math.Sqrt(batchMS) >= normalizeSilence {
+		alpha := 1 - math.Exp(-float64(count)/(normalizeWindow*float64(n.rate)))
+		n.meanSquare += alpha * (batchMS - n.meanSquare)
+	}
+	target := normalizeTarget / math.Sqrt(n.meanSquare)
+	target = max(normalizeMinGain, min(target, normalizeMaxGain))
+
+	// Ramp across the batch so gain changes don't click
+	start := n.gain
+	step := (target - start) / float64(count)
+	for i := range batch {
+		gain := start + step*float64(i+1)
+		batch[i][0] = softLimit(batch[i][0] * gain)
+		batch[i][1] = softLimit(batch[i][1] * gain)
+	}
+	n.gain = target
+	return count, ok
+}
+
+func (n *normalizer) Err() error {
+	return n.streamer.Err()
+}
+
+// softLimit leaves x alone below limiterThreshold and bends larger values
+// smoothly towards, but never past, full scale.
+func softLimit(x float64) float64 {
+	a := math.Abs(x)
+	if a <= limiterThreshold {
+		return x
+	}
+	headroom := 1 - limiterThreshold
+	limited := limiterThreshold + headroom*math.Tanh((a-limiterThreshold)/headroom)
+	return math.Copysign(limited, x)
+}
+
+// SetNormalize turns loudness normalization on or off. It takes effect
+// immediately, including for the stream already playing.
+func (p *Player) SetNormalize(enabled bool) {
+	p.normalize.Store(enabled)
+}
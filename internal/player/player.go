@@ -9,15 +9,19 @@ import (
 	"math"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/glebovdev/somafm-cli/internal/api"
+	"github.com/glebovdev/somafm-cli/internal/clock"
 	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/retitle"
 	"github.com/glebovdev/somafm-cli/internal/station"
 	"github.com/gopxl/beep/v2"
 	"github.com/gopxl/beep/v2/effects"
-	"github.com/gopxl/beep/v2/mp3"
 	"github.com/gopxl/beep/v2/speaker"
 	"github.com/rs/zerolog/log"
 )
@@ -34,6 +38,9 @@ const (
 	ReadTimeout         = 5 * time.Second
 	MaxErrorsToKeep     = 10
 	MaxPlaybackDelay    = 5 * time.Second
+	SilenceThreshold    = 0.001 // Peak amplitude below ~-60 dBFS counts as silence
+	ListenerIDHeader    = "X-Listener-ID"
+	NoTrackInfo         = "Waiting for track info..."
 )
 
 type PlayerState int
@@ -72,6 +79,8 @@ type StreamInfo struct {
 	Quality    string
 	Bitrate    int
 	SampleRate int
+	// PlaylistURL is the playlist the stream was found in.
+	PlaylistURL string
 }
 
 // Relies on context cancellation to clean up the spawned read goroutine.
@@ -122,11 +131,15 @@ type Player struct {
 	ctrl          *beep.Ctrl
 	mu            sync.Mutex
 	cancelFunc    context.CancelFunc
+	playlistURL   string // Explicit playlist choice, "" for all in order
 	isPaused      bool
 	isPlaying     bool
 	speakerInit   bool
 	volumePercent int
 	httpClient    *http.Client
+	listenerID    string
+	streamBaseURL string
+	playlistPref  station.PlaylistPreference
 
 	sampleCh       chan [2]float64
 	wg             sync.WaitGroup
@@ -139,11 +152,13 @@ type Player struct {
 
 	state        PlayerState
 	streamInfo   StreamInfo
+	fallback     *Fallback
 	retryAttempt int
 	maxRetries   int
 	sessionStart time.Time
 	lastError    string
 	stateMu      sync.RWMutex
+	changes      chan struct{}
 
 	currentStation *station.Station
 	streamAlive    bool
@@ -151,6 +166,41 @@ type Player struct {
 
 	pausedAt      time.Time
 	totalPausedMs int64
+
+	// Consecutive decoded samples below SilenceThreshold
+	silentSamples atomic.Int64
+
+	bitrate   bitrateMeter
+	journal   journal
+	startup   startupTimer
+	prefetch  prefetchCache
+	recorder  recorder
+	timeShift timeShift
+	listen    listenTracker
+	meter     levelMeter
+	health    healthHistory
+	normalize atomic.Bool
+	nightMode atomic.Bool
+
+	// clock drives retry delays, pause accounting, the session timer, and
+	// prefetch expiry. Nil means the wall clock.
+	clock clock.Clock
+
+	// Audio is held silent (while the stream keeps flowing) as long as the
+	// track title contains one of pauseKeywords
+	pauseKeywords []string
+	heldKeyword   string
+
+	// titles corrects ICY titles before they become the current track
+	titles *retitle.Rewriter
+
+	// onUnderrun is called when the buffer runs dry
+	onUnderrun atomic.Pointer[func()]
+
+	// comparison is the B stream of an A/B comparison, if one is running
+	comparison atomic.Pointer[comparisonStream]
+	// fadeIn asks for a short fade-in after the heard stream changed
+	fadeIn atomic.Bool
 }
 
 // Prevents panics from double-close when multiple goroutines signal completion.
@@ -190,6 +240,7 @@ func NewPlayer() *Player {
 		volumePercent: -1,
 		httpClient:    httpClient,
 		currentTrack:  "",
+		changes:       make(chan struct{}, 1),
 	}
 }
 
@@ -209,6 +260,38 @@ func (p *Player) initSpeaker(sampleRate beep.SampleRate) error {
 	return nil
 }
 
+// SetListenerID sets the anonymous ID sent with stream requests.
+// An empty ID disables the header.
+func (p *Player) SetListenerID(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.listenerID = id
+}
+
+// SetStreamBaseURL redirects stream URLs read from playlists to a mirror
+// or caching proxy, keeping their paths. An empty URL disables rewriting.
+func (p *Player) SetStreamBaseURL(baseURL string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.streamBaseURL = baseURL
+}
+
+// SetPlaylistPreference sets which of a station's playlists Play tries
+// first, e.g. low-bitrate AAC on metered connections.
+func (p *Player) SetPlaylistPreference(pref station.PlaylistPreference) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.playlistPref = pref
+}
+
+// playlistURLs returns the station's playlists in the order Play tries them.
+func (p *Player) playlistURLs(s *station.Station) []string {
+	p.mu.Lock()
+	pref := p.playlistPref
+	p.mu.Unlock()
+	return s.PreferredPlaylistURLs(pref)
+}
+
 func (p *Player) Stop() {
 	p.mu.Lock()
 
@@ -227,7 +310,9 @@ func (p *Player) Stop() {
 	p.isPaused = false
 	p.mu.Unlock()
 
+	p.StopComparison()
 	p.wg.Wait()
+	p.recorder.interrupted(p.timeSource().Now())
 
 	p.streamAliveMu.Lock()
 	p.streamAlive = false
@@ -235,10 +320,16 @@ func (p *Player) Stop() {
 
 	p.stateMu.Lock()
 	p.state = StateIdle
+	p.notifyChange()
 	p.sessionStart = time.Time{}
 	p.streamInfo = StreamInfo{}
+	p.fallback = nil
 	p.stateMu.Unlock()
 
+	p.silentSamples.Store(0)
+	p.bitrate.reset()
+	p.endListen()
+
 	log.Debug().Msg("Playback stopped")
 }
 
@@ -251,7 +342,7 @@ func (p *Player) TogglePause() {
 	}
 
 	if p.isPaused && !p.pausedAt.IsZero() {
-		pauseDuration := time.Since(p.pausedAt)
+		pauseDuration := p.timeSource().Since(p.pausedAt)
 		p.totalPausedMs += pauseDuration.Milliseconds()
 		totalPaused := time.Duration(p.totalPausedMs) * time.Millisecond
 
@@ -271,15 +362,19 @@ func (p *Player) TogglePause() {
 	speaker.Unlock()
 
 	if p.isPaused {
-		p.pausedAt = time.Now()
+		p.pausedAt = p.timeSource().Now()
+		p.listen.pause(p.pausedAt)
 		p.stateMu.Lock()
 		p.state = StatePaused
+		p.notifyChange()
 		p.stateMu.Unlock()
 		log.Debug().Msg("Playback paused")
 	} else {
 		p.pausedAt = time.Time{}
+		p.listen.resume(p.timeSource().Now())
 		p.stateMu.Lock()
 		p.state = StatePlaying
+		p.notifyChange()
 		p.stateMu.Unlock()
 		log.Debug().Msg("Playback resumed")
 	}
@@ -293,7 +388,7 @@ func (p *Player) GetPlaybackDelay() time.Duration {
 
 	total := time.Duration(p.totalPausedMs) * time.Millisecond
 	if !p.pausedAt.IsZero() {
-		total += time.Since(p.pausedAt)
+		total += p.timeSource().Since(p.pausedAt)
 	}
 	return total
 }
@@ -301,6 +396,7 @@ func (p *Player) GetPlaybackDelay() time.Duration {
 func (p *Player) Reconnect() {
 	p.mu.Lock()
 	station := p.currentStation
+	playlistURL := p.playlistURL
 	p.mu.Unlock()
 
 	if station == nil {
@@ -310,8 +406,9 @@ func (p *Player) Reconnect() {
 	p.setState(StateReconnecting)
 	p.Stop()
 
-	err := p.Play(station)
+	err := p.PlayPlaylist(station, playlistURL)
 	if err != nil && !errors.Is(err, context.Canceled) {
+		p.journal.add(JournalError, "Reconnect failed: %v", err)
 		log.Error().Err(err).Msg("Reconnect failed")
 		p.setState(StateError)
 		p.setLastError("Reconnect failed")
@@ -339,6 +436,16 @@ func (p *Player) SetVolume(volumePercent int) {
 	log.Debug().Msgf("Volume set to %d%% (%.2f dB)", volumePercent, volumeLevel)
 }
 
+// GetVolume returns the volume percent, or the default before one is set.
+func (p *Player) GetVolume() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.volumePercent < 0 {
+		return config.DefaultVolume
+	}
+	return p.volumePercent
+}
+
 func percentToExponent(p float64) float64 {
 	if p <= 0 {
 		return MinVolumeDB
@@ -369,7 +476,7 @@ func (p *Player) GetCurrentTrack() string {
 	defer p.trackMu.RUnlock()
 
 	if p.currentTrack == "" {
-		return "Waiting for track info..."
+		return NoTrackInfo
 	}
 	return p.currentTrack
 }
@@ -382,23 +489,107 @@ func (p *Player) GetCurrentStation() *station.Station {
 
 func (p *Player) setCurrentTrack(track string) {
 	p.trackMu.Lock()
-	defer p.trackMu.Unlock()
-
-	if track != p.currentTrack {
+	changed := track != p.currentTrack
+	if changed {
 		p.currentTrack = track
 		log.Debug().Msgf("Now playing: %s", track)
+
+		held := matchPauseKeyword(track, p.pauseKeywords)
+		if held != p.heldKeyword {
+			if held != "" {
+				log.Debug().Msgf("Title matched pause keyword %q, holding audio", held)
+			} else {
+				log.Debug().Msg("Title changed, releasing keyword hold")
+			}
+		}
+		p.heldKeyword = held
+	}
+	p.trackMu.Unlock()
+
+	if changed {
+		p.beginListen(track)
 	}
 }
 
-func (p *Player) SetInitialTrack(track string) {
+// SetUnderrunHandler registers fn to be called each time the buffer runs
+// dry, e.g. to hold back background downloads. fn runs on the audio path
+// and must return quickly.
+func (p *Player) SetUnderrunHandler(fn func()) {
+	p.onUnderrun.Store(&fn)
+}
+
+// SetTitleRewriter sets the rules applied to ICY titles. Nil leaves them
+// as the stream sends them.
+func (p *Player) SetTitleRewriter(r *retitle.Rewriter) {
+	p.trackMu.Lock()
+	defer p.trackMu.Unlock()
+	p.titles = r
+}
+
+// retitle applies the title rules for the current station.
+func (p *Player) retitle(title string) string {
+	p.trackMu.RLock()
+	r := p.titles
+	p.trackMu.RUnlock()
+	if r == nil {
+		return title
+	}
+
+	var id string
+	if s := p.GetCurrentStation(); s != nil {
+		id = s.ID.String()
+	}
+	return r.Apply(id, title)
+}
+
+// SetPauseKeywords configures keywords that silence playback while they
+// appear in the track title. Matching is case-insensitive.
+func (p *Player) SetPauseKeywords(keywords []string) {
 	p.trackMu.Lock()
 	defer p.trackMu.Unlock()
 
+	p.pauseKeywords = nil
+	for _, keyword := range keywords {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			p.pauseKeywords = append(p.pauseKeywords, keyword)
+		}
+	}
+	p.heldKeyword = matchPauseKeyword(p.currentTrack, p.pauseKeywords)
+}
+
+// GetHeldKeyword returns the pause keyword that is currently silencing
+// playback, or an empty string if audio is not held.
+func (p *Player) GetHeldKeyword() string {
+	p.trackMu.RLock()
+	defer p.trackMu.RUnlock()
+	return p.heldKeyword
+}
+
+func matchPauseKeyword(track string, keywords []string) string {
+	if track == "" {
+		return ""
+	}
+	title := strings.ToLower(track)
+	for _, keyword := range keywords {
+		if strings.Contains(title, strings.ToLower(keyword)) {
+			return keyword
+		}
+	}
+	return ""
+}
+
+func (p *Player) SetInitialTrack(track string) {
+	p.trackMu.Lock()
+
 	// Don't overwrite ICY metadata if already set
 	if p.currentTrack == "" {
 		p.currentTrack = track
 		log.Debug().Msgf("Initial track set from songs API: %s", track)
+		p.trackMu.Unlock()
+		p.beginListen(track)
+		return
 	}
+	p.trackMu.Unlock()
 }
 
 func (p *Player) GetState() PlayerState {
@@ -413,6 +604,21 @@ func (p *Player) setState(state PlayerState) {
 	if p.state != state {
 		log.Debug().Msgf("Player state: %s -> %s", p.state.String(), state.String())
 		p.state = state
+		p.notifyChange()
+	}
+}
+
+// Changes returns a channel that receives a value whenever the playback
+// state changes, e.g. to buffering, paused, or reconnecting. Changes made
+// while the previous one is unread are coalesced into one.
+func (p *Player) Changes() <-chan struct{} {
+	return p.changes
+}
+
+func (p *Player) notifyChange() {
+	select {
+	case p.changes <- struct{}{}:
+	default:
 	}
 }
 
@@ -422,6 +628,32 @@ func (p *Player) GetStreamInfo() StreamInfo {
 	return p.streamInfo
 }
 
+// Fallback describes a lower stream being played because the preferred
+// playlist failed.
+type Fallback struct {
+	FailedURL  string
+	PlayingURL string
+	Failed     StreamInfo
+	Playing    StreamInfo
+}
+
+// GetFallback returns the fallback in use since the station was started,
+// if the preferred playlist failed and a later one was tried.
+func (p *Player) GetFallback() (Fallback, bool) {
+	p.stateMu.RLock()
+	defer p.stateMu.RUnlock()
+	if p.fallback == nil {
+		return Fallback{}, false
+	}
+	return *p.fallback, true
+}
+
+func (p *Player) setFallback(fb *Fallback) {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+	p.fallback = fb
+}
+
 func (p *Player) setStreamInfo(info StreamInfo) {
 	p.stateMu.Lock()
 	defer p.stateMu.Unlock()
@@ -462,6 +694,18 @@ func (p *Player) setRetryInfo(current, max int) {
 	p.maxRetries = max
 }
 
+// SetClock replaces the wall clock, e.g. with a fake one in tests. Call it
+// before starting playback.
+func (p *Player) SetClock(c clock.Clock) {
+	p.clock = c
+	p.journal.setClock(c)
+	p.bitrate.now = c.Now
+}
+
+func (p *Player) timeSource() clock.Clock {
+	return clock.Or(p.clock)
+}
+
 func (p *Player) GetSessionDuration() time.Duration {
 	p.stateMu.RLock()
 	defer p.stateMu.RUnlock()
@@ -469,13 +713,36 @@ func (p *Player) GetSessionDuration() time.Duration {
 	if p.sessionStart.IsZero() {
 		return 0
 	}
-	return time.Since(p.sessionStart)
+	return p.timeSource().Since(p.sessionStart)
 }
 
 func (p *Player) startSession() {
 	p.stateMu.Lock()
 	defer p.stateMu.Unlock()
-	p.sessionStart = time.Now()
+	p.sessionStart = p.timeSource().Now()
+}
+
+// GetSilenceDuration returns how long the decoded audio has stayed below SilenceThreshold.
+// Useful for detecting dead air on a connection that is otherwise healthy.
+func (p *Player) GetSilenceDuration() time.Duration {
+	p.mu.Lock()
+	sampleRate := p.format.SampleRate
+	p.mu.Unlock()
+
+	if sampleRate <= 0 {
+		return 0
+	}
+	return sampleRate.D(int(p.silentSamples.Load()))
+}
+
+func (p *Player) trackSilence(samples [][2]float64) {
+	for _, sample := range samples {
+		if math.Abs(sample[0]) >= SilenceThreshold || math.Abs(sample[1]) >= SilenceThreshold {
+			p.silentSamples.Store(0)
+			return
+		}
+	}
+	p.silentSamples.Add(int64(len(samples)))
 }
 
 func (p *Player) GetLastError() string {
@@ -490,164 +757,60 @@ func (p *Player) setLastError(err string) {
 	p.lastError = err
 }
 
+// Play connects to the station and blocks until playback ends, trying each
+// playlist and stream URL under DefaultRetryPolicy. Stop cancels it,
+// including any pending retry wait.
 func (p *Player) Play(s *station.Station) error {
-	return p.playWithRetry(s, MaxRetries)
+	return p.PlayPlaylist(s, "")
 }
 
-func (p *Player) playWithRetry(s *station.Station, maxRetries int) error {
-	playlistURLs := s.GetAllPlaylistURLs()
+// PlayPlaylist is like Play but only uses playlistURL, one of the station's
+// playlists, instead of trying all of them in preference order. An empty
+// playlistURL behaves like Play. Reconnect keeps using the same playlist.
+func (p *Player) PlayPlaylist(s *station.Station, playlistURL string) error {
+	return p.playWithPolicy(s, playlistURL, DefaultRetryPolicy)
+}
+
+func (p *Player) playWithPolicy(s *station.Station, playlistURL string, policy RetryPolicy) error {
+	playlistURLs := p.playlistURLs(s)
+	if playlistURL != "" {
+		playlistURLs = []string{playlistURL}
+	}
 	if len(playlistURLs) == 0 {
 		p.setState(StateError)
 		p.setLastError("No playlists available")
 		return fmt.Errorf("no playlists available for station: %s", s.Title)
 	}
 
-	p.setState(StateBuffering)
-	p.setRetryInfo(0, maxRetries)
-	p.setCurrentTrack("")
-
-	allErrors := make([]string, 0, MaxErrorsToKeep)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	addError := func(msg string) {
-		if len(allErrors) < MaxErrorsToKeep {
-			allErrors = append(allErrors, msg)
-		}
+	p.mu.Lock()
+	if p.cancelFunc != nil {
+		p.cancelFunc()
 	}
+	p.cancelFunc = cancel
+	p.playlistURL = playlistURL
+	p.mu.Unlock()
 
-	var reconnectStreamURLs []string
-	var reconnectStreamInfo StreamInfo
-
-playlists:
-	for playlistIdx, playlistURL := range playlistURLs {
-		log.Debug().Msgf("Trying playlist %d/%d: %s", playlistIdx+1, len(playlistURLs), playlistURL)
-
-		streamInfo := parseStreamInfoFromURL(playlistURL)
-
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		streamURLs, err := p.fetchAndParsePLS(ctx, playlistURL)
-		cancel()
-
-		if err != nil {
-			log.Warn().Err(err).Msgf("Failed to fetch playlist: %s", playlistURL)
-			addError(fmt.Sprintf("playlist %s: %v", playlistURL, err))
-			continue
-		}
-
-		log.Debug().Msgf("Found %d stream URLs in playlist", len(streamURLs))
-
-		for urlIdx, streamURL := range streamURLs {
-			for attempt := 0; attempt <= maxRetries; attempt++ {
-				if attempt > 0 {
-					p.setState(StateReconnecting)
-					p.setRetryInfo(attempt, maxRetries)
-					log.Warn().Msgf("Stream failed, retrying in %v... (%d/%d)", RetryDelay, attempt, maxRetries)
-					time.Sleep(RetryDelay)
-				}
-
-				log.Debug().Msgf("Trying stream %d/%d (attempt %d/%d): %s",
-					urlIdx+1, len(streamURLs), attempt, maxRetries, streamURL)
-
-				ctx, cancel := context.WithCancel(context.Background())
-
-				p.mu.Lock()
-				if p.cancelFunc != nil {
-					p.cancelFunc()
-				}
-				p.cancelFunc = cancel
-				p.mu.Unlock()
-
-				p.setStreamInfo(streamInfo)
-
-				err := p.playStreamURL(ctx, s, streamURL)
-				if err == nil {
-					return nil
-				}
-
-				if errors.Is(err, context.Canceled) {
-					return context.Canceled
-				}
-
-				// StatePlaying means the stream connected and played before dropping
-				if p.GetState() == StatePlaying {
-					log.Info().Msg("Stream was playing, entering reconnect mode")
-					reconnectStreamURLs = streamURLs
-					reconnectStreamInfo = streamInfo
-					break playlists
-				}
-
-				if isNonRetryableError(err) {
-					log.Warn().Err(err).Msgf("Non-retryable error for %s, moving to next URL", streamURL)
-					addError(fmt.Sprintf("%s: %v", streamURL, err))
-					break
-				}
+	gen := p.startup.begin(s, p.timeSource().Now())
+	defer p.startup.abandon(gen)
 
-				addError(fmt.Sprintf("%s (attempt %d): %v", streamURL, attempt, err))
+	m := &connectionManager{
+		player: p,
+		policy: policy,
+		fetch: func(ctx context.Context, playlistURL string) ([]string, error) {
+			urls, err := p.cachedPlaylistFetcher(ctx, playlistURL)
+			if err == nil {
+				p.startup.markFetched(p.timeSource().Now())
 			}
-		}
-	}
-
-	var finalErr error
-	if len(reconnectStreamURLs) > 0 {
-		err := p.reconnectWithRotation(s, reconnectStreamURLs, reconnectStreamInfo, maxRetries)
-		if err == nil {
-			return nil
-		}
-		if errors.Is(err, context.Canceled) {
-			return context.Canceled
-		}
-		finalErr = err
-	} else {
-		finalErr = fmt.Errorf("all streams failed: %s", strings.Join(allErrors, "; "))
-	}
-
-	p.setState(StateError)
-	p.setLastError("Connection failed")
-	return finalErr
-}
-
-// If a stream recovers then drops again, the retry counter resets.
-func (p *Player) reconnectWithRotation(s *station.Station, streamURLs []string, streamInfo StreamInfo, maxRetries int) error {
-	var lastErr error
-
-	for retryCount := 1; retryCount <= maxRetries; retryCount++ {
-		streamURL := streamURLs[(retryCount-1)%len(streamURLs)]
-
-		p.setState(StateReconnecting)
-		p.setRetryInfo(retryCount, maxRetries)
-		log.Warn().Msgf("Reconnecting in %v... (%d/%d) %s", RetryDelay, retryCount, maxRetries, streamURL)
-		time.Sleep(RetryDelay)
-
-		ctx, cancel := context.WithCancel(context.Background())
-
-		p.mu.Lock()
-		if p.cancelFunc != nil {
-			p.cancelFunc()
-		}
-		p.cancelFunc = cancel
-		p.mu.Unlock()
-
-		p.setStreamInfo(streamInfo)
-
-		err := p.playStreamURL(ctx, s, streamURL)
-		if err == nil {
-			return nil
-		}
-
-		if errors.Is(err, context.Canceled) {
-			return context.Canceled
-		}
-
-		lastErr = err
-
-		// Stream recovered then dropped again — reset retry counter
-		if p.GetState() == StatePlaying {
-			log.Info().Msg("Stream was playing, resetting retry counter")
-			retryCount = 0
-			continue
-		}
+			return urls, err
+		},
+		connect: func(ctx context.Context, streamURL string) error {
+			return p.playStreamURL(ctx, s, streamURL)
+		},
 	}
-
-	return fmt.Errorf("reconnection failed: %w", lastErr)
+	return m.run(ctx, playlistURLs)
 }
 
 type httpStatusError struct {
@@ -660,6 +823,9 @@ func (e *httpStatusError) Error() string {
 }
 
 func isNonRetryableError(err error) bool {
+	if errors.Is(err, ErrUnsupportedFormat) {
+		return true
+	}
 	var statusErr *httpStatusError
 	if errors.As(err, &statusErr) {
 		switch statusErr.StatusCode {
@@ -670,22 +836,40 @@ func isNonRetryableError(err error) bool {
 	return false
 }
 
+func (p *Player) newStreamRequest(ctx context.Context, streamURL string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", streamURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", fmt.Sprintf("SomaFM-CLI/%s", config.AppVersion))
+	req.Header.Set("Icy-MetaData", "1")
+
+	p.mu.Lock()
+	listenerID := p.listenerID
+	p.mu.Unlock()
+	if listenerID != "" {
+		req.Header.Set(ListenerIDHeader, listenerID)
+	}
+
+	return req, nil
+}
+
 func (p *Player) playStreamURL(ctx context.Context, s *station.Station, streamURL string) error {
 	speaker.Clear()
 
 	log.Debug().Msgf("Connecting to stream: %s", streamURL)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", streamURL, nil)
+	req, err := p.newStreamRequest(ctx, streamURL)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("User-Agent", fmt.Sprintf("SomaFM-CLI/%s", config.AppVersion))
-	req.Header.Set("Icy-MetaData", "1")
-
-	resp, err := p.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to fetch MP3 stream: %w", err)
+	resp := p.takePrefetched(streamURL)
+	if resp != nil {
+		log.Debug().Msgf("Using prefetched connection: %s", streamURL)
+	} else if resp, err = p.httpClient.Do(req); err != nil {
+		return fmt.Errorf("failed to fetch stream: %w", err)
 	}
 
 	log.Debug().Msgf("Stream response status: %d, Content-Type: %s", resp.StatusCode, resp.Header.Get("Content-Type"))
@@ -694,6 +878,7 @@ func (p *Player) playStreamURL(ctx context.Context, s *station.Station, streamUR
 		resp.Body.Close()
 		return &httpStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
 	}
+	p.startup.markConnected(p.timeSource().Now())
 
 	var icyMetaint int
 	if val := resp.Header.Get("icy-metaint"); val != "" {
@@ -712,23 +897,34 @@ func (p *Player) playStreamURL(ctx context.Context, s *station.Station, streamUR
 	p.totalPausedMs = 0
 	p.mu.Unlock()
 
+	p.silentSamples.Store(0)
+	p.bitrate.reset()
+
 	timeoutBody := &contextReader{
 		reader:  resp.Body,
 		ctx:     ctx,
 		timeout: ReadTimeout,
 	}
 
+	// A recording that outlived the last connection continues from here
+	p.recorder.resumed(p.timeSource().Now())
 	p.wg.Add(1)
 	go p.readNetworkStream(ctx, resp.Body, timeoutBody, pipeWriter, icyMetaint)
 
-	log.Debug().Msg("Decoding MP3 stream...")
-	streamer, format, err := mp3.Decode(pipeReader)
+	streamInfo := streamInfoFromHeaders(resp.Header, p.GetStreamInfo())
+	p.setStreamInfo(streamInfo)
+	log.Debug().Msgf("Stream info: %s %dk %d Hz", streamInfo.Format, streamInfo.Bitrate, streamInfo.SampleRate)
+
+	codec, decode := selectDecoder(streamInfo.Format)
+	log.Debug().Msgf("Decoding %s stream...", codec)
+	streamer, format, err := decode(pipeReader)
 	if err != nil {
 		pipeReader.Close()
 		pipeWriter.Close()
 		resp.Body.Close()
-		return fmt.Errorf("failed to decode MP3 stream: %w", err)
+		return fmt.Errorf("failed to decode %s stream: %w", codec, err)
 	}
+	p.startup.markDecoded(p.timeSource().Now())
 
 	log.Debug().Msgf("Initializing audio output (sample rate: %d Hz)...", format.SampleRate)
 	if err := p.initSpeaker(format.SampleRate); err != nil {
@@ -742,6 +938,7 @@ func (p *Player) playStreamURL(ctx context.Context, s *station.Station, streamUR
 	p.mu.Lock()
 	p.format = format
 	p.mu.Unlock()
+	p.timeShift.reset(format.SampleRate)
 
 	p.streamAliveMu.Lock()
 	p.streamAlive = true
@@ -750,6 +947,9 @@ func (p *Player) playStreamURL(ctx context.Context, s *station.Station, streamUR
 	p.mu.Lock()
 	p.currentStation = s
 	p.mu.Unlock()
+	if track := p.GetCurrentTrack(); track != NoTrackInfo {
+		p.beginListen(track)
+	}
 
 	p.wg.Add(1)
 	go p.decodeAndBuffer(ctx, streamer, pipeReader)
@@ -769,7 +969,7 @@ func (p *Player) playStreamURL(ctx context.Context, s *station.Station, streamUR
 	}
 
 	p.volume = &effects.Volume{
-		Streamer: bufferedStreamer,
+		Streamer: newCompressor(newNormalizer(bufferedStreamer, format.SampleRate, &p.normalize), format.SampleRate, &p.nightMode),
 		Base:     2,
 		Volume:   volumeLevel,
 		Silent:   volumePercent == 0,
@@ -788,14 +988,19 @@ func (p *Player) playStreamURL(ctx context.Context, s *station.Station, streamUR
 	p.setState(StatePlaying)
 	p.startSession()
 
-	p.stateMu.Lock()
-	p.streamInfo.SampleRate = int(format.SampleRate)
-	p.stateMu.Unlock()
+	// Prefer the source rate the server reported; decoders may resample
+	if headerSampleRate(resp.Header) == 0 {
+		p.stateMu.Lock()
+		p.streamInfo.SampleRate = int(format.SampleRate)
+		p.stateMu.Unlock()
+	}
 
 	p.setLastError("")
+	p.journal.add(JournalConnect, "%s: %s %dk via %s", s.Title, streamInfo.Format, streamInfo.Bitrate, streamURL)
 	log.Debug().Msgf("Now playing: %s", s.Title)
 
 	stopPlayback := func() {
+		p.StopComparison()
 		p.closeStreamDone()
 		p.wg.Wait()
 		speaker.Clear()
@@ -811,9 +1016,11 @@ func (p *Player) playStreamURL(ctx context.Context, s *station.Station, streamUR
 		return ctx.Err()
 	case err := <-p.streamErr:
 		stopPlayback()
+		p.recorder.interrupted(p.timeSource().Now())
 		return fmt.Errorf("stream error: %w", err)
 	case <-p.streamDone:
 		stopPlayback()
+		p.recorder.interrupted(p.timeSource().Now())
 		return fmt.Errorf("stream ended unexpectedly")
 	}
 }
@@ -847,6 +1054,7 @@ func (p *Player) readNetworkStream(ctx context.Context, respBody io.ReadCloser,
 	}
 
 	bufReader := bufio.NewReader(bodyReader)
+	audioOut := &recordingWriter{out: pipeWriter, recorder: &p.recorder}
 
 	for {
 		select {
@@ -856,7 +1064,8 @@ func (p *Player) readNetworkStream(ctx context.Context, respBody io.ReadCloser,
 		case <-p.streamDone:
 			return
 		default:
-			_, err := io.CopyN(pipeWriter, bufReader, chunkSize)
+			n, err := io.CopyN(audioOut, bufReader, chunkSize)
+			p.bitrate.add(int(n))
 			if err != nil {
 				if ctx.Err() != nil || errors.Is(err, io.ErrClosedPipe) || strings.Contains(err.Error(), "closed pipe") {
 					return
@@ -906,8 +1115,9 @@ func (p *Player) readNetworkStream(ctx context.Context, respBody io.ReadCloser,
 						start := strings.Index(metaStr, "StreamTitle='") + len("StreamTitle='")
 						end := strings.Index(metaStr[start:], "';")
 						if end > 0 {
-							title := metaStr[start : start+end]
+							title := p.retitle(metaStr[start : start+end])
 							p.setCurrentTrack(title)
+							p.recorder.trackChanged(title)
 						}
 					}
 				}
@@ -947,11 +1157,15 @@ func (p *Player) decodeAndBuffer(ctx context.Context, streamer beep.StreamSeekCl
 			n, ok := streamer.Stream(decodedSamples)
 			if !ok {
 				if err := streamer.Err(); err != nil {
+					p.journal.add(JournalError, "Decoding failed: %v", err)
 					log.Error().Err(err).Msg("Stream decoding error")
 				}
 				return
 			}
 
+			p.trackSilence(decodedSamples[:n])
+			p.meter.add(decodedSamples[:n])
+
 			for i := 0; i < n; i++ {
 				select {
 				case <-p.streamDone:
@@ -978,6 +1192,8 @@ type bufferedStreamerWrapper struct {
 	fadeInRemaining int
 	fadeInTotal     int
 	done            bool
+	primed          bool
+	starved         bool
 }
 
 // Stream reads decoded audio samples into the buffer. Uses non-blocking reads
@@ -988,6 +1204,16 @@ func (b *bufferedStreamerWrapper) Stream(samples [][2]float64) (n int, ok bool)
 	p := b.player
 	audioEnd := 0
 
+	// During a comparison B may be heard instead of A, which is drained
+	// at the same pace to stay live
+	source, drained := p.sampleCh, (chan [2]float64)(nil)
+	if c := p.comparison.Load(); c != nil && c.hearing.Load() {
+		source, drained = c.samples, p.sampleCh
+	}
+	if p.fadeIn.Swap(false) {
+		b.fadeInRemaining = b.fadeInTotal
+	}
+
 	if !b.done {
 		for i := range samples {
 			select {
@@ -1000,7 +1226,7 @@ func (b *bufferedStreamerWrapper) Stream(samples [][2]float64) (n int, ok bool)
 			}
 
 			select {
-			case sample, more := <-p.sampleCh:
+			case sample, more := <-source:
 				if !more {
 					b.done = true
 				} else {
@@ -1015,6 +1241,9 @@ func (b *bufferedStreamerWrapper) Stream(samples [][2]float64) (n int, ok bool)
 				break
 			}
 		}
+		if drained != nil && !b.done {
+			drain(drained, len(samples))
+		}
 	}
 
 	// When stream ends mid-batch, discard any samples already read —
@@ -1023,6 +1252,38 @@ func (b *bufferedStreamerWrapper) Stream(samples [][2]float64) (n int, ok bool)
 		audioEnd = 0
 	}
 
+	// Keep draining the stream while held so ICY metadata keeps arriving
+	// and the next title can release the hold
+	held := p.GetHeldKeyword() != ""
+	if held {
+		audioEnd = 0
+	}
+
+	// A short batch after audio was flowing is an underrun; journal it once
+	// per gap rather than for every silent batch
+	switch {
+	case b.done || held:
+	case audioEnd == len(samples):
+		b.primed, b.starved = true, false
+	case b.primed && !b.starved:
+		b.starved = true
+		p.journal.add(JournalUnderrun, "Buffer ran dry (%d of %d samples)", audioEnd, len(samples))
+		p.health.markDropout()
+		if fn := p.onUnderrun.Load(); fn != nil {
+			(*fn)()
+		}
+	}
+	if b.primed && !b.done {
+		p.health.sample(p.timeSource().Now(), len(p.sampleCh)*100/cap(p.sampleCh))
+	}
+
+	if audioEnd > 0 && p.timeShift.process(samples[:audioEnd]) {
+		b.fadeInRemaining = b.fadeInTotal
+	}
+	if audioEnd > 0 {
+		p.finishStartup(samples[:audioEnd])
+	}
+
 	for i := audioEnd; i < len(samples); i++ {
 		samples[i] = [2]float64{}
 	}
@@ -1085,6 +1346,15 @@ func (p *Player) fetchAndParsePLS(ctx context.Context, plsURL string) ([]string,
 		return nil, fmt.Errorf("no valid stream URL found in PLS file")
 	}
 
+	p.mu.Lock()
+	streamBaseURL := p.streamBaseURL
+	p.mu.Unlock()
+	if streamBaseURL != "" {
+		for i, u := range urls {
+			urls[i] = api.RewriteURL(u, streamBaseURL)
+		}
+	}
+
 	return urls, nil
 }
 
@@ -1115,16 +1385,71 @@ func parseStreamInfoFromURL(url string) StreamInfo {
 		}
 	}
 
+	info.Quality = qualityForBitrate(info.Bitrate)
+
+	return info
+}
+
+func qualityForBitrate(bitrate int) string {
 	switch {
-	case info.Bitrate >= 256:
-		info.Quality = "highest"
-	case info.Bitrate >= 128:
-		info.Quality = "high"
-	case info.Bitrate >= 64:
-		info.Quality = "medium"
+	case bitrate >= 256:
+		return "highest"
+	case bitrate >= 128:
+		return "high"
+	case bitrate >= 64:
+		return "medium"
 	default:
-		info.Quality = "low"
+		return "low"
+	}
+}
+
+// streamInfoFromHeaders refines the URL-based guess with what the server
+// reports: Content-Type for the codec, icy-br/icy-sr (or ice-audio-info)
+// for bitrate and sample rate. Missing or malformed headers keep the guess.
+func streamInfoFromHeaders(h http.Header, guess StreamInfo) StreamInfo {
+	info := guess
+
+	if format := formatFromContentType(h.Get("Content-Type")); format != "" {
+		info.Format = format
+	}
+
+	audioInfo := parseIceAudioInfo(h.Get("ice-audio-info"))
+	if br := firstHeaderInt(h.Get("icy-br"), audioInfo["ice-bitrate"], audioInfo["bitrate"]); br > 0 {
+		info.Bitrate = br
+		info.Quality = qualityForBitrate(br)
+	}
+	if sr := headerSampleRate(h); sr > 0 {
+		info.SampleRate = sr
 	}
 
 	return info
 }
+
+// headerSampleRate returns the source sample rate reported by the server, or 0.
+func headerSampleRate(h http.Header) int {
+	audioInfo := parseIceAudioInfo(h.Get("ice-audio-info"))
+	return firstHeaderInt(h.Get("icy-sr"), audioInfo["ice-samplerate"], audioInfo["samplerate"])
+}
+
+// parseIceAudioInfo parses "ice-samplerate=44100;ice-bitrate=128;ice-channels=2".
+func parseIceAudioInfo(value string) map[string]string {
+	fields := make(map[string]string)
+	for _, part := range strings.Split(value, ";") {
+		if k, v, ok := strings.Cut(part, "="); ok {
+			fields[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+		}
+	}
+	return fields
+}
+
+// firstHeaderInt returns the first positive integer among values. Some
+// servers send icy-br as "128,128", so only the leading number is used.
+func firstHeaderInt(values ...string) int {
+	for _, v := range values {
+		v, _, _ = strings.Cut(v, ",")
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && n > 0 {
+			return n
+		}
+	}
+	return 0
+}
@@ -1,14 +1,29 @@
 package player
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"testing/iotest"
 	"time"
+	"unicode/utf8"
+
+	"github.com/glebovdev/somafm-cli/internal/clock"
+	"github.com/glebovdev/somafm-cli/internal/retitle"
+	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/gopxl/beep/v2"
 )
 
 func TestPercentToExponent(t *testing.T) {
@@ -226,17 +241,47 @@ func TestPlayerLastError(t *testing.T) {
 
 func TestPlayerSessionDuration(t *testing.T) {
 	p := NewPlayer()
+	fakeClock := clock.NewFake(time.Now())
+	p.SetClock(fakeClock)
 
 	if p.GetSessionDuration() != 0 {
 		t.Error("Initial session duration should be 0")
 	}
 
 	p.startSession()
-	time.Sleep(10 * time.Millisecond)
+	fakeClock.Advance(90 * time.Second)
+
+	if duration := p.GetSessionDuration(); duration != 90*time.Second {
+		t.Errorf("Session duration = %v, want 90s", duration)
+	}
+}
+
+func TestSleepContextFakeClock(t *testing.T) {
+	fakeClock := clock.NewFake(time.Now())
+	done := make(chan error, 1)
+	go func() {
+		done <- sleepContext(context.Background(), fakeClock, 5*time.Second)
+	}()
+
+	for fakeClock.Pending() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	fakeClock.Advance(4 * time.Second)
+	select {
+	case <-done:
+		t.Fatal("sleepContext returned before the delay elapsed")
+	default:
+	}
 
-	duration := p.GetSessionDuration()
-	if duration < 10*time.Millisecond {
-		t.Errorf("Session duration = %v, expected >= 10ms", duration)
+	fakeClock.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Errorf("sleepContext() = %v, want nil", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sleepContext(ctx, fakeClock, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("sleepContext() with cancelled ctx = %v, want context.Canceled", err)
 	}
 }
 
@@ -366,6 +411,26 @@ Title3=Stream 3
 	}
 }
 
+func TestFetchAndParsePLSStreamBaseURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("[playlist]\nFile1=http://ice1.somafm.com/groovesalad-256-mp3\n"))
+	}))
+	defer server.Close()
+
+	p := NewPlayer()
+	p.SetStreamBaseURL("http://proxy.lan:8000/soma")
+
+	urls, err := p.fetchAndParsePLS(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("fetchAndParsePLS error: %v", err)
+	}
+
+	want := "http://proxy.lan:8000/soma/groovesalad-256-mp3"
+	if len(urls) != 1 || urls[0] != want {
+		t.Errorf("urls = %v, want [%s]", urls, want)
+	}
+}
+
 func TestFetchAndParsePLSEmpty(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		_, _ = w.Write([]byte("[playlist]\nNumberOfEntries=0\n"))
@@ -461,3 +526,1381 @@ func TestGetCurrentStation(t *testing.T) {
 		t.Error("Initial station should be nil")
 	}
 }
+
+func TestPlayerSilenceTracking(t *testing.T) {
+	p := NewPlayer()
+
+	if d := p.GetSilenceDuration(); d != 0 {
+		t.Errorf("Initial silence duration = %v, want 0", d)
+	}
+
+	silence := make([][2]float64, int(DefaultSampleRate))
+	p.trackSilence(silence)
+	p.trackSilence(silence)
+
+	if d := p.GetSilenceDuration(); d != 2*time.Second {
+		t.Errorf("Silence duration after 2s of silence = %v, want 2s", d)
+	}
+
+	audio := make([][2]float64, 16)
+	audio[8] = [2]float64{0.2, -0.2}
+	p.trackSilence(audio)
+
+	if d := p.GetSilenceDuration(); d != 0 {
+		t.Errorf("Silence duration after audible samples = %v, want 0", d)
+	}
+
+	quiet := [][2]float64{{SilenceThreshold / 2, -SilenceThreshold / 2}}
+	p.trackSilence(quiet)
+	if p.silentSamples.Load() != 1 {
+		t.Errorf("Samples below threshold should count as silence, got %d", p.silentSamples.Load())
+	}
+}
+
+func TestTestToneStreamer(t *testing.T) {
+	sampleRate := beep.SampleRate(1000)
+	tone, err := testToneStreamer(sampleRate, TestToneFrequency, 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("testToneStreamer() error = %v", err)
+	}
+
+	samples := make([][2]float64, 128)
+	total := 0
+	peak := 0.0
+	for {
+		n, ok := tone.Stream(samples)
+		for _, s := range samples[:n] {
+			peak = math.Max(peak, math.Abs(s[0]))
+		}
+		total += n
+		if !ok {
+			break
+		}
+	}
+
+	if total != 500 {
+		t.Errorf("test tone streamed %d samples, want 500", total)
+	}
+	if peak == 0 || peak > 0.31 {
+		t.Errorf("test tone peak = %v, want within (0, 0.3]", peak)
+	}
+}
+
+func TestPauseKeywordHold(t *testing.T) {
+	p := NewPlayer()
+	p.SetPauseKeywords([]string{"BREAK", "  ", "station id"})
+
+	tests := []struct {
+		track    string
+		expected string
+	}{
+		{"Artist - Song", ""},
+		{"Commercial Break", "BREAK"},
+		{"SomaFM Station ID", "station id"},
+		{"Artist - Next Song", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		p.setCurrentTrack(tt.track)
+		if got := p.GetHeldKeyword(); got != tt.expected {
+			t.Errorf("after track %q GetHeldKeyword() = %q, want %q", tt.track, got, tt.expected)
+		}
+	}
+}
+
+func TestSetPauseKeywordsReevaluatesCurrentTrack(t *testing.T) {
+	p := NewPlayer()
+	p.setCurrentTrack("News Break")
+
+	if got := p.GetHeldKeyword(); got != "" {
+		t.Errorf("GetHeldKeyword() without keywords = %q, want empty", got)
+	}
+
+	p.SetPauseKeywords([]string{"break"})
+	if got := p.GetHeldKeyword(); got != "break" {
+		t.Errorf("GetHeldKeyword() = %q, want %q", got, "break")
+	}
+}
+
+func TestRetitleUsesCurrentStation(t *testing.T) {
+	p := NewPlayer()
+	if got := p.retitle("Kiara - Bonobo"); got != "Kiara - Bonobo" {
+		t.Errorf("retitle() without rules = %q", got)
+	}
+
+	r, err := retitle.New([]retitle.Rule{{Station: "groovesalad", Match: `^(.+) - (.+)$`, Replace: "$2 - $1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SetTitleRewriter(r)
+	p.currentStation = &station.Station{ID: "dronezone"}
+	if got := p.retitle("Kiara - Bonobo"); got != "Kiara - Bonobo" {
+		t.Errorf("retitle() on another station = %q, want it unchanged", got)
+	}
+	p.currentStation = &station.Station{ID: "groovesalad"}
+	if got := p.retitle("Kiara - Bonobo"); got != "Bonobo - Kiara" {
+		t.Errorf("retitle() = %q, want %q", got, "Bonobo - Kiara")
+	}
+}
+
+func TestNewStreamRequestListenerID(t *testing.T) {
+	p := NewPlayer()
+
+	req, err := p.newStreamRequest(context.Background(), "http://example.com/stream")
+	if err != nil {
+		t.Fatalf("newStreamRequest() error = %v", err)
+	}
+	if got := req.Header.Get(ListenerIDHeader); got != "" {
+		t.Errorf("%s header = %q without listener ID, want empty", ListenerIDHeader, got)
+	}
+	if got := req.Header.Get("Icy-MetaData"); got != "1" {
+		t.Errorf("Icy-MetaData header = %q, want %q", got, "1")
+	}
+
+	p.SetListenerID("abc123")
+	req, err = p.newStreamRequest(context.Background(), "http://example.com/stream")
+	if err != nil {
+		t.Fatalf("newStreamRequest() error = %v", err)
+	}
+	if got := req.Header.Get(ListenerIDHeader); got != "abc123" {
+		t.Errorf("%s header = %q, want %q", ListenerIDHeader, got, "abc123")
+	}
+}
+
+// fakeStreamServer serves a PLS playlist and stream endpoints with scripted
+// behavior: "/ok" streams then ends cleanly, "/flaky" streams once then
+// returns 500, "/missing" returns 404, and "/broken" returns 500.
+type fakeStreamServer struct {
+	*httptest.Server
+	mu   sync.Mutex
+	hits map[string]int
+}
+
+func newFakeStreamServer(t *testing.T, streams ...string) *fakeStreamServer {
+	t.Helper()
+	f := &fakeStreamServer{hits: make(map[string]int)}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.hits[r.URL.Path]++
+		hits := f.hits[r.URL.Path]
+		f.mu.Unlock()
+
+		switch r.URL.Path {
+		case "/station.pls":
+			var b strings.Builder
+			fmt.Fprintf(&b, "[playlist]\nNumberOfEntries=%d\n", len(streams))
+			for i, s := range streams {
+				fmt.Fprintf(&b, "File%d=%s%s\n", i+1, f.URL, s)
+			}
+			_, _ = w.Write([]byte(b.String()))
+		case "/ok":
+			_, _ = w.Write([]byte("audio"))
+		case "/flaky":
+			if hits > 1 {
+				http.Error(w, "unavailable", http.StatusInternalServerError)
+				return
+			}
+			_, _ = w.Write([]byte("audio"))
+		case "/missing":
+			http.NotFound(w, r)
+		default:
+			http.Error(w, "unavailable", http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeStreamServer) hitCount(path string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.hits[path]
+}
+
+// fakeConnect requests the stream like playStreamURL does, but stands in for
+// decoding: "/ok" plays and returns nil, anything else returns an error.
+func fakeConnect(p *Player) streamConnector {
+	return func(ctx context.Context, streamURL string) error {
+		req, err := p.newStreamRequest(ctx, streamURL)
+		if err != nil {
+			return err
+		}
+		resp, err := p.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return &httpStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+		}
+
+		p.setState(StatePlaying)
+		if strings.HasSuffix(streamURL, "/ok") {
+			return nil
+		}
+		return fmt.Errorf("stream ended unexpectedly")
+	}
+}
+
+func newTestConnectionManager(p *Player, policy RetryPolicy) *connectionManager {
+	return &connectionManager{
+		player:  p,
+		policy:  policy,
+		fetch:   p.fetchAndParsePLS,
+		connect: fakeConnect(p),
+	}
+}
+
+func TestConnectionManagerSkipsNonRetryable(t *testing.T) {
+	server := newFakeStreamServer(t, "/missing", "/ok")
+	p := NewPlayer()
+	m := newTestConnectionManager(p, FixedDelayPolicy{Retries: 3})
+
+	if err := m.run(context.Background(), []string{server.URL + "/station.pls"}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if got := server.hitCount("/missing"); got != 1 {
+		t.Errorf("404 stream requested %d times, want 1", got)
+	}
+	if got := server.hitCount("/ok"); got != 1 {
+		t.Errorf("working stream requested %d times, want 1", got)
+	}
+}
+
+func TestConnectionManagerRetriesThenFails(t *testing.T) {
+	server := newFakeStreamServer(t, "/broken")
+	p := NewPlayer()
+	m := newTestConnectionManager(p, FixedDelayPolicy{Retries: 2})
+
+	err := m.run(context.Background(), []string{server.URL + "/station.pls"})
+	if err == nil || !strings.Contains(err.Error(), "all streams failed") {
+		t.Fatalf("run() error = %v, want all streams failed", err)
+	}
+	if got := server.hitCount("/broken"); got != 3 {
+		t.Errorf("broken stream requested %d times, want 3 (1 + 2 retries)", got)
+	}
+	if p.GetState() != StateError {
+		t.Errorf("state = %v, want %v", p.GetState(), StateError)
+	}
+	if current, max := p.GetRetryInfo(); current != 2 || max != 2 {
+		t.Errorf("GetRetryInfo() = (%d, %d), want (2, 2)", current, max)
+	}
+}
+
+func TestConnectionManagerReconnectsAfterDrop(t *testing.T) {
+	server := newFakeStreamServer(t, "/flaky", "/broken")
+	p := NewPlayer()
+	m := newTestConnectionManager(p, FixedDelayPolicy{Retries: 3})
+
+	err := m.run(context.Background(), []string{server.URL + "/station.pls"})
+	if err == nil || !strings.Contains(err.Error(), "reconnection failed") {
+		t.Fatalf("run() error = %v, want reconnection failed", err)
+	}
+	// Reconnect rotates through the playlist's streams: flaky, broken, flaky
+	if got := server.hitCount("/flaky"); got != 3 {
+		t.Errorf("flaky stream requested %d times, want 3", got)
+	}
+	if got := server.hitCount("/broken"); got != 1 {
+		t.Errorf("broken stream requested %d times, want 1", got)
+	}
+	if p.GetState() != StateError {
+		t.Errorf("state = %v, want %v", p.GetState(), StateError)
+	}
+}
+
+func TestConnectionManagerPlaylistFailureFallsThrough(t *testing.T) {
+	server := newFakeStreamServer(t, "/ok")
+	p := NewPlayer()
+	m := newTestConnectionManager(p, FixedDelayPolicy{Retries: 1})
+
+	playlists := []string{server.URL + "/missing.pls", server.URL + "/station.pls"}
+	if err := m.run(context.Background(), playlists); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	fb, ok := p.GetFallback()
+	if !ok || fb.FailedURL != playlists[0] || fb.PlayingURL != playlists[1] {
+		t.Errorf("GetFallback() = %+v, %v; want the second playlist standing in for the first", fb, ok)
+	}
+
+	if err := m.run(context.Background(), playlists[1:]); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if _, ok := p.GetFallback(); ok {
+		t.Error("GetFallback() reports a fallback when the first playlist played")
+	}
+}
+
+func TestConnectionManagerCancelDuringRetryWait(t *testing.T) {
+	server := newFakeStreamServer(t, "/broken")
+	p := NewPlayer()
+	m := newTestConnectionManager(p, FixedDelayPolicy{Retries: 3, Wait: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- m.run(ctx, []string{server.URL + "/station.pls"})
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for p.GetState() != StateReconnecting && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("run() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("run() did not return after cancel during retry wait")
+	}
+	if got := server.hitCount("/broken"); got != 1 {
+		t.Errorf("stream requested %d times after cancel, want 1", got)
+	}
+}
+
+func TestFixedDelayPolicy(t *testing.T) {
+	policy := FixedDelayPolicy{Retries: 2, Wait: time.Second}
+
+	for retry := 1; retry <= 2; retry++ {
+		if d, ok := policy.Delay(retry); !ok || d != time.Second {
+			t.Errorf("Delay(%d) = (%v, %v), want (1s, true)", retry, d, ok)
+		}
+	}
+	if _, ok := policy.Delay(3); ok {
+		t.Error("Delay(3) should stop retrying")
+	}
+	if policy.MaxRetries() != 2 {
+		t.Errorf("MaxRetries() = %d, want 2", policy.MaxRetries())
+	}
+}
+
+func TestBitrateMeter(t *testing.T) {
+	now := time.Unix(1000, 0)
+	m := &bitrateMeter{now: func() time.Time { return now }}
+
+	if got := m.kbps(); got != 0 {
+		t.Errorf("kbps() with no data = %d, want 0", got)
+	}
+
+	// 16000 bytes per second = 128 kbps
+	for i := 0; i < 10; i++ {
+		m.add(16000)
+		now = now.Add(time.Second)
+	}
+	if got := m.kbps(); got != 128 {
+		t.Errorf("kbps() = %d, want 128", got)
+	}
+
+	// Throttled to half speed for a full window
+	for i := 0; i < 6; i++ {
+		m.add(8000)
+		now = now.Add(time.Second)
+	}
+	if got := m.kbps(); got != 64 {
+		t.Errorf("kbps() after throttling = %d, want 64", got)
+	}
+
+	m.reset()
+	if got := m.kbps(); got != 0 {
+		t.Errorf("kbps() after reset = %d, want 0", got)
+	}
+}
+
+func TestBitrateMeterNeedsOneSecond(t *testing.T) {
+	now := time.Unix(1000, 0)
+	m := &bitrateMeter{now: func() time.Time { return now }}
+
+	m.add(50000)
+	now = now.Add(500 * time.Millisecond)
+	if got := m.kbps(); got != 0 {
+		t.Errorf("kbps() after 500ms = %d, want 0", got)
+	}
+}
+
+func TestSelectDecoder(t *testing.T) {
+	tests := []struct {
+		contentType string
+		urlFormat   string
+		want        string
+	}{
+		{"audio/mpeg", "AAC", "MP3"},
+		{"audio/aacp", "MP3", "AAC"},
+		{"audio/aac; charset=binary", "MP3", "AAC"},
+		{"", "AAC", "AAC"},
+		{"application/octet-stream", "MP3", "MP3"},
+		{"", "", "MP3"},
+	}
+
+	for _, tt := range tests {
+		if got, _ := selectDecoder(streamInfoFromHeaders(http.Header{"Content-Type": {tt.contentType}}, StreamInfo{Format: tt.urlFormat}).Format); got != tt.want {
+			t.Errorf("selectDecoder(%q, %q) = %s, want %s", tt.contentType, tt.urlFormat, got, tt.want)
+		}
+	}
+}
+
+func TestPCMStreamer(t *testing.T) {
+	// Two stereo frames: (max, min) and (0, half), then a dangling byte
+	data := []byte{0xff, 0x7f, 0x00, 0x80, 0x00, 0x00, 0x00, 0x40, 0x01}
+	s := &pcmStreamer{reader: bytes.NewReader(data)}
+
+	samples := make([][2]float64, 8)
+	n, ok := s.Stream(samples)
+	if !ok || n != 2 {
+		t.Fatalf("Stream() = %d, %v, want 2, true", n, ok)
+	}
+	want := [][2]float64{{32767.0 / 32768, -1}, {0, 0.5}}
+	for i := range want {
+		if samples[i] != want[i] {
+			t.Errorf("samples[%d] = %v, want %v", i, samples[i], want[i])
+		}
+	}
+
+	if n, ok := s.Stream(samples); ok || n != 0 {
+		t.Errorf("Stream() at EOF = %d, %v, want 0, false", n, ok)
+	}
+	if err := s.Err(); err != nil {
+		t.Errorf("Err() at EOF = %v, want nil", err)
+	}
+}
+
+func TestDecodeWithFFmpegMissing(t *testing.T) {
+	old := ffmpegPath
+	ffmpegPath = "somafm-ffmpeg-does-not-exist"
+	t.Cleanup(func() { ffmpegPath = old })
+
+	_, _, err := decodeWithFFmpeg(io.NopCloser(strings.NewReader("")))
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("decodeWithFFmpeg() error = %v, want ErrUnsupportedFormat", err)
+	}
+	if !isNonRetryableError(fmt.Errorf("wrapped: %w", err)) {
+		t.Error("missing decoder should not be retried")
+	}
+}
+
+func TestStreamInfoFromHeaders(t *testing.T) {
+	guess := StreamInfo{Format: "MP3", Quality: "high", Bitrate: 128, SampleRate: 44100}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    StreamInfo
+	}{
+		{
+			name:    "no headers keeps guess",
+			headers: nil,
+			want:    guess,
+		},
+		{
+			name:    "icy headers",
+			headers: map[string]string{"Content-Type": "audio/aacp", "icy-br": "64", "icy-sr": "48000"},
+			want:    StreamInfo{Format: "AAC", Quality: "medium", Bitrate: 64, SampleRate: 48000},
+		},
+		{
+			name:    "duplicated bitrate",
+			headers: map[string]string{"Content-Type": "audio/mpeg", "icy-br": "256,256"},
+			want:    StreamInfo{Format: "MP3", Quality: "highest", Bitrate: 256, SampleRate: 44100},
+		},
+		{
+			name:    "ice-audio-info",
+			headers: map[string]string{"ice-audio-info": "ice-samplerate=32000;ice-bitrate=32;ice-channels=2"},
+			want:    StreamInfo{Format: "MP3", Quality: "low", Bitrate: 32, SampleRate: 32000},
+		},
+		{
+			name:    "malformed values",
+			headers: map[string]string{"Content-Type": "text/html", "icy-br": "fast", "icy-sr": "-1"},
+			want:    guess,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for k, v := range tt.headers {
+				h.Set(k, v)
+			}
+			if got := streamInfoFromHeaders(h, guess); got != tt.want {
+				t.Errorf("streamInfoFromHeaders() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPCMStreamerRealignsPartialReads(t *testing.T) {
+	// One byte at a time, so every read ends mid-frame
+	data := []byte{0x00, 0x40, 0x00, 0xc0, 0x00, 0x20, 0x00, 0xe0}
+	s := &pcmStreamer{reader: iotest.OneByteReader(bytes.NewReader(data))}
+
+	samples := make([][2]float64, 1)
+	for i, want := range [][2]float64{{0.5, -0.5}, {0.25, -0.25}} {
+		if n, ok := s.Stream(samples); !ok || n != 1 {
+			t.Fatalf("Stream() #%d = %d, %v, want 1, true", i, n, ok)
+		}
+		if samples[0] != want {
+			t.Errorf("frame %d = %v, want %v", i, samples[0], want)
+		}
+	}
+}
+
+func TestJournalKeepsLatestEntries(t *testing.T) {
+	var j journal
+	for i := 0; i < JournalSize+5; i++ {
+		j.add(JournalError, "event %d", i)
+	}
+
+	entries := j.snapshot()
+	if len(entries) != JournalSize {
+		t.Fatalf("snapshot() len = %d, want %d", len(entries), JournalSize)
+	}
+	if got := entries[0].Message; got != "event 5" {
+		t.Errorf("oldest = %q, want %q", got, "event 5")
+	}
+	if got := entries[len(entries)-1].Message; got != fmt.Sprintf("event %d", JournalSize+4) {
+		t.Errorf("newest = %q", got)
+	}
+}
+
+func TestBufferedStreamerJournalsUnderrunOncePerGap(t *testing.T) {
+	p := NewPlayer()
+	p.sampleCh = make(chan [2]float64, 16)
+	p.streamDone = make(chan struct{})
+	b := &bufferedStreamerWrapper{player: p}
+	samples := make([][2]float64, 4)
+
+	fill := func(n int) {
+		for i := 0; i < n; i++ {
+			p.sampleCh <- [2]float64{0.5, 0.5}
+		}
+	}
+
+	// Starting up short isn't an underrun
+	fill(2)
+	b.Stream(samples)
+	fill(4)
+	b.Stream(samples)
+	fill(1)
+	b.Stream(samples)
+	b.Stream(samples)
+	fill(4)
+	b.Stream(samples)
+	b.Stream(samples)
+
+	var underruns int
+	for _, e := range p.Journal() {
+		if e.Kind == JournalUnderrun {
+			underruns++
+		}
+	}
+	if underruns != 2 {
+		t.Errorf("underruns = %d, want 2", underruns)
+	}
+}
+
+func TestPrefetchOpensConnectionOnce(t *testing.T) {
+	f := newFakeStreamServer(t, "/ok")
+	p := NewPlayer()
+	s := &station.Station{ID: "groovesalad", Playlists: []station.Playlist{{URL: f.URL + "/station.pls", Format: "mp3", Quality: "highest"}}}
+
+	p.Prefetch(context.Background(), s)
+	p.Prefetch(context.Background(), s)
+	if got := f.hitCount("/ok"); got != 1 {
+		t.Fatalf("stream hits after prefetch = %d, want 1", got)
+	}
+
+	urls, err := p.cachedPlaylistFetcher(context.Background(), f.URL+"/station.pls")
+	if err != nil || len(urls) != 1 || urls[0] != f.URL+"/ok" {
+		t.Fatalf("cachedPlaylistFetcher() = %v, %v", urls, err)
+	}
+	if got := f.hitCount("/station.pls"); got != 1 {
+		t.Errorf("playlist hits = %d, want 1 (served from prefetch)", got)
+	}
+
+	resp := p.takePrefetched(f.URL + "/ok")
+	if resp == nil {
+		t.Fatal("takePrefetched() = nil, want prefetched response")
+	}
+	resp.Body.Close()
+	if p.takePrefetched(f.URL+"/ok") != nil {
+		t.Error("takePrefetched() handed out the same connection twice")
+	}
+}
+
+func TestPrefetchDropsUnwantedStations(t *testing.T) {
+	f := newFakeStreamServer(t, "/ok")
+	p := NewPlayer()
+	s := &station.Station{ID: "groovesalad", Playlists: []station.Playlist{{URL: f.URL + "/station.pls", Format: "mp3"}}}
+
+	p.Prefetch(context.Background(), s)
+	p.Prefetch(context.Background())
+
+	if resp := p.takePrefetched(f.URL + "/ok"); resp != nil {
+		resp.Body.Close()
+		t.Error("connection for a station no longer wanted should be closed")
+	}
+	if _, ok := p.prefetchedPlaylist(f.URL + "/station.pls"); ok {
+		t.Error("playlist for a station no longer wanted should be dropped")
+	}
+}
+
+func TestRecorderSplitsTracksWithPadding(t *testing.T) {
+	record := func(t *testing.T, keepPartial bool) (string, []string) {
+		dir := t.TempDir()
+		var r recorder
+		opts := RecordingOptions{Dir: dir, SplitTracks: true, KeepPartial: keepPartial, Padding: time.Second}
+		// 8 kbps makes one second of padding 1000 bytes
+		if _, err := r.start(opts, "Groove Salad", "A - One", "MP3", 8); err != nil {
+			t.Fatalf("start() error = %v", err)
+		}
+		r.write(bytes.Repeat([]byte("a"), 1500))
+		r.trackChanged("B - Two")
+		r.write(bytes.Repeat([]byte("b"), 2000))
+		r.trackChanged("B - Two")
+		r.trackChanged("C / Three")
+		r.write(bytes.Repeat([]byte("c"), 500))
+		saved, err := r.stop()
+		if err != nil {
+			t.Fatalf("stop() error = %v", err)
+		}
+		return dir, saved
+	}
+
+	t.Run("discard partial", func(t *testing.T) {
+		dir, saved := record(t, false)
+		if len(saved) != 1 || filepath.Base(saved[0]) != "B - Two.mp3" {
+			t.Fatalf("saved = %v, want only B - Two.mp3", saved)
+		}
+		data, _ := os.ReadFile(saved[0])
+		want := strings.Repeat("a", 1000) + strings.Repeat("b", 2000) + strings.Repeat("c", 500)
+		if string(data) != want {
+			t.Errorf("B - Two.mp3 has %d bytes, want %d with padding on both sides", len(data), len(want))
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+			t.Errorf("recording dir has %d files, want partial tracks removed", len(entries))
+		}
+	})
+
+	t.Run("keep partial", func(t *testing.T) {
+		_, saved := record(t, true)
+		var names []string
+		for _, path := range saved {
+			names = append(names, filepath.Base(path))
+		}
+		want := []string{"A - One.mp3", "B - Two.mp3", "C _ Three.mp3"}
+		if strings.Join(names, ",") != strings.Join(want, ",") {
+			t.Errorf("saved = %v, want %v", names, want)
+		}
+	})
+}
+
+func TestRecordingFileName(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"Air - La femme d'argent", "Air - La femme d'argent.mp3"},
+		{"AC/DC: Back\tin\x00Black?", "AC_DC_ Back_in_Black_.mp3"},
+		{" ..hidden. ", "hidden.mp3"},
+		{"", "Unknown.mp3"},
+		{strings.Repeat("a", 199) + "é", strings.Repeat("a", 199) + ".mp3"},
+	}
+	for _, tt := range tests {
+		got := recordingFileName(tt.name, ".mp3")
+		if got != tt.want {
+			t.Errorf("recordingFileName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("recordingFileName(%q) = %q, not valid UTF-8", tt.name, got)
+		}
+	}
+}
+
+func TestRecorderSingleFile(t *testing.T) {
+	dir := t.TempDir()
+	var r recorder
+	path, err := r.start(RecordingOptions{Dir: dir}, "Groove Salad", "", "AAC", 64)
+	if err != nil {
+		t.Fatalf("start() error = %v", err)
+	}
+	if !strings.HasPrefix(filepath.Base(path), "Groove Salad ") || filepath.Ext(path) != ".aac" {
+		t.Errorf("start() path = %q", path)
+	}
+
+	r.write([]byte("one"))
+	r.trackChanged("Next - Track")
+	r.write([]byte("two"))
+	saved, _ := r.stop()
+
+	data, _ := os.ReadFile(path)
+	if len(saved) != 1 || string(data) != "onetwo" {
+		t.Errorf("saved = %v with %q, want one file with all audio", saved, data)
+	}
+	if r.isActive() {
+		t.Error("recorder still active after stop()")
+	}
+}
+
+func TestPruneRecordings(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	write := func(name string, size int, age time.Duration) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	ancient := write("Ancient.mp3", 10, 40*24*time.Hour)
+	oldest := write("Oldest.mp3", 100, 3*time.Hour)
+	older := write("Older.aac", 100, 2*time.Hour)
+	newest := write("Newest.mp3", 100, time.Hour)
+	active := write("Active.mp3", 150, 0)
+	notes := write("notes.txt", 1000, 50*24*time.Hour)
+
+	usage, err := PruneRecordings(dir, RecordingQuota{MaxBytes: 400, MaxAge: 30 * 24 * time.Hour}, now, []string{active})
+	if err != nil {
+		t.Fatalf("PruneRecordings() error = %v", err)
+	}
+	if want := []string{ancient, oldest}; !slices.Equal(usage.Removed, want) {
+		t.Errorf("Removed = %v, want %v", usage.Removed, want)
+	}
+	if usage.Total != 350 || usage.Pinned != 150 {
+		t.Errorf("usage = %+v, want 350 bytes with 150 pinned", usage)
+	}
+	for _, path := range []string{older, newest, active, notes} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s should be kept: %v", filepath.Base(path), err)
+		}
+	}
+
+	usage, err = PruneRecordings(dir, RecordingQuota{MaxBytes: 100}, now, []string{active})
+	if err != nil {
+		t.Fatalf("PruneRecordings() error = %v", err)
+	}
+	if usage.Total != 150 || usage.Pinned != 150 {
+		t.Errorf("usage = %+v, want only the active recording left", usage)
+	}
+
+	if _, err := PruneRecordings(filepath.Join(dir, "missing"), RecordingQuota{MaxBytes: 1}, now, nil); err != nil {
+		t.Errorf("missing directory should not be an error, got %v", err)
+	}
+}
+
+func TestTimeShiftRewindAndForward(t *testing.T) {
+	var ts timeShift
+	ts.reset(beep.SampleRate(10)) // 300 samples of history
+
+	block := func(from int) [][2]float64 {
+		samples := make([][2]float64, 10)
+		for i := range samples {
+			samples[i] = [2]float64{float64(from + i), 0}
+		}
+		return samples
+	}
+
+	if got := ts.shift(-time.Second); got != 0 {
+		t.Fatalf("rewind with no history = %v, want 0", got)
+	}
+
+	for i := 0; i < 5; i++ {
+		ts.process(block(i * 10))
+	}
+	if got := ts.shift(-2 * time.Second); got != 2*time.Second {
+		t.Fatalf("rewind = %v, want 2s", got)
+	}
+
+	samples := block(50)
+	if !ts.process(samples) {
+		t.Error("first block after a jump should report it")
+	}
+	if samples[0][0] != 30 || samples[9][0] != 39 {
+		t.Errorf("delayed samples = %v..%v, want 30..39", samples[0][0], samples[9][0])
+	}
+	if ts.process(block(60)) {
+		t.Error("jump reported twice")
+	}
+
+	if got := ts.shift(-time.Minute); got != 6900*time.Millisecond {
+		t.Errorf("rewind past history = %v, want clamped to 6.9s", got)
+	}
+	if got := ts.shift(time.Minute); got != 0 {
+		t.Errorf("forward past live = %v, want 0", got)
+	}
+	samples = block(70)
+	ts.process(samples)
+	if samples[0][0] != 70 {
+		t.Errorf("live sample = %v, want 70", samples[0][0])
+	}
+}
+
+func TestListenExcludesPauses(t *testing.T) {
+	p := NewPlayer()
+	fakeClock := clock.NewFake(time.Now())
+	p.SetClock(fakeClock)
+	p.currentStation = &station.Station{ID: "groovesalad"}
+
+	var heard []TrackListen
+	p.SetListenHandler(func(l TrackListen) { heard = append(heard, l) })
+
+	p.setCurrentTrack("Artist - One")
+	fakeClock.Advance(30 * time.Second)
+	p.listen.pause(fakeClock.Now())
+	fakeClock.Advance(2 * time.Minute)
+	p.listen.resume(fakeClock.Now())
+	fakeClock.Advance(10 * time.Second)
+	p.setCurrentTrack("Artist - Two")
+	fakeClock.Advance(5 * time.Second)
+	p.listen.pause(fakeClock.Now())
+	fakeClock.Advance(time.Minute)
+	p.endListen()
+
+	if len(heard) != 2 {
+		t.Fatalf("got %d listens, want 2", len(heard))
+	}
+	if l := heard[0]; l.Track != "Artist - One" || l.Heard() != 40*time.Second || l.Paused() != 2*time.Minute {
+		t.Errorf("first listen = %q heard %v paused %v, want 40s heard, 2m paused", l.Track, l.Heard(), l.Paused())
+	}
+	if l := heard[1]; l.Track != "Artist - Two" || l.Heard() != 5*time.Second {
+		t.Errorf("second listen = %q heard %v, want 5s, counting a pause still open at stop", l.Track, l.Heard())
+	}
+}
+
+func TestSetTrackNote(t *testing.T) {
+	p := NewPlayer()
+	p.currentStation = &station.Station{ID: "groovesalad"}
+	var heard []TrackListen
+	p.SetListenHandler(func(l TrackListen) { heard = append(heard, l) })
+
+	p.setCurrentTrack("Artist - One")
+	if p.SetTrackNote("Artist - Zero", "too late") {
+		t.Error("SetTrackNote() = true for a track that already ended")
+	}
+	if !p.SetTrackNote("Artist - One", "from that movie") || p.TrackNote() != "from that movie" {
+		t.Errorf("TrackNote() = %q after SetTrackNote()", p.TrackNote())
+	}
+	p.setCurrentTrack("Artist - Two")
+	if p.TrackNote() != "" {
+		t.Errorf("TrackNote() = %q on the next track, want none", p.TrackNote())
+	}
+	if len(heard) != 1 || heard[0].Note != "from that movie" {
+		t.Errorf("listens = %+v, want the note handed over", heard)
+	}
+}
+
+func TestTrackStartHandler(t *testing.T) {
+	p := NewPlayer()
+	p.currentStation = &station.Station{ID: "groovesalad"}
+	var started []string
+	p.SetTrackStartHandler(func(l TrackListen) { started = append(started, l.Track) })
+
+	p.setCurrentTrack("Artist - One")
+	p.beginListen("Artist - One") // A reconnect continues the track
+	p.setCurrentTrack(NoTrackInfo)
+	p.setCurrentTrack("Artist - Two")
+	if !slices.Equal(started, []string{"Artist - One", "Artist - Two"}) {
+		t.Errorf("started = %q, want each track once", started)
+	}
+}
+
+// constStreamer plays a constant level on both channels.
+type constStreamer float64
+
+func (c constStreamer) Stream(samples [][2]float64) (int, bool) {
+	for i := range samples {
+		samples[i] = [2]float64{float64(c), float64(c)}
+	}
+	return len(samples), true
+}
+
+func (constStreamer) Err() error { return nil }
+
+func TestNormalizerEvensOutLoudness(t *testing.T) {
+	rate := beep.SampleRate(1000)
+	var enabled atomic.Bool
+
+	level := func(in float64) float64 {
+		n := newNormalizer(constStreamer(in), rate, &enabled)
+		samples := make([][2]float64, 100)
+		for i := 0; i < 200; i++ { // 20 seconds
+			n.Stream(samples)
+		}
+		return samples[len(samples)-1][0]
+	}
+
+	if got := level(0.05); got != 0.05 {
+		t.Errorf("disabled normalizer changed the level to %v", got)
+	}
+
+	enabled.Store(true)
+	quiet, loud := level(0.05), level(0.5)
+	if math.Abs(quiet-normalizeTarget) > 0.01 || math.Abs(loud-normalizeTarget) > 0.01 {
+		t.Errorf("normalized levels = %v and %v, want both near %v", quiet, loud, normalizeTarget)
+	}
+	if got := level(0.01); got > 0.01*normalizeMaxGain+1e-9 {
+		t.Errorf("gain on a very quiet stream = %v, want at most %vx", got/0.01, normalizeMaxGain)
+	}
+	if got := softLimit(1.2); got >= 1 || got <= limiterThreshold {
+		t.Errorf("softLimit(1.2) = %v, want between %v and 1", got, limiterThreshold)
+	}
+}
+
+func TestCompressorNarrowsDynamicRange(t *testing.T) {
+	rate := beep.SampleRate(1000)
+	var enabled atomic.Bool
+
+	level := func(in float64) float64 {
+		c := newCompressor(constStreamer(in), rate, &enabled)
+		samples := make([][2]float64, 100)
+		for i := 0; i < 50; i++ { // 5 seconds
+			c.Stream(samples)
+		}
+		return samples[len(samples)-1][0]
+	}
+
+	if got := level(0.8); got != 0.8 {
+		t.Errorf("disabled compressor changed the level to %v", got)
+	}
+
+	enabled.Store(true)
+	quiet, loud := level(0.04), level(0.8)
+	if math.Abs(quiet-0.04*nightMakeup) > 1e-6 {
+		t.Errorf("quiet level = %v, want %v with only the makeup gain", quiet, 0.04*nightMakeup)
+	}
+	// 26 dB apart going in, under 10 dB coming out
+	if ratio := loud / quiet; ratio > 3.2 {
+		t.Errorf("loud/quiet = %v, want at most 3.2", ratio)
+	}
+	if loud >= 1 {
+		t.Errorf("loud level = %v, want below full scale", loud)
+	}
+}
+
+func TestCompressorFadesWhenToggled(t *testing.T) {
+	rate := beep.SampleRate(1000)
+	var enabled atomic.Bool
+	c := newCompressor(constStreamer(0.04), rate, &enabled)
+	samples := make([][2]float64, 10)
+
+	enabled.Store(true)
+	c.Stream(samples)
+	if got := samples[len(samples)-1][0]; got <= 0.04 || got >= 0.04*nightMakeup {
+		t.Errorf("level 10 ms after switching on = %v, want between dry and full makeup", got)
+	}
+
+	enabled.Store(false)
+	for i := 0; i < 20; i++ {
+		c.Stream(samples)
+	}
+	if got := samples[len(samples)-1][0]; got != 0.04 {
+		t.Errorf("level after switching off = %v, want the dry 0.04", got)
+	}
+}
+
+func TestStartupTimerPhases(t *testing.T) {
+	var timer startupTimer
+	start := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+
+	gen := timer.begin(&station.Station{ID: "groovesalad", Title: "Groove Salad"}, at(0))
+	timer.markFetched(at(120))
+	timer.markConnected(at(200))
+	timer.markConnected(at(450)) // A retry: the later connect counts
+	timer.markDecoded(at(500))
+	timing, ok := timer.finish(at(1300))
+	if !ok {
+		t.Fatal("finish() found no start in progress")
+	}
+	want := StartupTiming{
+		Station: "groovesalad", Title: "Groove Salad",
+		Playlist: 120 * time.Millisecond, Connect: 330 * time.Millisecond,
+		Decode: 50 * time.Millisecond, Buffer: 800 * time.Millisecond, Total: 1300 * time.Millisecond,
+	}
+	if timing != want {
+		t.Errorf("finish() = %+v, want %+v", timing, want)
+	}
+	if _, ok := timer.finish(at(2000)); ok {
+		t.Error("finish() completed the same start twice")
+	}
+
+	// Abandoning a start that was replaced leaves the new one running
+	timer.begin(&station.Station{ID: "dronezone"}, at(3000))
+	timer.abandon(gen)
+	if _, ok := timer.finish(at(3500)); !ok {
+		t.Error("a stale abandon dropped the start in progress")
+	}
+	if got := timer.snapshot(); len(got) != 2 || got[1].Total != 500*time.Millisecond {
+		t.Errorf("snapshot() = %+v, want two starts, the second 500ms", got)
+	}
+}
+
+func TestStartupPercentile(t *testing.T) {
+	var timings []StartupTiming
+	for i := 1; i <= 10; i++ {
+		timings = append(timings, StartupTiming{Total: time.Duration(i) * 100 * time.Millisecond})
+	}
+	tests := []struct {
+		q    float64
+		want time.Duration
+	}{
+		{0.5, 500 * time.Millisecond},
+		{0.9, 900 * time.Millisecond},
+		{0.99, time.Second},
+		{0, 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := StartupPercentile(timings, tt.q); got != tt.want {
+			t.Errorf("StartupPercentile(%v) = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+	if got := StartupPercentile(nil, 0.5); got != 0 {
+		t.Errorf("StartupPercentile(nil) = %v, want 0", got)
+	}
+}
+
+func TestPlayerChangesCoalesce(t *testing.T) {
+	p := NewPlayer()
+
+	p.setState(StateBuffering)
+	p.setState(StatePlaying)
+	select {
+	case <-p.Changes():
+	default:
+		t.Fatal("Changes() did not fire after a state change")
+	}
+	select {
+	case <-p.Changes():
+		t.Fatal("Changes() fired twice for coalesced changes")
+	default:
+	}
+
+	p.setState(StatePlaying)
+	select {
+	case <-p.Changes():
+		t.Fatal("Changes() fired without a state change")
+	default:
+	}
+}
+
+func TestTimeShiftScrub(t *testing.T) {
+	var ts timeShift
+	ts.reset(beep.SampleRate(100)) // 3000 samples of history; a 60-sample preview
+
+	block := func(n int) [][2]float64 {
+		samples := make([][2]float64, n)
+		for i := range samples {
+			samples[i] = [2]float64{1, 1}
+		}
+		return samples
+	}
+	for i := 0; i < 10; i++ {
+		ts.process(block(100))
+	}
+	ts.shift(-time.Second)
+
+	ts.startScrub()
+	if got := ts.scrub(-4 * time.Second); got != 5*time.Second {
+		t.Fatalf("scrub = %v, want 5s", got)
+	}
+
+	samples := block(100)
+	if !ts.process(samples) {
+		t.Error("a scrub step should report a jump")
+	}
+	if samples[0][0] != 1 {
+		t.Errorf("preview start = %v, want full volume", samples[0][0])
+	}
+	if samples[59][0] <= 0 || samples[59][0] >= 1 {
+		t.Errorf("preview end = %v, want faded", samples[59][0])
+	}
+	if samples[60][0] != 0 || samples[99][0] != 0 {
+		t.Error("output after the preview should be silent")
+	}
+
+	if got := ts.endScrub(false); got != time.Second {
+		t.Errorf("cancelled scrub = %v, want back to 1s", got)
+	}
+	ts.startScrub()
+	ts.scrub(-2 * time.Second)
+	if got := ts.endScrub(true); got != 3*time.Second {
+		t.Errorf("settled scrub = %v, want 3s", got)
+	}
+	samples = block(10)
+	ts.process(samples)
+	if samples[9][0] != 1 {
+		t.Error("playback after settling should not be muted")
+	}
+}
+
+func TestProbeStream(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/groovesalad130.pls":
+			fmt.Fprintf(w, "[playlist]\nFile1=%s/groovesalad-128-aac\n", server.URL)
+		case "/groovesalad256.pls":
+			fmt.Fprintf(w, "[playlist]\nFile1=%s/gone\n", server.URL)
+		case "/groovesalad-128-aac":
+			w.Header().Set("Content-Type", "audio/aacp")
+			w.Header().Set("icy-br", "128")
+			_, _ = w.Write([]byte("audio"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	p := NewPlayer()
+
+	probe := p.ProbeStream(context.Background(), server.URL+"/groovesalad130.pls")
+	if probe.Err != nil {
+		t.Fatalf("ProbeStream() error = %v", probe.Err)
+	}
+	if probe.Info.Format != "AAC" || probe.Info.Bitrate != 128 || probe.Info.PlaylistURL != server.URL+"/groovesalad130.pls" {
+		t.Errorf("ProbeStream() info = %+v, want AAC 128k from the headers", probe.Info)
+	}
+
+	probe = p.ProbeStream(context.Background(), server.URL+"/groovesalad256.pls")
+	var statusErr *httpStatusError
+	if !errors.As(probe.Err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("ProbeStream() error = %v, want 404", probe.Err)
+	}
+	if probe.Info.Bitrate != 256 {
+		t.Errorf("ProbeStream() info = %+v, want the guess from the URL", probe.Info)
+	}
+}
+
+func TestRecorderResumesAfterReconnect(t *testing.T) {
+	dir := t.TempDir()
+	var r recorder
+	path, err := r.start(RecordingOptions{Dir: dir}, "Groove Salad", "", "MP3", 128)
+	if err != nil {
+		t.Fatalf("start() error = %v", err)
+	}
+	dropped := time.Date(2026, 1, 10, 3, 0, 0, 0, time.UTC)
+
+	r.write([]byte("one"))
+	r.resumed(dropped) // No drop yet, nothing to mark
+	r.interrupted(dropped)
+	r.interrupted(dropped.Add(time.Second)) // A failed retry keeps the first drop
+	r.resumed(dropped.Add(8 * time.Second))
+	r.write([]byte("two"))
+	saved, _ := r.stop()
+
+	marker := gapMarker(dropped.Add(8*time.Second), 8*time.Second)
+	data, _ := os.ReadFile(path)
+	if len(saved) != 1 || string(data) != "one"+string(marker)+"two" {
+		t.Fatalf("saved = %v with %q, want one file with a marker between the parts", saved, data)
+	}
+	if !bytes.HasPrefix(marker, []byte("ID3\x04\x00\x00\x00\x00\x00")) ||
+		!bytes.Contains(marker, []byte("RECONNECT\x00Stream reconnected at 2026-01-10T03:00:08Z after 8s")) {
+		t.Errorf("gapMarker() = %q", marker)
+	}
+	if size := int(marker[9]); size != len(marker)-10 {
+		t.Errorf("tag size = %d, want %d", size, len(marker)-10)
+	}
+}
+
+func TestLevels(t *testing.T) {
+	p := NewPlayer()
+	samples := [][2]float64{{0.5, 0}, {-0.5, 0.1}, {0.5, 0}, {-0.5, -0.1}}
+
+	p.meter.add(samples)
+	if l := p.Levels(); l != (Levels{}) {
+		t.Errorf("Levels() = %+v with metering off, want zero", l)
+	}
+
+	p.SetMetering(true)
+	p.meter.add(samples)
+	l := p.Levels()
+	if l.RMS[0] != 0.5 || l.Peak[0] != 0.5 || l.Peak[1] != 0.1 {
+		t.Errorf("Levels() = %+v, want left RMS 0.5 and peaks 0.5 and 0.1", l)
+	}
+	if l.RMS[1] <= 0 || l.RMS[1] >= 0.1 {
+		t.Errorf("right RMS = %v, want between 0 and 0.1", l.RMS[1])
+	}
+	if l := p.Levels(); l != (Levels{}) {
+		t.Errorf("second Levels() = %+v, want zero until more audio", l)
+	}
+}
+
+func TestHealthHistory(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC))
+	p := NewPlayer()
+	p.SetClock(fake)
+	p.sampleCh = make(chan [2]float64, 16)
+	p.streamDone = make(chan struct{})
+	b := &bufferedStreamerWrapper{player: p}
+	samples := make([][2]float64, 4)
+
+	fill := func(n int) {
+		for i := 0; i < n; i++ {
+			p.sampleCh <- [2]float64{0.5, 0.5}
+		}
+	}
+
+	// Nothing is sampled before audio flows
+	b.Stream(samples)
+	if h := p.HealthHistory(); len(h) != 0 {
+		t.Fatalf("HealthHistory() = %+v before playback", h)
+	}
+
+	fill(12)
+	b.Stream(samples)
+	fill(4)
+	b.Stream(samples) // Too soon for another sample
+	fake.Advance(HealthInterval)
+	b.Stream(samples)
+	b.Stream(samples)
+	fake.Advance(HealthInterval)
+	b.Stream(samples) // Runs dry
+	fake.Advance(HealthInterval)
+	fill(4)
+	b.Stream(samples)
+
+	h := p.HealthHistory()
+	if len(h) != 4 {
+		t.Fatalf("HealthHistory() has %d samples, want 4: %+v", len(h), h)
+	}
+	if h[0].Buffer != 50 || h[0].Dropout {
+		t.Errorf("first sample = %+v, want 50%% without a dropout", h[0])
+	}
+	if !h[2].Dropout || h[2].Buffer != 0 {
+		t.Errorf("third sample = %+v, want an empty buffer after a dropout", h[2])
+	}
+	if h[3].Dropout {
+		t.Errorf("last sample = %+v, want the dropout marked once", h[3])
+	}
+
+	for range HealthSamples {
+		fake.Advance(HealthInterval)
+		fill(4)
+		b.Stream(samples)
+	}
+	if h := p.HealthHistory(); len(h) != HealthSamples || h[len(h)-1].Time != fake.Now() {
+		t.Errorf("HealthHistory() has %d samples, want the latest %d", len(h), HealthSamples)
+	}
+}
+
+func TestComparison(t *testing.T) {
+	// B decodes as 16-bit PCM, every sample at half scale
+	old := decoders["MP3"]
+	decoders["MP3"] = func(r io.ReadCloser) (beep.StreamSeekCloser, beep.Format, error) {
+		return &pcmStreamer{reader: r, closer: r.Close}, beep.Format{SampleRate: DefaultSampleRate, NumChannels: 2, Precision: 2}, nil
+	}
+	t.Cleanup(func() { decoders["MP3"] = old })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/b.pls" {
+			fmt.Fprintf(w, "[playlist]\nNumberOfEntries=1\nFile1=http://%s/b\n", r.Host)
+			return
+		}
+		if r.Header.Get("Icy-MetaData") != "" {
+			t.Error("comparison stream asked for metadata")
+		}
+		_, _ = w.Write(bytes.Repeat([]byte{0x00, 0x40}, 2*SampleChannelSize))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	p := NewPlayer()
+	if err := p.StartComparison(server.URL + "/b.pls"); !errors.Is(err, ErrNotPlaying) {
+		t.Fatalf("StartComparison() while idle = %v, want ErrNotPlaying", err)
+	}
+
+	p.isPlaying = true
+	p.sampleCh = make(chan [2]float64, 16)
+	p.streamDone = make(chan struct{})
+	for range 16 {
+		p.sampleCh <- [2]float64{0.1, 0.1}
+	}
+	if err := p.StartComparison(server.URL + "/b.pls"); err != nil {
+		t.Fatalf("StartComparison() error = %v", err)
+	}
+	defer p.StopComparison()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c, ok := p.Comparison()
+		if !ok {
+			t.Fatal("Comparison() reported no comparison")
+		}
+		if c.B.Buffer == 100 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("B buffer = %d%%, want it filled", c.B.Buffer)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	b := &bufferedStreamerWrapper{player: p}
+	samples := make([][2]float64, 4)
+	b.Stream(samples)
+	if samples[0] != [2]float64{0.1, 0.1} {
+		t.Errorf("heard %v, want A", samples[0])
+	}
+
+	if hearingB, ok := p.SwitchComparison(); !hearingB || !ok {
+		t.Fatalf("SwitchComparison() = %v, %v, want B", hearingB, ok)
+	}
+	b.Stream(samples)
+	if samples[3] != [2]float64{0.5, 0.5} {
+		t.Errorf("heard %v, want B", samples[3])
+	}
+	if got := len(p.sampleCh); got != 8 {
+		t.Errorf("A buffer holds %d samples, want 8 after being drained along", got)
+	}
+
+	p.StopComparison()
+	if _, ok := p.Comparison(); ok {
+		t.Error("Comparison() still running after StopComparison()")
+	}
+	b.Stream(samples)
+	if samples[0] != [2]float64{0.1, 0.1} {
+		t.Errorf("heard %v after the comparison, want A", samples[0])
+	}
+}
+
+func TestComparisonStoppedWhileConnecting(t *testing.T) {
+	old := decoders["MP3"]
+	decoders["MP3"] = func(r io.ReadCloser) (beep.StreamSeekCloser, beep.Format, error) {
+		return &pcmStreamer{reader: r, closer: r.Close}, beep.Format{SampleRate: DefaultSampleRate, NumChannels: 2, Precision: 2}, nil
+	}
+	t.Cleanup(func() { decoders["MP3"] = old })
+
+	requested, release := make(chan struct{}), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/b.pls" {
+			close(requested)
+			<-release
+			fmt.Fprintf(w, "[playlist]\nNumberOfEntries=1\nFile1=http://%s/b\n", r.Host)
+			return
+		}
+		_, _ = w.Write(bytes.Repeat([]byte{0x00, 0x40}, 64))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	p := NewPlayer()
+	p.isPlaying = true
+	done := make(chan error, 1)
+	go func() { done <- p.StartComparison(server.URL + "/b.pls") }()
+
+	<-requested
+	p.mu.Lock()
+	p.isPlaying = false
+	p.mu.Unlock()
+	close(release)
+
+	if err := <-done; !errors.Is(err, ErrNotPlaying) {
+		t.Errorf("StartComparison() after a stop = %v, want ErrNotPlaying", err)
+	}
+	if _, ok := p.Comparison(); ok {
+		t.Error("Comparison() running after playback stopped")
+	}
+}
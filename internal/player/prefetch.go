@@ -0,0 +1,186 @@
+package player
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/rs/zerolog/log"
+)
+
+// PrefetchTTL is how long a prefetched connection is used. An unread
+// stream backs up in the socket, so older connections would start with
+// stale audio and are replaced instead.
+const PrefetchTTL = 30 * time.Second
+
+// prefetchedStation holds a resolved playlist and an open, unread
+// connection to its first stream.
+type prefetchedStation struct {
+	playlistURL string
+	streamURLs  []string
+	resp        *http.Response
+	at          time.Time
+}
+
+func (e *prefetchedStation) fresh(now time.Time) bool {
+	return now.Sub(e.at) < PrefetchTTL
+}
+
+func (e *prefetchedStation) close() {
+	if e.resp != nil {
+		e.resp.Body.Close()
+		e.resp = nil
+	}
+}
+
+type prefetchCache struct {
+	mu       sync.Mutex
+	stations map[station.StationID]*prefetchedStation
+}
+
+// Prefetch resolves the playlist of each station and opens, but doesn't
+// read, a connection to its first stream so switching to it starts
+// nearly instantly. Connections for stations not listed are closed.
+func (p *Player) Prefetch(ctx context.Context, stations ...*station.Station) {
+	wanted := make(map[station.StationID]bool)
+	for _, s := range stations {
+		if s != nil {
+			wanted[s.ID] = true
+		}
+	}
+
+	c := &p.prefetch
+	c.mu.Lock()
+	for id, e := range c.stations {
+		if !wanted[id] || !e.fresh(p.timeSource().Now()) {
+			e.close()
+			delete(c.stations, id)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, s := range stations {
+		if s == nil {
+			continue
+		}
+		c.mu.Lock()
+		_, ok := c.stations[s.ID]
+		c.mu.Unlock()
+		if ok {
+			continue
+		}
+
+		entry, err := p.prefetchStation(ctx, s)
+		if err != nil {
+			log.Debug().Err(err).Msgf("Prefetch failed for %s", s.ID)
+			continue
+		}
+
+		c.mu.Lock()
+		if c.stations == nil {
+			c.stations = make(map[station.StationID]*prefetchedStation)
+		}
+		if old := c.stations[s.ID]; old != nil {
+			old.close()
+		}
+		c.stations[s.ID] = entry
+		c.mu.Unlock()
+		log.Debug().Msgf("Prefetched %s: %s", s.ID, entry.streamURLs[0])
+	}
+}
+
+func (p *Player) prefetchStation(ctx context.Context, s *station.Station) (*prefetchedStation, error) {
+	playlistURLs := p.playlistURLs(s)
+	if len(playlistURLs) == 0 {
+		return nil, fmt.Errorf("no playlists for %s", s.ID)
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, playlistFetchTimeout)
+	streamURLs, err := p.fetchAndParsePLS(fetchCtx, playlistURLs[0])
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	if len(streamURLs) == 0 {
+		return nil, fmt.Errorf("empty playlist for %s", s.ID)
+	}
+
+	// Not bound to ctx: the connection must outlive this call
+	req, err := p.newStreamRequest(context.Background(), streamURLs[0])
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, &httpStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	return &prefetchedStation{
+		playlistURL: playlistURLs[0],
+		streamURLs:  streamURLs,
+		resp:        resp,
+		at:          p.timeSource().Now(),
+	}, nil
+}
+
+// prefetchedPlaylist returns the stream URLs resolved for playlistURL, if
+// they are still fresh.
+func (p *Player) prefetchedPlaylist(playlistURL string) ([]string, bool) {
+	c := &p.prefetch
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range c.stations {
+		if e.playlistURL == playlistURL && e.fresh(p.timeSource().Now()) {
+			return e.streamURLs, true
+		}
+	}
+	return nil, false
+}
+
+// takePrefetched hands over a fresh prefetched connection to streamURL.
+// Each connection is used at most once.
+func (p *Player) takePrefetched(streamURL string) *http.Response {
+	c := &p.prefetch
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range c.stations {
+		if e.resp == nil || e.streamURLs[0] != streamURL {
+			continue
+		}
+		if !e.fresh(p.timeSource().Now()) {
+			e.close()
+			continue
+		}
+		resp := e.resp
+		e.resp = nil
+		return resp
+	}
+	return nil
+}
+
+// ClearPrefetch closes all prefetched connections.
+func (p *Player) ClearPrefetch() {
+	c := &p.prefetch
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, e := range c.stations {
+		e.close()
+		delete(c.stations, id)
+	}
+}
+
+// cachedPlaylistFetcher prefers playlists resolved by Prefetch.
+func (p *Player) cachedPlaylistFetcher(ctx context.Context, playlistURL string) ([]string, error) {
+	if urls, ok := p.prefetchedPlaylist(playlistURL); ok {
+		log.Debug().Msgf("Using prefetched playlist: %s", playlistURL)
+		return urls, nil
+	}
+	return p.fetchAndParsePLS(ctx, playlistURL)
+}
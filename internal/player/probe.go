@@ -0,0 +1,59 @@
+package player
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// probeTimeout bounds one ProbeStream call, playlist and stream together.
+const probeTimeout = 10 * time.Second
+
+// StreamProbe is what ProbeStream found out about one playlist.
+type StreamProbe struct {
+	// Info comes from the stream's response headers, or is guessed from
+	// the playlist URL when the stream couldn't be reached.
+	Info StreamInfo
+	// Latency is how long the stream took to answer.
+	Latency time.Duration
+	Err     error
+}
+
+// ProbeStream resolves playlistURL and opens its first stream only long
+// enough to read the response headers, which tell the real codec and
+// bitrate. Nothing is played.
+func (p *Player) ProbeStream(ctx context.Context, playlistURL string) StreamProbe {
+	result := StreamProbe{Info: parseStreamInfoFromURL(playlistURL)}
+	result.Info.PlaylistURL = playlistURL
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	streamURLs, err := p.fetchAndParsePLS(ctx, playlistURL)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	req, err := p.newStreamRequest(ctx, streamURLs[0])
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	start := p.timeSource().Now()
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to connect: %w", err)
+		return result
+	}
+	resp.Body.Close()
+	result.Latency = p.timeSource().Since(start)
+
+	if resp.StatusCode != http.StatusOK {
+		result.Err = &httpStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+		return result
+	}
+	result.Info = streamInfoFromHeaders(resp.Header, result.Info)
+	return result
+}
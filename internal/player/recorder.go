@@ -0,0 +1,362 @@
+package player
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultRecordingPadding is the audio kept on both sides of a track
+// boundary when splitting. ICY titles change a few seconds away from the
+// actual transition, so each file overlaps its neighbours by this much.
+const DefaultRecordingPadding = 3 * time.Second
+
+// RecordingOptions configure StartRecording.
+type RecordingOptions struct {
+	Dir string
+	// SplitTracks starts a new "Artist - Title" file whenever the ICY
+	// StreamTitle changes.
+	SplitTracks bool
+	// KeepPartial keeps the first and last tracks when splitting, which are
+	// usually cut off because recording started or stopped mid-track.
+	KeepPartial bool
+	Padding     time.Duration
+}
+
+// ErrNotPlaying is returned when recording or a comparison is started
+// without a stream.
+var ErrNotPlaying = errors.New("nothing is playing")
+
+// recorder dumps the compressed stream to disk as it is received.
+type recorder struct {
+	mu     sync.Mutex
+	active bool
+	opts   RecordingOptions
+	ext    string
+	pad    int // padding in bytes
+
+	file  *os.File
+	path  string
+	title string
+
+	// tail holds the last pad bytes, prepended to the next track's file
+	tail []byte
+	// prev keeps receiving audio for pad bytes after a track boundary
+	prev          *os.File
+	prevPath      string
+	prevRemaining int
+
+	saved []string
+
+	// droppedAt is when the stream went away mid-recording, zero while it
+	// flows
+	droppedAt time.Time
+	gaps      int
+}
+
+var unsafeFileChars = strings.NewReplacer(
+	"/", "_", `\`, "_", ":", "_", "*", "_", "?", "_",
+	`"`, "'", "<", "_", ">", "_", "|", "_",
+)
+
+// maxFileNameBytes keeps recording file names, extension and " (2)"
+// included, under the common 255-byte limit.
+const maxFileNameBytes = 200
+
+// recordingFileName turns a track or station title into a file name.
+// Control characters become "_", and a long name is cut between
+// characters, not inside one.
+func recordingFileName(name, ext string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, unsafeFileChars.Replace(name))
+	if len(name) > maxFileNameBytes {
+		cut := maxFileNameBytes
+		for cut > 0 && !utf8.RuneStart(name[cut]) {
+			cut--
+		}
+		name = name[:cut]
+	}
+	name = strings.Trim(strings.TrimSpace(name), ".")
+	if name == "" {
+		name = "Unknown"
+	}
+	return name + ext
+}
+
+// createUnique creates dir/name, adding " (2)", " (3)", ... if it exists.
+func createUnique(dir, name string) (*os.File, string, error) {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		path := filepath.Join(dir, name)
+		if i > 1 {
+			path = filepath.Join(dir, fmt.Sprintf("%s (%d)%s", base, i, ext))
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		return f, path, err
+	}
+}
+
+func (r *recorder) start(opts RecordingOptions, stationTitle, track string, format string, bitrateKbps int) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.active {
+		return "", errors.New("already recording")
+	}
+	if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create recording directory: %w", err)
+	}
+
+	if opts.Padding <= 0 {
+		opts.Padding = DefaultRecordingPadding
+	}
+	if bitrateKbps <= 0 {
+		bitrateKbps = 128
+	}
+	r.opts = opts
+	r.pad = int(opts.Padding.Seconds() * float64(bitrateKbps) * 1000 / 8)
+	r.ext = ".mp3"
+	if format == "AAC" {
+		r.ext = ".aac"
+	}
+	r.tail = r.tail[:0]
+	r.saved = nil
+	r.title = track
+	r.droppedAt = time.Time{}
+	r.gaps = 0
+
+	switch {
+	case !opts.SplitTracks:
+		name := fmt.Sprintf("%s %s", stationTitle, time.Now().Format("2006-01-02 1504"))
+		if err := r.open(recordingFileName(name, r.ext)); err != nil {
+			return "", err
+		}
+	case opts.KeepPartial:
+		if err := r.open(recordingFileName(track, r.ext)); err != nil {
+			return "", err
+		}
+	}
+
+	r.active = true
+	if r.file != nil && !opts.SplitTracks {
+		return r.path, nil
+	}
+	return opts.Dir, nil
+}
+
+func (r *recorder) open(name string) error {
+	f, path, err := createUnique(r.opts.Dir, name)
+	if err != nil {
+		return fmt.Errorf("failed to create recording file: %w", err)
+	}
+	r.file, r.path = f, path
+	log.Debug().Msgf("Recording to %s", path)
+	return nil
+}
+
+func (r *recorder) isActive() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.active
+}
+
+// write appends received audio to the open files.
+func (r *recorder) write(b []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.active {
+		return
+	}
+
+	if r.prev != nil {
+		n := min(len(b), r.prevRemaining)
+		if _, err := r.prev.Write(b[:n]); err != nil {
+			log.Warn().Err(err).Msg("Failed to write recording")
+		}
+		r.prevRemaining -= n
+		if r.prevRemaining == 0 {
+			r.finishPrev()
+		}
+	}
+	if r.file != nil {
+		if _, err := r.file.Write(b); err != nil {
+			log.Warn().Err(err).Msg("Failed to write recording")
+		}
+	}
+
+	if r.opts.SplitTracks {
+		r.tail = append(r.tail, b...)
+		if extra := len(r.tail) - r.pad; extra > 0 {
+			r.tail = append(r.tail[:0], r.tail[extra:]...)
+		}
+	}
+}
+
+func (r *recorder) finishPrev() {
+	if err := r.prev.Close(); err != nil {
+		log.Warn().Err(err).Msg("Failed to close recording")
+	}
+	r.saved = append(r.saved, r.prevPath)
+	r.prev, r.prevPath, r.prevRemaining = nil, "", 0
+}
+
+// trackChanged starts a new file for title when splitting tracks.
+func (r *recorder) trackChanged(title string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.active || !r.opts.SplitTracks || title == r.title {
+		return
+	}
+	r.title = title
+
+	if r.prev != nil {
+		r.finishPrev()
+	}
+	if r.file != nil {
+		r.prev, r.prevPath, r.prevRemaining = r.file, r.path, r.pad
+		r.file, r.path = nil, ""
+	}
+
+	if err := r.open(recordingFileName(title, r.ext)); err != nil {
+		log.Warn().Err(err).Msg("Failed to start next recording")
+		return
+	}
+	if _, err := r.file.Write(r.tail); err != nil {
+		log.Warn().Err(err).Msg("Failed to write recording")
+	}
+}
+
+// interrupted notes that the stream stopped at at, to be marked in the
+// recording when it comes back.
+func (r *recorder) interrupted(at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.active && r.droppedAt.IsZero() {
+		r.droppedAt = at
+	}
+}
+
+// resumed carries on after a reconnect in the same file, behind a tag
+// that marks the gap, so a long recording stays in one piece.
+func (r *recorder) resumed(at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.active || r.droppedAt.IsZero() {
+		return
+	}
+	gap := at.Sub(r.droppedAt)
+	r.droppedAt = time.Time{}
+	r.gaps++
+	log.Info().Msgf("Recording resumed after a %v gap", gap.Round(time.Second))
+	if r.file == nil {
+		return
+	}
+	if _, err := r.file.Write(gapMarker(at, gap)); err != nil {
+		log.Warn().Err(err).Msg("Failed to write recording")
+	}
+}
+
+// gapMarker returns an ID3v2.4 tag with a TXXX frame saying when and for
+// how long the stream was gone. MP3 and ADTS decoders skip it like any
+// other bytes that aren't a frame; tag readers and strings(1) find it.
+func gapMarker(at time.Time, gap time.Duration) []byte {
+	text := fmt.Sprintf("Stream reconnected at %s after %v", at.Format(time.RFC3339), gap.Round(time.Second))
+	body := append([]byte{3}, "RECONNECT\x00"+text...) // 3 is UTF-8
+
+	frame := append([]byte("TXXX"), syncsafe(len(body))...)
+	frame = append(frame, 0, 0)
+	frame = append(frame, body...)
+
+	tag := append([]byte("ID3\x04\x00\x00"), syncsafe(len(frame))...)
+	return append(tag, frame...)
+}
+
+// syncsafe encodes n in ID3's 4-byte form, 7 bits per byte.
+func syncsafe(n int) []byte {
+	return []byte{byte(n>>21) & 0x7f, byte(n>>14) & 0x7f, byte(n>>7) & 0x7f, byte(n) & 0x7f}
+}
+
+// stop closes all files and returns the ones kept. A partial last track is
+// removed when splitting without KeepPartial.
+func (r *recorder) stop() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.active {
+		return nil, nil
+	}
+	r.active = false
+	if r.gaps > 0 {
+		log.Info().Msgf("Recording finished with %d reconnect gaps", r.gaps)
+	}
+
+	if r.prev != nil {
+		r.finishPrev()
+	}
+
+	var err error
+	if r.file != nil {
+		err = r.file.Close()
+		if r.opts.SplitTracks && !r.opts.KeepPartial {
+			_ = os.Remove(r.path)
+		} else {
+			r.saved = append(r.saved, r.path)
+		}
+		r.file, r.path = nil, ""
+	}
+	return r.saved, err
+}
+
+// StartRecording saves the stream as it plays, either to one file or split
+// per track. It returns the file or directory being written to.
+func (p *Player) StartRecording(opts RecordingOptions) (string, error) {
+	s := p.GetCurrentStation()
+	if s == nil || !p.IsPlaying() {
+		return "", ErrNotPlaying
+	}
+	track := p.GetCurrentTrack()
+	if track == NoTrackInfo {
+		track = ""
+	}
+	info := p.GetStreamInfo()
+	return p.recorder.start(opts, s.Title, track, info.Format, info.Bitrate)
+}
+
+// StopRecording finishes the recording and returns the saved files.
+func (p *Player) StopRecording() ([]string, error) {
+	return p.recorder.stop()
+}
+
+func (p *Player) IsRecording() bool {
+	return p.recorder.isActive()
+}
+
+// recordingWriter passes audio to the decoder and, while recording, to disk.
+type recordingWriter struct {
+	out      io.Writer
+	recorder *recorder
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	n, err := w.out.Write(b)
+	if n > 0 {
+		w.recorder.write(b[:n])
+	}
+	return n, err
+}
@@ -0,0 +1,111 @@
+package player
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RecordingQuota limits what recordings may take up in their directory.
+// Zero fields mean no limit.
+type RecordingQuota struct {
+	MaxBytes int64
+	MaxAge   time.Duration
+}
+
+// RecordingUsage is what a recording directory holds after pruning.
+type RecordingUsage struct {
+	Total int64 // Bytes in all recordings
+	// Pinned is the part of Total in files still being written, which
+	// pruning can't free.
+	Pinned  int64
+	Removed []string
+}
+
+// isRecording reports whether name looks like a file StartRecording wrote.
+// Only these are counted and pruned, so other files in the directory are
+// left alone.
+func isRecording(name string) bool {
+	switch filepath.Ext(name) {
+	case ".mp3", ".aac":
+		return true
+	}
+	return false
+}
+
+// PruneRecordings deletes recordings in dir older than q.MaxAge, then the
+// oldest ones until the rest fit in q.MaxBytes. Files in active are never
+// deleted.
+func PruneRecordings(dir string, q RecordingQuota, now time.Time, active []string) (RecordingUsage, error) {
+	var usage RecordingUsage
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return usage, nil
+	}
+	if err != nil {
+		return usage, fmt.Errorf("failed to read recording directory: %w", err)
+	}
+
+	type recording struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var old []recording
+	for _, e := range entries {
+		if !e.Type().IsRegular() || !isRecording(e.Name()) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		usage.Total += info.Size()
+		if slices.Contains(active, path) {
+			usage.Pinned += info.Size()
+			continue
+		}
+		old = append(old, recording{path, info.Size(), info.ModTime()})
+	}
+	sort.Slice(old, func(i, j int) bool { return old[i].modTime.Before(old[j].modTime) })
+
+	for _, r := range old {
+		expired := q.MaxAge > 0 && now.Sub(r.modTime) > q.MaxAge
+		over := q.MaxBytes > 0 && usage.Total > q.MaxBytes
+		if !expired && !over {
+			continue
+		}
+		if err := os.Remove(r.path); err != nil {
+			log.Warn().Err(err).Msgf("Failed to delete old recording %s", r.path)
+			continue
+		}
+		usage.Total -= r.size
+		usage.Removed = append(usage.Removed, r.path)
+	}
+	return usage, nil
+}
+
+// activeFiles returns the files the recorder is still writing to.
+func (r *recorder) activeFiles() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var files []string
+	for _, path := range []string{r.path, r.prevPath} {
+		if path != "" {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// RecordingFiles returns the files the current recording is writing to.
+func (p *Player) RecordingFiles() []string {
+	return p.recorder.activeFiles()
+}
@@ -0,0 +1,199 @@
+package player
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/rs/zerolog/log"
+)
+
+// StartupHistorySize is how many station starts the player keeps timings
+// for.
+const StartupHistorySize = 100
+
+// StartupTiming is how long one station start took, from asking the player
+// to play until the first audible sample reached the speaker. Retries
+// count towards the phase they interrupted.
+type StartupTiming struct {
+	Station  station.StationID
+	Title    string
+	Playlist time.Duration // Resolving the playlist into stream URLs
+	Connect  time.Duration // Until the stream server answered
+	Decode   time.Duration // Until the decoder recognized the format
+	Buffer   time.Duration // Until decoded audio was audible
+	Total    time.Duration
+}
+
+func (t StartupTiming) String() string {
+	return fmt.Sprintf("%s: %v (playlist %v, connect %v, decode %v, buffer %v)", t.Title,
+		roundMs(t.Total), roundMs(t.Playlist), roundMs(t.Connect), roundMs(t.Decode), roundMs(t.Buffer))
+}
+
+func roundMs(d time.Duration) time.Duration {
+	return d.Round(time.Millisecond)
+}
+
+// startupTimer times the start in progress and keeps the timings of recent
+// starts. waiting lets the audio callback check for a start in progress
+// without taking the lock.
+type startupTimer struct {
+	mu      sync.Mutex
+	waiting atomic.Bool
+
+	gen                       uint64 // Counts starts, so a stale abandon is ignored
+	station                   *station.Station
+	start, fetched, connected time.Time
+	decoded                   time.Time
+
+	history [StartupHistorySize]StartupTiming
+	next    int
+	count   int
+}
+
+// begin starts timing a start of s, dropping one still in progress, and
+// returns its generation for abandon.
+func (t *startupTimer) begin(s *station.Station, now time.Time) uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.gen++
+	t.station = s
+	t.start, t.fetched, t.connected, t.decoded = now, time.Time{}, time.Time{}, time.Time{}
+	t.waiting.Store(true)
+	return t.gen
+}
+
+// abandon drops start gen if it is still in progress, e.g. when playback
+// stopped before anything was heard.
+func (t *startupTimer) abandon(gen uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.gen != gen {
+		return
+	}
+	t.station = nil
+	t.waiting.Store(false)
+}
+
+// mark records that a phase ended at now. A later mark of the same phase,
+// after a retry, replaces the earlier one.
+func (t *startupTimer) mark(phase *time.Time, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.station != nil {
+		*phase = now
+	}
+}
+
+func (t *startupTimer) markFetched(now time.Time)   { t.mark(&t.fetched, now) }
+func (t *startupTimer) markConnected(now time.Time) { t.mark(&t.connected, now) }
+func (t *startupTimer) markDecoded(now time.Time)   { t.mark(&t.decoded, now) }
+
+// finish completes the start in progress when audio became audible at
+// now. It reports false when no start was being timed.
+func (t *startupTimer) finish(now time.Time) (StartupTiming, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.waiting.Store(false)
+	if t.station == nil {
+		return StartupTiming{}, false
+	}
+
+	// A phase skipped, e.g. the playlist of a prefetched connection,
+	// took no time
+	fetched := latest(t.start, t.fetched)
+	connected := latest(fetched, t.connected)
+	decoded := latest(connected, t.decoded)
+	timing := StartupTiming{
+		Station:  t.station.ID,
+		Title:    t.station.Title,
+		Playlist: fetched.Sub(t.start),
+		Connect:  connected.Sub(fetched),
+		Decode:   decoded.Sub(connected),
+		Buffer:   now.Sub(decoded),
+		Total:    now.Sub(t.start),
+	}
+	t.station = nil
+
+	t.history[t.next] = timing
+	t.next = (t.next + 1) % StartupHistorySize
+	if t.count < StartupHistorySize {
+		t.count++
+	}
+	return timing, true
+}
+
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// snapshot returns the kept timings, oldest first.
+func (t *startupTimer) snapshot() []StartupTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timings := make([]StartupTiming, 0, t.count)
+	start := (t.next - t.count + StartupHistorySize) % StartupHistorySize
+	for i := 0; i < t.count; i++ {
+		timings = append(timings, t.history[(start+i)%StartupHistorySize])
+	}
+	return timings
+}
+
+// finishStartup records the start in progress as complete once samples
+// carry audible sound. It is called from the audio callback.
+func (p *Player) finishStartup(samples [][2]float64) {
+	if !p.startup.waiting.Load() || !audible(samples) {
+		return
+	}
+	timing, ok := p.startup.finish(p.timeSource().Now())
+	if !ok {
+		return
+	}
+	p.journal.add(JournalStartup, "%s", timing)
+	log.Info().
+		Str("station", timing.Station.String()).
+		Dur("total", timing.Total).
+		Dur("playlist", timing.Playlist).
+		Dur("connect", timing.Connect).
+		Dur("decode", timing.Decode).
+		Dur("buffer", timing.Buffer).
+		Msg("Station start")
+}
+
+func audible(samples [][2]float64) bool {
+	for _, s := range samples {
+		if math.Abs(s[0]) >= SilenceThreshold || math.Abs(s[1]) >= SilenceThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+// StartupTimings returns how long recent station starts took, oldest
+// first.
+func (p *Player) StartupTimings() []StartupTiming {
+	return p.startup.snapshot()
+}
+
+// StartupPercentile returns the q-th quantile (0 to 1) of the total
+// startup times, by the nearest-rank method, or 0 without timings.
+func StartupPercentile(timings []StartupTiming, q float64) time.Duration {
+	if len(timings) == 0 {
+		return 0
+	}
+	totals := make([]time.Duration, len(timings))
+	for i, t := range timings {
+		totals[i] = t.Total
+	}
+	slices.Sort(totals)
+	rank := int(math.Ceil(q * float64(len(totals))))
+	return totals[max(0, min(rank-1, len(totals)-1))]
+}
@@ -0,0 +1,56 @@
+package player
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gopxl/beep/v2"
+	"github.com/gopxl/beep/v2/effects"
+	"github.com/gopxl/beep/v2/generators"
+	"github.com/gopxl/beep/v2/speaker"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	TestToneFrequency = 440.0
+	TestToneDuration  = 2 * time.Second
+	testToneGain      = -0.7 // Play at 30% amplitude
+)
+
+func testToneStreamer(sampleRate beep.SampleRate, frequency float64, duration time.Duration) (beep.Streamer, error) {
+	tone, err := generators.SineTone(sampleRate, frequency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate tone: %w", err)
+	}
+	return &effects.Gain{Streamer: beep.Take(sampleRate.N(duration), tone), Gain: testToneGain}, nil
+}
+
+// PlayTestTone plays a sine tone through the audio output and blocks until
+// the device has consumed it. It does not touch the network, so a failure
+// here points at the local audio stack.
+func (p *Player) PlayTestTone(frequency float64, duration time.Duration) error {
+	if err := p.initSpeaker(DefaultSampleRate); err != nil {
+		return err
+	}
+
+	tone, err := testToneStreamer(DefaultSampleRate, frequency, duration)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	log.Debug().Msgf("Playing %.0f Hz test tone for %v", frequency, duration)
+	speaker.Play(beep.Seq(tone, beep.Callback(func() {
+		close(done)
+	})))
+
+	select {
+	case <-done:
+		// Let the last buffer drain before the caller exits
+		time.Sleep(SpeakerBufferSize)
+		return nil
+	case <-time.After(duration + MaxPlaybackDelay):
+		speaker.Clear()
+		return fmt.Errorf("audio output did not play the tone within %v", duration+MaxPlaybackDelay)
+	}
+}
@@ -0,0 +1,218 @@
+package player
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gopxl/beep/v2"
+)
+
+const (
+	// TimeShiftWindow is how much recent audio is kept for rewinding.
+	TimeShiftWindow = 30 * time.Second
+	// TimeShiftStep is how far one rewind or fast-forward jumps.
+	TimeShiftStep = 5 * time.Second
+	// ScrubStep is how far one step moves while scrubbing.
+	ScrubStep = 2 * time.Second
+	// ScrubPreview is how much audio each scrub step plays before going
+	// quiet until the next step, like a radio scanning for stations.
+	ScrubPreview = 600 * time.Millisecond
+	// scrubFade ends each preview without a click.
+	scrubFade = 20 * time.Millisecond
+)
+
+// timeShift keeps the most recent stream audio in a ring buffer. Live audio
+// is always written to it; when offset is non-zero, output is read back
+// from offset samples earlier, so playback stays that far behind live for
+// as long as the stream keeps flowing.
+type timeShift struct {
+	mu     sync.Mutex
+	rate   beep.SampleRate
+	buf    [][2]float32
+	next   int // Write position
+	filled int
+	offset int // Samples behind live
+	jumped bool
+
+	scrubbing bool
+	scrubFrom int // Offset to return to when scrubbing is cancelled
+	preview   int // Samples of the current preview still to play
+}
+
+// reset empties the buffer for a new stream.
+func (t *timeShift) reset(rate beep.SampleRate) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	size := rate.N(TimeShiftWindow)
+	if len(t.buf) != size {
+		t.buf = make([][2]float32, size)
+	}
+	t.rate = rate
+	t.next, t.filled, t.offset = 0, 0, 0
+	t.jumped = false
+	t.scrubbing, t.preview = false, 0
+}
+
+// process records live samples and, when behind live, replaces them with
+// the delayed ones. It reports whether the position just jumped, so the
+// caller can fade in instead of clicking.
+func (t *timeShift) process(samples [][2]float64) (jumped bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	size := len(t.buf)
+	if size == 0 {
+		return false
+	}
+	for i, s := range samples {
+		t.buf[t.next] = [2]float32{float32(s[0]), float32(s[1])}
+		t.next = (t.next + 1) % size
+		if t.filled < size {
+			t.filled++
+		}
+		if t.offset > 0 {
+			delayed := t.buf[(t.next-1-t.offset+size)%size]
+			samples[i] = [2]float64{float64(delayed[0]), float64(delayed[1])}
+		}
+		if t.scrubbing {
+			gain := 0.0
+			if t.preview > 0 {
+				gain = min(1, float64(t.preview)/float64(t.rate.N(scrubFade)))
+				t.preview--
+			}
+			samples[i][0] *= gain
+			samples[i][1] *= gain
+		}
+	}
+
+	jumped, t.jumped = t.jumped, false
+	return jumped
+}
+
+// shift moves the playback position by d (negative rewinds), clamped to
+// the buffered audio, and returns how far behind live it ends up.
+func (t *timeShift) shift(d time.Duration) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.rate == 0 {
+		return 0
+	}
+	offset := t.offset - t.rate.N(d)
+	offset = max(0, min(offset, t.filled-1))
+	if offset != t.offset {
+		t.offset = offset
+		t.jumped = true
+	}
+	return t.rate.D(t.offset)
+}
+
+// startScrub enters scrub mode at the current position and plays a
+// preview of it.
+func (t *timeShift) startScrub() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.scrubbing {
+		t.scrubbing = true
+		t.scrubFrom = t.offset
+	}
+	t.preview = t.rate.N(ScrubPreview)
+	t.jumped = true
+}
+
+// scrub moves by d like shift and plays a preview from there.
+func (t *timeShift) scrub(d time.Duration) time.Duration {
+	behind := t.shift(d)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.scrubbing {
+		t.preview = t.rate.N(ScrubPreview)
+		t.jumped = true
+	}
+	return behind
+}
+
+// endScrub leaves scrub mode, staying at the scrubbed position when settle
+// is true and going back to where scrubbing started otherwise.
+func (t *timeShift) endScrub(settle bool) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.scrubbing {
+		return t.rate.D(t.offset)
+	}
+	t.scrubbing, t.preview = false, 0
+	if !settle {
+		t.offset = min(t.scrubFrom, max(0, t.filled-1))
+	}
+	t.jumped = true
+	return t.rate.D(t.offset)
+}
+
+func (t *timeShift) buffered() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.rate == 0 {
+		return 0
+	}
+	return t.rate.D(t.filled)
+}
+
+func (t *timeShift) behind() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.rate == 0 {
+		return 0
+	}
+	return t.rate.D(t.offset)
+}
+
+// Rewind jumps back d within the buffered audio and returns how far behind
+// live playback now is.
+func (p *Player) Rewind(d time.Duration) time.Duration {
+	return p.timeShift.shift(-d)
+}
+
+// FastForward jumps forward d, up to live, and returns how far behind live
+// playback still is.
+func (p *Player) FastForward(d time.Duration) time.Duration {
+	return p.timeShift.shift(d)
+}
+
+// GoLive returns playback to the live edge of the stream.
+func (p *Player) GoLive() {
+	p.timeShift.shift(TimeShiftWindow)
+}
+
+// BehindLive returns how far playback is behind the live stream.
+func (p *Player) BehindLive() time.Duration {
+	return p.timeShift.behind()
+}
+
+// Buffered returns how much audio is kept for rewinding, up to
+// TimeShiftWindow.
+func (p *Player) Buffered() time.Duration {
+	return p.timeShift.buffered()
+}
+
+// StartScrub enters scrub mode: output goes quiet except for a short
+// preview at each step, until EndScrub.
+func (p *Player) StartScrub() {
+	p.timeShift.startScrub()
+}
+
+// Scrub steps d through the buffered audio (negative goes back), plays a
+// preview from there, and returns how far behind live that is.
+func (p *Player) Scrub(d time.Duration) time.Duration {
+	return p.timeShift.scrub(d)
+}
+
+// EndScrub leaves scrub mode. With settle, playback continues from the
+// scrubbed position; otherwise it returns to where scrubbing started. It
+// returns how far behind live playback ends up.
+func (p *Player) EndScrub(settle bool) time.Duration {
+	return p.timeShift.endScrub(settle)
+}
@@ -0,0 +1,122 @@
+// Package playlog logs every track change to a file in the cache
+// directory: the raw material for stats, exports, and finding the track
+// that played an hour ago.
+package playlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/cache"
+	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/glebovdev/somafm-cli/internal/storage"
+)
+
+const (
+	// FileName is the log inside the cache directory.
+	FileName = cache.PlayLogName + ".jsonl"
+	// MaxSize is how large the log grows before it is rotated.
+	MaxSize = 1 << 20
+	// Keep is how many rotated logs are kept, plays.1.jsonl the newest.
+	Keep = 3
+)
+
+// Entry is one track change.
+type Entry struct {
+	Time         time.Time `json:"time"`
+	Station      string    `json:"station"`
+	StationTitle string    `json:"station_title"`
+	Artist       string    `json:"artist,omitempty"`
+	Title        string    `json:"title"`
+}
+
+// NewEntry splits track, as the stream titles it, into artist and title.
+// Tracks without " - " are all title.
+func NewEntry(at time.Time, s *station.Station, track string) Entry {
+	e := Entry{Time: at, Station: s.ID.String(), StationTitle: s.Title, Title: track}
+	if artist, title, ok := strings.Cut(track, " - "); ok {
+		e.Artist, e.Title = artist, title
+	}
+	return e
+}
+
+// DefaultPath returns the log path in the cache directory.
+func DefaultPath() (string, error) {
+	dir, err := cache.GetCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileName), nil
+}
+
+// Log appends entries to a file, rotating it once it reaches MaxSize.
+type Log struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+}
+
+// New returns a log writing to path.
+func New(path string) *Log {
+	return &Log{path: path, maxSize: MaxSize}
+}
+
+// Append adds e at the end of the log, first rotating it when it has
+// reached MaxSize.
+func (l *Log) Append(e Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if info, err := os.Stat(l.path); err == nil && info.Size() >= l.maxSize {
+		if err := l.rotate(); err != nil {
+			return fmt.Errorf("failed to rotate the play log: %w", err)
+		}
+	}
+	if err := storage.AppendJSONL(l.path, e); err != nil {
+		return fmt.Errorf("failed to write the play log: %w", err)
+	}
+	return nil
+}
+
+// rotate shifts plays.jsonl to plays.1.jsonl, plays.1.jsonl to
+// plays.2.jsonl, and so on, dropping the oldest beyond Keep.
+func (l *Log) rotate() error {
+	for n := Keep - 1; n >= 1; n-- {
+		if err := os.Rename(rotatedPath(l.path, n), rotatedPath(l.path, n+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(l.path, rotatedPath(l.path, 1))
+}
+
+// Entries returns the entries of the log and its rotated files, oldest
+// first.
+func (l *Log) Entries() ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var entries []Entry
+	for n := Keep; n >= 0; n-- {
+		path := l.path
+		if n > 0 {
+			path = rotatedPath(l.path, n)
+		}
+		part, err := storage.ReadJSONL[Entry](path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+		}
+		entries = append(entries, part...)
+	}
+	return entries, nil
+}
+
+// rotatedPath returns the nth rotated file of path: plays.jsonl becomes
+// plays.1.jsonl.
+func rotatedPath(path string, n int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(path, ext), n, ext)
+}
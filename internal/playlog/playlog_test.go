@@ -0,0 +1,73 @@
+package playlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/station"
+)
+
+func TestNewEntry(t *testing.T) {
+	s := &station.Station{ID: "groovesalad", Title: "Groove Salad"}
+	at := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	e := NewEntry(at, s, "Bonobo - Kiara - Live")
+	if e.Artist != "Bonobo" || e.Title != "Kiara - Live" || e.Station != "groovesalad" || e.StationTitle != "Groove Salad" {
+		t.Errorf("NewEntry() = %+v", e)
+	}
+	if e := NewEntry(at, s, "Station ID"); e.Artist != "" || e.Title != "Station ID" {
+		t.Errorf("NewEntry() without an artist = %+v", e)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	l := New(path)
+	l.maxSize = 300
+	s := &station.Station{ID: "groovesalad", Title: "Groove Salad"}
+	start := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	const tracks = 40
+	for i := range tracks {
+		if err := l.Append(NewEntry(start.Add(time.Duration(i)*time.Minute), s, "Artist - Title")); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	for n := 1; n <= Keep; n++ {
+		if _, err := os.Stat(rotatedPath(path, n)); err != nil {
+			t.Errorf("rotated log %d: %v", n, err)
+		}
+	}
+	if _, err := os.Stat(rotatedPath(path, Keep+1)); !os.IsNotExist(err) {
+		t.Errorf("kept more than %d rotated logs", Keep)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() > 2*l.maxSize {
+		t.Errorf("log size = %v, %v, want about %d at most", info.Size(), err, l.maxSize)
+	}
+
+	entries, err := l.Entries()
+	if err != nil {
+		t.Fatalf("Entries() error = %v", err)
+	}
+	if len(entries) == 0 || len(entries) >= tracks {
+		t.Fatalf("Entries() = %d entries, want the newest of %d", len(entries), tracks)
+	}
+	for i := 1; i < len(entries); i++ {
+		if !entries[i].Time.After(entries[i-1].Time) {
+			t.Fatalf("entries out of order at %d: %v after %v", i, entries[i].Time, entries[i-1].Time)
+		}
+	}
+	if last := entries[len(entries)-1]; !last.Time.Equal(start.Add((tracks - 1) * time.Minute)) {
+		t.Errorf("last entry at %v, want the last track", last.Time)
+	}
+}
+
+func TestRotatedPath(t *testing.T) {
+	if got := rotatedPath(filepath.Join("cache", "plays.jsonl"), 2); !strings.HasSuffix(got, "plays.2.jsonl") {
+		t.Errorf("rotatedPath() = %q", got)
+	}
+}
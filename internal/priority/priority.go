@@ -0,0 +1,35 @@
+// Package priority raises the scheduling priority of the player so audio
+// keeps flowing while the machine is busy, e.g. on a small single-board
+// computer that crackles under load. Go runs the audio output on whichever
+// OS threads it likes, so the priority applies to every thread of the
+// process; threads started later inherit it from the one that starts them.
+package priority
+
+import "errors"
+
+// Nice values accepted by Apply. Lower is more favorable.
+const (
+	MinNice = -20
+	MaxNice = 19
+)
+
+// RealtimePriority is the round-robin priority requested for realtime
+// scheduling, low enough to stay below the sound server and IRQ threads.
+const RealtimePriority = 10
+
+// ErrUnsupported is returned where the platform has no way to raise the
+// priority.
+var ErrUnsupported = errors.New("scheduling priority is not supported on this platform")
+
+// Method says how realtime scheduling was obtained.
+type Method string
+
+const (
+	// MethodNone means realtime scheduling was not requested or denied.
+	MethodNone Method = ""
+	// MethodScheduler means the process was allowed to set it directly,
+	// through CAP_SYS_NICE or a raised RLIMIT_RTPRIO.
+	MethodScheduler Method = "scheduler"
+	// MethodRTKit means the RealtimeKit daemon granted it.
+	MethodRTKit Method = "rtkit"
+)
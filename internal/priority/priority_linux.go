@@ -0,0 +1,153 @@
+package priority
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sys/unix"
+)
+
+// RealtimeKit only serves processes that cap the CPU time a realtime
+// thread may use without blocking at or below its own limit, 200 ms by
+// default. The kernel kills the process at the hard limit, so the soft
+// limit's SIGXCPU drops realtime scheduling before that.
+const (
+	rtkitSoftRTTime = 150000 // µs
+	rtkitHardRTTime = 200000 // µs
+)
+
+// Apply sets the nice value of every thread, unless nice is 0, and then
+// asks for realtime scheduling if requested: directly where permitted,
+// otherwise through RealtimeKit. It returns how realtime scheduling was
+// obtained and an error for each part that was denied; whatever was
+// granted stays in effect.
+func Apply(nice int, realtime bool) (Method, error) {
+	if nice == 0 && !realtime {
+		return MethodNone, nil
+	}
+	tids, err := threads()
+	if err != nil {
+		return MethodNone, err
+	}
+
+	var errs []error
+	if nice != 0 {
+		if err := setNice(tids, nice); err != nil {
+			errs = append(errs, fmt.Errorf("nice %d denied: %w", nice, err))
+		}
+	}
+	if !realtime {
+		return MethodNone, errors.Join(errs...)
+	}
+
+	err = setRealtime(tids)
+	if err == nil {
+		return MethodScheduler, errors.Join(errs...)
+	}
+	rtkitErr := rtkit(tids, nice)
+	if rtkitErr == nil {
+		return MethodRTKit, errors.Join(errs...)
+	}
+	errs = append(errs, fmt.Errorf("realtime scheduling denied: %w; rtkit: %w", err, rtkitErr))
+	return MethodNone, errors.Join(errs...)
+}
+
+// threads returns the IDs of the process's threads.
+func threads() ([]int, error) {
+	entries, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list threads: %w", err)
+	}
+	tids := make([]int, 0, len(entries))
+	for _, e := range entries {
+		if tid, err := strconv.Atoi(e.Name()); err == nil {
+			tids = append(tids, tid)
+		}
+	}
+	return tids, nil
+}
+
+// setNice sets the nice value of each thread; on Linux it is per thread.
+func setNice(tids []int, nice int) error {
+	for _, tid := range tids {
+		if err := unix.Setpriority(unix.PRIO_PROCESS, tid, nice); err != nil && !errors.Is(err, unix.ESRCH) {
+			return err
+		}
+	}
+	return nil
+}
+
+func setRealtime(tids []int) error {
+	attr := &unix.SchedAttr{
+		Size:     unix.SizeofSchedAttr,
+		Policy:   unix.SCHED_RR,
+		Priority: RealtimePriority,
+	}
+	for _, tid := range tids {
+		// Threads that exited since they were listed are fine to skip
+		if err := unix.SchedSetAttr(tid, attr, 0); err != nil && !errors.Is(err, unix.ESRCH) {
+			return err
+		}
+	}
+	return nil
+}
+
+// rtkit asks the RealtimeKit daemon, which desktop distributions run for
+// sound servers, to make each thread realtime. It talks D-Bus through
+// busctl from systemd.
+func rtkit(tids []int, nice int) error {
+	busctl, err := exec.LookPath("busctl")
+	if err != nil {
+		return errors.New("busctl not found")
+	}
+	limit := &unix.Rlimit{Cur: rtkitSoftRTTime, Max: rtkitHardRTTime}
+	if err := unix.Setrlimit(unix.RLIMIT_RTTIME, limit); err != nil {
+		return fmt.Errorf("failed to limit realtime CPU time: %w", err)
+	}
+	watchCPULimit(nice)
+
+	pid := strconv.Itoa(os.Getpid())
+	for _, tid := range tids {
+		out, err := exec.Command(busctl, "--system", "call",
+			"org.freedesktop.RealtimeKit1", "/org/freedesktop/RealtimeKit1", "org.freedesktop.RealtimeKit1",
+			"MakeThreadRealtimeWithPID", "ttu", pid, strconv.Itoa(tid), strconv.Itoa(RealtimePriority),
+		).CombinedOutput()
+		if err != nil {
+			if msg := strings.TrimSpace(string(out)); msg != "" {
+				return errors.New(msg)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// watchCPULimit puts every thread back on normal scheduling when a
+// realtime thread ran into the soft CPU time limit, keeping their nice value.
+func watchCPULimit(nice int) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, unix.SIGXCPU)
+	go func() {
+		<-sig
+		signal.Stop(sig)
+		log.Warn().Msg("A thread hit the realtime CPU limit, dropping realtime scheduling")
+		tids, err := threads()
+		if err != nil {
+			return
+		}
+		attr := &unix.SchedAttr{Size: unix.SizeofSchedAttr, Policy: unix.SCHED_NORMAL, Nice: int32(nice)}
+		plain := &unix.SchedAttr{Size: unix.SizeofSchedAttr, Policy: unix.SCHED_NORMAL}
+		for _, tid := range tids {
+			// Leaving realtime matters more than keeping a denied nice value
+			if unix.SchedSetAttr(tid, attr, 0) != nil {
+				_ = unix.SchedSetAttr(tid, plain, 0)
+			}
+		}
+	}()
+}
@@ -0,0 +1,47 @@
+package priority
+
+import (
+	"os"
+	"slices"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestApplyNothingRequested(t *testing.T) {
+	method, err := Apply(0, false)
+	if err != nil || method != MethodNone {
+		t.Errorf("Apply(0, false) = %q, %v, want no change", method, err)
+	}
+}
+
+func TestThreadsIncludesMainThread(t *testing.T) {
+	tids, err := threads()
+	if err != nil {
+		t.Fatalf("threads() error = %v", err)
+	}
+	if !slices.Contains(tids, os.Getpid()) {
+		t.Errorf("threads() = %v, want it to include the main thread %d", tids, os.Getpid())
+	}
+}
+
+func TestApplyNiceEveryThread(t *testing.T) {
+	// Raising the nice value is always allowed, so this runs unprivileged
+	if _, err := Apply(MaxNice, false); err != nil {
+		t.Fatalf("Apply(%d, false) error = %v", MaxNice, err)
+	}
+	tids, err := threads()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tid := range tids {
+		// Getpriority returns 20 - nice to stay positive
+		prio, err := unix.Getpriority(unix.PRIO_PROCESS, tid)
+		if err != nil {
+			continue
+		}
+		if nice := 20 - prio; nice != MaxNice {
+			t.Errorf("thread %d nice = %d, want %d", tid, nice, MaxNice)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package priority
+
+// Apply is only implemented on Linux; elsewhere it fails if anything was
+// requested.
+func Apply(nice int, realtime bool) (Method, error) {
+	if nice == 0 && !realtime {
+		return MethodNone, nil
+	}
+	return MethodNone, ErrUnsupported
+}
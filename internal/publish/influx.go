@@ -0,0 +1,90 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const influxTimeout = 5 * time.Second
+
+// InfluxPublisher writes events in InfluxDB line protocol to a write
+// endpoint, e.g. http://localhost:8086/api/v2/write?org=home&bucket=radio.
+type InfluxPublisher struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func NewInfluxPublisher(url, token string) *InfluxPublisher {
+	return &InfluxPublisher{
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: influxTimeout},
+	}
+}
+
+func (i *InfluxPublisher) Name() string {
+	return "influxdb"
+}
+
+func (i *InfluxPublisher) Publish(e Event) error {
+	ctx, cancel := context.WithTimeout(context.Background(), influxTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.url, strings.NewReader(lineProtocol(e)))
+	if err != nil {
+		return fmt.Errorf("failed to create InfluxDB request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if i.token != "" {
+		req.Header.Set("Authorization", "Token "+i.token)
+	}
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to write to InfluxDB: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("InfluxDB returned status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+// lineProtocol renders e as a single "somafm" measurement line.
+func lineProtocol(e Event) string {
+	var b strings.Builder
+	b.WriteString("somafm")
+	if e.Station != "" {
+		b.WriteString(",station=")
+		b.WriteString(escapeTag(e.Station))
+	}
+	fmt.Fprintf(&b, " state=%s,playing=%t,volume=%di", quoteField(e.State), e.State == "playing", e.Volume)
+	if e.StationTitle != "" {
+		fmt.Fprintf(&b, ",station_title=%s", quoteField(e.StationTitle))
+	}
+	if e.Track != "" {
+		fmt.Fprintf(&b, ",track=%s", quoteField(e.Track))
+	}
+	fmt.Fprintf(&b, " %d\n", e.Time.UnixNano())
+	return b.String()
+}
+
+var tagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+func escapeTag(s string) string {
+	return tagEscaper.Replace(s)
+}
+
+var fieldEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func quoteField(s string) string {
+	return `"` + fieldEscaper.Replace(s) + `"`
+}
@@ -0,0 +1,261 @@
+package publish
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	mqttDialTimeout = 5 * time.Second
+	mqttKeepAlive   = 30 // seconds; each connection only lives for one publish
+
+	mqttConnect    = 0x10
+	mqttConnack    = 0x20
+	mqttPublish    = 0x30
+	mqttDisconnect = 0xe0
+	mqttRetain     = 0x01
+)
+
+// MQTTPublisher publishes retained JSON state to "<topic>/state" and, when
+// discovery is enabled, Home Assistant MQTT discovery configs for sensors.
+// A short-lived connection is opened per event, which keeps the client
+// free of keep-alive handling; events only happen on state changes.
+type MQTTPublisher struct {
+	addr            string
+	useTLS          bool
+	username        string
+	password        string
+	clientID        string
+	topic           string
+	discoveryPrefix string
+
+	mu            sync.Mutex
+	sentDiscovery bool
+}
+
+// NewMQTTPublisher parses a broker URL such as tcp://host:1883 or
+// mqtts://host:8883.
+func NewMQTTPublisher(broker, username, password, topic, discoveryPrefix string) (*MQTTPublisher, error) {
+	u, err := url.Parse(broker)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MQTT broker URL: %w", err)
+	}
+
+	m := &MQTTPublisher{
+		addr:            u.Host,
+		username:        username,
+		password:        password,
+		clientID:        "somafm-cli",
+		topic:           strings.TrimRight(topic, "/"),
+		discoveryPrefix: strings.TrimRight(discoveryPrefix, "/"),
+	}
+	switch u.Scheme {
+	case "tcp", "mqtt":
+		if u.Port() == "" {
+			m.addr = net.JoinHostPort(u.Hostname(), "1883")
+		}
+	case "ssl", "tls", "mqtts":
+		m.useTLS = true
+		if u.Port() == "" {
+			m.addr = net.JoinHostPort(u.Hostname(), "8883")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported MQTT broker scheme %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, errors.New("MQTT broker URL has no host")
+	}
+	if m.topic == "" {
+		m.topic = "somafm"
+	}
+	return m, nil
+}
+
+func (m *MQTTPublisher) Name() string {
+	return "mqtt"
+}
+
+func (m *MQTTPublisher) stateTopic() string {
+	return m.topic + "/state"
+}
+
+// Publish sends the event as retained JSON, preceded by discovery configs
+// the first time.
+func (m *MQTTPublisher) Publish(e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	messages := []mqttMessage{{topic: m.stateTopic(), payload: payload}}
+	if m.discoveryPrefix != "" && !m.sentDiscovery {
+		messages = append(m.discoveryMessages(), messages...)
+	}
+
+	if err := m.send(messages); err != nil {
+		return err
+	}
+	if m.discoveryPrefix != "" {
+		m.sentDiscovery = true
+	}
+	return nil
+}
+
+type mqttMessage struct {
+	topic   string
+	payload []byte
+}
+
+// discoveryMessages builds Home Assistant sensor configs that read from the
+// state topic.
+func (m *MQTTPublisher) discoveryMessages() []mqttMessage {
+	device := map[string]any{
+		"identifiers":  []string{m.clientID},
+		"name":         "SomaFM CLI",
+		"manufacturer": "somafm-cli",
+	}
+	sensors := []struct {
+		id, name, template, icon string
+	}{
+		{"state", "State", "{{ value_json.state }}", "mdi:radio"},
+		{"station", "Station", "{{ value_json.station_title }}", "mdi:radio-tower"},
+		{"track", "Track", "{{ value_json.track }}", "mdi:music"},
+	}
+
+	var messages []mqttMessage
+	for _, s := range sensors {
+		config := map[string]any{
+			"name":                  s.name,
+			"unique_id":             m.clientID + "_" + s.id,
+			"state_topic":           m.stateTopic(),
+			"value_template":        s.template,
+			"icon":                  s.icon,
+			"device":                device,
+			"json_attributes_topic": m.stateTopic(),
+		}
+		payload, _ := json.Marshal(config)
+		messages = append(messages, mqttMessage{
+			topic:   fmt.Sprintf("%s/sensor/%s/%s/config", m.discoveryPrefix, m.clientID, s.id),
+			payload: payload,
+		})
+	}
+	return messages
+}
+
+func (m *MQTTPublisher) send(messages []mqttMessage) error {
+	dialer := &net.Dialer{Timeout: mqttDialTimeout}
+	var conn net.Conn
+	var err error
+	if m.useTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", m.addr, nil)
+	} else {
+		conn, err = dialer.Dial("tcp", m.addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to MQTT broker: %w", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(mqttDialTimeout))
+
+	if _, err := conn.Write(m.connectPacket()); err != nil {
+		return fmt.Errorf("failed to send MQTT connect: %w", err)
+	}
+	if err := readConnack(bufio.NewReader(conn)); err != nil {
+		return err
+	}
+
+	for _, msg := range messages {
+		if _, err := conn.Write(publishPacket(msg.topic, msg.payload)); err != nil {
+			return fmt.Errorf("failed to publish to %s: %w", msg.topic, err)
+		}
+	}
+
+	_, _ = conn.Write([]byte{mqttDisconnect, 0})
+	return nil
+}
+
+// connectPacket builds an MQTT 3.1.1 CONNECT with a clean session.
+func (m *MQTTPublisher) connectPacket() []byte {
+	var body []byte
+	body = appendString(body, "MQTT")
+	body = append(body, 4) // protocol level 3.1.1
+
+	flags := byte(0x02) // clean session
+	if m.username != "" {
+		flags |= 0x80
+		if m.password != "" {
+			flags |= 0x40
+		}
+	}
+	body = append(body, flags)
+	body = binary.BigEndian.AppendUint16(body, mqttKeepAlive)
+
+	body = appendString(body, m.clientID)
+	if m.username != "" {
+		body = appendString(body, m.username)
+		if m.password != "" {
+			body = appendString(body, m.password)
+		}
+	}
+	return packet(mqttConnect, body)
+}
+
+// publishPacket builds a retained QoS 0 PUBLISH.
+func publishPacket(topic string, payload []byte) []byte {
+	body := appendString(nil, topic)
+	body = append(body, payload...)
+	return packet(mqttPublish|mqttRetain, body)
+}
+
+func readConnack(r *bufio.Reader) error {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return fmt.Errorf("failed to read MQTT connack: %w", err)
+	}
+	if header[0] != mqttConnack || header[1] != 2 {
+		return fmt.Errorf("unexpected MQTT packet 0x%02x", header[0])
+	}
+	if code := header[3]; code != 0 {
+		return fmt.Errorf("MQTT broker refused connection (code %d)", code)
+	}
+	return nil
+}
+
+func packet(kind byte, body []byte) []byte {
+	out := []byte{kind}
+	out = appendRemainingLength(out, len(body))
+	return append(out, body...)
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// appendRemainingLength encodes n as an MQTT variable byte integer.
+func appendRemainingLength(b []byte, n int) []byte {
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			return b
+		}
+	}
+}
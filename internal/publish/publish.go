@@ -0,0 +1,133 @@
+// Package publish sends playback state to home automation and metrics
+// systems: MQTT (with Home Assistant discovery) and InfluxDB. It is opt-in
+// and only active when a broker or write URL is configured.
+package publish
+
+import (
+	"context"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/rs/zerolog/log"
+)
+
+// PollInterval is how often the player is checked for changes.
+const PollInterval = 2 * time.Second
+
+// Event is a snapshot of playback published whenever it changes.
+type Event struct {
+	State        string    `json:"state"`
+	Station      string    `json:"station,omitempty"`
+	StationTitle string    `json:"station_title,omitempty"`
+	Track        string    `json:"track,omitempty"`
+	Volume       int       `json:"volume"`
+	Time         time.Time `json:"time"`
+}
+
+// same reports whether two events describe the same playback, ignoring time.
+func (e Event) same(other Event) bool {
+	e.Time, other.Time = time.Time{}, time.Time{}
+	return e == other
+}
+
+// Publisher delivers events to one destination.
+type Publisher interface {
+	Name() string
+	Publish(Event) error
+}
+
+// FromConfig returns the publishers enabled in cfg, or an error if any of
+// them is misconfigured.
+func FromConfig(cfg config.Publish) ([]Publisher, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	var publishers []Publisher
+	if cfg.MQTT.Broker != "" {
+		prefix := ""
+		if cfg.MQTT.Discovery {
+			prefix = cfg.MQTT.DiscoveryPrefix
+		}
+		m, err := NewMQTTPublisher(cfg.MQTT.Broker, cfg.MQTT.Username, cfg.MQTT.Password, cfg.MQTT.Topic, prefix)
+		if err != nil {
+			return nil, err
+		}
+		publishers = append(publishers, m)
+	}
+	if cfg.InfluxDB.URL != "" {
+		publishers = append(publishers, NewInfluxPublisher(cfg.InfluxDB.URL, cfg.InfluxDB.Token))
+	}
+	return publishers, nil
+}
+
+// stateName maps player states to stable lowercase names for consumers.
+func stateName(s player.PlayerState) string {
+	switch s {
+	case player.StateBuffering:
+		return "buffering"
+	case player.StatePlaying:
+		return "playing"
+	case player.StatePaused:
+		return "paused"
+	case player.StateReconnecting:
+		return "reconnecting"
+	case player.StateError:
+		return "error"
+	default:
+		return "idle"
+	}
+}
+
+// Snapshot describes the current playback of p.
+func Snapshot(p *player.Player) Event {
+	e := Event{
+		State:  stateName(p.GetState()),
+		Volume: p.GetVolume(),
+		Time:   time.Now(),
+	}
+	if s := p.GetCurrentStation(); s != nil && e.State != "idle" {
+		e.Station = s.ID.String()
+		e.StationTitle = s.Title
+		if track := p.GetCurrentTrack(); track != player.NoTrackInfo {
+			e.Track = track
+		}
+	}
+	return e
+}
+
+// Watch polls p until ctx is done, publishing each change, then publishes a
+// final idle event. Failures are logged and don't stop playback.
+func Watch(ctx context.Context, p *player.Player, publishers []Publisher) {
+	if len(publishers) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+
+	var last Event
+	first := true
+	for {
+		select {
+		case <-ctx.Done():
+			publishAll(publishers, Event{State: "idle", Volume: last.Volume, Time: time.Now()})
+			return
+		case <-ticker.C:
+			e := Snapshot(p)
+			if !first && e.same(last) {
+				continue
+			}
+			publishAll(publishers, e)
+			last, first = e, false
+		}
+	}
+}
+
+func publishAll(publishers []Publisher, e Event) {
+	for _, pub := range publishers {
+		if err := pub.Publish(e); err != nil {
+			log.Warn().Err(err).Msgf("Failed to publish to %s", pub.Name())
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package publish
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/config"
+)
+
+// fakeBroker accepts MQTT connections and records published messages.
+type fakeBroker struct {
+	ln       net.Listener
+	messages chan mqttMessage
+	username chan string
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	b := &fakeBroker{ln: ln, messages: make(chan mqttMessage, 16), username: make(chan string, 4)}
+	t.Cleanup(func() { ln.Close() })
+	go b.serve()
+	return b
+}
+
+func (b *fakeBroker) serve() {
+	for {
+		conn, err := b.ln.Accept()
+		if err != nil {
+			return
+		}
+		go b.handle(conn)
+	}
+}
+
+func (b *fakeBroker) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		kind, body, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		switch kind & 0xf0 {
+		case mqttConnect:
+			b.username <- connectUsername(body)
+			_, _ = conn.Write([]byte{mqttConnack, 2, 0, 0})
+		case mqttPublish:
+			n := int(body[0])<<8 | int(body[1])
+			b.messages <- mqttMessage{topic: string(body[2 : 2+n]), payload: body[2+n:]}
+		case mqttDisconnect:
+			return
+		}
+	}
+}
+
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	kind, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for {
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(digit&0x7f) * multiplier
+		multiplier *= 128
+		if digit&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	return kind, body, err
+}
+
+// connectUsername extracts the username from a CONNECT body, if any.
+func connectUsername(body []byte) string {
+	flags := body[7]
+	rest := body[10:]
+	readString := func() string {
+		n := int(rest[0])<<8 | int(rest[1])
+		s := string(rest[2 : 2+n])
+		rest = rest[2+n:]
+		return s
+	}
+	readString() // client ID
+	if flags&0x80 != 0 {
+		return readString()
+	}
+	return ""
+}
+
+func (b *fakeBroker) next(t *testing.T) mqttMessage {
+	t.Helper()
+	select {
+	case m := <-b.messages:
+		return m
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for MQTT publish")
+		return mqttMessage{}
+	}
+}
+
+func TestMQTTPublishWithDiscovery(t *testing.T) {
+	b := newFakeBroker(t)
+	m, err := NewMQTTPublisher("tcp://"+b.ln.Addr().String(), "radio", "secret", "home/somafm/", "homeassistant")
+	if err != nil {
+		t.Fatalf("NewMQTTPublisher() error = %v", err)
+	}
+
+	event := Event{State: "playing", Station: "groovesalad", StationTitle: "Groove Salad", Track: "Artist - Title", Volume: 70, Time: time.Unix(0, 0)}
+	if err := m.Publish(event); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if got := <-b.username; got != "radio" {
+		t.Errorf("CONNECT username = %q, want %q", got, "radio")
+	}
+
+	for _, id := range []string{"state", "station", "track"} {
+		msg := b.next(t)
+		want := "homeassistant/sensor/somafm-cli/" + id + "/config"
+		if msg.topic != want {
+			t.Fatalf("discovery topic = %q, want %q", msg.topic, want)
+		}
+		var config map[string]any
+		if err := json.Unmarshal(msg.payload, &config); err != nil {
+			t.Fatalf("discovery payload: %v", err)
+		}
+		if config["state_topic"] != "home/somafm/state" {
+			t.Errorf("state_topic = %v, want home/somafm/state", config["state_topic"])
+		}
+	}
+
+	msg := b.next(t)
+	if msg.topic != "home/somafm/state" {
+		t.Fatalf("state topic = %q", msg.topic)
+	}
+	var got Event
+	if err := json.Unmarshal(msg.payload, &got); err != nil {
+		t.Fatalf("state payload: %v", err)
+	}
+	if !got.same(event) {
+		t.Errorf("state = %+v, want %+v", got, event)
+	}
+
+	// Discovery is only sent once per process
+	if err := m.Publish(Event{State: "paused"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	<-b.username
+	if msg := b.next(t); msg.topic != "home/somafm/state" {
+		t.Errorf("second publish topic = %q, want state only", msg.topic)
+	}
+}
+
+func TestMQTTBrokerURL(t *testing.T) {
+	tests := []struct {
+		broker  string
+		addr    string
+		tls     bool
+		wantErr bool
+	}{
+		{"tcp://ha.local", "ha.local:1883", false, false},
+		{"mqtt://ha.local:1884", "ha.local:1884", false, false},
+		{"mqtts://broker.example.com", "broker.example.com:8883", true, false},
+		{"http://ha.local", "", false, true},
+		{"tcp://", "", false, true},
+	}
+
+	for _, tt := range tests {
+		m, err := NewMQTTPublisher(tt.broker, "", "", "", "")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewMQTTPublisher(%q) error = %v, wantErr %v", tt.broker, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if m.addr != tt.addr || m.useTLS != tt.tls {
+			t.Errorf("NewMQTTPublisher(%q) = %s tls=%v, want %s tls=%v", tt.broker, m.addr, m.useTLS, tt.addr, tt.tls)
+		}
+		if m.topic != "somafm" {
+			t.Errorf("default topic = %q, want somafm", m.topic)
+		}
+	}
+}
+
+func TestFromConfigRefusesInvalidSettings(t *testing.T) {
+	cfg := config.Publish{
+		MQTT:     config.MQTT{Broker: "tcp://ha.local"},
+		InfluxDB: config.InfluxDB{URL: "localhost:8086"},
+	}
+	if publishers, err := FromConfig(cfg); err == nil {
+		t.Errorf("FromConfig() = %d publishers, want an error for the InfluxDB URL", len(publishers))
+	}
+
+	cfg.InfluxDB.URL = "http://localhost:8086"
+	publishers, err := FromConfig(cfg)
+	if err != nil || len(publishers) != 2 {
+		t.Errorf("FromConfig() = %d publishers, %v, want 2", len(publishers), err)
+	}
+}
+
+func TestRemainingLength(t *testing.T) {
+	tests := []struct {
+		n    int
+		want []byte
+	}{
+		{0, []byte{0x00}},
+		{127, []byte{0x7f}},
+		{128, []byte{0x80, 0x01}},
+		{16383, []byte{0xff, 0x7f}},
+		{16384, []byte{0x80, 0x80, 0x01}},
+	}
+	for _, tt := range tests {
+		if got := appendRemainingLength(nil, tt.n); string(got) != string(tt.want) {
+			t.Errorf("appendRemainingLength(%d) = %x, want %x", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestInfluxPublish(t *testing.T) {
+	var body, auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		auth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	i := NewInfluxPublisher(server.URL+"/api/v2/write?bucket=radio", "tok")
+	event := Event{State: "playing", Station: "groovesalad", StationTitle: "Groove Salad", Track: `Say "Hi"`, Volume: 70, Time: time.Unix(1, 5)}
+	if err := i.Publish(event); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	want := `somafm,station=groovesalad state="playing",playing=true,volume=70i,station_title="Groove Salad",track="Say \"Hi\"" 1000000005` + "\n"
+	if body != want {
+		t.Errorf("body = %q\nwant   %q", body, want)
+	}
+	if auth != "Token tok" {
+		t.Errorf("Authorization = %q, want %q", auth, "Token tok")
+	}
+}
+
+func TestInfluxPublishError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bucket not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	err := NewInfluxPublisher(server.URL, "").Publish(Event{State: "idle"})
+	if err == nil || !strings.Contains(err.Error(), "bucket not found") {
+		t.Errorf("Publish() error = %v, want status error", err)
+	}
+}
+
+func TestLineProtocolEscaping(t *testing.T) {
+	got := lineProtocol(Event{State: "idle", Station: "a b,c=d", Time: time.Unix(0, 0)})
+	want := `somafm,station=a\ b\,c\=d state="idle",playing=false,volume=0i 0` + "\n"
+	if got != want {
+		t.Errorf("lineProtocol() = %q, want %q", got, want)
+	}
+}
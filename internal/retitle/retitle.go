@@ -0,0 +1,67 @@
+// Package retitle corrects track titles with user-defined regular
+// expression rules before they are shown, recorded, or published.
+package retitle
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Rule replaces matches of Match with Replace, which may refer to groups as
+// $1 or ${name}. With Station set, the rule only applies to that station.
+type Rule struct {
+	Station string `yaml:"station,omitempty"`
+	Match   string `yaml:"match"`
+	Replace string `yaml:"replace"`
+}
+
+type compiled struct {
+	station string
+	re      *regexp.Regexp
+	replace string
+}
+
+// Rewriter applies rules in order, each to the result of the previous one.
+// A nil Rewriter returns titles unchanged.
+type Rewriter struct {
+	rules []compiled
+}
+
+// New compiles rules. It returns nil when there are none.
+func New(rules []Rule) (*Rewriter, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	r := &Rewriter{}
+	for i, rule := range rules {
+		if rule.Match == "" {
+			return nil, fmt.Errorf("title rule %d has no match", i+1)
+		}
+		re, err := regexp.Compile(rule.Match)
+		if err != nil {
+			return nil, fmt.Errorf("title rule %d: %w", i+1, err)
+		}
+		r.rules = append(r.rules, compiled{
+			station: strings.TrimSpace(rule.Station),
+			re:      re,
+			replace: rule.Replace,
+		})
+	}
+	return r, nil
+}
+
+// Apply rewrites title as heard on the station with the given ID. Empty
+// titles are left alone, and surrounding spaces left by a rule are trimmed.
+func (r *Rewriter) Apply(stationID, title string) string {
+	if r == nil || title == "" {
+		return title
+	}
+	for _, rule := range r.rules {
+		if rule.station != "" && !strings.EqualFold(rule.station, stationID) {
+			continue
+		}
+		title = rule.re.ReplaceAllString(title, rule.replace)
+	}
+	return strings.TrimSpace(title)
+}
@@ -0,0 +1,48 @@
+package retitle
+
+import "testing"
+
+func TestApply(t *testing.T) {
+	r, err := New([]Rule{
+		{Match: `\s*\[SomaFM\]$`},
+		{Station: "DroneZone", Match: `^(.+?) - (.+)$`, Replace: "$2 - $1"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		station string
+		title   string
+		want    string
+	}{
+		{"groovesalad", "Bonobo - Kiara [SomaFM]", "Bonobo - Kiara"},
+		{"groovesalad", "Bonobo - Kiara", "Bonobo - Kiara"},
+		{"dronezone", "Requiem - Stars of the Lid [SomaFM]", "Stars of the Lid - Requiem"},
+		{"dronezone", "", ""},
+	}
+	for _, tt := range tests {
+		if got := r.Apply(tt.station, tt.title); got != tt.want {
+			t.Errorf("Apply(%q, %q) = %q, want %q", tt.station, tt.title, got, tt.want)
+		}
+	}
+
+	var none *Rewriter
+	if got := none.Apply("groovesalad", " As is "); got != " As is " {
+		t.Errorf("nil Apply() = %q, want the title unchanged", got)
+	}
+}
+
+func TestNewRejectsInvalidRules(t *testing.T) {
+	for _, rules := range [][]Rule{
+		{{Match: "("}},
+		{{Replace: "x"}},
+	} {
+		if _, err := New(rules); err == nil {
+			t.Errorf("New(%+v) should fail", rules)
+		}
+	}
+	if r, err := New(nil); r != nil || err != nil {
+		t.Errorf("New(nil) = %v, %v; want nil, nil", r, err)
+	}
+}
@@ -0,0 +1,68 @@
+// Package sdnotify implements the systemd service notification protocol
+// (sd_notify), so a headless player can report readiness, status lines, and
+// watchdog keep-alives to a systemd unit with Type=notify.
+package sdnotify
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Ready tells systemd that startup is finished.
+	Ready = "READY=1"
+	// Stopping tells systemd that the service is shutting down.
+	Stopping = "STOPPING=1"
+	// Watchdog resets the watchdog timer.
+	Watchdog = "WATCHDOG=1"
+)
+
+// Status returns a STATUS= line, shown by `systemctl status`.
+func Status(format string, args ...any) string {
+	status := fmt.Sprintf(format, args...)
+	return "STATUS=" + strings.ReplaceAll(status, "\n", " ")
+}
+
+// Notify sends states to the socket in NOTIFY_SOCKET. It reports false,
+// without error, when not running under systemd.
+func Notify(states ...string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+
+	addr := &net.UnixAddr{Name: socket, Net: "unixgram"}
+	// A leading @ denotes an abstract socket
+	if strings.HasPrefix(socket, "@") {
+		addr.Name = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, addr)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to notify socket: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(strings.Join(states, "\n"))); err != nil {
+		return false, fmt.Errorf("failed to send notification: %w", err)
+	}
+	return true, nil
+}
+
+// WatchdogInterval returns how often systemd expects a Watchdog ping, or 0
+// when the watchdog isn't enabled for this process. Pinging at half the
+// interval is recommended.
+func WatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
@@ -0,0 +1,85 @@
+package sdnotify
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNotifyWithoutSocket(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+
+	sent, err := Notify(Ready)
+	if sent || err != nil {
+		t.Errorf("Notify() = %v, %v, want false, nil", sent, err)
+	}
+}
+
+func TestNotifySendsStates(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unixgram sockets are not available")
+	}
+
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("ListenUnixgram() error = %v", err)
+	}
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", path)
+
+	sent, err := Notify(Ready, Status("Playing %s", "Groove Salad\nnow"))
+	if !sent || err != nil {
+		t.Fatalf("Notify() = %v, %v, want true, nil", sent, err)
+	}
+
+	buf := make([]byte, 256)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	want := "READY=1\nSTATUS=Playing Groove Salad now"
+	if got := string(buf[:n]); got != want {
+		t.Errorf("datagram = %q, want %q", got, want)
+	}
+}
+
+func TestNotifyMissingSocket(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", filepath.Join(t.TempDir(), "missing.sock"))
+
+	if sent, err := Notify(Ready); sent || err == nil {
+		t.Errorf("Notify() = %v, %v, want false and an error", sent, err)
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+
+	tests := []struct {
+		name string
+		usec string
+		pid  string
+		want time.Duration
+	}{
+		{"disabled", "", "", 0},
+		{"enabled", "30000000", "", 30 * time.Second},
+		{"this process", "2000000", pid, 2 * time.Second},
+		{"other process", "2000000", "1", 0},
+		{"invalid", "soon", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WATCHDOG_USEC", tt.usec)
+			t.Setenv("WATCHDOG_PID", tt.pid)
+			if got := WatchdogInterval(); got != tt.want {
+				t.Errorf("WatchdogInterval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// Terminal image renderers sample each cell as an 8x8 grid, and cells are
+	// roughly twice as tall as they are wide.
+	cellPixelWidth  = 8
+	cellPixelHeight = 16
+
+	maxImageVariants = 64
+)
+
+func variantKey(url string, cols, rows int) string {
+	return fmt.Sprintf("%s@%dx%d", url, cols, rows)
+}
+
+// LoadImageVariant returns the image at url pre-scaled to fit a panel of the
+// given cell dimensions, keeping its aspect ratio. Variants are kept in
+// memory and on disk so layout changes don't re-decode and re-scale the
+// original. Use LoadImage for the full-resolution original.
+func (s *StationService) LoadImageVariant(url string, cols, rows int) (image.Image, error) {
+	if cols <= 0 || rows <= 0 {
+		return s.LoadImage(url)
+	}
+
+	key := variantKey(url, cols, rows)
+
+	s.variantsMu.Lock()
+	img, ok := s.variants[key]
+	s.variantsMu.Unlock()
+	if ok {
+		return img, nil
+	}
+
+	if s.imageCache != nil {
+		if img := s.imageCache.GetImageVariant(url, cols, rows); img != nil {
+			log.Debug().Str("url", url).Int("cols", cols).Int("rows", rows).Msg("Image variant loaded from cache")
+			s.storeVariant(key, img)
+			return img, nil
+		}
+	}
+
+	original, err := s.LoadImage(url)
+	if err != nil {
+		return nil, err
+	}
+
+	img = scaleToFit(original, cols*cellPixelWidth, rows*cellPixelHeight)
+	s.storeVariant(key, img)
+
+	if s.imageCache != nil && img != original {
+		go func() {
+			if err := s.imageCache.SaveImageVariant(url, cols, rows, img); err != nil {
+				log.Debug().Err(err).Str("url", url).Msg("Failed to cache image variant")
+			}
+		}()
+	}
+
+	return img, nil
+}
+
+// LoadImagePixels returns the image at url scaled down to fit width x
+// height pixels, for terminals that draw images pixel for pixel. These
+// variants depend on the font size, so they are kept in memory only.
+func (s *StationService) LoadImagePixels(url string, width, height int) (image.Image, error) {
+	key := fmt.Sprintf("%s@%dx%dpx", url, width, height)
+
+	s.variantsMu.Lock()
+	img, ok := s.variants[key]
+	s.variantsMu.Unlock()
+	if ok {
+		return img, nil
+	}
+
+	original, err := s.LoadImage(url)
+	if err != nil {
+		return nil, err
+	}
+	img = scaleToFit(original, width, height)
+	s.storeVariant(key, img)
+	return img, nil
+}
+
+func (s *StationService) storeVariant(key string, img image.Image) {
+	s.variantsMu.Lock()
+	defer s.variantsMu.Unlock()
+
+	if s.variants == nil || len(s.variants) >= maxImageVariants {
+		s.variants = make(map[string]image.Image)
+	}
+	s.variants[key] = img
+}
+
+// scaleToFit downscales img to fit within maxWidth x maxHeight pixels using
+// an area-averaging box filter. Images that already fit are returned as is.
+func scaleToFit(img image.Image, maxWidth, maxHeight int) image.Image {
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	if srcWidth == 0 || srcHeight == 0 || (srcWidth <= maxWidth && srcHeight <= maxHeight) {
+		return img
+	}
+
+	scale := min(float64(maxWidth)/float64(srcWidth), float64(maxHeight)/float64(srcHeight))
+	dstWidth := max(int(float64(srcWidth)*scale), 1)
+	dstHeight := max(int(float64(srcHeight)*scale), 1)
+
+	dst := image.NewRGBA64(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		y0 := bounds.Min.Y + y*srcHeight/dstHeight
+		y1 := max(bounds.Min.Y+(y+1)*srcHeight/dstHeight, y0+1)
+		for x := 0; x < dstWidth; x++ {
+			x0 := bounds.Min.X + x*srcWidth/dstWidth
+			x1 := max(bounds.Min.X+(x+1)*srcWidth/dstWidth, x0+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r += uint64(pr)
+					g += uint64(pg)
+					b += uint64(pb)
+					a += uint64(pa)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+
+	return dst
+}
@@ -2,24 +2,46 @@
 package service
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"errors"
 	"image"
 	_ "image/jpeg"
 	_ "image/png"
+	"io"
 	"net/http"
-	"sort"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/glebovdev/somafm-cli/internal/api"
 	"github.com/glebovdev/somafm-cli/internal/cache"
+	"github.com/glebovdev/somafm-cli/internal/retitle"
 	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/glebovdev/somafm-cli/internal/throttle"
 	"github.com/rs/zerolog/log"
 )
 
 const imageLoadTimeout = 15 * time.Second
 
+// maxImageSize bounds how much of a logo response is read.
+const maxImageSize = 10 << 20
+
+// CacheMode controls how the service uses the on-disk image cache.
+type CacheMode int
+
+const (
+	// CacheNormal reads from and writes to the cache.
+	CacheNormal CacheMode = iota
+	// CacheDisabled bypasses the cache entirely for this run.
+	CacheDisabled
+	// CacheRefresh clears the cache on startup so everything is re-fetched.
+	CacheRefresh
+)
+
 // StationService manages station data, including fetching, caching, and periodic refresh.
 type StationService struct {
 	apiClient     *api.SomaFMClient
@@ -27,18 +49,38 @@ type StationService struct {
 	mu            sync.RWMutex
 	imageCache    *cache.Cache
 	refreshTicker *time.Ticker
-	stopRefresh   chan struct{}
+	stopRefresh   context.CancelFunc
 	onRefresh     func([]station.Station)
+	variants      map[string]image.Image
+	variantsMu    sync.Mutex
+	titles        *retitle.Rewriter
+	order         Comparator
+	stopWatch     context.CancelFunc
+	watched       map[station.StationID]string
+	httpClient    *http.Client
+	pinned        station.StationID
+	cacheMode     CacheMode
 }
 
 // NewStationService creates a new StationService with the given API client.
-func NewStationService(apiClient *api.SomaFMClient) *StationService {
+func NewStationService(apiClient *api.SomaFMClient, mode CacheMode) *StationService {
+	if mode == CacheDisabled {
+		log.Debug().Msg("Image cache disabled for this run")
+		return &StationService{apiClient: apiClient, cacheMode: mode}
+	}
+
 	imageCache, err := cache.NewCache()
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to initialize image cache, images will not be cached")
 	}
 
-	if imageCache != nil {
+	if imageCache != nil && mode == CacheRefresh {
+		if err := imageCache.Clear(); err != nil {
+			log.Warn().Err(err).Msg("Failed to clear image cache")
+		} else {
+			log.Debug().Msg("Image cache cleared")
+		}
+	} else if imageCache != nil {
 		go func() {
 			if err := imageCache.CleanExpired(); err != nil {
 				log.Debug().Err(err).Msg("Failed to clean expired cache")
@@ -49,18 +91,25 @@ func NewStationService(apiClient *api.SomaFMClient) *StationService {
 	return &StationService{
 		apiClient:  apiClient,
 		imageCache: imageCache,
+		cacheMode:  mode,
 	}
 }
 
-func (s *StationService) GetStations() ([]station.Station, error) {
-	stations, err := s.apiClient.GetStations()
+// CacheMode returns how the service was told to use the cache, for the
+// other caches to follow.
+func (s *StationService) CacheMode() CacheMode {
+	return s.cacheMode
+}
+
+func (s *StationService) GetStations(ctx context.Context) ([]station.Station, error) {
+	stations, err := s.apiClient.GetStations(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	s.sortStationsByListeners(stations)
-
 	s.mu.Lock()
+	s.applyWatched(stations)
+	s.sortStations(stations)
 	s.stations = stations
 	s.mu.Unlock()
 
@@ -75,34 +124,92 @@ func (s *StationService) GetCachedStations() []station.Station {
 	return result
 }
 
-func (s *StationService) sortStationsByListeners(stations []station.Station) {
-	sort.Slice(stations, func(i, j int) bool {
-		listenersI, errI := strconv.Atoi(stations[i].Listeners)
-		listenersJ, errJ := strconv.Atoi(stations[j].Listeners)
+// Comparator orders the station list. It returns a negative number when a
+// comes before b, a positive number when after, and zero to keep the two
+// in their current order.
+type Comparator func(a, b station.Station) int
 
-		if errI != nil {
-			return false
-		}
-		if errJ != nil {
-			return true
+// ByListeners puts the busiest stations first. Stations with an unknown
+// listener count go last.
+func ByListeners(a, b station.Station) int {
+	return cmp.Compare(listenerCount(b), listenerCount(a))
+}
+
+// ByTitle sorts stations alphabetically, ignoring case.
+func ByTitle(a, b station.Station) int {
+	return cmp.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+}
+
+// ByGenre groups stations by genre, busiest first within a genre.
+func ByGenre(a, b station.Station) int {
+	if c := cmp.Compare(strings.ToLower(a.Genre), strings.ToLower(b.Genre)); c != 0 {
+		return c
+	}
+	return ByListeners(a, b)
+}
+
+// FavoritesFirst puts favorites before the other stations, each group
+// busiest first.
+func FavoritesFirst(isFavorite func(station.StationID) bool) Comparator {
+	return func(a, b station.Station) int {
+		favA, favB := isFavorite(a.ID), isFavorite(b.ID)
+		if favA != favB {
+			if favA {
+				return -1
+			}
+			return 1
 		}
+		return ByListeners(a, b)
+	}
+}
+
+func listenerCount(s station.Station) int {
+	n, err := strconv.Atoi(s.Listeners)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// SetOrder sets how the station list is sorted and re-sorts the cached
+// stations. A nil order sorts by listeners.
+func (s *StationService) SetOrder(order Comparator) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.order = order
+	s.sortStations(s.stations)
+}
 
-		return listenersI > listenersJ
-	})
+// Resort sorts the cached stations again, e.g. after favorites changed
+// under FavoritesFirst.
+func (s *StationService) Resort() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sortStations(s.stations)
 }
 
-func (s *StationService) GetValidStationIDs() map[string]bool {
+// sortStations sorts stations by the current order. The sort is stable, so
+// stations the order considers equal keep the API's order.
+func (s *StationService) sortStations(stations []station.Station) {
+	order := s.order
+	if order == nil {
+		order = ByListeners
+	}
+	slices.SortStableFunc(stations, order)
+}
+
+func (s *StationService) GetValidStationIDs() map[station.StationID]bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	validIDs := make(map[string]bool)
+	validIDs := make(map[station.StationID]bool)
 	for _, st := range s.stations {
 		validIDs[st.ID] = true
 	}
 	return validIDs
 }
 
-func (s *StationService) FindIndexByID(stationID string) int {
+func (s *StationService) FindIndexByID(stationID station.StationID) int {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -135,6 +242,8 @@ func (s *StationService) GetStation(index int) *station.Station {
 	return &st
 }
 
+// LoadImage returns the full-resolution image at url, using the disk cache
+// when possible.
 func (s *StationService) LoadImage(url string) (image.Image, error) {
 	if s.imageCache != nil {
 		if img := s.imageCache.GetImage(url); img != nil {
@@ -151,20 +260,30 @@ func (s *StationService) LoadImage(url string) (image.Image, error) {
 		return nil, err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	client := http.DefaultClient
+	if s.httpClient != nil {
+		client = s.httpClient
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	img, _, err := image.Decode(resp.Body)
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
 	if err != nil {
 		return nil, err
 	}
 
 	if s.imageCache != nil {
+		contentType := resp.Header.Get("Content-Type")
 		go func() {
-			if err := s.imageCache.SaveImage(url, img); err != nil {
+			if err := s.imageCache.SaveImageData(url, data, contentType); err != nil {
 				log.Debug().Err(err).Str("url", url).Msg("Failed to cache image")
 			} else {
 				log.Debug().Str("url", url).Msg("Image cached")
@@ -175,27 +294,85 @@ func (s *StationService) LoadImage(url string) (image.Image, error) {
 	return img, nil
 }
 
-func (s *StationService) GetCurrentTrackForStation(stationID string) (string, error) {
-	return s.apiClient.GetCurrentTrackForStation(stationID)
+// ErrCacheDisabled is returned by cache operations when running without a cache.
+var ErrCacheDisabled = errors.New("cache is disabled")
+
+// CacheDir returns the on-disk cache location.
+func (s *StationService) CacheDir() (string, error) {
+	if s.imageCache == nil {
+		return "", ErrCacheDisabled
+	}
+	return s.imageCache.Dir(), nil
+}
+
+// CacheUsage reports disk usage per cache category.
+func (s *StationService) CacheUsage() ([]cache.CategoryUsage, error) {
+	if s.imageCache == nil {
+		return nil, ErrCacheDisabled
+	}
+	return s.imageCache.Usage()
+}
+
+// PruneCache removes all cached files in a category.
+func (s *StationService) PruneCache(category string) (int, error) {
+	if s.imageCache == nil {
+		return 0, ErrCacheDisabled
+	}
+	if category == cache.CategoryImages || category == cache.CategoryVariants {
+		s.variantsMu.Lock()
+		s.variants = nil
+		s.variantsMu.Unlock()
+	}
+	return s.imageCache.Prune(category)
+}
+
+// SetThrottle reads logos and API responses through l, so they don't
+// compete with the stream on slow links.
+func (s *StationService) SetThrottle(l *throttle.Limiter) {
+	s.apiClient.SetTransport(l.Transport(http.DefaultTransport))
+	s.httpClient = &http.Client{Transport: l.Transport(http.DefaultTransport)}
+}
+
+// SetTitleRewriter sets the rules applied to tracks from the songs API, so
+// they read the same as the corrected stream titles.
+func (s *StationService) SetTitleRewriter(r *retitle.Rewriter) {
+	s.titles = r
+}
+
+func (s *StationService) GetCurrentTrackForStation(ctx context.Context, stationID station.StationID) (string, error) {
+	track, err := s.apiClient.GetCurrentTrackForStation(ctx, stationID)
+	return s.titles.Apply(stationID.String(), track), err
+}
+
+// GetRecentTracksForStation returns up to n recent tracks, newest first.
+func (s *StationService) GetRecentTracksForStation(ctx context.Context, stationID station.StationID, n int) ([]string, error) {
+	tracks, err := s.apiClient.GetRecentTracks(ctx, stationID, n)
+	for i := range tracks {
+		tracks[i] = s.titles.Apply(stationID.String(), tracks[i])
+	}
+	return tracks, err
 }
 
+// StartPeriodicRefresh fetches the channel list every interval and passes
+// it to callback, until StopPeriodicRefresh, which also cancels a fetch in
+// flight.
 func (s *StationService) StartPeriodicRefresh(interval time.Duration, callback func([]station.Station)) {
 	s.StopPeriodicRefresh()
 
+	ctx, cancel := context.WithCancel(context.Background())
 	s.mu.Lock()
 	s.onRefresh = callback
-	s.stopRefresh = make(chan struct{})
+	s.stopRefresh = cancel
 	s.refreshTicker = time.NewTicker(interval)
 	ticker := s.refreshTicker
-	stopCh := s.stopRefresh
 	s.mu.Unlock()
 
 	go func() {
 		for {
 			select {
 			case <-ticker.C:
-				s.refreshStationsInBackground()
-			case <-stopCh:
+				s.refreshStationsInBackground(ctx)
+			case <-ctx.Done():
 				ticker.Stop()
 				return
 			}
@@ -210,22 +387,27 @@ func (s *StationService) StopPeriodicRefresh() {
 	defer s.mu.Unlock()
 
 	if s.stopRefresh != nil {
-		close(s.stopRefresh)
+		s.stopRefresh()
 		s.stopRefresh = nil
 	}
 	log.Debug().Msg("Stopped periodic station refresh")
 }
 
-func (s *StationService) refreshStationsInBackground() {
-	newStations, err := s.apiClient.GetStations()
+func (s *StationService) refreshStationsInBackground(ctx context.Context) {
+	newStations, err := s.apiClient.GetStations(ctx)
+	if ctx.Err() != nil {
+		log.Debug().Msg("Background refresh canceled")
+		return
+	}
 	if err != nil {
 		log.Warn().Err(err).Msg("Background refresh failed, keeping cached data")
 		return
 	}
 
-	s.sortStationsByListeners(newStations)
-
 	s.mu.Lock()
+	newStations = s.keepPinned(newStations)
+	s.applyWatched(newStations)
+	s.sortStations(newStations)
 	s.stations = newStations
 	callback := s.onRefresh
 	s.mu.Unlock()
@@ -236,3 +418,35 @@ func (s *StationService) refreshStationsInBackground() {
 
 	log.Debug().Int("count", len(newStations)).Msg("Station data refreshed in background")
 }
+
+// Pin keeps the station with id in the list when a refresh drops it from
+// the channel list, marked Retired, so the station playing keeps its row
+// and index. One station is pinned at a time; an empty id unpins.
+func (s *StationService) Pin(id station.StationID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pinned = id
+}
+
+// keepPinned appends the pinned station to stations, the fresh channel
+// list, if the list no longer has it. Callers must hold s.mu.
+func (s *StationService) keepPinned(stations []station.Station) []station.Station {
+	if s.pinned == "" {
+		return stations
+	}
+	for _, st := range stations {
+		if st.ID == s.pinned {
+			return stations
+		}
+	}
+	for _, st := range s.stations {
+		if st.ID == s.pinned {
+			if !st.Retired {
+				log.Info().Str("station", st.ID.String()).Msg("Playing station was removed from the channel list")
+			}
+			st.Retired = true
+			return append(stations, st)
+		}
+	}
+	return stations
+}
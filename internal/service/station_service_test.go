@@ -1,14 +1,19 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"image"
 	"image/color"
 	"image/png"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/glebovdev/somafm-cli/internal/api"
 	"github.com/glebovdev/somafm-cli/internal/cache"
 	"github.com/glebovdev/somafm-cli/internal/station"
 )
@@ -82,15 +87,50 @@ func TestSortStationsByListeners(t *testing.T) {
 			stations := make([]station.Station, len(tt.stations))
 			copy(stations, tt.stations)
 
-			service.sortStationsByListeners(stations)
+			service.sortStations(stations)
 
 			if len(stations) != len(tt.expected) {
-				t.Fatalf("sortStationsByListeners resulted in %d stations, want %d",
+				t.Fatalf("sortStations resulted in %d stations, want %d",
 					len(stations), len(tt.expected))
 			}
 
 			for i, st := range stations {
-				if st.ID != tt.expected[i] {
+				if st.ID.String() != tt.expected[i] {
+					t.Errorf("stations[%d].ID = %q, want %q", i, st.ID, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestStationOrders(t *testing.T) {
+	stations := []station.Station{
+		{ID: "lush", Title: "Lush", Genre: "electronica", Listeners: "300"},
+		{ID: "dronezone", Title: "Drone Zone", Genre: "ambient", Listeners: "800"},
+		{ID: "bagel", Title: "BAGeL Radio", Genre: "alternative", Listeners: "100"},
+		{ID: "groovesalad", Title: "Groove Salad", Genre: "ambient", Listeners: "1200"},
+	}
+	favorites := map[station.StationID]bool{"lush": true, "bagel": true}
+
+	tests := []struct {
+		name     string
+		order    Comparator
+		expected []string
+	}{
+		{"default", nil, []string{"groovesalad", "dronezone", "lush", "bagel"}},
+		{"title", ByTitle, []string{"bagel", "dronezone", "groovesalad", "lush"}},
+		{"genre", ByGenre, []string{"bagel", "groovesalad", "dronezone", "lush"}},
+		{"favorites first", FavoritesFirst(func(id station.StationID) bool { return favorites[id] }),
+			[]string{"lush", "bagel", "groovesalad", "dronezone"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &StationService{stations: append([]station.Station(nil), stations...)}
+			service.SetOrder(tt.order)
+
+			for i, st := range service.GetCachedStations() {
+				if st.ID.String() != tt.expected[i] {
 					t.Errorf("stations[%d].ID = %q, want %q", i, st.ID, tt.expected[i])
 				}
 			}
@@ -113,7 +153,7 @@ func TestGetValidStationIDs(t *testing.T) {
 		t.Fatalf("GetValidStationIDs() returned %d IDs, want 3", len(validIDs))
 	}
 
-	expectedIDs := []string{"groovesalad", "dronezone", "lush"}
+	expectedIDs := []station.StationID{"groovesalad", "dronezone", "lush"}
 	for _, id := range expectedIDs {
 		if !validIDs[id] {
 			t.Errorf("GetValidStationIDs() missing %q", id)
@@ -148,7 +188,7 @@ func TestFindIndexByID(t *testing.T) {
 
 	tests := []struct {
 		name     string
-		id       string
+		id       station.StationID
 		expected int
 	}{
 		{"first station", "groovesalad", 0},
@@ -214,7 +254,7 @@ func TestGetStation(t *testing.T) {
 				}
 			} else if result == nil {
 				t.Fatalf("GetStation(%d) = nil, want station", tt.index)
-			} else if result.ID != tt.expectedID {
+			} else if result.ID.String() != tt.expectedID {
 				t.Errorf("GetStation(%d).ID = %q, want %q", tt.index, result.ID, tt.expectedID)
 			}
 		})
@@ -309,7 +349,7 @@ func TestGetCachedStationsEmpty(t *testing.T) {
 }
 
 func TestNewStationService(t *testing.T) {
-	service := NewStationService(nil)
+	service := NewStationService(nil, CacheNormal)
 
 	if service == nil {
 		t.Fatal("NewStationService() returned nil")
@@ -321,6 +361,14 @@ func TestNewStationService(t *testing.T) {
 	}
 }
 
+func TestNewStationServiceCacheDisabled(t *testing.T) {
+	service := NewStationService(nil, CacheDisabled)
+
+	if service.imageCache != nil {
+		t.Error("NewStationService() with CacheDisabled should not use the image cache")
+	}
+}
+
 func TestLoadImage(t *testing.T) {
 	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
 	for y := 0; y < 100; y++ {
@@ -450,7 +498,201 @@ func TestStartAndStopPeriodicRefresh(t *testing.T) {
 	service.StopPeriodicRefresh()
 }
 
+func TestStopPeriodicRefreshCancelsFetch(t *testing.T) {
+	requested := make(chan struct{}, 1)
+	canceled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested <- struct{}{}
+		<-r.Context().Done()
+		close(canceled)
+	}))
+	defer server.Close()
+
+	service := &StationService{apiClient: api.NewSomaFMClientWithBaseURL(server.URL)}
+	service.StartPeriodicRefresh(10*time.Millisecond, func([]station.Station) {
+		t.Error("callback called for a canceled refresh")
+	})
+	<-requested
+	service.StopPeriodicRefresh()
+
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("fetch still running after StopPeriodicRefresh()")
+	}
+}
+
 func TestStopPeriodicRefreshBeforeStart(t *testing.T) {
 	service := &StationService{}
 	service.StopPeriodicRefresh()
 }
+
+func TestScaleToFit(t *testing.T) {
+	tests := []struct {
+		name             string
+		width, height    int
+		maxW, maxH       int
+		expectW, expectH int
+	}{
+		{"square into wide box", 512, 512, 208, 192, 192, 192},
+		{"landscape", 400, 200, 100, 100, 100, 50},
+		{"already fits", 50, 40, 208, 192, 50, 40},
+		{"tiny target", 300, 300, 1, 1, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rect(0, 0, tt.width, tt.height))
+			scaled := scaleToFit(img, tt.maxW, tt.maxH)
+			b := scaled.Bounds()
+			if b.Dx() != tt.expectW || b.Dy() != tt.expectH {
+				t.Errorf("scaleToFit() size = %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.expectW, tt.expectH)
+			}
+		})
+	}
+}
+
+func TestScaleToFitAveragesColors(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	img.Set(1, 0, color.RGBA{R: 255, A: 255})
+	img.Set(0, 1, color.RGBA{A: 255})
+	img.Set(1, 1, color.RGBA{A: 255})
+
+	scaled := scaleToFit(img, 1, 1)
+	r, _, _, a := scaled.At(0, 0).RGBA()
+	if r>>8 != 127 || a>>8 != 255 {
+		t.Errorf("scaleToFit() pixel = r:%d a:%d, want r:127 a:255", r>>8, a>>8)
+	}
+}
+
+func TestLoadImageVariant(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 512, 512))
+
+	requestCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requestCount++
+		w.Header().Set("Content-Type", "image/png")
+		_ = png.Encode(w, img)
+	}))
+	defer server.Close()
+
+	service := &StationService{}
+	testURL := server.URL + "/variant.png"
+
+	variant, err := service.LoadImageVariant(testURL, 26, 12)
+	if err != nil {
+		t.Fatalf("LoadImageVariant() error = %v", err)
+	}
+	if b := variant.Bounds(); b.Dx() != 192 || b.Dy() != 192 {
+		t.Errorf("LoadImageVariant() size = %dx%d, want 192x192", b.Dx(), b.Dy())
+	}
+
+	if _, err := service.LoadImageVariant(testURL, 26, 12); err != nil {
+		t.Fatalf("Second LoadImageVariant() error = %v", err)
+	}
+	if requestCount != 1 {
+		t.Errorf("Expected 1 HTTP request for repeated variant, got %d", requestCount)
+	}
+
+	pixels, err := service.LoadImagePixels(testURL, 260, 264)
+	if err != nil {
+		t.Fatalf("LoadImagePixels() error = %v", err)
+	}
+	if b := pixels.Bounds(); b.Dx() != 260 || b.Dy() != 260 {
+		t.Errorf("LoadImagePixels() size = %dx%d, want 260x260", b.Dx(), b.Dy())
+	}
+
+	original, err := service.LoadImage(testURL)
+	if err != nil {
+		t.Fatalf("LoadImage() error = %v", err)
+	}
+	if b := original.Bounds(); b.Dx() != 512 {
+		t.Errorf("LoadImage() width = %d, want full resolution 512", b.Dx())
+	}
+}
+
+func TestCacheOperationsWhenDisabled(t *testing.T) {
+	service := &StationService{}
+
+	if _, err := service.CacheUsage(); !errors.Is(err, ErrCacheDisabled) {
+		t.Errorf("CacheUsage() error = %v, want ErrCacheDisabled", err)
+	}
+	if _, err := service.PruneCache(cache.CategoryImages); !errors.Is(err, ErrCacheDisabled) {
+		t.Errorf("PruneCache() error = %v, want ErrCacheDisabled", err)
+	}
+	if _, err := service.CacheDir(); !errors.Is(err, ErrCacheDisabled) {
+		t.Errorf("CacheDir() error = %v, want ErrCacheDisabled", err)
+	}
+}
+
+func TestPollWatched(t *testing.T) {
+	tracks := map[string]string{"groovesalad": "Bonobo", "dronezone": "Stars of the Lid"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/songs/"), ".json")
+		fmt.Fprintf(w, `{"id": %q, "songs": [{"artist": %q, "title": "Live"}]}`, id, tracks[id])
+	}))
+	defer server.Close()
+
+	service := &StationService{
+		apiClient: api.NewSomaFMClientWithBaseURL(server.URL),
+		stations: []station.Station{
+			{ID: "groovesalad", LastPlaying: "Old - Track"},
+			{ID: "dronezone", LastPlaying: "Stars of the Lid - Live"},
+			{ID: "defcon", LastPlaying: "Unwatched - Track"},
+		},
+	}
+
+	changed := service.pollWatched(context.Background(), []station.StationID{"groovesalad", "dronezone"})
+	if len(changed) != 1 || changed[0] != "groovesalad" {
+		t.Errorf("pollWatched() changed = %v, want [groovesalad]", changed)
+	}
+	if got := service.GetStation(0).LastPlaying; got != "Bonobo - Live" {
+		t.Errorf("LastPlaying = %q, want the polled track", got)
+	}
+
+	// A refresh from the lagging channel list keeps the polled track
+	refreshed := []station.Station{
+		{ID: "groovesalad", LastPlaying: "Old - Track"},
+		{ID: "defcon", LastPlaying: "Newer - Track"},
+	}
+	service.applyWatched(refreshed)
+	if refreshed[0].LastPlaying != "Bonobo - Live" || refreshed[1].LastPlaying != "Newer - Track" {
+		t.Errorf("applyWatched() = %+v", refreshed)
+	}
+}
+
+func TestRefreshKeepsPinnedStation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"channels": [{"id": "dronezone", "title": "Drone Zone", "listeners": "50"}]}`))
+	}))
+	defer server.Close()
+
+	service := &StationService{
+		apiClient: api.NewSomaFMClientWithBaseURL(server.URL),
+		stations: []station.Station{
+			{ID: "groovesalad", Title: "Groove Salad", Listeners: "100"},
+			{ID: "secretagent", Title: "Secret Agent", Listeners: "80"},
+			{ID: "dronezone", Title: "Drone Zone", Listeners: "50"},
+		},
+	}
+	service.Pin("groovesalad")
+	service.refreshStationsInBackground(context.Background())
+
+	if got := service.StationCount(); got != 2 {
+		t.Fatalf("StationCount() = %d, want the new list plus the pinned station", got)
+	}
+	pinned := service.GetStation(service.FindIndexByID("groovesalad"))
+	if pinned == nil || !pinned.Retired {
+		t.Errorf("pinned station = %+v, want it kept and retired", pinned)
+	}
+	if service.FindIndexByID("secretagent") >= 0 {
+		t.Error("unpinned station kept after it was dropped")
+	}
+
+	service.Pin("dronezone")
+	service.refreshStationsInBackground(context.Background())
+	if got := service.StationCount(); got != 1 {
+		t.Errorf("StationCount() = %d after unpinning the retired station, want 1", got)
+	}
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/rs/zerolog/log"
+)
+
+// StartWatching polls the songs API every interval for the stations ids
+// returns, keeping their LastPlaying fresher than the channel list. It
+// calls onChange with each station whose track changed. No audio streams
+// are opened. StopWatching also cancels the requests of a poll in flight.
+func (s *StationService) StartWatching(interval time.Duration, ids func() []station.StationID, onChange func(station.StationID)) {
+	s.StopWatching()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	s.stopWatch = cancel
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			for _, id := range s.pollWatched(ctx, ids()) {
+				onChange(id)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	log.Debug().Dur("interval", interval).Msg("Started watching stations")
+}
+
+// StopWatching stops polling started by StartWatching.
+func (s *StationService) StopWatching() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopWatch != nil {
+		s.stopWatch()
+		s.stopWatch = nil
+	}
+}
+
+// pollWatched fetches the current track of each station in ids and returns
+// the ones whose track changed. Stations no longer in ids go back to the
+// channel list's track on the next refresh.
+func (s *StationService) pollWatched(ctx context.Context, ids []station.StationID) []station.StationID {
+	tracks := make(map[station.StationID]string, len(ids))
+	for _, id := range ids {
+		track, err := s.GetCurrentTrackForStation(ctx, id)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			log.Debug().Err(err).Str("station", id.String()).Msg("Failed to poll watched station")
+			continue
+		}
+		if track != "" {
+			tracks[id] = track
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watched = tracks
+	var changed []station.StationID
+	for i := range s.stations {
+		if track, ok := tracks[s.stations[i].ID]; ok && track != s.stations[i].LastPlaying {
+			s.stations[i].LastPlaying = track
+			changed = append(changed, s.stations[i].ID)
+		}
+	}
+	return changed
+}
+
+// applyWatched keeps the polled tracks of watched stations when the
+// channel list, which lags behind them, is fetched again.
+func (s *StationService) applyWatched(stations []station.Station) {
+	for i := range stations {
+		if track, ok := s.watched[stations[i].ID]; ok {
+			stations[i].LastPlaying = track
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package session tracks whether the previous run exited cleanly.
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+)
+
+// markerGlob matches the markers of all instances, and the single
+// session.lock of earlier versions.
+const markerGlob = "session*.lock"
+
+// MarkerFileName returns the name of the marker the process pid creates on
+// startup and removes on a clean exit. Each instance has its own, so one
+// exiting doesn't hide a later crash of another.
+func MarkerFileName(pid int) string {
+	return fmt.Sprintf("session-%d.lock", pid)
+}
+
+// Marker represents the running-session marker file.
+type Marker struct {
+	path string
+}
+
+// Status describes what was found when the session started.
+type Status int
+
+const (
+	// StatusClean means the previous run exited normally.
+	StatusClean Status = iota
+	// StatusCrashed means a marker was left behind by a process that is gone.
+	StatusCrashed
+	// StatusRunning means another instance is running.
+	StatusRunning
+)
+
+// Begin inspects the markers in dir and then writes a fresh one for the
+// current process. Markers of processes that are gone are removed once
+// reported. A crash is reported over another instance running.
+func Begin(dir string) (*Marker, Status, error) {
+	status := StatusClean
+
+	paths, _ := filepath.Glob(filepath.Join(dir, markerGlob))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+		if pid > 0 && pid != os.Getpid() && processAlive(pid) {
+			if status == StatusClean {
+				status = StatusRunning
+			}
+			log.Debug().Int("pid", pid).Msg("Found the session marker of a running instance")
+			continue
+		}
+		status = StatusCrashed
+		log.Debug().Int("pid", pid).Msg("Found a stale session marker")
+		if err := os.Remove(path); err != nil {
+			log.Debug().Err(err).Msg("Failed to remove stale session marker")
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, status, fmt.Errorf("failed to create session directory: %w", err)
+	}
+	path := filepath.Join(dir, MarkerFileName(os.Getpid()))
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		return nil, status, fmt.Errorf("failed to write session marker: %w", err)
+	}
+
+	return &Marker{path: path}, status, nil
+}
+
+// End removes the marker, recording a clean exit. Safe to call on nil.
+func (m *Marker) End() {
+	if m == nil {
+		return
+	}
+	if err := os.Remove(m.path); err != nil && !os.IsNotExist(err) {
+		log.Debug().Err(err).Msg("Failed to remove session marker")
+	}
+}
+
+func processAlive(pid int) bool {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// On Windows FindProcess only succeeds for running processes
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	return proc.Signal(syscall.Signal(0)) == nil
+}
@@ -0,0 +1,105 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestBeginClean(t *testing.T) {
+	dir := t.TempDir()
+
+	marker, status, err := Begin(dir)
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if status != StatusClean {
+		t.Errorf("Begin() status = %v, want StatusClean", status)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, MarkerFileName(os.Getpid()))); err != nil {
+		t.Errorf("marker file not created: %v", err)
+	}
+
+	marker.End()
+	if _, err := os.Stat(filepath.Join(dir, MarkerFileName(os.Getpid()))); !os.IsNotExist(err) {
+		t.Error("End() should remove the marker file")
+	}
+
+	_, status, _ = Begin(dir)
+	if status != StatusClean {
+		t.Errorf("Begin() after clean exit status = %v, want StatusClean", status)
+	}
+}
+
+func TestBeginAfterCrash(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"own pid", strconv.Itoa(os.Getpid())},
+		{"garbage", "not a pid"},
+		{"empty", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			// The single marker of earlier versions counts too
+			_ = os.WriteFile(filepath.Join(dir, "session.lock"), []byte(tt.content), 0644)
+
+			_, status, err := Begin(dir)
+			if err != nil {
+				t.Fatalf("Begin() error = %v", err)
+			}
+			if status != StatusCrashed {
+				t.Errorf("Begin() status = %v, want StatusCrashed", status)
+			}
+		})
+	}
+}
+
+func TestBeginWhileRunning(t *testing.T) {
+	dir := t.TempDir()
+	// The parent process (go test runner) is alive for the duration of the test
+	other := filepath.Join(dir, MarkerFileName(os.Getppid()))
+	_ = os.WriteFile(other, []byte(strconv.Itoa(os.Getppid())), 0644)
+
+	marker, status, err := Begin(dir)
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if status != StatusRunning {
+		t.Errorf("Begin() status = %v, want StatusRunning", status)
+	}
+
+	// Exiting leaves the other instance's marker, so its crash still shows
+	marker.End()
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("End() removed the other instance's marker: %v", err)
+	}
+}
+
+func TestBeginReportsCrashOverRunning(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, MarkerFileName(os.Getppid())), []byte(strconv.Itoa(os.Getppid())), 0644)
+	stale := filepath.Join(dir, MarkerFileName(0))
+	_ = os.WriteFile(stale, []byte("0"), 0644)
+
+	_, status, err := Begin(dir)
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if status != StatusCrashed {
+		t.Errorf("Begin() status = %v, want StatusCrashed", status)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("Begin() kept the stale marker, so the crash would be reported again")
+	}
+}
+
+func TestEndNilMarker(t *testing.T) {
+	var m *Marker
+	m.End()
+}
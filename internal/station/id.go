@@ -0,0 +1,49 @@
+package station
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StationID identifies a station, such as "groovesalad". IDs are compared
+// after normalization, so "GrooveSalad" and " groovesalad " are the same
+// station.
+type StationID string
+
+// NewStationID returns s trimmed and lowercased. It does not validate.
+func NewStationID(s string) StationID {
+	return StationID(strings.ToLower(strings.TrimSpace(s)))
+}
+
+// ParseStationID normalizes s and checks that it is a valid station ID.
+func ParseStationID(s string) (StationID, error) {
+	id := NewStationID(s)
+	if err := id.Validate(); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Validate reports whether id is non-empty and made of lowercase letters,
+// digits, '-' and '_' only, which keeps it safe to use in API paths.
+func (id StationID) Validate() error {
+	if id == "" {
+		return fmt.Errorf("empty station ID")
+	}
+	for _, c := range id {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' && c != '_' {
+			return fmt.Errorf("invalid station ID %q", string(id))
+		}
+	}
+	return nil
+}
+
+func (id StationID) String() string {
+	return string(id)
+}
+
+// UnmarshalText normalizes IDs read from JSON and YAML.
+func (id *StationID) UnmarshalText(text []byte) error {
+	*id = NewStationID(string(text))
+	return nil
+}
@@ -1,6 +1,8 @@
 // Package station defines the data structures for SomaFM radio stations.
 package station
 
+import "sort"
+
 // Playlist represents a streaming endpoint for a radio station.
 type Playlist struct {
 	URL     string `json:"url"`
@@ -10,7 +12,7 @@ type Playlist struct {
 
 // Station represents a SomaFM radio station with its metadata and streaming options.
 type Station struct {
-	ID          string     `json:"id"`
+	ID          StationID  `json:"id"`
 	Title       string     `json:"title"`
 	Description string     `json:"description"`
 	DJ          string     `json:"dj"`
@@ -25,6 +27,30 @@ type Station struct {
 	Preroll     []string   `json:"preroll"`
 	Listeners   string     `json:"listeners"`
 	LastPlaying string     `json:"lastPlaying"`
+	// Retired is set on a station the channel list dropped while it was
+	// playing, which the service keeps until playback moves on.
+	Retired bool `json:"-"`
+}
+
+// Variant names the playlist by format and quality, such as "aac-high".
+// It identifies a stream choice across playlist URL changes.
+func (p Playlist) Variant() string {
+	return p.Format + "-" + p.Quality
+}
+
+// PageURL returns the station's page on the SomaFM website.
+func (s *Station) PageURL() string {
+	return "https://somafm.com/" + s.ID.String() + "/"
+}
+
+// PlaylistByVariant returns the first playlist with the given variant.
+func (s *Station) PlaylistByVariant(variant string) (Playlist, bool) {
+	for _, playlist := range s.Playlists {
+		if playlist.Variant() == variant {
+			return playlist, true
+		}
+	}
+	return Playlist{}, false
 }
 
 // GetBestPlaylistURL returns the URL of the highest quality MP3 playlist.
@@ -44,24 +70,66 @@ func (s *Station) GetBestPlaylistURL() string {
 // GetAllPlaylistURLs returns all playlist URLs sorted by preference:
 // MP3 highest quality first, then other MP3, then other formats.
 func (s *Station) GetAllPlaylistURLs() []string {
-	var mp3Highest, mp3Other, other []string
+	return playlistURLs(s.sortedPlaylists())
+}
+
+func (s *Station) sortedPlaylists() []Playlist {
+	var mp3Highest, mp3Other, other []Playlist
 
 	for _, playlist := range s.Playlists {
 		if playlist.Format == "mp3" {
 			if playlist.Quality == "highest" {
-				mp3Highest = append(mp3Highest, playlist.URL)
+				mp3Highest = append(mp3Highest, playlist)
 			} else {
-				mp3Other = append(mp3Other, playlist.URL)
+				mp3Other = append(mp3Other, playlist)
 			}
 		} else {
-			other = append(other, playlist.URL)
+			other = append(other, playlist)
 		}
 	}
 
-	result := make([]string, 0, len(s.Playlists))
+	result := make([]Playlist, 0, len(s.Playlists))
 	result = append(result, mp3Highest...)
 	result = append(result, mp3Other...)
 	result = append(result, other...)
 
 	return result
 }
+
+// PlaylistPreference picks which playlists are tried first. Quality is
+// "highest", "high" or "low"; Format is "mp3" or "aac", which also matches
+// SomaFM's "aacp" streams. Empty fields express no preference.
+type PlaylistPreference struct {
+	Quality string
+	Format  string
+}
+
+func (pref PlaylistPreference) score(p Playlist) int {
+	score := 0
+	if pref.Format != "" && (p.Format == pref.Format || pref.Format == "aac" && p.Format == "aacp") {
+		score += 2
+	}
+	if pref.Quality != "" && p.Quality == pref.Quality {
+		score++
+	}
+	return score
+}
+
+// PreferredPlaylistURLs returns all playlist URLs with those matching pref
+// first: both format and quality, then format only, then quality only.
+// Ties keep the GetAllPlaylistURLs order, so a zero pref changes nothing.
+func (s *Station) PreferredPlaylistURLs(pref PlaylistPreference) []string {
+	playlists := s.sortedPlaylists()
+	sort.SliceStable(playlists, func(i, j int) bool {
+		return pref.score(playlists[i]) > pref.score(playlists[j])
+	})
+	return playlistURLs(playlists)
+}
+
+func playlistURLs(playlists []Playlist) []string {
+	urls := make([]string, len(playlists))
+	for i, playlist := range playlists {
+		urls[i] = playlist.URL
+	}
+	return urls
+}
@@ -1,6 +1,7 @@
 package station
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -184,3 +185,59 @@ func TestStationFields(t *testing.T) {
 		t.Errorf("Station.Listeners = %q, want %q", station.Listeners, "1234")
 	}
 }
+
+func TestParseStationID(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    StationID
+		wantErr bool
+	}{
+		{"groovesalad", "groovesalad", false},
+		{" GrooveSalad\n", "groovesalad", false},
+		{"sf1033", "sf1033", false},
+		{"", "", true},
+		{"   ", "", true},
+		{"../channels", "", true},
+		{"groove salad", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseStationID(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseStationID(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseStationID(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPreferredPlaylistURLs(t *testing.T) {
+	s := Station{Playlists: []Playlist{
+		{URL: "aac-highest", Format: "aac", Quality: "highest"},
+		{URL: "aacp-high", Format: "aacp", Quality: "high"},
+		{URL: "mp3-high", Format: "mp3", Quality: "high"},
+		{URL: "mp3-highest", Format: "mp3", Quality: "highest"},
+		{URL: "aacp-low", Format: "aacp", Quality: "low"},
+	}}
+
+	tests := []struct {
+		name string
+		pref PlaylistPreference
+		want []string
+	}{
+		{"no preference", PlaylistPreference{}, s.GetAllPlaylistURLs()},
+		{"low", PlaylistPreference{Quality: "low"}, []string{"aacp-low", "mp3-highest", "mp3-high", "aac-highest", "aacp-high"}},
+		{"aac high", PlaylistPreference{Quality: "high", Format: "aac"}, []string{"aacp-high", "aac-highest", "aacp-low", "mp3-high", "mp3-highest"}},
+		{"mp3", PlaylistPreference{Format: "mp3"}, []string{"mp3-highest", "mp3-high", "aac-highest", "aacp-high", "aacp-low"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.PreferredPlaylistURLs(tt.pref)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("PreferredPlaylistURLs(%+v) = %v, want %v", tt.pref, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !sqlite
+
+package storage
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// SQLiteSupported reports whether this build includes the SQLite driver.
+const SQLiteSupported = false
+
+// ErrNoSQLite is returned by OpenSQLite in builds without the sqlite tag.
+var ErrNoSQLite = errors.New("SQLite storage is not available in this build, rebuild with -tags sqlite")
+
+// OpenSQLite always fails; build with -tags sqlite to enable it.
+func OpenSQLite(path string) (*sql.DB, error) {
+	return nil, ErrNoSQLite
+}
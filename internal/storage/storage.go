@@ -0,0 +1,136 @@
+// Package storage holds the persistence helpers shared by the listening
+// history and likes: append-only JSON Lines files and an optional SQLite
+// database.
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// DBFileName is the SQLite database inside the config directory.
+const DBFileName = "somafm.db"
+
+// DefaultDBPath returns the SQLite database path next to the config file.
+func DefaultDBPath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, DBFileName), nil
+}
+
+// Open returns the database for backend, or nil for the flat-file backend.
+func Open(backend string) (*sql.DB, error) {
+	if backend != config.StorageSQLite {
+		return nil, nil
+	}
+	path, err := DefaultDBPath()
+	if err != nil {
+		return nil, err
+	}
+	return OpenSQLite(path)
+}
+
+// ReadJSONL decodes one value per line of the file at path. A missing file
+// yields no values. Blank lines are skipped, and so are lines that don't
+// decode, such as one cut short by a crash mid-append, with a warning.
+func ReadJSONL[T any](path string) ([]T, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var items []T
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var item T
+		if err := json.Unmarshal(data, &item); err != nil {
+			log.Warn().Err(err).Msgf("Skipping line %d of %s", line, path)
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, scanner.Err()
+}
+
+// AppendJSONL writes v as one line at the end of the file at path, creating
+// the file and its directory as needed.
+func AppendJSONL(path string, v any) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	// Start a new line after one left unfinished by a crash, so this one
+	// doesn't get skipped along with it
+	data := buf.Bytes()
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			data = append([]byte{'\n'}, data...)
+		}
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteJSONL replaces the file at path with items, one per line, using a
+// temp file and rename so a crash never leaves it half written.
+func WriteJSONL[T any](path string, items []T) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	for _, item := range items {
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmpFile.Write(buf.Bytes()); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadJSONLSkipsTruncatedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plays.jsonl")
+	data := "{\"n\":1}\n\n{\"n\":2}\n{\"n\":3"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := ReadJSONL[struct{ N int }](path)
+	if err != nil {
+		t.Fatalf("ReadJSONL() error = %v", err)
+	}
+	if len(items) != 2 || items[0].N != 1 || items[1].N != 2 {
+		t.Errorf("ReadJSONL() = %v, want the two complete lines", items)
+	}
+
+	if err := AppendJSONL(path, struct{ N int }{4}); err != nil {
+		t.Fatalf("AppendJSONL() error = %v", err)
+	}
+	items, _ = ReadJSONL[struct{ N int }](path)
+	if len(items) != 3 || items[2].N != 4 {
+		t.Errorf("ReadJSONL() after an append = %v, want the appended line kept", items)
+	}
+}
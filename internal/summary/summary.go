@@ -0,0 +1,165 @@
+// Package summary writes a listening session up as Markdown: the
+// stations listened to and for how long, and the tracks liked on the way,
+// for sharing discoveries or keeping a journal.
+package summary
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/format"
+	"github.com/glebovdev/somafm-cli/internal/history"
+	"github.com/glebovdev/somafm-cli/internal/likes"
+)
+
+// Gap is how long a break in listening has to be to end a session.
+const Gap = 30 * time.Minute
+
+// Session is one stretch of listening without a break of Gap or more.
+type Session struct {
+	Start    time.Time         `json:"start"`
+	End      time.Time         `json:"end"`
+	Tracks   int               `json:"tracks"`
+	Stations []StationTime     `json:"stations"`
+	Liked    []likes.Track     `json:"liked"`
+	Notes    []history.Entry   `json:"notes,omitempty"`
+	titles   map[string]string // Station titles by ID, for liked tracks
+}
+
+// StationTime is how long one station was listened to in a session.
+type StationTime struct {
+	Station string        `json:"station"`
+	Title   string        `json:"title"`
+	Heard   time.Duration `json:"heard"`
+	Tracks  int           `json:"tracks"`
+}
+
+// Last returns the most recent session in entries, which must be in
+// chronological order, with the tracks liked during it. Likes up to gap
+// after the last entry count too: they may be of a track still playing,
+// which isn't in the history yet. It reports false when there is no
+// history.
+func Last(entries []history.Entry, liked []likes.Track, gap time.Duration) (Session, bool) {
+	var spans []history.Span
+	for _, day := range history.Days(entries, time.UTC) {
+		spans = append(spans, day.Spans...)
+	}
+	if len(spans) == 0 {
+		return Session{}, false
+	}
+
+	first := len(spans) - 1
+	for first > 0 && spans[first].Start.Sub(spans[first-1].End) < gap {
+		first--
+	}
+	spans = spans[first:]
+
+	s := Session{
+		Start:  spans[0].Start,
+		End:    spans[len(spans)-1].End,
+		Tracks: len(spans),
+		titles: make(map[string]string),
+	}
+	byStation := make(map[string]*StationTime)
+	for _, span := range spans {
+		st, ok := byStation[span.Station]
+		if !ok {
+			st = &StationTime{Station: span.Station, Title: span.StationTitle}
+			byStation[span.Station] = st
+			s.titles[span.Station] = span.StationTitle
+		}
+		st.Heard += max(span.End.Sub(span.Start)-span.Paused, 0)
+		st.Tracks++
+		if span.Note != "" {
+			s.Notes = append(s.Notes, span.Entry)
+		}
+	}
+	for _, st := range byStation {
+		s.Stations = append(s.Stations, *st)
+	}
+	sort.Slice(s.Stations, func(i, j int) bool {
+		if s.Stations[i].Heard != s.Stations[j].Heard {
+			return s.Stations[i].Heard > s.Stations[j].Heard
+		}
+		return s.Stations[i].Title < s.Stations[j].Title
+	})
+
+	for _, t := range liked {
+		if !t.LikedAt.Before(s.Start) && !t.LikedAt.After(s.End.Add(gap)) {
+			s.Liked = append(s.Liked, t)
+		}
+	}
+	sort.SliceStable(s.Liked, func(i, j int) bool {
+		return s.Liked[i].LikedAt.Before(s.Liked[j].LikedAt)
+	})
+	return s, true
+}
+
+// Markdown writes s as a Markdown document, with times in loc.
+func Markdown(w io.Writer, s Session, loc *time.Location) error {
+	var b strings.Builder
+	start, end := s.Start.In(loc), s.End.In(loc)
+	fmt.Fprintf(&b, "# Listening session, %s\n\n", start.Format("Mon Jan 2, 2006"))
+	until := end.Format("15:04")
+	if end.YearDay() != start.YearDay() || end.Year() != start.Year() {
+		until = end.Format("Jan 2 15:04")
+	}
+	fmt.Fprintf(&b, "%s–%s · %s · %s\n", start.Format("15:04"), until,
+		format.Duration(s.End.Sub(s.Start)), plural(s.Tracks, "track"))
+
+	b.WriteString("\n## Stations\n\n")
+	b.WriteString("| Station | Listened | Tracks |\n")
+	b.WriteString("| --- | ---: | ---: |\n")
+	for _, st := range s.Stations {
+		fmt.Fprintf(&b, "| %s | %s | %d |\n", escape(st.Title), format.Duration(st.Heard), st.Tracks)
+	}
+
+	if len(s.Liked) > 0 {
+		b.WriteString("\n## Liked tracks\n\n")
+		for _, t := range s.Liked {
+			b.WriteString("- ")
+			if t.Artist != "" {
+				fmt.Fprintf(&b, "**%s** – ", escape(t.Artist))
+			}
+			b.WriteString(escape(t.Title))
+			if title := s.titles[t.Station]; title != "" {
+				fmt.Fprintf(&b, " (%s)", escape(title))
+			}
+			if t.Artist != "" {
+				fmt.Fprintf(&b, " · [look up](%s)", likes.LookupURL(t.Artist))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if len(s.Notes) > 0 {
+		b.WriteString("\n## Notes\n\n")
+		for _, e := range s.Notes {
+			fmt.Fprintf(&b, "- %s %s: %s\n", e.Start.In(loc).Format("15:04"), escape(e.Track), escape(e.Note))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// markdownEscaper backslash-escapes the characters that would turn a
+// station or track title into Markdown formatting or break a table.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "|", `\|`, "#", `\#`,
+)
+
+func escape(s string) string {
+	return markdownEscaper.Replace(s)
+}
@@ -0,0 +1,84 @@
+package summary
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/history"
+	"github.com/glebovdev/somafm-cli/internal/likes"
+)
+
+func TestLast(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 6, 1, hour, minute, 0, 0, time.UTC)
+	}
+	entry := func(station, track string, start, end time.Time) history.Entry {
+		title := map[string]string{"groovesalad": "Groove Salad", "dronezone": "Drone Zone"}[station]
+		return history.Entry{Station: station, StationTitle: title, Track: track, Start: start, End: end}
+	}
+	entries := []history.Entry{
+		entry("groovesalad", "Earlier - Session", at(9, 0), at(9, 5)),
+		entry("groovesalad", "Bonobo - Kiara", at(20, 0), at(20, 5)),
+		entry("groovesalad", "Tycho - Awake", at(20, 5), at(20, 12)),
+		entry("dronezone", "Stars of the Lid - Requiem", at(20, 20), at(20, 30)),
+	}
+	entries[2].Note = "the one from that movie"
+	liked := []likes.Track{
+		{Artist: "Earlier", Title: "Session", Station: "groovesalad", LikedAt: at(9, 1)},
+		{Artist: "Stars of the Lid", Title: "Requiem", Station: "dronezone", LikedAt: at(20, 40)},
+		{Artist: "Bonobo", Title: "Kiara", Station: "groovesalad", LikedAt: at(20, 2)},
+	}
+
+	s, ok := Last(entries, liked, Gap)
+	if !ok {
+		t.Fatal("Last() found no session")
+	}
+	if !s.Start.Equal(at(20, 0)) || !s.End.Equal(at(20, 30)) || s.Tracks != 3 {
+		t.Errorf("session %v–%v with %d tracks, want 20:00–20:30 with 3", s.Start, s.End, s.Tracks)
+	}
+	if len(s.Stations) != 2 || s.Stations[0].Station != "groovesalad" || s.Stations[0].Heard != 12*time.Minute {
+		t.Errorf("stations = %+v, want Groove Salad first with 12 min", s.Stations)
+	}
+	if len(s.Liked) != 2 || s.Liked[0].Artist != "Bonobo" {
+		t.Errorf("liked = %+v, want Bonobo, then the like of the track still playing", s.Liked)
+	}
+
+	var b strings.Builder
+	if err := Markdown(&b, s, time.UTC); err != nil {
+		t.Fatal(err)
+	}
+	want := `# Listening session, Sat Jun 1, 2024
+
+20:00–20:30 · 30 min · 3 tracks
+
+## Stations
+
+| Station | Listened | Tracks |
+| --- | ---: | ---: |
+| Groove Salad | 12 min | 2 |
+| Drone Zone | 10 min | 1 |
+
+## Liked tracks
+
+- **Bonobo** – Kiara (Groove Salad) · [look up](https://www.discogs.com/search/?type=artist&q=Bonobo)
+- **Stars of the Lid** – Requiem (Drone Zone) · [look up](https://www.discogs.com/search/?type=artist&q=Stars+of+the+Lid)
+
+## Notes
+
+- 20:05 Tycho - Awake: the one from that movie
+`
+	if got := b.String(); got != want {
+		t.Errorf("Markdown() =\n%s\nwant\n%s", got, want)
+	}
+
+	if _, ok := Last(nil, liked, Gap); ok {
+		t.Error("Last() found a session without history")
+	}
+}
+
+func TestEscape(t *testing.T) {
+	if got, want := escape("Sade | *Live* [Remix] #1"), `Sade \| \*Live\* \[Remix\] \#1`; got != want {
+		t.Errorf("escape() = %q, want %q", got, want)
+	}
+}
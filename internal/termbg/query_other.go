@@ -0,0 +1,11 @@
+//go:build !unix
+
+package termbg
+
+import "time"
+
+// queryBackground is only implemented on Unix; elsewhere COLORFGBG is the
+// only hint.
+func queryBackground(time.Duration) (r, g, b float64, ok bool) {
+	return 0, 0, 0, false
+}
@@ -0,0 +1,54 @@
+//go:build unix
+
+package termbg
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// queryBackground sends OSC 11 followed by a primary device attributes
+// request to the controlling terminal. Every terminal answers the latter,
+// so a terminal without OSC 11 support is recognized without waiting out
+// the timeout.
+func queryBackground(timeout time.Duration) (r, g, b float64, ok bool) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	defer tty.Close()
+
+	fd := int(tty.Fd())
+	if !term.IsTerminal(fd) {
+		return 0, 0, 0, false
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	defer func() { _ = term.Restore(fd, state) }()
+
+	if err := tty.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return 0, 0, 0, false
+	}
+	if _, err := tty.WriteString("\x1b]11;?\x1b\\\x1b[c"); err != nil {
+		return 0, 0, 0, false
+	}
+
+	var reply strings.Builder
+	buf := make([]byte, 64)
+	for {
+		n, err := tty.Read(buf)
+		reply.Write(buf[:n])
+		// The device attributes reply ends in "c" and comes last
+		if s := reply.String(); strings.Contains(s, "\x1b[?") && strings.HasSuffix(s, "c") {
+			return ParseReply(s)
+		}
+		if err != nil {
+			return ParseReply(reply.String())
+		}
+	}
+}
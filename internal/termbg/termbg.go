@@ -0,0 +1,97 @@
+// Package termbg finds out whether the terminal has a light or a dark
+// background, so a readable theme can be picked before anything is drawn.
+package termbg
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Background is the kind of terminal background.
+type Background int
+
+const (
+	Unknown Background = iota
+	Dark
+	Light
+)
+
+func (b Background) String() string {
+	switch b {
+	case Dark:
+		return "dark"
+	case Light:
+		return "light"
+	}
+	return "unknown"
+}
+
+// DefaultTimeout is how long Detect waits for the terminal to answer.
+const DefaultTimeout = 200 * time.Millisecond
+
+// Detect asks the terminal for its background color with an OSC 11 query
+// and, when it doesn't answer, falls back to the COLORFGBG variable some
+// terminals set. It must run before the UI takes over the terminal.
+func Detect(timeout time.Duration) Background {
+	if r, g, b, ok := queryBackground(timeout); ok {
+		return fromRGB(r, g, b)
+	}
+	return FromColorFGBG(os.Getenv("COLORFGBG"))
+}
+
+// fromRGB classifies a color by its relative luminance.
+func fromRGB(r, g, b float64) Background {
+	if 0.2126*r+0.7152*g+0.0722*b > 0.5 {
+		return Light
+	}
+	return Dark
+}
+
+// FromColorFGBG reads a COLORFGBG value such as "15;0" or "0;default;15".
+// The last field is the background's palette index; like Vim, 0-6 and 8
+// count as dark and the rest as light.
+func FromColorFGBG(value string) Background {
+	fields := strings.Split(value, ";")
+	if len(fields) < 2 {
+		return Unknown
+	}
+	bg, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil || bg < 0 || bg > 15 {
+		return Unknown
+	}
+	if bg <= 6 || bg == 8 {
+		return Dark
+	}
+	return Light
+}
+
+// ParseReply reads the color from an OSC 11 reply such as
+// "\x1b]11;rgb:ffff/ffff/ffff\x1b\\". Each channel has one to four hex
+// digits and is returned scaled to 0..1.
+func ParseReply(reply string) (r, g, b float64, ok bool) {
+	_, spec, found := strings.Cut(reply, "]11;rgb:")
+	if !found {
+		return 0, 0, 0, false
+	}
+	if end := strings.IndexAny(spec, "\x07\x1b"); end >= 0 {
+		spec = spec[:end]
+	}
+	parts := strings.Split(spec, "/")
+	if len(parts) != 3 {
+		return 0, 0, 0, false
+	}
+	var rgb [3]float64
+	for i, p := range parts {
+		if len(p) == 0 || len(p) > 4 {
+			return 0, 0, 0, false
+		}
+		v, err := strconv.ParseUint(p, 16, 16)
+		if err != nil {
+			return 0, 0, 0, false
+		}
+		rgb[i] = float64(v) / float64(uint64(1)<<(4*len(p))-1)
+	}
+	return rgb[0], rgb[1], rgb[2], true
+}
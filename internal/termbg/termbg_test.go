@@ -0,0 +1,50 @@
+package termbg
+
+import "testing"
+
+func TestParseReply(t *testing.T) {
+	tests := []struct {
+		reply string
+		want  Background
+		ok    bool
+	}{
+		{"\x1b]11;rgb:ffff/ffff/ffff\x1b\\\x1b[?62;22c", Light, true},
+		{"\x1b]11;rgb:1a1a/1b1b/2525\x07", Dark, true},
+		{"\x1b]11;rgb:fd/f6/e3\x1b\\", Light, true},
+		{"\x1b[?1;2c", Unknown, false},
+		{"\x1b]11;rgb:ffff/ffff\x07", Unknown, false},
+		{"\x1b]11;rgb:zz/00/00\x07", Unknown, false},
+	}
+	for _, tt := range tests {
+		r, g, b, ok := ParseReply(tt.reply)
+		if ok != tt.ok {
+			t.Errorf("ParseReply(%q) ok = %v, want %v", tt.reply, ok, tt.ok)
+			continue
+		}
+		if ok && fromRGB(r, g, b) != tt.want {
+			t.Errorf("ParseReply(%q) = %v, want %v", tt.reply, fromRGB(r, g, b), tt.want)
+		}
+	}
+
+	if r, _, _, _ := ParseReply("\x1b]11;rgb:f/0/0\x07"); r != 1 {
+		t.Errorf("one-digit channel f = %v, want 1", r)
+	}
+}
+
+func TestFromColorFGBG(t *testing.T) {
+	tests := map[string]Background{
+		"15;0":         Dark,
+		"0;15":         Light,
+		"0;default;7":  Light,
+		"7;8":          Dark,
+		"":             Unknown,
+		"15;default":   Unknown,
+		"15;99":        Unknown,
+		"not a number": Unknown,
+	}
+	for value, want := range tests {
+		if got := FromColorFGBG(value); got != want {
+			t.Errorf("FromColorFGBG(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package termimg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/png"
+)
+
+// ImageID is the kitty image id of every picture Encode sends, so a new
+// one replaces the last and Clear can remove it.
+const ImageID = 4711
+
+// kittyChunk is the most base64 a single kitty graphics command may carry.
+const kittyChunk = 4096
+
+// Encode returns the escape sequence that draws img at the cursor, one
+// image pixel per screen pixel.
+func Encode(p Protocol, img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	switch p {
+	case Sixel:
+		encodeSixel(&buf, img)
+	case Kitty:
+		if err := encodeKitty(&buf, img); err != nil {
+			return nil, err
+		}
+	case ITerm2:
+		if err := encodeITerm2(&buf, img); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("no graphics protocol %v", p)
+	}
+	return buf.Bytes(), nil
+}
+
+// At wraps seq so it runs with the cursor at column x and row y, counted
+// from zero, and puts the cursor back afterwards, where a screen library
+// expects it.
+func At(x, y int, seq []byte) []byte {
+	out := fmt.Appendf(nil, "\x1b7\x1b[%d;%dH", y+1, x+1)
+	out = append(out, seq...)
+	return append(out, "\x1b8"...)
+}
+
+// Clear returns the escape sequence that removes the last picture Encode
+// drew, or nil if writing over its cells is enough.
+func Clear(p Protocol) []byte {
+	if p != Kitty {
+		return nil
+	}
+	return fmt.Appendf(nil, "\x1b_Ga=d,d=I,i=%d,q=2\x1b\\", ImageID)
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeKitty transmits img as PNG and places it in one go, replacing the
+// image of the same id. C=1 keeps the cursor still.
+func encodeKitty(buf *bytes.Buffer, img image.Image) error {
+	data, err := encodePNG(img)
+	if err != nil {
+		return err
+	}
+	payload := base64.StdEncoding.EncodeToString(data)
+	for first := true; first || payload != ""; first = false {
+		chunk := payload[:min(len(payload), kittyChunk)]
+		payload = payload[len(chunk):]
+		more := 0
+		if payload != "" {
+			more = 1
+		}
+		if first {
+			fmt.Fprintf(buf, "\x1b_Ga=T,f=100,i=%d,q=2,C=1,m=%d;%s\x1b\\", ImageID, more, chunk)
+		} else {
+			fmt.Fprintf(buf, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+	return nil
+}
+
+func encodeITerm2(buf *bytes.Buffer, img image.Image) error {
+	data, err := encodePNG(img)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(buf, "\x1b]1337;File=inline=1;size=%d;preserveAspectRatio=1:%s\x07",
+		len(data), base64.StdEncoding.EncodeToString(data))
+	return nil
+}
+
+// encodeSixel dithers img to the 256 colors of the Plan 9 palette, which
+// every sixel terminal has registers for, and writes it six rows at a
+// time: each color of a band is one pass over the band, with runs
+// compressed.
+func encodeSixel(buf *bytes.Buffer, img image.Image) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	pal := image.NewPaletted(image.Rect(0, 0, width, height), palette.Plan9)
+	draw.FloydSteinberg.Draw(pal, pal.Bounds(), img, bounds.Min)
+
+	fmt.Fprintf(buf, "\x1bP0;1;0q\"1;1;%d;%d", width, height)
+	used := make([]bool, len(pal.Palette))
+	for _, i := range pal.Pix {
+		used[i] = true
+	}
+	for i, c := range pal.Palette {
+		if !used[i] {
+			continue
+		}
+		r, g, b, _ := c.RGBA()
+		fmt.Fprintf(buf, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, b*100/0xffff)
+	}
+
+	row := make([]byte, width)
+	for top := 0; top < height; top += 6 {
+		inBand := make([]bool, len(pal.Palette))
+		for y := top; y < min(top+6, height); y++ {
+			for _, i := range pal.Pix[y*pal.Stride : y*pal.Stride+width] {
+				inBand[i] = true
+			}
+		}
+		first := true
+		for i := range inBand {
+			if !inBand[i] {
+				continue
+			}
+			for x := 0; x < width; x++ {
+				var bits byte
+				for dy := 0; dy < 6 && top+dy < height; dy++ {
+					if pal.Pix[(top+dy)*pal.Stride+x] == uint8(i) {
+						bits |= 1 << dy
+					}
+				}
+				row[x] = '?' + bits
+			}
+			if !first {
+				buf.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(buf, "#%d", i)
+			writeSixelRuns(buf, bytes.TrimRight(row, "?"))
+		}
+		if top+6 < height {
+			buf.WriteByte('-')
+		}
+	}
+	buf.WriteString("\x1b\\")
+}
+
+// writeSixelRuns writes row with runs of four or more repeats as !count.
+func writeSixelRuns(buf *bytes.Buffer, row []byte) {
+	for len(row) > 0 {
+		n := 1
+		for n < len(row) && row[n] == row[0] {
+			n++
+		}
+		if n >= 4 {
+			fmt.Fprintf(buf, "!%d%c", n, row[0])
+		} else {
+			buf.Write(row[:n])
+		}
+		row = row[n:]
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package termimg
+
+import "time"
+
+// query is only implemented on Unix; elsewhere no protocol is detected.
+func query(time.Duration) string {
+	return ""
+}
+
+// CellSize is only implemented on Unix.
+func CellSize() (width, height int, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,65 @@
+//go:build unix
+
+package termimg
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+// query sends queryRequest to the controlling terminal and returns what
+// came back before the device attributes reply or the timeout.
+func query(timeout time.Duration) string {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return ""
+	}
+	defer tty.Close()
+
+	fd := int(tty.Fd())
+	if !term.IsTerminal(fd) {
+		return ""
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = term.Restore(fd, state) }()
+
+	if err := tty.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return ""
+	}
+	if _, err := tty.WriteString(queryRequest); err != nil {
+		return ""
+	}
+
+	var reply strings.Builder
+	buf := make([]byte, 64)
+	for {
+		n, err := tty.Read(buf)
+		reply.Write(buf[:n])
+		if s := reply.String(); deviceAttributes.MatchString(s) || err != nil {
+			return s
+		}
+	}
+}
+
+// CellSize returns the size of a character cell in pixels, or false when
+// the terminal doesn't report its size in pixels.
+func CellSize() (width, height int, ok bool) {
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return 0, 0, false
+	}
+	defer tty.Close()
+
+	ws, err := unix.IoctlGetWinsize(int(tty.Fd()), unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 || ws.Row == 0 || ws.Xpixel == 0 || ws.Ypixel == 0 {
+		return 0, 0, false
+	}
+	return int(ws.Xpixel / ws.Col), int(ws.Ypixel / ws.Row), true
+}
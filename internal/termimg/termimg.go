@@ -0,0 +1,84 @@
+// Package termimg draws pictures with the terminal's own graphics
+// protocols, sixel, kitty, or iTerm2's inline images, which show every
+// pixel rather than two per character cell.
+package termimg
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Protocol is a way to send a picture to the terminal.
+type Protocol int
+
+const (
+	None Protocol = iota
+	Sixel
+	Kitty
+	ITerm2
+)
+
+func (p Protocol) String() string {
+	switch p {
+	case Sixel:
+		return "sixel"
+	case Kitty:
+		return "kitty"
+	case ITerm2:
+		return "iterm2"
+	}
+	return "none"
+}
+
+// DefaultTimeout is how long Detect waits for the terminal to answer.
+const DefaultTimeout = 200 * time.Millisecond
+
+// Detect asks the terminal which protocol it speaks, preferring kitty's,
+// which keeps pictures apart from the text, over iTerm2's and sixel. It
+// must run before the UI takes over the terminal.
+func Detect(timeout time.Duration) Protocol {
+	kitty, sixel := ParseReply(query(timeout))
+	switch {
+	case kitty:
+		return Kitty
+	case isITerm2(os.Getenv("TERM_PROGRAM"), os.Getenv("LC_TERMINAL")):
+		return ITerm2
+	case sixel:
+		return Sixel
+	}
+	return None
+}
+
+// iTerm2 and WezTerm don't answer a query for inline images, so they are
+// recognized by the variables they set. LC_TERMINAL survives ssh.
+func isITerm2(termProgram, lcTerminal string) bool {
+	return termProgram == "iTerm.app" || termProgram == "WezTerm" || lcTerminal == "iTerm2"
+}
+
+// deviceAttributes matches a primary device attributes reply such as
+// "\x1b[?62;4;22c".
+var deviceAttributes = regexp.MustCompile(`\x1b\[\?([0-9;]*)c`)
+
+// ParseReply reads the terminal's answer to a kitty graphics query
+// followed by a primary device attributes request. Kitty acknowledges the
+// query with OK; sixel support is attribute 4.
+func ParseReply(reply string) (kitty, sixel bool) {
+	kitty = strings.Contains(reply, "\x1b_Gi="+queryID+";OK")
+	if m := deviceAttributes.FindStringSubmatch(reply); m != nil {
+		for _, attr := range strings.Split(m[1], ";") {
+			if attr == "4" {
+				sixel = true
+			}
+		}
+	}
+	return kitty, sixel
+}
+
+// queryID numbers the 1x1 image of the kitty query, which is never shown.
+const queryID = "31"
+
+// queryRequest asks for kitty graphics support, then for the device
+// attributes, which every terminal answers and which come last.
+const queryRequest = "\x1b_Gi=" + queryID + ",s=1,v=1,a=q,t=d,f=24;AAAA\x1b\\\x1b[c"
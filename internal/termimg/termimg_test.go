@@ -0,0 +1,101 @@
+package termimg
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestParseReply(t *testing.T) {
+	tests := []struct {
+		reply        string
+		kitty, sixel bool
+	}{
+		{"\x1b_Gi=31;OK\x1b\\\x1b[?62;22c", true, false},
+		{"\x1b[?62;4;9;22c", false, true},
+		{"\x1b[?4c", false, true},
+		{"\x1b_Gi=31;EINVAL:bad\x1b\\\x1b[?1;2c", false, false},
+		{"\x1b[?64;14c", false, false},
+		{"", false, false},
+	}
+	for _, tt := range tests {
+		kitty, sixel := ParseReply(tt.reply)
+		if kitty != tt.kitty || sixel != tt.sixel {
+			t.Errorf("ParseReply(%q) = %v, %v, want %v, %v", tt.reply, kitty, sixel, tt.kitty, tt.sixel)
+		}
+	}
+}
+
+func TestIsITerm2(t *testing.T) {
+	if !isITerm2("iTerm.app", "") || !isITerm2("", "iTerm2") || !isITerm2("WezTerm", "") {
+		t.Error("isITerm2() missed iTerm2 or WezTerm")
+	}
+	if isITerm2("Apple_Terminal", "") {
+		t.Error("isITerm2() = true for Terminal.app")
+	}
+}
+
+func TestEncodeSixel(t *testing.T) {
+	// Seven rows of black over white take two bands
+	img := image.NewRGBA(image.Rect(0, 0, 5, 7))
+	for x := 0; x < 5; x++ {
+		img.Set(x, 6, color.White)
+	}
+	out, err := Encode(Sixel, img)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	s := string(out)
+	if !strings.HasPrefix(s, "\x1bP0;1;0q\"1;1;5;7") || !strings.HasSuffix(s, "\x1b\\") {
+		t.Errorf("Encode() = %q, want a 5x7 sixel image", s)
+	}
+	// Black fills the first band, five columns of all six bits
+	if !strings.Contains(s, "!5~") {
+		t.Errorf("Encode() = %q, want the first band as one run", s)
+	}
+	if strings.Count(s, "-") != 1 {
+		t.Errorf("Encode() = %q, want one band separator", s)
+	}
+}
+
+func TestEncodeKittyChunks(t *testing.T) {
+	// Noise doesn't compress, so the PNG spans several chunks
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	rand.New(rand.NewSource(1)).Read(img.Pix)
+	out, err := Encode(Kitty, img)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	s := string(out)
+	if !strings.HasPrefix(s, "\x1b_Ga=T,f=100,i=4711,q=2,C=1,m=1;") {
+		t.Errorf("Encode() starts %q", s[:40])
+	}
+	if n := strings.Count(s, "\x1b_Gm=1;"); n < 2 {
+		t.Errorf("Encode() has %d middle chunks, want at least 2", n)
+	}
+	if strings.Count(s, "m=0;") != 1 {
+		t.Error("Encode() doesn't end with one final chunk")
+	}
+	for _, cmd := range strings.Split(s, "\x1b\\") {
+		if _, payload, ok := strings.Cut(cmd, ";"); ok && len(payload) > kittyChunk {
+			t.Errorf("chunk of %d bytes, want at most %d", len(payload), kittyChunk)
+		}
+	}
+}
+
+func TestAt(t *testing.T) {
+	if got := string(At(3, 0, []byte("img"))); got != "\x1b7\x1b[1;4Himg\x1b8" {
+		t.Errorf("At() = %q", got)
+	}
+}
+
+func TestClear(t *testing.T) {
+	if Clear(Sixel) != nil || Clear(ITerm2) != nil {
+		t.Error("Clear() returned a sequence for a protocol without image ids")
+	}
+	if got := string(Clear(Kitty)); got != "\x1b_Ga=d,d=I,i=4711,q=2\x1b\\" {
+		t.Errorf("Clear(Kitty) = %q", got)
+	}
+}
@@ -0,0 +1,191 @@
+// Package throttle limits the bandwidth of background downloads, such as
+// station logos and API refreshes, so they leave room for the audio
+// stream on slow links.
+package throttle
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/clock"
+)
+
+const (
+	// MinRate is the lowest rate, in bytes per second, Tighten goes down to.
+	MinRate = 8 << 10
+	// TightenFrom is the rate the first Tighten sets when there is no limit.
+	TightenFrom = 256 << 10
+	// RelaxAfter is how long after the last Tighten the rate doubles back
+	// towards the limit, and again after each further RelaxAfter.
+	RelaxAfter = time.Minute
+
+	// chunkSize bounds each read, so waits stay short and even.
+	chunkSize = 4 << 10
+)
+
+// Limiter is a token bucket shared by all background downloads. Its rate
+// is the configured limit, lowered by Tighten when the stream runs dry.
+// A nil Limiter doesn't limit anything.
+type Limiter struct {
+	mu        sync.Mutex
+	limit     int // bytes per second, 0 for none
+	rate      int // the limit as tightened, 0 for none
+	tokens    float64
+	filled    time.Time
+	tightened time.Time
+	clock     clock.Clock
+}
+
+// New returns a limiter allowing bytesPerSecond, or any rate when it's 0.
+func New(bytesPerSecond int) *Limiter {
+	return &Limiter{limit: bytesPerSecond, rate: bytesPerSecond}
+}
+
+// SetClock replaces the wall clock, for tests.
+func (l *Limiter) SetClock(c clock.Clock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clock = c
+}
+
+// Rate returns the current rate in bytes per second, 0 for none.
+func (l *Limiter) Rate() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.relax(clock.Or(l.clock).Now())
+	return l.rate
+}
+
+// Tighten halves the rate, down to MinRate, after the stream ran dry. It
+// never raises a limit below MinRate. Without a limit it starts at
+// TightenFrom.
+func (l *Limiter) Tighten() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := clock.Or(l.clock).Now()
+	l.relax(now)
+	if l.rate == 0 {
+		l.rate = TightenFrom
+	} else {
+		l.rate = max(l.rate/2, MinRate)
+		if l.limit > 0 {
+			l.rate = min(l.rate, l.limit)
+		}
+	}
+	l.tokens = min(l.tokens, float64(l.rate))
+	l.tightened = now
+}
+
+// relax doubles the rate back towards the limit for every RelaxAfter
+// since the last Tighten.
+func (l *Limiter) relax(now time.Time) {
+	for l.rate != l.limit && now.Sub(l.tightened) >= RelaxAfter {
+		l.rate *= 2
+		if l.limit > 0 && l.rate >= l.limit {
+			l.rate = l.limit
+		} else if l.limit == 0 && l.rate >= 2*TightenFrom {
+			l.rate = 0
+		}
+		l.tightened = l.tightened.Add(RelaxAfter)
+	}
+}
+
+// WaitN blocks until n more bytes may be read, or ctx is done.
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	c := clock.Or(l.clock)
+	now := c.Now()
+	l.relax(now)
+	if l.rate == 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	// A second's worth may go out at once after an idle spell
+	if !l.filled.IsZero() {
+		l.tokens = min(l.tokens+now.Sub(l.filled).Seconds()*float64(l.rate), float64(l.rate))
+	} else {
+		l.tokens = float64(l.rate)
+	}
+	l.filled = now
+	l.tokens -= float64(n)
+	wait := time.Duration(-l.tokens / float64(l.rate) * float64(time.Second))
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	timer := c.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Reader returns r, reading no faster than the limiter allows.
+func (l *Limiter) Reader(ctx context.Context, r io.Reader) io.Reader {
+	if l == nil {
+		return r
+	}
+	return &reader{ctx: ctx, r: r, l: l}
+}
+
+type reader struct {
+	ctx context.Context
+	r   io.Reader
+	l   *Limiter
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if len(p) > chunkSize {
+		p = p[:chunkSize]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if werr := r.l.WaitN(r.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+// Transport returns base with response bodies read through the limiter.
+func (l *Limiter) Transport(base http.RoundTripper) http.RoundTripper {
+	if l == nil {
+		return base
+	}
+	return transport{base: base, l: l}
+}
+
+type transport struct {
+	base http.RoundTripper
+	l    *Limiter
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = body{Reader: t.l.Reader(req.Context(), resp.Body), Closer: resp.Body}
+	return resp, nil
+}
+
+type body struct {
+	io.Reader
+	io.Closer
+}
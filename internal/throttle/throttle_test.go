@@ -0,0 +1,143 @@
+package throttle
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/clock"
+)
+
+func TestTightenAndRelax(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC))
+	l := New(64 << 10)
+	l.SetClock(fake)
+
+	l.Tighten()
+	l.Tighten()
+	if got := l.Rate(); got != 16<<10 {
+		t.Errorf("Rate() after two underruns = %d, want %d", got, 16<<10)
+	}
+	for range 4 {
+		l.Tighten()
+	}
+	if got := l.Rate(); got != MinRate {
+		t.Errorf("Rate() = %d, want the floor %d", got, MinRate)
+	}
+
+	fake.Advance(RelaxAfter)
+	if got := l.Rate(); got != 2*MinRate {
+		t.Errorf("Rate() a minute later = %d, want %d", got, 2*MinRate)
+	}
+	fake.Advance(10 * RelaxAfter)
+	if got := l.Rate(); got != 64<<10 {
+		t.Errorf("Rate() once relaxed = %d, want the limit", got)
+	}
+}
+
+func TestTightenBelowMinRate(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC))
+	l := New(4 << 10)
+	l.SetClock(fake)
+
+	l.Tighten()
+	if got := l.Rate(); got != 4<<10 {
+		t.Errorf("Rate() after an underrun = %d, want the limit %d", got, 4<<10)
+	}
+	fake.Advance(RelaxAfter)
+	if got := l.Rate(); got != 4<<10 {
+		t.Errorf("Rate() a minute later = %d, want the limit %d", got, 4<<10)
+	}
+}
+
+func TestTightenWithoutLimit(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC))
+	l := New(0)
+	l.SetClock(fake)
+
+	l.Tighten()
+	if got := l.Rate(); got != TightenFrom {
+		t.Errorf("Rate() = %d, want %d", got, TightenFrom)
+	}
+	fake.Advance(2 * RelaxAfter)
+	if got := l.Rate(); got != 0 {
+		t.Errorf("Rate() once relaxed = %d, want no limit", got)
+	}
+}
+
+func TestWaitN(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC))
+	l := New(1000)
+	l.SetClock(fake)
+	ctx := context.Background()
+
+	// The first second's worth goes out at once
+	if err := l.WaitN(ctx, 1000); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- l.WaitN(ctx, 500) }()
+	for fake.Pending() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	fake.Advance(400 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("WaitN(500) returned before half a second")
+	case <-time.After(10 * time.Millisecond):
+	}
+	fake.Advance(100 * time.Millisecond)
+	if err := <-done; err != nil {
+		t.Errorf("WaitN() error = %v", err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := l.WaitN(canceled, 1000); err == nil {
+		t.Error("WaitN() with a canceled context returned nil")
+	}
+}
+
+func TestNilLimiter(t *testing.T) {
+	var l *Limiter
+	l.Tighten()
+	if err := l.WaitN(context.Background(), 1<<20); err != nil {
+		t.Errorf("WaitN() error = %v", err)
+	}
+	if l.Rate() != 0 || l.Transport(http.DefaultTransport) != http.DefaultTransport {
+		t.Error("nil limiter limits")
+	}
+}
+
+func TestTransport(t *testing.T) {
+	payload := strings.Repeat("x", 3*chunkSize)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, payload)
+	}))
+	defer server.Close()
+
+	l := New(2 * chunkSize)
+	client := &http.Client{Transport: l.Transport(http.DefaultTransport)}
+	start := time.Now()
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != payload {
+		t.Errorf("read %d bytes, want %d", len(data), len(payload))
+	}
+	// Two chunks go out at once, the third half a second later
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("read took %v, want about half a second", elapsed)
+	}
+}
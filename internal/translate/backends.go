@@ -0,0 +1,187 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/glebovdev/somafm-cli/internal/hooks"
+)
+
+// CommandBackend runs a shell command once per text, with the text on
+// stdin and SOMAFM_LANGUAGE in the environment, and reads the translation
+// from stdout, e.g. "trans -b :$SOMAFM_LANGUAGE".
+type CommandBackend struct {
+	command string
+}
+
+func NewCommandBackend(command string) *CommandBackend {
+	return &CommandBackend{command: command}
+}
+
+func (b *CommandBackend) Name() string {
+	return "translate command"
+}
+
+func (b *CommandBackend) Translate(ctx context.Context, texts []string, target string) ([]string, error) {
+	translated := make([]string, len(texts))
+	for i, text := range texts {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		cmd := hooks.Command(ctx, b.command)
+		cmd.Env = append(os.Environ(), "SOMAFM_LANGUAGE="+target)
+		cmd.Stdin = strings.NewReader(text)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+
+		output, err := cmd.Output()
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("timed out: %w", ctx.Err())
+		}
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return nil, fmt.Errorf("%w: %s", err, msg)
+			}
+			return nil, err
+		}
+		translated[i] = strings.TrimSpace(string(output))
+	}
+	return translated, nil
+}
+
+// DeepL API endpoints. Keys of the free plan end in ":fx".
+const (
+	deepLFreeURL = "https://api-free.deepl.com"
+	deepLProURL  = "https://api.deepl.com"
+)
+
+// DeepLBackend uses the DeepL API.
+type DeepLBackend struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// NewDeepLBackend returns a DeepL backend. An empty url picks the API of
+// the key's plan.
+func NewDeepLBackend(url, apiKey string) *DeepLBackend {
+	if url == "" {
+		url = deepLProURL
+		if strings.HasSuffix(apiKey, ":fx") {
+			url = deepLFreeURL
+		}
+	}
+	return &DeepLBackend{
+		url:    strings.TrimRight(url, "/"),
+		apiKey: apiKey,
+		client: &http.Client{},
+	}
+}
+
+func (b *DeepLBackend) Name() string {
+	return "DeepL"
+}
+
+func (b *DeepLBackend) Translate(ctx context.Context, texts []string, target string) ([]string, error) {
+	request := struct {
+		Text       []string `json:"text"`
+		SourceLang string   `json:"source_lang"`
+		TargetLang string   `json:"target_lang"`
+	}{texts, "EN", strings.ToUpper(target)}
+
+	var response struct {
+		Translations []struct {
+			Text string `json:"text"`
+		} `json:"translations"`
+	}
+	headers := map[string]string{"Authorization": "DeepL-Auth-Key " + b.apiKey}
+	if err := postJSON(ctx, b.client, b.url+"/v2/translate", headers, request, &response); err != nil {
+		return nil, err
+	}
+
+	translated := make([]string, len(response.Translations))
+	for i, t := range response.Translations {
+		translated[i] = t.Text
+	}
+	return translated, nil
+}
+
+// LibreTranslateBackend uses a LibreTranslate server.
+type LibreTranslateBackend struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+func NewLibreTranslateBackend(url, apiKey string) *LibreTranslateBackend {
+	return &LibreTranslateBackend{
+		url:    strings.TrimRight(url, "/"),
+		apiKey: apiKey,
+		client: &http.Client{},
+	}
+}
+
+func (b *LibreTranslateBackend) Name() string {
+	return "LibreTranslate"
+}
+
+func (b *LibreTranslateBackend) Translate(ctx context.Context, texts []string, target string) ([]string, error) {
+	request := struct {
+		Q      []string `json:"q"`
+		Source string   `json:"source"`
+		Target string   `json:"target"`
+		Format string   `json:"format"`
+		APIKey string   `json:"api_key,omitempty"`
+	}{texts, "en", baseLanguage(target), "text", b.apiKey}
+
+	var response struct {
+		TranslatedText []string `json:"translatedText"`
+	}
+	if err := postJSON(ctx, b.client, b.url+"/translate", nil, request, &response); err != nil {
+		return nil, err
+	}
+	return response.TranslatedText, nil
+}
+
+// postJSON sends request as JSON and decodes the JSON reply into response.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, request, response any) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("timed out after %v", Timeout)
+		}
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package translate
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/glebovdev/somafm-cli/internal/cache"
+	"github.com/rs/zerolog/log"
+)
+
+// CacheFileName is the translation cache in the cache directory.
+const CacheFileName = cache.TranslationsFileName
+
+// cacheEntry is the last translation of a station's texts.
+type cacheEntry struct {
+	Language string   `json:"language"`
+	Source   []string `json:"source"`
+	Text     []string `json:"text"`
+}
+
+// Cache keeps one translation per station in a JSON file. A nil Cache
+// remembers nothing.
+type Cache struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// OpenDefaultCache opens the cache in the user cache directory.
+func OpenDefaultCache() (*Cache, error) {
+	dir, err := cache.GetCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return OpenCache(filepath.Join(dir, CacheFileName)), nil
+}
+
+// OpenCache reads the cache at path. A missing file is an empty cache, and
+// so is one that can't be read: translations can always be fetched again,
+// and the next Put replaces the file.
+func OpenCache(path string) *Cache {
+	c := &Cache{path: path, entries: make(map[string]cacheEntry)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &c.entries)
+	}
+	if err != nil {
+		log.Warn().Err(err).Msg("Translation cache unreadable, starting empty")
+		c.entries = make(map[string]cacheEntry)
+	}
+	return c
+}
+
+// Clear forgets every translation and removes the cache file.
+func (c *Cache) Clear() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]cacheEntry)
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Get returns the cached translation of the station's texts into language,
+// if the texts are the ones that were translated.
+func (c *Cache) Get(stationID, language string, texts []string) ([]string, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[stationID]
+	if !ok || entry.Language != language || !slices.Equal(entry.Source, texts) {
+		return nil, false
+	}
+	return slices.Clone(entry.Text), true
+}
+
+// Put stores the translation of the station's texts, replacing the one
+// before, and writes the cache file.
+func (c *Cache) Put(stationID, language string, texts, translated []string) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[stationID] = cacheEntry{
+		Language: language,
+		Source:   slices.Clone(texts),
+		Text:     slices.Clone(translated),
+	}
+
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	tmpPath := c.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, c.path)
+}
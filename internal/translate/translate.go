@@ -0,0 +1,141 @@
+// Package translate translates station descriptions and genre names into
+// the user's language through a configured command or a DeepL or
+// LibreTranslate server. Translations are cached per station on disk.
+package translate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/config"
+)
+
+// Timeout bounds one translation request, covering every text of a station.
+const Timeout = 15 * time.Second
+
+// Backend translates texts into the target language, keeping their order.
+type Backend interface {
+	Name() string
+	Translate(ctx context.Context, texts []string, target string) ([]string, error)
+}
+
+// Client translates station texts through a backend, remembering the
+// results per station until the source text or the language changes.
+type Client struct {
+	backend  Backend
+	language string
+	cache    *Cache
+}
+
+// FromConfig returns a client for the configured backend, caching results
+// in cache, which may be nil, or nil when translation is off.
+func FromConfig(cfg config.Translate, cache *Cache) (*Client, error) {
+	if cfg.Backend == "" {
+		return nil, nil
+	}
+
+	language := strings.TrimSpace(cfg.Language)
+	if language == "" {
+		language = Locale()
+	}
+	if language == "" {
+		return nil, fmt.Errorf("no target language: set translate.language or LANG")
+	}
+
+	var backend Backend
+	switch cfg.Backend {
+	case config.TranslateCommand:
+		backend = NewCommandBackend(cfg.Command)
+	case config.TranslateDeepL:
+		backend = NewDeepLBackend(cfg.URL, cfg.APIKey)
+	case config.TranslateLibreTranslate:
+		backend = NewLibreTranslateBackend(cfg.URL, cfg.APIKey)
+	default:
+		return nil, fmt.Errorf("unknown translation backend %q", cfg.Backend)
+	}
+
+	return &Client{backend: backend, language: language, cache: cache}, nil
+}
+
+// NewClient returns a client translating into language through backend,
+// caching results in cache, which may be nil.
+func NewClient(backend Backend, language string, cache *Cache) *Client {
+	return &Client{backend: backend, language: language, cache: cache}
+}
+
+// Language returns the target language.
+func (c *Client) Language() string {
+	return c.language
+}
+
+// ClearCache forgets every cached translation, e.g. after the cache file
+// was pruned.
+func (c *Client) ClearCache() error {
+	return c.cache.Clear()
+}
+
+// Cached returns the cached translation of the station's texts, if any.
+func (c *Client) Cached(stationID string, texts []string) ([]string, bool) {
+	return c.cache.Get(stationID, c.language, texts)
+}
+
+// Station translates the texts of the station with the given ID, from the
+// cache when they were translated before.
+func (c *Client) Station(ctx context.Context, stationID string, texts []string) ([]string, error) {
+	if cached, ok := c.cache.Get(stationID, c.language, texts); ok {
+		return cached, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	translated, err := c.backend.Translate(ctx, texts, c.language)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.backend.Name(), err)
+	}
+	if len(translated) != len(texts) {
+		return nil, fmt.Errorf("%s returned %d translations for %d texts", c.backend.Name(), len(translated), len(texts))
+	}
+
+	if err := c.cache.Put(stationID, c.language, texts, translated); err != nil {
+		return translated, fmt.Errorf("failed to cache translation: %w", err)
+	}
+	return translated, nil
+}
+
+// Locale returns the language of the user's locale from LC_ALL,
+// LC_MESSAGES, or LANG, e.g. "de" or "pt-BR". It returns "" for the C
+// and POSIX locales.
+func Locale() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return parseLocale(value)
+		}
+	}
+	return ""
+}
+
+// parseLocale turns a POSIX locale such as pt_BR.UTF-8@euro into a
+// language tag such as pt-BR.
+func parseLocale(locale string) string {
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	if locale == "" || locale == "C" || locale == "POSIX" {
+		return ""
+	}
+	language, region, _ := strings.Cut(locale, "_")
+	if region == "" {
+		return strings.ToLower(language)
+	}
+	return strings.ToLower(language) + "-" + strings.ToUpper(region)
+}
+
+// baseLanguage drops the region of a language tag, e.g. pt-BR becomes pt.
+func baseLanguage(language string) string {
+	base, _, _ := strings.Cut(language, "-")
+	return strings.ToLower(base)
+}
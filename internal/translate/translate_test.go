@@ -0,0 +1,256 @@
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/glebovdev/somafm-cli/internal/config"
+)
+
+func TestParseLocale(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"de_DE.UTF-8", "de-DE"},
+		{"pt_BR", "pt-BR"},
+		{"fr", "fr"},
+		{"ca_ES@valencia", "ca-ES"},
+		{"C.UTF-8", ""},
+		{"POSIX", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := parseLocale(tt.locale); got != tt.want {
+			t.Errorf("parseLocale(%q) = %q, want %q", tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestLocalePrefersLCAll(t *testing.T) {
+	t.Setenv("LC_ALL", "ja_JP.UTF-8")
+	t.Setenv("LC_MESSAGES", "de_DE.UTF-8")
+	t.Setenv("LANG", "fr_FR.UTF-8")
+
+	if got := Locale(); got != "ja-JP" {
+		t.Errorf("Locale() = %q, want ja-JP", got)
+	}
+}
+
+func TestFromConfigOff(t *testing.T) {
+	client, err := FromConfig(config.Translate{}, nil)
+	if err != nil || client != nil {
+		t.Errorf("FromConfig() = %v, %v, want nil, nil", client, err)
+	}
+}
+
+func TestFromConfigNeedsLanguage(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "C")
+
+	if _, err := FromConfig(config.Translate{Backend: config.TranslateCommand, Command: "cat"}, nil); err == nil {
+		t.Error("FromConfig() without a language should fail")
+	}
+}
+
+// fakeBackend upper-cases texts and counts the requests.
+type fakeBackend struct {
+	calls int
+}
+
+func (b *fakeBackend) Name() string { return "fake" }
+
+func (b *fakeBackend) Translate(ctx context.Context, texts []string, target string) ([]string, error) {
+	b.calls++
+	translated := make([]string, len(texts))
+	for i, text := range texts {
+		translated[i] = strings.ToUpper(text) + " (" + target + ")"
+	}
+	return translated, nil
+}
+
+func TestClientCachesPerStation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), CacheFileName)
+	cache := OpenCache(path)
+	backend := &fakeBackend{}
+	client := NewClient(backend, "de", cache)
+	texts := []string{"Ambient beats", "Ambient"}
+
+	got, err := client.Station(context.Background(), "groovesalad", texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"AMBIENT BEATS (de)", "AMBIENT (de)"}; !slices.Equal(got, want) {
+		t.Errorf("Station() = %q, want %q", got, want)
+	}
+
+	if _, err := client.Station(context.Background(), "groovesalad", texts); err != nil {
+		t.Fatal(err)
+	}
+	if backend.calls != 1 {
+		t.Errorf("backend called %d times, want 1 with the second from the cache", backend.calls)
+	}
+
+	// The cache survives a restart
+	reopened := OpenCache(path)
+	if cached, ok := reopened.Get("groovesalad", "de", texts); !ok || !slices.Equal(cached, got) {
+		t.Errorf("reopened Get() = %q, %v, want %q", cached, ok, got)
+	}
+
+	// A changed description or language is translated again
+	if _, err := client.Station(context.Background(), "groovesalad", []string{"New description", "Ambient"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reopened.Get("groovesalad", "fr", texts); ok {
+		t.Error("Get() returned a translation for another language")
+	}
+	if backend.calls != 2 {
+		t.Errorf("backend called %d times, want 2 after the description changed", backend.calls)
+	}
+}
+
+func TestCommandBackend(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell command")
+	}
+	backend := NewCommandBackend(`printf '%s:' "$SOMAFM_LANGUAGE"; tr a-z A-Z`)
+
+	got, err := backend.Translate(context.Background(), []string{"chill", "", "beats"}, "de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"de:CHILL", "", "de:BEATS"}; !slices.Equal(got, want) {
+		t.Errorf("Translate() = %q, want %q", got, want)
+	}
+
+	failing := NewCommandBackend("echo 'no network' >&2; exit 3")
+	if _, err := failing.Translate(context.Background(), []string{"chill"}, "de"); err == nil || !strings.Contains(err.Error(), "no network") {
+		t.Errorf("Translate() error = %v, want the command's stderr", err)
+	}
+}
+
+func TestDeepLBackend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/translate" {
+			t.Errorf("path = %q, want /v2/translate", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "DeepL-Auth-Key secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		var req struct {
+			Text       []string `json:"text"`
+			TargetLang string   `json:"target_lang"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if req.TargetLang != "PT-BR" {
+			t.Errorf("target_lang = %q, want PT-BR", req.TargetLang)
+		}
+		type translation struct {
+			Text string `json:"text"`
+		}
+		var resp struct {
+			Translations []translation `json:"translations"`
+		}
+		for _, text := range req.Text {
+			resp.Translations = append(resp.Translations, translation{"pt:" + text})
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	got, err := NewDeepLBackend(server.URL, "secret").Translate(context.Background(), []string{"a", "b"}, "pt-BR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"pt:a", "pt:b"}; !slices.Equal(got, want) {
+		t.Errorf("Translate() = %q, want %q", got, want)
+	}
+}
+
+func TestDeepLDefaultURL(t *testing.T) {
+	if got := NewDeepLBackend("", "key:fx").url; got != deepLFreeURL {
+		t.Errorf("free key URL = %q, want %q", got, deepLFreeURL)
+	}
+	if got := NewDeepLBackend("", "key").url; got != deepLProURL {
+		t.Errorf("pro key URL = %q, want %q", got, deepLProURL)
+	}
+}
+
+func TestLibreTranslateBackend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Q      []string `json:"q"`
+			Source string   `json:"source"`
+			Target string   `json:"target"`
+			APIKey string   `json:"api_key"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if req.Source != "en" || req.Target != "pt" || req.APIKey != "k" {
+			t.Errorf("request = %+v, want en to pt with the key", req)
+		}
+		translated := make([]string, len(req.Q))
+		for i, q := range req.Q {
+			translated[i] = "pt:" + q
+		}
+		_ = json.NewEncoder(w).Encode(map[string][]string{"translatedText": translated})
+	}))
+	defer server.Close()
+
+	got, err := NewLibreTranslateBackend(server.URL+"/", "k").Translate(context.Background(), []string{"a"}, "pt-BR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"pt:a"}; !slices.Equal(got, want) {
+		t.Errorf("Translate() = %q, want %q", got, want)
+	}
+}
+
+func TestBackendErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, err := NewLibreTranslateBackend(server.URL, "").Translate(context.Background(), []string{"a"}, "de")
+	if err == nil || !strings.Contains(err.Error(), "403") || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("Translate() error = %v, want the status and body", err)
+	}
+}
+
+func TestOpenCorruptCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), CacheFileName)
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := OpenCache(path)
+	if _, ok := cache.Get("groovesalad", "de", []string{"Ambient"}); ok {
+		t.Error("Get() found a translation in a corrupt cache")
+	}
+	if err := cache.Put("groovesalad", "de", []string{"Ambient"}, []string{"Ambient (de)"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if _, ok := OpenCache(path).Get("groovesalad", "de", []string{"Ambient"}); !ok {
+		t.Error("Put() didn't replace the corrupt file")
+	}
+
+	if err := cache.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Clear() kept the cache file")
+	}
+}
@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/alarm"
+	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	alarmTickInterval = time.Second
+	alarmRampStep     = 500 * time.Millisecond
+)
+
+// alarmState is a pending alarm. Guarded by UI.mu.
+type alarmState struct {
+	alarm alarm.Alarm
+	ramp  time.Duration
+	set   bool
+}
+
+// SetAlarm schedules a to fire once the UI is running.
+func (ui *UI) SetAlarm(a alarm.Alarm, ramp time.Duration) {
+	ui.mu.Lock()
+	ui.alarm = alarmState{alarm: a, ramp: ramp, set: true}
+	ui.mu.Unlock()
+}
+
+func (ui *UI) pendingAlarm() (alarmState, bool) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	return ui.alarm, ui.alarm.set
+}
+
+// alarmStationIndex returns the station the alarm plays: its own, the last
+// station, or the first one.
+func (ui *UI) alarmStationIndex(a alarm.Alarm) int {
+	for _, id := range []station.StationID{a.Station, ui.config.LastStation} {
+		if id == "" {
+			continue
+		}
+		if index := ui.stationService.FindIndexByID(id); index >= 0 {
+			return index
+		}
+		log.Warn().Msgf("Alarm station %q not found", id)
+	}
+	return 0
+}
+
+// startAlarm selects the alarm station and waits for the alarm time,
+// redrawing every second so the footer countdown stays current.
+func (ui *UI) startAlarm() {
+	state, ok := ui.pendingAlarm()
+	if !ok {
+		return
+	}
+
+	index := ui.alarmStationIndex(state.alarm)
+	s := ui.stationService.GetStation(index)
+	if s == nil {
+		return
+	}
+	ui.selectAndShowStation(index)
+	ui.statusRenderer.SetAlarm(state.alarm.At, s.Title)
+	ui.showNotice("⏰ Alarm set for " + state.alarm.At.Format("15:04") + " — " + s.Title)
+	log.Info().Msgf("Alarm set for %s: %s", state.alarm.At.Format(time.RFC3339), s.ID)
+
+	go func() {
+		ticker := ui.timeSource().NewTicker(alarmTickInterval)
+		defer ticker.Stop()
+		for range ticker.C() {
+			if !ui.timeSource().Now().Before(state.alarm.At) {
+				ui.app.QueueUpdateDraw(func() {
+					ui.fireAlarm(index, state.ramp)
+				})
+				return
+			}
+			ui.app.QueueUpdateDraw(func() {})
+		}
+	}()
+}
+
+// fireAlarm starts the alarm station silently and fades it in.
+func (ui *UI) fireAlarm(index int, ramp time.Duration) {
+	ui.mu.Lock()
+	ui.alarm = alarmState{}
+	ui.mu.Unlock()
+	ui.statusRenderer.SetAlarm(time.Time{}, "")
+
+	target := ui.currentVolume
+	if ui.isMuted {
+		target = 0
+	}
+	ui.player.SetVolume(0)
+
+	ui.stations.selectStation(index)
+	ui.onStationSelected(index)
+	if s := ui.stationService.GetStation(index); s != nil {
+		ui.showNotice("⏰ Good morning — " + s.Title)
+	}
+
+	go ui.rampVolume(target, ramp)
+}
+
+// rampVolume raises the player volume to target over ramp. It gives up as
+// soon as the volume is changed by anything else, e.g. the user.
+func (ui *UI) rampVolume(target int, ramp time.Duration) {
+	start := ui.timeSource().Now()
+	last := 0
+	ticker := ui.timeSource().NewTicker(alarmRampStep)
+	defer ticker.Stop()
+
+	for range ticker.C() {
+		if ui.player.GetVolume() != last {
+			log.Debug().Msg("Volume changed during alarm ramp, stopping ramp")
+			return
+		}
+		last = alarm.RampVolume(target, ui.timeSource().Since(start), ramp)
+		ui.player.SetVolume(last)
+		if last >= target {
+			return
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/format"
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/rs/zerolog/log"
+)
+
+// AutoStopWarning is how long before an auto stop a warning is shown.
+const AutoStopWarning = 5 * time.Minute
+
+// autoStopBreak is how long playback has to be paused or stopped for the
+// auto stop to count the next playback as a new session. Switching
+// stations doesn't start one.
+const autoStopBreak = time.Minute
+
+// autoStopState tracks the current stretch of playback for auto_stop_after.
+type autoStopState struct {
+	start  time.Time // Zero while there is no session
+	active time.Time // Last seen playing
+	warned bool
+}
+
+// autoStopAction is what checkAutoStop does after a tick.
+type autoStopAction int
+
+const (
+	autoStopNone autoStopAction = iota
+	autoStopWarn
+	autoStopNow
+)
+
+// advance records whether playback is going on at now and returns what
+// to do about a session of at most limit.
+func (s *autoStopState) advance(now time.Time, playing bool, limit time.Duration) autoStopAction {
+	if now.Sub(s.active) >= autoStopBreak {
+		*s = autoStopState{}
+	}
+	if !playing {
+		return autoStopNone
+	}
+	if s.start.IsZero() {
+		s.start = now
+	}
+	s.active = now
+
+	played := now.Sub(s.start)
+	switch {
+	case played >= limit:
+		*s = autoStopState{}
+		return autoStopNow
+	case played >= limit-AutoStopWarning && !s.warned:
+		s.warned = true
+		return autoStopWarn
+	}
+	return autoStopNone
+}
+
+// checkAutoStop stops playback once it has gone on for the configured
+// auto_stop_after without a break, warning five minutes before. It runs
+// every second.
+func (ui *UI) checkAutoStop() {
+	limit := ui.config.AutoStopAfter
+	if limit <= 0 {
+		ui.autoStop = autoStopState{}
+		return
+	}
+	now := ui.timeSource().Now()
+	var playing bool
+	switch ui.player.GetState() {
+	case player.StatePlaying, player.StateBuffering, player.StateReconnecting:
+		playing = true
+	}
+
+	switch ui.autoStop.advance(now, playing, limit) {
+	case autoStopNow:
+		log.Info().Msgf("Played for %v, stopping playback", limit)
+		ui.stopPlayback()
+		ui.showNotice(fmt.Sprintf("Stopped after %s of playback (auto_stop_after)", format.Duration(limit)))
+	case autoStopWarn:
+		ui.showNotice(fmt.Sprintf("Playback stops in %s, after %s without a break",
+			format.Duration(limit-now.Sub(ui.autoStop.start)), format.Duration(limit)))
+	}
+}
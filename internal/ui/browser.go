@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"os/exec"
+	"runtime"
+
+	"github.com/glebovdev/somafm-cli/internal/likes"
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/rs/zerolog/log"
+)
+
+// openURL opens u in the default browser. Tests replace it.
+var openURL = func(u string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Reap the opener once it hands off to the browser
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
+
+// searchCurrentTrack opens the configured web search, Bandcamp by default,
+// for the playing track.
+func (ui *UI) searchCurrentTrack() {
+	track := ui.player.GetCurrentTrack()
+	if track == "" || track == player.NoTrackInfo {
+		ui.showNotice("Nothing to search for yet — no track title")
+		return
+	}
+	u := ui.config.TrackSearchURL(likes.SplitTrack(track))
+	if err := openURL(u); err != nil {
+		log.Warn().Err(err).Str("url", u).Msg("Failed to open track search")
+		ui.showNotice("Couldn't open a browser: " + err.Error())
+		return
+	}
+	log.Debug().Str("url", u).Msg("Opened track search")
+	ui.showNotice("Searching for " + track)
+}
@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"github.com/glebovdev/somafm-cli/internal/dircheck"
+	"github.com/rs/zerolog/log"
+)
+
+// SetDirProblems passes on directories found unwritable at startup. The
+// first is shown in the footer once the UI is up; all are logged.
+func (ui *UI) SetDirProblems(problems []error) {
+	for _, err := range problems {
+		log.Warn().Err(err).Msg("Directory not usable")
+	}
+	ui.dirProblems = problems
+}
+
+// showDirProblems shows the first startup directory problem in the footer.
+func (ui *UI) showDirProblems() {
+	if len(ui.dirProblems) == 0 {
+		return
+	}
+	err := ui.dirProblems[0]
+	if e, ok := dircheck.As(err); ok {
+		ui.showNotice(e.Short())
+		return
+	}
+	ui.showNotice(err.Error())
+}
+
+// onConfigSaveError logs a failed config save and, the first time it is a
+// permission problem, says so in the footer rather than only in the log.
+func (ui *UI) onConfigSaveError(err error) {
+	log.Error().Err(err).Msg("Failed to save config")
+
+	e, ok := dircheck.As(err)
+	if !ok || !ui.saveErrorShown.CompareAndSwap(false, true) {
+		return
+	}
+	ui.app.QueueUpdateDraw(func() {
+		ui.showNotice(e.Short())
+	})
+}
@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"reflect"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/station"
+)
+
+// EventBus carries events between the UI components, so a view can react
+// to another without holding a reference to it. Events are delivered
+// synchronously, in subscription order, and are published from the tview
+// goroutine like every other widget update, so handlers need no locking.
+// A nil bus drops everything, which lets tests build a bare UI.
+type EventBus struct {
+	handlers map[reflect.Type][]func(any)
+}
+
+func newEventBus() *EventBus {
+	return &EventBus{handlers: make(map[reflect.Type][]func(any))}
+}
+
+// subscribe calls handler for every published event of type E.
+func subscribe[E any](b *EventBus, handler func(E)) {
+	t := reflect.TypeFor[E]()
+	b.handlers[t] = append(b.handlers[t], func(e any) { handler(e.(E)) })
+}
+
+// publish hands event to the handlers subscribed to its type.
+func (b *EventBus) publish(event any) {
+	if b == nil {
+		return
+	}
+	for _, handler := range b.handlers[reflect.TypeOf(event)] {
+		handler(event)
+	}
+}
+
+// noticePosted asks the footer to show text in place of the key help for
+// NoticeDisplayTime.
+type noticePosted struct {
+	text string
+}
+
+// volumeChanged carries the volume to display. While muted it is the
+// volume that unmuting restores.
+type volumeChanged struct {
+	volume int
+	muted  bool
+}
+
+// playingChanged is published when playback starts on a station.
+type playingChanged struct {
+	id station.StationID
+}
+
+// stationsChanged is published when the station data shown in the list
+// changed: a periodic refresh or favorites edited in the config file.
+type stationsChanged struct{}
+
+// selectionChanged is published when the station list selection moves.
+type selectionChanged struct {
+	station *station.Station
+}
+
+// modalClosed is published when a modal page closes and focus should go
+// back to the station list.
+type modalClosed struct {
+	name string
+}
+
+// footerResized asks the layout to give the footer a new height when the
+// terminal crosses FooterBreakpoint.
+type footerResized struct {
+	height int
+}
+
+// stationClicked is published when a station row is clicked, to play it.
+type stationClicked struct {
+	index int
+}
+
+// volumeScrolled is published when the mouse wheel turns over the volume
+// bar; step is positive for up.
+type volumeScrolled struct {
+	step int
+}
+
+// hintClicked is published when a footer hint is clicked, with the key it
+// stands for.
+type hintClicked struct {
+	key *tcell.EventKey
+}
@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/rs/zerolog/log"
+)
+
+// fallbackState remembers, per station and for this session only, whether
+// the user was asked about staying on a lower stream and what they chose.
+type fallbackState struct {
+	asked    map[station.StationID]bool
+	variants map[station.StationID]string // Lower streams accepted for the session
+	pending  *fallbackPrompt
+}
+
+// fallbackPrompt is the unanswered question shown in the footer.
+type fallbackPrompt struct {
+	station station.StationID
+	variant string
+	label   string
+	text    string
+}
+
+// streamLabel names a stream by bitrate, adding the format when the two
+// streams being compared differ in it.
+func streamLabel(info, other player.StreamInfo) string {
+	if info.Format != other.Format {
+		return fmt.Sprintf("%s %dk", info.Format, info.Bitrate)
+	}
+	return fmt.Sprintf("%dk", info.Bitrate)
+}
+
+// checkQualityFallback asks once per station, when a lower stream plays
+// because the preferred one failed, whether to use it for the rest of the
+// session instead of trying the failing one first every time. It runs
+// every second and keeps an unanswered question on screen.
+func (ui *UI) checkQualityFallback() {
+	playing := ui.player.GetState() == player.StatePlaying
+	if p := ui.fallback.pending; p != nil {
+		if !playing || p.station != ui.playingStationID {
+			ui.fallback.pending = nil
+			return
+		}
+		ui.showNotice(p.text)
+		return
+	}
+
+	fb, ok := ui.player.GetFallback()
+	s := ui.player.GetCurrentStation()
+	if !ok || !playing || s == nil || ui.fallback.asked[s.ID] {
+		return
+	}
+	var variant string
+	for _, playlist := range s.Playlists {
+		if playlist.URL == fb.PlayingURL {
+			variant = playlist.Variant()
+		}
+	}
+	if variant == "" {
+		return
+	}
+
+	if ui.fallback.asked == nil {
+		ui.fallback.asked = make(map[station.StationID]bool)
+	}
+	ui.fallback.asked[s.ID] = true
+	label := streamLabel(fb.Playing, fb.Failed)
+	ui.fallback.pending = &fallbackPrompt{
+		station: s.ID,
+		variant: variant,
+		label:   label,
+		text: fmt.Sprintf("%s keeps failing — switch to %s for this session? [y/N]",
+			streamLabel(fb.Failed, fb.Playing), label),
+	}
+	log.Info().Msgf("%s fell back from %s to %s", s.ID, fb.FailedURL, fb.PlayingURL)
+	ui.showNotice(ui.fallback.pending.text)
+}
+
+// answerQualityFallback takes y, n, Enter, or Esc as the answer to a
+// pending prompt and reports whether it consumed the key. Other keys work
+// as usual and leave the question open.
+func (ui *UI) answerQualityFallback(event *tcell.EventKey) bool {
+	p := ui.fallback.pending
+	if p == nil {
+		return false
+	}
+	switch {
+	case event.Key() == tcell.KeyRune && (event.Rune() == 'y' || event.Rune() == 'Y'):
+		if ui.fallback.variants == nil {
+			ui.fallback.variants = make(map[station.StationID]string)
+		}
+		ui.fallback.variants[p.station] = p.variant
+		ui.showNotice(fmt.Sprintf("Using %s for this session", p.label))
+	case event.Key() == tcell.KeyEnter, event.Key() == tcell.KeyEscape,
+		event.Key() == tcell.KeyRune && (event.Rune() == 'n' || event.Rune() == 'N'):
+		ui.showNotice("Keeping the best available stream")
+	default:
+		return false
+	}
+	ui.fallback.pending = nil
+	return true
+}
@@ -0,0 +1,205 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/genre"
+	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/rivo/tview"
+)
+
+const FilterInputHeight = 1
+
+func (v *StationListView) createFilterInput() *tview.InputField {
+	input := tview.NewInputField().
+		SetLabel(" / ").
+		SetLabelColor(v.colors.highlight).
+		SetFieldBackgroundColor(v.colors.background).
+		SetFieldTextColor(v.colors.foreground).
+		SetPlaceholder("filter by name, genre, or description").
+		SetPlaceholderTextColor(v.colors.borders)
+	input.SetBackgroundColor(v.colors.background)
+
+	input.SetChangedFunc(func(text string) {
+		v.applyFilter(text)
+	})
+
+	input.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEscape:
+			v.clearFilter()
+		case tcell.KeyEnter:
+			if v.filterQuery == "" {
+				v.hideFilter()
+			}
+			v.focus()
+		}
+	})
+
+	// Let arrow keys move the table selection while typing.
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn:
+			if handler := v.table.InputHandler(); handler != nil {
+				handler(event, func(tview.Primitive) {})
+			}
+			return nil
+		}
+		return event
+	})
+
+	return input
+}
+
+func (v *StationListView) showFilter() {
+	v.root.ResizeItem(v.filterInput, FilterInputHeight, 0)
+	v.app.SetFocus(v.filterInput)
+}
+
+func (v *StationListView) hideFilter() {
+	v.root.ResizeItem(v.filterInput, 0, 0)
+}
+
+func (v *StationListView) clearFilter() {
+	v.filterInput.SetText("")
+	v.applyFilter("")
+	v.hideFilter()
+	v.focus()
+}
+
+func (v *StationListView) applyFilter(query string) {
+	query = strings.TrimSpace(query)
+	if query == v.filterQuery {
+		return
+	}
+	v.filterQuery = query
+	v.refresh()
+
+	if v.stationIndexAtRow(1) >= 0 {
+		if row, _ := v.table.GetSelection(); v.stationIndexAtRow(row) < 0 {
+			v.table.Select(1, 0)
+		}
+	}
+}
+
+func (v *StationListView) updateTitle() {
+	total := v.service.StationCount()
+	// The default order goes unmentioned
+	order := ""
+	if name, ok := sortOrderNames[v.config.SortBy]; ok && v.config.SortBy != config.SortListeners {
+		order = ", by " + name
+	}
+	if v.filterQuery == "" {
+		v.table.SetTitle(fmt.Sprintf("Stations (%d%s)", total, order))
+		return
+	}
+	v.table.SetTitle(fmt.Sprintf("Stations (%d of %d match \"%s\"%s)",
+		len(v.visible), total, tview.Escape(v.filterQuery), order))
+}
+
+// stationMatchesFilter reports whether every word of the query matches the
+// station, ignoring case. A word matches when it occurs in the title, genre,
+// or description, or fuzzily when its letters appear in order in the title
+// or genre names, so "grvsld" finds Groove Salad. Genres match by tag,
+// display name, or description. An empty query matches every station.
+func stationMatchesFilter(s *station.Station, query string, genres *genre.Translator) bool {
+	title := strings.ToLower(s.Title)
+	description := strings.ToLower(s.Description)
+	genreText := strings.ToLower(genreDisplayText(s.Genre, genres))
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		if strings.Contains(title, word) || strings.Contains(description, word) || genres.Matches(s.Genre, word) {
+			continue
+		}
+		if fuzzyIndexes(title, word) == nil && fuzzyIndexes(genreText, word) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// fuzzyIndexes returns the byte offsets in text of the leftmost runes that
+// spell out word in order, or nil if word is not a subsequence of text.
+func fuzzyIndexes(text, word string) []int {
+	want := []rune(word)
+	if len(want) == 0 {
+		return nil
+	}
+	indexes := make([]int, 0, len(want))
+	for i, r := range text {
+		if r == want[len(indexes)] {
+			indexes = append(indexes, i)
+			if len(indexes) == len(want) {
+				return indexes
+			}
+		}
+	}
+	return nil
+}
+
+// genreDisplayText joins the translated names of a pipe-separated genre string.
+func genreDisplayText(g string, genres *genre.Translator) string {
+	return strings.Join(genres.Names(g), ", ")
+}
+
+// highlightMatches escapes text for tview and wraps every case-insensitive
+// occurrence of each query word in color tags. A word that doesn't occur
+// but fuzzily matches has each of its matched letters highlighted.
+func highlightMatches(text, query, color string) string {
+	if query == "" {
+		return tview.Escape(text)
+	}
+
+	lowerText := strings.ToLower(text)
+	lowerQuery := strings.ToLower(query)
+	// Case folding changed byte offsets; indexes would not line up with text.
+	if len(lowerText) != len(text) || len(lowerQuery) != len(query) {
+		return tview.Escape(text)
+	}
+
+	type span struct{ start, end int }
+	var spans []span
+	for _, word := range strings.Fields(lowerQuery) {
+		if !strings.Contains(lowerText, word) {
+			for _, i := range fuzzyIndexes(lowerText, word) {
+				_, size := utf8.DecodeRuneInString(lowerText[i:])
+				spans = append(spans, span{i, i + size})
+			}
+			continue
+		}
+		for pos := 0; ; {
+			idx := strings.Index(lowerText[pos:], word)
+			if idx < 0 {
+				break
+			}
+			start := pos + idx
+			pos = start + len(word)
+			spans = append(spans, span{start, pos})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var b strings.Builder
+	pos := 0
+	for i := 0; i < len(spans); i++ {
+		start, end := max(spans[i].start, pos), spans[i].end
+		// Overlapping matches of different words become one highlight
+		for i+1 < len(spans) && spans[i+1].start < end {
+			i++
+			end = max(end, spans[i].end)
+		}
+		if start >= end {
+			continue
+		}
+		b.WriteString(tview.Escape(text[pos:start]))
+		fmt.Fprintf(&b, "[%s::u]%s[-::-]", color, tview.Escape(text[start:end]))
+		pos = end
+	}
+	b.WriteString(tview.Escape(text[pos:]))
+
+	return b.String()
+}
@@ -2,8 +2,13 @@ package ui
 
 import (
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/clock"
+	"github.com/glebovdev/somafm-cli/internal/format"
 	"github.com/glebovdev/somafm-cli/internal/player"
 	"github.com/rivo/tview"
 )
@@ -19,8 +24,19 @@ type StatusRenderer struct {
 	bufferHealth         int
 	bufferTickCount      int
 	bufferTicksPerUpdate int
+	bufferGraph          bool
 
 	primaryColor string
+
+	alarmAt      time.Time
+	alarmStation string
+
+	clock clock.Clock
+}
+
+// SetClock replaces the wall clock used for countdowns.
+func (s *StatusRenderer) SetClock(c clock.Clock) {
+	s.clock = c
 }
 
 func NewStatusRenderer(p *player.Player) *StatusRenderer {
@@ -36,10 +52,21 @@ func (s *StatusRenderer) SetMuted(muted bool) {
 	s.isMuted = muted
 }
 
+// SetBufferGraph swaps the buffer bars for a graph of the last minute.
+func (s *StatusRenderer) SetBufferGraph(on bool) {
+	s.bufferGraph = on
+}
+
 func (s *StatusRenderer) SetPrimaryColor(color string) {
 	s.primaryColor = color
 }
 
+// SetAlarm shows a countdown to at while idle. A zero time clears it.
+func (s *StatusRenderer) SetAlarm(at time.Time, stationTitle string) {
+	s.alarmAt = at
+	s.alarmStation = stationTitle
+}
+
 func (s *StatusRenderer) AdvanceAnimation() {
 	s.tickCount++
 	if s.tickCount >= s.ticksPerFrame {
@@ -82,6 +109,10 @@ func (s *StatusRenderer) Render() string {
 }
 
 func (s *StatusRenderer) renderIdle() string {
+	if !s.alarmAt.IsZero() {
+		return fmt.Sprintf("⏰ ALARM %s │ %s in %s",
+			s.alarmAt.Format("15:04"), tview.Escape(s.alarmStation), format.Duration(clock.Or(s.clock).Until(s.alarmAt)))
+	}
 	if s.isMuted {
 		return "○ IDLE │ [red]MUTED[-] │ Select a station"
 	}
@@ -102,22 +133,43 @@ func (s *StatusRenderer) renderPlaying() string {
 	}
 
 	parts := []string{dot + " LIVE"}
+	if behind := s.player.BehindLive(); behind > 0 {
+		parts[0] = fmt.Sprintf("%s [yellow]%s BEHIND[-]", dot, format.Duration(behind))
+	}
+
+	if s.player.IsRecording() {
+		parts = append(parts, "[red]● REC[-]")
+	}
+
+	if s.player.NightMode() {
+		parts = append(parts, "☾ NIGHT")
+	}
 
 	if s.isMuted {
 		parts = append(parts, "[red]MUTED[-]")
 	}
 
+	if keyword := s.player.GetHeldKeyword(); keyword != "" {
+		parts = append(parts, fmt.Sprintf("[yellow]ON HOLD (%s)[-]", tview.Escape(keyword)))
+	}
+
 	streamInfo := s.player.GetStreamInfo()
-	if streamInfo.Format != "" {
+	if c, ok := s.player.Comparison(); ok {
+		parts = append(parts, formatComparison(c))
+	} else if streamInfo.Format != "" {
 		sampleRateKHz := float64(streamInfo.SampleRate) / 1000.0
-		parts = append(parts, fmt.Sprintf("%s %s %dk %.1fkHz",
+		parts = append(parts, fmt.Sprintf("%s %s %s %.1fkHz",
 			streamInfo.Format,
 			qualityShort(streamInfo.Quality),
-			streamInfo.Bitrate,
+			formatBitrate(streamInfo.Bitrate, s.player.GetMeasuredBitrate()),
 			sampleRateKHz))
 	}
 
-	parts = append(parts, s.formatBufferHealth(s.bufferHealth))
+	if s.bufferGraph {
+		parts = append(parts, formatBufferGraph(s.player.HealthHistory(), clock.Or(s.clock).Now()))
+	} else {
+		parts = append(parts, s.formatBufferHealth(s.bufferHealth))
+	}
 
 	return joinParts(parts)
 }
@@ -125,6 +177,10 @@ func (s *StatusRenderer) renderPlaying() string {
 func (s *StatusRenderer) renderPaused() string {
 	parts := []string{PauseIcon + " PAUSED"}
 
+	if s.player.NightMode() {
+		parts = append(parts, "☾ NIGHT")
+	}
+
 	if s.isMuted {
 		parts = append(parts, "[red]MUTED[-]")
 	}
@@ -176,6 +232,72 @@ func (s *StatusRenderer) formatBufferHealth(percent int) string {
 	return bar
 }
 
+// bufferGraphCells is how wide the buffer graph is. Each cell shows the
+// lowest fill of the samples it covers, so short dips stay visible.
+const bufferGraphCells = 30
+
+// formatBufferGraph draws the buffer fill over the last minute as a
+// sparkline, newest on the right, with a red ! where the buffer ran dry.
+// Time without samples, before playback or while paused, stays blank.
+func formatBufferGraph(samples []player.HealthSample, now time.Time) string {
+	bars := []rune("▁▂▃▄▅▆▇█")
+	span := player.HealthInterval * player.HealthSamples / bufferGraphCells
+
+	var fill [bufferGraphCells]int
+	var dropout [bufferGraphCells]bool
+	for i := range fill {
+		fill[i] = -1
+	}
+	for _, sample := range samples {
+		age := now.Sub(sample.Time)
+		if age < 0 || age >= span*bufferGraphCells {
+			continue
+		}
+		i := bufferGraphCells - 1 - int(age/span)
+		if fill[i] < 0 || sample.Buffer < fill[i] {
+			fill[i] = sample.Buffer
+		}
+		dropout[i] = dropout[i] || sample.Dropout
+	}
+
+	var b strings.Builder
+	for i, percent := range fill {
+		switch {
+		case dropout[i]:
+			b.WriteString("[red]![-]")
+		case percent < 0:
+			b.WriteByte(' ')
+		default:
+			b.WriteRune(bars[min(percent, 100)*(len(bars)-1)/100])
+		}
+	}
+	return b.String()
+}
+
+// toggleBufferGraph switches the footer between the buffer bars and the
+// graph of the last minute.
+func (ui *UI) toggleBufferGraph() {
+	on := !ui.config.BufferGraph
+	ui.config.BufferGraph = on
+	ui.requestConfigSave()
+	ui.statusRenderer.SetBufferGraph(on)
+
+	if on {
+		ui.showNotice("Buffer graph on")
+	} else {
+		ui.showNotice("Buffer graph off")
+	}
+}
+
+// formatBitrate shows the nominal playlist bitrate alongside the measured
+// one, e.g. "128k (rx 131k)", once a measurement is available.
+func formatBitrate(nominal, measured int) string {
+	if measured <= 0 {
+		return fmt.Sprintf("%dk", nominal)
+	}
+	return fmt.Sprintf("%dk (rx %dk)", nominal, measured)
+}
+
 func qualityShort(quality string) string {
 	switch quality {
 	case "highest", "high":
@@ -200,68 +322,226 @@ func joinParts(parts []string) string {
 	return result
 }
 
-func (ui *UI) getPlaybackHint(keyColor string) string {
-	state := ui.player.GetState()
+// FooterView is the bar under the station list: key help on one side and
+// the playback status on the other. Notices replace the key help for
+// NoticeDisplayTime.
+type FooterView struct {
+	box         *tview.Box
+	notice      string
+	noticeUntil time.Time
+	muted       bool
+	lastWidth   int // Track width to detect layout changes
+	hintAreas   []hintArea
+	mu          sync.Mutex
+
+	app    *tview.Application
+	bus    *EventBus
+	colors palette
+	player *player.Player
+	status *StatusRenderer
+	clock  func() clock.Clock
+}
 
-	switch state {
+func newFooterView(ui *UI) *FooterView {
+	ui.mu.Lock()
+	muted := ui.isMuted
+	ui.mu.Unlock()
+
+	v := &FooterView{
+		muted:  muted,
+		app:    ui.app,
+		bus:    ui.bus,
+		colors: ui.colors,
+		player: ui.player,
+		status: ui.statusRenderer,
+		clock:  ui.timeSource,
+	}
+	v.box = v.createBox()
+
+	subscribe(ui.bus, func(e noticePosted) { v.showNotice(e.text) })
+	subscribe(ui.bus, func(e volumeChanged) { v.muted = e.muted })
+	return v
+}
+
+// showNotice asks the footer to replace its help text with a message for
+// NoticeDisplayTime.
+func (ui *UI) showNotice(message string) {
+	ui.bus.publish(noticePosted{text: message})
+}
+
+// activeNotice returns the notice the footer shows, if any.
+func (ui *UI) activeNotice() string {
+	if ui.footer == nil {
+		return ""
+	}
+	return ui.footer.activeNotice()
+}
+
+func (v *FooterView) showNotice(message string) {
+	v.mu.Lock()
+	v.notice = message
+	v.noticeUntil = v.clock().Now().Add(NoticeDisplayTime)
+	v.mu.Unlock()
+
+	// Redraw once the notice expires, even when nothing else triggers a draw
+	v.clock().AfterFunc(NoticeDisplayTime, func() {
+		v.app.QueueUpdateDraw(func() {})
+	})
+}
+
+func (v *FooterView) activeNotice() string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.notice == "" || v.clock().Now().After(v.noticeUntil) {
+		return ""
+	}
+	return v.notice
+}
+
+// footerHint is one piece of the key help. Clicking it presses key.
+type footerHint struct {
+	text string          // Color tags allowed
+	key  *tcell.EventKey // Nil for text that does nothing
+}
+
+// hintArea is the part of the screen a footerHint was drawn on.
+type hintArea struct {
+	x, y, width int
+	key         *tcell.EventKey
+}
+
+func runeKey(r rune) *tcell.EventKey {
+	return tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone)
+}
+
+func (v *FooterView) playbackHints(keyColor string) []footerHint {
+	enter := footerHint{fmt.Sprintf("[%s]Enter[-] play", keyColor), tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone)}
+	gap := footerHint{text: "  "}
+	space := func(label string) footerHint {
+		return footerHint{fmt.Sprintf("[%s]Space[-] %s", keyColor, label), runeKey(' ')}
+	}
+
+	switch v.player.GetState() {
 	case player.StatePaused:
-		return fmt.Sprintf("[%s]Enter[-] play  [%s]Space[-] resume", keyColor, keyColor)
+		return []footerHint{enter, gap, space("resume")}
 	case player.StatePlaying, player.StateBuffering, player.StateReconnecting:
-		return fmt.Sprintf("[%s]Enter[-] play  [%s]Space[-] pause", keyColor, keyColor)
+		return []footerHint{enter, gap, space("pause")}
 	default:
-		return fmt.Sprintf("[%s]Space[-] play", keyColor)
+		return []footerHint{space("play")}
 	}
 }
 
-func (ui *UI) getHelpText() string {
-	keyColor := ui.colors.helpHotkey.String()
-	playbackHint := ui.getPlaybackHint(keyColor)
+// hints returns the key help, piece by piece.
+func (v *FooterView) hints() []footerHint {
+	keyColor := v.colors.helpHotkey.String()
+	key := func(k string, r rune) footerHint {
+		return footerHint{fmt.Sprintf("[%s]%s[-]", keyColor, k), runeKey(r)}
+	}
+	labeled := func(k, label string) footerHint {
+		return footerHint{fmt.Sprintf("[%s]%s[-] %s", keyColor, k, label), runeKey([]rune(k)[0])}
+	}
+	gap := footerHint{text: "  "}
 
 	muteText := "mute"
-	if ui.isMuted {
+	if v.muted {
 		muteText = "unmute"
 	}
 
-	return fmt.Sprintf(" %s  [%s]+/-[-] vol  [%s]m[-] %s  [%s]?[-] help  [%s]a[-] about  [%s]q[-] quit ",
-		playbackHint, keyColor, keyColor, muteText, keyColor, keyColor, keyColor)
+	hints := []footerHint{{text: " "}}
+	hints = append(hints, v.playbackHints(keyColor)...)
+	return append(hints,
+		gap, key("+", '+'), footerHint{text: fmt.Sprintf("[%s]/[-]", keyColor)}, key("-", '-'), footerHint{text: " vol"},
+		gap, labeled("m", muteText),
+		gap, labeled("?", "help"),
+		gap, labeled("a", "about"),
+		gap, labeled("q", "quit"),
+		footerHint{text: " "})
 }
 
-func (ui *UI) handleFooterResize(width int) {
+// help returns the key hints, or the active notice cut to fit in width
+// cells.
+func (v *FooterView) help(width int) []footerHint {
+	if notice := v.activeNotice(); notice != "" {
+		notice = truncateWidth(notice, width-2)
+		return []footerHint{{text: fmt.Sprintf(" [%s]%s[-] ", v.colors.highlight.String(), tview.Escape(notice))}}
+	}
+	return v.hints()
+}
+
+// printHelp draws the help centered on row y and remembers where each
+// clickable hint landed.
+func (v *FooterView) printHelp(screen tcell.Screen, help []footerHint, x, y, width int) {
+	var b strings.Builder
+	for _, h := range help {
+		b.WriteString(h.text)
+	}
+	text := b.String()
+	tview.Print(screen, text, x, y, width, tview.AlignCenter, v.colors.helpForeground)
+
+	v.hintAreas = v.hintAreas[:0]
+	textWidth := tview.TaggedStringWidth(text)
+	if textWidth > width {
+		return
+	}
+	// Where tview.Print puts centered text that fits
+	col := x + width/2 - textWidth/2
+	for _, h := range help {
+		w := tview.TaggedStringWidth(h.text)
+		if h.key != nil {
+			v.hintAreas = append(v.hintAreas, hintArea{x: col, y: y, width: w, key: h.key})
+		}
+		col += w
+	}
+}
+
+// hintAt returns the key of the hint drawn at the screen position.
+func (v *FooterView) hintAt(x, y int) *tcell.EventKey {
+	for _, a := range v.hintAreas {
+		if y == a.y && x >= a.x && x < a.x+a.width {
+			return a.key
+		}
+	}
+	return nil
+}
+
+// handleResize asks for a taller or shorter footer when the width crosses
+// FooterBreakpoint.
+func (v *FooterView) handleResize(width int) {
 	isWide := width >= FooterBreakpoint
-	wasWide := ui.lastFooterWidth >= FooterBreakpoint
+	wasWide := v.lastWidth >= FooterBreakpoint
 
-	if ui.lastFooterWidth > 0 && isWide != wasWide && ui.contentLayout != nil {
+	if v.lastWidth > 0 && isWide != wasWide {
 		newHeight := FooterHeightWide
 		if !isWide {
 			newHeight = FooterHeightNarrow
 		}
-		ui.contentLayout.ResizeItem(ui.helpPanel, newHeight, 0)
+		v.bus.publish(footerResized{height: newHeight})
 	}
-	ui.lastFooterWidth = width
+	v.lastWidth = width
 }
 
-func (ui *UI) drawWideFooter(screen tcell.Screen, x, y, width, height int, helpText, statusText string) {
+func (v *FooterView) drawWide(screen tcell.Screen, x, y, width, height int, help []footerHint, statusText string) {
 	helpWidth := width / 2
 	statusWidth := width - helpWidth
 
 	for row := y; row < y+height; row++ {
 		for col := x; col < x+helpWidth; col++ {
-			screen.SetContent(col, row, ' ', nil, tcell.StyleDefault.Background(ui.colors.helpBackground))
+			screen.SetContent(col, row, ' ', nil, tcell.StyleDefault.Background(v.colors.helpBackground))
 		}
 	}
 
 	for row := y; row < y+height; row++ {
 		for col := x + helpWidth; col < x+width; col++ {
-			screen.SetContent(col, row, ' ', nil, tcell.StyleDefault.Background(ui.colors.background))
+			screen.SetContent(col, row, ' ', nil, tcell.StyleDefault.Background(v.colors.background))
 		}
 	}
 
 	centerY := y + height/2
-	tview.Print(screen, helpText, x, centerY, helpWidth, tview.AlignCenter, ui.colors.helpForeground)
-	tview.Print(screen, statusText, x+helpWidth, centerY, statusWidth-2, tview.AlignRight, ui.colors.foreground)
+	v.printHelp(screen, help, x, centerY, helpWidth)
+	tview.Print(screen, statusText, x+helpWidth, centerY, statusWidth-2, tview.AlignRight, v.colors.foreground)
 }
 
-func (ui *UI) drawNarrowFooter(screen tcell.Screen, x, y, width, height int, helpText, statusText string) {
+func (v *FooterView) drawNarrow(screen tcell.Screen, x, y, width, height int, help []footerHint, statusText string) {
 	helpHeight := height / 2
 	if helpHeight < 1 {
 		helpHeight = 1
@@ -271,48 +551,63 @@ func (ui *UI) drawNarrowFooter(screen tcell.Screen, x, y, width, height int, hel
 
 	for row := y; row < helpBoxEnd; row++ {
 		for col := x; col < x+width; col++ {
-			screen.SetContent(col, row, ' ', nil, tcell.StyleDefault.Background(ui.colors.helpBackground))
+			screen.SetContent(col, row, ' ', nil, tcell.StyleDefault.Background(v.colors.helpBackground))
 		}
 	}
 
 	for row := helpBoxEnd; row < y+height; row++ {
 		for col := x; col < x+width; col++ {
-			screen.SetContent(col, row, ' ', nil, tcell.StyleDefault.Background(ui.colors.background))
+			screen.SetContent(col, row, ' ', nil, tcell.StyleDefault.Background(v.colors.background))
 		}
 	}
 
 	helpTextY := y + helpHeight/2
-	tview.Print(screen, helpText, x, helpTextY, width, tview.AlignCenter, ui.colors.helpForeground)
+	v.printHelp(screen, help, x, helpTextY, width)
 
 	if statusHeight > 0 {
 		statusTextY := helpBoxEnd + statusHeight/2
-		tview.Print(screen, statusText, x, statusTextY, width-2, tview.AlignRight, ui.colors.foreground)
+		tview.Print(screen, statusText, x, statusTextY, width-2, tview.AlignRight, v.colors.foreground)
 	}
 }
 
-func (ui *UI) createFooter() *tview.Box {
-	box := tview.NewBox().SetBackgroundColor(ui.colors.background)
+func (v *FooterView) createBox() *tview.Box {
+	box := tview.NewBox().SetBackgroundColor(v.colors.background)
 
 	box.SetDrawFunc(func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
-		ui.handleFooterResize(width)
-
-		helpText := ui.getHelpText()
-		statusText := " " + ui.statusRenderer.Render() + " "
+		v.handleResize(width)
 
 		isWide := width >= FooterBreakpoint
+		helpWidth := width
+		if isWide {
+			helpWidth = width / 2
+		}
+		help := v.help(helpWidth)
+		statusText := " " + v.status.Render() + " "
+
 		usedHeight := height
 		if isWide && height > FooterHeightWide {
 			usedHeight = FooterHeightWide
 		}
 
 		if isWide {
-			ui.drawWideFooter(screen, x, y, width, usedHeight, helpText, statusText)
+			v.drawWide(screen, x, y, width, usedHeight, help, statusText)
 		} else {
-			ui.drawNarrowFooter(screen, x, y, width, height, helpText, statusText)
+			v.drawNarrow(screen, x, y, width, height, help, statusText)
 		}
 
 		return x, y, width, height
 	})
 
+	box.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+		if action != tview.MouseLeftClick {
+			return action, event
+		}
+		if key := v.hintAt(event.Position()); key != nil {
+			v.bus.publish(hintClicked{key: key})
+			return tview.MouseConsumed, nil
+		}
+		return action, event
+	})
+
 	return box
 }
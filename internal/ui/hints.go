@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/clock"
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/glebovdev/somafm-cli/internal/station"
+)
+
+const (
+	// HintDelay is how long a non-favorite station must stay selected
+	// before suggesting the favorite key.
+	HintDelay = 8 * time.Second
+	// hintNavigationThreshold is how many selection changes without a
+	// filter suggest the filter key.
+	hintNavigationThreshold = 20
+
+	hintFavorite = "favorite"
+	hintFilter   = "filter"
+	hintOSD      = "osd"
+)
+
+// hintState tracks which keybinding hints were shown this session. Each
+// hint is shown at most once. Guarded by UI.mu.
+type hintState struct {
+	shown       map[string]bool
+	timer       clock.Timer
+	navigations int
+}
+
+// stationListRunes are navigation keys handled by the station table itself.
+var stationListRunes = map[rune]bool{'j': true, 'k': true, 'g': true, 'G': true}
+
+// showUnboundKey tells the user a key does nothing rather than silently
+// ignoring it. Unlike hints this is always shown: it answers a key press.
+func (ui *UI) showUnboundKey(r rune) {
+	ui.showNotice(fmt.Sprintf("Unbound key '%c' — press ? for help", r))
+}
+
+// showHint displays a coaching hint in the footer unless hints are disabled,
+// it was already shown, or another notice is active.
+func (ui *UI) showHint(id, message string) {
+	if !ui.config.Hints || ui.activeNotice() != "" {
+		return
+	}
+
+	ui.mu.Lock()
+	if ui.hints.shown == nil {
+		ui.hints.shown = make(map[string]bool)
+	}
+	if ui.hints.shown[id] {
+		ui.mu.Unlock()
+		return
+	}
+	ui.hints.shown[id] = true
+	ui.mu.Unlock()
+
+	ui.showNotice("Tip: " + message)
+}
+
+// onSelectionHint reacts to the station list selection moving.
+func (ui *UI) onSelectionHint(stationID station.StationID, title string) {
+	if !ui.config.Hints {
+		return
+	}
+
+	ui.mu.Lock()
+	ui.hints.navigations++
+	navigations := ui.hints.navigations
+	if ui.hints.timer != nil {
+		ui.hints.timer.Stop()
+		ui.hints.timer = nil
+	}
+	if !ui.config.IsFavorite(stationID) {
+		ui.hints.timer = ui.timeSource().AfterFunc(HintDelay, func() {
+			ui.app.QueueUpdateDraw(func() {
+				if ui.stations.selectedID == stationID && !ui.config.IsFavorite(stationID) {
+					ui.showHint(hintFavorite, fmt.Sprintf("press f to add %s to favorites", title))
+				}
+			})
+		})
+	}
+	ui.mu.Unlock()
+
+	if navigations >= hintNavigationThreshold && ui.stations.filterQuery == "" {
+		ui.showHint(hintFilter, "press / to filter stations by name, genre, or description")
+	}
+}
+
+// checkPlaybackHint suggests the OSD once playback is live.
+func (ui *UI) checkPlaybackHint() {
+	if ui.player.GetState() == player.StatePlaying {
+		ui.showHint(hintOSD, "press o for a big-text now playing view")
+	}
+}
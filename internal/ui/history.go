@@ -0,0 +1,468 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/history"
+	"github.com/glebovdev/somafm-cli/internal/likes"
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/glebovdev/somafm-cli/internal/summary"
+	"github.com/rivo/tview"
+	"github.com/rs/zerolog/log"
+)
+
+// timelineStationColors are assigned to stations in order of appearance
+// on a day, so neighbouring blocks of different stations stay distinct.
+var timelineStationColors = []tcell.Color{
+	tcell.NewHexColor(0x5f87af),
+	tcell.NewHexColor(0x87af5f),
+	tcell.NewHexColor(0xaf5f87),
+	tcell.NewHexColor(0xaf875f),
+	tcell.NewHexColor(0x5fafaf),
+	tcell.NewHexColor(0x875faf),
+}
+
+// recordListen adds a track to the history once it stops playing, unless
+// it played for less than the configured minimum. Pauses don't count.
+func (ui *UI) recordListen(l player.TrackListen) {
+	if ui.history == nil || l.Station == nil {
+		return
+	}
+	// A track worth a note is worth keeping, however briefly it played
+	if heard := l.Heard(); heard < ui.config.History.MinListen && l.Note == "" {
+		log.Debug().Msgf("Not recording %q, heard for %v", l.Track, heard.Round(time.Second))
+		return
+	}
+	entry := history.Entry{
+		Station:      l.Station.ID.String(),
+		StationTitle: l.Station.Title,
+		Track:        l.Track,
+		Start:        l.Start,
+		End:          l.End,
+		Paused:       l.Paused(),
+		Note:         l.Note,
+	}
+	if _, err := ui.history.Add(entry); err != nil {
+		log.Warn().Err(err).Msg("Failed to save listening history")
+	}
+}
+
+// exportSession writes the latest listening session as Markdown to a file
+// in the home directory, named for when the session started.
+func (ui *UI) exportSession() {
+	var liked []likes.Track
+	if ui.likes != nil {
+		liked = ui.likes.Tracks()
+	}
+	session, ok := summary.Last(ui.history.Entries(), liked, summary.Gap)
+	if !ok {
+		ui.showNotice("No listening session to export")
+		return
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		log.Error().Err(err).Msg("No home directory for export")
+		ui.showNotice("Export failed: no home directory")
+		return
+	}
+	path := filepath.Join(home, fmt.Sprintf("somafm-session-%s.md", session.Start.In(ui.timeZone()).Format("2006-01-02-1504")))
+	var buf bytes.Buffer
+	if err := summary.Markdown(&buf, session, ui.timeZone()); err == nil {
+		err = os.WriteFile(path, buf.Bytes(), 0644)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("path", path).Msg("Failed to export session")
+		ui.showNotice("Export failed: " + err.Error())
+		return
+	}
+	log.Debug().Str("path", path).Int("tracks", session.Tracks).Msg("Exported session")
+	ui.showNotice("Exported this session to " + path)
+}
+
+// noteModalWidth fits a note of a sentence or two.
+const noteModalWidth = 64
+
+// showNoteInput asks for a note on the playing track, e.g. "the one from
+// that movie", which is saved with its history entry once the track ends.
+// An existing note is offered for editing; clearing it removes it.
+func (ui *UI) showNoteInput() {
+	if ui.history == nil || ui.currentStation == nil {
+		return
+	}
+	track := ui.player.GetCurrentTrack()
+	if track == "" || track == player.NoTrackInfo {
+		ui.showNotice("Nothing to note yet — no track title")
+		return
+	}
+
+	input := tview.NewInputField().
+		SetLabel("✎ ").
+		SetLabelColor(ui.colors.highlight).
+		SetFieldBackgroundColor(ui.colors.modalBackground).
+		SetFieldTextColor(ui.colors.foreground).
+		SetPlaceholder("e.g. the one from that movie").
+		SetPlaceholderTextColor(ui.colors.borders).
+		SetText(ui.player.TrackNote())
+	input.SetBackgroundColor(ui.colors.modalBackground)
+	input.SetDoneFunc(func(key tcell.Key) {
+		ui.modals.close(modalPage)
+		if key == tcell.KeyEnter {
+			ui.saveTrackNote(track, strings.TrimSpace(input.GetText()))
+		}
+	})
+
+	frame := tview.NewFrame(input).
+		SetBorders(0, 0, 0, 0, 1, 1)
+	frame.SetBorder(true).
+		SetBorderColor(ui.colors.borders).
+		SetBackgroundColor(ui.colors.modalBackground).
+		SetTitle(" Note: " + tview.Escape(truncateWidth(track, noteModalWidth-10)) + " ").
+		SetTitleColor(ui.colors.highlight).
+		SetTitleAlign(tview.AlignCenter)
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(frame, 3, 0, true).
+			AddItem(nil, 0, 1, false),
+			noteModalWidth, 0, true).
+		AddItem(nil, 0, 1, false)
+	modal.SetBackgroundColor(ui.colors.background)
+
+	ui.modals.open(modalPage, modal, input)
+}
+
+// saveTrackNote attaches note to track, unless another track started
+// while it was typed.
+func (ui *UI) saveTrackNote(track, note string) {
+	hadNote := ui.player.TrackNote() != ""
+	if !ui.player.SetTrackNote(track, note) {
+		ui.showNotice("The track changed — note not saved")
+		return
+	}
+	switch {
+	case note != "":
+		ui.showNotice("✎ Noted — saved to history when the track ends")
+	case hadNote:
+		ui.showNotice("Note removed")
+	}
+}
+
+// noteText formats an entry's note for a detail line.
+func noteText(note string) string {
+	if note == "" {
+		return ""
+	}
+	return " ✎ " + tview.Escape(note)
+}
+
+// timelineColors maps each station of a day to a color.
+func timelineColors(day history.Day) map[string]tcell.Color {
+	colors := make(map[string]tcell.Color)
+	for _, span := range day.Spans {
+		if _, ok := colors[span.Station]; !ok {
+			colors[span.Station] = timelineStationColors[len(colors)%len(timelineStationColors)]
+		}
+	}
+	return colors
+}
+
+// renderTimeline draws a day as one row per hour with a cell per minute.
+// Cells are colored by station, a track start is marked with │, and the
+// selected track is drawn in the highlight color.
+func renderTimeline(day history.Day, selected int, highlight, empty tcell.Color) string {
+	if len(day.Spans) == 0 {
+		return ""
+	}
+	colors := timelineColors(day)
+	loc := day.Date.Location()
+
+	firstHour := day.Spans[0].Start.In(loc).Hour()
+	lastEnd := day.Spans[len(day.Spans)-1].End.In(loc)
+	lastHour := lastEnd.Hour()
+	if !lastEnd.Before(day.Date.AddDate(0, 0, 1)) {
+		lastHour = 23
+	}
+
+	var b strings.Builder
+	span := 0
+	for hour := firstHour; hour <= lastHour; hour++ {
+		fmt.Fprintf(&b, "[%s]%02d:00[-] ", empty, hour)
+		prevTag := ""
+		for minute := 0; minute < 60; minute++ {
+			cellStart := day.Date.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+			cellEnd := cellStart.Add(time.Minute)
+			for span < len(day.Spans)-1 && !day.Spans[span].End.After(cellStart) {
+				span++
+			}
+
+			tag, char := fmt.Sprintf("[%s:-]", empty), "·"
+			s := day.Spans[span]
+			if s.Start.Before(cellEnd) && s.End.After(cellStart) {
+				bg := colors[s.Station]
+				if span == selected {
+					bg = highlight
+				}
+				tag, char = fmt.Sprintf("[#000000:%s]", bg), " "
+				if !s.Start.Before(cellStart) {
+					char = "│"
+				}
+			}
+			if tag != prevTag {
+				b.WriteString(tag)
+				prevTag = tag
+			}
+			b.WriteString(char)
+		}
+		b.WriteString("[-:-]\n")
+	}
+
+	b.WriteString("\n")
+	seen := make(map[string]bool)
+	for _, s := range day.Spans {
+		if seen[s.Station] {
+			continue
+		}
+		seen[s.Station] = true
+		fmt.Fprintf(&b, "[%s]■[-] %s  ", colors[s.Station], tview.Escape(s.StationTitle))
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+// fillHistoryList lists history entries, most recent first.
+func (ui *UI) fillHistoryList(table *tview.Table, entries []history.Entry) {
+	table.Clear()
+	headers := []string{"Time", "Station", "Track"}
+	for col, h := range headers {
+		table.SetCell(0, col, tview.NewTableCell(h).
+			SetTextColor(ui.colors.highlight).
+			SetSelectable(false))
+	}
+	for i := range entries {
+		e := entries[len(entries)-1-i]
+		row := i + 1
+		table.SetCell(row, 0, tview.NewTableCell(e.Start.In(ui.timeZone()).Format("Jan 02 15:04")).SetTextColor(ui.colors.borders))
+		table.SetCell(row, 1, tview.NewTableCell(tview.Escape(e.StationTitle)).SetMaxWidth(20))
+		track := tview.Escape(e.Track)
+		if e.Note != "" {
+			track += " ✎"
+		}
+		table.SetCell(row, 2, tview.NewTableCell(track).SetMaxWidth(44).SetExpansion(1))
+	}
+}
+
+func (ui *UI) showHistoryModal() {
+	if ui.history == nil {
+		return
+	}
+	keyColor := ui.colors.helpHotkey.String()
+	entries := ui.history.Entries()
+	loc := ui.timeZone()
+	days := history.Days(entries, loc)
+	timeline := false
+	day, selected := len(days)-1, 0
+	if day >= 0 {
+		selected = len(days[day].Spans) - 1
+	}
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBackgroundColor(ui.colors.modalBackground)
+	table.SetSelectedStyle(tcell.StyleDefault.
+		Background(ui.colors.highlight).
+		Foreground(ui.colors.modalBackground))
+	ui.fillHistoryList(table, entries)
+
+	timelineView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(false)
+	timelineView.SetTextColor(ui.colors.foreground)
+	timelineView.SetBackgroundColor(ui.colors.modalBackground)
+
+	detailView := tview.NewTextView().
+		SetDynamicColors(true)
+	detailView.SetTextColor(ui.colors.foreground)
+	detailView.SetBackgroundColor(ui.colors.modalBackground)
+
+	hintView := tview.NewTextView().
+		SetTextAlign(tview.AlignCenter).
+		SetDynamicColors(true)
+	hintView.SetTextColor(tcell.ColorDarkGray)
+	hintView.SetBackgroundColor(ui.colors.modalBackground)
+
+	body := tview.NewPages()
+	frame := tview.NewFrame(nil)
+
+	render := func() {
+		if !timeline || day < 0 {
+			body.SwitchToPage("list")
+			frame.SetTitle(fmt.Sprintf(" Listening History (%d) ", len(entries)))
+			detailView.SetText("")
+			if row, _ := table.GetSelection(); row >= 1 && row <= len(entries) {
+				detailView.SetText(strings.TrimSpace(noteText(entries[len(entries)-row].Note)))
+			}
+			hintView.SetText(fmt.Sprintf("[::d][%s]t[-] timeline • [%s]e[-] export session • Esc close[::-]", keyColor, keyColor))
+			return
+		}
+		d := days[day]
+		s := d.Spans[selected]
+		body.SwitchToPage("timeline")
+		frame.SetTitle(fmt.Sprintf(" Timeline: %s (%d/%d) ", d.Date.Format("Mon Jan 2, 2006"), day+1, len(days)))
+		timelineView.SetText(renderTimeline(d, selected, ui.colors.highlight, ui.colors.borders))
+		timelineView.ScrollToBeginning()
+		detailView.SetText(fmt.Sprintf("[%s]%s–%s[-] %s — %s%s",
+			ui.colors.highlight,
+			s.Start.In(loc).Format("15:04"), s.End.In(loc).Format("15:04"),
+			tview.Escape(s.StationTitle), tview.Escape(s.Track), noteText(s.Note)))
+		hintView.SetText(fmt.Sprintf("[::d][%s]←/→[-] track • [%s]↑/↓[-] day • [%s]t[-] list • [%s]e[-] export • Esc close[::-]", keyColor, keyColor, keyColor, keyColor))
+	}
+
+	if len(entries) == 0 {
+		empty := tview.NewTextView().
+			SetTextAlign(tview.AlignCenter).
+			SetText("\nNo listening history yet.\n\nTracks are recorded here as you listen.")
+		empty.SetTextColor(ui.colors.foreground)
+		empty.SetBackgroundColor(ui.colors.modalBackground)
+		body.AddPage("list", empty, true, true)
+	} else {
+		body.AddPage("list", table, true, true)
+	}
+	body.AddPage("timeline", timelineView, true, false)
+
+	content := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(body, 0, 1, true).
+		AddItem(detailView, 1, 0, false).
+		AddItem(hintView, 1, 0, false)
+	content.SetBackgroundColor(ui.colors.modalBackground)
+
+	frame.SetPrimitive(content).
+		SetBorders(1, 0, 0, 0, 2, 2)
+	frame.SetBorder(true).
+		SetBorderColor(ui.colors.borders).
+		SetBackgroundColor(ui.colors.modalBackground).
+		SetTitleColor(ui.colors.highlight).
+		SetTitleAlign(tview.AlignCenter)
+
+	table.SetSelectionChangedFunc(func(int, int) {
+		render()
+	})
+	render()
+
+	modalWidth := 84
+	modalHeight := min(len(entries), 20) + 9
+	for _, d := range days {
+		hours := d.Spans[len(d.Spans)-1].End.In(loc).Hour() - d.Spans[0].Start.In(loc).Hour() + 1
+		modalHeight = max(modalHeight, min(hours, 24)+9)
+	}
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(frame, modalHeight, 0, true).
+			AddItem(nil, 0, 1, false),
+			modalWidth, 0, true).
+		AddItem(nil, 0, 1, false)
+	modal.SetBackgroundColor(ui.colors.background)
+
+	closeModal := func() {
+		ui.modals.close(modalPage)
+	}
+
+	// dayOfEntry finds the day and span of the entry at list row
+	dayOfEntry := func(row int) (int, int) {
+		index := len(entries) - row
+		for d := range days {
+			if index < len(days[d].Spans) {
+				return d, index
+			}
+			index -= len(days[d].Spans)
+		}
+		return len(days) - 1, len(days[len(days)-1].Spans) - 1
+	}
+
+	modal.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			closeModal()
+			return nil
+		}
+		if event.Key() == tcell.KeyRune {
+			switch event.Rune() {
+			case 't', 'T':
+				if day >= 0 {
+					if !timeline {
+						if row, _ := table.GetSelection(); row >= 1 {
+							day, selected = dayOfEntry(row)
+						}
+					}
+					timeline = !timeline
+					render()
+				}
+				return nil
+			case 'e', 'E':
+				ui.exportSession()
+				return nil
+			case 'h', 'H', 'q', 'Q':
+				closeModal()
+				return nil
+			}
+		}
+
+		if !timeline {
+			switch event.Key() {
+			case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn, tcell.KeyHome, tcell.KeyEnd:
+				return event
+			case tcell.KeyRune:
+				if r := event.Rune(); r == 'j' || r == 'k' {
+					return event
+				}
+			}
+			return nil
+		}
+
+		switch event.Key() {
+		case tcell.KeyLeft:
+			if selected > 0 {
+				selected--
+			} else if day > 0 {
+				day--
+				selected = len(days[day].Spans) - 1
+			}
+		case tcell.KeyRight:
+			if selected < len(days[day].Spans)-1 {
+				selected++
+			} else if day < len(days)-1 {
+				day++
+				selected = 0
+			}
+		case tcell.KeyUp, tcell.KeyPgUp:
+			if day > 0 {
+				day--
+				selected = 0
+			}
+		case tcell.KeyDown, tcell.KeyPgDn:
+			if day < len(days)-1 {
+				day++
+				selected = 0
+			}
+		case tcell.KeyHome:
+			selected = 0
+		case tcell.KeyEnd:
+			selected = len(days[day].Spans) - 1
+		default:
+			return nil
+		}
+		render()
+		return nil
+	})
+
+	ui.modals.open(modalPage, modal, body)
+}
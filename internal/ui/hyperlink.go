@@ -0,0 +1,18 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/rivo/tview"
+)
+
+// hyperlink returns text in color, tagged to open url when clicked.
+// tcell writes the link as an OSC 8 escape only to terminals that look
+// like they handle it, so the others show plain text. With enabled false,
+// as with hyperlinks: false, it is only text.
+func hyperlink(url, text, color string, enabled bool) string {
+	if !enabled {
+		return fmt.Sprintf("[%s]%s[-]", color, tview.Escape(text))
+	}
+	return fmt.Sprintf("[%s:::%s]%s[-:::-]", color, url, tview.Escape(text))
+}
@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/format"
+	"github.com/rs/zerolog/log"
+)
+
+// IdleStopWarning is how long before an idle stop a warning is shown.
+const IdleStopWarning = 60 * time.Second
+
+// checkIdleStop stops playback once nobody has touched the player for the
+// configured idle_stop time, warning a minute before. It runs every second.
+func (ui *UI) checkIdleStop() {
+	limit := ui.config.IdleStop
+	if limit <= 0 || !ui.player.IsPlaying() {
+		ui.idleWarning = false
+		return
+	}
+	if ui.lastInput.IsZero() {
+		ui.lastInput = ui.timeSource().Now()
+		return
+	}
+
+	idle := ui.timeSource().Since(ui.lastInput)
+	switch {
+	case idle >= limit:
+		ui.idleWarning = false
+		log.Info().Msgf("No input for %v, stopping playback", limit)
+		ui.stopPlayback()
+		ui.showNotice(fmt.Sprintf("Stopped after %s without input", format.Duration(limit)))
+	case idle >= limit-IdleStopWarning:
+		ui.idleWarning = true
+		ui.showNotice(fmt.Sprintf("Idle — stopping in %s, press any key to keep playing", format.Duration(limit-idle)))
+	}
+}
+
+// cancelIdleStop dismisses the idle stop warning, if showing, and reports
+// whether it did, so the key that dismissed it isn't also acted on.
+func (ui *UI) cancelIdleStop() bool {
+	if !ui.idleWarning {
+		return false
+	}
+	ui.idleWarning = false
+	ui.showNotice("Still here — playback continues")
+	return true
+}
@@ -0,0 +1,404 @@
+package ui
+
+import (
+	"image"
+	"math"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/service"
+	"github.com/glebovdev/somafm-cli/internal/termimg"
+	"github.com/rivo/tview"
+	"github.com/rs/zerolog/log"
+)
+
+// Tests replace these, since there is no terminal to ask.
+var (
+	detectGraphics = termimg.Detect
+	cellSize       = termimg.CellSize
+)
+
+// Cell size assumed when logos is set to a protocol but the terminal
+// doesn't report its size in pixels.
+const (
+	fallbackCellWidth  = 10
+	fallbackCellHeight = 20
+)
+
+// imageRenderer draws station logos. textRenderer works everywhere with
+// characters; graphicsRenderer sends the real pixels with the terminal's
+// graphics protocol.
+type imageRenderer interface {
+	// load fetches the image at url sized for cols x rows cells. It runs
+	// off the UI thread.
+	load(s *service.StationService, url string, cols, rows int) (image.Image, error)
+	// newView returns an empty view to show an image in.
+	newView(colors palette) imageView
+	// flush runs after every frame is drawn.
+	flush(screen tcell.Screen)
+	// reset forgets what is on the screen, which is a new one.
+	reset()
+}
+
+// imageView is a primitive showing one image.
+type imageView interface {
+	tview.Primitive
+	setImage(img image.Image)
+	SetDrawFunc(handler func(screen tcell.Screen, x, y, width, height int) (int, int, int, int)) *tview.Box
+}
+
+// newImageRenderer picks the renderer for the logos and cover_art
+// settings.
+func newImageRenderer(logos, coverArt string) imageRenderer {
+	switch coverArt {
+	case config.CoverArtBlocks, config.CoverArtASCII, config.CoverArtOff:
+		return textRenderer{mode: coverArt}
+	}
+	text := textRenderer{mode: config.CoverArtAuto}
+
+	var protocol termimg.Protocol
+	switch logos {
+	case config.LogosBlocks:
+		return textRenderer{mode: config.CoverArtBlocks}
+	case config.LogosSixel:
+		protocol = termimg.Sixel
+	case config.LogosKitty:
+		protocol = termimg.Kitty
+	case config.LogosITerm2:
+		protocol = termimg.ITerm2
+	default:
+		protocol = detectGraphics(termimg.DefaultTimeout)
+		if protocol == termimg.None {
+			return text
+		}
+	}
+
+	width, height, ok := cellSize()
+	if !ok {
+		if logos == config.LogosAuto {
+			log.Debug().Stringer("protocol", protocol).Msg("Terminal reports no cell size, drawing logos with characters")
+			return text
+		}
+		width, height = fallbackCellWidth, fallbackCellHeight
+	}
+	log.Debug().Stringer("protocol", protocol).Int("cell_width", width).Int("cell_height", height).Msg("Drawing logos with terminal graphics")
+	return &graphicsRenderer{protocol: protocol, cellWidth: width, cellHeight: height}
+}
+
+// imageRenderer returns the renderer for logos, half blocks when the UI
+// was built without NewUI.
+func (ui *UI) imageRenderer() imageRenderer {
+	if ui.images == nil {
+		return textRenderer{mode: config.CoverArtBlocks}
+	}
+	return ui.images
+}
+
+// textRenderer draws logos with characters: half blocks, two pixels per
+// cell, or ASCII art, which looks the same in any number of colors.
+type textRenderer struct {
+	mode string // A cover_art value
+}
+
+func (r textRenderer) load(s *service.StationService, url string, cols, rows int) (image.Image, error) {
+	if r.mode == config.CoverArtOff {
+		return nil, nil
+	}
+	return s.LoadImageVariant(url, cols, rows)
+}
+
+func (r textRenderer) newView(colors palette) imageView {
+	v := &textView{
+		Box:    tview.NewBox(),
+		mode:   r.mode,
+		blocks: tview.NewImage(),
+		style:  tcell.StyleDefault.Foreground(colors.foreground).Background(colors.background),
+		dark:   isDark(colors.background),
+	}
+	v.SetBackgroundColor(colors.background)
+	v.blocks.SetBackgroundColor(colors.background)
+	v.blocks.SetAlign(tview.AlignLeft, tview.AlignTop)
+	return v
+}
+
+func (textRenderer) flush(tcell.Screen) {}
+
+func (textRenderer) reset() {}
+
+// textView draws its image with half blocks, or as ASCII art when its
+// mode asks for it or, in auto mode, the screen has no true colors to
+// draw the blocks in.
+type textView struct {
+	*tview.Box
+	img    image.Image
+	mode   string
+	blocks *tview.Image
+	style  tcell.Style
+	dark   bool
+}
+
+func (v *textView) setImage(img image.Image) {
+	v.img = img
+	if img != nil {
+		v.blocks.SetImage(img)
+	}
+}
+
+func (v *textView) Draw(screen tcell.Screen) {
+	v.DrawForSubclass(screen, v)
+	if v.img == nil {
+		return
+	}
+	x, y, width, height := v.GetInnerRect()
+	if v.mode == config.CoverArtBlocks || v.mode == config.CoverArtAuto && screen.Colors() >= tview.TrueColor {
+		v.blocks.SetRect(x, y, width, height)
+		v.blocks.Draw(screen)
+		return
+	}
+	for row, line := range asciiArt(v.img, width, height, v.dark) {
+		for col := 0; col < len(line); col++ {
+			screen.SetContent(x+col, y+row, rune(line[col]), nil, v.style)
+		}
+	}
+}
+
+// asciiRamp runs from an empty cell to the densest one.
+const asciiRamp = " .:-=+*#%@"
+
+// asciiArt draws img in at most cols x rows cells, which are about twice
+// as tall as wide, as lines of asciiRamp characters. The ink of a cell is
+// how much it stands out from the background, bright pixels on a dark
+// one, and is dithered so gradients don't turn into bands.
+func asciiArt(img image.Image, cols, rows int, dark bool) []string {
+	bounds := img.Bounds()
+	if bounds.Empty() || cols <= 0 || rows <= 0 {
+		return nil
+	}
+	scale := min(float64(cols)/float64(bounds.Dx()), 2*float64(rows)/float64(bounds.Dy()))
+	cols = max(1, int(float64(bounds.Dx())*scale))
+	rows = max(1, int(float64(bounds.Dy())*scale/2))
+
+	ink := make([]float64, cols*rows)
+	for row := range rows {
+		y0 := bounds.Min.Y + row*bounds.Dy()/rows
+		y1 := max(y0+1, bounds.Min.Y+(row+1)*bounds.Dy()/rows)
+		for col := range cols {
+			x0 := bounds.Min.X + col*bounds.Dx()/cols
+			x1 := max(x0+1, bounds.Min.X+(col+1)*bounds.Dx()/cols)
+			ink[row*cols+col] = cellInk(img, image.Rect(x0, y0, x1, y1), dark)
+		}
+	}
+
+	steps := float64(len(asciiRamp) - 1)
+	lines := make([]string, rows)
+	line := make([]byte, cols)
+	for row := range rows {
+		for col := range cols {
+			i := row*cols + col
+			level := math.Round(min(max(ink[i], 0), 1) * steps)
+			line[col] = asciiRamp[int(level)]
+
+			// Floyd-Steinberg: pass what was rounded away on to the
+			// neighbors not drawn yet
+			spill := ink[i] - level/steps
+			if col+1 < cols {
+				ink[i+1] += spill * 7 / 16
+			}
+			if row+1 < rows {
+				if col > 0 {
+					ink[i+cols-1] += spill * 3 / 16
+				}
+				ink[i+cols] += spill * 5 / 16
+				if col+1 < cols {
+					ink[i+cols+1] += spill * 1 / 16
+				}
+			}
+		}
+		lines[row] = string(line)
+	}
+	return lines
+}
+
+// cellInk averages the ink of the pixels in rect, from 0 to 1.
+// Transparent pixels are background, so they have none.
+func cellInk(img image.Image, rect image.Rectangle, dark bool) float64 {
+	var sum float64
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			// Premultiplied, so luma is already scaled by alpha
+			r, g, b, a := img.At(x, y).RGBA()
+			luma := 0.2126*float64(r) + 0.7152*float64(g) + 0.0722*float64(b)
+			if dark {
+				sum += luma / 0xffff
+			} else {
+				sum += (float64(a) - luma) / 0xffff
+			}
+		}
+	}
+	return sum / float64(rect.Dx()*rect.Dy())
+}
+
+// isDark reports whether c is a dark color. The terminal's default
+// background, whose color isn't known, counts as dark.
+func isDark(c tcell.Color) bool {
+	r, g, b := c.RGB()
+	if r < 0 {
+		return true
+	}
+	return 0.2126*float64(r)+0.7152*float64(g)+0.0722*float64(b) <= 0.5*255
+}
+
+// placement is an image at a screen position, and the blank cells it
+// covers.
+type placement struct {
+	img        image.Image
+	x, y       int
+	cols, rows int
+	blank      tcell.Style
+}
+
+// graphicsRenderer draws the image of one view at a time, the logo, with
+// a graphics protocol. Views only reserve their cells while the frame is
+// drawn; flush then sends the picture, once tcell has written the frame,
+// and again only when the screen under it changed.
+type graphicsRenderer struct {
+	protocol   termimg.Protocol
+	cellWidth  int
+	cellHeight int
+
+	frame  placement // Reserved by a view this frame
+	shown  placement // On the terminal
+	screen [2]int    // Screen size when shown was sent
+
+	encodedImg image.Image // Image that encoded holds
+	encoded    []byte
+
+	out func([]byte) // Replaces the screen's tty in tests
+}
+
+// load scales the full-size image to the pixels of cols x rows cells.
+func (r *graphicsRenderer) load(s *service.StationService, url string, cols, rows int) (image.Image, error) {
+	return s.LoadImagePixels(url, cols*r.cellWidth, rows*r.cellHeight)
+}
+
+func (r *graphicsRenderer) newView(colors palette) imageView {
+	v := &graphicsView{Box: tview.NewBox(), renderer: r}
+	v.SetBackgroundColor(colors.background)
+	return v
+}
+
+func (r *graphicsRenderer) flush(screen tcell.Screen) {
+	next := r.frame
+	r.frame = placement{}
+	if next.img != nil && !r.blank(screen, next) {
+		// Something, e.g. a dialog, is drawn over the image
+		next = placement{}
+	}
+	width, height := screen.Size()
+	if next == r.shown && r.screen == [2]int{width, height} {
+		return
+	}
+
+	// Write the frame's blank cells first, or tcell would write them over
+	// the picture afterwards
+	screen.Show()
+	var out []byte
+	if r.shown.img != nil {
+		out = termimg.Clear(r.protocol)
+	}
+	if next.img != nil {
+		seq, err := r.encode(next.img)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to encode logo")
+			next = placement{}
+		} else {
+			out = append(out, termimg.At(next.x, next.y, seq)...)
+		}
+	}
+	r.write(screen, out)
+	r.shown, r.screen = next, [2]int{width, height}
+}
+
+func (r *graphicsRenderer) reset() {
+	r.shown = placement{}
+}
+
+// blank reports whether the cells of p still hold the view's blanks.
+func (r *graphicsRenderer) blank(screen tcell.Screen, p placement) bool {
+	for y := p.y; y < p.y+p.rows; y++ {
+		for x := p.x; x < p.x+p.cols; x++ {
+			ch, _, style, _ := screen.GetContent(x, y)
+			if ch != ' ' || style != p.blank {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// encode caches the last image's escape sequence, which is sent again
+// every time the screen is redrawn from scratch.
+func (r *graphicsRenderer) encode(img image.Image) ([]byte, error) {
+	if img == r.encodedImg {
+		return r.encoded, nil
+	}
+	seq, err := termimg.Encode(r.protocol, img)
+	if err != nil {
+		return nil, err
+	}
+	r.encodedImg, r.encoded = img, seq
+	return seq, nil
+}
+
+func (r *graphicsRenderer) write(screen tcell.Screen, out []byte) {
+	if len(out) == 0 {
+		return
+	}
+	if r.out != nil {
+		r.out(out)
+		return
+	}
+	tty, ok := screen.Tty()
+	if !ok {
+		return
+	}
+	if _, err := tty.Write(out); err != nil {
+		log.Debug().Err(err).Msg("Failed to write logo")
+	}
+}
+
+// graphicsView blanks its cells and asks the renderer to put the image
+// over them.
+type graphicsView struct {
+	*tview.Box
+	img      image.Image
+	renderer *graphicsRenderer
+}
+
+func (v *graphicsView) setImage(img image.Image) {
+	v.img = img
+}
+
+func (v *graphicsView) Draw(screen tcell.Screen) {
+	v.DrawForSubclass(screen, v)
+	if v.img == nil {
+		return
+	}
+	x, y, width, height := v.GetInnerRect()
+	r := v.renderer
+	size := v.img.Bounds().Size()
+	cols := (size.X + r.cellWidth - 1) / r.cellWidth
+	rows := (size.Y + r.cellHeight - 1) / r.cellHeight
+	if cols > width || rows > height {
+		// The terminal would draw it over the neighboring views
+		return
+	}
+	r.frame = placement{
+		img:   v.img,
+		x:     x,
+		y:     y,
+		cols:  cols,
+		rows:  rows,
+		blank: tcell.StyleDefault.Background(v.GetBackgroundColor()),
+	}
+}
@@ -0,0 +1,258 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/likes"
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/rivo/tview"
+	"github.com/rs/zerolog/log"
+)
+
+// likeCurrentTrack adds the playing track to the liked-tracks file.
+func (ui *UI) likeCurrentTrack() {
+	if ui.likes == nil || ui.currentStation == nil {
+		return
+	}
+	track := ui.player.GetCurrentTrack()
+	if track == "" || track == player.NoTrackInfo {
+		ui.showNotice("Nothing to like yet — no track title")
+		return
+	}
+
+	artist, title := likes.SplitTrack(track)
+	ui.likeTrack(likes.Track{Artist: artist, Title: title, Station: ui.currentStation.ID.String()}, track)
+}
+
+// likeTrack adds t, shown to the user as track, to the liked tracks.
+func (ui *UI) likeTrack(t likes.Track, track string) {
+	if ui.likes == nil {
+		return
+	}
+	added, err := ui.likes.Add(t)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to save liked track")
+		ui.showNotice("Failed to save liked track")
+		return
+	}
+	if !added {
+		ui.showNotice("Already liked: " + track)
+		return
+	}
+	log.Debug().Msgf("Liked track: %s", track)
+	ui.showNotice("♥ Liked: " + track)
+}
+
+// exportLikes writes tracks as CSV to a dated file in the home directory,
+// where a spreadsheet or a playlist importer can pick it up.
+func (ui *UI) exportLikes(tracks []likes.Track) {
+	if len(tracks) == 0 {
+		ui.showNotice("No liked tracks to export")
+		return
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		log.Error().Err(err).Msg("No home directory for export")
+		ui.showNotice("Export failed: no home directory")
+		return
+	}
+	path := filepath.Join(home, fmt.Sprintf("somafm-likes-%s.csv", time.Now().Format("2006-01-02")))
+	var buf bytes.Buffer
+	if err := likes.Export(&buf, tracks, likes.FormatCSV); err == nil {
+		err = os.WriteFile(path, buf.Bytes(), 0644)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("path", path).Msg("Failed to export liked tracks")
+		ui.showNotice("Export failed: " + err.Error())
+		return
+	}
+	log.Debug().Str("path", path).Int("tracks", len(tracks)).Msg("Exported liked tracks")
+	ui.showNotice(fmt.Sprintf("Exported %d liked tracks to %s", len(tracks), path))
+}
+
+// fillLikedTracks lists liked tracks, most recent first.
+func (ui *UI) fillLikedTracks(table *tview.Table, tracks []likes.Track) {
+	table.Clear()
+	headers := []string{"Artist", "Title", "Station", "Liked"}
+	for col, h := range headers {
+		table.SetCell(0, col, tview.NewTableCell(h).
+			SetTextColor(ui.colors.highlight).
+			SetSelectable(false))
+	}
+	for i, t := range tracks {
+		row := i + 1
+		table.SetCell(row, 0, tview.NewTableCell(tview.Escape(t.Artist)).SetMaxWidth(24))
+		table.SetCell(row, 1, tview.NewTableCell(tview.Escape(t.Title)).SetMaxWidth(32).SetExpansion(1))
+		table.SetCell(row, 2, tview.NewTableCell(t.Station).SetTextColor(ui.colors.borders))
+		table.SetCell(row, 3, tview.NewTableCell(t.LikedAt.In(ui.timeZone()).Format("2006-01-02")).SetTextColor(ui.colors.borders))
+	}
+}
+
+// fillLikedArtists lists artists by how many of their tracks were liked.
+func (ui *UI) fillLikedArtists(table *tview.Table, artists []likes.ArtistCount) {
+	table.Clear()
+	headers := []string{"Artist", "Likes", "Last liked"}
+	for col, h := range headers {
+		table.SetCell(0, col, tview.NewTableCell(h).
+			SetTextColor(ui.colors.highlight).
+			SetSelectable(false))
+	}
+	for i, a := range artists {
+		row := i + 1
+		table.SetCell(row, 0, tview.NewTableCell(tview.Escape(a.Artist)).SetMaxWidth(40).SetExpansion(1))
+		table.SetCell(row, 1, tview.NewTableCell(fmt.Sprintf("%d", a.Count)).SetAlign(tview.AlignRight))
+		table.SetCell(row, 2, tview.NewTableCell(a.LastLiked.In(ui.timeZone()).Format("2006-01-02")).SetTextColor(ui.colors.borders))
+	}
+}
+
+func (ui *UI) showLikesModal() {
+	if ui.likes == nil {
+		return
+	}
+	keyColor := ui.colors.helpHotkey.String()
+	tracks := ui.likes.Tracks()
+	artists := likes.ByArtist(tracks)
+	byArtist := false
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBackgroundColor(ui.colors.modalBackground)
+	table.SetSelectedStyle(tcell.StyleDefault.
+		Background(ui.colors.highlight).
+		Foreground(ui.colors.modalBackground))
+
+	linkView := tview.NewTextView().
+		SetDynamicColors(true)
+	linkView.SetTextColor(ui.colors.foreground)
+	linkView.SetBackgroundColor(ui.colors.modalBackground)
+
+	hintView := tview.NewTextView().
+		SetTextAlign(tview.AlignCenter).
+		SetDynamicColors(true)
+	hintView.SetTextColor(tcell.ColorDarkGray)
+	hintView.SetBackgroundColor(ui.colors.modalBackground)
+
+	frame := tview.NewFrame(nil)
+
+	selectedArtist := func() string {
+		row, _ := table.GetSelection()
+		if byArtist {
+			if row >= 1 && row <= len(artists) {
+				return artists[row-1].Artist
+			}
+			return ""
+		}
+		if row >= 1 && row <= len(tracks) {
+			return tracks[row-1].Artist
+		}
+		return ""
+	}
+
+	updateLink := func() {
+		artist := selectedArtist()
+		if artist == "" {
+			linkView.SetText("")
+			return
+		}
+		lookup := likes.LookupURL(artist)
+		linkView.SetText(fmt.Sprintf("[::d]Lookup:[::-] [skyblue:::%s]%s[-:::-]", lookup, tview.Escape(lookup)))
+	}
+
+	render := func() {
+		if byArtist {
+			ui.fillLikedArtists(table, artists)
+			frame.SetTitle(fmt.Sprintf(" Liked Tracks by Artist (%d) ", len(artists)))
+			hintView.SetText(fmt.Sprintf("[::d][%s]Enter[-] open lookup • [%s]g[-] tracks • [%s]e[-] export • Esc close[::-]", keyColor, keyColor, keyColor))
+		} else {
+			ui.fillLikedTracks(table, tracks)
+			frame.SetTitle(fmt.Sprintf(" Liked Tracks (%d) ", len(tracks)))
+			hintView.SetText(fmt.Sprintf("[::d][%s]Enter[-] open lookup • [%s]g[-] group by artist • [%s]e[-] export • Esc close[::-]", keyColor, keyColor, keyColor))
+		}
+		table.Select(1, 0)
+		table.ScrollToBeginning()
+		updateLink()
+	}
+
+	table.SetSelectionChangedFunc(func(row, column int) {
+		updateLink()
+	})
+
+	var body tview.Primitive = table
+	if len(tracks) == 0 {
+		empty := tview.NewTextView().
+			SetTextAlign(tview.AlignCenter).
+			SetDynamicColors(true).
+			SetText(fmt.Sprintf("\nNo liked tracks yet.\n\nPress [%s]l[-] while a track is playing to like it.", keyColor))
+		empty.SetTextColor(ui.colors.foreground)
+		empty.SetBackgroundColor(ui.colors.modalBackground)
+		body = empty
+	}
+
+	content := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(body, 0, 1, true).
+		AddItem(linkView, 1, 0, false).
+		AddItem(hintView, 1, 0, false)
+	content.SetBackgroundColor(ui.colors.modalBackground)
+
+	frame.SetPrimitive(content).
+		SetBorders(1, 0, 0, 0, 2, 2)
+	frame.SetBorder(true).
+		SetBorderColor(ui.colors.borders).
+		SetBackgroundColor(ui.colors.modalBackground).
+		SetTitleColor(ui.colors.highlight).
+		SetTitleAlign(tview.AlignCenter)
+
+	render()
+
+	modalWidth := 84
+	modalHeight := min(len(tracks), 20) + 9
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(frame, modalHeight, 0, true).
+			AddItem(nil, 0, 1, false),
+			modalWidth, 0, true).
+		AddItem(nil, 0, 1, false)
+	modal.SetBackgroundColor(ui.colors.background)
+
+	modal.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEscape:
+			ui.modals.close(modalPage)
+			return nil
+		case tcell.KeyEnter:
+			if artist := selectedArtist(); artist != "" {
+				if err := openURL(likes.LookupURL(artist)); err != nil {
+					log.Warn().Err(err).Msg("Failed to open lookup link")
+				}
+			}
+			return nil
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn, tcell.KeyHome, tcell.KeyEnd:
+			return event
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'g', 'G':
+				byArtist = !byArtist
+				render()
+			case 'e', 'E':
+				ui.exportLikes(tracks)
+			case 'j', 'k':
+				return event
+			case 'L', 'q', 'Q':
+				ui.modals.close(modalPage)
+			}
+			return nil
+		}
+		return nil
+	})
+
+	ui.modals.open(modalPage, modal, table)
+}
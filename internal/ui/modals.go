@@ -4,13 +4,80 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/cache"
 	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/player"
 	"github.com/rivo/tview"
+	"github.com/rs/zerolog/log"
 )
 
+// Page names of the layers above the main layout.
+const (
+	mainPage       = "main"
+	modalPage      = "modal"
+	errorModalPage = "error-modal"
+	osdPage        = "osd"
+)
+
+// ModalManager stacks modal pages and overlays above the main layout. It
+// owns the tview root and publishes modalClosed when a modal goes away,
+// so whoever holds the focus underneath can take it back.
+type ModalManager struct {
+	app   *tview.Application
+	pages *tview.Pages
+	bus   *EventBus
+}
+
+func newModalManager(app *tview.Application, bus *EventBus, main tview.Primitive, background tcell.Color) *ModalManager {
+	pages := tview.NewPages().
+		AddPage(mainPage, main, true, true)
+	pages.SetBackgroundColor(background)
+	return &ModalManager{app: app, pages: pages, bus: bus}
+}
+
+// open shows p as the named page, replacing one of the same name, and
+// focuses focus, the widget that takes the modal's keys.
+func (m *ModalManager) open(name string, p, focus tview.Primitive) {
+	m.pages.AddPage(name, p, true, true)
+	m.app.SetFocus(focus)
+}
+
+// close removes the named page.
+func (m *ModalManager) close(name string) {
+	m.pages.RemovePage(name)
+	m.bus.publish(modalClosed{name: name})
+}
+
+// overlay shows p above everything without taking the focus, for
+// transient displays like the volume flash.
+func (m *ModalManager) overlay(name string, p tview.Primitive) {
+	focused := m.app.GetFocus()
+	m.pages.RemovePage(name)
+	m.pages.AddPage(name, p, true, true)
+	if focused != nil {
+		m.app.SetFocus(focused)
+	}
+}
+
+// removeOverlay hides an overlay shown with overlay.
+func (m *ModalManager) removeOverlay(name string) {
+	m.pages.RemovePage(name)
+}
+
+func (m *ModalManager) isOpen(name string) bool {
+	return m.pages.HasPage(name)
+}
+
+// blocking reports whether a modal that takes all keys is open.
+func (m *ModalManager) blocking() bool {
+	return m.isOpen(modalPage) || m.isOpen(errorModalPage)
+}
+
 func friendlyErrorMessage(errStr string) string {
 	if strings.Contains(errStr, "no such host") {
 		return "Unable to connect to server.\nPlease check your internet connection."
@@ -24,6 +91,9 @@ func friendlyErrorMessage(errStr string) string {
 	if strings.Contains(errStr, "network is unreachable") || strings.Contains(errStr, "network read error") {
 		return "Network is unreachable.\nPlease check your internet connection."
 	}
+	if strings.Contains(errStr, "requires ffmpeg") {
+		return "This stream needs ffmpeg to decode.\nInstall ffmpeg or pick an MP3 stream."
+	}
 	if strings.Contains(errStr, "status 401") {
 		return "Stream access denied (401)."
 	}
@@ -37,31 +107,32 @@ func friendlyErrorMessage(errStr string) string {
 	if idx := strings.Index(errStr, ": dial"); idx > 0 {
 		return errStr[:idx]
 	}
-	if len(errStr) > 100 {
-		return errStr[:100] + "..."
+	if runes := []rune(errStr); len(runes) > 100 {
+		return string(runes[:100]) + ellipsis
 	}
 	return errStr
 }
 
 func (ui *UI) showError(err error) {
+	if ui.suggestAfterRetired() {
+		return
+	}
 	ui.showPlaybackErrorModal(friendlyErrorMessage(err.Error()))
 }
 
 func (ui *UI) showPlaybackErrorModal(message string) {
 	doDismiss := func() {
-		ui.pages.RemovePage("error-modal")
-		ui.app.SetFocus(ui.stationList)
+		ui.modals.close(errorModalPage)
 	}
 
 	doRetry := func() {
-		ui.pages.RemovePage("error-modal")
-		ui.app.SetFocus(ui.stationList)
+		ui.modals.close(errorModalPage)
 		if ui.currentStation != nil {
 			ui.safeCloseChannel()
 			ui.recreateStopChannel()
 			ui.startPlayingAnimation()
 			go func() {
-				err := ui.player.Play(ui.currentStation)
+				err := ui.player.PlayPlaylist(ui.currentStation, ui.playlistURLFor(ui.currentStation))
 				if err != nil {
 					if errors.Is(err, context.Canceled) {
 						return
@@ -138,47 +209,79 @@ func (ui *UI) showPlaybackErrorModal(message string) {
 		return event
 	})
 
-	ui.pages.AddPage("error-modal", modal, true, true)
-	ui.app.SetFocus(modal)
+	ui.modals.open(errorModalPage, modal, modal)
+}
+
+// shortenHome replaces the home directory prefix of path with ~.
+func shortenHome(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if rest, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+		return "~" + string(filepath.Separator) + rest
+	}
+	return path
 }
 
 func (ui *UI) showHelpModal() {
 	keyColor := ui.colors.helpHotkey.String()
 
 	configPath, _ := config.GetConfigPath()
+	configPath = shortenHome(configPath)
+
+	quickSelectNoun := "favorite"
+	if ui.config.QuickSelect == config.QuickSelectRows {
+		quickSelectNoun = "row"
+	}
+
+	docs := ""
+	if !ui.config.Branding.HideLinks {
+		docs = "  " + hyperlink(config.AppProjectURL+"#keyboard-shortcuts", "full docs", "skyblue", ui.config.Hyperlinks)
+	}
 
-	helpText := fmt.Sprintf(`[::b]KEYBOARD SHORTCUTS[::-]
+	helpText := fmt.Sprintf(`[::b]KEYBOARD SHORTCUTS[::-]%s
 
 [%s]PLAYBACK[-]
   [%s]Enter[-]      Play selected station
+  [%s]1[-]-[%s]9[-]        Play %s 1-9
   [%s]Space[-]      Pause / Resume
-  [%s]<[-]          Previous station
-  [%s]>[-]          Next station
-  [%s]r[-]          Random station
+  [%s]<[-] / [%s]>[-]      Previous / next station
+  [%s]r[-] / [%s]y[-]      Random / similar station
+  [%s]Ctrl-R[-]     Reconnect stream
+  [%s][ ] \ p[-]    Rewind / live / scrub
+  [%s]s[-] [%s]x[-] [%s]X[-]      Streams / A/B, stop A/B
 
 [%s]VOLUME[-]
-  [%s]+[-] / [%s]-[-]      Volume up / down
-  [%s]←[-] / [%s]→[-]      Volume up / down
-  [%s]m[-]          Mute / Unmute
+  [%s]+[-] [%s]-[-] [%s]←[-] [%s]→[-] [%s]m[-]  Volume up / down, mute
+  [%s]n[-]          Night mode
 
 [%s]STATIONS[-]
   [%s]↑[-] / [%s]↓[-]      Navigate list
   [%s]f[-]          Toggle favorite
+  [%s]/[-] / [%s]S[-]      Filter / sort stations
+  [%s]t[-] / [%s]T[-]      Translate / theme
+  [%s]l[-] [%s]L[-] [%s]b[-]      Like / liked / web search
+  [%s]h[-] [%s]H[-] [%s]e[-]      History / plays / note
+  [%s]w[-]          Record to disk
 
 [%s]APPLICATION[-]
   [%s]?[-]          Show this help
+  [%s]o[-] / [%s]v[-]      Big-text OSD / visualizer
   [%s]a[-]          About %s
+  [%s]c[-] [%s]i[-] [%s]d[-]      Cache / stats / graph
   [%s]q[-] / [%s]Esc[-]    Quit
 
 [%s]CONFIG[-]: %s`,
+		docs,
 		keyColor,
-		keyColor, keyColor, keyColor, keyColor, keyColor,
+		keyColor, keyColor, keyColor, quickSelectNoun, keyColor, keyColor, keyColor, keyColor, keyColor, keyColor, keyColor, keyColor, keyColor, keyColor,
 		keyColor,
-		keyColor, keyColor, keyColor, keyColor, keyColor,
+		keyColor, keyColor, keyColor, keyColor, keyColor, keyColor,
 		keyColor,
-		keyColor, keyColor, keyColor,
+		keyColor, keyColor, keyColor, keyColor, keyColor, keyColor, keyColor, keyColor, keyColor, keyColor, keyColor, keyColor, keyColor, keyColor,
 		keyColor,
-		keyColor, keyColor, config.AppName, keyColor, keyColor,
+		keyColor, keyColor, keyColor, keyColor, ui.config.Branding.DisplayTitle(), keyColor, keyColor, keyColor, keyColor, keyColor,
 		keyColor, configPath)
 
 	ui.showInfoModal("Help", helpText)
@@ -186,32 +289,41 @@ func (ui *UI) showHelpModal() {
 
 func (ui *UI) showAboutModal() {
 	doDismiss := func() {
-		ui.pages.RemovePage("modal")
-		ui.app.SetFocus(ui.stationList)
+		ui.modals.close(modalPage)
 	}
 
 	linkColor := "skyblue"
 	dimColor := "gray"
 
-	aboutText := fmt.Sprintf(`[::b]%s[::-]
-[%s]%s[-]
-
-Version: %s
-Author:  %s ([%s:::%s]%s[-:::-])
-Project: [%s:::%s]%s[-:::-]
+	branding := ui.config.Branding
+	aboutText := fmt.Sprintf("[::b]%s[::-]\n[%s]%s[-]\n\n", branding.DisplayTitle(), dimColor, config.AppTagline)
+	details := ""
+	if !branding.HideVersion {
+		details += fmt.Sprintf("Version: %s\n", config.AppVersion)
+	}
+	if !branding.HideLinks {
+		details += fmt.Sprintf(`Author:  %s (%s)
+Project: %s
 License: MIT
+`,
+			config.AppAuthor, hyperlink(config.AppAuthorURL, config.AppAuthorURLShort, linkColor, ui.config.Hyperlinks),
+			hyperlink(config.AppProjectURL, config.AppProjectShort, linkColor, ui.config.Hyperlinks))
+	}
+	if details != "" {
+		aboutText += details + "\n"
+	}
+	aboutText += fmt.Sprintf(`[%s]Privacy:[-] %s
+[%s]Cache:[-]   %s (press [%s]c[-] to manage)
 
 ───────────────────────────────────────────
 
-[%s]Radio content from[-] [::b]SomaFM[::-]
-Listener-supported • [%s:::%s]%s[-:::-]`,
-		config.AppName,
-		dimColor, config.AppTagline,
-		config.AppVersion,
-		config.AppAuthor, linkColor, config.AppAuthorURL, config.AppAuthorURLShort,
-		linkColor, config.AppProjectURL, config.AppProjectShort,
-		dimColor,
-		linkColor, config.AppDonateURL, config.AppDonateShort)
+[%s]Radio content from[-] [::b]SomaFM[::-]`,
+		dimColor, ui.privacySummary(),
+		dimColor, ui.cacheTotal(), ui.colors.helpHotkey.String(),
+		dimColor)
+	if !branding.HideLinks {
+		aboutText += "\nListener-supported • " + hyperlink(config.AppDonateURL, config.AppDonateShort, linkColor, ui.config.Hyperlinks)
+	}
 
 	messageView := tview.NewTextView().
 		SetTextAlign(tview.AlignLeft).
@@ -244,7 +356,7 @@ Listener-supported • [%s:::%s]%s[-:::-]`,
 		SetTitleAlign(tview.AlignCenter)
 
 	modalWidth := 50
-	modalHeight := 20
+	modalHeight := 25
 
 	modal := tview.NewFlex().
 		AddItem(nil, 0, 1, false).
@@ -258,17 +370,41 @@ Listener-supported • [%s:::%s]%s[-:::-]`,
 
 	modal.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		doDismiss()
+		if event.Key() == tcell.KeyRune && (event.Rune() == 'c' || event.Rune() == 'C') {
+			ui.showCacheModal()
+		}
 		return nil
 	})
 
-	ui.pages.AddPage("modal", modal, true, true)
-	ui.app.SetFocus(modal)
+	ui.modals.open(modalPage, modal, modal)
+}
+
+// cacheTotal returns the total size of the on-disk cache.
+func (ui *UI) cacheTotal() string {
+	usage, err := ui.stationService.CacheUsage()
+	if err != nil {
+		return "disabled"
+	}
+	var total int64
+	for _, u := range usage {
+		total += u.Bytes
+	}
+	return formatBytes(total)
+}
+
+// privacySummary describes what identifying data is sent with stream requests.
+func (ui *UI) privacySummary() string {
+	summary := fmt.Sprintf("streams are requested with User-Agent SomaFM-CLI/%s", config.AppVersion)
+	if id := ui.config.ActiveListenerID(); id != "" {
+		return fmt.Sprintf("%s and an anonymous %s header (%s…). Set listener_id.enabled to false to stop.",
+			summary, player.ListenerIDHeader, id[:min(8, len(id))])
+	}
+	return summary + " only; no listener ID is sent."
 }
 
 func (ui *UI) showInfoModal(title, message string) {
 	doDismiss := func() {
-		ui.pages.RemovePage("modal")
-		ui.app.SetFocus(ui.stationList)
+		ui.modals.close(modalPage)
 	}
 
 	messageView := tview.NewTextView().
@@ -324,8 +460,7 @@ func (ui *UI) showInfoModal(title, message string) {
 		return nil
 	})
 
-	ui.pages.AddPage("modal", modal, true, true)
-	ui.app.SetFocus(modal)
+	ui.modals.open(modalPage, modal, modal)
 }
 
 func (ui *UI) showInitialErrorScreen(title, message string, onRetry, onQuit func()) {
@@ -387,7 +522,7 @@ func (ui *UI) showInitialErrorScreen(title, message string, onRetry, onQuit func
 		return event
 	})
 
-	ui.app.SetRoot(layout, true)
+	ui.setRoot(layout)
 	ui.app.SetFocus(layout)
 }
 
@@ -398,7 +533,7 @@ func (ui *UI) handleInitialError(err error) {
 		"Unable to Load Stations",
 		friendlyMsg,
 		func() { // onRetry
-			ui.app.SetRoot(ui.loadingScreen, true)
+			ui.setRoot(ui.loadingScreen)
 			go func() {
 				if err := ui.fetchStationsAndInitUI(); err != nil {
 					ui.app.QueueUpdateDraw(func() {
@@ -412,3 +547,117 @@ func (ui *UI) handleInitialError(err error) {
 		},
 	)
 }
+
+// formatBytes renders a byte count compactly, e.g. "512 B", "1.4 MB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func (ui *UI) cacheUsageText(keyColor string) (string, []cache.CategoryUsage) {
+	usage, err := ui.stationService.CacheUsage()
+	if err != nil {
+		return fmt.Sprintf("Cache unavailable: %v", err), nil
+	}
+
+	dir, _ := ui.stationService.CacheDir()
+	var b strings.Builder
+	fmt.Fprintf(&b, "Location: %s\n\n", tview.Escape(dir))
+
+	var total, saved int64
+	for i, u := range usage {
+		files := "files"
+		if u.Files == 1 {
+			files = "file"
+		}
+		fmt.Fprintf(&b, "  [%s]%d[-]  %-15s %9s  %d %s\n", keyColor, i+1, u.Description, formatBytes(u.Bytes), u.Files, files)
+		total += u.Bytes
+		saved += u.Saved
+	}
+	fmt.Fprintf(&b, "\n     %-15s %9s", "Total", formatBytes(total))
+	if saved > 0 {
+		fmt.Fprintf(&b, "\n     %-15s %9s", "Saved vs. PNG", formatBytes(saved))
+	}
+	return b.String(), usage
+}
+
+func (ui *UI) showCacheModal() {
+	keyColor := ui.colors.helpHotkey.String()
+
+	messageView := tview.NewTextView().
+		SetTextAlign(tview.AlignLeft).
+		SetDynamicColors(true)
+	messageView.SetTextColor(ui.colors.foreground)
+	messageView.SetBackgroundColor(ui.colors.modalBackground)
+
+	var usage []cache.CategoryUsage
+	refresh := func() {
+		var text string
+		text, usage = ui.cacheUsageText(keyColor)
+		messageView.SetText("\n" + text)
+	}
+	refresh()
+
+	hintView := tview.NewTextView().
+		SetTextAlign(tview.AlignCenter).
+		SetDynamicColors(true).
+		SetText("[::d]Number to clear a category • Esc to close[::-]")
+	hintView.SetTextColor(tcell.ColorDarkGray)
+	hintView.SetBackgroundColor(ui.colors.modalBackground)
+
+	content := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(messageView, 0, 1, false).
+		AddItem(hintView, 1, 0, false).
+		AddItem(nil, 1, 0, false)
+	content.SetBackgroundColor(ui.colors.modalBackground)
+
+	frame := tview.NewFrame(content).
+		SetBorders(1, 0, 1, 1, 2, 2)
+	frame.SetBorder(true).
+		SetBorderColor(ui.colors.borders).
+		SetBackgroundColor(ui.colors.modalBackground).
+		SetTitle(" Cache ").
+		SetTitleColor(ui.colors.highlight).
+		SetTitleAlign(tview.AlignCenter)
+
+	modalWidth := 56
+	modalHeight := 16
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(frame, modalHeight, 0, true).
+			AddItem(nil, 0, 1, false),
+			modalWidth, 0, true).
+		AddItem(nil, 0, 1, false)
+	modal.SetBackgroundColor(ui.colors.background)
+
+	modal.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyRune && event.Rune() >= '1' && event.Rune() <= '9' {
+			i := int(event.Rune() - '1')
+			if i < len(usage) {
+				if _, err := ui.stationService.PruneCache(usage[i].Name); err != nil {
+					log.Warn().Err(err).Msg("Failed to prune cache")
+				} else if usage[i].Name == cache.CategoryTranslations && ui.translator != nil {
+					// Or the next translation writes them all back
+					_ = ui.translator.ClearCache()
+				}
+				refresh()
+			}
+			return nil
+		}
+		ui.modals.close(modalPage)
+		return nil
+	})
+
+	ui.modals.open(modalPage, modal, modal)
+}
@@ -0,0 +1,243 @@
+package ui
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	bigGlyphRows = 5
+	bigTextLines = (bigGlyphRows + 1) / 2 // Two pixel rows per terminal line via half blocks
+	osdPadding   = 2
+)
+
+// bigFont is a 5-pixel-high block font. '#' marks a filled pixel.
+var bigFont = map[rune][bigGlyphRows]string{
+	'A':  {".##.", "#..#", "####", "#..#", "#..#"},
+	'B':  {"###.", "#..#", "###.", "#..#", "###."},
+	'C':  {".###", "#...", "#...", "#...", ".###"},
+	'D':  {"###.", "#..#", "#..#", "#..#", "###."},
+	'E':  {"####", "#...", "###.", "#...", "####"},
+	'F':  {"####", "#...", "###.", "#...", "#..."},
+	'G':  {".###", "#...", "#.##", "#..#", ".###"},
+	'H':  {"#..#", "#..#", "####", "#..#", "#..#"},
+	'I':  {"###", ".#.", ".#.", ".#.", "###"},
+	'J':  {"..##", "...#", "...#", "#..#", ".##."},
+	'K':  {"#..#", "#.#.", "##..", "#.#.", "#..#"},
+	'L':  {"#...", "#...", "#...", "#...", "####"},
+	'M':  {"#...#", "##.##", "#.#.#", "#...#", "#...#"},
+	'N':  {"#..#", "##.#", "#.##", "#..#", "#..#"},
+	'O':  {".##.", "#..#", "#..#", "#..#", ".##."},
+	'P':  {"###.", "#..#", "###.", "#...", "#..."},
+	'Q':  {".##.", "#..#", "#..#", "#.##", ".###"},
+	'R':  {"###.", "#..#", "###.", "#.#.", "#..#"},
+	'S':  {".###", "#...", ".##.", "...#", "###."},
+	'T':  {"#####", "..#..", "..#..", "..#..", "..#.."},
+	'U':  {"#..#", "#..#", "#..#", "#..#", ".##."},
+	'V':  {"#...#", "#...#", "#...#", ".#.#.", "..#.."},
+	'W':  {"#...#", "#...#", "#.#.#", "##.##", "#...#"},
+	'X':  {"#..#", "#..#", ".##.", "#..#", "#..#"},
+	'Y':  {"#...#", ".#.#.", "..#..", "..#..", "..#.."},
+	'Z':  {"####", "...#", ".##.", "#...", "####"},
+	'0':  {".##.", "#.##", "##.#", "#..#", ".##."},
+	'1':  {".#.", "##.", ".#.", ".#.", "###"},
+	'2':  {"###.", "...#", ".##.", "#...", "####"},
+	'3':  {"###.", "...#", ".##.", "...#", "###."},
+	'4':  {"#..#", "#..#", "####", "...#", "...#"},
+	'5':  {"####", "#...", "###.", "...#", "###."},
+	'6':  {".##.", "#...", "###.", "#..#", ".##."},
+	'7':  {"####", "...#", "..#.", ".#..", ".#.."},
+	'8':  {".##.", "#..#", ".##.", "#..#", ".##."},
+	'9':  {".##.", "#..#", ".###", "...#", ".##."},
+	' ':  {"..", "..", "..", "..", ".."},
+	'-':  {"...", "...", "###", "...", "..."},
+	'.':  {".", ".", ".", ".", "#"},
+	',':  {"..", "..", "..", ".#", "#."},
+	':':  {".", "#", ".", "#", "."},
+	'\'': {"#", "#", ".", ".", "."},
+	'"':  {"#.#", "#.#", "...", "...", "..."},
+	'!':  {"#", "#", "#", ".", "#"},
+	'?':  {"###.", "...#", ".##.", "....", ".#.."},
+	'&':  {".#..", "#.#.", ".#.#", "#.#.", ".#.#"},
+	'(':  {".#", "#.", "#.", "#.", ".#"},
+	')':  {"#.", ".#", ".#", ".#", "#."},
+	'/':  {"...#", "..#.", ".#..", "#...", "#..."},
+	'+':  {"...", ".#.", "###", ".#.", "..."},
+}
+
+func bigGlyph(r rune) [bigGlyphRows]string {
+	if glyph, ok := bigFont[unicode.ToUpper(r)]; ok {
+		return glyph
+	}
+	return bigFont['?']
+}
+
+// bigWordWidth returns the rendered width of a word, including the
+// one-column gap between glyphs.
+func bigWordWidth(word string) int {
+	width := 0
+	for i, r := range word {
+		if i > 0 {
+			width++
+		}
+		width += len(bigGlyph(r)[0])
+	}
+	return width
+}
+
+// wrapBigText splits text into lines whose rendered width fits maxWidth.
+// Words wider than maxWidth are broken between characters. Nothing fits a
+// maxWidth below 1.
+func wrapBigText(text string, maxWidth int) []string {
+	if maxWidth < 1 {
+		return nil
+	}
+	var lines []string
+	current := ""
+
+	for _, word := range strings.Fields(text) {
+		for bigWordWidth(word) > maxWidth {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			runes := []rune(word)
+			cut := max(len(runes)-1, 1)
+			for cut > 1 && bigWordWidth(string(runes[:cut])) > maxWidth {
+				cut--
+			}
+			lines = append(lines, string(runes[:cut]))
+			word = string(runes[cut:])
+		}
+		if word == "" {
+			continue
+		}
+
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if bigWordWidth(candidate) <= maxWidth {
+			current = candidate
+			continue
+		}
+		lines = append(lines, current)
+		current = word
+	}
+
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// renderBigLine renders a single line of text with the block font using
+// half-block characters, returning bigTextLines terminal rows.
+func renderBigLine(text string) []string {
+	pixels := make([]string, bigGlyphRows)
+	for i, r := range text {
+		glyph := bigGlyph(r)
+		for row := range pixels {
+			if i > 0 {
+				pixels[row] += "."
+			}
+			pixels[row] += glyph[row]
+		}
+	}
+
+	rows := make([]string, 0, bigTextLines)
+	for top := 0; top < bigGlyphRows; top += 2 {
+		var b strings.Builder
+		for col := 0; col < len(pixels[top]); col++ {
+			upper := pixels[top][col] == '#'
+			lower := top+1 < bigGlyphRows && pixels[top+1][col] == '#'
+			switch {
+			case upper && lower:
+				b.WriteRune('█')
+			case upper:
+				b.WriteRune('▀')
+			case lower:
+				b.WriteRune('▄')
+			default:
+				b.WriteRune(' ')
+			}
+		}
+		rows = append(rows, b.String())
+	}
+	return rows
+}
+
+// renderBigText wraps and renders text in the block font, separating
+// wrapped lines with an empty row.
+func renderBigText(text string, maxWidth int) []string {
+	var rows []string
+	for i, line := range wrapBigText(text, maxWidth) {
+		if i > 0 {
+			rows = append(rows, "")
+		}
+		rows = append(rows, renderBigLine(line)...)
+	}
+	return rows
+}
+
+func (ui *UI) createOSD() *tview.Box {
+	box := tview.NewBox().SetBackgroundColor(ui.colors.background)
+
+	box.SetDrawFunc(func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
+		stationTitle := "No station selected"
+		track := ""
+		if ui.currentStation != nil {
+			stationTitle = ui.currentStation.Title
+			track = ui.player.GetCurrentTrack()
+		}
+
+		contentWidth := max(width-osdPadding*2, 1)
+		hintRows := 2
+		stationRows := renderBigText(stationTitle, contentWidth)
+		trackRows := renderBigText(track, contentWidth)
+
+		// Degrade to plain text when the big font does not fit
+		bigTrack := len(stationRows)+1+len(trackRows)+hintRows <= height
+		if !bigTrack {
+			trackRows = []string{tview.Escape(track)}
+		}
+		bigStation := len(stationRows)+1+len(trackRows)+hintRows <= height
+		if !bigStation {
+			stationRows = []string{tview.Escape(stationTitle)}
+		}
+
+		total := len(stationRows) + 1 + len(trackRows)
+		row := y + (height-hintRows-total)/2
+		if row < y {
+			row = y
+		}
+
+		for _, line := range stationRows {
+			tview.Print(screen, line, x+osdPadding, row, contentWidth, tview.AlignCenter, ui.colors.highlight)
+			row++
+		}
+		row++
+		for _, line := range trackRows {
+			tview.Print(screen, line, x+osdPadding, row, contentWidth, tview.AlignCenter, ui.colors.foreground)
+			row++
+		}
+
+		tview.Print(screen, "[::d]o / Esc to return[::-]", x, y+height-1, width, tview.AlignCenter, ui.colors.borders)
+
+		return x, y, width, height
+	})
+
+	return box
+}
+
+func (ui *UI) toggleOSD() {
+	if ui.modals.isOpen(osdPage) {
+		ui.modals.close(osdPage)
+		return
+	}
+	// The OSD takes no keys; they keep going to the station list
+	ui.modals.overlay(osdPage, ui.createOSD())
+}
@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/glebovdev/somafm-cli/internal/outputdev"
+	"github.com/rs/zerolog/log"
+)
+
+// watchOutput is replaced in tests, which have no sound server.
+var watchOutput = outputdev.Watch
+
+// startOutputWatch pauses playback when the audio output disappears, if
+// audio.pause_on_disconnect is set, until the UI stops.
+func (ui *UI) startOutputWatch() {
+	if !ui.config.Audio.PauseOnDisconnect {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	err := watchOutput(ctx, func(out outputdev.Output) {
+		ui.app.QueueUpdateDraw(func() {
+			ui.pauseForLostOutput(out)
+		})
+	})
+	if err != nil {
+		cancel()
+		log.Warn().Err(err).Msg("Can't watch the audio output, pause_on_disconnect is off")
+		return
+	}
+	ui.stopOutputWatch = cancel
+}
+
+// pauseForLostOutput pauses playback after out went away, so the stream
+// doesn't carry on through whatever the sound server switched to.
+func (ui *UI) pauseForLostOutput(out outputdev.Output) {
+	if !ui.player.IsPlaying() {
+		return
+	}
+	log.Info().Msgf("Audio output %s disconnected, pausing", out)
+	ui.player.TogglePause()
+	ui.stations.updatePlayingIndicator()
+	ui.showNotice(fmt.Sprintf("Paused: %s disconnected — Space resumes", out))
+}
@@ -0,0 +1,488 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/clock"
+	"github.com/glebovdev/somafm-cli/internal/genre"
+	"github.com/glebovdev/somafm-cli/internal/service"
+	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/rivo/tview"
+)
+
+// PlayerPanelView is the panel above the station list: cover art, station
+// details, the current track with its ticker, and the volume bar. It shows
+// one station at a time, which need not be the one playing.
+type PlayerPanelView struct {
+	root       *tview.Flex
+	logo       imageView
+	trackView  *tview.TextView
+	track      string // Shown in trackView, before truncation
+	trackColor tcell.Color
+	trackWidth int // Width trackView was last drawn at
+	tickerView *tview.TextView
+	meterView  *tview.Box
+	info       *tview.Flex // Holds meterView, one row high while visualizer is on
+	statusView *tview.TextView
+	nameView   *tview.TextView
+	volumeView *tview.Flex
+	descView   *tview.TextView
+	genreView  *tview.Flex
+	station    *station.Station // Shown station, nil until the first one
+	playingID  station.StationID
+	ticker     trackTicker
+	meter      vuMeter
+	pulse      accentPulse
+	volume     int
+	muted      bool
+
+	visualizer   bool
+	hyperlinks   bool // Link the station name to its page
+	translated   bool // Show translations where there are some
+	translations map[station.StationID]stationTranslation
+
+	app     *tview.Application
+	bus     *EventBus
+	colors  palette
+	service *service.StationService
+	images  imageRenderer
+	status  *StatusRenderer
+	genres  *genre.Translator
+	clock   func() clock.Clock
+}
+
+func newPlayerPanelView(ui *UI) *PlayerPanelView {
+	ui.mu.Lock()
+	volume, muted := ui.currentVolume, ui.isMuted
+	if muted {
+		volume = ui.config.Volume
+	}
+	ui.mu.Unlock()
+
+	v := &PlayerPanelView{
+		root:       tview.NewFlex().SetDirection(tview.FlexRow),
+		volume:     volume,
+		muted:      muted,
+		visualizer: ui.config.Visualizer,
+		hyperlinks: ui.config.Hyperlinks,
+		app:        ui.app,
+		bus:        ui.bus,
+		colors:     ui.colors,
+		service:    ui.stationService,
+		images:     ui.imageRenderer(),
+		status:     ui.statusRenderer,
+		genres:     ui.genres,
+		clock:      ui.timeSource,
+	}
+	v.root.SetBackgroundColor(v.colors.background)
+	v.meter.reset()
+
+	subscribe(ui.bus, func(e playingChanged) { v.setPlaying(e.id) })
+	subscribe(ui.bus, func(e volumeChanged) { v.setVolume(e.volume, e.muted) })
+	subscribe(ui.bus, func(stationsChanged) { v.updateRetired() })
+	return v
+}
+
+// show rebuilds the panel for s and starts loading its cover.
+func (v *PlayerPanelView) show(s *station.Station) {
+	v.station = s
+	v.root.Clear()
+	v.root.AddItem(v.createContent(), 0, 1, false)
+	v.loadLogo(s)
+}
+
+// setPlaying starts a fresh ticker and pulse for the station now playing.
+func (v *PlayerPanelView) setPlaying(id station.StationID) {
+	v.playingID = id
+	v.ticker.reset()
+	v.pulse.reset()
+}
+
+// updateRetired badges the shown station once a refresh has retired it.
+func (v *PlayerPanelView) updateRetired() {
+	if v.station == nil || v.nameView == nil {
+		return
+	}
+	s := v.service.GetStation(v.service.FindIndexByID(v.station.ID))
+	if s == nil || s.Retired == v.station.Retired {
+		return
+	}
+	// The player may still read the old copy
+	shown := *v.station
+	shown.Retired = s.Retired
+	v.station = &shown
+	v.nameView.SetText(v.stationName())
+}
+
+// stationName is the shown station's title for the Station row.
+func (v *PlayerPanelView) stationName() string {
+	name := " " + hyperlink(v.station.PageURL(), v.station.Title, v.colors.highlight.String(), v.hyperlinks)
+	if v.station.Retired {
+		name += retiredBadge
+	}
+	return name
+}
+
+func (v *PlayerPanelView) loadLogo(s *station.Station) {
+	stationID := s.ID
+	go func() {
+		img, err := v.images.load(v.service, s.XLImage, CoverWidth, CoverHeight)
+		if err != nil {
+			v.app.QueueUpdateDraw(func() {
+				if v.station == nil || v.station.ID != stationID {
+					return
+				}
+				v.logo.SetDrawFunc(func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
+					errorMsg := fmt.Sprintf("Failed to load image: %v", err)
+					tview.Print(screen, errorMsg, x, y, 10, tview.AlignCenter, tcell.ColorRed)
+					return x, y, 10, 10
+				})
+			})
+			return
+		}
+
+		v.app.QueueUpdateDraw(func() {
+			if v.station == nil || v.station.ID != stationID {
+				return
+			}
+			v.logo.setImage(img)
+		})
+	}()
+}
+
+func (v *PlayerPanelView) fillGenreTags(container *tview.Flex, names []string) {
+	container.AddItem(tview.NewBox().SetBackgroundColor(v.colors.background), 1, 0, false)
+
+	if len(names) == 0 {
+		noGenre := tview.NewTextView()
+		noGenre.SetText("N/A")
+		noGenre.SetTextColor(v.colors.foreground)
+		noGenre.SetBackgroundColor(v.colors.background)
+		container.AddItem(noGenre, 3, 0, false)
+		return
+	}
+
+	for i, name := range names {
+		tag := tview.NewTextView()
+		tag.SetText(" " + tview.Escape(name) + " ")
+		tag.SetTextColor(v.colors.foreground)
+		tag.SetBackgroundColor(v.colors.genreTagBackground)
+		tag.SetTextAlign(tview.AlignCenter)
+
+		tagWidth := tview.TaggedStringWidth(name) + 2
+		container.AddItem(tag, tagWidth, 0, false)
+
+		if i < len(names)-1 {
+			spacer := tview.NewBox().SetBackgroundColor(v.colors.background)
+			container.AddItem(spacer, 1, 0, false)
+		}
+	}
+
+	container.AddItem(tview.NewBox().SetBackgroundColor(v.colors.background), 0, 1, false)
+}
+
+func (v *PlayerPanelView) createContent() *tview.Flex {
+	v.logo = v.images.newView(v.colors)
+
+	stationLabel := tview.NewTextView()
+	stationLabel.SetText(" Station:")
+	stationLabel.SetTextColor(v.colors.foreground)
+	stationLabel.SetBackgroundColor(v.colors.background)
+	stationLabel.SetWrap(false)
+
+	v.nameView = tview.NewTextView()
+	v.nameView.SetDynamicColors(true)
+	v.nameView.SetText(v.stationName())
+	v.nameView.SetTextColor(v.colors.highlight)
+	v.nameView.SetBackgroundColor(v.colors.background)
+	v.nameView.SetWrap(false)
+	v.nameView.SetTextStyle(tcell.StyleDefault.Background(v.colors.background).Attributes(tcell.AttrBold))
+
+	v.statusView = tview.NewTextView()
+	v.statusView.SetDynamicColors(true)
+	v.statusView.SetTextColor(v.colors.foreground)
+	v.statusView.SetBackgroundColor(v.colors.background)
+	v.statusView.SetWrap(false)
+	v.updateStatus()
+
+	playingLabel := tview.NewTextView()
+	playingLabel.SetText(" Playing:")
+	playingLabel.SetTextColor(v.colors.foreground)
+	playingLabel.SetBackgroundColor(v.colors.background)
+	playingLabel.SetWrap(false)
+
+	v.trackView = tview.NewTextView()
+	v.trackView.SetDynamicColors(true)
+	v.trackView.SetDrawFunc(func(_ tcell.Screen, x, y, width, height int) (int, int, int, int) {
+		if width != v.trackWidth {
+			v.trackWidth = width
+			v.fitTrack(v.track, v.trackColor)
+		}
+		return x, y, width, height
+	})
+	v.fitTrack(v.station.LastPlaying, v.colors.highlight)
+	v.trackView.SetTextColor(v.colors.highlight)
+	v.trackView.SetBackgroundColor(v.colors.background)
+	v.trackView.SetWrap(true)
+	v.trackView.SetTextStyle(tcell.StyleDefault.Background(v.colors.background).Attributes(tcell.AttrBold))
+
+	v.tickerView = tview.NewTextView()
+	v.tickerView.SetDynamicColors(true)
+	v.tickerView.SetTextColor(v.colors.foreground)
+	v.tickerView.SetBackgroundColor(v.colors.background)
+	v.tickerView.SetWrap(false)
+	if v.station.ID == v.playingID {
+		v.tickerView.SetText(v.ticker.render())
+	}
+
+	v.meterView = tview.NewBox().SetBackgroundColor(v.colors.background)
+	v.meterView.SetDrawFunc(func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
+		if v.station.ID == v.playingID && width > 1 {
+			v.meter.draw(screen, x+1, y, width-1, v.colors)
+		}
+		return x, y, width, height
+	})
+
+	genreLabel := tview.NewTextView()
+	genreLabel.SetText(" Genre:")
+	genreLabel.SetTextColor(v.colors.foreground)
+	genreLabel.SetBackgroundColor(v.colors.background)
+	genreLabel.SetWrap(false)
+
+	similar := similarStations(v.station, v.service.GetCachedStations())
+	similarView := tview.NewTextView()
+	similarView.SetDynamicColors(true)
+	similarView.SetDrawFunc(func(_ tcell.Screen, x, y, width, height int) (int, int, int, int) {
+		similarView.SetText(similarText(similar, width))
+		return x, y, width, height
+	})
+	similarView.SetTextColor(v.colors.foreground)
+	similarView.SetBackgroundColor(v.colors.background)
+	similarView.SetWrap(false)
+
+	// Similar stations share the genre label's row, which is otherwise empty
+	genreRow := tview.NewFlex().SetDirection(tview.FlexColumn).
+		AddItem(genreLabel, 9, 0, false).
+		AddItem(similarView, 0, 1, false)
+	genreRow.SetBackgroundColor(v.colors.background)
+
+	v.genreView = tview.NewFlex().SetDirection(tview.FlexColumn)
+	v.genreView.SetBackgroundColor(v.colors.background)
+	v.fillGenreTags(v.genreView, v.genreNames())
+
+	descriptionLabel := tview.NewTextView()
+	descriptionLabel.SetText(" Description:")
+	descriptionLabel.SetTextColor(v.colors.foreground)
+	descriptionLabel.SetBackgroundColor(v.colors.background)
+	descriptionLabel.SetWrap(false)
+
+	v.descView = tview.NewTextView()
+	v.descView.SetDynamicColors(true)
+	v.descView.SetText(fmt.Sprintf(" [%s]%s[-]",
+		v.colors.foreground.String(),
+		v.description()))
+	v.descView.SetTextColor(v.colors.foreground)
+	v.descView.SetBackgroundColor(v.colors.background)
+	v.descView.SetWrap(true)
+
+	infoSpacer := tview.NewBox().SetBackgroundColor(v.colors.background)
+
+	infoContent := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(stationLabel, 1, 0, false).
+		AddItem(v.nameView, 1, 0, false).
+		AddItem(v.statusView, 1, 0, false).
+		AddItem(playingLabel, 1, 0, false).
+		AddItem(v.trackView, 1, 0, false).
+		AddItem(v.tickerView, 1, 0, false).
+		AddItem(v.meterView, v.meterRows(), 0, false).
+		AddItem(genreRow, 1, 0, false).
+		AddItem(v.genreView, 1, 0, false).
+		AddItem(nil, 1, 0, false).
+		AddItem(descriptionLabel, 1, 0, false).
+		AddItem(v.descView, 0, 1, false).
+		AddItem(infoSpacer, 0, 1, false)
+	infoContent.SetBackgroundColor(v.colors.background)
+	v.info = infoContent
+
+	v.volumeView = tview.NewFlex().SetDirection(tview.FlexRow)
+	v.volumeView.SetBackgroundColor(v.colors.background)
+	v.volumeView.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+		if !v.volumeView.InRect(event.Position()) {
+			return action, event
+		}
+		switch action {
+		case tview.MouseScrollUp:
+			v.bus.publish(volumeScrolled{step: VolumeStep})
+		case tview.MouseScrollDown:
+			v.bus.publish(volumeScrolled{step: -VolumeStep})
+		default:
+			return action, event
+		}
+		return tview.MouseConsumed, nil
+	})
+	v.buildVolumeBar()
+
+	// Wrap logo in vertical flex to constrain height
+	logoWrapper := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(v.logo, CoverHeight, 0, false).
+		AddItem(nil, 0, 1, false)
+	logoWrapper.SetBackgroundColor(v.colors.background)
+
+	contentFlex := tview.NewFlex().SetDirection(tview.FlexColumn).
+		AddItem(logoWrapper, CoverWidth, 0, false).
+		AddItem(infoContent, 0, 1, false).
+		AddItem(v.volumeView, 7, 0, false)
+	contentFlex.SetBackgroundColor(v.colors.background)
+
+	contentWithPadding := tview.NewFlex().SetDirection(tview.FlexColumn).
+		AddItem(nil, 4, 0, false).
+		AddItem(contentFlex, 0, 1, false).
+		AddItem(nil, 4, 0, false)
+	contentWithPadding.SetBackgroundColor(v.colors.background)
+
+	return contentWithPadding
+}
+
+// updateStatus shows the playback status line (state, bitrate, buffer)
+// under the station name while the shown station is the one playing.
+func (v *PlayerPanelView) updateStatus() {
+	if v.statusView == nil {
+		return
+	}
+	if v.station == nil || v.station.ID != v.playingID {
+		v.statusView.SetText("")
+		return
+	}
+	v.statusView.SetText(" " + v.status.Render())
+}
+
+// setVisualizer shows or hides the level meter under the ticker.
+func (v *PlayerPanelView) setVisualizer(on bool) {
+	v.visualizer = on
+	v.meter.reset()
+	if v.info != nil {
+		v.info.ResizeItem(v.meterView, v.meterRows(), 0)
+	}
+}
+
+func (v *PlayerPanelView) meterRows() int {
+	if v.visualizer {
+		return 1
+	}
+	return 0
+}
+
+// showTrack sets the current track line without touching the pulse.
+func (v *PlayerPanelView) showTrack(track string) {
+	if v.trackView != nil {
+		v.fitTrack(track, v.colors.highlight)
+	}
+}
+
+// setTrack shows the track the player reports, starting a pulse when it
+// changed and pulse is on, and feeds it to the ticker.
+func (v *PlayerPanelView) setTrack(track string, pulse bool) {
+	if v.trackView == nil {
+		return
+	}
+
+	color := v.colors.highlight
+	if pulse {
+		now := v.clock().Now()
+		v.pulse.observe(track, now)
+		color, _ = v.pulse.color(color, now)
+	}
+	v.fitTrack(track, color)
+	v.ticker.observe(track)
+	v.updateTicker()
+}
+
+// fitTrack sets the track line, cut to the width it was last drawn at so
+// a long title ends in an ellipsis instead of wrapping out of sight.
+func (v *PlayerPanelView) fitTrack(track string, color tcell.Color) {
+	v.track, v.trackColor = track, color
+	if v.trackWidth > 1 {
+		track = truncateWidth(track, v.trackWidth-1)
+	}
+	v.trackView.SetText(fmt.Sprintf(" [%s]%s[-]", color.String(), track))
+}
+
+func (v *PlayerPanelView) setVolume(volume int, muted bool) {
+	v.volume = volume
+	v.muted = muted
+	if v.volumeView != nil {
+		v.volumeView.Clear()
+		v.buildVolumeBar()
+	}
+}
+
+func (v *PlayerPanelView) buildVolumeBar() {
+	const barHeight = 10
+
+	filledLines := (v.volume * barHeight) / 100
+	emptyLines := barHeight - filledLines
+
+	createText := func(text string, color tcell.Color) *tview.TextView {
+		tv := tview.NewTextView()
+		tv.SetText(text)
+		tv.SetTextAlign(tview.AlignRight)
+		tv.SetTextColor(color)
+		tv.SetBackgroundColor(v.colors.background)
+		return tv
+	}
+
+	createBarLine := func(barText string, barColor tcell.Color, showPercent bool) *tview.Flex {
+		line := tview.NewFlex().SetDirection(tview.FlexColumn)
+		line.SetBackgroundColor(v.colors.background)
+
+		if showPercent {
+			percentText := fmt.Sprintf("%d%%", v.volume)
+
+			var percentColor tcell.Color
+			if v.muted {
+				percentColor = v.colors.mutedVolume
+			} else {
+				percentColor = v.colors.highlight
+			}
+
+			percentView := createText(percentText, percentColor)
+			percentView.SetTextAlign(tview.AlignRight)
+
+			if v.muted {
+				percentView.SetTextStyle(tcell.StyleDefault.
+					Foreground(percentColor).
+					Background(v.colors.background).
+					Attributes(tcell.AttrStrikeThrough))
+			}
+
+			line.AddItem(percentView, 4, 0, false)
+		} else {
+			line.AddItem(createText("    ", v.colors.foreground), 4, 0, false)
+		}
+
+		line.AddItem(createText(barText, barColor), 0, 1, false)
+
+		return line
+	}
+
+	container := v.volumeView
+	container.AddItem(createText("   max", v.colors.foreground), 1, 0, false)
+
+	for i := 0; i < emptyLines; i++ {
+		container.AddItem(createBarLine(" ░░", v.colors.foreground, false), 1, 0, false)
+	}
+
+	barColor := v.colors.highlight
+	if v.muted {
+		barColor = v.colors.mutedVolume
+	}
+	for i := 0; i < filledLines; i++ {
+		showPercent := (i == 0)
+		container.AddItem(createBarLine(" ██", barColor, showPercent), 1, 0, false)
+	}
+
+	container.AddItem(createText("   min", v.colors.foreground), 1, 0, false)
+
+	container.AddItem(nil, 0, 1, false)
+}
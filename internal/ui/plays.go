@@ -0,0 +1,221 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/likes"
+	"github.com/glebovdev/somafm-cli/internal/playlog"
+	"github.com/rivo/tview"
+	"github.com/rs/zerolog/log"
+)
+
+// SetPlayLog passes on the log of every track change, which the play log
+// view browses. Without one the view is unavailable.
+func (ui *UI) SetPlayLog(l *playlog.Log) {
+	ui.plays = l
+}
+
+// playTrack formats e the way the stream titles it.
+func playTrack(e playlog.Entry) string {
+	if e.Artist == "" {
+		return e.Title
+	}
+	return e.Artist + " - " + e.Title
+}
+
+// filterPlays returns the entries matching every word of query in their
+// artist, title, or station, most recent first.
+func filterPlays(entries []playlog.Entry, query string) []playlog.Entry {
+	words := strings.Fields(strings.ToLower(query))
+	matches := make([]playlog.Entry, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		text := strings.ToLower(e.Artist + " " + e.Title + " " + e.StationTitle)
+		matched := true
+		for _, w := range words {
+			if !strings.Contains(text, w) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			matches = append(matches, e)
+		}
+	}
+	return matches
+}
+
+// fillPlayList lists play log entries in the given order.
+func (ui *UI) fillPlayList(table *tview.Table, entries []playlog.Entry) {
+	table.Clear()
+	headers := []string{"Time", "Station", "Artist", "Title"}
+	for col, h := range headers {
+		table.SetCell(0, col, tview.NewTableCell(h).
+			SetTextColor(ui.colors.highlight).
+			SetSelectable(false))
+	}
+	for i, e := range entries {
+		row := i + 1
+		table.SetCell(row, 0, tview.NewTableCell(e.Time.In(ui.timeZone()).Format("Jan 02 15:04")).SetTextColor(ui.colors.borders))
+		table.SetCell(row, 1, tview.NewTableCell(tview.Escape(e.StationTitle)).SetMaxWidth(16))
+		table.SetCell(row, 2, tview.NewTableCell(tview.Escape(e.Artist)).SetMaxWidth(20))
+		table.SetCell(row, 3, tview.NewTableCell(tview.Escape(e.Title)).SetMaxWidth(28).SetExpansion(1))
+	}
+}
+
+// copyTrack puts track on the system clipboard. The terminal does the
+// copying, so it works over ssh but only in terminals that allow it.
+func (ui *UI) copyTrack(track string) {
+	ui.clipboard = []byte(track)
+	ui.showNotice("Copied: " + track)
+}
+
+// showPlayLogModal browses every track change in the play log, across
+// sessions, however briefly each track played.
+func (ui *UI) showPlayLogModal() {
+	if ui.plays == nil {
+		ui.showNotice("Play log unavailable")
+		return
+	}
+	entries, err := ui.plays.Entries()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to read the play log")
+		ui.showNotice("Failed to read the play log")
+		return
+	}
+	keyColor := ui.colors.helpHotkey.String()
+	shown := filterPlays(entries, "")
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBackgroundColor(ui.colors.modalBackground)
+	table.SetSelectedStyle(tcell.StyleDefault.
+		Background(ui.colors.highlight).
+		Foreground(ui.colors.modalBackground))
+
+	search := tview.NewInputField().
+		SetLabel("/").
+		SetLabelColor(ui.colors.highlight).
+		SetFieldBackgroundColor(ui.colors.modalBackground).
+		SetFieldTextColor(ui.colors.foreground).
+		SetPlaceholder("artist, title, or station").
+		SetPlaceholderTextColor(ui.colors.borders)
+	search.SetBackgroundColor(ui.colors.modalBackground)
+
+	hintView := tview.NewTextView().
+		SetTextAlign(tview.AlignCenter).
+		SetDynamicColors(true)
+	hintView.SetTextColor(tcell.ColorDarkGray)
+	hintView.SetBackgroundColor(ui.colors.modalBackground)
+
+	frame := tview.NewFrame(nil)
+
+	render := func() {
+		ui.fillPlayList(table, shown)
+		table.Select(1, 0)
+		table.ScrollToBeginning()
+		if query := strings.TrimSpace(search.GetText()); query != "" {
+			frame.SetTitle(fmt.Sprintf(" Play Log: %d of %d match %q ", len(shown), len(entries), query))
+		} else {
+			frame.SetTitle(fmt.Sprintf(" Play Log (%d) ", len(entries)))
+		}
+	}
+	hintView.SetText(fmt.Sprintf("[::d][%s]/[-] search • [%s]l[-] like • [%s]c[-] copy • Esc close[::-]", keyColor, keyColor, keyColor))
+
+	selected := func() (playlog.Entry, bool) {
+		row, _ := table.GetSelection()
+		if row < 1 || row > len(shown) {
+			return playlog.Entry{}, false
+		}
+		return shown[row-1], true
+	}
+
+	search.SetChangedFunc(func(text string) {
+		shown = filterPlays(entries, text)
+		render()
+	})
+	search.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			search.SetText("")
+		}
+		ui.app.SetFocus(table)
+	})
+
+	var body tview.Primitive = table
+	if len(entries) == 0 {
+		empty := tview.NewTextView().
+			SetTextAlign(tview.AlignCenter).
+			SetText("\nNo plays logged yet.\n\nEvery track change is logged here as you listen.")
+		empty.SetTextColor(ui.colors.foreground)
+		empty.SetBackgroundColor(ui.colors.modalBackground)
+		body = empty
+	}
+
+	content := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(body, 0, 1, true).
+		AddItem(search, 1, 0, false).
+		AddItem(hintView, 1, 0, false)
+	content.SetBackgroundColor(ui.colors.modalBackground)
+
+	frame.SetPrimitive(content).
+		SetBorders(1, 0, 0, 0, 2, 2)
+	frame.SetBorder(true).
+		SetBorderColor(ui.colors.borders).
+		SetBackgroundColor(ui.colors.modalBackground).
+		SetTitleColor(ui.colors.highlight).
+		SetTitleAlign(tview.AlignCenter)
+
+	render()
+
+	modalWidth := 84
+	modalHeight := min(len(entries), 20) + 9
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(frame, modalHeight, 0, true).
+			AddItem(nil, 0, 1, false),
+			modalWidth, 0, true).
+		AddItem(nil, 0, 1, false)
+	modal.SetBackgroundColor(ui.colors.background)
+
+	modal.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if search.HasFocus() {
+			return event
+		}
+		switch event.Key() {
+		case tcell.KeyEscape:
+			ui.modals.close(modalPage)
+			return nil
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn, tcell.KeyHome, tcell.KeyEnd:
+			return event
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case '/':
+				if len(entries) > 0 {
+					ui.app.SetFocus(search)
+				}
+			case 'l', 'L':
+				if e, ok := selected(); ok {
+					ui.likeTrack(likes.Track{Artist: e.Artist, Title: e.Title, Station: e.Station}, playTrack(e))
+				}
+			case 'c', 'C', 'y', 'Y':
+				if e, ok := selected(); ok {
+					ui.copyTrack(playTrack(e))
+				}
+			case 'j', 'k', 'g', 'G':
+				return event
+			case 'H', 'q', 'Q':
+				ui.modals.close(modalPage)
+			}
+			return nil
+		}
+		return nil
+	})
+
+	ui.modals.open(modalPage, modal, table)
+}
@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/player"
+)
+
+const (
+	// PulseDuration is how long the track title pulses after a track change.
+	PulseDuration = 1500 * time.Millisecond
+	// pulseStrength is how far toward white the color gets at the peak.
+	pulseStrength = 0.6
+)
+
+// accentPulse briefly brightens the highlight color when the track changes,
+// as a cue for a terminal kept in peripheral vision.
+type accentPulse struct {
+	track   string    // Last track seen, to notice changes
+	started time.Time // Zero when no pulse is running
+}
+
+// reset forgets the track of the previous station, so its first track
+// doesn't pulse.
+func (p *accentPulse) reset() {
+	p.track = ""
+	p.started = time.Time{}
+}
+
+// observe starts a pulse when track differs from a known previous track.
+func (p *accentPulse) observe(track string, now time.Time) {
+	if track == "" || track == player.NoTrackInfo || track == p.track {
+		return
+	}
+	if p.track != "" {
+		p.started = now
+	}
+	p.track = track
+}
+
+// color returns base as brightened at now, and whether the pulse is still
+// running. Colors without a known RGB value, such as the terminal default,
+// can't be brightened and don't pulse.
+func (p *accentPulse) color(base tcell.Color, now time.Time) (tcell.Color, bool) {
+	if p.started.IsZero() {
+		return base, false
+	}
+	elapsed := now.Sub(p.started)
+	if elapsed >= PulseDuration || elapsed < 0 {
+		return base, false
+	}
+
+	r, g, b := base.RGB()
+	if r < 0 {
+		return base, true
+	}
+	// Ramps up and back down over the pulse
+	amount := pulseStrength * math.Sin(math.Pi*float64(elapsed)/float64(PulseDuration))
+	brighten := func(c int32) int32 {
+		return c + int32(math.Round(float64(255-c)*amount))
+	}
+	return tcell.NewRGBColor(brighten(r), brighten(g), brighten(b)), true
+}
+
+// updatePulse redraws the track title while a pulse runs. It is called on
+// every animation frame.
+func (v *PlayerPanelView) updatePulse() {
+	if v.pulse.started.IsZero() || v.trackView == nil {
+		return
+	}
+	color, running := v.pulse.color(v.colors.highlight, v.clock().Now())
+	if !running {
+		v.pulse.started = time.Time{}
+	}
+	v.trackView.SetText(fmt.Sprintf(" [%s]%s[-]", color.String(), v.pulse.track))
+}
@@ -0,0 +1,369 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/rivo/tview"
+	"github.com/rs/zerolog/log"
+)
+
+// playlistURLFor returns the playlist picked for s with the quality
+// selector, or else the lower stream accepted for this session after the
+// preferred one failed, or "" to try all of its playlists.
+func (ui *UI) playlistURLFor(s *station.Station) string {
+	playlistURL := ui.config.StreamPlaylistURL(s)
+	if variant := ui.config.StreamVariant(s.ID); variant != "" && playlistURL == "" {
+		log.Debug().Msgf("Stream %s is no longer offered by %s, using the best available", variant, s.ID)
+	}
+	if variant := ui.fallback.variants[s.ID]; variant != "" && playlistURL == "" {
+		if playlist, ok := s.PlaylistByVariant(variant); ok {
+			playlistURL = playlist.URL
+		}
+	}
+	return playlistURL
+}
+
+// qualityOrder sorts the matrix columns from best to worst. Unknown
+// qualities go last, in the order the station lists them.
+var qualityOrder = map[string]int{"highest": 0, "high": 1, "medium": 2, "low": 3}
+
+// streamMatrix arranges a station's playlists by format (rows) and
+// quality (columns).
+type streamMatrix struct {
+	formats   []string
+	qualities []string
+	playlists map[[2]int]station.Playlist // By format and quality index
+}
+
+func newStreamMatrix(s *station.Station) streamMatrix {
+	m := streamMatrix{playlists: make(map[[2]int]station.Playlist)}
+	for _, playlist := range s.Playlists {
+		if !slices.Contains(m.formats, playlist.Format) {
+			m.formats = append(m.formats, playlist.Format)
+		}
+		if !slices.Contains(m.qualities, playlist.Quality) {
+			m.qualities = append(m.qualities, playlist.Quality)
+		}
+	}
+	slices.SortStableFunc(m.qualities, func(a, b string) int {
+		rank := func(q string) int {
+			if r, ok := qualityOrder[q]; ok {
+				return r
+			}
+			return len(qualityOrder)
+		}
+		return rank(a) - rank(b)
+	})
+	for _, playlist := range s.Playlists {
+		key := [2]int{slices.Index(m.formats, playlist.Format), slices.Index(m.qualities, playlist.Quality)}
+		if _, ok := m.playlists[key]; !ok {
+			m.playlists[key] = playlist
+		}
+	}
+	return m
+}
+
+// streamCellText shows a playlist in the matrix: ● for the saved choice,
+// ➤ for the stream playing now, then the bitrate and whether it answered.
+func streamCellText(saved, playing bool, probe *player.StreamProbe) string {
+	mark := " "
+	switch {
+	case playing:
+		mark = "➤"
+	case saved:
+		mark = "●"
+	}
+	switch {
+	case probe == nil:
+		return mark + " …"
+	case probe.Err != nil:
+		return mark + " [red]✗[-]"
+	default:
+		return fmt.Sprintf("%s %dk [green]✓[-]", mark, probe.Info.Bitrate)
+	}
+}
+
+// streamDetail describes the selected playlist below the matrix.
+func streamDetail(playlist station.Playlist, probe *player.StreamProbe) string {
+	name := tview.Escape(path.Base(playlist.URL))
+	switch {
+	case probe == nil:
+		return fmt.Sprintf("%s [::d]checking…[::-]", name)
+	case probe.Err != nil:
+		return fmt.Sprintf("%s [red]%s[-]", name, tview.Escape(probe.Err.Error()))
+	default:
+		info := probe.Info
+		return fmt.Sprintf("%s [::d]%s %dk %.1fkHz, answered in %s[::-]", name, info.Format, info.Bitrate,
+			float64(info.SampleRate)/1000, probe.Latency.Round(time.Millisecond))
+	}
+}
+
+// showQualityModal shows the playlists of the highlighted station as a
+// format by quality matrix, checks each stream for its real codec and
+// bitrate, and remembers the one picked. If the station is playing, it is
+// restarted on the new stream.
+func (ui *UI) showQualityModal() {
+	index := ui.stations.selectedIndex()
+	s := ui.stationService.GetStation(index)
+	if s == nil || len(s.Playlists) == 0 {
+		ui.showNotice("No streams to choose from")
+		return
+	}
+	keyColor := ui.colors.helpHotkey.String()
+	current := ui.config.StreamVariant(s.ID)
+	playingURL := ""
+	if s.ID == ui.playingStationID && (ui.player.IsPlaying() || ui.player.IsPaused()) {
+		playingURL = ui.player.GetStreamInfo().PlaylistURL
+	}
+	m := newStreamMatrix(s)
+	probes := make(map[string]*player.StreamProbe)
+
+	table := tview.NewTable().
+		SetSelectable(true, true).
+		SetFixed(1, 1)
+	table.SetBackgroundColor(ui.colors.modalBackground)
+	table.SetSelectedStyle(tcell.StyleDefault.
+		Background(ui.colors.highlight).
+		Foreground(ui.colors.modalBackground))
+
+	detailView := tview.NewTextView().
+		SetDynamicColors(true)
+	detailView.SetTextColor(ui.colors.foreground)
+	detailView.SetBackgroundColor(ui.colors.modalBackground)
+
+	table.SetCell(0, 0, tview.NewTableCell("").SetSelectable(false))
+	for col, quality := range m.qualities {
+		table.SetCell(0, col+1, tview.NewTableCell(quality).
+			SetTextColor(ui.colors.highlight).
+			SetExpansion(1).
+			SetSelectable(false))
+	}
+	render := func() {
+		for row, format := range m.formats {
+			table.SetCell(row+1, 0, tview.NewTableCell(strings.ToUpper(format)).
+				SetTextColor(ui.colors.highlight).
+				SetSelectable(false))
+			for col := range m.qualities {
+				playlist, ok := m.playlists[[2]int{row, col}]
+				if !ok {
+					table.SetCell(row+1, col+1, tview.NewTableCell("  ─").
+						SetTextColor(ui.colors.borders).
+						SetSelectable(false))
+					continue
+				}
+				text := streamCellText(playlist.Variant() == current, playlist.URL == playingURL, probes[playlist.URL])
+				table.SetCell(row+1, col+1, tview.NewTableCell(text).SetTextColor(ui.colors.foreground))
+			}
+		}
+		automatic := "  Automatic"
+		if current == "" {
+			automatic = "● Automatic"
+		}
+		table.SetCell(len(m.formats)+1, 0, tview.NewTableCell("").SetSelectable(false))
+		table.SetCell(len(m.formats)+1, 1, tview.NewTableCell(automatic).SetTextColor(ui.colors.foreground))
+	}
+	selected := func() (station.Playlist, bool) {
+		row, col := table.GetSelection()
+		playlist, ok := m.playlists[[2]int{row - 1, col - 1}]
+		return playlist, ok
+	}
+	updateDetail := func() {
+		if playlist, ok := selected(); ok {
+			detailView.SetText(streamDetail(playlist, probes[playlist.URL]))
+		} else {
+			detailView.SetText("[::d]The best stream available, falling back to lower ones[::-]")
+		}
+	}
+	render()
+	table.SetSelectionChangedFunc(func(row, column int) {
+		updateDetail()
+	})
+	table.Select(len(m.formats)+1, 1)
+	for key, playlist := range m.playlists {
+		if playlist.Variant() == current {
+			table.Select(key[0]+1, key[1]+1)
+		}
+	}
+	updateDetail()
+
+	hint := fmt.Sprintf("[::d][%s]Enter[-] use stream • ● saved • ➤ playing • Esc close[::-]", keyColor)
+	if playingURL != "" {
+		hint = fmt.Sprintf("[::d][%s]Enter[-] use stream • [%s]x[-] compare A/B • ➤ playing • Esc close[::-]", keyColor, keyColor)
+	}
+	hintView := tview.NewTextView().
+		SetTextAlign(tview.AlignCenter).
+		SetDynamicColors(true).
+		SetText(hint)
+	hintView.SetTextColor(tcell.ColorDarkGray)
+	hintView.SetBackgroundColor(ui.colors.modalBackground)
+
+	content := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(detailView, 1, 0, false).
+		AddItem(hintView, 1, 0, false)
+	content.SetBackgroundColor(ui.colors.modalBackground)
+
+	frame := tview.NewFrame(content).
+		SetBorders(1, 0, 0, 0, 2, 2)
+	frame.SetBorder(true).
+		SetBorderColor(ui.colors.borders).
+		SetBackgroundColor(ui.colors.modalBackground).
+		SetTitle(fmt.Sprintf(" Streams: %s ", s.Title)).
+		SetTitleColor(ui.colors.highlight).
+		SetTitleAlign(tview.AlignCenter)
+
+	modalWidth := 66
+	modalHeight := len(m.formats) + 8
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(frame, modalHeight, 0, true).
+			AddItem(nil, 0, 1, false),
+			modalWidth, 0, true).
+		AddItem(nil, 0, 1, false)
+	modal.SetBackgroundColor(ui.colors.background)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	for _, playlist := range m.playlists {
+		go func() {
+			probe := ui.player.ProbeStream(ctx, playlist.URL)
+			if ctx.Err() != nil {
+				return
+			}
+			ui.app.QueueUpdateDraw(func() {
+				probes[playlist.URL] = &probe
+				render()
+				updateDetail()
+			})
+		}()
+	}
+
+	closeModal := func() {
+		cancel()
+		ui.modals.close(modalPage)
+	}
+
+	modal.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEscape:
+			closeModal()
+			return nil
+		case tcell.KeyEnter:
+			variant := ""
+			if playlist, ok := selected(); ok {
+				variant = playlist.Variant()
+			}
+			closeModal()
+			ui.selectStreamVariant(index, s, variant)
+			return nil
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyLeft, tcell.KeyRight, tcell.KeyHome, tcell.KeyEnd:
+			return event
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'h', 'j', 'k', 'l':
+				return event
+			case 'x', 'X':
+				if playlist, ok := selected(); ok && playingURL != "" {
+					closeModal()
+					ui.startComparison(playlist)
+				}
+			case 's', 'S', 'q', 'Q':
+				closeModal()
+			}
+			return nil
+		}
+		return nil
+	})
+
+	ui.modals.open(modalPage, modal, table)
+}
+
+// selectStreamVariant saves the stream choice for s and switches to it if
+// s is playing.
+func (ui *UI) selectStreamVariant(index int, s *station.Station, variant string) {
+	if variant == ui.config.StreamVariant(s.ID) {
+		return
+	}
+	ui.config.SetStreamVariant(s.ID, variant)
+	ui.requestConfigSave()
+
+	if variant == "" {
+		ui.showNotice("Stream: automatic")
+	} else {
+		ui.showNotice("Stream: " + variant)
+	}
+
+	if s.ID == ui.playingStationID && (ui.player.IsPlaying() || ui.player.IsPaused()) {
+		ui.stopRecording()
+		ui.player.Stop()
+		ui.onStationSelected(index)
+	}
+}
+
+// startComparison connects to playlist alongside the playing stream, for
+// x to switch between them.
+func (ui *UI) startComparison(playlist station.Playlist) {
+	if playlist.URL == ui.player.GetStreamInfo().PlaylistURL {
+		ui.showNotice("That stream is already playing, pick another to compare")
+		return
+	}
+	ui.showNotice("Connecting to " + playlist.Variant() + "…")
+	go func() {
+		err := ui.player.StartComparison(playlist.URL)
+		ui.app.QueueUpdateDraw(func() {
+			if err != nil {
+				log.Warn().Err(err).Msgf("Comparison with %s failed", playlist.URL)
+				ui.showNotice("Can't compare: " + err.Error())
+				return
+			}
+			ui.showNotice(fmt.Sprintf("Comparing with %s: x switches A/B, X stops", playlist.Variant()))
+		})
+	}()
+}
+
+// switchComparison flips between the A and B streams of a comparison.
+func (ui *UI) switchComparison() {
+	hearingB, ok := ui.player.SwitchComparison()
+	if !ok {
+		ui.showNotice("Nothing to compare: press s, pick a stream, then x")
+		return
+	}
+	c, _ := ui.player.Comparison()
+	side, info := "A", c.A.Info
+	if hearingB {
+		side, info = "B", c.B.Info
+	}
+	ui.showNotice(fmt.Sprintf("Hearing %s: %s %dk", side, info.Format, info.Bitrate))
+}
+
+// stopComparison disconnects B and goes back to A.
+func (ui *UI) stopComparison() {
+	if _, ok := ui.player.Comparison(); !ok {
+		return
+	}
+	ui.player.StopComparison()
+	ui.showNotice("Comparison stopped")
+}
+
+// formatComparison shows both streams of a comparison with their
+// measured bitrate and buffer, the one heard marked with ▶.
+func formatComparison(c player.Comparison) string {
+	side := func(name string, heard bool, s player.ComparisonSide) string {
+		mark := " "
+		if heard {
+			mark = "▶"
+		}
+		return fmt.Sprintf("%s%s %s %s %d%%", mark, name, s.Info.Format, formatBitrate(s.Info.Bitrate, s.Bitrate), s.Buffer)
+	}
+	return side("A", !c.HearingB, c.A) + " │ " + side("B", c.HearingB, c.B)
+}
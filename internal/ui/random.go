@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"math"
+	"math/rand/v2"
+	"strconv"
+
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/station"
+)
+
+// maxRecentStations bounds the list of recently played station IDs.
+const maxRecentStations = 50
+
+// favoriteRandomWeight is how much likelier a favorite is to be picked
+// with the "favorites" random weighting.
+const favoriteRandomWeight = 3
+
+// randomStation plays a random visible station, skipping the ones played
+// most recently and applying the configured weighting.
+func (ui *UI) randomStation() {
+	candidates := ui.randomCandidates()
+	if len(candidates) == 0 {
+		return
+	}
+
+	weights := make([]float64, len(candidates))
+	for i, index := range candidates {
+		weights[i] = ui.randomWeight(ui.stationService.GetStation(index))
+	}
+	index := candidates[weightedPick(weights, rand.Float64())]
+
+	ui.stations.selectStation(index)
+	ui.onStationSelected(index)
+}
+
+// randomCandidates returns the visible stations not played recently. When
+// that leaves nothing, e.g. under a narrow filter, only the playing station
+// is excluded, and failing that every visible station is a candidate.
+func (ui *UI) randomCandidates() []int {
+	exclude := make(map[station.StationID]bool)
+	for i, id := range ui.recentStations {
+		if i >= ui.config.Random.ExcludeRecent {
+			break
+		}
+		exclude[id] = true
+	}
+
+	for _, skip := range []map[station.StationID]bool{exclude, {ui.playingStationID: true}} {
+		var candidates []int
+		for _, index := range ui.stations.visible {
+			if s := ui.stationService.GetStation(index); s != nil && !skip[s.ID] {
+				candidates = append(candidates, index)
+			}
+		}
+		if len(candidates) > 0 {
+			return candidates
+		}
+	}
+	return append([]int(nil), ui.stations.visible...)
+}
+
+func (ui *UI) randomWeight(s *station.Station) float64 {
+	if s == nil {
+		return 0
+	}
+	switch ui.config.Random.Weight {
+	case config.RandomWeightFavorites:
+		if ui.config.IsFavorite(s.ID) {
+			return favoriteRandomWeight
+		}
+	case config.RandomWeightListeners:
+		// The square root keeps the biggest channels from drowning out the rest.
+		listeners, _ := strconv.Atoi(s.Listeners)
+		return 1 + math.Sqrt(float64(max(listeners, 0)))
+	}
+	return 1
+}
+
+// weightedPick returns the index whose cumulative weight range contains r,
+// a number in [0, 1).
+func weightedPick(weights []float64, r float64) int {
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	if total <= 0 {
+		return int(r * float64(len(weights)))
+	}
+
+	target := r * total
+	for i, w := range weights {
+		if target < w {
+			return i
+		}
+		target -= w
+	}
+	return len(weights) - 1
+}
+
+// recordRecentStation moves id to the front of the recently played list.
+func (ui *UI) recordRecentStation(id station.StationID) {
+	recent := []station.StationID{id}
+	for _, existing := range ui.recentStations {
+		if existing != id && len(recent) < maxRecentStations {
+			recent = append(recent, existing)
+		}
+	}
+	ui.recentStations = recent
+}
+
+// seedRecentStations fills the recently played list from listening history,
+// so random keeps avoiding the same stations across restarts.
+func (ui *UI) seedRecentStations() {
+	if ui.history == nil {
+		return
+	}
+	entries := ui.history.Entries()
+	seen := make(map[station.StationID]bool)
+	for i := len(entries) - 1; i >= 0 && len(ui.recentStations) < maxRecentStations; i-- {
+		id := station.NewStationID(entries[i].Station)
+		if !seen[id] {
+			seen[id] = true
+			ui.recentStations = append(ui.recentStations, id)
+		}
+	}
+}
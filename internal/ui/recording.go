@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/rs/zerolog/log"
+)
+
+// toggleRecording starts or stops saving the playing stream to disk.
+func (ui *UI) toggleRecording() {
+	if ui.player.IsRecording() {
+		ui.stopRecording()
+		return
+	}
+
+	rec := ui.config.Recording
+	dir, err := rec.Directory()
+	if err != nil {
+		log.Error().Err(err).Msg("No recording directory")
+		ui.showNotice("Recording failed: no directory")
+		return
+	}
+
+	if !ui.pruneRecordings(dir) {
+		return
+	}
+
+	target, err := ui.player.StartRecording(player.RecordingOptions{
+		Dir:         dir,
+		SplitTracks: rec.SplitTracks,
+		KeepPartial: rec.KeepPartial,
+	})
+	if errors.Is(err, player.ErrNotPlaying) {
+		ui.showNotice("Start a station to record it")
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to start recording")
+		ui.showNotice("Recording failed: " + err.Error())
+		return
+	}
+
+	if rec.SplitTracks {
+		ui.showNotice("● Recording tracks to " + target)
+	} else {
+		ui.showNotice("● Recording to " + filepath.Base(target))
+	}
+}
+
+// stopRecording finishes a recording, if any, and reports what was saved.
+func (ui *UI) stopRecording() {
+	if !ui.player.IsRecording() {
+		return
+	}
+	ui.recordingQuota = recordingQuotaState{}
+	files, err := ui.player.StopRecording()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to finish recording")
+	}
+	switch len(files) {
+	case 0:
+		ui.showNotice("Recording stopped, no complete tracks")
+	case 1:
+		ui.showNotice("Saved " + filepath.Base(files[0]))
+	default:
+		ui.showNotice(fmt.Sprintf("Saved %d recordings to %s", len(files), filepath.Dir(files[0])))
+	}
+}
+
+const (
+	// RecordingQuotaInterval is how often the recording directory is checked
+	// against its quota while recording.
+	RecordingQuotaInterval = time.Minute
+	// RecordingQuotaWarning is the share of the quota the current recording
+	// may fill before a warning that it will be stopped.
+	RecordingQuotaWarning = 0.9
+)
+
+type recordingQuotaState struct {
+	lastCheck time.Time
+	warned    bool
+}
+
+// pruneRecordings applies the recording quota and retention to dir. It
+// reports false, after telling the user, when there is still no room.
+func (ui *UI) pruneRecordings(dir string) bool {
+	rec := ui.config.Recording
+	quota := player.RecordingQuota{MaxBytes: rec.MaxBytes(), MaxAge: rec.MaxAge()}
+	if quota == (player.RecordingQuota{}) {
+		return true
+	}
+
+	usage, err := player.PruneRecordings(dir, quota, ui.timeSource().Now(), ui.player.RecordingFiles())
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to apply recording quota")
+		return true
+	}
+	if len(usage.Removed) > 0 {
+		log.Info().Strs("files", usage.Removed).Msg("Deleted old recordings")
+		noun := "recordings"
+		if len(usage.Removed) == 1 {
+			noun = "recording"
+		}
+		ui.showNotice(fmt.Sprintf("Deleted %d old %s to stay within limits", len(usage.Removed), noun))
+	}
+	if quota.MaxBytes == 0 {
+		return true
+	}
+
+	limit := formatBytes(quota.MaxBytes)
+	switch {
+	case usage.Total >= quota.MaxBytes && ui.player.IsRecording():
+		log.Warn().Int64("bytes", usage.Total).Msg("Recording quota reached, stopping")
+		ui.stopRecording()
+		ui.showNotice(fmt.Sprintf("Recording stopped: the %s quota is full", limit))
+		return false
+	case usage.Total >= quota.MaxBytes:
+		ui.showNotice(fmt.Sprintf("Recording quota of %s is full", limit))
+		return false
+	case float64(usage.Pinned) >= RecordingQuotaWarning*float64(quota.MaxBytes) && !ui.recordingQuota.warned:
+		ui.recordingQuota.warned = true
+		ui.showNotice(fmt.Sprintf("● Recording uses %s of the %s quota and stops when it is full",
+			formatBytes(usage.Pinned), limit))
+	}
+	return true
+}
+
+// checkRecordingQuota keeps a running recording within its quota, every
+// RecordingQuotaInterval.
+func (ui *UI) checkRecordingQuota() {
+	if !ui.player.IsRecording() {
+		return
+	}
+	now := ui.timeSource().Now()
+	if now.Sub(ui.recordingQuota.lastCheck) < RecordingQuotaInterval {
+		return
+	}
+	ui.recordingQuota.lastCheck = now
+
+	dir, err := ui.config.Recording.Directory()
+	if err != nil {
+		return
+	}
+	ui.pruneRecordings(dir)
+}
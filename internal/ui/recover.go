@@ -0,0 +1,276 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/rivo/tview"
+	"github.com/rs/zerolog/log"
+)
+
+// lineTrackInterval is how often line mode checks for a new track.
+const lineTrackInterval = time.Second
+
+// guard runs f on the UI thread. A panic in f is logged and stops the
+// app, which restores the terminal; Run then asks how to go on. The
+// player has its own goroutines and keeps playing meanwhile.
+func (ui *UI) guard(f func()) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Error().Interface("panic", p).Str("stack", string(debug.Stack())).Msg("Interface crashed")
+			if ui.crash == nil {
+				ui.crash = p
+				// Stop waits for the lock a draw holds
+				go ui.app.Stop()
+			}
+		}
+	}()
+	f()
+}
+
+// runApp runs the app until it stops. A panic that got past guard, in a
+// queued update or tview itself, is recovered here: tview has restored
+// the terminal by then, but the app can't run again.
+func (ui *UI) runApp() (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Error().Interface("panic", p).Str("stack", string(debug.Stack())).Msg("Interface crashed")
+			ui.crash, ui.appBroken = p, true
+		}
+	}()
+	ui.crash = nil
+	return ui.app.Run()
+}
+
+// setRoot shows p full screen, with its drawing and input under guard.
+func (ui *UI) setRoot(p tview.Primitive) *tview.Application {
+	return ui.app.SetRoot(guardedRoot{Primitive: p, ui: ui}, true)
+}
+
+// guardedRoot runs the root primitive's drawing and event handlers under
+// guard, which covers every view below it.
+type guardedRoot struct {
+	tview.Primitive
+	ui *UI
+}
+
+func (g guardedRoot) Draw(screen tcell.Screen) {
+	g.ui.guard(func() { g.Primitive.Draw(screen) })
+}
+
+func (g guardedRoot) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	handler := g.Primitive.InputHandler()
+	if handler == nil {
+		return nil
+	}
+	return func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		g.ui.guard(func() { handler(event, setFocus) })
+	}
+}
+
+func (g guardedRoot) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (bool, tview.Primitive) {
+	handler := g.Primitive.MouseHandler()
+	if handler == nil {
+		return nil
+	}
+	return func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+		g.ui.guard(func() { consumed, capture = handler(action, event, setFocus) })
+		return consumed, capture
+	}
+}
+
+func (g guardedRoot) PasteHandler() func(text string, setFocus func(p tview.Primitive)) {
+	handler := g.Primitive.PasteHandler()
+	if handler == nil {
+		return nil
+	}
+	return func(text string, setFocus func(p tview.Primitive)) {
+		g.ui.guard(func() { handler(text, setFocus) })
+	}
+}
+
+// recoverFromCrash tells the user the interface crashed and does what
+// they choose: restart it, which brings the app back on a fresh screen,
+// or line mode. It reports false to quit.
+func (ui *UI) recoverFromCrash() bool {
+	restartable := !ui.appBroken
+	c := ui.console
+	c.printf("\nThe interface crashed: %v\nThe music keeps playing. Run with --debug to log the details.\n", ui.crash)
+	for {
+		if restartable {
+			c.printf("[r] restart the interface, [l] line mode, [q] quit: ")
+		} else {
+			c.printf("[l] line mode, [q] quit: ")
+		}
+		answer, ok := c.readLine(ui.shutdown)
+		if !ok {
+			return false
+		}
+		switch strings.ToLower(answer) {
+		case "r":
+			if restartable {
+				ui.restart()
+				return true
+			}
+		case "l":
+			if ui.runLineMode(restartable) {
+				ui.restart()
+				return true
+			}
+			return false
+		case "q":
+			return false
+		}
+	}
+}
+
+// restart rebuilds the screen for the next run of the app. Updates queued
+// while it was down run once it's back.
+func (ui *UI) restart() {
+	ui.imageRenderer().reset()
+	if ui.modals != nil {
+		ui.rebuildScreen()
+	}
+	log.Info().Msg("Restarting the interface")
+}
+
+// runLineMode is the minimal interface left after a crash: it prints the
+// track as it changes and takes one-letter commands. It reports whether
+// to restart the full interface.
+func (ui *UI) runLineMode(restartable bool) bool {
+	c := ui.console
+	commands := "p pause/resume, + - volume, s status, q quit"
+	if restartable {
+		commands += ", r restart the interface"
+	}
+	c.printf("Line mode: %s\n", commands)
+	ui.printLineStatus()
+
+	done := make(chan struct{})
+	defer close(done)
+	go ui.printTrackChanges(done)
+
+	for {
+		command, ok := c.readLine(ui.shutdown)
+		if !ok {
+			return false
+		}
+		switch command {
+		case "p", "P":
+			if ui.player.IsPlaying() || ui.player.IsPaused() {
+				ui.player.TogglePause()
+			}
+			ui.printLineStatus()
+		case "+", "=":
+			ui.setLineVolume(VolumeStep)
+		case "-", "_":
+			ui.setLineVolume(-VolumeStep)
+		case "s", "S", "":
+			ui.printLineStatus()
+		case "r", "R":
+			if restartable {
+				return true
+			}
+			c.printf("%s\n", commands)
+		case "q", "Q":
+			return false
+		default:
+			c.printf("%s\n", commands)
+		}
+	}
+}
+
+// setLineVolume changes the volume without the views, which may be what
+// crashed; a restart picks it up.
+func (ui *UI) setLineVolume(delta int) {
+	ui.mu.Lock()
+	if ui.isMuted {
+		ui.currentVolume, ui.isMuted = ui.config.Volume, false
+		ui.statusRenderer.SetMuted(false)
+	}
+	ui.currentVolume = config.ClampVolume(ui.currentVolume + delta)
+	volume := ui.currentVolume
+	ui.mu.Unlock()
+
+	ui.player.SetVolume(volume)
+	ui.SaveConfig()
+	ui.console.printf("Volume %d%%\n", volume)
+}
+
+func (ui *UI) printLineStatus() {
+	title := "Nothing playing"
+	if s := ui.player.GetCurrentStation(); s != nil {
+		title = s.Title
+	}
+	ui.mu.Lock()
+	volume := ui.currentVolume
+	ui.mu.Unlock()
+	ui.console.printf("%s — %s, volume %d%%\n", title, strings.ToLower(ui.player.GetState().String()), volume)
+	if track := ui.player.GetCurrentTrack(); track != "" && ui.player.IsPlaying() {
+		ui.console.printf("♫ %s\n", track)
+	}
+}
+
+// printTrackChanges prints each new track until done is closed.
+func (ui *UI) printTrackChanges(done <-chan struct{}) {
+	ticker := ui.timeSource().NewTicker(lineTrackInterval)
+	defer ticker.Stop()
+	last := ui.player.GetCurrentTrack()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C():
+			if track := ui.player.GetCurrentTrack(); track != last {
+				last = track
+				ui.console.printf("♫ %s\n", track)
+			}
+		}
+	}
+}
+
+// console is the terminal while the app is down: where the crash prompt
+// and line mode read and write.
+type console struct {
+	in  *bufio.Reader
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func newConsole(in io.Reader, out io.Writer) *console {
+	return &console{in: bufio.NewReader(in), out: out}
+}
+
+func (c *console) printf(format string, args ...any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(c.out, format, args...)
+}
+
+// readLine returns the next line of input, trimmed. It reports false at
+// the end of input or once shutdown is closed, leaving the read pending.
+// Nothing is read ahead, so the restarted app gets every key after it.
+func (c *console) readLine(shutdown <-chan struct{}) (string, bool) {
+	lines := make(chan string, 1)
+	go func() {
+		line, err := c.in.ReadString('\n')
+		if err != nil && line == "" {
+			close(lines)
+			return
+		}
+		lines <- strings.TrimSpace(line)
+	}()
+	select {
+	case line, ok := <-lines:
+		return line, ok
+	case <-shutdown:
+		return "", false
+	}
+}
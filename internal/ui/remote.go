@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/control"
+	"github.com/glebovdev/somafm-cli/internal/publish"
+	"github.com/glebovdev/somafm-cli/internal/station"
+)
+
+// remoteTimeout bounds how long a control command waits for the UI thread,
+// which stops taking updates once the app has quit.
+const remoteTimeout = 5 * time.Second
+
+var errRemoteTimeout = errors.New("player is not responding")
+
+// remote runs control socket commands on the UI thread, like the matching
+// keys would.
+type remote struct {
+	ui *UI
+}
+
+// Remote returns the control socket target for this UI.
+func (ui *UI) Remote() control.Target {
+	return remote{ui: ui}
+}
+
+// do runs f on the UI thread and waits for it to finish.
+func (r remote) do(f func()) error {
+	done := make(chan struct{})
+	r.ui.app.QueueUpdateDraw(func() {
+		// Remote commands count as activity for the idle stop
+		r.ui.lastInput = r.ui.timeSource().Now()
+		f()
+		close(done)
+	})
+	select {
+	case <-done:
+		return nil
+	case <-time.After(remoteTimeout):
+		return errRemoteTimeout
+	}
+}
+
+func (r remote) Play(id station.StationID) error {
+	var err error
+	if doErr := r.do(func() {
+		index := r.ui.stationService.FindIndexByID(id)
+		if index < 0 {
+			err = fmt.Errorf("unknown station %q", id)
+			return
+		}
+		r.ui.stations.selectStation(index)
+		r.ui.onStationSelected(index)
+	}); doErr != nil {
+		return doErr
+	}
+	return err
+}
+
+func (r remote) Next() (station.StationID, error) {
+	var id station.StationID
+	err := r.do(func() {
+		r.ui.nextStation()
+		id = r.ui.playingStationID
+	})
+	return id, err
+}
+
+func (r remote) Stop() {
+	_ = r.do(r.ui.stopPlayback)
+}
+
+func (r remote) TogglePause() (bool, error) {
+	var paused bool
+	var err error
+	if doErr := r.do(func() {
+		if !r.ui.player.IsPlaying() && !r.ui.player.IsPaused() {
+			err = errors.New("nothing is playing")
+			return
+		}
+		r.ui.player.TogglePause()
+		r.ui.stations.updatePlayingIndicator()
+		paused = r.ui.player.IsPaused()
+	}); doErr != nil {
+		return false, doErr
+	}
+	return paused, err
+}
+
+func (r remote) SetVolume(percent int) int {
+	percent = config.ClampVolume(percent)
+	_ = r.do(func() {
+		r.ui.setVolume(percent)
+		r.ui.flashVolume()
+	})
+	return percent
+}
+
+func (r remote) Status() publish.Event {
+	return publish.Snapshot(r.ui.player)
+}
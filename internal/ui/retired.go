@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+)
+
+// retiredBadge follows the title of a station the channel list no longer
+// has. It keeps playing, and keeps its row, until the stream ends.
+const retiredBadge = " [::d](retired)[::-]"
+
+// noticeRetired tells once when a refresh has retired the playing station.
+func (ui *UI) noticeRetired() {
+	playing := ui.stationService.GetStation(ui.playingIndex)
+	if playing == nil || !playing.Retired || ui.retiredNoticed == playing.ID {
+		return
+	}
+	ui.retiredNoticed = playing.ID
+	ui.showNotice(playing.Title + " was removed from SomaFM — it keeps playing while the stream lasts")
+}
+
+// suggestAfterRetired replaces the error for a retired station whose stream
+// died, as it won't come back, with a station to switch to. It reports
+// whether the playing station was retired.
+func (ui *UI) suggestAfterRetired() bool {
+	playing := ui.stationService.GetStation(ui.playingIndex)
+	if playing == nil || !playing.Retired {
+		return false
+	}
+	log.Info().Msgf("Stream of retired station %s ended", playing.Title)
+	next, ok := ui.pickSimilar(playing)
+	if !ok {
+		ui.showNotice(playing.Title + " was removed from SomaFM and its stream has ended")
+		return true
+	}
+	ui.showNotice(fmt.Sprintf("%s was removed from SomaFM and its stream has ended — press y for %s",
+		playing.Title, next.Title))
+	return true
+}
@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// RenderText draws p onto a simulation screen and returns its text content,
+// one line per row with trailing spaces trimmed. Colors and attributes are
+// ignored.
+func RenderText(p tview.Primitive, width, height int) (string, error) {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		return "", fmt.Errorf("failed to init simulation screen: %w", err)
+	}
+	defer screen.Fini()
+	screen.SetSize(width, height)
+
+	p.SetRect(0, 0, width, height)
+	p.Draw(screen)
+	screen.Show()
+
+	cells, w, h := screen.GetContents()
+	lines := make([]string, h)
+	for y := 0; y < h; y++ {
+		var b strings.Builder
+		for x := 0; x < w; x++ {
+			runes := cells[y*w+x].Runes
+			if len(runes) == 0 {
+				b.WriteRune(' ')
+				continue
+			}
+			b.WriteString(string(runes))
+		}
+		lines[y] = strings.TrimRight(b.String(), " ")
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// Screenshot builds the main screen with the first station selected and
+// renders it as text, without starting the event loop or playback. Set a
+// fake clock first so animations and countdowns are frozen.
+func (ui *UI) Screenshot(width, height int) (string, error) {
+	if _, err := ui.stationService.GetStations(ui.requestContext()); err != nil {
+		return "", fmt.Errorf("failed to fetch stations: %w", err)
+	}
+	ui.setupUI()
+	ui.selectAndShowStation(0)
+	return RenderText(ui.modals.pages, width, height)
+}
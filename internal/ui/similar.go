@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/glebovdev/somafm-cli/internal/format"
+	"github.com/glebovdev/somafm-cli/internal/genre"
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/rivo/tview"
+	"github.com/rs/zerolog/log"
+)
+
+// similarShown is how many similar stations the player panel lists.
+const similarShown = 3
+
+// similarState tracks how long the playing station has played, so similar
+// stations are suggested once per stretch on it.
+type similarState struct {
+	station   station.StationID
+	since     time.Time
+	suggested bool
+}
+
+// similarStations returns the stations sharing genres with s, the most
+// similar first. Ties go to the station with more listeners.
+func similarStations(s *station.Station, stations []station.Station) []station.Station {
+	type scored struct {
+		station   station.Station
+		score     float64
+		listeners int
+	}
+	var candidates []scored
+	for _, other := range stations {
+		if other.ID == s.ID {
+			continue
+		}
+		if score := genre.Similarity(s.Genre, other.Genre); score > 0 {
+			listeners, _ := strconv.Atoi(other.Listeners)
+			candidates = append(candidates, scored{other, score, listeners})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].listeners > candidates[j].listeners
+	})
+
+	similar := make([]station.Station, len(candidates))
+	for i, c := range candidates {
+		similar[i] = c.station
+	}
+	return similar
+}
+
+// similarText lists as many of the first few similar stations as fit in
+// width cells, or nothing when not even one fits.
+func similarText(similar []station.Station, width int) string {
+	text := ""
+	for i := 0; i < len(similar) && i < similarShown; i++ {
+		next := text + " · " + tview.Escape(similar[i].Title)
+		if i == 0 {
+			next = "[::d]Similar:[::-] " + tview.Escape(similar[i].Title)
+		}
+		if tview.TaggedStringWidth(next) > width {
+			break
+		}
+		text = next
+	}
+	return text
+}
+
+// pickSimilar returns the station y jumps to from s: the most similar one
+// not played recently, so pressing y again moves on instead of going back.
+func (ui *UI) pickSimilar(s *station.Station) (station.Station, bool) {
+	similar := similarStations(s, ui.stationService.GetCachedStations())
+	if len(similar) == 0 {
+		return station.Station{}, false
+	}
+	recent := make(map[station.StationID]bool)
+	for _, id := range ui.recentStations {
+		recent[id] = true
+	}
+	for _, candidate := range similar {
+		if !recent[candidate.ID] {
+			return candidate, true
+		}
+	}
+	return similar[0], true
+}
+
+// playSimilar switches to a station similar to the shown one.
+func (ui *UI) playSimilar() {
+	from := ui.currentStation
+	if from == nil {
+		return
+	}
+	next, ok := ui.pickSimilar(from)
+	if !ok {
+		ui.showNotice("No stations share a genre with " + from.Title)
+		return
+	}
+	index := ui.stationService.FindIndexByID(next.ID)
+	if index < 0 {
+		return
+	}
+	log.Debug().Msgf("Switching to %s, similar to %s", next.Title, from.Title)
+	ui.stations.selectStation(index)
+	ui.onStationSelected(index)
+	ui.showNotice(fmt.Sprintf("≈ %s, similar to %s", next.Title, from.Title))
+}
+
+// checkSimilarSuggestion suggests a similar station once the playing one
+// has played for the configured similar_after time. It runs every second.
+func (ui *UI) checkSimilarSuggestion() {
+	after := ui.config.SimilarAfter
+	if after <= 0 || ui.player.GetState() != player.StatePlaying {
+		return
+	}
+	now := ui.timeSource().Now()
+	if ui.similar.station != ui.playingStationID {
+		ui.similar = similarState{station: ui.playingStationID, since: now}
+		return
+	}
+	if ui.similar.suggested || now.Sub(ui.similar.since) < after {
+		return
+	}
+	ui.similar.suggested = true
+
+	playing := ui.stationService.GetStation(ui.stationService.FindIndexByID(ui.playingStationID))
+	if playing == nil {
+		return
+	}
+	next, ok := ui.pickSimilar(playing)
+	if !ok {
+		return
+	}
+	ui.showNotice(fmt.Sprintf("%s on %s — you might also like %s, press y to switch",
+		format.Duration(after), playing.Title, next.Title))
+}
@@ -0,0 +1,309 @@
+package ui
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/api"
+	"github.com/glebovdev/somafm-cli/internal/clock"
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/format"
+	"github.com/glebovdev/somafm-cli/internal/history"
+	"github.com/glebovdev/somafm-cli/internal/likes"
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/glebovdev/somafm-cli/internal/service"
+	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/glebovdev/somafm-cli/internal/termbg"
+	"github.com/glebovdev/somafm-cli/internal/termimg"
+	"github.com/rivo/tview"
+)
+
+// Run `go test ./internal/ui -run Snapshot -update` to rewrite the golden
+// files after an intentional layout change, then review the diff.
+var updateSnapshots = flag.Bool("update", false, "update UI snapshot files")
+
+func init() {
+	// There is no terminal to ask; the auto theme stays dark and logos
+	// are drawn with blocks
+	detectBackground = func(time.Duration) termbg.Background { return termbg.Dark }
+	detectGraphics = func(time.Duration) termimg.Protocol { return termimg.None }
+}
+
+const (
+	snapshotWidth  = 100
+	snapshotHeight = 40
+)
+
+// newSnapshotUI builds the main layout against a local fixture server, without
+// starting the tview event loop.
+func newSnapshotUI(t *testing.T) *UI {
+	t.Helper()
+	t.Setenv("HOME", "/home/snapshot")
+
+	fixture, err := os.ReadFile(filepath.Join("testdata", "channels.json"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(fixture)
+	}))
+	t.Cleanup(server.Close)
+
+	stationService := service.NewStationService(api.NewSomaFMClientWithBaseURL(server.URL), service.CacheDisabled)
+	if _, err := stationService.GetStations(context.Background()); err != nil {
+		t.Fatalf("GetStations() error = %v", err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Favorites = []station.StationID{"dronezone"}
+
+	ui := NewUI(player.NewPlayer(), stationService, cfg, false)
+	ui.SetClock(clock.NewFake(time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)))
+	ui.numbers = format.NumbersFor("en_US.UTF-8")
+	ui.location = time.UTC
+	ui.setupUI()
+	ui.selectAndShowStation(0)
+	return ui
+}
+
+// renderSnapshot draws p onto a simulation screen and returns its text
+// content with trailing spaces trimmed. Colors and attributes are ignored.
+func renderSnapshot(t *testing.T, p tview.Primitive, width, height int) string {
+	t.Helper()
+
+	text, err := RenderText(p, width, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return text
+}
+
+func assertSnapshot(t *testing.T, name, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", "snapshots", name+".txt")
+	if *updateSnapshots {
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf("failed to write snapshot: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read snapshot %s (run with -update to create it): %v", path, err)
+	}
+	if got == string(want) {
+		return
+	}
+
+	gotLines := strings.Split(got, "\n")
+	wantLines := strings.Split(string(want), "\n")
+	for i := 0; i < max(len(gotLines), len(wantLines)); i++ {
+		var g, w string
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if g != w {
+			t.Errorf("snapshot %s differs at line %d:\n got: %q\nwant: %q\n(run with -update if the change is intended)", name, i+1, g, w)
+			return
+		}
+	}
+}
+
+func TestSnapshotMainLayout(t *testing.T) {
+	ui := newSnapshotUI(t)
+	assertSnapshot(t, "main", renderSnapshot(t, ui.modals.pages, snapshotWidth, snapshotHeight))
+}
+
+func TestSnapshotNarrowLayout(t *testing.T) {
+	ui := newSnapshotUI(t)
+	assertSnapshot(t, "main_narrow", renderSnapshot(t, ui.modals.pages, 60, snapshotHeight))
+}
+
+func TestSnapshotStationList(t *testing.T) {
+	ui := newSnapshotUI(t)
+	assertSnapshot(t, "station_list", renderSnapshot(t, ui.stations.table, 80, 8))
+}
+
+func TestSnapshotFilteredStationList(t *testing.T) {
+	ui := newSnapshotUI(t)
+	ui.stations.applyFilter("ambient")
+	assertSnapshot(t, "station_list_filtered", renderSnapshot(t, ui.stations.table, 80, 8))
+}
+
+func TestSnapshotStationListWatching(t *testing.T) {
+	ui := newSnapshotUI(t)
+	ui.config.Watch.Stations = 1
+	ui.stations.setHeader(ui.stations.table)
+	ui.stations.populate()
+	assertSnapshot(t, "station_list_watching", renderSnapshot(t, ui.stations.table, 120, 8))
+}
+
+func TestSnapshotPlayerPanel(t *testing.T) {
+	ui := newSnapshotUI(t)
+	assertSnapshot(t, "player_panel", renderSnapshot(t, ui.panel.root, 90, PlayerPanelHeight))
+}
+
+func TestSnapshotPlayerPanelTicker(t *testing.T) {
+	ui := newSnapshotUI(t)
+	ui.panel.setPlaying(ui.currentStation.ID)
+	ui.panel.ticker.seed("Bonobo - Kiara", []string{"Tycho - Awake", "Boards of Canada - Dayvan Cowboy"})
+	ui.selectAndShowStation(0)
+	assertSnapshot(t, "player_panel_ticker", renderSnapshot(t, ui.panel.root, 90, PlayerPanelHeight))
+}
+
+func TestSnapshotPlayerPanelVisualizer(t *testing.T) {
+	ui := newSnapshotUI(t)
+	ui.panel.setVisualizer(true)
+	ui.panel.setPlaying(ui.currentStation.ID)
+	// Left at -6 dBFS, right at -18 dBFS
+	ui.panel.meter.update(player.Levels{RMS: [2]float64{0.5, 0.125}})
+	assertSnapshot(t, "player_panel_visualizer", renderSnapshot(t, ui.panel.root, 90, PlayerPanelHeight))
+}
+
+func TestSnapshotPlayerPanelTranslated(t *testing.T) {
+	ui := newSnapshotUI(t)
+	ui.panel.setTranslated(true)
+	ui.panel.addTranslation(ui.currentStation.ID, stationTranslation{
+		description: "Eine köstliche Mischung aus Ambient-Beats",
+		genres:      []string{"Ambient", "Elektronika"},
+	})
+	assertSnapshot(t, "player_panel_translated", renderSnapshot(t, ui.panel.root, 90, PlayerPanelHeight))
+}
+
+func TestSnapshotFooter(t *testing.T) {
+	ui := newSnapshotUI(t)
+	assertSnapshot(t, "footer", renderSnapshot(t, ui.footer.box, 90, FooterHeightWide))
+}
+
+func TestSnapshotHelpModal(t *testing.T) {
+	ui := newSnapshotUI(t)
+	ui.showHelpModal()
+	assertSnapshot(t, "help_modal", renderSnapshot(t, ui.modals.pages, snapshotWidth, snapshotHeight))
+}
+
+func TestSnapshotAboutModal(t *testing.T) {
+	ui := newSnapshotUI(t)
+	ui.showAboutModal()
+	assertSnapshot(t, "about_modal", renderSnapshot(t, ui.modals.pages, snapshotWidth, snapshotHeight))
+}
+
+func TestSnapshotKioskBranding(t *testing.T) {
+	ui := newSnapshotUI(t)
+	ui.config.Branding = config.Branding{Title: "Corner Cafe Radio", HideVersion: true, HideLinks: true}
+	ui.setupUI()
+	ui.selectAndShowStation(0)
+	ui.showAboutModal()
+	assertSnapshot(t, "kiosk_branding", renderSnapshot(t, ui.modals.pages, snapshotWidth, snapshotHeight))
+}
+
+func newSnapshotLikes(t *testing.T) *likes.Store {
+	t.Helper()
+
+	store, err := likes.Open(filepath.Join(t.TempDir(), likes.FileName))
+	if err != nil {
+		t.Fatalf("likes.Open() error = %v", err)
+	}
+	day := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+	for i, track := range []likes.Track{
+		{Artist: "Bonobo", Title: "Kiara", Station: "groovesalad"},
+		{Artist: "Stars of the Lid", Title: "Requiem for Dying Mothers", Station: "dronezone"},
+		{Artist: "Bonobo", Title: "Black Sands", Station: "groovesalad"},
+	} {
+		track.LikedAt = day.Add(time.Duration(i) * 24 * time.Hour)
+		if _, err := store.Add(track); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	return store
+}
+
+func TestSnapshotLikesModal(t *testing.T) {
+	ui := newSnapshotUI(t)
+	ui.likes = newSnapshotLikes(t)
+	ui.showLikesModal()
+	assertSnapshot(t, "likes_modal", renderSnapshot(t, ui.modals.pages, snapshotWidth, snapshotHeight))
+}
+
+func TestSnapshotLikesByArtist(t *testing.T) {
+	ui := newSnapshotUI(t)
+	ui.likes = newSnapshotLikes(t)
+	ui.showLikesModal()
+	ui.modals.pages.InputHandler()(tcell.NewEventKey(tcell.KeyRune, 'g', tcell.ModNone), func(p tview.Primitive) { ui.app.SetFocus(p) })
+	assertSnapshot(t, "likes_by_artist", renderSnapshot(t, ui.modals.pages, snapshotWidth, snapshotHeight))
+}
+
+func newSnapshotHistory(t *testing.T) *history.Store {
+	t.Helper()
+
+	store, err := history.Open(filepath.Join(t.TempDir(), history.FileName))
+	if err != nil {
+		t.Fatalf("history.Open() error = %v", err)
+	}
+	day := time.Date(2026, 3, 14, 9, 40, 0, 0, time.UTC)
+	for _, e := range []struct {
+		offset  time.Duration
+		station string
+		title   string
+		track   string
+	}{
+		{0, "groovesalad", "Groove Salad", "Bonobo - Kiara"},
+		{6 * time.Minute, "groovesalad", "Groove Salad", "Thievery Corporation - Lebanese Blonde"},
+		{11 * time.Minute, "groovesalad", "Groove Salad", "Boards of Canada - Dayvan Cowboy"},
+		{17 * time.Minute, "dronezone", "Drone Zone", "Stars of the Lid - Requiem for Dying Mothers"},
+		{26 * time.Minute, "dronezone", "Drone Zone", "Brian Eno - An Ending (Ascent)"},
+		{24*time.Hour + 5*time.Minute, "defcon", "DEF CON Radio", "Mr. Robot - Hello Friend"},
+	} {
+		entry := history.Entry{Station: e.station, StationTitle: e.title, Track: e.track, Start: day.Add(e.offset)}
+		if _, err := store.Add(entry); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	return store
+}
+
+func TestSnapshotHistoryModal(t *testing.T) {
+	ui := newSnapshotUI(t)
+	ui.history = newSnapshotHistory(t)
+	ui.showHistoryModal()
+	assertSnapshot(t, "history_modal", renderSnapshot(t, ui.modals.pages, snapshotWidth, snapshotHeight))
+}
+
+func TestSnapshotHistoryTimeline(t *testing.T) {
+	ui := newSnapshotUI(t)
+	ui.history = newSnapshotHistory(t)
+	ui.showHistoryModal()
+	send := func(key tcell.Key, r rune) {
+		ui.modals.pages.InputHandler()(tcell.NewEventKey(key, r, tcell.ModNone), func(p tview.Primitive) { ui.app.SetFocus(p) })
+	}
+	send(tcell.KeyRune, 't')
+	send(tcell.KeyUp, 0)
+	send(tcell.KeyRight, 0)
+	assertSnapshot(t, "history_timeline", renderSnapshot(t, ui.modals.pages, snapshotWidth, snapshotHeight))
+}
+
+func TestSnapshotStatsModal(t *testing.T) {
+	ui := newSnapshotUI(t)
+	ui.showStatsModal()
+	assertSnapshot(t, "stats_modal", renderSnapshot(t, ui.modals.pages, snapshotWidth, snapshotHeight))
+}
+
+func TestSnapshotVolumeFlash(t *testing.T) {
+	ui := newSnapshotUI(t)
+	ui.currentVolume = 40
+	ui.flashVolume()
+	assertSnapshot(t, "volume_flash", renderSnapshot(t, ui.modals.pages, snapshotWidth, snapshotHeight))
+}
@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/service"
+	"github.com/rs/zerolog/log"
+)
+
+// sortOrderNames are the station list orders as shown in notices and the
+// list title.
+var sortOrderNames = map[string]string{
+	config.SortListeners: "listeners",
+	config.SortTitle:     "title",
+	config.SortGenre:     "genre",
+	config.SortFavorites: "favorites first",
+}
+
+// stationOrder returns the comparator for a sort_by value.
+func (ui *UI) stationOrder(sortBy string) service.Comparator {
+	switch sortBy {
+	case config.SortTitle:
+		return service.ByTitle
+	case config.SortGenre:
+		return service.ByGenre
+	case config.SortFavorites:
+		return service.FavoritesFirst(ui.config.IsFavorite)
+	default:
+		return service.ByListeners
+	}
+}
+
+// cycleSortOrder switches the station list to the next order, keeping the
+// selected and playing stations.
+func (ui *UI) cycleSortOrder() {
+	next := (slices.Index(config.SortOrders, ui.config.SortBy) + 1) % len(config.SortOrders)
+	ui.config.SortBy = config.SortOrders[next]
+	ui.stationService.SetOrder(ui.stationOrder(ui.config.SortBy))
+	ui.refreshStationTable()
+	ui.requestConfigSave()
+
+	ui.showNotice(fmt.Sprintf("Sorted by %s", sortOrderNames[ui.config.SortBy]))
+	log.Debug().Str("sort_by", ui.config.SortBy).Msg("Changed station order")
+}
+
+// resortForFavorites re-sorts the station list after favorites changed,
+// which only moves stations when favorites come first.
+func (ui *UI) resortForFavorites() {
+	if ui.config.SortBy != config.SortFavorites {
+		return
+	}
+	ui.stationService.Resort()
+	ui.refreshStationTable()
+}
@@ -1,16 +1,73 @@
 package ui
 
 import (
+	"context"
 	"fmt"
-	"math/rand/v2"
-	"strings"
+	"slices"
+	"strconv"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/format"
+	"github.com/glebovdev/somafm-cli/internal/genre"
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/glebovdev/somafm-cli/internal/service"
+	"github.com/glebovdev/somafm-cli/internal/station"
 	"github.com/rivo/tview"
+	"github.com/rivo/uniseg"
 	"github.com/rs/zerolog/log"
 )
 
-func (ui *UI) createStationListTable() *tview.Table {
+// StationListView is the station table with the filter input under it. It
+// keeps the visible rows in step with the station service and marks
+// favorites and the playing station.
+type StationListView struct {
+	root        *tview.Flex
+	table       *tview.Table
+	filterInput *tview.InputField
+	filterQuery string
+	visible     []int // Station indexes in table row order (row = position + 1)
+	playingID   station.StationID
+	selectedID  station.StationID
+	spinner     *PlayingSpinner
+	frame       int
+
+	app     *tview.Application
+	bus     *EventBus
+	colors  palette
+	service *service.StationService
+	player  *player.Player
+	config  *config.Config
+	genres  *genre.Translator
+	numbers format.Numbers
+}
+
+func newStationListView(ui *UI) *StationListView {
+	v := &StationListView{
+		spinner: NewPlayingSpinner(),
+		app:     ui.app,
+		bus:     ui.bus,
+		colors:  ui.colors,
+		service: ui.stationService,
+		player:  ui.player,
+		config:  ui.config,
+		genres:  ui.genres,
+		numbers: ui.numbers,
+	}
+	v.table = v.createTable()
+	v.filterInput = v.createFilterInput()
+	v.root = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(v.table, 0, 1, true).
+		AddItem(v.filterInput, 0, 0, false)
+	v.populate()
+
+	subscribe(ui.bus, func(e playingChanged) { v.setPlaying(e.id) })
+	subscribe(ui.bus, func(stationsChanged) { v.refresh() })
+	subscribe(ui.bus, func(modalClosed) { v.focus() })
+	return v
+}
+
+func (v *StationListView) createTable() *tview.Table {
 	table := tview.NewTable().
 		SetBorders(false).
 		SetSeparator(' ').
@@ -18,264 +75,546 @@ func (ui *UI) createStationListTable() *tview.Table {
 		SetFixed(1, 0)
 
 	table.SetBorder(true).
-		SetTitle(fmt.Sprintf("Stations (%d)", ui.stationService.StationCount())).
-		SetBorderColor(ui.colors.borders).
-		SetTitleColor(ui.colors.foreground).
-		SetBackgroundColor(ui.colors.background).
+		SetBorderColor(v.colors.borders).
+		SetTitleColor(v.colors.foreground).
+		SetBackgroundColor(v.colors.background).
 		SetBorderPadding(1, 0, 1, 1)
 
 	table.SetSelectedStyle(tcell.StyleDefault.
-		Foreground(ui.colors.background).
-		Background(ui.colors.highlight))
+		Foreground(v.colors.background).
+		Background(v.colors.highlight))
+
+	v.setHeader(table)
 
+	// Track selected station ID for preserving selection after refresh
+	table.SetSelectionChangedFunc(func(row, column int) {
+		if s := v.service.GetStation(v.stationIndexAtRow(row)); s != nil {
+			v.selectedID = s.ID
+			v.bus.publish(selectionChanged{station: s})
+		}
+	})
+
+	// A click plays the station instead of only selecting it
+	table.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+		if action != tview.MouseLeftClick || !table.InRect(event.Position()) {
+			return action, event
+		}
+		row, _ := table.CellAt(event.Position())
+		index := v.stationIndexAtRow(row)
+		if index < 0 {
+			return action, event
+		}
+		table.Select(row, 0)
+		v.bus.publish(stationClicked{index: index})
+		return tview.MouseConsumed, nil
+	})
+
+	return table
+}
+
+func (v *StationListView) setHeader(table *tview.Table) {
 	table.SetCell(0, 0, tview.NewTableCell(" ").
-		SetTextColor(ui.colors.stationListHeaderForeground).
-		SetBackgroundColor(ui.colors.stationListHeaderBackground).
+		SetTextColor(v.colors.stationListHeaderForeground).
+		SetBackgroundColor(v.colors.stationListHeaderBackground).
 		SetMaxWidth(2).
 		SetSelectable(false))
 
 	table.SetCell(0, 1, tview.NewTableCell(" ").
-		SetTextColor(ui.colors.stationListHeaderForeground).
-		SetBackgroundColor(ui.colors.stationListHeaderBackground).
+		SetTextColor(v.colors.stationListHeaderForeground).
+		SetBackgroundColor(v.colors.stationListHeaderBackground).
 		SetMaxWidth(2).
 		SetSelectable(false))
 
 	table.SetCell(0, 2, tview.NewTableCell("Name").
-		SetTextColor(ui.colors.stationListHeaderForeground).
-		SetBackgroundColor(ui.colors.stationListHeaderBackground).
+		SetTextColor(v.colors.stationListHeaderForeground).
+		SetBackgroundColor(v.colors.stationListHeaderBackground).
 		SetExpansion(1).
 		SetSelectable(false))
 
 	table.SetCell(0, 3, tview.NewTableCell("Genre").
-		SetTextColor(ui.colors.stationListHeaderForeground).
-		SetBackgroundColor(ui.colors.stationListHeaderBackground).
+		SetTextColor(v.colors.stationListHeaderForeground).
+		SetBackgroundColor(v.colors.stationListHeaderBackground).
 		SetExpansion(1).
 		SetSelectable(false))
 
-	table.SetCell(0, 4, tview.NewTableCell("Listeners").
-		SetTextColor(ui.colors.stationListHeaderForeground).
-		SetBackgroundColor(ui.colors.stationListHeaderBackground).
+	if v.showNowPlaying() {
+		table.SetCell(0, 4, tview.NewTableCell("Now Playing").
+			SetTextColor(v.colors.stationListHeaderForeground).
+			SetBackgroundColor(v.colors.stationListHeaderBackground).
+			SetExpansion(2).
+			SetSelectable(false))
+	}
+
+	table.SetCell(0, v.listenersColumn(), tview.NewTableCell("Listeners").
+		SetTextColor(v.colors.stationListHeaderForeground).
+		SetBackgroundColor(v.colors.stationListHeaderBackground).
 		SetAlign(tview.AlignRight).
 		SetSelectable(false))
+}
 
-	stationCount := ui.stationService.StationCount()
-	for i := 0; i < stationCount; i++ {
-		ui.setStationRow(table, i+1, i)
+// showNowPlaying reports whether the list has a Now Playing column, which
+// comes with watching stations.
+func (v *StationListView) showNowPlaying() bool {
+	return v.config.Watch.Stations > 0
+}
+
+func (v *StationListView) listenersColumn() int {
+	if v.showNowPlaying() {
+		return 5
 	}
+	return 4
+}
 
-	// Track selected station ID for preserving selection after refresh
-	table.SetSelectionChangedFunc(func(row, column int) {
-		count := ui.stationService.StationCount()
-		if row > 0 && row <= count {
-			if s := ui.stationService.GetStation(row - 1); s != nil {
-				ui.selectedStationID = s.ID
-			}
+func (v *StationListView) focus() {
+	v.app.SetFocus(v.table)
+}
+
+// stationIndexAtRow maps a table row to an index in the station service.
+// Returns -1 for the header row and rows outside the visible list.
+func (v *StationListView) stationIndexAtRow(row int) int {
+	if row <= 0 || row > len(v.visible) {
+		return -1
+	}
+	return v.visible[row-1]
+}
+
+// rowForStationIndex returns the table row showing the station, or -1 if it is filtered out.
+func (v *StationListView) rowForStationIndex(index int) int {
+	for i, stationIndex := range v.visible {
+		if stationIndex == index {
+			return i + 1
 		}
-	})
+	}
+	return -1
+}
 
-	return table
+// selectedIndex returns the station index of the selected row, or -1.
+func (v *StationListView) selectedIndex() int {
+	row, _ := v.table.GetSelection()
+	return v.stationIndexAtRow(row)
+}
+
+// selectStation moves the selection to the station, unless it is filtered out.
+func (v *StationListView) selectStation(index int) {
+	if row := v.rowForStationIndex(index); row > 0 {
+		v.table.Select(row, 0)
+	}
 }
 
-func (ui *UI) setStationRow(table *tview.Table, row int, stationIndex int) {
-	s := ui.stationService.GetStation(stationIndex)
+// selectNext moves the selection one row down, wrapping around, and returns
+// the newly selected station index, or -1 when no station is visible.
+func (v *StationListView) selectNext() int {
+	rowCount := len(v.visible)
+	if rowCount == 0 {
+		return -1
+	}
+
+	row, _ := v.table.GetSelection()
+	nextRow := row%rowCount + 1
+	v.table.Select(nextRow, 0)
+	return v.stationIndexAtRow(nextRow)
+}
+
+// selectPrev moves the selection one row up, wrapping around, and returns
+// the newly selected station index, or -1 when no station is visible.
+func (v *StationListView) selectPrev() int {
+	rowCount := len(v.visible)
+	if rowCount == 0 {
+		return -1
+	}
+
+	row, _ := v.table.GetSelection()
+	prevRow := row - 1
+	if prevRow < 1 {
+		prevRow = rowCount
+	}
+	v.table.Select(prevRow, 0)
+	return v.stationIndexAtRow(prevRow)
+}
+
+// populate rebuilds the visible rows from the service, applying the active filter.
+func (v *StationListView) populate() {
+	stationCount := v.service.StationCount()
+
+	v.visible = v.visible[:0]
+	for i := 0; i < stationCount; i++ {
+		s := v.service.GetStation(i)
+		if s != nil && stationMatchesFilter(s, v.filterQuery, v.genres) {
+			v.visible = append(v.visible, i)
+		}
+	}
+
+	for row := v.table.GetRowCount() - 1; row > len(v.visible); row-- {
+		v.table.RemoveRow(row)
+	}
+	for i, stationIndex := range v.visible {
+		v.setRow(i+1, stationIndex)
+	}
+
+	v.updateTitle()
+}
+
+func (v *StationListView) setRow(row int, stationIndex int) {
+	s := v.service.GetStation(stationIndex)
 	if s == nil {
 		return
 	}
 
-	favIcon := " "
-	if ui.config.IsFavorite(s.ID) {
-		favIcon = "★"
-	}
-	table.SetCell(row, 0, tview.NewTableCell(favIcon).
-		SetTextColor(ui.colors.foreground).
+	v.table.SetCell(row, 0, tview.NewTableCell(v.leadText(stationIndex, s.ID)).
+		SetTextColor(v.colors.foreground).
 		SetMaxWidth(2))
 
 	playIcon := " "
-	if stationIndex == ui.playingIndex {
-		if ui.player.IsPaused() {
+	if s.ID == v.playingID {
+		if v.player.IsPaused() {
 			playIcon = PauseIcon
 		} else {
 			playIcon = "➤"
 		}
 	}
-	table.SetCell(row, 1, tview.NewTableCell(playIcon).
-		SetTextColor(ui.colors.foreground).
+	v.table.SetCell(row, 1, tview.NewTableCell(playIcon).
+		SetTextColor(v.colors.foreground).
 		SetMaxWidth(2))
 
-	table.SetCell(row, 2, tview.NewTableCell(s.Title).
-		SetTextColor(ui.colors.foreground).
+	highlightColor := v.colors.highlight.String()
+	name := highlightMatches(s.Title, v.filterQuery, highlightColor)
+	if s.Retired {
+		name += retiredBadge
+	}
+	v.table.SetCell(row, 2, tview.NewTableCell(name).
+		SetTextColor(v.colors.foreground).
 		SetMaxWidth(35).
 		SetExpansion(2))
 
-	genreText := strings.ReplaceAll(s.Genre, "|", ", ")
-	table.SetCell(row, 3, tview.NewTableCell(genreText).
-		SetTextColor(ui.colors.foreground).
+	genreText := highlightMatches(genreDisplayText(s.Genre, v.genres), v.filterQuery, highlightColor)
+	v.table.SetCell(row, 3, tview.NewTableCell(genreText).
+		SetTextColor(v.colors.foreground).
 		SetMaxWidth(27).
 		SetExpansion(1))
 
-	table.SetCell(row, 4, tview.NewTableCell(s.Listeners).
-		SetTextColor(ui.colors.foreground).
+	if v.showNowPlaying() {
+		// Watched stations are polled often; the others lag behind by up
+		// to a channel list refresh, so they are dimmed
+		color := v.colors.borders
+		if slices.Contains(v.config.WatchedStations(), s.ID) {
+			color = v.colors.foreground
+		}
+		v.table.SetCell(row, 4, tview.NewTableCell(tview.Escape(s.LastPlaying)).
+			SetTextColor(color).
+			SetMaxWidth(30).
+			SetExpansion(2))
+	}
+
+	listeners := s.Listeners
+	if n, err := strconv.Atoi(listeners); err == nil {
+		listeners = v.numbers.Int(n)
+	}
+	v.table.SetCell(row, v.listenersColumn(), tview.NewTableCell(listeners).
+		SetTextColor(v.colors.foreground).
 		SetAlign(tview.AlignRight))
 }
 
-func (ui *UI) nextStation() {
-	stationCount := ui.stationService.StationCount()
-	if stationCount == 0 {
-		return
+// redrawStation rebuilds the row of the station, if it is visible.
+func (v *StationListView) redrawStation(index int) {
+	if row := v.rowForStationIndex(index); row > 0 {
+		v.setRow(row, index)
 	}
-
-	row, _ := ui.stationList.GetSelection()
-	currentIndex := row - 1
-	nextIndex := (currentIndex + 1) % stationCount
-	ui.stationList.Select(nextIndex+1, 0)
-	ui.onStationSelected(nextIndex)
 }
 
-func (ui *UI) prevStation() {
-	stationCount := ui.stationService.StationCount()
-	if stationCount == 0 {
-		return
+// leadText is the first column of a station's row: its number key, if
+// any, and the favorite star.
+func (v *StationListView) leadText(index int, id station.StationID) string {
+	badge := " "
+	if key := slices.Index(v.quickSelectIndexes(), index); key >= 0 {
+		badge = fmt.Sprintf("[%s]%d[-]", v.colors.helpHotkey.String(), key+1)
 	}
-
-	row, _ := ui.stationList.GetSelection()
-	currentIndex := row - 1
-	prevIndex := currentIndex - 1
-	if prevIndex < 0 {
-		prevIndex = stationCount - 1
+	if v.config.IsFavorite(id) {
+		return badge + "★"
 	}
-	ui.stationList.Select(prevIndex+1, 0)
-	ui.onStationSelected(prevIndex)
+	return badge + " "
 }
 
-func (ui *UI) randomStation() {
-	stationCount := ui.stationService.StationCount()
-	if stationCount == 0 {
+// updateFavorite redraws the favorite star of the selected station, and
+// the number keys of every row when they follow the favorites.
+func (v *StationListView) updateFavorite(id station.StationID) {
+	if v.config.QuickSelect == config.QuickSelectFavorites {
+		for i, stationIndex := range v.visible {
+			v.table.GetCell(i+1, 0).SetText(v.leadText(stationIndex, v.service.GetStation(stationIndex).ID))
+		}
 		return
 	}
+	row, _ := v.table.GetSelection()
+	favCell := v.table.GetCell(row, 0)
+	if favCell == nil {
+		return
+	}
+	favCell.SetText(v.leadText(v.stationIndexAtRow(row), id))
+}
 
-	randomIndex := rand.IntN(stationCount)
-	ui.stationList.Select(randomIndex+1, 0)
-	ui.onStationSelected(randomIndex)
+// QuickSelectKeys is how many stations the number keys reach, 1 to 9.
+const QuickSelectKeys = 9
+
+// quickSelectIndexes returns the stations number keys play, in key order:
+// the first favorites, or the first visible rows.
+func (v *StationListView) quickSelectIndexes() []int {
+	if v.config.QuickSelect == config.QuickSelectRows {
+		return v.visible[:min(len(v.visible), QuickSelectKeys)]
+	}
+	var indexes []int
+	for _, id := range v.config.FavoriteIDs() {
+		if index := v.service.FindIndexByID(id); index >= 0 {
+			indexes = append(indexes, index)
+		}
+		if len(indexes) == QuickSelectKeys {
+			break
+		}
+	}
+	return indexes
 }
 
-func (ui *UI) selectAndShowStation(index int) {
-	stationCount := ui.stationService.StationCount()
-	if stationCount == 0 || index < 0 || index >= stationCount {
-		return
+// setPlaying moves the playing mark to the station with the given ID.
+func (v *StationListView) setPlaying(id station.StationID) {
+	previous := v.service.FindIndexByID(v.playingID)
+	v.playingID = id
+	if previous >= 0 {
+		v.redrawStation(previous)
 	}
+	v.updatePlayingIndicator()
+}
 
-	ui.currentStation = ui.stationService.GetStation(index)
+// refresh rebuilds the rows after the stations changed, keeping the
+// selection on the same station even if it moved.
+func (v *StationListView) refresh() {
+	v.populate()
 
-	ui.stationList.Select(index+1, 0)
+	if v.selectedID != "" {
+		v.selectStation(v.service.FindIndexByID(v.selectedID))
+	}
 
-	ui.playerPanel.Clear()
-	contentPanel := ui.createContentPanel()
-	ui.playerPanel.AddItem(contentPanel, 0, 1, false)
+	log.Debug().Int("count", v.service.StationCount()).Msg("Station table refreshed")
+}
 
-	ui.updateLogoPanel(ui.currentStation)
+// advanceSpinner moves the playing spinner to its next frame.
+func (v *StationListView) advanceSpinner() {
+	v.frame++
+}
 
-	log.Debug().Msgf("Showing station info (without playing): %s", ui.currentStation.Title)
+func (v *StationListView) playingIndicator() string {
+	return v.spinner.Frames[v.frame%len(v.spinner.Frames)]
 }
 
-func (ui *UI) toggleFavorite() {
-	row, _ := ui.stationList.GetSelection()
-	stationCount := ui.stationService.StationCount()
-	if row <= 0 || row > stationCount {
+func (v *StationListView) updatePlayingIndicator() {
+	if !v.player.IsPlaying() && !v.player.IsPaused() {
 		return
 	}
 
-	stationIndex := row - 1
-	selectedStation := ui.stationService.GetStation(stationIndex)
-	if selectedStation == nil {
+	index := v.service.FindIndexByID(v.playingID)
+	row := v.rowForStationIndex(index)
+	s := v.service.GetStation(index)
+	if row < 0 || s == nil {
 		return
 	}
 
-	ui.config.ToggleFavorite(selectedStation.ID)
-
-	favCell := ui.stationList.GetCell(row, 0)
-	if favCell != nil {
-		if ui.config.IsFavorite(selectedStation.ID) {
-			favCell.SetText("★")
+	playCell := v.table.GetCell(row, 1)
+	if playCell != nil {
+		if v.player.IsPaused() {
+			playCell.SetText(PauseIcon)
 		} else {
-			favCell.SetText(" ")
+			playCell.SetText("➤")
 		}
 	}
 
-	go func() {
-		if err := ui.config.Save(); err != nil {
-			log.Error().Err(err).Msg("Failed to save config")
-		}
-	}()
+	nameCell := v.table.GetCell(row, 2)
+	if nameCell == nil {
+		return
+	}
 
-	log.Debug().Msgf("Toggled favorite for station: %s", selectedStation.Title)
+	name := s.Title
+	indicator := v.playingIndicator()
+	badge := ""
+	if s.Retired {
+		badge = retiredBadge
+	}
+
+	const maxNameWidth = 35
+	name = truncateWidth(name, maxNameWidth-uniseg.StringWidth(indicator)-tview.TaggedStringWidth(badge)-1)
+
+	nameText := highlightMatches(name, v.filterQuery, v.colors.highlight.String()) + badge + " " + indicator
+	nameCell.SetText(nameText)
 }
 
-func (ui *UI) refreshStationTable() {
-	stationCount := ui.stationService.StationCount()
+// neighbors returns the stations in the rows around the selection,
+// wrapping around, or nil with fewer than two visible stations.
+func (v *StationListView) neighbors() []*station.Station {
+	rowCount := len(v.visible)
+	if rowCount < 2 {
+		return nil
+	}
 
-	// Stations may have been re-sorted, so update index by ID
-	if ui.playingStationID != "" {
-		newIndex := ui.stationService.FindIndexByID(ui.playingStationID)
-		if newIndex >= 0 {
-			ui.playingIndex = newIndex
-		}
+	row, _ := v.table.GetSelection()
+	prevRow := row - 1
+	if prevRow < 1 {
+		prevRow = rowCount
 	}
+	nextRow := row%rowCount + 1
+	return []*station.Station{
+		v.service.GetStation(v.stationIndexAtRow(prevRow)),
+		v.service.GetStation(v.stationIndexAtRow(nextRow)),
+	}
+}
 
-	for i := 0; i < stationCount; i++ {
-		ui.setStationRow(ui.stationList, i+1, i)
+func (ui *UI) nextStation() {
+	if index := ui.stations.selectNext(); index >= 0 {
+		ui.onStationSelected(index)
+	}
+}
+
+func (ui *UI) prevStation() {
+	if index := ui.stations.selectPrev(); index >= 0 {
+		ui.onStationSelected(index)
 	}
+}
 
-	if ui.selectedStationID != "" {
-		newIndex := ui.stationService.FindIndexByID(ui.selectedStationID)
-		if newIndex >= 0 {
-			ui.stationList.Select(newIndex+1, 0)
+// quickSelect plays the station behind number key n, 1 to 9.
+func (ui *UI) quickSelect(n int) {
+	indexes := ui.stations.quickSelectIndexes()
+	if n > len(indexes) {
+		if ui.config.QuickSelect == config.QuickSelectRows {
+			ui.showNotice(fmt.Sprintf("No station %d in the list", n))
+		} else {
+			ui.showNotice(fmt.Sprintf("No favorite %d — press f to add one", n))
 		}
+		return
 	}
+	index := indexes[n-1]
+	ui.stations.selectStation(index)
+	ui.onStationSelected(index)
+}
 
-	ui.stationList.SetTitle(fmt.Sprintf("Stations (%d)", stationCount))
+// stopPlayback stops the playing station, leaving it selected.
+func (ui *UI) stopPlayback() {
+	ui.stopRecording()
+	ui.player.Stop()
+	ui.stations.updatePlayingIndicator()
+}
 
-	log.Debug().Int("count", stationCount).Msg("Station table refreshed")
+// forceReconnect tears down and reopens the playing stream, for audio that
+// sounds garbled while the player still reports it as healthy.
+func (ui *UI) forceReconnect() {
+	if !ui.player.IsPlaying() && !ui.player.IsPaused() {
+		ui.showNotice("Nothing playing to reconnect")
+		return
+	}
+	log.Info().Msg("Manual reconnect requested")
+	if ui.player.IsRecording() {
+		ui.showNotice("Reconnecting stream… the recording continues in the same file")
+	} else {
+		ui.showNotice("Reconnecting stream…")
+	}
+	go ui.player.Reconnect()
 }
 
-func (ui *UI) updateStationListPlayingIndicator() {
+func (ui *UI) selectAndShowStation(index int) {
 	stationCount := ui.stationService.StationCount()
-	if ui.playingIndex < 0 || ui.playingIndex >= stationCount {
+	if stationCount == 0 || index < 0 || index >= stationCount {
 		return
 	}
 
-	if !ui.player.IsPlaying() && !ui.player.IsPaused() {
+	ui.currentStation = ui.stationService.GetStation(index)
+	ui.stations.selectStation(index)
+	ui.panel.show(ui.currentStation)
+	ui.translateShownStation()
+
+	log.Debug().Msgf("Showing station info (without playing): %s", ui.currentStation.Title)
+}
+
+func (ui *UI) toggleFavorite() {
+	selectedStation := ui.stationService.GetStation(ui.stations.selectedIndex())
+	if selectedStation == nil {
 		return
 	}
 
-	row := ui.playingIndex + 1
-	s := ui.stationService.GetStation(ui.playingIndex)
-	if s == nil {
+	ui.config.ToggleFavorite(selectedStation.ID)
+	ui.stations.updateFavorite(selectedStation.ID)
+	ui.resortForFavorites()
+	if ui.stations.showNowPlaying() {
+		// The watched stations are the first favorites, so they may
+		// have changed
+		ui.refreshStationTable()
+	}
+	ui.requestConfigSave()
+
+	log.Debug().Msgf("Toggled favorite for station: %s", selectedStation.Title)
+}
+
+// startWatching polls the watched favorites for their tracks and redraws
+// their rows as the tracks change.
+func (ui *UI) startWatching() {
+	if ui.config.Watch.Stations == 0 {
 		return
 	}
+	ui.stationService.StartWatching(ui.config.Watch.Interval, ui.config.WatchedStations, func(id station.StationID) {
+		ui.app.QueueUpdateDraw(func() {
+			ui.stations.redrawStation(ui.stationService.FindIndexByID(id))
+		})
+	})
+}
 
-	playCell := ui.stationList.GetCell(row, 1)
-	if playCell != nil {
-		if ui.player.IsPaused() {
-			playCell.SetText(PauseIcon)
-		} else {
-			playCell.SetText("➤")
-		}
+// refreshStationTable redraws the station list after the stations changed.
+func (ui *UI) refreshStationTable() {
+	// Stations may have been re-sorted, so update index by ID
+	if ui.playingStationID != "" {
+		// A station gone from the list must not leave its old index behind,
+		// which now belongs to another station
+		ui.playingIndex = ui.stationService.FindIndexByID(ui.playingStationID)
 	}
+	ui.bus.publish(stationsChanged{})
+	ui.noticeRetired()
+}
 
-	nameCell := ui.stationList.GetCell(row, 2)
-	if nameCell == nil {
+// prefetchNeighbors opens connections to the stations before and after the
+// selected one once the user has been idle for PrefetchIdleDelay.
+func (ui *UI) prefetchNeighbors() {
+	if !ui.config.Prefetch || ui.player.GetState() != player.StatePlaying {
+		return
+	}
+	if ui.timeSource().Since(ui.lastInput) < PrefetchIdleDelay {
+		return
+	}
+	neighbors := ui.stations.neighbors()
+	if neighbors == nil {
 		return
 	}
 
-	name := s.Title
-	indicator := ui.getPlayingIndicator()
-
-	const maxNameWidth = 35
-	maxLen := maxNameWidth - len(indicator) - 1
-	if len(name) > maxLen {
-		name = name[:maxLen-3] + "..."
+	if !ui.prefetching.CompareAndSwap(false, true) {
+		return
 	}
+	go func() {
+		defer ui.prefetching.Store(false)
+		ui.player.Prefetch(context.Background(), neighbors...)
+	}()
+}
 
-	nameText := name + " " + indicator
-	nameCell.SetText(nameText)
+// watchFavorites picks up favorites edited in the config file while the
+// app runs, e.g. by dotfile sync or another instance, and redraws the stars.
+func (ui *UI) watchFavorites() {
+	ticker := ui.timeSource().NewTicker(FavoritesReloadInterval)
+	defer ticker.Stop()
+	for range ticker.C() {
+		changed, err := ui.config.ReloadFavorites()
+		if err != nil {
+			log.Debug().Err(err).Msg("Failed to reload favorites")
+			continue
+		}
+		if changed {
+			log.Info().Msg("Favorites changed in the config file")
+			ui.app.QueueUpdateDraw(func() {
+				if ui.config.SortBy == config.SortFavorites {
+					ui.stationService.Resort()
+				}
+				ui.refreshStationTable()
+				ui.showNotice("Favorites updated from config file")
+			})
+		}
+	}
 }
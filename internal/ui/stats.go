@@ -0,0 +1,167 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/format"
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/rivo/tview"
+)
+
+// journalKindColors highlights the event kinds worth noticing.
+var journalKindColors = map[player.JournalKind]string{
+	player.JournalConnect:   "green",
+	player.JournalError:     "red",
+	player.JournalReconnect: "yellow",
+	player.JournalUnderrun:  "orange",
+	player.JournalStartup:   "skyblue",
+}
+
+// statsText describes the stream and dumps the diagnostics journal,
+// newest event first.
+func (ui *UI) statsText(keyColor string) string {
+	p := ui.player
+	var b strings.Builder
+
+	stationTitle := "-"
+	if s := p.GetCurrentStation(); s != nil {
+		stationTitle = hyperlink(s.PageURL(), s.Title, "-", ui.config.Hyperlinks)
+	}
+	info := p.GetStreamInfo()
+	current, maxRetries := p.GetRetryInfo()
+	lastError := p.GetLastError()
+	if lastError == "" {
+		lastError = "-"
+	}
+
+	fmt.Fprintf(&b, "[%s]Station:[-]    %s\n", keyColor, stationTitle)
+	fmt.Fprintf(&b, "[%s]State:[-]      %s\n", keyColor, p.GetState())
+	if info.Format != "" {
+		fmt.Fprintf(&b, "[%s]Stream:[-]     %s %s, %d Hz\n", keyColor, info.Format, formatBitrate(info.Bitrate, p.GetMeasuredBitrate()), info.SampleRate)
+	}
+	fmt.Fprintf(&b, "[%s]Buffer:[-]     %d%%\n", keyColor, p.GetBufferHealth())
+	fmt.Fprintf(&b, "[%s]Session:[-]    %s\n", keyColor, format.Duration(p.GetSessionDuration()))
+	fmt.Fprintf(&b, "[%s]Retries:[-]    %d/%d\n", keyColor, current, maxRetries)
+	fmt.Fprintf(&b, "[%s]Last error:[-] %s\n", keyColor, tview.Escape(lastError))
+
+	b.WriteString(startupText(p.StartupTimings(), p.GetCurrentStation(), keyColor))
+
+	entries := p.Journal()
+	fmt.Fprintf(&b, "\n[::b]JOURNAL[::-] [::d](%d events, last %d kept)[::-]\n", len(entries), player.JournalSize)
+	if len(entries) == 0 {
+		b.WriteString("[::d]Nothing recorded yet.[::-]\n")
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		color := journalKindColors[e.Kind]
+		fmt.Fprintf(&b, "[::d]%s[::-] [%s]%-9s[-] %s\n",
+			e.Time.Local().Format("15:04:05"), color, e.Kind, tview.Escape(e.Message))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func (ui *UI) showStatsModal() {
+	keyColor := ui.colors.helpHotkey.String()
+
+	textView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(false).
+		SetText(ui.statsText(keyColor))
+	textView.SetTextColor(ui.colors.foreground)
+	textView.SetBackgroundColor(ui.colors.modalBackground)
+
+	hintView := tview.NewTextView().
+		SetTextAlign(tview.AlignCenter).
+		SetDynamicColors(true).
+		SetText(fmt.Sprintf("[::d][%s]↑/↓[-] scroll • [%s]r[-] refresh • Esc close[::-]", keyColor, keyColor))
+	hintView.SetTextColor(tcell.ColorDarkGray)
+	hintView.SetBackgroundColor(ui.colors.modalBackground)
+
+	content := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(textView, 0, 1, true).
+		AddItem(hintView, 1, 0, false)
+	content.SetBackgroundColor(ui.colors.modalBackground)
+
+	frame := tview.NewFrame(content).
+		SetBorders(1, 0, 0, 0, 2, 2)
+	frame.SetBorder(true).
+		SetBorderColor(ui.colors.borders).
+		SetBackgroundColor(ui.colors.modalBackground).
+		SetTitle(" Stream Stats ").
+		SetTitleColor(ui.colors.highlight).
+		SetTitleAlign(tview.AlignCenter)
+
+	modalWidth := 84
+	modalHeight := 30
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(frame, modalHeight, 0, true).
+			AddItem(nil, 0, 1, false),
+			modalWidth, 0, true).
+		AddItem(nil, 0, 1, false)
+	modal.SetBackgroundColor(ui.colors.background)
+
+	modal.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEscape:
+			ui.modals.close(modalPage)
+			return nil
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn, tcell.KeyHome, tcell.KeyEnd:
+			return event
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'r', 'R':
+				textView.SetText(ui.statsText(keyColor))
+			case 'j', 'k':
+				return event
+			case 'i', 'I', 'q', 'Q':
+				ui.modals.close(modalPage)
+			}
+		}
+		return nil
+	})
+
+	ui.modals.open(modalPage, modal, textView)
+}
+
+// startupText summarizes how long station starts took, from pressing Enter
+// to audible music, with percentiles over the kept starts.
+func startupText(timings []player.StartupTiming, current *station.Station, keyColor string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n[::b]STARTUP TIME[::-] [::d](Enter to audible music; %d starts, last %d kept)[::-]\n", len(timings), player.StartupHistorySize)
+	if len(timings) == 0 {
+		b.WriteString("[::d]No station started yet.[::-]\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "[%s]All:[-]        p50 %v  p90 %v  p99 %v\n", keyColor,
+		roundMs(player.StartupPercentile(timings, 0.5)),
+		roundMs(player.StartupPercentile(timings, 0.9)),
+		roundMs(player.StartupPercentile(timings, 0.99)))
+	if current != nil {
+		var own []player.StartupTiming
+		for _, t := range timings {
+			if t.Station == current.ID {
+				own = append(own, t)
+			}
+		}
+		if len(own) > 0 {
+			fmt.Fprintf(&b, "[%s]Station:[-]    p50 %v  p90 %v  [::d](%d starts)[::-]\n", keyColor,
+				roundMs(player.StartupPercentile(own, 0.5)),
+				roundMs(player.StartupPercentile(own, 0.9)), len(own))
+		}
+	}
+	fmt.Fprintf(&b, "[%s]Last:[-]       %s\n", keyColor, tview.Escape(timings[len(timings)-1].String()))
+	return b.String()
+}
+
+func roundMs(d time.Duration) time.Duration {
+	return d.Round(time.Millisecond)
+}
@@ -0,0 +1,147 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/termbg"
+	"github.com/rivo/tview"
+	"github.com/rs/zerolog/log"
+)
+
+// detectBackground asks the terminal for its background color. Tests
+// replace it, as they have no terminal to ask.
+var detectBackground = termbg.Detect
+
+// resolveTheme returns the colors to draw theme in: the auto preset turns
+// light on a light terminal.
+func (ui *UI) resolveTheme(theme config.Theme) config.Theme {
+	if !theme.IsAuto() {
+		return theme
+	}
+	if ui.termBackground == termbg.Unknown {
+		// Once the UI owns the terminal it can't be queried anymore
+		ui.termBackground = termbg.FromColorFGBG(os.Getenv("COLORFGBG"))
+	}
+	if ui.termBackground == termbg.Light {
+		theme, _ = config.ThemePreset(config.ThemeLight)
+	}
+	return theme
+}
+
+// applyTheme switches to a preset and rebuilds the screen in its colors,
+// keeping the shown and playing stations, the selection, and the filter.
+func (ui *UI) applyTheme(name string) {
+	theme, ok := config.ThemePreset(name)
+	if !ok {
+		return
+	}
+	ui.config.Theme = theme
+	ui.requestConfigSave()
+
+	ui.setPalette(ui.resolveTheme(theme))
+	ui.statusRenderer.SetPrimaryColor(ui.colors.highlight.String())
+	ui.rebuildScreen()
+
+	message := fmt.Sprintf("Theme: %s", name)
+	if ui.config.InSafeMode() {
+		message += " (not saved in safe mode)"
+	}
+	ui.showNotice(message)
+	log.Debug().Str("theme", name).Msg("Changed theme")
+}
+
+// themeSwatch draws a preset's main colors as a row of blocks.
+func themeSwatch(theme config.Theme) string {
+	var b strings.Builder
+	for _, c := range []string{theme.Background, theme.Foreground, theme.Highlight, theme.Borders, theme.StationListHeaderBackground, theme.HelpHotkey} {
+		fmt.Fprintf(&b, "[%s]██", c)
+	}
+	b.WriteString("[-]")
+	return b.String()
+}
+
+// showThemeModal lists the built-in presets; Enter switches to the
+// selected one.
+func (ui *UI) showThemeModal() {
+	keyColor := ui.colors.helpHotkey.String()
+	current := ui.config.Theme.Name
+	if current == "" {
+		current = ui.config.Theme.Preset
+	}
+
+	table := tview.NewTable().
+		SetSelectable(true, false)
+	table.SetBackgroundColor(ui.colors.modalBackground)
+	table.SetSelectedStyle(tcell.StyleDefault.
+		Background(ui.colors.highlight).
+		Foreground(ui.colors.modalBackground))
+	for row, name := range config.ThemePresets {
+		theme, _ := config.ThemePreset(name)
+		label := name
+		if name == current {
+			label += " ✓"
+		}
+		table.SetCell(row, 0, tview.NewTableCell(label).
+			SetTextColor(ui.colors.foreground).
+			SetExpansion(1))
+		table.SetCell(row, 1, tview.NewTableCell(themeSwatch(theme)).
+			SetSelectable(false))
+	}
+	if row := slices.Index(config.ThemePresets, current); row >= 0 {
+		table.Select(row, 0)
+	}
+
+	hintView := tview.NewTextView().
+		SetTextAlign(tview.AlignCenter).
+		SetDynamicColors(true).
+		SetText(fmt.Sprintf("[::d][%s]Enter[-] apply • Esc close[::-]", keyColor))
+	hintView.SetTextColor(tcell.ColorDarkGray)
+	hintView.SetBackgroundColor(ui.colors.modalBackground)
+
+	content := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(hintView, 1, 0, false)
+	content.SetBackgroundColor(ui.colors.modalBackground)
+
+	frame := tview.NewFrame(content).
+		SetBorders(1, 0, 0, 0, 2, 2)
+	frame.SetBorder(true).
+		SetBorderColor(ui.colors.borders).
+		SetBackgroundColor(ui.colors.modalBackground).
+		SetTitle(" Theme ").
+		SetTitleColor(ui.colors.highlight).
+		SetTitleAlign(tview.AlignCenter)
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(frame, len(config.ThemePresets)+5, 0, true).
+			AddItem(nil, 0, 1, false),
+			40, 0, true).
+		AddItem(nil, 0, 1, false)
+	modal.SetBackgroundColor(ui.colors.background)
+
+	modal.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEscape:
+			ui.modals.close(modalPage)
+			return nil
+		case tcell.KeyEnter:
+			row, _ := table.GetSelection()
+			ui.modals.close(modalPage)
+			if name := config.ThemePresets[row]; name != current {
+				ui.applyTheme(name)
+			}
+			return nil
+		}
+		return event
+	})
+
+	ui.modals.open(modalPage, modal, table)
+}
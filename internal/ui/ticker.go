@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/rivo/tview"
+)
+
+// TickerTracks is how many previous tracks are shown under the Playing line.
+const TickerTracks = 2
+
+// trackTicker remembers the tracks that played before the current one,
+// newest first, from song history and observed stream title changes.
+type trackTicker struct {
+	current  string
+	previous []string
+}
+
+// reset forgets the tracks of the previous station.
+func (t *trackTicker) reset() {
+	t.current = ""
+	t.previous = nil
+}
+
+// observe records track as playing. When it differs from the current one,
+// the current track moves into the previous list.
+func (t *trackTicker) observe(track string) {
+	if track == "" || track == player.NoTrackInfo || track == t.current {
+		return
+	}
+	if t.current != "" {
+		t.push(t.current)
+	}
+	t.current = track
+}
+
+// seed fills the previous list from song history, keeping tracks already
+// observed ahead of older ones.
+func (t *trackTicker) seed(current string, previous []string) {
+	t.observe(current)
+	for _, track := range previous {
+		if len(t.previous) >= TickerTracks {
+			break
+		}
+		if track != "" && track != t.current && !containsTrack(t.previous, track) {
+			t.previous = append(t.previous, track)
+		}
+	}
+}
+
+func (t *trackTicker) push(track string) {
+	previous := []string{track}
+	for _, p := range t.previous {
+		if p != track && len(previous) < TickerTracks {
+			previous = append(previous, p)
+		}
+	}
+	t.previous = previous
+}
+
+func containsTrack(tracks []string, track string) bool {
+	for _, t := range tracks {
+		if t == track {
+			return true
+		}
+	}
+	return false
+}
+
+// render returns the dim ticker line for the player panel, newest first.
+// It takes the place of the blank line under the track, so it is empty
+// until a previous track is known.
+func (t *trackTicker) render() string {
+	if len(t.previous) == 0 {
+		return ""
+	}
+	tracks := make([]string, len(t.previous))
+	for i, track := range t.previous {
+		tracks[i] = tview.Escape(track)
+	}
+	return fmt.Sprintf(" [::d]↳ %s[::-]", strings.Join(tracks, "  ·  "))
+}
+
+// updateTicker redraws the ticker view.
+func (v *PlayerPanelView) updateTicker() {
+	if v.tickerView != nil {
+		v.tickerView.SetText(v.ticker.render())
+	}
+}
+
+// seedTicker fills the ticker from the station's recent tracks, unless
+// playback moved on to another station while they loaded.
+func (v *PlayerPanelView) seedTicker(stationID station.StationID, current string, previous []string) {
+	if v.playingID != stationID {
+		return
+	}
+	v.ticker.seed(current, previous)
+	v.updateTicker()
+}
+
+// loadTrackTicker seeds the ticker from the station's song history. It
+// runs off the UI thread and returns the current track, or "".
+func (ui *UI) loadTrackTicker(stationID station.StationID) (string, error) {
+	tracks, err := ui.stationService.GetRecentTracksForStation(ui.requestContext(), stationID, TickerTracks+1)
+	if err != nil || len(tracks) == 0 {
+		return "", err
+	}
+
+	ui.app.QueueUpdateDraw(func() {
+		ui.panel.seedTicker(stationID, tracks[0], tracks[1:])
+	})
+	return tracks[0], nil
+}
@@ -0,0 +1,153 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/format"
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/rivo/tview"
+)
+
+// shiftPlayback rewinds (negative d) or fast-forwards within the audio the
+// player keeps buffered, up to player.TimeShiftWindow behind live.
+func (ui *UI) shiftPlayback(d time.Duration) {
+	if !ui.player.IsPlaying() {
+		ui.showNotice("Start a station to rewind it")
+		return
+	}
+
+	var behind time.Duration
+	if d < 0 {
+		behind = ui.player.Rewind(-d)
+	} else {
+		behind = ui.player.FastForward(d)
+	}
+	ui.showTimeShiftNotice(behind)
+}
+
+// goLive jumps back to the live edge of the stream.
+func (ui *UI) goLive() {
+	if !ui.player.IsPlaying() || ui.player.BehindLive() == 0 {
+		return
+	}
+	ui.player.GoLive()
+	ui.showTimeShiftNotice(0)
+}
+
+func (ui *UI) showTimeShiftNotice(behind time.Duration) {
+	if behind == 0 {
+		ui.showNotice("Back to live")
+		return
+	}
+	ui.showNotice(fmt.Sprintf("⏪ %s behind live (max %s) — press \\ for live",
+		format.Duration(behind), format.Duration(player.TimeShiftWindow)))
+}
+
+// scrubBarWidth is the number of cells the time-shift window is drawn in.
+const scrubBarWidth = 40
+
+// showScrubModal skims through the time-shift buffer: ← and → step by
+// player.ScrubStep and play a short preview at each stop, Enter settles
+// there, and Esc goes back to where scrubbing started.
+func (ui *UI) showScrubModal() {
+	if !ui.player.IsPlaying() {
+		ui.showNotice("Start a station to scrub through it")
+		return
+	}
+	keyColor := ui.colors.helpHotkey.String()
+
+	barView := tview.NewTextView().
+		SetTextAlign(tview.AlignCenter).
+		SetDynamicColors(true)
+	barView.SetBackgroundColor(ui.colors.modalBackground)
+
+	hintView := tview.NewTextView().
+		SetTextAlign(tview.AlignCenter).
+		SetDynamicColors(true).
+		SetText(fmt.Sprintf("[::d][%s]←[-] [%s]→[-] skim • [%s]Enter[-] settle • Esc cancel[::-]", keyColor, keyColor, keyColor))
+	hintView.SetTextColor(tcell.ColorDarkGray)
+	hintView.SetBackgroundColor(ui.colors.modalBackground)
+
+	render := func(behind time.Duration) {
+		barView.SetText(ui.scrubBar(behind, ui.player.Buffered()))
+	}
+
+	content := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(barView, 0, 1, false).
+		AddItem(hintView, 1, 0, false)
+	content.SetBackgroundColor(ui.colors.modalBackground)
+
+	frame := tview.NewFrame(content).
+		SetBorders(1, 0, 0, 0, 2, 2)
+	frame.SetBorder(true).
+		SetBorderColor(ui.colors.borders).
+		SetBackgroundColor(ui.colors.modalBackground).
+		SetTitle(" Scrub ").
+		SetTitleColor(ui.colors.highlight).
+		SetTitleAlign(tview.AlignCenter)
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(frame, 7, 0, true).
+			AddItem(nil, 0, 1, false),
+			scrubBarWidth+10, 0, true).
+		AddItem(nil, 0, 1, false)
+	modal.SetBackgroundColor(ui.colors.background)
+
+	modal.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyLeft:
+			render(ui.player.Scrub(-player.ScrubStep))
+		case tcell.KeyRight:
+			render(ui.player.Scrub(player.ScrubStep))
+		case tcell.KeyEnter:
+			ui.modals.close(modalPage)
+			ui.showTimeShiftNotice(ui.player.EndScrub(true))
+		case tcell.KeyEscape:
+			ui.modals.close(modalPage)
+			ui.player.EndScrub(false)
+		}
+		return nil
+	})
+
+	ui.player.StartScrub()
+	render(ui.player.BehindLive())
+	ui.modals.open(modalPage, modal, modal)
+}
+
+// scrubBar draws the time-shift window, oldest audio on the left and live
+// on the right, with the buffered part highlighted and ● at behind.
+func (ui *UI) scrubBar(behind, buffered time.Duration) string {
+	cell := func(d time.Duration) int {
+		return scrubBarWidth - 1 - int(int64(scrubBarWidth-1)*int64(d)/int64(player.TimeShiftWindow))
+	}
+	pos, start := cell(behind), cell(buffered)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s]", ui.colors.borders)
+	for i := range scrubBarWidth {
+		if i == start {
+			fmt.Fprintf(&b, "[%s]", ui.colors.highlight)
+		}
+		switch {
+		case i == pos:
+			b.WriteString("●")
+		case i >= start:
+			b.WriteString("━")
+		default:
+			b.WriteString("─")
+		}
+	}
+	b.WriteString("[-]\n\n")
+	if behind == 0 {
+		b.WriteString("live")
+	} else {
+		fmt.Fprintf(&b, "%s behind live", format.Duration(behind))
+	}
+	return b.String()
+}
@@ -0,0 +1,172 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/rs/zerolog/log"
+)
+
+// Where a tour callout goes relative to the part of the screen it explains.
+const (
+	tourInside = iota
+	tourBelow
+	tourAbove
+)
+
+const tourCalloutWidth = 52
+
+// tourStep explains one part of the main screen.
+type tourStep struct {
+	title  string
+	lines  []string // Color tags allowed; {key} is the hotkey color
+	target func(ui *UI) tview.Primitive
+	side   int
+}
+
+var tourSteps = []tourStep{
+	{
+		title: "Stations",
+		lines: []string{
+			"Every SomaFM station, with your favorites starred.",
+			"[{key}]↑[-] [{key}]↓[-] move, [{key}]Enter[-] plays, [{key}]f[-] favorites,",
+			"[{key}]/[-] filters and [{key}]S[-] changes the order.",
+		},
+		target: func(ui *UI) tview.Primitive { return ui.stations.root },
+		side:   tourInside,
+	},
+	{
+		title: "Player",
+		lines: []string{
+			"The selected station and what it plays now.",
+			"[{key}]Space[-] pauses, [{key}]+[-] [{key}]-[-] change the volume,",
+			"[{key}]m[-] mutes and [{key}]l[-] likes the current track.",
+		},
+		target: func(ui *UI) tview.Primitive { return ui.panel.root },
+		side:   tourBelow,
+	},
+	{
+		title: "Footer",
+		lines: []string{
+			"Key help and notices on the left, stream status",
+			"on the right. [{key}]?[-] lists every key, [{key}]q[-] quits.",
+		},
+		target: func(ui *UI) tview.Primitive { return ui.footer.box },
+		side:   tourAbove,
+	},
+}
+
+// tourOverlay draws the current step's callout next to its target and
+// leaves the rest of the screen visible underneath.
+type tourOverlay struct {
+	*tview.Box
+	ui      *UI
+	step    int
+	callout *tview.TextView
+}
+
+func (o *tourOverlay) Draw(screen tcell.Screen) {
+	step := tourSteps[o.step]
+	keyColor := o.ui.colors.helpHotkey.String()
+
+	var b strings.Builder
+	for _, line := range step.lines {
+		b.WriteString(strings.ReplaceAll(line, "{key}", keyColor) + "\n")
+	}
+	back := ""
+	if o.step > 0 {
+		back = fmt.Sprintf("[%s]←[-] back • ", keyColor)
+	}
+	next := "next"
+	if o.step == len(tourSteps)-1 {
+		next = "done"
+	}
+	fmt.Fprintf(&b, "\n[::d]%s[%s]Enter[-] %s • Esc skip[::-]", back, keyColor, next)
+	o.callout.SetText(b.String())
+	o.callout.SetTitle(fmt.Sprintf(" %s (%d/%d) ", step.title, o.step+1, len(tourSteps)))
+
+	screenWidth, screenHeight := screen.Size()
+	tx, ty, tw, th := step.target(o.ui).GetRect()
+	width := min(tourCalloutWidth, screenWidth)
+	height := len(step.lines) + 4
+	x := tx + (tw-width)/2
+	var y int
+	switch step.side {
+	case tourBelow:
+		y = ty + th
+	case tourAbove:
+		y = ty - height
+	default:
+		y = ty + (th-height)/2
+	}
+	x = max(0, min(x, screenWidth-width))
+	y = max(0, min(y, screenHeight-height))
+	o.callout.SetRect(x, y, width, height)
+	o.callout.Draw(screen)
+
+	// Point at the target from the edge that faces it, clear of the title
+	arrowX := x + 3
+	switch step.side {
+	case tourBelow:
+		screen.SetContent(arrowX, y, '▲', nil, tcell.StyleDefault.
+			Foreground(o.ui.colors.highlight).Background(o.ui.colors.modalBackground))
+	case tourAbove:
+		screen.SetContent(arrowX, y+height-1, '▼', nil, tcell.StyleDefault.
+			Foreground(o.ui.colors.highlight).Background(o.ui.colors.modalBackground))
+	}
+}
+
+// showTour walks a first-time user through the main screen. Finishing or
+// skipping it sets tour_shown, so it is not shown again.
+func (ui *UI) showTour() {
+	callout := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(false)
+	callout.SetTextColor(ui.colors.foreground)
+	callout.SetBackgroundColor(ui.colors.modalBackground)
+	callout.SetBorder(true).
+		SetBorderColor(ui.colors.highlight).
+		SetBorderPadding(0, 0, 1, 1).
+		SetTitleColor(ui.colors.highlight)
+
+	overlay := &tourOverlay{Box: tview.NewBox(), ui: ui, callout: callout}
+
+	advance := func() {
+		if overlay.step == len(tourSteps)-1 {
+			ui.endTour()
+			return
+		}
+		overlay.step++
+	}
+	overlay.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEnter, tcell.KeyRight, tcell.KeyTab:
+			advance()
+		case tcell.KeyLeft, tcell.KeyBacktab:
+			overlay.step = max(0, overlay.step-1)
+		case tcell.KeyEscape:
+			ui.endTour()
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case ' ':
+				advance()
+			case 'q', 'Q':
+				ui.endTour()
+			}
+		}
+		return nil
+	})
+
+	ui.modals.open(modalPage, overlay, overlay)
+}
+
+// endTour closes the tour and remembers that it was shown.
+func (ui *UI) endTour() {
+	ui.modals.close(modalPage)
+	ui.config.TourShown = true
+	ui.requestConfigSave()
+	ui.showNotice("Press ? any time to see every key")
+	log.Debug().Msg("Onboarding tour finished")
+}
@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/glebovdev/somafm-cli/internal/service"
+	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/glebovdev/somafm-cli/internal/translate"
+	"github.com/rivo/tview"
+	"github.com/rs/zerolog/log"
+)
+
+// stationTranslation is a station's description and genre names in the
+// user's language.
+type stationTranslation struct {
+	description string
+	genres      []string
+}
+
+// translation returns the translation of the shown station, if the panel
+// shows translations and there is one.
+func (v *PlayerPanelView) translation() (stationTranslation, bool) {
+	if !v.translated || v.station == nil {
+		return stationTranslation{}, false
+	}
+	t, ok := v.translations[v.station.ID]
+	return t, ok
+}
+
+func (v *PlayerPanelView) description() string {
+	if t, ok := v.translation(); ok {
+		return t.description
+	}
+	return v.station.Description
+}
+
+func (v *PlayerPanelView) genreNames() []string {
+	if t, ok := v.translation(); ok {
+		return t.genres
+	}
+	return v.genres.Names(v.station.Genre)
+}
+
+// setTranslated switches between the original and the translated texts.
+func (v *PlayerPanelView) setTranslated(on bool) {
+	v.translated = on
+	v.updateTexts()
+}
+
+func (v *PlayerPanelView) hasTranslation(id station.StationID) bool {
+	_, ok := v.translations[id]
+	return ok
+}
+
+// addTranslation stores the translation of a station, showing it if the
+// station is on screen.
+func (v *PlayerPanelView) addTranslation(id station.StationID, t stationTranslation) {
+	if v.translations == nil {
+		v.translations = make(map[station.StationID]stationTranslation)
+	}
+	v.translations[id] = t
+	v.updateTexts()
+}
+
+// updateTexts redraws the description and genre tags in place.
+func (v *PlayerPanelView) updateTexts() {
+	if v.descView == nil || v.station == nil {
+		return
+	}
+	v.descView.SetText(fmt.Sprintf(" [%s]%s[-]", v.colors.foreground.String(), v.description()))
+	v.genreView.Clear()
+	v.fillGenreTags(v.genreView, v.genreNames())
+}
+
+// toggleTranslation switches the player panel between the original and
+// the translated station description and genre names.
+func (ui *UI) toggleTranslation() {
+	if ui.translator == nil {
+		ui.showNotice("Translation is off — set translate.backend in the config")
+		return
+	}
+	ui.panel.setTranslated(!ui.panel.translated)
+	ui.translateShownStation()
+}
+
+// translateShownStation translates the station shown in the panel while
+// translations are on. Cached translations show at once; others are
+// fetched in the background.
+func (ui *UI) translateShownStation() {
+	s := ui.currentStation
+	if ui.translator == nil || s == nil || !ui.panel.translated || ui.panel.hasTranslation(s.ID) {
+		return
+	}
+
+	texts := append([]string{s.Description}, ui.genres.Names(s.Genre)...)
+	if translated, ok := ui.translator.Cached(string(s.ID), texts); ok {
+		ui.panel.addTranslation(s.ID, newStationTranslation(translated))
+		return
+	}
+
+	ui.showNotice(fmt.Sprintf("Translating %s to %s…", s.Title, ui.translator.Language()))
+	go func() {
+		translated, err := ui.translator.Station(context.Background(), string(s.ID), texts)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Failed to translate %s", s.ID)
+		}
+		ui.app.QueueUpdateDraw(func() {
+			if translated == nil {
+				ui.showNotice(fmt.Sprintf("Translation failed: %v", err))
+				return
+			}
+			ui.panel.addTranslation(s.ID, newStationTranslation(translated))
+		})
+	}()
+}
+
+// newStationTranslation splits translated texts, the description followed
+// by the genre names, escaping them for display.
+func newStationTranslation(texts []string) stationTranslation {
+	t := stationTranslation{description: tview.Escape(texts[0])}
+	t.genres = append(t.genres, texts[1:]...)
+	return t
+}
+
+// translationCache opens the translation cache under the cache mode of the
+// logos: none with --no-cache, emptied first with --refresh. It is nil
+// while translation is off.
+func (ui *UI) translationCache() *translate.Cache {
+	if ui.config.Translate.Backend == "" {
+		return nil
+	}
+	mode := ui.stationService.CacheMode()
+	if mode == service.CacheDisabled {
+		return nil
+	}
+	c, err := translate.OpenDefaultCache()
+	if err != nil {
+		log.Warn().Err(err).Msg("Translation cache unavailable")
+		return nil
+	}
+	if mode == service.CacheRefresh {
+		if err := c.Clear(); err != nil {
+			log.Warn().Err(err).Msg("Failed to clear translation cache")
+		}
+	}
+	return c
+}
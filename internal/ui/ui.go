@@ -4,31 +4,45 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/clock"
 	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/format"
+	"github.com/glebovdev/somafm-cli/internal/genre"
+	"github.com/glebovdev/somafm-cli/internal/history"
+	"github.com/glebovdev/somafm-cli/internal/likes"
 	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/glebovdev/somafm-cli/internal/playlog"
 	"github.com/glebovdev/somafm-cli/internal/service"
 	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/glebovdev/somafm-cli/internal/storage"
+	"github.com/glebovdev/somafm-cli/internal/termbg"
+	"github.com/glebovdev/somafm-cli/internal/translate"
 	"github.com/rivo/tview"
 	"github.com/rs/zerolog/log"
 )
 
 const (
-	VolumeStep            = 5
-	HeaderHeight          = 3
-	FooterHeightWide      = 3 // Wide: 1 row with padding (top + text + bottom)
-	FooterHeightNarrow    = 6 // Narrow: 2 rows × 3 lines each
-	CoverWidth            = 26
-	CoverHeight           = 12
-	PlayerPanelHeight     = 12
-	FooterBreakpoint      = 130 // Width threshold for responsive footer
-	MinLoadingDisplayTime = 1200 * time.Millisecond
-	MinStatusDisplayTime  = 300 * time.Millisecond
+	VolumeStep              = 5
+	HeaderHeight            = 3
+	FooterHeightWide        = 3 // Wide: 1 row with padding (top + text + bottom)
+	FooterHeightNarrow      = 6 // Narrow: 2 rows × 3 lines each
+	CoverWidth              = 26
+	CoverHeight             = 12
+	PlayerPanelHeight       = 12
+	FooterBreakpoint        = 130 // Width threshold for responsive footer
+	MinLoadingDisplayTime   = 1200 * time.Millisecond
+	MinStatusDisplayTime    = 300 * time.Millisecond
+	NoticeDisplayTime       = 4 * time.Second
+	PrefetchIdleDelay       = 5 * time.Second // Keyboard idle time before neighbors are prefetched
+	FavoritesReloadInterval = 5 * time.Second // How often the config file is checked for favorites edited elsewhere
 )
 
 // PauseIcon uses platform-specific character (Windows renders ⏸ as emoji)
@@ -39,59 +53,91 @@ var PauseIcon = func() string {
 	return "⏸"
 }()
 
+// primitiveBackground is tview's own default, restored for themes that are
+// not transparent.
+var primitiveBackground = tview.Styles.PrimitiveBackgroundColor
+
 type UI struct {
-	app               *tview.Application
-	stationService    *service.StationService
-	player            *player.Player
-	currentStation    *station.Station
-	stationList       *tview.Table
-	helpPanel         *tview.Box
-	contentLayout     *tview.Flex
-	playerPanel       *tview.Flex
-	currentTrackView  *tview.TextView
-	logoPanel         *tview.Image
-	volumeView        *tview.Flex
-	mainLayout        *tview.Flex
-	loadingScreen     *tview.Flex
-	loadingText       *tview.TextView
-	progressBar       *tview.TextView
-	pages             *tview.Pages
-	stopUpdates       chan struct{}
-	playingIndex      int
-	playingStationID  string
-	selectedStationID string
-	currentVolume     int
-	isMuted           bool
-	config            *config.Config
-	startRandom       bool
-	lastFooterWidth   int // Track width to detect layout changes
-	mu                sync.Mutex
-	animationFrame    int
-	playingSpinner    *PlayingSpinner
-	statusRenderer    *StatusRenderer
-	colors            struct {
-		background                  tcell.Color
-		foreground                  tcell.Color
-		borders                     tcell.Color
-		highlight                   tcell.Color
-		headerBackground            tcell.Color
-		stationListHeaderBackground tcell.Color
-		stationListHeaderForeground tcell.Color
-		helpBackground              tcell.Color
-		helpForeground              tcell.Color
-		helpHotkey                  tcell.Color
-		genreTagBackground          tcell.Color
-		modalBackground             tcell.Color
-	}
-}
-
-func NewUI(player *player.Player, stationService *service.StationService, startRandom bool) *UI {
-	cfg, err := config.Load()
-	if err != nil {
-		log.Warn().Err(err).Msg("Failed to load config, using defaults")
-		cfg = config.DefaultConfig()
-	}
+	app              *tview.Application
+	bus              *EventBus
+	stations         *StationListView
+	panel            *PlayerPanelView
+	footer           *FooterView
+	modals           *ModalManager
+	stationService   *service.StationService
+	player           *player.Player
+	currentStation   *station.Station
+	contentLayout    *tview.Flex
+	mainLayout       *tview.Flex
+	loadingScreen    *tview.Flex
+	loadingText      *tview.TextView
+	progressBar      *tview.TextView
+	stopUpdates      chan struct{}
+	playingIndex     int
+	playingStationID station.StationID
+	retiredNoticed   station.StationID // Playing station whose retirement was announced
+	currentVolume    int
+	isMuted          bool
+	config           *config.Config
+	configWriter     *config.Writer // Nil in tests that build a UI directly
+	startRandom      bool
+	dirProblems      []error     // Unwritable directories found at startup
+	saveErrorShown   atomic.Bool // A config save failure was shown in the footer
+	hints            hintState
+	volumeFlash      volumeFlashState
+	volumeRamp       volumeRampState
+	likes            *likes.Store
+	history          *history.Store
+	plays            *playlog.Log        // Nil when the play log is off
+	clipboard        []byte              // Sent to the terminal on the next draw
+	recentStations   []station.StationID // Station IDs, most recently played first
+	genres           *genre.Translator
+	numbers          format.Numbers    // Groups listener counts; zero in tests that build a UI directly
+	translator       *translate.Client // Nil when translation is off
+	lastInput        time.Time
+	idleWarning      bool // The idle stop warning is showing
+	autoStop         autoStopState
+	fallback         fallbackState
+	alarm            alarmState
+	similar          similarState
+	recordingQuota   recordingQuotaState
+	stopOutputWatch  context.CancelFunc // Nil unless pause_on_disconnect is on
+	ctx              context.Context    // Canceled by stop; nil in tests that build a UI directly
+	cancel           context.CancelFunc
+	clock            clock.Clock    // Nil means the wall clock
+	location         *time.Location // Times are shown in; nil means time.Local
+	prefetching      atomic.Bool
+	mu               sync.Mutex
+	statusRenderer   *StatusRenderer
+	colors           palette
+	termBackground   termbg.Background // Picks the auto theme's colors
+	images           imageRenderer     // Nil in tests that build a UI directly
+	crash            any               // Panic that stopped the app, until Run handles it
+	appBroken        bool              // The panic got past tview, which can't run again
+	console          *console          // Where Run talks after a crash
+	shutdown         chan struct{}     // Closed by Shutdown
+	shutdownOnce     sync.Once
+}
+
+// palette holds the theme colors, resolved when the UI is built and again
+// when the theme is switched.
+type palette struct {
+	background                  tcell.Color
+	foreground                  tcell.Color
+	borders                     tcell.Color
+	highlight                   tcell.Color
+	headerBackground            tcell.Color
+	stationListHeaderBackground tcell.Color
+	stationListHeaderForeground tcell.Color
+	helpBackground              tcell.Color
+	helpForeground              tcell.Color
+	helpHotkey                  tcell.Color
+	genreTagBackground          tcell.Color
+	modalBackground             tcell.Color
+	mutedVolume                 tcell.Color
+}
 
+func NewUI(player *player.Player, stationService *service.StationService, cfg *config.Config, startRandom bool) *UI {
 	ui := &UI{
 		app:            tview.NewApplication(),
 		player:         player,
@@ -102,26 +148,56 @@ func NewUI(player *player.Player, stationService *service.StationService, startR
 		isMuted:        false,
 		config:         cfg,
 		startRandom:    startRandom,
+		console:        newConsole(os.Stdin, os.Stderr),
+		shutdown:       make(chan struct{}),
 	}
+	ui.ctx, ui.cancel = context.WithCancel(context.Background())
+	ui.configWriter = config.NewWriter(cfg, config.DefaultSaveDelay, ui.onConfigSaveError)
+	applyAmbiguousWidth(cfg.AmbiguousWidth)
 
-	ui.colors.background = config.GetColor(cfg.Theme.Background)
-	ui.colors.foreground = config.GetColor(cfg.Theme.Foreground)
-	ui.colors.borders = config.GetColor(cfg.Theme.Borders)
-	ui.colors.highlight = config.GetColor(cfg.Theme.Highlight)
-	ui.colors.headerBackground = config.GetColor(cfg.Theme.HeaderBackground)
-	ui.colors.stationListHeaderBackground = config.GetColor(cfg.Theme.StationListHeaderBackground)
-	ui.colors.stationListHeaderForeground = config.GetColor(cfg.Theme.StationListHeaderForeground)
-	ui.colors.helpBackground = config.GetColor(cfg.Theme.HelpBackground)
-	ui.colors.helpForeground = config.GetColor(cfg.Theme.HelpForeground)
-	ui.colors.helpHotkey = config.GetColor(cfg.Theme.HelpHotkey)
-	ui.colors.genreTagBackground = config.GetColor(cfg.Theme.GenreTagBackground)
-	ui.colors.modalBackground = config.GetColor(cfg.Theme.ModalBackground)
+	if cfg.Theme.IsAuto() {
+		ui.termBackground = detectBackground(termbg.DefaultTimeout)
+		log.Debug().Stringer("background", ui.termBackground).Msg("Detected terminal background")
+	}
+	ui.setPalette(ui.resolveTheme(cfg.Theme))
+	ui.images = newImageRenderer(cfg.Logos, cfg.CoverArt)
+	ui.numbers = format.LocaleNumbers()
+	player.SetMetering(cfg.Visualizer)
+
+	if stationService != nil {
+		stationService.SetOrder(ui.stationOrder(cfg.SortBy))
+	}
 
 	player.SetVolume(cfg.Volume)
+	player.SetPauseKeywords(cfg.PauseKeywords)
+	player.SetListenerID(cfg.ActiveListenerID())
 	log.Debug().Msgf("Loaded volume from config: %d%%", cfg.Volume)
 
+	genres, err := genre.NewTranslator(cfg.Genres)
+	if err != nil {
+		log.Warn().Err(err).Msg("Genre names unavailable, showing raw tags")
+	}
+	ui.genres = genres
+
+	db, err := storage.Open(cfg.Storage.Backend)
+	if err != nil {
+		log.Warn().Err(err).Msg("SQLite storage unavailable, using files")
+	}
+	if ui.likes, err = likes.OpenDefault(db); err != nil {
+		log.Warn().Err(err).Msg("Failed to load liked tracks")
+	}
+	if ui.history, err = history.OpenDefault(db); err != nil {
+		log.Warn().Err(err).Msg("Failed to load listening history")
+	}
+	if ui.translator, err = translate.FromConfig(cfg.Translate, ui.translationCache()); err != nil {
+		log.Warn().Err(err).Msg("Translation unavailable")
+	}
+	ui.seedRecentStations()
+	player.SetListenHandler(ui.recordListen)
+
 	ui.statusRenderer = NewStatusRenderer(player)
 	ui.statusRenderer.SetPrimaryColor(ui.colors.highlight.String())
+	ui.statusRenderer.SetBufferGraph(cfg.BufferGraph)
 
 	return ui
 }
@@ -136,7 +212,22 @@ func (ui *UI) SaveConfig() {
 	}
 	ui.mu.Unlock()
 
-	if err := ui.config.Save(); err != nil {
+	ui.requestConfigSave()
+}
+
+// requestConfigSave saves the config shortly, batching it with other
+// changes made around the same time. stop flushes it on exit.
+func (ui *UI) requestConfigSave() {
+	if ui.configWriter != nil {
+		ui.configWriter.Request()
+	}
+}
+
+func (ui *UI) flushConfig() {
+	if ui.configWriter == nil {
+		return
+	}
+	if err := ui.configWriter.Flush(); err != nil {
 		log.Error().Err(err).Msg("Failed to save config")
 	}
 }
@@ -162,15 +253,37 @@ func (ui *UI) recreateStopChannel() {
 	ui.stopUpdates = make(chan struct{})
 }
 
+// requestContext returns the context for API requests, which is canceled
+// when the UI stops so they don't hold up the exit.
+func (ui *UI) requestContext() context.Context {
+	if ui.ctx == nil {
+		return context.Background()
+	}
+	return ui.ctx
+}
+
 func (ui *UI) stop() {
+	if ui.cancel != nil {
+		ui.cancel()
+	}
 	ui.stationService.StopPeriodicRefresh()
+	ui.stationService.StopWatching()
+	if ui.stopOutputWatch != nil {
+		ui.stopOutputWatch()
+	}
+	if _, err := ui.player.StopRecording(); err != nil {
+		log.Warn().Err(err).Msg("Failed to finish recording")
+	}
 	ui.player.Stop()
+	ui.player.ClearPrefetch()
 	ui.safeCloseChannel()
+	ui.flushConfig()
 	ui.app.Stop()
 }
 
 // Shutdown stops the UI gracefully from external callers (e.g., signal handlers).
 func (ui *UI) Shutdown() {
+	ui.shutdownOnce.Do(func() { close(ui.shutdown) })
 	ui.app.QueueUpdateDraw(func() {
 		ui.stop()
 	})
@@ -178,12 +291,43 @@ func (ui *UI) Shutdown() {
 
 func (ui *UI) Run() error {
 	ui.setupLoadingScreen()
-	ui.app.SetRoot(ui.loadingScreen, true)
+	ui.setRoot(ui.loadingScreen)
 	ui.configureScreen()
 
 	go ui.initAsync()
 
-	return ui.app.Run()
+	for {
+		err := ui.runApp()
+		if ui.crash == nil {
+			return err
+		}
+		if !ui.recoverFromCrash() {
+			ui.stop()
+			return nil
+		}
+	}
+}
+
+// SetClock replaces the wall clock that drives animations, tickers, and
+// notice timeouts. A fake clock freezes them for tests and screenshots.
+func (ui *UI) SetClock(c clock.Clock) {
+	ui.clock = c
+	ui.statusRenderer.SetClock(c)
+	if ui.configWriter != nil {
+		ui.configWriter.SetClock(c)
+	}
+}
+
+func (ui *UI) timeSource() clock.Clock {
+	return clock.Or(ui.clock)
+}
+
+// timeZone returns the location times are shown in.
+func (ui *UI) timeZone() *time.Location {
+	if ui.location == nil {
+		return time.Local
+	}
+	return ui.location
 }
 
 func (ui *UI) configureScreen() {
@@ -195,8 +339,15 @@ func (ui *UI) configureScreen() {
 	})
 
 	var titleSet sync.Once
+	title := ui.config.Branding.DisplayTitle()
+	images := ui.imageRenderer()
 	ui.app.SetAfterDrawFunc(func(screen tcell.Screen) {
-		titleSet.Do(func() { screen.SetTitle(config.AppName) })
+		titleSet.Do(func() { screen.SetTitle(title) })
+		if ui.clipboard != nil {
+			screen.SetClipboard(ui.clipboard)
+			ui.clipboard = nil
+		}
+		ui.guard(func() { images.flush(screen) })
 	})
 }
 
@@ -253,7 +404,7 @@ func (ui *UI) animateProgress(fromPercent, toPercent int, duration time.Duration
 	lastBar := ui.renderProgressBar(fromPercent)
 
 	for p := fromPercent + 1; p <= toPercent; p++ {
-		time.Sleep(stepDuration)
+		ui.timeSource().Sleep(stepDuration)
 		if bar := ui.renderProgressBar(p); bar != lastBar {
 			ui.app.QueueUpdateDraw(func() {
 				ui.progressBar.SetText(bar)
@@ -267,7 +418,7 @@ func (ui *UI) fetchStationsAndInitUI() error {
 	const totalStages = 3
 	stagePercent := func(stage int) int { return (stage * 100) / totalStages }
 
-	startTime := time.Now()
+	startTime := ui.timeSource().Now()
 
 	animDone := make(chan struct{})
 	go func() {
@@ -275,11 +426,11 @@ func (ui *UI) fetchStationsAndInitUI() error {
 		close(animDone)
 	}()
 
-	_, err := ui.stationService.GetStations()
+	_, err := ui.stationService.GetStations(ui.requestContext())
 	if err != nil {
 		return fmt.Errorf("failed to fetch stations: %w", err)
 	}
-	log.Debug().Msgf("Loaded %d stations in %v", ui.stationService.StationCount(), time.Since(startTime))
+	log.Debug().Msgf("Loaded %d stations in %v", ui.stationService.StationCount(), ui.timeSource().Since(startTime))
 
 	<-animDone
 
@@ -298,18 +449,34 @@ func (ui *UI) fetchStationsAndInitUI() error {
 
 	ui.setupUI()
 	ui.stationService.StartPeriodicRefresh(30*time.Second, ui.onStationsRefreshed)
+	ui.startWatching()
+	ui.startOutputWatch()
+	go ui.watchFavorites()
 
 	ui.animateProgress(stagePercent(2), stagePercent(3), MinStatusDisplayTime)
 
 	// Floor, not ceiling: wait only if real work finished early.
-	if elapsed := time.Since(startTime); elapsed < MinLoadingDisplayTime {
-		time.Sleep(MinLoadingDisplayTime - elapsed)
+	if elapsed := ui.timeSource().Since(startTime); elapsed < MinLoadingDisplayTime {
+		ui.timeSource().Sleep(MinLoadingDisplayTime - elapsed)
 	}
-	log.Debug().Msgf("Total loading time: %v", time.Since(startTime))
+	log.Debug().Msgf("Total loading time: %v", ui.timeSource().Since(startTime))
 
 	ui.app.QueueUpdateDraw(func() {
-		ui.app.SetRoot(ui.pages, true).EnableMouse(true)
-		ui.app.SetFocus(ui.stationList)
+		ui.setRoot(ui.modals.pages).EnableMouse(true)
+		ui.stations.focus()
+
+		if ui.config.InSafeMode() {
+			ui.showNotice("Safe mode: custom theme, hooks, and autostart are off for this run")
+		}
+		ui.showDirProblems()
+		if !ui.config.TourShown {
+			ui.showTour()
+		}
+
+		if _, ok := ui.pendingAlarm(); ok {
+			ui.startAlarm()
+			return
+		}
 
 		if ui.startRandom {
 			ui.randomStation()
@@ -330,7 +497,7 @@ func (ui *UI) fetchStationsAndInitUI() error {
 
 		if ui.config.Autostart {
 			log.Debug().Msgf("Autostart enabled, playing last station: %s", ui.config.LastStation)
-			ui.stationList.Select(index+1, 0)
+			ui.stations.selectStation(index)
 			ui.onStationSelected(index)
 		} else {
 			ui.selectAndShowStation(index)
@@ -340,23 +507,47 @@ func (ui *UI) fetchStationsAndInitUI() error {
 	return nil
 }
 
+// setPalette resolves the theme's colors for the widgets built after it.
+func (ui *UI) setPalette(theme config.Theme) {
+	ui.colors.background = config.GetColor(theme.Background)
+	ui.colors.foreground = config.GetColor(theme.Foreground)
+	ui.colors.borders = config.GetColor(theme.Borders)
+	ui.colors.highlight = config.GetColor(theme.Highlight)
+	ui.colors.headerBackground = config.GetColor(theme.HeaderBackground)
+	ui.colors.stationListHeaderBackground = config.GetColor(theme.StationListHeaderBackground)
+	ui.colors.stationListHeaderForeground = config.GetColor(theme.StationListHeaderForeground)
+	ui.colors.helpBackground = config.GetColor(theme.HelpBackground)
+	ui.colors.helpForeground = config.GetColor(theme.HelpForeground)
+	ui.colors.helpHotkey = config.GetColor(theme.HelpHotkey)
+	ui.colors.genreTagBackground = config.GetColor(theme.GenreTagBackground)
+	ui.colors.modalBackground = config.GetColor(theme.ModalBackground)
+	ui.colors.mutedVolume = config.GetColor(theme.MutedVolume)
+	// Widgets without an explicit background take tview's default, which is
+	// solid black.
+	tview.Styles.PrimitiveBackgroundColor = primitiveBackground
+	if theme.Transparent() {
+		ui.colors.headerBackground = tcell.ColorDefault
+		ui.colors.helpBackground = tcell.ColorDefault
+		ui.colors.modalBackground = tcell.ColorDefault
+		tview.Styles.PrimitiveBackgroundColor = tcell.ColorDefault
+	}
+}
+
 func (ui *UI) setupUI() {
+	ui.bus = newEventBus()
 	header := ui.createHeader()
 
-	ui.playerPanel = tview.NewFlex().SetDirection(tview.FlexRow)
-	ui.playerPanel.SetBackgroundColor(ui.colors.background)
-
-	ui.stationList = ui.createStationListTable()
-
-	ui.helpPanel = ui.createFooter()
+	ui.panel = newPlayerPanelView(ui)
+	ui.stations = newStationListView(ui)
+	ui.footer = newFooterView(ui)
 
 	ui.contentLayout = tview.NewFlex().SetDirection(tview.FlexRow).
 		AddItem(header, HeaderHeight, 0, false).
 		AddItem(nil, 1, 0, false).
-		AddItem(ui.playerPanel, PlayerPanelHeight, 0, false).
+		AddItem(ui.panel.root, PlayerPanelHeight, 0, false).
 		AddItem(nil, 1, 0, false).
-		AddItem(ui.stationList, 0, 1, true).
-		AddItem(ui.helpPanel, FooterHeightWide, 0, false)
+		AddItem(ui.stations.root, 0, 1, true).
+		AddItem(ui.footer.box, FooterHeightWide, 0, false)
 	ui.contentLayout.SetBackgroundColor(ui.colors.background)
 
 	wrapper := tview.NewFlex().SetDirection(tview.FlexColumn).
@@ -371,52 +562,103 @@ func (ui *UI) setupUI() {
 		AddItem(nil, 1, 0, false)
 	ui.mainLayout.SetBackgroundColor(ui.colors.background)
 
-	ui.pages = tview.NewPages().
-		AddPage("main", ui.mainLayout, true, true)
-	ui.pages.SetBackgroundColor(ui.colors.background)
+	ui.modals = newModalManager(ui.app, ui.bus, ui.mainLayout, ui.colors.background)
 
-	ui.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if ui.pages.HasPage("modal") || ui.pages.HasPage("error-modal") {
-			return event
+	subscribe(ui.bus, func(e footerResized) {
+		ui.contentLayout.ResizeItem(ui.footer.box, e.height, 0)
+	})
+	subscribe(ui.bus, func(e selectionChanged) {
+		ui.onSelectionHint(e.station.ID, e.station.Title)
+	})
+	// Clicks fall through the empty space around a modal, so the mouse
+	// only acts on the main screen while none is open
+	subscribe(ui.bus, func(e stationClicked) {
+		if !ui.modals.blocking() {
+			ui.onStationSelected(e.index)
 		}
-		return ui.globalInputHandler(event)
 	})
-
-	ui.app.SetMouseCapture(func(event *tcell.EventMouse, action tview.MouseAction) (*tcell.EventMouse, tview.MouseAction) {
-		if ui.pages.HasPage("modal") || ui.pages.HasPage("error-modal") {
-			return event, action
+	subscribe(ui.bus, func(e volumeScrolled) {
+		if !ui.modals.blocking() {
+			ui.adjustVolume(e.step)
+			ui.flashVolume()
+		}
+	})
+	subscribe(ui.bus, func(e hintClicked) {
+		if !ui.modals.blocking() {
+			ui.globalInputHandler(e.key)
 		}
+	})
 
-		// Handle scroll on volume bar only
-		if ui.volumeView != nil {
-			mx, my := event.Position()
-			vx, vy, vw, vh := ui.volumeView.GetRect()
-			onVolumeBar := mx >= vx && mx < vx+vw && my >= vy && my < vy+vh
-
-			if onVolumeBar {
-				switch action {
-				case tview.MouseScrollUp:
-					ui.adjustVolume(VolumeStep)
-					return nil, tview.MouseConsumed
-				case tview.MouseScrollDown:
-					ui.adjustVolume(-VolumeStep)
-					return nil, tview.MouseConsumed
-				}
-			}
+	ui.app.SetInputCapture(func(event *tcell.EventKey) (forward *tcell.EventKey) {
+		ui.guard(func() {
+			forward = ui.captureKey(event)
+		})
+		return forward
+	})
+
+	ui.app.SetMouseCapture(func(event *tcell.EventMouse, action tview.MouseAction) (*tcell.EventMouse, tview.MouseAction) {
+		if action != tview.MouseMove {
+			ui.lastInput = ui.timeSource().Now()
+			ui.cancelIdleStop()
 		}
 		return event, action
 	})
 }
 
+// captureKey sees every key first: it counts as input for the idle stop,
+// and keys not meant for an open modal or the filter are shortcuts.
+func (ui *UI) captureKey(event *tcell.EventKey) *tcell.EventKey {
+	ui.lastInput = ui.timeSource().Now()
+	if ui.cancelIdleStop() {
+		return nil
+	}
+	if ui.modals.blocking() || ui.stations.filterInput.HasFocus() {
+		return event
+	}
+	if ui.answerQualityFallback(event) {
+		return nil
+	}
+	return ui.globalInputHandler(event)
+}
+
+// rebuildScreen builds the main screen anew, keeping the shown and
+// playing stations, the selection, and the filter.
+func (ui *UI) rebuildScreen() {
+	shown := ui.panel.station
+	translated, translations := ui.panel.translated, ui.panel.translations
+	playingID := ui.stations.playingID
+	selected := ui.stations.selectedIndex()
+	query := ui.stations.filterQuery
+
+	ui.setupUI()
+
+	ui.panel.translated, ui.panel.translations = translated, translations
+	ui.bus.publish(playingChanged{id: playingID})
+	if query != "" {
+		ui.stations.filterInput.SetText(query)
+		ui.stations.showFilter()
+	}
+	if selected >= 0 {
+		ui.stations.selectStation(selected)
+	}
+	if shown != nil {
+		ui.panel.show(shown)
+	}
+	ui.setRoot(ui.modals.pages)
+	ui.stations.focus()
+}
+
 func (ui *UI) createHeader() tview.Primitive {
 	titleView := tview.NewTextView()
-	titleView.SetText(" " + config.AppName)
+	titleView.SetText(" " + ui.config.Branding.DisplayTitle())
 	titleView.SetTextAlign(tview.AlignLeft)
 	titleView.SetTextColor(ui.colors.foreground)
 	titleView.SetBackgroundColor(ui.colors.headerBackground)
 
 	versionView := tview.NewTextView()
-	versionView.SetText("v" + config.AppVersion + " ")
+	if !ui.config.Branding.HideVersion {
+		versionView.SetText("v" + config.AppVersion + " ")
+	}
 	versionView.SetTextAlign(tview.AlignRight)
 	versionView.SetTextColor(ui.colors.foreground)
 	versionView.SetBackgroundColor(ui.colors.headerBackground)
@@ -446,33 +688,6 @@ func (ui *UI) createHeader() tview.Primitive {
 	return headerFlex
 }
 
-func (ui *UI) updateLogoPanel(s *station.Station) {
-	stationID := s.ID
-	go func() {
-		img, err := ui.stationService.LoadImage(s.XLImage)
-		if err != nil {
-			ui.app.QueueUpdateDraw(func() {
-				if ui.currentStation == nil || ui.currentStation.ID != stationID {
-					return
-				}
-				ui.logoPanel.SetDrawFunc(func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
-					errorMsg := fmt.Sprintf("Failed to load image: %v", err)
-					tview.Print(screen, errorMsg, x, y, 10, tview.AlignCenter, tcell.ColorRed)
-					return x, y, 10, 10
-				})
-			})
-			return
-		}
-
-		ui.app.QueueUpdateDraw(func() {
-			if ui.currentStation == nil || ui.currentStation.ID != stationID {
-				return
-			}
-			ui.logoPanel.SetImage(img)
-		})
-	}()
-}
-
 func (ui *UI) onStationSelected(index int) {
 	stationCount := ui.stationService.StationCount()
 	if index < 0 || index >= stationCount {
@@ -483,45 +698,33 @@ func (ui *UI) onStationSelected(index int) {
 		return
 	}
 
+	ui.stopRecording()
 	ui.player.Stop()
 	ui.safeCloseChannel()
 	ui.recreateStopChannel()
 
-	previousPlayingIndex := ui.playingIndex
-
 	ui.playingIndex = index
 	ui.currentStation = ui.stationService.GetStation(index)
 	ui.playingStationID = ui.currentStation.ID
-
-	if previousPlayingIndex >= 0 && previousPlayingIndex < stationCount && previousPlayingIndex != index {
-		ui.setStationRow(ui.stationList, previousPlayingIndex+1, previousPlayingIndex)
-	}
-
-	ui.updateStationListPlayingIndicator()
+	ui.stationService.Pin(ui.playingStationID)
+	ui.recordRecentStation(ui.playingStationID)
+	ui.bus.publish(playingChanged{id: ui.playingStationID})
 
 	ui.SaveConfig()
 
-	ui.playerPanel.Clear()
-
-	contentPanel := ui.createContentPanel()
-	ui.playerPanel.AddItem(contentPanel, 0, 1, false)
-
-	ui.updateLogoPanel(ui.currentStation)
+	ui.panel.show(ui.currentStation)
+	ui.translateShownStation()
 
 	go func() {
 		stationID := ui.currentStation.ID
-		track, err := ui.stationService.GetCurrentTrackForStation(stationID)
+		track, err := ui.loadTrackTicker(stationID)
 		if err != nil {
 			log.Debug().Err(err).Msg("Failed to fetch song history, using lastPlaying")
 			return
 		}
 		if track != "" {
 			ui.app.QueueUpdateDraw(func() {
-				if ui.currentTrackView != nil {
-					ui.currentTrackView.SetText(fmt.Sprintf(" [%s]%s[-]",
-						ui.colors.highlight.String(),
-						track))
-				}
+				ui.panel.showTrack(track)
 			})
 			ui.player.SetInitialTrack(track)
 		}
@@ -531,7 +734,7 @@ func (ui *UI) onStationSelected(index int) {
 
 	go func() {
 		log.Info().Msgf("Starting playback for station: %s", ui.currentStation.Title)
-		err := ui.player.Play(ui.currentStation)
+		err := ui.player.PlayPlaylist(ui.currentStation, ui.playlistURLFor(ui.currentStation))
 		if err != nil {
 			if errors.Is(err, context.Canceled) {
 				log.Debug().Msg("Playback stopped (station changed)")
@@ -545,145 +748,6 @@ func (ui *UI) onStationSelected(index int) {
 	}()
 }
 
-func (ui *UI) createGenreTags(genre string) *tview.Flex {
-	container := tview.NewFlex().SetDirection(tview.FlexColumn)
-	container.SetBackgroundColor(ui.colors.background)
-
-	container.AddItem(tview.NewBox().SetBackgroundColor(ui.colors.background), 1, 0, false)
-
-	if genre == "" {
-		noGenre := tview.NewTextView()
-		noGenre.SetText("N/A")
-		noGenre.SetTextColor(ui.colors.foreground)
-		noGenre.SetBackgroundColor(ui.colors.background)
-		container.AddItem(noGenre, 3, 0, false)
-		return container
-	}
-
-	genres := strings.Split(genre, "|")
-	for i, g := range genres {
-		g = strings.TrimSpace(g)
-
-		tag := tview.NewTextView()
-		tag.SetText(" " + g + " ")
-		tag.SetTextColor(ui.colors.foreground)
-		tag.SetBackgroundColor(ui.colors.genreTagBackground)
-		tag.SetTextAlign(tview.AlignCenter)
-
-		tagWidth := len(g) + 2
-		container.AddItem(tag, tagWidth, 0, false)
-
-		if i < len(genres)-1 {
-			spacer := tview.NewBox().SetBackgroundColor(ui.colors.background)
-			container.AddItem(spacer, 1, 0, false)
-		}
-	}
-
-	container.AddItem(tview.NewBox().SetBackgroundColor(ui.colors.background), 0, 1, false)
-
-	return container
-}
-
-func (ui *UI) createContentPanel() *tview.Flex {
-	ui.logoPanel = tview.NewImage()
-	ui.logoPanel.SetBackgroundColor(ui.colors.background)
-	ui.logoPanel.SetAlign(tview.AlignLeft, tview.AlignTop)
-
-	stationLabel := tview.NewTextView()
-	stationLabel.SetText(" Station:")
-	stationLabel.SetTextColor(ui.colors.foreground)
-	stationLabel.SetBackgroundColor(ui.colors.background)
-	stationLabel.SetWrap(false)
-
-	stationNameView := tview.NewTextView()
-	stationNameView.SetDynamicColors(true)
-	stationNameView.SetText(fmt.Sprintf(" [%s]%s[-]",
-		ui.colors.highlight.String(),
-		ui.currentStation.Title))
-	stationNameView.SetTextColor(ui.colors.highlight)
-	stationNameView.SetBackgroundColor(ui.colors.background)
-	stationNameView.SetWrap(false)
-	stationNameView.SetTextStyle(tcell.StyleDefault.Background(ui.colors.background).Attributes(tcell.AttrBold))
-
-	playingLabel := tview.NewTextView()
-	playingLabel.SetText(" Playing:")
-	playingLabel.SetTextColor(ui.colors.foreground)
-	playingLabel.SetBackgroundColor(ui.colors.background)
-	playingLabel.SetWrap(false)
-
-	ui.currentTrackView = tview.NewTextView()
-	ui.currentTrackView.SetDynamicColors(true)
-	ui.currentTrackView.SetText(fmt.Sprintf(" [%s]%s[-]",
-		ui.colors.highlight.String(),
-		ui.currentStation.LastPlaying))
-	ui.currentTrackView.SetTextColor(ui.colors.highlight)
-	ui.currentTrackView.SetBackgroundColor(ui.colors.background)
-	ui.currentTrackView.SetWrap(true)
-	ui.currentTrackView.SetTextStyle(tcell.StyleDefault.Background(ui.colors.background).Attributes(tcell.AttrBold))
-
-	genreLabel := tview.NewTextView()
-	genreLabel.SetText(" Genre:")
-	genreLabel.SetTextColor(ui.colors.foreground)
-	genreLabel.SetBackgroundColor(ui.colors.background)
-	genreLabel.SetWrap(false)
-
-	genreView := ui.createGenreTags(ui.currentStation.Genre)
-
-	descriptionLabel := tview.NewTextView()
-	descriptionLabel.SetText(" Description:")
-	descriptionLabel.SetTextColor(ui.colors.foreground)
-	descriptionLabel.SetBackgroundColor(ui.colors.background)
-	descriptionLabel.SetWrap(false)
-
-	descriptionView := tview.NewTextView()
-	descriptionView.SetDynamicColors(true)
-	descriptionView.SetText(fmt.Sprintf(" [%s]%s[-]",
-		ui.colors.foreground.String(),
-		ui.currentStation.Description))
-	descriptionView.SetTextColor(ui.colors.foreground)
-	descriptionView.SetBackgroundColor(ui.colors.background)
-	descriptionView.SetWrap(true)
-
-	infoSpacer := tview.NewBox().SetBackgroundColor(ui.colors.background)
-
-	infoContent := tview.NewFlex().SetDirection(tview.FlexRow).
-		AddItem(stationLabel, 1, 0, false).
-		AddItem(stationNameView, 1, 0, false).
-		AddItem(nil, 1, 0, false).
-		AddItem(playingLabel, 1, 0, false).
-		AddItem(ui.currentTrackView, 1, 0, false).
-		AddItem(nil, 1, 0, false).
-		AddItem(genreLabel, 1, 0, false).
-		AddItem(genreView, 1, 0, false).
-		AddItem(nil, 1, 0, false).
-		AddItem(descriptionLabel, 1, 0, false).
-		AddItem(descriptionView, 0, 1, false).
-		AddItem(infoSpacer, 0, 1, false)
-	infoContent.SetBackgroundColor(ui.colors.background)
-
-	ui.volumeView = ui.createGraphicalVolumeBar()
-
-	// Wrap logo in vertical flex to constrain height
-	logoWrapper := tview.NewFlex().SetDirection(tview.FlexRow).
-		AddItem(ui.logoPanel, CoverHeight, 0, false).
-		AddItem(nil, 0, 1, false)
-	logoWrapper.SetBackgroundColor(ui.colors.background)
-
-	contentFlex := tview.NewFlex().SetDirection(tview.FlexColumn).
-		AddItem(logoWrapper, CoverWidth, 0, false).
-		AddItem(infoContent, 0, 1, false).
-		AddItem(ui.volumeView, 7, 0, false)
-	contentFlex.SetBackgroundColor(ui.colors.background)
-
-	contentWithPadding := tview.NewFlex().SetDirection(tview.FlexColumn).
-		AddItem(nil, 4, 0, false).
-		AddItem(contentFlex, 0, 1, false).
-		AddItem(nil, 4, 0, false)
-	contentWithPadding.SetBackgroundColor(ui.colors.background)
-
-	return contentWithPadding
-}
-
 type PlayingSpinner struct {
 	Frames []string
 	FPS    time.Duration
@@ -696,47 +760,48 @@ func NewPlayingSpinner() *PlayingSpinner {
 	}
 }
 
-func (ui *UI) getPlayingIndicator() string {
-	if ui.playingSpinner == nil {
-		ui.playingSpinner = NewPlayingSpinner()
-	}
-
-	frameIndex := ui.animationFrame % len(ui.playingSpinner.Frames)
-	return ui.playingSpinner.Frames[frameIndex]
-}
-
 func (ui *UI) startPlayingAnimation() {
-	if ui.playingSpinner == nil {
-		ui.playingSpinner = NewPlayingSpinner()
-	}
-
 	go func() {
-		animationTicker := time.NewTicker(ui.playingSpinner.FPS)
-		trackUpdateTicker := time.NewTicker(5 * time.Second)
+		animationTicker := ui.timeSource().NewTicker(ui.stations.spinner.FPS)
+		trackUpdateTicker := ui.timeSource().NewTicker(5 * time.Second)
+		statusTicker := ui.timeSource().NewTicker(time.Second)
 		defer animationTicker.Stop()
 		defer trackUpdateTicker.Stop()
+		defer statusTicker.Stop()
 
 		for {
 			select {
 			case <-ui.stopUpdates:
 				return
-			case <-animationTicker.C:
-				ui.mu.Lock()
-				ui.animationFrame++
-				ui.mu.Unlock()
-
+			case <-animationTicker.C():
 				ui.statusRenderer.AdvanceAnimation()
 
 				ui.app.QueueUpdateDraw(func() {
-					ui.updateStationListPlayingIndicator()
-					if ui.pages.HasPage("error-modal") && ui.player.GetState() == player.StatePlaying {
-						ui.pages.RemovePage("error-modal")
-						ui.app.SetFocus(ui.stationList)
+					ui.stations.advanceSpinner()
+					ui.stations.updatePlayingIndicator()
+					ui.panel.updatePulse()
+					ui.updateVisualizer()
+					if ui.modals.isOpen(errorModalPage) && ui.player.GetState() == player.StatePlaying {
+						ui.modals.close(errorModalPage)
 					}
 				})
-			case <-trackUpdateTicker.C:
+			case <-statusTicker.C():
+				ui.app.QueueUpdateDraw(func() {
+					ui.panel.updateStatus()
+					ui.checkIdleStop()
+					ui.checkAutoStop()
+					ui.checkSimilarSuggestion()
+					ui.checkQualityFallback()
+				})
+			case <-ui.player.Changes():
+				ui.app.QueueUpdateDraw(ui.panel.updateStatus)
+			case <-trackUpdateTicker.C():
 				ui.app.QueueUpdateDraw(func() {
 					ui.updateTrackInfo()
+					ui.checkDeadAir()
+					ui.checkRecordingQuota()
+					ui.checkPlaybackHint()
+					ui.prefetchNeighbors()
 				})
 			}
 		}
@@ -744,14 +809,40 @@ func (ui *UI) startPlayingAnimation() {
 }
 
 func (ui *UI) updateTrackInfo() {
-	if ui.currentTrackView == nil || !ui.player.IsPlaying() {
+	if !ui.player.IsPlaying() {
 		return
 	}
+	ui.panel.setTrack(ui.player.GetCurrentTrack(), ui.config.Pulse)
+}
 
-	trackInfo := ui.player.GetCurrentTrack()
-	ui.currentTrackView.SetText(fmt.Sprintf(" [%s]%s[-]",
-		ui.colors.highlight.String(),
-		trackInfo))
+// checkDeadAir reacts to a stream that stays connected but silent,
+// switching to the configured fallback station or stopping playback.
+func (ui *UI) checkDeadAir() {
+	deadAir := ui.config.DeadAir
+	if !deadAir.Enabled || ui.player.GetState() != player.StatePlaying {
+		return
+	}
+
+	silence := ui.player.GetSilenceDuration()
+	if silence < deadAir.Timeout {
+		return
+	}
+
+	fallbackIndex := ui.stationService.FindIndexByID(deadAir.FallbackStation)
+	if fallbackIndex >= 0 && fallbackIndex != ui.playingIndex {
+		fallback := ui.stationService.GetStation(fallbackIndex)
+		log.Info().Msgf("Dead air for %v, switching to fallback station: %s", silence, fallback.Title)
+		ui.showNotice(fmt.Sprintf("No audio for %s — switched to %s", format.Duration(silence), fallback.Title))
+		ui.stations.selectStation(fallbackIndex)
+		ui.onStationSelected(fallbackIndex)
+		return
+	}
+
+	log.Info().Msgf("Dead air for %v, stopping playback", silence)
+	ui.player.Stop()
+	ui.safeCloseChannel()
+	ui.stations.redrawStation(ui.playingIndex)
+	ui.showNotice(fmt.Sprintf("No audio for %s — playback stopped", format.Duration(silence)))
 }
 
 func (ui *UI) onStationsRefreshed(stations []station.Station) {
@@ -770,12 +861,9 @@ func (ui *UI) globalInputHandler(event *tcell.EventKey) *tcell.EventKey {
 		case ' ':
 			if ui.player.IsPlaying() || ui.player.IsPaused() {
 				ui.player.TogglePause()
-				ui.updateStationListPlayingIndicator()
+				ui.stations.updatePlayingIndicator()
 			} else {
-				row, _ := ui.stationList.GetSelection()
-				if row > 0 && row <= ui.stationService.StationCount() {
-					ui.onStationSelected(row - 1)
-				}
+				ui.onStationSelected(ui.stations.selectedIndex())
 			}
 			return nil
 		case '>':
@@ -791,13 +879,14 @@ func (ui *UI) globalInputHandler(event *tcell.EventKey) *tcell.EventKey {
 			ui.toggleFavorite()
 			return nil
 		case '+', '=':
-			ui.adjustVolume(VolumeStep)
+			ui.volumeKey(1)
 			return nil
 		case '-', '_':
-			ui.adjustVolume(-VolumeStep)
+			ui.volumeKey(-1)
 			return nil
 		case 'm', 'M':
 			ui.toggleMute()
+			ui.flashVolume()
 			return nil
 		case '?':
 			ui.showHelpModal()
@@ -805,23 +894,115 @@ func (ui *UI) globalInputHandler(event *tcell.EventKey) *tcell.EventKey {
 		case 'a', 'A':
 			ui.showAboutModal()
 			return nil
+		case '/':
+			ui.stations.showFilter()
+			return nil
+		case 'o', 'O':
+			ui.toggleOSD()
+			return nil
+		case 'v', 'V':
+			ui.toggleVisualizer()
+			return nil
+		case 'x':
+			ui.switchComparison()
+			return nil
+		case 'X':
+			ui.stopComparison()
+			return nil
+		case 'd', 'D':
+			ui.toggleBufferGraph()
+			return nil
+		case 'c', 'C':
+			ui.showCacheModal()
+			return nil
+		case 'l':
+			ui.likeCurrentTrack()
+			return nil
+		case 'L':
+			ui.showLikesModal()
+			return nil
+		case 'h':
+			ui.showHistoryModal()
+			return nil
+		case 'H':
+			ui.showPlayLogModal()
+			return nil
+		case 'e', 'E':
+			ui.showNoteInput()
+			return nil
+		case 's':
+			ui.showQualityModal()
+			return nil
+		case 'S':
+			ui.cycleSortOrder()
+			return nil
+		case 'n', 'N':
+			ui.toggleNightMode()
+			return nil
+		case 'i', 'I':
+			ui.showStatsModal()
+			return nil
+		case 'w', 'W':
+			ui.toggleRecording()
+			return nil
+		case 't':
+			ui.toggleTranslation()
+			return nil
+		case 'T':
+			ui.showThemeModal()
+			return nil
+		case '[':
+			ui.shiftPlayback(-player.TimeShiftStep)
+			return nil
+		case ']':
+			ui.shiftPlayback(player.TimeShiftStep)
+			return nil
+		case '\\':
+			ui.goLive()
+			return nil
+		case 'p', 'P':
+			ui.showScrubModal()
+			return nil
+		case 'b', 'B':
+			ui.searchCurrentTrack()
+			return nil
+		case 'y', 'Y':
+			ui.playSimilar()
+			return nil
 		}
-	case tcell.KeyEnter:
-		row, _ := ui.stationList.GetSelection()
-		if row > 0 && row <= ui.stationService.StationCount() {
-			ui.onStationSelected(row - 1)
+		if r := event.Rune(); r >= '1' && r <= '9' {
+			ui.quickSelect(int(r - '0'))
+			return nil
 		}
+		if !stationListRunes[event.Rune()] {
+			ui.showUnboundKey(event.Rune())
+			return nil
+		}
+	case tcell.KeyEnter:
+		ui.onStationSelected(ui.stations.selectedIndex())
+		return nil
+	case tcell.KeyCtrlR:
+		ui.forceReconnect()
 		return nil
 	case tcell.KeyEscape:
+		if ui.modals.isOpen(osdPage) {
+			ui.toggleOSD()
+			return nil
+		}
+		// First Esc clears an active filter, the next one quits
+		if ui.stations.filterQuery != "" {
+			ui.stations.clearFilter()
+			return nil
+		}
 		ui.stop()
 		return nil
 	case tcell.KeyRight:
 		// Right arrow - volume up (hidden shortcut)
-		ui.adjustVolume(VolumeStep)
+		ui.volumeKey(1)
 		return nil
 	case tcell.KeyLeft:
 		// Left arrow - volume down (hidden shortcut)
-		ui.adjustVolume(-VolumeStep)
+		ui.volumeKey(-1)
 		return nil
 	}
 	return event
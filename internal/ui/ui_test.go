@@ -1,8 +1,39 @@
 package ui
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/api"
+	"github.com/glebovdev/somafm-cli/internal/clock"
+	"github.com/glebovdev/somafm-cli/internal/config"
+	"github.com/glebovdev/somafm-cli/internal/genre"
+	"github.com/glebovdev/somafm-cli/internal/history"
+	"github.com/glebovdev/somafm-cli/internal/outputdev"
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/glebovdev/somafm-cli/internal/playlog"
+	"github.com/glebovdev/somafm-cli/internal/service"
+	"github.com/glebovdev/somafm-cli/internal/station"
+	"github.com/glebovdev/somafm-cli/internal/termbg"
+	"github.com/glebovdev/somafm-cli/internal/termimg"
+	"github.com/glebovdev/somafm-cli/internal/translate"
+	"github.com/rivo/tview"
 )
 
 func TestNewPlayingSpinner(t *testing.T) {
@@ -143,6 +174,11 @@ func TestExtractErrorReason(t *testing.T) {
 			err:      errors.New("unexpected status 404"),
 			contains: "404",
 		},
+		{
+			name:     "missing ffmpeg",
+			err:      errors.New("all streams failed: unsupported stream format: AAC playback requires ffmpeg in PATH"),
+			contains: "ffmpeg",
+		},
 		{
 			name:     "generic error (short)",
 			err:      errors.New("some error"),
@@ -298,6 +334,28 @@ func TestStatusRendererRenderIdle(t *testing.T) {
 	}
 }
 
+func TestToggleNightMode(t *testing.T) {
+	p := player.NewPlayer()
+	ui := withFooter(&UI{app: tview.NewApplication(), config: config.DefaultConfig(), player: p})
+	renderer := NewStatusRenderer(p)
+
+	ui.globalInputHandler(tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModNone))
+	if !p.NightMode() || !ui.config.Audio.NightMode {
+		t.Fatalf("night mode after n: player %v, config %v, want both on", p.NightMode(), ui.config.Audio.NightMode)
+	}
+	if got := renderer.renderPaused(); !strings.Contains(got, "NIGHT") {
+		t.Errorf("renderPaused() = %q, want the night mode indicator", got)
+	}
+
+	ui.toggleNightMode()
+	if p.NightMode() || ui.config.Audio.NightMode {
+		t.Error("night mode still on after toggling twice")
+	}
+	if got := renderer.renderPaused(); strings.Contains(got, "NIGHT") {
+		t.Errorf("renderPaused() = %q, want no night mode indicator", got)
+	}
+}
+
 func TestStatusRendererRenderBuffering(t *testing.T) {
 	renderer := NewStatusRenderer(nil)
 
@@ -333,3 +391,1631 @@ func TestNewStatusRenderer(t *testing.T) {
 		}
 	}
 }
+
+func TestStationMatchesFilter(t *testing.T) {
+	s := &station.Station{Title: "Groove Salad", Genre: "ambient|electronica"}
+
+	tests := []struct {
+		query    string
+		expected bool
+	}{
+		{"", true},
+		{"groove", true},
+		{"SALAD", true},
+		{"ambient", true},
+		{"ambient, elec", true},
+		{"drone", false},
+		{"braindance", false},
+	}
+
+	genres, err := genre.NewTranslator(nil)
+	if err != nil {
+		t.Fatalf("NewTranslator() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := stationMatchesFilter(s, tt.query, genres); got != tt.expected {
+				t.Errorf("stationMatchesFilter(%q) = %v, want %v", tt.query, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStationMatchesFuzzyFilter(t *testing.T) {
+	s := &station.Station{
+		Title:       "Groove Salad",
+		Genre:       "ambient|electronica",
+		Description: "A nicely chilled plate of ambient/downtempo beats and grooves.",
+	}
+	genres, err := genre.NewTranslator(nil)
+	if err != nil {
+		t.Fatalf("NewTranslator() error = %v", err)
+	}
+
+	tests := []struct {
+		query    string
+		expected bool
+	}{
+		{"grvsld", true},
+		{"elctrnc", true},
+		{"downtempo", true},
+		{"chilled groove", true},
+		{"salad drone", false},
+		{"dwntmp", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := stationMatchesFilter(s, tt.query, genres); got != tt.expected {
+				t.Errorf("stationMatchesFilter(%q) = %v, want %v", tt.query, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHighlightMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		query    string
+		expected string
+	}{
+		{"empty query", "Groove Salad", "", "Groove Salad"},
+		{"no match", "Groove Salad", "drone", "Groove Salad"},
+		{"case insensitive", "Groove Salad", "groove", "[red::u]Groove[-::-] Salad"},
+		{"multiple matches", "Deep Space One", "e", "D[red::u]e[-::-][red::u]e[-::-]p Spac[red::u]e[-::-] On[red::u]e[-::-]"},
+		{"escapes tags", "[Live] Mix", "mix", "[Live[] [red::u]Mix[-::-]"},
+		{"each word", "Groove Salad", "salad gro", "[red::u]Gro[-::-]ove [red::u]Salad[-::-]"},
+		{"fuzzy letters", "Groove Salad", "gsd", "[red::u]G[-::-]roove [red::u]S[-::-]ala[red::u]d[-::-]"},
+		{"overlapping words", "Drone Zone", "drone one", "[red::u]Drone[-::-] Z[red::u]one[-::-]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := highlightMatches(tt.text, tt.query, "red")
+			if result != tt.expected {
+				t.Errorf("highlightMatches(%q, %q) = %q, want %q", tt.text, tt.query, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBigFontGlyphsAreRectangular(t *testing.T) {
+	for r, glyph := range bigFont {
+		width := len(glyph[0])
+		for row, line := range glyph {
+			if len(line) != width {
+				t.Errorf("glyph %q row %d has width %d, want %d", r, row, len(line), width)
+			}
+		}
+	}
+}
+
+func TestRenderBigLine(t *testing.T) {
+	rows := renderBigLine("HI")
+	if len(rows) != bigTextLines {
+		t.Fatalf("renderBigLine() returned %d rows, want %d", len(rows), bigTextLines)
+	}
+
+	expectedWidth := bigWordWidth("HI")
+	for i, row := range rows {
+		if n := len([]rune(row)); n != expectedWidth {
+			t.Errorf("row %d has width %d, want %d", i, n, expectedWidth)
+		}
+	}
+
+	if rows[0] != "█  █ ▀█▀" {
+		t.Errorf("renderBigLine(\"HI\")[0] = %q, want %q", rows[0], "█  █ ▀█▀")
+	}
+}
+
+func TestWrapBigText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxWidth int
+		expected []string
+	}{
+		{"fits on one line", "Groove Salad", 100, []string{"Groove Salad"}},
+		{"wraps words", "Groove Salad", 30, []string{"Groove", "Salad"}},
+		{"breaks long word", "Groove", 12, []string{"Gr", "oo", "ve"}},
+		{"empty", "", 20, nil},
+		{"no room", "Groove", -4, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := wrapBigText(tt.text, tt.maxWidth)
+			if len(result) != len(tt.expected) {
+				t.Fatalf("wrapBigText(%q, %d) = %q, want %q", tt.text, tt.maxWidth, result, tt.expected)
+			}
+			for i := range result {
+				if result[i] != tt.expected[i] {
+					t.Errorf("wrapBigText(%q, %d)[%d] = %q, want %q", tt.text, tt.maxWidth, i, result[i], tt.expected[i])
+				}
+				if w := bigWordWidth(result[i]); w > tt.maxWidth {
+					t.Errorf("line %q has width %d, exceeds %d", result[i], w, tt.maxWidth)
+				}
+			}
+		})
+	}
+}
+
+func TestWrapBigTextNarrowWidth(t *testing.T) {
+	result := wrapBigText("MW", 2)
+	if len(result) != 2 {
+		t.Errorf("wrapBigText(\"MW\", 2) = %q, want one glyph per line", result)
+	}
+}
+
+func TestShowHintOncePerSession(t *testing.T) {
+	ui := withFooter(&UI{app: tview.NewApplication(), config: config.DefaultConfig()})
+
+	ui.showHint(hintFilter, "press / to filter")
+	if got := ui.activeNotice(); got != "Tip: press / to filter" {
+		t.Fatalf("activeNotice() = %q, want the hint", got)
+	}
+
+	ui.footer.notice = ""
+	ui.showHint(hintFilter, "press / to filter")
+	if got := ui.activeNotice(); got != "" {
+		t.Errorf("hint shown twice, activeNotice() = %q", got)
+	}
+}
+
+func TestShowHintDisabled(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Hints = false
+	ui := withFooter(&UI{app: tview.NewApplication(), config: cfg})
+
+	ui.showHint(hintOSD, "press o")
+	if got := ui.activeNotice(); got != "" {
+		t.Errorf("activeNotice() with hints disabled = %q, want empty", got)
+	}
+}
+
+func TestShowHintDoesNotReplaceNotice(t *testing.T) {
+	ui := withFooter(&UI{app: tview.NewApplication(), config: config.DefaultConfig()})
+
+	ui.showNotice("Dead air")
+	ui.showHint(hintOSD, "press o")
+	if got := ui.activeNotice(); got != "Dead air" {
+		t.Errorf("activeNotice() = %q, want the original notice", got)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		bytes    int64
+		expected string
+	}{
+		{0, "0 B"},
+		{512, "512 B"},
+		{1024, "1.0 KB"},
+		{1536, "1.5 KB"},
+		{5 * 1024 * 1024, "5.0 MB"},
+		{3 * 1024 * 1024 * 1024, "3.0 GB"},
+	}
+
+	for _, tt := range tests {
+		if got := formatBytes(tt.bytes); got != tt.expected {
+			t.Errorf("formatBytes(%d) = %q, want %q", tt.bytes, got, tt.expected)
+		}
+	}
+}
+
+func TestFormatBitrate(t *testing.T) {
+	if got := formatBitrate(128, 0); got != "128k" {
+		t.Errorf("formatBitrate(128, 0) = %q, want %q", got, "128k")
+	}
+	if got := formatBitrate(128, 131); got != "128k (rx 131k)" {
+		t.Errorf("formatBitrate(128, 131) = %q, want %q", got, "128k (rx 131k)")
+	}
+}
+
+func TestVolumeFlashBar(t *testing.T) {
+	tests := []struct {
+		volume int
+		want   string
+	}{
+		{0, "░░░░░░░░░░"},
+		{55, "█████░░░░░"},
+		{100, "██████████"},
+		{120, "██████████"},
+	}
+
+	for _, tt := range tests {
+		if got := volumeFlashBar(tt.volume, 10); got != tt.want {
+			t.Errorf("volumeFlashBar(%d, 10) = %q, want %q", tt.volume, got, tt.want)
+		}
+	}
+}
+
+func TestVolumeRamp(t *testing.T) {
+	ui := newSnapshotUI(t)
+	t.Setenv("HOME", t.TempDir())
+	fake := clock.NewFake(time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC))
+	ui.SetClock(fake)
+	ui.currentVolume = 10
+
+	// A held key repeats every 30 ms
+	var got []int
+	for range 11 {
+		ui.globalInputHandler(tcell.NewEventKey(tcell.KeyRune, '+', tcell.ModNone))
+		got = append(got, ui.currentVolume)
+		fake.Advance(30 * time.Millisecond)
+	}
+	if want := []int{15, 20, 25, 30, 35, 45, 55, 65, 75, 85, 100}; !slices.Equal(got, want) {
+		t.Errorf("volumes while held = %v, want %v", got, want)
+	}
+
+	fake.Advance(volumeRepeatGap)
+	ui.globalInputHandler(tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModNone))
+	if ui.currentVolume != 95 {
+		t.Errorf("volume after a fresh press = %d, want a single step down to 95", ui.currentVolume)
+	}
+
+	// The writer saves once the presses settle
+	if !ui.configWriter.Pending() {
+		t.Fatal("config written during the sweep")
+	}
+	ui.flushConfig()
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load() error = %v", err)
+	}
+	if cfg.Volume != 95 {
+		t.Errorf("saved volume = %d, want 95", cfg.Volume)
+	}
+}
+
+func TestStationMatchesTranslatedGenre(t *testing.T) {
+	s := &station.Station{Title: "Cliqhop IDM", Genre: "idm|electronica"}
+	genres, err := genre.NewTranslator(map[string]genre.Info{
+		"electronica": {Name: "Electronica", Description: "Melodic beats"},
+	})
+	if err != nil {
+		t.Fatalf("NewTranslator() error = %v", err)
+	}
+
+	for _, query := range []string{"braindance", "idm / brain", "melodic"} {
+		if !stationMatchesFilter(s, query, genres) {
+			t.Errorf("stationMatchesFilter(%q) = false, want true", query)
+		}
+	}
+	if got := genreDisplayText(s.Genre, genres); got != "IDM / braindance, Electronica" {
+		t.Errorf("genreDisplayText() = %q", got)
+	}
+}
+
+func TestUnboundKeyShowsNotice(t *testing.T) {
+	ui := withFooter(&UI{app: tview.NewApplication(), config: config.DefaultConfig()})
+
+	if got := ui.globalInputHandler(tcell.NewEventKey(tcell.KeyRune, 'z', tcell.ModNone)); got != nil {
+		t.Errorf("globalInputHandler('z') = %v, want consumed", got)
+	}
+	if got, want := ui.activeNotice(), "Unbound key 'z' — press ? for help"; got != want {
+		t.Errorf("activeNotice() = %q, want %q", got, want)
+	}
+
+	ui.footer.notice = ""
+	if got := ui.globalInputHandler(tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone)); got == nil {
+		t.Error("globalInputHandler('j') consumed a station list key")
+	}
+	if got := ui.activeNotice(); got != "" {
+		t.Errorf("activeNotice() after 'j' = %q, want empty", got)
+	}
+}
+
+func TestStatusRendererAlarmCountdown(t *testing.T) {
+	s := NewStatusRenderer(nil)
+	s.SetAlarm(time.Now().Add(90*time.Minute+30*time.Second), "Groove Salad")
+
+	got := s.Render()
+	if !strings.Contains(got, "ALARM") || !strings.Contains(got, "Groove Salad in 1 h 30 min") {
+		t.Errorf("Render() = %q, want alarm countdown", got)
+	}
+
+	s.SetAlarm(time.Time{}, "")
+	if got := s.Render(); got != "○ IDLE │ Select a station" {
+		t.Errorf("Render() after clearing alarm = %q", got)
+	}
+}
+
+func TestWeightedPick(t *testing.T) {
+	weights := []float64{1, 3, 0, 1}
+	tests := []struct {
+		r    float64
+		want int
+	}{
+		{0, 0},
+		{0.19, 0},
+		{0.2, 1},
+		{0.79, 1},
+		{0.8, 3},
+		{0.99, 3},
+	}
+	for _, tt := range tests {
+		if got := weightedPick(weights, tt.r); got != tt.want {
+			t.Errorf("weightedPick(%v, %v) = %d, want %d", weights, tt.r, got, tt.want)
+		}
+	}
+	if got := weightedPick([]float64{0, 0}, 0.6); got != 1 {
+		t.Errorf("weightedPick() with zero weights = %d, want uniform pick 1", got)
+	}
+}
+
+func TestRandomCandidatesSkipRecent(t *testing.T) {
+	ui := newSnapshotUI(t)
+	ui.config.Random.ExcludeRecent = 2
+	ui.recordRecentStation("dronezone")
+	ui.recordRecentStation("groovesalad")
+
+	candidates := ui.randomCandidates()
+	if len(candidates) != 1 || ui.stationService.GetStation(candidates[0]).ID != "defcon" {
+		t.Errorf("randomCandidates() = %v, want only defcon", candidates)
+	}
+
+	ui.config.Random.ExcludeRecent = 3
+	ui.recordRecentStation("defcon")
+	ui.playingStationID = "defcon"
+	if got := ui.randomCandidates(); len(got) != 2 {
+		t.Errorf("randomCandidates() with everything recent = %v, want all but the playing station", got)
+	}
+}
+
+func TestTrackTicker(t *testing.T) {
+	var ticker trackTicker
+	ticker.seed("C - Now", []string{"B - Before", "A - Earlier", "Z - Oldest"})
+	if got, want := strings.Join(ticker.previous, "|"), "B - Before|A - Earlier"; got != want {
+		t.Errorf("previous after seed = %q, want %q", got, want)
+	}
+
+	ticker.observe("C - Now")
+	ticker.observe(player.NoTrackInfo)
+	ticker.observe("D - Next")
+	if got, want := strings.Join(ticker.previous, "|"), "C - Now|B - Before"; got != want {
+		t.Errorf("previous after track change = %q, want %q", got, want)
+	}
+
+	ticker.reset()
+	if ticker.render() != "" {
+		t.Errorf("render() after reset = %q, want empty", ticker.render())
+	}
+}
+
+func TestForceReconnectWhenIdle(t *testing.T) {
+	ui := withFooter(&UI{app: tview.NewApplication(), config: config.DefaultConfig(), player: player.NewPlayer()})
+
+	if got := ui.globalInputHandler(tcell.NewEventKey(tcell.KeyCtrlR, 0, tcell.ModCtrl)); got != nil {
+		t.Errorf("globalInputHandler(Ctrl-R) = %v, want consumed", got)
+	}
+	if got, want := ui.activeNotice(), "Nothing playing to reconnect"; got != want {
+		t.Errorf("activeNotice() = %q, want %q", got, want)
+	}
+}
+
+func TestToggleTranslationWhenOff(t *testing.T) {
+	ui := withFooter(&UI{app: tview.NewApplication(), config: config.DefaultConfig()})
+
+	ui.globalInputHandler(tcell.NewEventKey(tcell.KeyRune, 't', tcell.ModNone))
+	if got := ui.activeNotice(); !strings.Contains(got, "translate.backend") {
+		t.Errorf("activeNotice() = %q, want a hint to configure translation", got)
+	}
+}
+
+func TestToggleTranslationFromCache(t *testing.T) {
+	ui := newSnapshotUI(t)
+	s := ui.currentStation
+
+	cache := translate.OpenCache(filepath.Join(t.TempDir(), translate.CacheFileName))
+	texts := append([]string{s.Description}, ui.genres.Names(s.Genre)...)
+	translated := make([]string, len(texts))
+	for i, text := range texts {
+		translated[i] = "de:" + text
+	}
+	if err := cache.Put(string(s.ID), "de", texts, translated); err != nil {
+		t.Fatal(err)
+	}
+	ui.translator = translate.NewClient(nil, "de", cache)
+
+	ui.toggleTranslation()
+	if got, want := ui.panel.description(), "de:"+s.Description; got != want {
+		t.Errorf("translated description = %q, want %q", got, want)
+	}
+	if got := ui.panel.genreNames(); len(got) == 0 || got[0] != translated[1] {
+		t.Errorf("translated genres = %q, want %q", got, translated[1:])
+	}
+
+	ui.toggleTranslation()
+	if got := ui.panel.description(); got != s.Description {
+		t.Errorf("description after toggling back = %q, want the original", got)
+	}
+}
+
+func TestNoticeExpiresWithClock(t *testing.T) {
+	fakeClock := clock.NewFake(time.Now())
+	ui := withFooter(&UI{app: tview.NewApplication(), config: config.DefaultConfig(), clock: fakeClock})
+
+	ui.showNotice("Hello")
+	fakeClock.Advance(NoticeDisplayTime - time.Millisecond)
+	if got := ui.activeNotice(); got != "Hello" {
+		t.Errorf("activeNotice() before expiry = %q, want %q", got, "Hello")
+	}
+
+	fakeClock.Advance(time.Millisecond + 1)
+	if got := ui.activeNotice(); got != "" {
+		t.Errorf("activeNotice() after expiry = %q, want empty", got)
+	}
+}
+
+func TestPlaylistURLForStreamVariant(t *testing.T) {
+	s := &station.Station{ID: "groovesalad", Playlists: []station.Playlist{
+		{URL: "https://api.somafm.com/groovesalad256.pls", Format: "mp3", Quality: "highest"},
+		{URL: "https://api.somafm.com/groovesalad64.pls", Format: "aacp", Quality: "high"},
+	}}
+	ui := &UI{config: config.DefaultConfig()}
+
+	if got := ui.playlistURLFor(s); got != "" {
+		t.Errorf("playlistURLFor() without a choice = %q, want all playlists", got)
+	}
+	ui.config.SetStreamVariant(s.ID, "aacp-high")
+	if got, want := ui.playlistURLFor(s), "https://api.somafm.com/groovesalad64.pls"; got != want {
+		t.Errorf("playlistURLFor() = %q, want %q", got, want)
+	}
+	ui.config.SetStreamVariant(s.ID, "aac-low")
+	if got := ui.playlistURLFor(s); got != "" {
+		t.Errorf("playlistURLFor() with a variant no longer offered = %q, want all playlists", got)
+	}
+	ui.config.SetStreamVariant(s.ID, "")
+	if _, ok := ui.config.StreamVariants[s.ID]; ok {
+		t.Error("SetStreamVariant(\"\") should forget the choice")
+	}
+}
+
+func TestTransparentBackground(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := config.DefaultConfig()
+	cfg.Theme.Background = config.ColorTransparent
+
+	ui := NewUI(player.NewPlayer(), nil, cfg, false)
+	if ui.colors.background != tcell.ColorDefault || ui.colors.modalBackground != tcell.ColorDefault {
+		t.Errorf("backgrounds = %v, %v, want terminal default", ui.colors.background, ui.colors.modalBackground)
+	}
+	if tview.Styles.PrimitiveBackgroundColor != tcell.ColorDefault {
+		t.Errorf("PrimitiveBackgroundColor = %v, want terminal default", tview.Styles.PrimitiveBackgroundColor)
+	}
+
+	NewUI(player.NewPlayer(), nil, config.DefaultConfig(), false)
+	if tview.Styles.PrimitiveBackgroundColor != primitiveBackground {
+		t.Errorf("PrimitiveBackgroundColor = %v after an opaque theme, want %v", tview.Styles.PrimitiveBackgroundColor, primitiveBackground)
+	}
+}
+
+func TestAccentPulse(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	base := tcell.NewRGBColor(200, 100, 0)
+	var p accentPulse
+
+	p.observe("Artist - First", start)
+	if _, running := p.color(base, start); running {
+		t.Error("first track started a pulse")
+	}
+
+	p.observe("Artist - Second", start)
+	peak, running := p.color(base, start.Add(PulseDuration/2))
+	if !running {
+		t.Fatal("track change didn't start a pulse")
+	}
+	if r, g, b := peak.RGB(); r <= 200 || g <= 100 || b <= 0 {
+		t.Errorf("peak color = %d,%d,%d, want brighter than 200,100,0", r, g, b)
+	}
+
+	if got, running := p.color(base, start.Add(PulseDuration)); running || got != base {
+		t.Errorf("color after the pulse = %v, %v, want base color, stopped", got, running)
+	}
+	if got, _ := p.color(tcell.ColorDefault, start.Add(PulseDuration/2)); got != tcell.ColorDefault {
+		t.Errorf("default color pulsed to %v", got)
+	}
+}
+
+func TestCancelIdleStop(t *testing.T) {
+	ui := withFooter(&UI{app: tview.NewApplication(), config: config.DefaultConfig(), player: player.NewPlayer()})
+	ui.clock = clock.NewFake(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	if ui.cancelIdleStop() {
+		t.Error("cancelIdleStop() without a warning consumed the key")
+	}
+
+	ui.idleWarning = true
+	if !ui.cancelIdleStop() {
+		t.Fatal("cancelIdleStop() with a warning didn't consume the key")
+	}
+	if ui.idleWarning || ui.activeNotice() == "" {
+		t.Errorf("after cancel: warning = %v, notice = %q, want dismissed with a notice", ui.idleWarning, ui.activeNotice())
+	}
+
+	ui.config.IdleStop = time.Hour
+	ui.idleWarning = true
+	ui.checkIdleStop()
+	if ui.idleWarning {
+		t.Error("checkIdleStop() kept the warning while nothing plays")
+	}
+}
+
+func TestAnswerQualityFallback(t *testing.T) {
+	ui := &UI{app: tview.NewApplication(), config: config.DefaultConfig(), player: player.NewPlayer()}
+	ui.clock = clock.NewFake(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	s := &station.Station{ID: "groovesalad", Playlists: []station.Playlist{
+		{URL: "https://somafm.com/groovesalad256.pls", Format: "mp3", Quality: "highest"},
+		{URL: "https://somafm.com/groovesalad130.pls", Format: "mp3", Quality: "high"},
+	}}
+
+	key := tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone)
+	if ui.answerQualityFallback(key) {
+		t.Error("answerQualityFallback() consumed a key without a prompt")
+	}
+
+	ui.fallback.pending = &fallbackPrompt{station: s.ID, variant: "mp3-high", label: "128k"}
+	if ui.answerQualityFallback(tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone)) {
+		t.Error("answerQualityFallback() consumed an unrelated key")
+	}
+	if got := ui.playlistURLFor(s); got != "" {
+		t.Errorf("playlistURLFor() before answering = %q, want automatic", got)
+	}
+	if !ui.answerQualityFallback(key) {
+		t.Fatal("answerQualityFallback() didn't take y")
+	}
+	if ui.fallback.pending != nil {
+		t.Error("prompt still pending after y")
+	}
+	if got := ui.playlistURLFor(s); got != s.Playlists[1].URL {
+		t.Errorf("playlistURLFor() after y = %q, want the lower stream", got)
+	}
+
+	ui.config.SetStreamVariant(s.ID, "mp3-highest")
+	if got := ui.playlistURLFor(s); got != s.Playlists[0].URL {
+		t.Errorf("playlistURLFor() = %q, want the stream picked in the quality selector", got)
+	}
+}
+
+func TestStreamLabel(t *testing.T) {
+	high := player.StreamInfo{Format: "MP3", Bitrate: 256}
+	low := player.StreamInfo{Format: "MP3", Bitrate: 128}
+	aac := player.StreamInfo{Format: "AAC", Bitrate: 128}
+
+	if got := streamLabel(high, low); got != "256k" {
+		t.Errorf("streamLabel() = %q, want 256k", got)
+	}
+	if got := streamLabel(aac, high); got != "AAC 128k" {
+		t.Errorf("streamLabel() = %q, want AAC 128k", got)
+	}
+}
+
+// withFooter gives a bare UI the event bus and footer that notices go to.
+func withFooter(ui *UI) *UI {
+	ui.bus = newEventBus()
+	ui.footer = newFooterView(ui)
+	return ui
+}
+
+func TestCycleSortOrderKeepsSelection(t *testing.T) {
+	ui := newSnapshotUI(t)
+	ui.selectAndShowStation(3)
+	selected := ui.stations.selectedID
+
+	ui.globalInputHandler(tcell.NewEventKey(tcell.KeyRune, 'S', tcell.ModNone))
+	if ui.config.SortBy != config.SortTitle {
+		t.Fatalf("SortBy = %q, want %q", ui.config.SortBy, config.SortTitle)
+	}
+	if got := ui.stationService.GetStation(ui.stations.selectedIndex()); got == nil || got.ID != selected {
+		t.Errorf("selection after re-sort = %v, want %s", got, selected)
+	}
+	if got := ui.activeNotice(); got != "Sorted by title" {
+		t.Errorf("activeNotice() = %q, want %q", got, "Sorted by title")
+	}
+
+	for i := 1; i < ui.stationService.StationCount(); i++ {
+		prev, cur := ui.stationService.GetStation(i-1), ui.stationService.GetStation(i)
+		if strings.ToLower(prev.Title) > strings.ToLower(cur.Title) {
+			t.Fatalf("%q sorted before %q", prev.Title, cur.Title)
+		}
+	}
+}
+
+func TestFavoritesFirstResortsOnToggle(t *testing.T) {
+	ui := newSnapshotUI(t)
+	ui.config.SortBy = config.SortGenre
+	ui.cycleSortOrder()
+	if ui.config.SortBy != config.SortFavorites {
+		t.Fatalf("SortBy = %q, want %q", ui.config.SortBy, config.SortFavorites)
+	}
+	if got := ui.stationService.GetStation(0); got.ID != "dronezone" {
+		t.Fatalf("first station = %s, want the favorite dronezone", got.ID)
+	}
+
+	last := ui.stationService.StationCount() - 1
+	ui.selectAndShowStation(last)
+	id := ui.stations.selectedID
+	ui.toggleFavorite()
+
+	if got := ui.stationService.FindIndexByID(id); got > 1 {
+		t.Errorf("new favorite at index %d, want among the first two", got)
+	}
+	if got := ui.stationService.GetStation(ui.stations.selectedIndex()); got == nil || got.ID != id {
+		t.Errorf("selection after toggling favorite = %v, want %s", got, id)
+	}
+}
+
+func TestStartupText(t *testing.T) {
+	timings := []player.StartupTiming{
+		{Station: "groovesalad", Title: "Groove Salad", Total: 800 * time.Millisecond},
+		{Station: "dronezone", Title: "Drone Zone", Total: 2 * time.Second},
+		{Station: "groovesalad", Title: "Groove Salad", Total: 1200 * time.Millisecond, Buffer: 700 * time.Millisecond},
+	}
+
+	got := startupText(timings, &station.Station{ID: "groovesalad"}, "white")
+	for _, want := range []string{
+		"3 starts",
+		"p50 1.2s  p90 2s",
+		"Station:[-]    p50 800ms  p90 1.2s",
+		"Last:[-]       Groove Salad: 1.2s (playlist 0s, connect 0s, decode 0s, buffer 700ms)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("startupText() = %q, want it to contain %q", got, want)
+		}
+	}
+
+	if got := startupText(timings, &station.Station{ID: "lush"}, "white"); strings.Contains(got, "Station:") {
+		t.Errorf("startupText() for a station never started = %q, want no station line", got)
+	}
+}
+
+func TestExportLikes(t *testing.T) {
+	ui := newSnapshotUI(t)
+	ui.likes = newSnapshotLikes(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	ui.exportLikes(ui.likes.Tracks())
+
+	path := filepath.Join(home, fmt.Sprintf("somafm-likes-%s.csv", time.Now().Format("2006-01-02")))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[1], "Bonobo,Black Sands,") {
+		t.Errorf("export = %q, want a header and 3 tracks, newest first", data)
+	}
+	if got, want := ui.activeNotice(), "Exported 3 liked tracks to "+path; got != want {
+		t.Errorf("activeNotice() = %q, want %q", got, want)
+	}
+}
+
+func TestExportSession(t *testing.T) {
+	ui := newSnapshotUI(t)
+	ui.history = newSnapshotHistory(t)
+	ui.likes = newSnapshotLikes(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	ui.exportSession()
+
+	path := filepath.Join(home, "somafm-session-2026-03-15-0945.md")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.HasPrefix(string(data), "# Listening session, Sun Mar 15, 2026\n") || !strings.Contains(string(data), "| DEF CON Radio |") {
+		t.Errorf("export = %q, want the last session, on DEF CON Radio", data)
+	}
+	if got, want := ui.activeNotice(), "Exported this session to "+path; got != want {
+		t.Errorf("activeNotice() = %q, want %q", got, want)
+	}
+}
+
+func TestFilterPlays(t *testing.T) {
+	entries := []playlog.Entry{
+		{Artist: "Bonobo", Title: "Kiara", StationTitle: "Groove Salad"},
+		{Artist: "Stars of the Lid", Title: "Requiem for Dying Mothers", StationTitle: "Drone Zone"},
+		{Title: "Station ID", StationTitle: "Groove Salad"},
+	}
+	titles := func(entries []playlog.Entry) string {
+		var got []string
+		for _, e := range entries {
+			got = append(got, e.Title)
+		}
+		return strings.Join(got, ", ")
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "Station ID, Requiem for Dying Mothers, Kiara"},
+		{"groove", "Station ID, Kiara"},
+		{"BONOBO salad", "Kiara"},
+		{"bonobo drone", ""},
+	}
+	for _, tt := range tests {
+		if got := titles(filterPlays(entries, tt.query)); got != tt.want {
+			t.Errorf("filterPlays(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestPlayLogModal(t *testing.T) {
+	ui := newSnapshotUI(t)
+	ui.likes = newSnapshotLikes(t)
+	plays := playlog.New(filepath.Join(t.TempDir(), playlog.FileName))
+	start := time.Date(2026, 3, 14, 20, 0, 0, 0, time.UTC)
+	for i, track := range []string{"Bonobo - Kiara", "Boards of Canada - Roygbiv", "Tycho - Awake"} {
+		s := &station.Station{ID: "groovesalad", Title: "Groove Salad"}
+		if err := plays.Append(playlog.NewEntry(start.Add(time.Duration(i)*5*time.Minute), s, track)); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	ui.SetPlayLog(plays)
+
+	ui.globalInputHandler(tcell.NewEventKey(tcell.KeyRune, 'H', tcell.ModNone))
+	if !ui.modals.isOpen(modalPage) {
+		t.Fatal("play log not shown")
+	}
+	capture := ui.modals.pages.GetPage(modalPage).(*tview.Flex).GetInputCapture()
+
+	capture(tcell.NewEventKey(tcell.KeyRune, 'c', tcell.ModNone))
+	if got := string(ui.clipboard); got != "Tycho - Awake" {
+		t.Errorf("clipboard = %q, want the most recent track", got)
+	}
+
+	capture(tcell.NewEventKey(tcell.KeyRune, '/', tcell.ModNone))
+	search, ok := ui.app.GetFocus().(*tview.InputField)
+	if !ok {
+		t.Fatalf("focus = %T after /, want the search field", ui.app.GetFocus())
+	}
+	search.SetText("canada")
+	search.InputHandler()(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), func(tview.Primitive) {})
+
+	capture(tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone))
+	if got := ui.activeNotice(); got != "♥ Liked: Boards of Canada - Roygbiv" {
+		t.Errorf("activeNotice() = %q after liking the match", got)
+	}
+	if liked := ui.likes.Tracks()[0]; liked.Artist != "Boards of Canada" || liked.Station != "groovesalad" {
+		t.Errorf("liked %+v, want the searched track", liked)
+	}
+
+	capture(tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone))
+	if ui.modals.isOpen(modalPage) {
+		t.Error("play log still open after Esc")
+	}
+}
+
+func TestSimilarStations(t *testing.T) {
+	ui := newSnapshotUI(t)
+	groove := ui.stationService.GetStation(ui.stationService.FindIndexByID("groovesalad"))
+
+	similar := similarStations(groove, ui.stationService.GetCachedStations())
+	var ids []station.StationID
+	for _, s := range similar {
+		ids = append(ids, s.ID)
+	}
+	if want := []station.StationID{"defcon", "dronezone"}; !slices.Equal(ids, want) {
+		t.Fatalf("similarStations() = %v, want %v", ids, want)
+	}
+
+	strip := func(s string) string {
+		return regexp.MustCompile(`\[[^\]]*\]`).ReplaceAllString(s, "")
+	}
+	for _, tt := range []struct {
+		width int
+		want  string
+	}{
+		{80, "Similar: DEF CON Radio · Drone Zone"},
+		{30, "Similar: DEF CON Radio"},
+		{10, ""},
+	} {
+		if got := strip(similarText(similar, tt.width)); got != tt.want {
+			t.Errorf("similarText(width %d) = %q, want %q", tt.width, got, tt.want)
+		}
+	}
+
+	ui.recentStations = []station.StationID{"defcon", "groovesalad"}
+	if next, _ := ui.pickSimilar(groove); next.ID != "dronezone" {
+		t.Errorf("pickSimilar() = %s, want dronezone, skipping the recently played defcon", next.ID)
+	}
+	ui.recentStations = []station.StationID{"dronezone", "defcon"}
+	if next, _ := ui.pickSimilar(groove); next.ID != "defcon" {
+		t.Errorf("pickSimilar() = %s with every candidate recent, want the most similar", next.ID)
+	}
+}
+
+func TestScrubBar(t *testing.T) {
+	ui := newSnapshotUI(t)
+	strip := func(s string) string {
+		return regexp.MustCompile(`\[[^\]]*\]`).ReplaceAllString(s, "")
+	}
+
+	bar := strip(ui.scrubBar(0, 10*time.Second))
+	if !strings.HasSuffix(strings.SplitN(bar, "\n", 2)[0], "━━●") || !strings.HasSuffix(bar, "live") {
+		t.Errorf("scrubBar(live) = %q, want the marker at the right edge", bar)
+	}
+	if !strings.HasPrefix(bar, strings.Repeat("─", 26)) {
+		t.Errorf("scrubBar() = %q, want the unbuffered part dim", bar)
+	}
+
+	bar = strip(ui.scrubBar(player.TimeShiftWindow, player.TimeShiftWindow))
+	if !strings.HasPrefix(bar, "●━") || !strings.HasSuffix(bar, "30 s behind live") {
+		t.Errorf("scrubBar(oldest) = %q, want the marker at the left edge", bar)
+	}
+}
+
+func TestSearchCurrentTrack(t *testing.T) {
+	var opened []string
+	defer func(orig func(string) error) { openURL = orig }(openURL)
+	openURL = func(u string) error {
+		opened = append(opened, u)
+		return nil
+	}
+
+	ui := withFooter(&UI{player: player.NewPlayer(), config: config.DefaultConfig()})
+	ui.searchCurrentTrack()
+	if len(opened) != 0 {
+		t.Fatalf("opened %v without a track", opened)
+	}
+
+	ui.player.SetInitialTrack("Bonobo - Kiara")
+	ui.searchCurrentTrack()
+	if want := []string{"https://bandcamp.com/search?q=Bonobo+Kiara"}; !slices.Equal(opened, want) {
+		t.Errorf("opened %v, want %v", opened, want)
+	}
+	if got := ui.activeNotice(); got != "Searching for Bonobo - Kiara" {
+		t.Errorf("activeNotice() = %q", got)
+	}
+}
+
+func TestStreamMatrix(t *testing.T) {
+	s := &station.Station{ID: "groovesalad", Playlists: []station.Playlist{
+		{URL: "https://api.somafm.com/groovesalad130.pls", Format: "mp3", Quality: "high"},
+		{URL: "https://api.somafm.com/groovesalad256.pls", Format: "mp3", Quality: "highest"},
+		{URL: "https://api.somafm.com/groovesalad64.pls", Format: "aac", Quality: "low"},
+		{URL: "https://api.somafm.com/groovesalad-alt.pls", Format: "mp3", Quality: "high"},
+	}}
+
+	m := newStreamMatrix(s)
+	if !slices.Equal(m.formats, []string{"mp3", "aac"}) {
+		t.Errorf("formats = %v, want [mp3 aac]", m.formats)
+	}
+	if !slices.Equal(m.qualities, []string{"highest", "high", "low"}) {
+		t.Errorf("qualities = %v, want best first", m.qualities)
+	}
+	if got := m.playlists[[2]int{0, 1}].URL; got != s.Playlists[0].URL {
+		t.Errorf("mp3/high = %q, want the first playlist listed", got)
+	}
+	if _, ok := m.playlists[[2]int{1, 0}]; ok {
+		t.Error("aac/highest should be empty")
+	}
+
+	ok := &player.StreamProbe{Info: player.StreamInfo{Format: "AAC", Bitrate: 64, SampleRate: 44100}, Latency: 120 * time.Millisecond}
+	failed := &player.StreamProbe{Err: errors.New("HTTP 404 Not Found")}
+	tests := []struct {
+		saved, playing bool
+		probe          *player.StreamProbe
+		want           string
+	}{
+		{false, false, nil, "  …"},
+		{true, false, ok, "● 64k [green]✓[-]"},
+		{true, true, failed, "➤ [red]✗[-]"},
+	}
+	for _, tt := range tests {
+		if got := streamCellText(tt.saved, tt.playing, tt.probe); got != tt.want {
+			t.Errorf("streamCellText(%v, %v) = %q, want %q", tt.saved, tt.playing, got, tt.want)
+		}
+	}
+	if got := streamDetail(s.Playlists[2], ok); got != "groovesalad64.pls [::d]AAC 64k 44.1kHz, answered in 120ms[::-]" {
+		t.Errorf("streamDetail() = %q", got)
+	}
+}
+
+func TestApplyTheme(t *testing.T) {
+	ui := newSnapshotUI(t)
+	t.Setenv("HOME", t.TempDir())
+	ui.stations.filterInput.SetText("drone")
+	ui.stations.selectStation(ui.stationService.FindIndexByID("dronezone"))
+
+	ui.applyTheme(config.ThemeLight)
+
+	light, _ := config.ThemePreset(config.ThemeLight)
+	if ui.config.Theme != light {
+		t.Errorf("config theme = %+v, want the light preset", ui.config.Theme)
+	}
+	if want := config.GetColor(light.Background); ui.colors.background != want {
+		t.Errorf("background = %v, want %v", ui.colors.background, want)
+	}
+	if ui.stations.filterQuery != "drone" {
+		t.Errorf("filterQuery = %q, want the filter kept", ui.stations.filterQuery)
+	}
+	if s := ui.stationService.GetStation(ui.stations.selectedIndex()); s == nil || s.ID != "dronezone" {
+		t.Errorf("selection = %v, want dronezone", s)
+	}
+	if got := ui.activeNotice(); got != "Theme: light" {
+		t.Errorf("activeNotice() = %q", got)
+	}
+}
+
+func TestPruneRecordingsOnStart(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	for name, age := range map[string]time.Duration{"Old.mp3": 10 * 24 * time.Hour, "Recent.mp3": time.Hour} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("audio"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Recording.KeepDays = 7
+	ui := withFooter(&UI{player: player.NewPlayer(), config: cfg, clock: clock.NewFake(now)})
+	if !ui.pruneRecordings(dir) {
+		t.Fatal("pruneRecordings() = false, want room to record")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Old.mp3")); !os.IsNotExist(err) {
+		t.Error("recording older than keep_days should be deleted")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Recent.mp3")); err != nil {
+		t.Errorf("recent recording should be kept: %v", err)
+	}
+	if got := ui.activeNotice(); got != "Deleted 1 old recording to stay within limits" {
+		t.Errorf("activeNotice() = %q", got)
+	}
+}
+
+func TestAutoTheme(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	defer func(orig func(time.Duration) termbg.Background) { detectBackground = orig }(detectBackground)
+	detectBackground = func(time.Duration) termbg.Background { return termbg.Light }
+
+	ui := NewUI(player.NewPlayer(), nil, config.DefaultConfig(), false)
+	light, _ := config.ThemePreset(config.ThemeLight)
+	if want := config.GetColor(light.Background); ui.colors.background != want {
+		t.Errorf("background on a light terminal = %v, want %v", ui.colors.background, want)
+	}
+	if !ui.config.Theme.IsAuto() {
+		t.Error("config should keep the auto theme")
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Theme, _ = config.ThemePreset(config.ThemeGruvbox)
+	ui = NewUI(player.NewPlayer(), nil, cfg, false)
+	if want := config.GetColor(cfg.Theme.Background); ui.colors.background != want {
+		t.Errorf("background with a chosen preset = %v, want %v", ui.colors.background, want)
+	}
+}
+
+func TestTruncateWidth(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"Groove Salad", 20, "Groove Salad"},
+		{"Groove Salad", 10, "Groove..."},
+		{"坂本龍一 - Merry Christmas", 10, "坂本龍..."},
+		{"坂本龍一", 8, "坂本龍一"},
+		{"坂本龍一", 7, "坂本..."},
+		{"🎧🎧🎧🎧 mix", 8, "🎧🎧..."},
+		{"👩‍🎤👩‍🎤👩‍🎤", 5, "👩‍🎤..."},
+		{"Groove Salad", 2, ".."},
+	}
+	for _, tt := range tests {
+		got := truncateWidth(tt.in, tt.width)
+		if got != tt.want {
+			t.Errorf("truncateWidth(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+		}
+		if w := tview.TaggedStringWidth(got); w > tt.width {
+			t.Errorf("truncateWidth(%q, %d) is %d cells wide", tt.in, tt.width, w)
+		}
+	}
+}
+
+func TestTour(t *testing.T) {
+	ui := newSnapshotUI(t)
+	t.Setenv("HOME", t.TempDir())
+	ui.showTour()
+	if !ui.modals.isOpen(modalPage) {
+		t.Fatal("tour not shown")
+	}
+
+	overlay := ui.modals.pages.GetPage(modalPage).(*tourOverlay)
+	capture := overlay.GetInputCapture()
+	capture(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+	capture(tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModNone))
+	if overlay.step != 0 {
+		t.Errorf("step = %d after next and back, want 0", overlay.step)
+	}
+	for range tourSteps {
+		capture(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+	}
+
+	if ui.modals.isOpen(modalPage) {
+		t.Error("tour still open after the last step")
+	}
+	if !ui.config.TourShown {
+		t.Error("TourShown not set")
+	}
+	if got := ui.activeNotice(); got != "Press ? any time to see every key" {
+		t.Errorf("activeNotice() = %q", got)
+	}
+}
+
+func TestOutputWatch(t *testing.T) {
+	var watched context.Context
+	var onLost func(outputdev.Output)
+	watchOutput = func(ctx context.Context, f func(outputdev.Output)) error {
+		watched, onLost = ctx, f
+		return nil
+	}
+	t.Cleanup(func() { watchOutput = outputdev.Watch })
+
+	ui := withFooter(&UI{app: tview.NewApplication(), config: config.DefaultConfig(), player: player.NewPlayer()})
+	ui.startOutputWatch()
+	if watched != nil {
+		t.Fatal("startOutputWatch() watched with pause_on_disconnect off")
+	}
+
+	ui.config.Audio.PauseOnDisconnect = true
+	ui.startOutputWatch()
+	if watched == nil || onLost == nil {
+		t.Fatal("startOutputWatch() didn't watch")
+	}
+
+	ui.pauseForLostOutput(outputdev.Output{Name: "Headphones"})
+	if got := ui.activeNotice(); got != "" {
+		t.Errorf("notice %q while nothing plays", got)
+	}
+
+	// Playing another station recreates the update channel; the watch
+	// has to outlive that and end only with the UI
+	ui.safeCloseChannel()
+	ui.recreateStopChannel()
+	if watched.Err() != nil {
+		t.Error("watch canceled by a station change")
+	}
+	ui.stopOutputWatch()
+	if watched.Err() == nil {
+		t.Error("watch not canceled when the UI stopped")
+	}
+}
+
+// clickAt sends a left click at the first cell showing text on the screen
+// rendered from ui's pages.
+func clickAt(t *testing.T, ui *UI, p tview.Primitive, text string) {
+	t.Helper()
+	screen := renderSnapshot(t, ui.modals.pages, 100, 40)
+	for y, line := range strings.Split(screen, "\n") {
+		if i := strings.Index(line, text); i >= 0 {
+			x := utf8.RuneCountInString(line[:i])
+			p.MouseHandler()(tview.MouseLeftClick, tcell.NewEventMouse(x, y, tcell.Button1, tcell.ModNone), func(tview.Primitive) {})
+			return
+		}
+	}
+	t.Fatalf("%q not on screen", text)
+}
+
+func TestMouse(t *testing.T) {
+	ui := newSnapshotUI(t)
+	t.Setenv("HOME", t.TempDir())
+
+	clickAt(t, ui, ui.footer.box, "? help")
+	if !ui.modals.isOpen(modalPage) {
+		t.Fatal("clicking the ? hint didn't open the help")
+	}
+
+	// With a modal open, a click on a row selects it without playing
+	var clicked []int
+	subscribe(ui.bus, func(e stationClicked) { clicked = append(clicked, e.index) })
+	clickAt(t, ui, ui.stations.table, "DEF CON Radio")
+	want := ui.stationService.FindIndexByID("defcon")
+	if !slices.Equal(clicked, []int{want}) || ui.stations.selectedIndex() != want {
+		t.Errorf("clicked = %v, selected = %d, want %d", clicked, ui.stations.selectedIndex(), want)
+	}
+	if ui.playingStationID != "" {
+		t.Errorf("playing %q under a modal", ui.playingStationID)
+	}
+	ui.modals.close(modalPage)
+
+	volume := ui.currentVolume
+	scroll := func(x, y int) {
+		event := tcell.NewEventMouse(x, y, tcell.WheelDown, tcell.ModNone)
+		ui.panel.root.MouseHandler()(tview.MouseScrollDown, event, func(tview.Primitive) {})
+	}
+	scroll(0, 0)
+	if ui.currentVolume != volume {
+		t.Errorf("scrolling outside the volume bar changed the volume to %d", ui.currentVolume)
+	}
+	x, y, _, _ := ui.panel.volumeView.GetRect()
+	scroll(x+1, y+1)
+	if ui.currentVolume != volume-VolumeStep {
+		t.Errorf("volume after scrolling down = %d, want %d", ui.currentVolume, volume-VolumeStep)
+	}
+}
+
+func TestQuickSelect(t *testing.T) {
+	ui := newSnapshotUI(t)
+	t.Setenv("HOME", t.TempDir())
+	drone := ui.stationService.FindIndexByID("dronezone")
+	defcon := ui.stationService.FindIndexByID("defcon")
+
+	if got := ui.stations.quickSelectIndexes(); !slices.Equal(got, []int{drone}) {
+		t.Errorf("quickSelectIndexes() = %v, want the favorite %d", got, drone)
+	}
+	ui.globalInputHandler(tcell.NewEventKey(tcell.KeyRune, '2', tcell.ModNone))
+	if got := ui.activeNotice(); got != "No favorite 2 — press f to add one" {
+		t.Errorf("activeNotice() = %q", got)
+	}
+
+	// A new favorite gets the next number, shown in its row
+	ui.stations.selectStation(defcon)
+	ui.toggleFavorite()
+	if got := ui.stations.quickSelectIndexes(); !slices.Equal(got, []int{drone, defcon}) {
+		t.Errorf("quickSelectIndexes() = %v, want %v", got, []int{drone, defcon})
+	}
+	if got := ui.stations.table.GetCell(ui.stations.rowForStationIndex(defcon), 0).Text; !strings.Contains(got, "]2[") {
+		t.Errorf("DEF CON Radio lead cell = %q, want badge 2", got)
+	}
+
+	ui.config.QuickSelect = config.QuickSelectRows
+	if got := ui.stations.quickSelectIndexes(); !slices.Equal(got, ui.stations.visible) {
+		t.Errorf("quickSelectIndexes() = %v, want the rows %v", got, ui.stations.visible)
+	}
+}
+
+func TestGraphicsLogo(t *testing.T) {
+	var sent []string
+	r := &graphicsRenderer{protocol: termimg.Kitty, cellWidth: 8, cellHeight: 16}
+	r.out = func(b []byte) { sent = append(sent, string(b)) }
+
+	view := r.newView(palette{background: tcell.ColorBlack})
+	view.setImage(image.NewRGBA(image.Rect(0, 0, 16, 32)))
+	view.SetRect(2, 1, 4, 3)
+
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer screen.Fini()
+	screen.SetSize(20, 10)
+	draw := func(cover bool) {
+		sent = nil
+		screen.Clear()
+		view.Draw(screen)
+		if cover {
+			screen.SetContent(3, 2, 'x', nil, tcell.StyleDefault)
+		}
+		r.flush(screen)
+	}
+
+	draw(false)
+	if len(sent) != 1 || !strings.HasPrefix(sent[0], "\x1b7\x1b[2;3H\x1b_Ga=T") {
+		t.Fatalf("first frame sent %q, want the logo at row 2, column 3", sent)
+	}
+	draw(false)
+	if len(sent) != 0 {
+		t.Errorf("unchanged frame sent %q again", sent)
+	}
+
+	// A dialog over the logo takes it down until it closes
+	draw(true)
+	if len(sent) != 1 || sent[0] != string(termimg.Clear(termimg.Kitty)) {
+		t.Errorf("covered frame sent %q, want the logo removed", sent)
+	}
+	draw(false)
+	if len(sent) != 1 || !strings.Contains(sent[0], "\x1b_Ga=T") {
+		t.Errorf("uncovered frame sent %q, want the logo again", sent)
+	}
+
+	// Too little room: the terminal would draw over the next view
+	view.SetRect(2, 1, 4, 1)
+	draw(false)
+	if len(sent) != 1 || sent[0] != string(termimg.Clear(termimg.Kitty)) {
+		t.Errorf("clipped frame sent %q, want the logo removed", sent)
+	}
+}
+
+func TestASCIIArt(t *testing.T) {
+	// White on the left, transparent on the right
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 10; x++ {
+			img.Set(x, y, color.White)
+		}
+	}
+
+	lines := asciiArt(img, 30, 5, true)
+	if len(lines) != 5 {
+		t.Fatalf("asciiArt() = %d lines, want 5", len(lines))
+	}
+	for _, line := range lines {
+		if line != "@@@@@     " {
+			t.Errorf("line = %q, want dense then empty", line)
+		}
+	}
+	// On a light background, white is what doesn't stand out
+	if line := asciiArt(img, 10, 5, false)[0]; line != "          " {
+		t.Errorf("light line = %q, want empty", line)
+	}
+
+	// Gray between two levels dithers into a mix of both
+	gray := image.NewGray(image.Rect(0, 0, 20, 20))
+	for i := range gray.Pix {
+		gray.Pix[i] = 100
+	}
+	art := strings.Join(asciiArt(gray, 10, 5, true), "")
+	if strings.Count(art, art[:1]) == len(art) {
+		t.Errorf("gray = %q, want more than one character", art)
+	}
+}
+
+func TestTextLogo(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer screen.Fini()
+	screen.SetSize(20, 10)
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	tests := []struct {
+		mode  string
+		ascii bool
+	}{
+		// The simulation screen has 256 colors
+		{config.CoverArtAuto, true},
+		{config.CoverArtASCII, true},
+		{config.CoverArtBlocks, false},
+	}
+	for _, tt := range tests {
+		view := textRenderer{mode: tt.mode}.newView(palette{background: tcell.ColorBlack, foreground: tcell.ColorWhite})
+		view.setImage(img)
+		view.SetRect(0, 0, 4, 2)
+		screen.Clear()
+		view.Draw(screen)
+		if ch, _, _, _ := screen.GetContent(0, 0); (ch == '@') != tt.ascii {
+			t.Errorf("%s: drew %q, ASCII art %v", tt.mode, ch, tt.ascii)
+		}
+	}
+
+	if img, err := (textRenderer{mode: config.CoverArtOff}).load(nil, "http://example.com/logo.png", 4, 2); img != nil || err != nil {
+		t.Errorf("off load() = %v, %v, want nothing", img, err)
+	}
+}
+
+func TestVisualizer(t *testing.T) {
+	ui := newSnapshotUI(t)
+	t.Setenv("HOME", t.TempDir())
+
+	ui.toggleVisualizer()
+	if !ui.config.Visualizer || ui.panel.meterRows() != 1 {
+		t.Fatal("toggleVisualizer() didn't show the meter")
+	}
+	if got := ui.activeNotice(); got != "Visualizer on" {
+		t.Errorf("notice = %q", got)
+	}
+
+	// Up at once, down a step per frame
+	ui.panel.meter.update(player.Levels{RMS: [2]float64{1, 1}})
+	if ui.panel.meter.level != [2]float64{0, 0} {
+		t.Errorf("level = %v, want 0 dBFS", ui.panel.meter.level)
+	}
+	ui.updateVisualizer()
+	if ui.panel.meter.level != [2]float64{-meterFall, -meterFall} {
+		t.Errorf("level = %v after silence, want %v", ui.panel.meter.level, -meterFall)
+	}
+
+	ui.toggleVisualizer()
+	if ui.config.Visualizer || ui.panel.meterRows() != 0 {
+		t.Error("toggleVisualizer() didn't hide the meter")
+	}
+	if ui.panel.meter.level != [2]float64{meterFloor, meterFloor} {
+		t.Errorf("level = %v after hiding, want the floor", ui.panel.meter.level)
+	}
+}
+
+func TestTrackNote(t *testing.T) {
+	ui := newSnapshotUI(t)
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	store, err := history.Open(filepath.Join(dir, history.FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ui.history = store
+	ui.config.History.MinListen = 30 * time.Second
+
+	ui.showNoteInput()
+	if ui.modals.isOpen(modalPage) {
+		t.Fatal("note input opened with nothing playing")
+	}
+
+	// The track ends while the note is typed
+	ui.player.SetInitialTrack("Bonobo - Kiara")
+	ui.showNoteInput()
+	input, ok := ui.app.GetFocus().(*tview.InputField)
+	if !ui.modals.isOpen(modalPage) || !ok {
+		t.Fatal("showNoteInput() didn't focus an input")
+	}
+	input.SetText("from that movie")
+	input.InputHandler()(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), func(tview.Primitive) {})
+	if ui.modals.isOpen(modalPage) {
+		t.Error("Enter didn't close the note input")
+	}
+	if got := ui.activeNotice(); got != "The track changed — note not saved" {
+		t.Errorf("activeNotice() = %q", got)
+	}
+
+	// A noted track is kept even when it played too briefly to count
+	start := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	s := ui.stationService.GetStation(0)
+	ui.recordListen(player.TrackListen{Station: s, Track: "Skipped", Start: start, End: start.Add(5 * time.Second)})
+	ui.recordListen(player.TrackListen{Station: s, Track: "Bonobo - Kiara", Start: start, End: start.Add(5 * time.Second), Note: "from that movie"})
+	entries := store.Entries()
+	if len(entries) != 1 || entries[0].Note != "from that movie" {
+		t.Fatalf("history = %+v, want only the noted track", entries)
+	}
+	if found, _ := store.Search("movie", 0); len(found) != 1 {
+		t.Errorf("Search(movie) found %d entries, want 1", len(found))
+	}
+}
+
+type panickingBox struct{ *tview.Box }
+
+func (panickingBox) Draw(tcell.Screen) { panic("bad draw") }
+
+func TestGuardRecordsCrash(t *testing.T) {
+	ui := newSnapshotUI(t)
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatal(err)
+	}
+	screen.SetSize(80, 24)
+
+	guardedRoot{Primitive: panickingBox{tview.NewBox()}, ui: ui}.Draw(screen)
+	if ui.crash != "bad draw" {
+		t.Errorf("crash = %v, want the draw panic", ui.crash)
+	}
+
+	// Only the first panic is kept
+	ui.guard(func() { panic("later") })
+	if ui.crash != "bad draw" {
+		t.Errorf("crash = %v after a second panic, want the first", ui.crash)
+	}
+}
+
+func TestRecoverFromCrash(t *testing.T) {
+	ui := newSnapshotUI(t)
+	t.Setenv("HOME", t.TempDir())
+	ui.crash = "bad draw"
+	ui.selectAndShowStation(2)
+	shown := ui.panel.station
+
+	var out strings.Builder
+	ui.console = newConsole(strings.NewReader("x\nr\n"), &out)
+	if !ui.recoverFromCrash() {
+		t.Fatal("recoverFromCrash() quit, want a restart")
+	}
+	if ui.panel.station != shown {
+		t.Error("restart didn't keep the shown station")
+	}
+	if !strings.Contains(out.String(), "The interface crashed: bad draw") {
+		t.Errorf("output = %q, want the crash reported", out.String())
+	}
+
+	// After a panic that got past tview only line mode is left
+	ui.appBroken = true
+	out.Reset()
+	ui.console = newConsole(strings.NewReader("r\nl\n+\nr\nq\n"), &out)
+	volume := ui.currentVolume
+	if ui.recoverFromCrash() {
+		t.Error("recoverFromCrash() restarted a broken app")
+	}
+	if ui.currentVolume != volume+VolumeStep || ui.config.Volume != ui.currentVolume {
+		t.Errorf("volume = %d, saved %d, want %d", ui.currentVolume, ui.config.Volume, volume+VolumeStep)
+	}
+	if strings.Contains(out.String(), "restart the interface") {
+		t.Errorf("output = %q offers a restart", out.String())
+	}
+}
+
+func TestConsoleReadLine(t *testing.T) {
+	c := newConsole(strings.NewReader(" l \n"), io.Discard)
+	if line, ok := c.readLine(nil); line != "l" || !ok {
+		t.Errorf("readLine() = %q, %v, want l", line, ok)
+	}
+	if _, ok := c.readLine(nil); ok {
+		t.Error("readLine() at the end of input reported a line")
+	}
+
+	r, w := io.Pipe()
+	defer w.Close()
+	shutdown := make(chan struct{})
+	close(shutdown)
+	if _, ok := newConsole(r, io.Discard).readLine(shutdown); ok {
+		t.Error("readLine() after shutdown reported a line")
+	}
+}
+
+func TestBufferGraph(t *testing.T) {
+	now := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	samples := []player.HealthSample{
+		{Time: now.Add(-61 * time.Second), Buffer: 100},
+		{Time: now.Add(-5 * time.Second), Buffer: 100},
+		{Time: now.Add(-4 * time.Second), Buffer: 10},
+		{Time: now.Add(-3 * time.Second), Buffer: 0, Dropout: true},
+		{Time: now.Add(-1 * time.Second), Buffer: 50},
+		{Time: now, Buffer: 100},
+	}
+
+	got := formatBufferGraph(samples, now)
+	want := strings.Repeat(" ", bufferGraphCells-3) + "▁[red]![-]▄"
+	if got != want {
+		t.Errorf("formatBufferGraph() = %q, want %q", got, want)
+	}
+	if w := tview.TaggedStringWidth(got); w != bufferGraphCells {
+		t.Errorf("graph width = %d, want %d", w, bufferGraphCells)
+	}
+
+	ui := newSnapshotUI(t)
+	t.Setenv("HOME", t.TempDir())
+	ui.toggleBufferGraph()
+	if !ui.config.BufferGraph || !ui.statusRenderer.bufferGraph || ui.activeNotice() != "Buffer graph on" {
+		t.Errorf("after toggle: config %v, renderer %v, notice %q", ui.config.BufferGraph, ui.statusRenderer.bufferGraph, ui.activeNotice())
+	}
+}
+
+func TestFormatComparison(t *testing.T) {
+	c := player.Comparison{
+		A: player.ComparisonSide{Info: player.StreamInfo{Format: "MP3", Bitrate: 128}, Bitrate: 131, Buffer: 92},
+		B: player.ComparisonSide{Info: player.StreamInfo{Format: "MP3", Bitrate: 256}, Buffer: 100},
+	}
+	if got, want := formatComparison(c), "▶A MP3 128k (rx 131k) 92% │  B MP3 256k 100%"; got != want {
+		t.Errorf("formatComparison() = %q, want %q", got, want)
+	}
+	c.HearingB = true
+	if got := formatComparison(c); !strings.HasPrefix(got, " A") || !strings.Contains(got, "▶B") {
+		t.Errorf("formatComparison() hearing B = %q", got)
+	}
+
+	ui := newSnapshotUI(t)
+	ui.switchComparison()
+	if got := ui.activeNotice(); !strings.HasPrefix(got, "Nothing to compare") {
+		t.Errorf("switchComparison() without a comparison: notice %q", got)
+	}
+}
+
+func TestRetiredStation(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var dropped atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if dropped.Load() {
+			_, _ = w.Write([]byte(`{"channels": [{"id": "defcon", "title": "DEF CON Radio", "genre": "electronic|ambient"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"channels": [
+			{"id": "groovesalad", "title": "Groove Salad", "genre": "ambient|electronic", "listeners": "100"},
+			{"id": "defcon", "title": "DEF CON Radio", "genre": "electronic|ambient", "listeners": "50"}]}`))
+	}))
+	defer server.Close()
+
+	stationService := service.NewStationService(api.NewSomaFMClientWithBaseURL(server.URL), service.CacheDisabled)
+	if _, err := stationService.GetStations(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ui := NewUI(player.NewPlayer(), stationService, config.DefaultConfig(), false)
+	ui.setupUI()
+	ui.selectAndShowStation(0)
+	ui.playingIndex = 0
+	ui.playingStationID = "groovesalad"
+	stationService.Pin(ui.playingStationID)
+
+	// The retired station sorts below DEF CON, which has listeners now
+	dropped.Store(true)
+	refreshed := make(chan struct{}, 1)
+	stationService.StartPeriodicRefresh(time.Millisecond, func([]station.Station) {
+		select {
+		case refreshed <- struct{}{}:
+		default:
+		}
+	})
+	<-refreshed
+	stationService.StopPeriodicRefresh()
+	ui.refreshStationTable()
+
+	if ui.playingIndex != stationService.FindIndexByID("groovesalad") || ui.playingIndex < 0 {
+		t.Fatalf("playingIndex = %d, want the retired station's new index", ui.playingIndex)
+	}
+	row := ui.stations.rowForStationIndex(ui.playingIndex)
+	if cell := ui.stations.table.GetCell(row, 2); row < 0 || !strings.Contains(cell.Text, "(retired)") {
+		t.Errorf("row %d = %q, want the retired badge", row, cell.Text)
+	}
+	if !strings.Contains(ui.panel.nameView.GetText(false), "(retired)") {
+		t.Errorf("panel station = %q, want the retired badge", ui.panel.nameView.GetText(false))
+	}
+	if notice := ui.activeNotice(); !strings.Contains(notice, "Groove Salad was removed") {
+		t.Errorf("notice = %q, want the station's removal", notice)
+	}
+
+	ui.showError(errors.New("reconnection failed"))
+	if ui.modals.isOpen(errorModalPage) {
+		t.Error("error modal shown for a retired station")
+	}
+	if notice := ui.activeNotice(); !strings.Contains(notice, "press y for DEF CON Radio") {
+		t.Errorf("notice = %q, want a similar station to switch to", notice)
+	}
+}
+
+func TestAutoStop(t *testing.T) {
+	start := time.Date(2026, 1, 1, 22, 0, 0, 0, time.UTC)
+	var s autoStopState
+	// play ticks every second from..to and returns when each action came
+	play := func(from, to time.Duration, playing bool) map[autoStopAction]time.Duration {
+		seen := make(map[autoStopAction]time.Duration)
+		for at := from; at <= to; at += time.Second {
+			if action := s.advance(start.Add(at), playing, time.Hour); action != autoStopNone {
+				seen[action] = at
+			}
+		}
+		return seen
+	}
+
+	// A station switch or a short pause doesn't break the session
+	play(0, 10*time.Minute, true)
+	play(10*time.Minute, 10*time.Minute+30*time.Second, false)
+	seen := play(10*time.Minute+31*time.Second, time.Hour, true)
+	if at, ok := seen[autoStopWarn]; !ok || at != 55*time.Minute {
+		t.Errorf("warning at %v, %v; want once five minutes before", at, ok)
+	}
+	if at, ok := seen[autoStopNow]; !ok || at != time.Hour {
+		t.Errorf("stop at %v, %v; want an hour in", at, ok)
+	}
+
+	// A long enough break starts a new session
+	s = autoStopState{}
+	play(0, 50*time.Minute, true)
+	play(50*time.Minute, 52*time.Minute, false)
+	if seen := play(52*time.Minute, 70*time.Minute, true); len(seen) != 0 {
+		t.Errorf("actions after a break = %v, want a new session", seen)
+	}
+}
+
+func TestHyperlink(t *testing.T) {
+	url := "https://somafm.com/groovesalad/"
+	if got, want := hyperlink(url, "Groove [Salad]", "skyblue", true), "[skyblue:::"+url+"]Groove [Salad[][-:::-]"; got != want {
+		t.Errorf("hyperlink() = %q, want %q", got, want)
+	}
+	if got := hyperlink(url, "Groove Salad", "skyblue", false); strings.Contains(got, url) {
+		t.Errorf("hyperlink() turned off = %q, want no link", got)
+	}
+
+	// The link survives into the screen cell's style, which tcell turns
+	// into OSC 8 for terminals that support it
+	view := tview.NewTextView().SetDynamicColors(true).SetText(hyperlink(url, "Groove Salad", "skyblue", true))
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer screen.Fini()
+	screen.SetSize(20, 1)
+	view.SetRect(0, 0, 20, 1)
+	view.Draw(screen)
+	// Style has no getter for the link, but setting it again changes
+	// nothing if it is there
+	if _, style, _ := screen.Get(0, 0); style.Url(url) != style {
+		t.Errorf("cell style has no link to %s", url)
+	}
+}
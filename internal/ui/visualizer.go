@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"math"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/glebovdev/somafm-cli/internal/player"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// meterFloor is the quietest level the meter shows, in dBFS.
+	meterFloor = -48.0
+	// meterFall is how far the meter drops per frame, so it sinks back
+	// smoothly rather than flickering between frames.
+	meterFall = 3.0 // dB
+	// Levels above meterWarn are drawn yellow, above meterHot red.
+	meterWarn = -12.0
+	meterHot  = -3.0
+)
+
+// vuMeter is the visualizer: a level meter one row high, the upper half
+// of its cells showing the left channel and the lower half the right.
+type vuMeter struct {
+	level [2]float64 // dBFS, as shown
+}
+
+func (m *vuMeter) reset() {
+	m.level = [2]float64{meterFloor, meterFloor}
+}
+
+// update moves the meter to the RMS of l: up at once, down by at most
+// meterFall.
+func (m *vuMeter) update(l player.Levels) {
+	for ch := range 2 {
+		m.level[ch] = max(amplitudeDB(l.RMS[ch]), m.level[ch]-meterFall, meterFloor)
+	}
+}
+
+// amplitudeDB converts an amplitude from 0 to 1 to dBFS.
+func amplitudeDB(amplitude float64) float64 {
+	if amplitude <= 0 {
+		return math.Inf(-1)
+	}
+	return 20 * math.Log10(amplitude)
+}
+
+// draw fills width cells from x, y: each cell stands for an equal step
+// from meterFloor to 0 dBFS.
+func (m *vuMeter) draw(screen tcell.Screen, x, y, width int, colors palette) {
+	for i := range width {
+		threshold := meterFloor - meterFloor*float64(i+1)/float64(width)
+		left, right := m.level[0] >= threshold, m.level[1] >= threshold
+
+		color := colors.highlight
+		switch {
+		case threshold > meterHot:
+			color = tcell.ColorRed
+		case threshold > meterWarn:
+			color = tcell.ColorYellow
+		}
+		style := tcell.StyleDefault.Foreground(color).Background(colors.background)
+
+		ch := '█'
+		switch {
+		case left && !right:
+			ch = '▀'
+		case right && !left:
+			ch = '▄'
+		case !left && !right:
+			ch = '·'
+			style = style.Foreground(colors.borders)
+		}
+		screen.SetContent(x+i, y, ch, nil, style)
+	}
+}
+
+// updateVisualizer feeds the levels decoded since the last frame to the
+// meter. It runs with the playing animation, ten times a second.
+func (ui *UI) updateVisualizer() {
+	if !ui.config.Visualizer {
+		return
+	}
+	ui.panel.meter.update(ui.player.Levels())
+}
+
+// toggleVisualizer shows or hides the level meter. The player only
+// measures the audio while it's shown.
+func (ui *UI) toggleVisualizer() {
+	on := !ui.config.Visualizer
+	ui.config.Visualizer = on
+	ui.requestConfigSave()
+	ui.player.SetMetering(on)
+	ui.panel.setVisualizer(on)
+
+	if on {
+		ui.showNotice("Visualizer on")
+	} else {
+		ui.showNotice("Visualizer off")
+	}
+	log.Debug().Bool("visualizer", on).Msg("Toggled visualizer")
+}
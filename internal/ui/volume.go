@@ -2,6 +2,8 @@ package ui
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/glebovdev/somafm-cli/internal/config"
@@ -9,95 +11,76 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-func (ui *UI) buildVolumeBar(container *tview.Flex) {
-	const barHeight = 10
-
-	ui.mu.Lock()
-	displayVolume := ui.currentVolume
-	isMuted := ui.isMuted
-	if isMuted {
-		displayVolume = ui.config.Volume
-	}
-	ui.mu.Unlock()
-
-	filledLines := (displayVolume * barHeight) / 100
-	emptyLines := barHeight - filledLines
-
-	createText := func(text string, color tcell.Color) *tview.TextView {
-		tv := tview.NewTextView()
-		tv.SetText(text)
-		tv.SetTextAlign(tview.AlignRight)
-		tv.SetTextColor(color)
-		tv.SetBackgroundColor(ui.colors.background)
-		return tv
-	}
-
-	createBarLine := func(barText string, barColor tcell.Color, showPercent bool) *tview.Flex {
-		line := tview.NewFlex().SetDirection(tview.FlexColumn)
-		line.SetBackgroundColor(ui.colors.background)
-
-		if showPercent {
-			percentText := fmt.Sprintf("%d%%", displayVolume)
-
-			var percentColor tcell.Color
-			if isMuted {
-				percentColor = config.GetColor(ui.config.Theme.MutedVolume)
-			} else {
-				percentColor = ui.colors.highlight
-			}
-
-			percentView := createText(percentText, percentColor)
-			percentView.SetTextAlign(tview.AlignRight)
-
-			if isMuted {
-				percentView.SetTextStyle(tcell.StyleDefault.
-					Foreground(percentColor).
-					Background(ui.colors.background).
-					Attributes(tcell.AttrStrikeThrough))
-			}
-
-			line.AddItem(percentView, 4, 0, false)
-		} else {
-			line.AddItem(createText("    ", ui.colors.foreground), 4, 0, false)
-		}
-
-		line.AddItem(createText(barText, barColor), 0, 1, false)
-
-		return line
-	}
+const (
+	VolumeFlashDuration = time.Second
+	volumeFlashFadeAt   = 600 * time.Millisecond
+	volumeFlashWidth    = 50
+	volumeFlashHeight   = 5
+	volumeFlashPage     = "volume-flash"
+)
 
-	container.AddItem(createText("   max", ui.colors.foreground), 1, 0, false)
+// Held volume keys speed up: after volumeRampAfter key repeats the step
+// doubles, and after twice that many it doubles again, so a sweep across
+// the range takes a moment while single presses stay fine-grained.
+const (
+	volumeRepeatGap = 200 * time.Millisecond // Presses closer than this are key repeats
+	volumeRampAfter = 5
+)
 
-	for i := 0; i < emptyLines; i++ {
-		container.AddItem(createBarLine(" ░░", ui.colors.foreground, false), 1, 0, false)
-	}
+// volumeRampState tracks a held volume key. Its saves need no batching
+// here: the config writer already writes once the key is let go.
+type volumeRampState struct {
+	last      time.Time
+	direction int
+	repeats   int
+}
 
-	barColor := ui.colors.highlight
-	if isMuted {
-		barColor = config.GetColor(ui.config.Theme.MutedVolume)
+// volumeRampStep returns the step for a volume key press in direction,
+// 1 for up and -1 for down, growing while the key is held.
+func (ui *UI) volumeRampStep(direction int) int {
+	now := ui.timeSource().Now()
+	r := &ui.volumeRamp
+	if direction == r.direction && now.Sub(r.last) < volumeRepeatGap {
+		r.repeats++
+	} else {
+		r.repeats = 0
 	}
-	for i := 0; i < filledLines; i++ {
-		showPercent := (i == 0)
-		container.AddItem(createBarLine(" ██", barColor, showPercent), 1, 0, false)
+	r.direction, r.last = direction, now
+
+	step := VolumeStep
+	switch {
+	case r.repeats >= 2*volumeRampAfter:
+		step *= 4
+	case r.repeats >= volumeRampAfter:
+		step *= 2
 	}
+	return direction * step
+}
 
-	container.AddItem(createText("   min", ui.colors.foreground), 1, 0, false)
-
-	container.AddItem(nil, 0, 1, false)
+// volumeKey handles a volume key press, or key repeat, in direction.
+func (ui *UI) volumeKey(direction int) {
+	ui.adjustVolume(ui.volumeRampStep(direction))
+	ui.flashVolume()
 }
 
-func (ui *UI) createGraphicalVolumeBar() *tview.Flex {
-	volumeContainer := tview.NewFlex().SetDirection(tview.FlexRow)
-	volumeContainer.SetBackgroundColor(ui.colors.background)
-	ui.buildVolumeBar(volumeContainer)
-	return volumeContainer
+// volumeFlashState tracks the on-screen volume overlay. gen invalidates the
+// timers of earlier flashes when the volume keeps changing.
+type volumeFlashState struct {
+	gen   int
+	faded bool
 }
 
+// updateVolumeDisplay tells the player panel and footer about a volume
+// change. While muted they show the volume that unmuting restores.
 func (ui *UI) updateVolumeDisplay() {
-	if ui.volumeView != nil {
-		ui.volumeView.Clear()
-		ui.buildVolumeBar(ui.volumeView)
+	ui.mu.Lock()
+	volume, muted := ui.currentVolume, ui.isMuted
+	if muted {
+		volume = ui.config.Volume
 	}
+	ui.mu.Unlock()
+
+	ui.bus.publish(volumeChanged{volume: volume, muted: muted})
 }
 
 func (ui *UI) adjustVolume(delta int) {
@@ -124,6 +107,20 @@ func (ui *UI) adjustVolume(delta int) {
 	log.Debug().Msgf("Volume adjusted to %d%%", ui.currentVolume)
 }
 
+// setVolume sets an absolute volume, unmuting first.
+func (ui *UI) setVolume(percent int) {
+	ui.mu.Lock()
+	ui.currentVolume = config.ClampVolume(percent)
+	ui.isMuted = false
+	ui.statusRenderer.SetMuted(false)
+	ui.mu.Unlock()
+
+	ui.player.SetVolume(ui.currentVolume)
+	ui.updateVolumeDisplay()
+	ui.SaveConfig()
+	log.Debug().Msgf("Volume set to %d%%", ui.currentVolume)
+}
+
 func (ui *UI) toggleMute() {
 	ui.mu.Lock()
 	if ui.isMuted {
@@ -147,3 +144,96 @@ func (ui *UI) toggleMute() {
 	ui.updateVolumeDisplay()
 	ui.SaveConfig()
 }
+
+// toggleNightMode switches the night mode compressor, which keeps loud
+// tracks and station changes from startling at a low volume.
+func (ui *UI) toggleNightMode() {
+	on := !ui.player.NightMode()
+	ui.player.SetNightMode(on)
+	ui.config.Audio.NightMode = on
+	ui.requestConfigSave()
+
+	if on {
+		ui.showNotice("Night mode on — loud passages are evened out")
+	} else {
+		ui.showNotice("Night mode off")
+	}
+	log.Debug().Bool("night_mode", on).Msg("Toggled night mode")
+}
+
+// volumeFlashBar renders a horizontal bar of width cells for volume.
+func volumeFlashBar(volume, width int) string {
+	filled := config.ClampVolume(volume) * width / 100
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+func (ui *UI) createVolumeFlash() tview.Primitive {
+	box := tview.NewBox().
+		SetBorder(true).
+		SetBackgroundColor(ui.colors.modalBackground)
+
+	box.SetDrawFunc(func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
+		ui.mu.Lock()
+		volume := ui.currentVolume
+		isMuted := ui.isMuted
+		if isMuted {
+			volume = ui.config.Volume
+		}
+		ui.mu.Unlock()
+
+		color := ui.colors.highlight
+		if isMuted {
+			color = ui.colors.mutedVolume
+		}
+		if ui.volumeFlash.faded {
+			color = ui.colors.borders
+		}
+		box.SetBorderColor(color)
+
+		label := fmt.Sprintf("Volume %d%%", volume)
+		if isMuted {
+			label = fmt.Sprintf("Muted (%d%%)", volume)
+		}
+
+		innerX, innerY, innerWidth, _ := box.GetInnerRect()
+		barWidth := innerWidth - 4
+		tview.Print(screen, label, innerX, innerY, innerWidth, tview.AlignCenter, color)
+		tview.Print(screen, volumeFlashBar(volume, barWidth), innerX+2, innerY+2, barWidth, tview.AlignLeft, color)
+
+		return x, y, width, height
+	})
+
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(box, volumeFlashHeight, 0, false).
+			AddItem(nil, 0, 1, false),
+			volumeFlashWidth, 0, false).
+		AddItem(nil, 0, 1, false)
+}
+
+// flashVolume briefly shows a large volume bar centered on screen. It dims
+// shortly before disappearing after VolumeFlashDuration.
+func (ui *UI) flashVolume() {
+	ui.volumeFlash.gen++
+	ui.volumeFlash.faded = false
+	gen := ui.volumeFlash.gen
+
+	ui.modals.overlay(volumeFlashPage, ui.createVolumeFlash())
+
+	ui.timeSource().AfterFunc(volumeFlashFadeAt, func() {
+		ui.app.QueueUpdateDraw(func() {
+			if ui.volumeFlash.gen == gen {
+				ui.volumeFlash.faded = true
+			}
+		})
+	})
+	ui.timeSource().AfterFunc(VolumeFlashDuration, func() {
+		ui.app.QueueUpdateDraw(func() {
+			if ui.volumeFlash.gen == gen {
+				ui.modals.removeOverlay(volumeFlashPage)
+			}
+		})
+	})
+}
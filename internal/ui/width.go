@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"os"
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// ellipsis marks text cut short by truncateWidth. Three dots rather than
+// "…", which is itself of ambiguous width.
+const ellipsis = "..."
+
+// truncateWidth shortens s to fit in width screen cells, ending it with
+// ellipsis when cut. It measures grapheme clusters the way tview draws
+// them, so CJK characters and emoji count as two cells and are never split.
+func truncateWidth(s string, width int) string {
+	if uniseg.StringWidth(s) <= width {
+		return s
+	}
+	if width <= len(ellipsis) {
+		return ellipsis[:max(width, 0)]
+	}
+
+	var b strings.Builder
+	room := width - len(ellipsis)
+	state := -1
+	for s != "" {
+		var cluster string
+		var w int
+		cluster, s, w, state = uniseg.FirstGraphemeClusterInString(s, state)
+		if w > room {
+			break
+		}
+		b.WriteString(cluster)
+		room -= w
+	}
+	return strings.TrimRight(b.String(), " ") + ellipsis
+}
+
+// applyAmbiguousWidth sets how many cells East Asian ambiguous-width
+// characters take, for both measuring and drawing. 0 keeps what the
+// RUNEWIDTH_EASTASIAN variable asks for.
+func applyAmbiguousWidth(width int) {
+	switch width {
+	case 1, 2:
+		uniseg.EastAsianAmbiguousWidth = width
+	default:
+		uniseg.EastAsianAmbiguousWidth = 1
+		switch strings.ToLower(os.Getenv("RUNEWIDTH_EASTASIAN")) {
+		case "1", "true", "yes":
+			uniseg.EastAsianAmbiguousWidth = 2
+		}
+	}
+}